@@ -0,0 +1,420 @@
+// Code generated from backend/pkg/api by `make types`. DO NOT EDIT.
+
+export interface DashboardResponse {
+  overall_status: string;
+  workloads: WorkloadStatus[];
+  last_updated: string;
+  generation?: number;
+  data_source?: string;
+  data_as_of?: string | null;
+}
+
+export interface WorkloadStatus {
+  name: string;
+  namespace: string;
+  attested: boolean;
+  attestation_status: string;
+  unknown_reason?: string;
+  status_label?: string;
+  timestamp: string;
+  timestamp_local?: string;
+  details: string;
+  gate_one_status: string;
+  gate_two_status: string;
+  last_checked: string;
+  last_checked_local?: string;
+  tee_type?: string;
+  tcb_version?: string;
+  trust_vector?: TrustVector | null;
+  node_name?: string;
+  cluster?: string;
+  maintenance?: string;
+  gates?: GateResult[];
+  raw_report_id?: string;
+  source?: string;
+  secondary_verdict?: string;
+  pod_started_at?: string | null;
+  first_attested_at?: string | null;
+  time_to_attest_seconds?: number;
+  restart_count?: number;
+  last_restart?: string | null;
+  host_status?: string;
+  image_digests?: string[];
+  failed_checks?: Check[];
+  flapping?: boolean;
+  flap_count?: number;
+  lifecycle?: string;
+  last_seen?: string | null;
+  owner?: Owner | null;
+  pinned?: boolean;
+  first_seen?: string | null;
+  total_violations?: number;
+  last_violation_at?: string | null;
+  acknowledgement?: Acknowledgement | null;
+  annotations?: Annotations | null;
+}
+
+export interface GateResult {
+  name: string;
+  status: string;
+  details?: string;
+}
+
+export interface Check {
+  name: string;
+  expected: string;
+  actual: string;
+  severity: string;
+  remediation?: string;
+  runbook_url?: string;
+}
+
+export interface AckRequest {
+  comment?: string;
+  duration?: string;
+}
+
+export interface Acknowledgement {
+  key: string;
+  by: string;
+  comment?: string;
+  created_at: string;
+  expires_at: string;
+  silence?: boolean;
+}
+
+export interface BatchAckRequest {
+  selector: WorkloadSelector;
+  comment?: string;
+  duration?: string;
+}
+
+export interface BatchAckResult {
+  acknowledged: Acknowledgement[];
+  skipped?: string[];
+}
+
+export interface AnnotationsRequest {
+  notes?: string;
+  labels?: Record<string, string>;
+}
+
+export interface ExpectedWorkload {
+  key: string;
+  namespace: string;
+  name: string;
+  cluster?: string;
+  comment?: string;
+  registered_by: string;
+  registered_at: string;
+}
+
+export interface WatchedWorkload {
+  key: string;
+  namespace: string;
+  name: string;
+  reason?: string;
+  pinned_by: string;
+  pinned_at: string;
+  until?: string | null;
+}
+
+export interface SuppressedWorkload {
+  key: string;
+  namespace: string;
+  name: string;
+  reason: string;
+  suppressed_by: string;
+  suppressed_at: string;
+}
+
+export interface ShareLink {
+  id: string;
+  key: string;
+  url?: string;
+  shared_by: string;
+  created_at: string;
+  expires_at: string;
+}
+
+export interface SharedWorkload {
+  workload: WorkloadStatus;
+  history: HistoryEvent[];
+  shared_by: string;
+  expires_at: string;
+}
+
+export interface DowntimeWindow {
+  id: string;
+  start: string;
+  end: string;
+  namespaces?: string[];
+  reason: string;
+  created_by: string;
+  created_at: string;
+}
+
+export interface SearchResult {
+  kind: string;
+  key: string;
+  score: number;
+  matched: string[];
+  workload?: WorkloadStatus | null;
+  event?: HistoryEvent | null;
+}
+
+export interface FleetDiff {
+  from: string;
+  to: string;
+  added: WorkloadStatus[];
+  removed: WorkloadStatus[];
+  changed: WorkloadChange[];
+}
+
+export interface HistoryEvent {
+  time: string;
+  key: string;
+  type: string;
+  previous_status?: string;
+  status?: WorkloadStatus | null;
+  imported?: boolean;
+}
+
+export interface WebhookPayload {
+  event: string;
+  time: string;
+  key: string;
+  previous_status?: string;
+  workload?: WorkloadStatus | null;
+  flap_count?: number;
+  summary?: string;
+}
+
+export interface NodeSummary {
+  name: string;
+  status: string;
+  workloads: number;
+  attested: number;
+  failed: number;
+  tee_types: string[];
+  failed_workloads: string[];
+  host_attestation?: NodeReport | null;
+}
+
+export interface NodeReport {
+  node_name: string;
+  cluster?: string;
+  tee_type?: string;
+  tcb_version?: string;
+  attested: boolean;
+  timestamp: string;
+  error?: string;
+}
+
+export interface ClusterSummary {
+  name: string;
+  status: string;
+  workloads: number;
+  attested: number;
+  failed: number;
+  unknown: number;
+  last_sync?: string | null;
+  last_error?: string;
+}
+
+export interface FederationResponse {
+  overall_status: string;
+  sites: SiteSummary[];
+  last_updated: string;
+}
+
+export interface StateSnapshot {
+  taken_at: string;
+  taken_by?: string;
+  site?: string;
+  version: string;
+  commit: string;
+  config_hash?: string;
+  generation: number;
+  overall_status: string;
+  data_source: string;
+  data_as_of?: string | null;
+  clusters: ClusterSummary[];
+  workloads: WorkloadStatus[];
+}
+
+export interface SignedSnapshot {
+  payload: string;
+  protected: string;
+  signature: string;
+}
+
+export interface FeaturesResponse {
+  flags: FeatureFlag[];
+  deprecations: Deprecation[];
+}
+
+export interface Explanation {
+  key: string;
+  verdict: string;
+  attestation_status: string;
+  cause?: string;
+  summary: string;
+  ar4si_profile?: string;
+  policy_version?: number;
+  evaluated_at: string;
+  rules: ExplainedRule[];
+  notes?: string[];
+}
+
+export interface WallboardResponse {
+  overall_status: string;
+  color: string;
+  issues: WallboardIssue[];
+  counts: WallboardCounts;
+  data_source: string;
+  data_as_of?: string | null;
+  stale: boolean;
+  last_updated: string;
+}
+
+export interface TEEInventory {
+  tee_type: string;
+  workloads: number;
+  attested: number;
+  nodes: number;
+  clusters: string[];
+  tcb_versions: TCBVersionCount[];
+}
+
+export interface TrustTrend {
+  key: string;
+  dimensions: Record<string, TrustSample[]>;
+}
+
+export interface TrustTier {
+  name: string;
+  value: number;
+  label: string;
+  color: string;
+}
+
+export interface Session {
+  authenticated: boolean;
+  name?: string;
+  roles?: string[];
+  namespaces?: string[];
+  permissions?: string[];
+  expires_at?: string | null;
+}
+
+export interface TrustVector {
+  instance_identity: number;
+  configuration: number;
+  executables: number;
+  file_system: number;
+  hardware: number;
+  runtime_opaque: number;
+  storage_opaque: number;
+  sourced_data: number;
+}
+
+export interface Owner {
+  team: string;
+  contact?: string;
+}
+
+export interface Annotations {
+  notes?: string;
+  labels?: Record<string, string>;
+  updated_by: string;
+  updated_at: string;
+}
+
+export interface WorkloadSelector {
+  namespaces?: string[];
+  labels?: Record<string, string>;
+  statuses?: string[];
+}
+
+export interface WorkloadChange {
+  key: string;
+  fields: string[];
+  before: WorkloadStatus;
+  after: WorkloadStatus;
+}
+
+export interface SiteSummary {
+  name: string;
+  url: string;
+  status: string;
+  workloads: number;
+  attested: number;
+  failed: number;
+  unknown: number;
+  data_source?: string;
+  data_as_of?: string | null;
+  last_poll?: string | null;
+  last_error?: string;
+}
+
+export interface FeatureFlag {
+  name: string;
+  description: string;
+  enabled: boolean;
+  default: boolean;
+  endpoints?: string[];
+}
+
+export interface Deprecation {
+  endpoint: string;
+  deprecated: string;
+  sunset?: string | null;
+  successor?: string;
+}
+
+export interface ExplainedRule {
+  rule: string;
+  description: string;
+  outcome: string;
+  decisive?: boolean;
+  evidence?: EvidenceField[];
+  expected?: string;
+  actual?: string;
+  severity?: string;
+}
+
+export interface WallboardIssue {
+  workload: string;
+  cluster?: string;
+  severity: string;
+  text: string;
+  acknowledged?: boolean;
+}
+
+export interface WallboardCounts {
+  workloads: number;
+  issues: number;
+  violations: number;
+  unknown: number;
+  acknowledged: number;
+}
+
+export interface TCBVersionCount {
+  version: string;
+  workloads: number;
+  nodes: number;
+}
+
+export interface TrustSample {
+  time: string;
+  value: number;
+  tier: string;
+  color?: string;
+}
+
+export interface EvidenceField {
+  field: string;
+  value: string;
+  source: string;
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const accessBucket = "access"
+
+// maxRecentAccess bounds the access entries kept in memory when there is no
+// store to persist them to
+const maxRecentAccess = 10000
+
+// AccessEntry records one authenticated API request, so it can be shown who
+// viewed the security posture of which workloads
+type AccessEntry struct {
+	Time      time.Time `json:"time"`
+	Identity  string    `json:"identity"`
+	SourceIP  string    `json:"source_ip,omitempty"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	Query     string    `json:"query,omitempty"`
+	Status    int       `json:"status"`
+	Workloads []string  `json:"workloads,omitempty"`  // namespace/name of every workload returned
+	LocalTime string    `json:"local_time,omitempty"` // time in the display time zone, set in exports only
+}
+
+// AccessLog records authenticated API access. With a store, entries are
+// appended to it and compacted to the retention period on startup; without
+// one only the most recent entries are kept in memory.
+type AccessLog struct {
+	mu     sync.Mutex
+	recent []AccessEntry
+	store  *Store
+}
+
+// newAccessLog drops persisted entries older than retention
+func newAccessLog(store *Store, retention time.Duration) (*AccessLog, error) {
+	a := &AccessLog{store: store}
+	if store == nil || retention <= 0 {
+		return a, nil
+	}
+
+	cutoff := time.Now().Add(-retention)
+	var kept []interface{}
+	dropped := false
+	err := store.Load(accessBucket, func(raw json.RawMessage) error {
+		var entry AccessEntry
+		if err := json.Unmarshal(raw, &entry); err != nil {
+			return err
+		}
+		if entry.Time.Before(cutoff) {
+			dropped = true
+			return nil
+		}
+		kept = append(kept, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if dropped {
+		if err := store.Rewrite(accessBucket, kept); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// Record appends an entry to the access log
+func (a *AccessLog) Record(entry AccessEntry) {
+	if a == nil {
+		return
+	}
+
+	if a.store != nil {
+		if err := a.store.Append(accessBucket, entry); err != nil {
+			log.Printf("Failed to persist access entry: %v", err)
+		}
+		return
+	}
+
+	a.mu.Lock()
+	a.recent = append(a.recent, entry)
+	if len(a.recent) > maxRecentAccess {
+		a.recent = append([]AccessEntry(nil), a.recent[len(a.recent)-maxRecentAccess:]...)
+	}
+	a.mu.Unlock()
+}
+
+// Entries returns access entries recorded at or after since, optionally only
+// those of one identity
+func (a *AccessLog) Entries(ctx context.Context, since time.Time, identity string) ([]AccessEntry, error) {
+	entries := []AccessEntry{}
+	if a == nil {
+		return entries, nil
+	}
+
+	matches := func(entry AccessEntry) bool {
+		return !entry.Time.Before(since) && (identity == "" || entry.Identity == identity)
+	}
+
+	if a.store != nil {
+		err := a.store.LoadContext(ctx, accessBucket, func(raw json.RawMessage) error {
+			var entry AccessEntry
+			if err := json.Unmarshal(raw, &entry); err != nil {
+				return err
+			}
+			if matches(entry) {
+				entries = append(entries, entry)
+			}
+			return nil
+		})
+		return entries, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, entry := range a.recent {
+		if matches(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// accessRecord collects what a handler returned for the request's access entry
+type accessRecord struct {
+	mu        sync.Mutex
+	workloads []string
+}
+
+type accessContextKey struct{}
+
+// noteWorkloads records that the workloads are being returned to the caller.
+// The access log can only show who viewed a workload if every handler
+// returning a workload's status, verdict or evidence records it here:
+// TestAPIRoutesNoteWorkloads fails for a route that returns workloads
+// without doing so.
+func noteWorkloads(r *http.Request, workloads []WorkloadStatus) {
+	keys := make([]string, len(workloads))
+	for i := range workloads {
+		keys[i] = workloads[i].Namespace + "/" + workloads[i].Name
+	}
+	noteWorkloadKeys(r, keys...)
+}
+
+// noteWorkloadKeys records workloads returned by their namespace/name, for
+// handlers returning something other than their statuses
+func noteWorkloadKeys(r *http.Request, keys ...string) {
+	record, _ := r.Context().Value(accessContextKey{}).(*accessRecord)
+	if record == nil {
+		return
+	}
+	record.mu.Lock()
+	defer record.mu.Unlock()
+	record.workloads = append(record.workloads, keys...)
+}
+
+// noteReports records the workloads of Collector reports returned as
+// received. Reports that don't identify a pod name no workload.
+func noteReports(r *http.Request, raws ...json.RawMessage) {
+	for _, raw := range raws {
+		if report, err := decodeCollectorReport(raw); err == nil && report.Namespace != "" && report.PodName != "" {
+			noteWorkloadKeys(r, report.Namespace+"/"+report.PodName)
+		}
+	}
+}
+
+// accessStatusWriter captures the status code a handler responds with
+type accessStatusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *accessStatusWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *accessStatusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *accessStatusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// accessLogMiddleware records every API request made by an authenticated
+// identity. Anonymous requests - all of them when auth is disabled - and
+// event streams, which authenticate with subscription tokens, are not logged.
+func (s *Server) accessLogMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity := identityFromContext(r.Context())
+		if s.access == nil || identity == nil || !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		record := &accessRecord{}
+		recorder := &accessStatusWriter{ResponseWriter: w}
+		start := time.Now()
+		next.ServeHTTP(recorder, r.WithContext(context.WithValue(r.Context(), accessContextKey{}, record)))
+
+		status := recorder.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		sort.Strings(record.workloads)
+		s.access.Record(AccessEntry{
+			Time:      start,
+			Identity:  identity.Name,
+			SourceIP:  requestIP(r),
+			Method:    r.Method,
+			Path:      r.URL.Path,
+			Query:     r.URL.RawQuery,
+			Status:    status,
+			Workloads: record.workloads,
+		})
+	})
+}
+
+// handleAccessLog exports the API access log. Restricted to admins, as it
+// shows what every user has looked at.
+// GET /api/audit/access?since=2024-05-01T00:00:00Z&identity=raj
+func (s *Server) handleAccessLog(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if _, ok := requireAdmin(w, r); !ok {
+		return
+	}
+	zone, ok := s.requestZone(w, r)
+	if !ok {
+		return
+	}
+
+	var since time.Time
+	if raw := r.URL.Query().Get("since"); raw != "" {
+		var err error
+		if since, err = time.Parse(time.RFC3339, raw); err != nil {
+			http.Error(w, "invalid since parameter, expected RFC3339", http.StatusBadRequest)
+			return
+		}
+	}
+
+	entries, err := s.access.Entries(r.Context(), since, r.URL.Query().Get("identity"))
+	if err != nil {
+		if r.Context().Err() != nil {
+			return // the client went away or the request timed out
+		}
+		log.Printf("Failed to read access log: %v", err)
+		http.Error(w, "failed to read access log", http.StatusInternalServerError)
+		return
+	}
+	for i := range entries {
+		entries[i].Time = entries[i].Time.UTC()
+		entries[i].LocalTime = zone.format(entries[i].Time)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
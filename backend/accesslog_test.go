@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+)
+
+// TestAccessLogMiddleware tests that authenticated requests are logged with
+// the workloads they returned, and anonymous ones are not
+func TestAccessLogMiddleware(t *testing.T) {
+	store, err := openStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	access, _ := newAccessLog(store, time.Hour)
+	server := &Server{
+		access: access,
+		statusCache: map[string]*WorkloadStatus{
+			"icu/ai-model":    failedStatus("icu", "ai-model"),
+			"radiology/pacs":  verifiedStatus("radiology", "pacs"),
+			"radiology/other": verifiedStatus("radiology", "other"),
+		},
+	}
+	handler := server.accessLogMiddleware(http.HandlerFunc(server.handleWorkloadDetail))
+
+	raj := &Identity{Name: "raj"}
+	handler.ServeHTTP(httptest.NewRecorder(), ackRequestAs(raj, "GET", "/api/workload/icu/ai-model", ""))
+	handler.ServeHTTP(httptest.NewRecorder(), ackRequestAs(raj, "GET", "/api/workload/icu/missing", ""))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/workload/radiology/pacs", nil))
+
+	entries, err := access.Entries(context.Background(), time.Time{}, "")
+	if err != nil {
+		t.Fatalf("Failed to read access log: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 logged requests, got %+v", entries)
+	}
+	if e := entries[0]; e.Identity != "raj" || e.Path != "/api/workload/icu/ai-model" || e.Status != http.StatusOK || len(e.Workloads) != 1 || e.Workloads[0] != "icu/ai-model" {
+		t.Errorf("Unexpected entry %+v", e)
+	}
+	if e := entries[1]; e.Status != http.StatusNotFound || len(e.Workloads) != 0 {
+		t.Errorf("Expected 404 without workloads, got %+v", e)
+	}
+
+	server.accessLogMiddleware(http.HandlerFunc(server.handleWorkloads)).ServeHTTP(httptest.NewRecorder(), ackRequestAs(&Identity{Name: "sam"}, "GET", "/api/workloads?cluster=", ""))
+	entries, _ = access.Entries(context.Background(), time.Time{}, "sam")
+	if len(entries) != 1 || len(entries[0].Workloads) != 3 || entries[0].Workloads[0] != "icu/ai-model" {
+		t.Errorf("Expected all 3 workloads logged for sam, got %+v", entries)
+	}
+}
+
+// TestAccessLogRetention tests that entries older than the retention period
+// are dropped when the log is reopened
+func TestAccessLogRetention(t *testing.T) {
+	store, err := openStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	access, _ := newAccessLog(store, 0)
+	access.Record(AccessEntry{Time: time.Now().Add(-48 * time.Hour), Identity: "old"})
+	access.Record(AccessEntry{Time: time.Now(), Identity: "new"})
+
+	access, err = newAccessLog(store, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to reopen access log: %v", err)
+	}
+	entries, _ := access.Entries(context.Background(), time.Time{}, "")
+	if len(entries) != 1 || entries[0].Identity != "new" {
+		t.Errorf("Expected only the recent entry, got %+v", entries)
+	}
+}
+
+// TestHandleAccessLog tests that the access log export requires the admin role
+func TestHandleAccessLog(t *testing.T) {
+	access, _ := newAccessLog(nil, 0)
+	access.Record(AccessEntry{Time: time.Now(), Identity: "raj", Method: "GET", Path: "/api/status", Status: http.StatusOK})
+	server := &Server{access: access}
+
+	w := httptest.NewRecorder()
+	server.handleAccessLog(w, ackRequestAs(&Identity{Name: "raj"}, "GET", "/api/audit/access", ""))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 without admin role, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	server.handleAccessLog(w, ackRequestAs(&Identity{Name: "sre", Roles: []string{adminRole}}, "GET", "/api/audit/access?identity=raj", ""))
+	var entries []AccessEntry
+	if err := json.NewDecoder(w.Body).Decode(&entries); err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Path != "/api/status" {
+		t.Errorf("Expected raj's access, got %+v", entries)
+	}
+}
+
+// TestAPIRoutesNoteWorkloads tests that every route returning a workload's
+// status, verdict or evidence records the workload in the access log. A new
+// route must either be checked here or exempted with the reason it returns
+// no workload.
+func TestAPIRoutesNoteWorkloads(t *testing.T) {
+	store, err := openStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	now := time.Now()
+	access, _ := newAccessLog(nil, time.Hour)
+	audit, _ := newAuditLog(nil)
+	acks, _ := newAckStore(nil, time.Hour, 4*time.Hour)
+	history, _ := newHistory(nil, 90*24*time.Hour)
+	history.Record([]HistoryEvent{{Time: now.Add(-time.Hour), Key: "icu/pacs", Type: "added", Status: failedStatus("icu", "pacs")}})
+	policies, _ := newPolicyStore(store, Policy{})
+	shadow, _ := policies.Create(Policy{}, "", "sre")
+	policies.SetShadow(&shadow.Version)
+	policies.recordShadow(&shadowReport{Version: shadow.Version, policyEvaluation: policyEvaluation{Workloads: []policyOutcome{{Key: "icu/pacs"}}}})
+	shares, _ := newShareStore(store, "secret", time.Hour)
+	_, shareToken := shares.Create("icu/pacs", "raj", time.Hour, now)
+
+	raw := json.RawMessage(`{"pod_name":"pacs","namespace":"icu","tee_type":"SNP","attested":false,"nonce":"x"}`)
+	rawID := rawReportID(raw)
+	store.SaveBlob(rawReportBucket, rawID, raw)
+	_, private, _ := ed25519.GenerateKey(rand.Reader)
+	signer, _ := newResponseSigner(private)
+	status := failedStatus("icu", "pacs")
+	status.NodeName = "worker-1"
+	server := &Server{
+		access:      access,
+		audit:       audit,
+		acks:        acks,
+		history:     history,
+		policies:    policies,
+		shares:      shares,
+		rawArchive:  store,
+		anomalies:   newAnomalyTracker(),
+		metrics:     newMetrics(),
+		signer:      signer,
+		generation:  1,
+		statusCache: map[string]*WorkloadStatus{"icu/pacs": status},
+		reports:     map[string]CollectorReport{"icu/pacs": {PodName: "pacs", Namespace: "icu", TEEType: "SNP", Timestamp: now}},
+	}
+	server.inspectReport("local", raw)
+
+	type request struct{ method, target, body string }
+	at := url.QueryEscape(now.Format(time.RFC3339))
+	returning := map[string][]request{
+		"/api/status":                 {{"GET", "/api/status", ""}},
+		"/api/status/at":              {{"GET", "/api/status/at?time=" + at, ""}},
+		"/api/status/wait":            {{"GET", "/api/status/wait?since=0", ""}},
+		"/api/diff":                   {{"GET", "/api/diff?from=" + url.QueryEscape(now.Add(-2*time.Hour).Format(time.RFC3339)) + "&to=" + at, ""}},
+		"/api/workloads":              {{"GET", "/api/workloads", ""}},
+		"/api/workload/":              {{"GET", "/api/workload/icu/pacs", ""}, {"GET", "/api/workload/icu/pacs/explanation", ""}, {"GET", "/api/workload/icu/pacs/trust-trend", ""}},
+		"/api/shared/":                {{"GET", "/api/shared/" + shareToken, ""}},
+		"/api/search":                 {{"GET", "/api/search?q=pacs", ""}},
+		"/api/nodes":                  {{"GET", "/api/nodes", ""}},
+		"/api/wallboard":              {{"GET", "/api/wallboard", ""}},
+		"/api/reports/mttr":           {{"GET", "/api/reports/mttr", ""}},
+		"/api/reports/raw/":           {{"GET", "/api/reports/raw/" + rawID, ""}},
+		"/api/export/openmetrics":     {{"GET", "/api/export/openmetrics", ""}},
+		"/api/export/snapshot":        {{"GET", "/api/export/snapshot", ""}},
+		"/api/policy/evaluate":        {{"POST", "/api/policy/evaluate", `{"image_policies":[]}`}},
+		"/api/policies":               {{"GET", "/api/policies", ""}},
+		"/api/admin/backup":           {{"POST", "/api/admin/backup", ""}},
+		"/api/admin/ingest-anomalies": {{"GET", "/api/admin/ingest-anomalies", ""}},
+	}
+	exempt := map[string]string{
+		"/api/workloads/ack-batch":     "returns the acknowledgements made",
+		"/api/workloads/silence-batch": "returns the acknowledgements made",
+		"/api/expected-workloads":      "configuration",
+		"/api/expected-workloads/":     "configuration",
+		"/api/watchlist":               "configuration",
+		"/api/watchlist/":              "configuration",
+		"/api/suppressions":            "configuration",
+		"/api/downtime":                "configuration",
+		"/api/downtime/":               "configuration",
+		"/api/maintenance":             "configuration",
+		"/api/trust-tiers":             "configuration",
+		"/api/admin/collectors":        "configuration",
+		"/api/admin/collectors/":       "configuration",
+		"/api/policies/":               "configuration",
+		"/api/tee-inventory":           "counts",
+		"/api/clusters":                "counts",
+		"/api/federation":              "counts of other sites",
+		"/api/reports/heatmap":         "counts",
+		"/api/pipeline/health":         "figures of the Collector pipeline",
+		"/api/admin/import":            "counts",
+		"/api/admin/restore":           "writes only",
+		"/api/admin/refresh":           "writes only",
+		"/api/ingest/":                 "writes only",
+		"/api/audit":                   "the audit trail: actions, not posture",
+		"/api/audit/access":            "the access log itself",
+		"/api/events":                  "streams authenticate with subscription tokens and are not access logged",
+		"/api/ws":                      "streams authenticate with subscription tokens and are not access logged",
+		"/api/simulate/report":         "evaluates the caller's own report",
+		"/api/admin/benchmark":         "evaluates a synthetic fleet",
+		"/api/admin/selftest":          "checks the dashboard's dependencies",
+		"/api/admin/runtime":           "process internals",
+		"/api/stream-token":            "authentication",
+		"/api/session":                 "authentication",
+		"/api/auth/saml/metadata":      "authentication",
+		"/api/auth/saml/login":         "authentication",
+		"/api/auth/saml/acs":           "authentication",
+		"/api/auth/saml/logout":        "authentication",
+		"/api/schema":                  "metadata",
+		"/api/schemas/":                "metadata",
+		"/api/version":                 "metadata",
+		"/api/features":                "metadata",
+		"/api/signing-keys":            "metadata",
+	}
+
+	routes := server.apiRoutes()
+	for pattern, handler := range routes {
+		requests, ok := returning[pattern]
+		if !ok {
+			if exempt[pattern] == "" {
+				t.Errorf("%s: check that the route notes the workloads it returns, or exempt it", pattern)
+			}
+			continue
+		}
+		for _, req := range requests {
+			identity := &Identity{Name: req.method + " " + req.target, Roles: []string{adminRole}}
+			w := httptest.NewRecorder()
+			server.accessLogMiddleware(handler).ServeHTTP(w, ackRequestAs(identity, req.method, req.target, req.body))
+			if w.Code != http.StatusOK {
+				t.Errorf("%s: expected 200, got %d: %s", identity.Name, w.Code, w.Body.String())
+				continue
+			}
+			entries, _ := access.Entries(context.Background(), time.Time{}, identity.Name)
+			if len(entries) != 1 || !slices.Contains(entries[0].Workloads, "icu/pacs") {
+				t.Errorf("%s: expected icu/pacs in the access log, got %+v", identity.Name, entries)
+			}
+		}
+	}
+	for pattern := range returning {
+		if routes[pattern] == nil {
+			t.Errorf("%s: checked but not routed", pattern)
+		}
+	}
+	for pattern := range exempt {
+		if routes[pattern] == nil {
+			t.Errorf("%s: exempted but not routed", pattern)
+		}
+	}
+}
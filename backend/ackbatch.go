@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// matchesSelector reports whether a decorated workload matches every field
+// set in the selector
+func matchesSelector(sel WorkloadSelector, status *WorkloadStatus) bool {
+	if len(sel.Namespaces) > 0 && !containsString(sel.Namespaces, status.Namespace) {
+		return false
+	}
+	if len(sel.Statuses) > 0 && !containsString(sel.Statuses, status.AttestationStatus) {
+		return false
+	}
+	for key, value := range sel.Labels {
+		if status.Annotations == nil {
+			return false
+		}
+		actual, ok := status.Annotations.Labels[key]
+		if !ok || value != "" && actual != value {
+			return false
+		}
+	}
+	return true
+}
+
+// handleBatchAck acknowledges, or silences, every workload matching a
+// selector, e.g. all of a namespace during a known cluster-wide outage.
+// ack-batch skips matched workloads that aren't in violation; silence-batch
+// covers them too, in case they start failing during the outage.
+// POST /api/workloads/ack-batch
+// POST /api/workloads/silence-batch
+func (s *Server) handleBatchAck(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	identity := identityFromContext(r.Context())
+	if identity == nil {
+		http.Error(w, "acknowledgements require an authenticated identity", http.StatusUnauthorized)
+		return
+	}
+	if s.acks == nil {
+		http.Error(w, "acknowledgements are not enabled", http.StatusServiceUnavailable)
+		return
+	}
+	silence := strings.HasSuffix(r.URL.Path, "/silence-batch")
+
+	var req BatchAckRequest
+	if !decodeValid(w, r, batchAckSchema, &req) {
+		return
+	}
+	sel := req.Selector
+	if len(sel.Namespaces) == 0 && len(sel.Labels) == 0 && len(sel.Statuses) == 0 {
+		http.Error(w, "selector must set namespaces, labels or statuses", http.StatusBadRequest)
+		return
+	}
+	ttl, err := s.acks.ttl(req.Duration)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var matched, skipped []string
+	s.cacheMutex.RLock()
+	for key, status := range s.statusCache {
+		workload := s.decorate(*status)
+		if !matchesSelector(sel, &workload) {
+			continue
+		}
+		if !silence && !isViolation(status) {
+			skipped = append(skipped, key)
+			continue
+		}
+		matched = append(matched, key)
+	}
+	s.cacheMutex.RUnlock()
+	sort.Strings(matched)
+	sort.Strings(skipped)
+
+	action := "ack.create"
+	if silence {
+		action = "silence.create"
+	}
+	now := time.Now()
+	result := BatchAckResult{Acknowledged: make([]Acknowledgement, 0, len(matched)), Skipped: skipped}
+	for _, key := range matched {
+		ack := Acknowledgement{
+			Key:       key,
+			By:        identity.Name,
+			Comment:   req.Comment,
+			CreatedAt: now,
+			ExpiresAt: now.Add(ttl),
+			Silence:   silence,
+		}
+		result.Acknowledged = append(result.Acknowledged, ack)
+		s.audit.RecordRequest(r, identity.Name, action, key, fmt.Sprintf("batch, expires %s: %s", ack.ExpiresAt.Format(time.RFC3339), ack.Comment))
+	}
+	s.acks.SetAll(result.Acknowledged)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestHandleBatchAck tests acknowledging and silencing workloads by selector
+func TestHandleBatchAck(t *testing.T) {
+	server := newAckTestServer(t)
+	server.statusCache["lab/lims"] = failedStatus("lab", "lims")
+	server.statusCache["icu/pacs"] = failedStatus("icu", "pacs")
+	annotations, _ := newAnnotationStore(nil)
+	annotations.Set("icu/pacs", &Annotations{Labels: map[string]string{"site": "north"}})
+	server.annotations = annotations
+	raj := &Identity{Name: "raj"}
+
+	for _, body := range []string{`{}`, `{"selector": {}}`, `{"selector": {"namespaces": ["icu"]}, "duration": "48h"}`} {
+		w := httptest.NewRecorder()
+		server.handleBatchAck(w, ackRequestAs(raj, "POST", "/api/workloads/ack-batch", body))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d", body, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	server.handleBatchAck(w, ackRequestAs(raj, "POST", "/api/workloads/ack-batch", `{"selector": {"namespaces": ["icu"]}, "comment": "cluster outage"}`))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var result BatchAckResult
+	json.NewDecoder(w.Body).Decode(&result)
+	if len(result.Acknowledged) != 2 || result.Acknowledged[0].Key != "icu/broken" || result.Acknowledged[1].Key != "icu/pacs" {
+		t.Errorf("Expected icu/broken and icu/pacs acknowledged, got %+v", result.Acknowledged)
+	}
+	if len(result.Skipped) != 1 || result.Skipped[0] != "icu/healthy" {
+		t.Errorf("Expected healthy icu/healthy skipped, got %v", result.Skipped)
+	}
+	if server.acks.Get("lab/lims") != nil || server.acks.Get("icu/pacs").By != "raj" {
+		t.Error("Expected only icu workloads to be acknowledged")
+	}
+
+	// Silences cover healthy workloads and combine selector fields
+	w = httptest.NewRecorder()
+	server.handleBatchAck(w, ackRequestAs(raj, "POST", "/api/workloads/silence-batch", `{"selector": {"labels": {"site": ""}, "statuses": ["failed"]}}`))
+	json.NewDecoder(w.Body).Decode(&result)
+	if len(result.Acknowledged) != 1 || !result.Acknowledged[0].Silence || result.Acknowledged[0].Key != "icu/pacs" {
+		t.Errorf("Expected a silence for icu/pacs, got %+v", result.Acknowledged)
+	}
+	w = httptest.NewRecorder()
+	server.handleBatchAck(w, ackRequestAs(raj, "POST", "/api/workloads/silence-batch", `{"selector": {"namespaces": ["icu"], "statuses": ["verified"]}}`))
+	json.NewDecoder(w.Body).Decode(&result)
+	if len(result.Acknowledged) != 1 || result.Acknowledged[0].Key != "icu/healthy" {
+		t.Errorf("Expected a silence for healthy icu/healthy, got %+v", result.Acknowledged)
+	}
+
+	// Silenced workloads don't notify even when healthy, and their silence
+	// survives the recovery
+	events := server.unacknowledged([]HistoryEvent{
+		{Time: time.Now(), Key: "icu/healthy", Type: "changed", Status: verifiedStatus("icu", "healthy")},
+		{Time: time.Now(), Key: "lab/lims", Type: "changed", Status: failedStatus("lab", "lims")},
+	})
+	if len(events) != 1 || events[0].Key != "lab/lims" {
+		t.Errorf("Expected only lab/lims to notify, got %+v", events)
+	}
+	server.processAcks()
+	if server.acks.Get("icu/healthy") == nil {
+		t.Error("Expected the silence to outlive a healthy workload")
+	}
+}
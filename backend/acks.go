@@ -0,0 +1,272 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const acksDoc = "acks"
+
+// AckStore holds active acknowledgements, persisted to the store
+type AckStore struct {
+	mu         sync.Mutex
+	acks       map[string]*Acknowledgement
+	store      *Store
+	defaultTTL time.Duration
+	maxTTL     time.Duration
+}
+
+// newAckStore loads persisted acknowledgements
+func newAckStore(store *Store, defaultTTL, maxTTL time.Duration) (*AckStore, error) {
+	a := &AckStore{
+		acks:       make(map[string]*Acknowledgement),
+		store:      store,
+		defaultTTL: defaultTTL,
+		maxTTL:     maxTTL,
+	}
+	if _, err := store.LoadDoc(acksDoc, &a.acks); err != nil {
+		return nil, fmt.Errorf("failed to load acknowledgements: %w", err)
+	}
+	return a, nil
+}
+
+// Get returns the acknowledgement for a workload, or nil
+func (a *AckStore) Get(key string) *Acknowledgement {
+	if a == nil {
+		return nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if ack, ok := a.acks[key]; ok {
+		c := *ack
+		return &c
+	}
+	return nil
+}
+
+// Set stores an acknowledgement, replacing any existing one for the workload
+func (a *AckStore) Set(ack Acknowledgement) {
+	a.SetAll([]Acknowledgement{ack})
+}
+
+// SetAll stores acknowledgements with a single write to the store
+func (a *AckStore) SetAll(acks []Acknowledgement) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for i := range acks {
+		ack := acks[i]
+		a.acks[ack.Key] = &ack
+	}
+	a.persistLocked()
+}
+
+// Remove deletes and returns the acknowledgement for a workload
+func (a *AckStore) Remove(key string) *Acknowledgement {
+	if a == nil {
+		return nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	ack, ok := a.acks[key]
+	if !ok {
+		return nil
+	}
+	delete(a.acks, key)
+	a.persistLocked()
+	return ack
+}
+
+// Expire removes and returns all acknowledgements that expired before now
+func (a *AckStore) Expire(now time.Time) []Acknowledgement {
+	if a == nil {
+		return nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var expired []Acknowledgement
+	for key, ack := range a.acks {
+		if !ack.ExpiresAt.After(now) {
+			expired = append(expired, *ack)
+			delete(a.acks, key)
+		}
+	}
+	if len(expired) > 0 {
+		a.persistLocked()
+	}
+	return expired
+}
+
+// persistLocked saves acknowledgements to the store. Caller must hold mu.
+func (a *AckStore) persistLocked() {
+	if err := a.store.SaveDoc(acksDoc, a.acks); err != nil {
+		log.Printf("Failed to persist acknowledgements: %v", err)
+	}
+}
+
+// ttl returns the lifetime of an acknowledgement requested for duration,
+// the default if it is empty
+func (a *AckStore) ttl(duration string) (time.Duration, error) {
+	ttl := a.defaultTTL
+	if duration != "" {
+		d, err := time.ParseDuration(duration)
+		if err != nil || d <= 0 {
+			return 0, fmt.Errorf("invalid duration")
+		}
+		ttl = d
+	}
+	if ttl > a.maxTTL {
+		return 0, fmt.Errorf("duration exceeds maximum of %s", a.maxTTL)
+	}
+	return ttl, nil
+}
+
+// handleAck creates (POST) or removes (DELETE) the acknowledgement of a workload
+func (s *Server) handleAck(w http.ResponseWriter, r *http.Request, key string) {
+	identity := identityFromContext(r.Context())
+	if identity == nil {
+		http.Error(w, "acknowledgements require an authenticated identity", http.StatusUnauthorized)
+		return
+	}
+	if s.acks == nil {
+		http.Error(w, "acknowledgements are not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		var req AckRequest
+		if !decodeValid(w, r, ackRequestSchema, &req) {
+			return
+		}
+
+		ttl, err := s.acks.ttl(req.Duration)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		s.cacheMutex.RLock()
+		status, exists := s.statusCache[key]
+		violating := exists && isViolation(status)
+		s.cacheMutex.RUnlock()
+
+		if !exists {
+			http.Error(w, "workload not found", http.StatusNotFound)
+			return
+		}
+		if !violating {
+			http.Error(w, "workload is not in violation", http.StatusConflict)
+			return
+		}
+
+		now := time.Now()
+		ack := Acknowledgement{
+			Key:       key,
+			By:        identity.Name,
+			Comment:   req.Comment,
+			CreatedAt: now,
+			ExpiresAt: now.Add(ttl),
+		}
+		s.acks.Set(ack)
+		s.audit.RecordRequest(r, identity.Name, "ack.create", key, fmt.Sprintf("expires %s: %s", ack.ExpiresAt.Format(time.RFC3339), ack.Comment))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(ack)
+
+	case http.MethodDelete:
+		if s.acks.Remove(key) == nil {
+			http.Error(w, "acknowledgement not found", http.StatusNotFound)
+			return
+		}
+		s.audit.RecordRequest(r, identity.Name, "ack.delete", key, "")
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// processAcks resolves acknowledgements of recovered workloads and expires
+// old ones, re-alerting when the violation is still present
+func (s *Server) processAcks() {
+	if s.acks == nil {
+		return
+	}
+
+	now := time.Now()
+	var realerts []HistoryEvent
+
+	s.cacheMutex.RLock()
+	violating := make(map[string]*WorkloadStatus)
+	for key, status := range s.statusCache {
+		if isViolation(status) {
+			violating[key] = copyStatus(status)
+		}
+	}
+	s.cacheMutex.RUnlock()
+
+	for _, ack := range s.acks.Expire(now) {
+		s.audit.Record("system", "ack.expired", ack.Key, "acknowledged by "+ack.By)
+		if status, ok := violating[ack.Key]; ok {
+			realerts = append(realerts, HistoryEvent{
+				Time:           now,
+				Key:            ack.Key,
+				Type:           "ack_expired",
+				PreviousStatus: status.AttestationStatus,
+				Status:         status,
+			})
+		}
+	}
+
+	s.acks.mu.Lock()
+	var resolved []string
+	for key, ack := range s.acks.acks {
+		// Silences hold through recoveries until they expire
+		if _, ok := violating[key]; !ok && !ack.Silence {
+			resolved = append(resolved, key)
+		}
+	}
+	s.acks.mu.Unlock()
+
+	for _, key := range resolved {
+		if ack := s.acks.Remove(key); ack != nil {
+			s.audit.Record("system", "ack.resolved", key, "workload recovered or removed")
+		}
+	}
+
+	s.notifier.Notify(realerts)
+}
+
+// unacknowledged drops events for acknowledged workloads that are still in
+// violation, so operators working an incident aren't paged repeatedly, and
+// every event of silenced workloads
+func (s *Server) unacknowledged(events []HistoryEvent) []HistoryEvent {
+	if s.acks == nil {
+		return events
+	}
+
+	filtered := make([]HistoryEvent, 0, len(events))
+	for _, event := range events {
+		ack := s.acks.Get(event.Key)
+		if ack != nil && ack.Silence {
+			continue
+		}
+		if event.Status != nil && isViolation(event.Status) && event.Type != "removed" && ack != nil {
+			continue
+		}
+		filtered = append(filtered, event)
+	}
+	return filtered
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newAckTestServer(t *testing.T) *Server {
+	t.Helper()
+	acks, err := newAckStore(nil, time.Hour, 4*time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to create ack store: %v", err)
+	}
+	audit, _ := newAuditLog(nil)
+	return &Server{
+		acks:  acks,
+		audit: audit,
+		statusCache: map[string]*WorkloadStatus{
+			"icu/broken":  failedStatus("icu", "broken"),
+			"icu/healthy": verifiedStatus("icu", "healthy"),
+		},
+	}
+}
+
+func ackRequestAs(identity *Identity, method, path, body string) *http.Request {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if identity != nil {
+		req = req.WithContext(context.WithValue(req.Context(), identityContextKey{}, identity))
+	}
+	return req
+}
+
+// TestHandleAck tests creating acknowledgements with attribution and audit
+func TestHandleAck(t *testing.T) {
+	server := newAckTestServer(t)
+	raj := &Identity{Name: "raj"}
+
+	// Anonymous callers are rejected
+	w := httptest.NewRecorder()
+	server.handleWorkloadDetail(w, ackRequestAs(nil, "POST", "/api/workload/icu/broken/ack", `{}`))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for anonymous ack, got %d", w.Code)
+	}
+
+	// Healthy workloads can't be acknowledged
+	w = httptest.NewRecorder()
+	server.handleWorkloadDetail(w, ackRequestAs(raj, "POST", "/api/workload/icu/healthy/ack", `{}`))
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected 409 for healthy workload, got %d", w.Code)
+	}
+
+	// Durations above the maximum are rejected
+	w = httptest.NewRecorder()
+	server.handleWorkloadDetail(w, ackRequestAs(raj, "POST", "/api/workload/icu/broken/ack", `{"duration":"48h"}`))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for excessive duration, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	server.handleWorkloadDetail(w, ackRequestAs(raj, "POST", "/api/workload/icu/broken/ack", `{"comment":"investigating","duration":"2h"}`))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	ack := server.acks.Get("icu/broken")
+	if ack == nil || ack.By != "raj" || ack.Comment != "investigating" {
+		t.Fatalf("Expected attributed acknowledgement, got %+v", ack)
+	}
+	if d := ack.ExpiresAt.Sub(ack.CreatedAt); d != 2*time.Hour {
+		t.Errorf("Expected 2h expiry, got %s", d)
+	}
+
+	entries := server.audit.Entries(time.Time{})
+	if len(entries) != 1 || entries[0].Action != "ack.create" || entries[0].Actor != "raj" {
+		t.Errorf("Expected ack.create audit entry, got %+v", entries)
+	}
+
+	// Acknowledgement is returned with the workload
+	w = httptest.NewRecorder()
+	server.handleWorkloadDetail(w, httptest.NewRequest("GET", "/api/workload/icu/broken", nil))
+	var detail WorkloadStatus
+	json.NewDecoder(w.Body).Decode(&detail)
+	if detail.Acknowledgement == nil || detail.Acknowledgement.By != "raj" {
+		t.Errorf("Expected acknowledgement in detail response, got %+v", detail.Acknowledgement)
+	}
+}
+
+// TestAckExpiryRealerts tests that an expired acknowledgement re-alerts while
+// the violation persists, and that recovered workloads resolve their ack
+func TestAckExpiryRealerts(t *testing.T) {
+	receiver := &webhookReceiver{}
+	srv := httptest.NewServer(receiver)
+	defer srv.Close()
+
+	server := newAckTestServer(t)
+	server.notifier, _ = newNotifier([]notifyTarget{{Name: "hook", URL: srv.URL}}, nil)
+	server.statusCache["icu/fixed"] = verifiedStatus("icu", "fixed")
+
+	past := time.Now().Add(-time.Minute)
+	server.acks.Set(Acknowledgement{Key: "icu/broken", By: "raj", CreatedAt: past.Add(-time.Hour), ExpiresAt: past})
+	server.acks.Set(Acknowledgement{Key: "icu/fixed", By: "raj", CreatedAt: past, ExpiresAt: time.Now().Add(time.Hour)})
+
+	server.processAcks()
+	server.notifier.deliverDue()
+
+	if server.acks.Get("icu/broken") != nil || server.acks.Get("icu/fixed") != nil {
+		t.Error("Expected expired and resolved acknowledgements to be removed")
+	}
+
+	if receiver.received != 1 {
+		t.Fatalf("Expected one re-alert, got %d", receiver.received)
+	}
+	var payload WebhookPayload
+	json.Unmarshal(receiver.bodies[0], &payload)
+	if payload.Event != "workload.ack_expired" || payload.Key != "icu/broken" {
+		t.Errorf("Unexpected re-alert payload: %+v", payload)
+	}
+
+	actions := map[string]bool{}
+	for _, entry := range server.audit.Entries(time.Time{}) {
+		actions[entry.Action] = true
+	}
+	if !actions["ack.expired"] || !actions["ack.resolved"] {
+		t.Errorf("Expected ack.expired and ack.resolved audit entries, got %v", actions)
+	}
+}
+
+// TestUnacknowledgedFiltersEvents tests notification suppression for acked workloads
+func TestUnacknowledgedFiltersEvents(t *testing.T) {
+	server := newAckTestServer(t)
+	server.acks.Set(Acknowledgement{Key: "icu/broken", By: "raj", ExpiresAt: time.Now().Add(time.Hour)})
+
+	events := []HistoryEvent{
+		{Key: "icu/broken", Type: "changed", Status: failedStatus("icu", "broken")},
+		{Key: "icu/other", Type: "changed", Status: failedStatus("icu", "other")},
+	}
+
+	filtered := server.unacknowledged(events)
+	if len(filtered) != 1 || filtered[0].Key != "icu/other" {
+		t.Errorf("Expected only icu/other to be notified, got %+v", filtered)
+	}
+}
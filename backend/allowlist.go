@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// allowlistedPrefixes are the admin and export endpoints that only accept
+// requests from ADMIN_ALLOWED_IPS, on top of authentication
+var allowlistedPrefixes = []string{
+	"/api/admin/",          // backup, restore, selftest
+	"/api/audit",           // audit log and access log exports
+	"/api/export/snapshot", // signed state snapshots
+	"/api/reports/raw/",    // archived Collector reports
+}
+
+// ipAllowlist restricts endpoints to source addresses in a set of networks.
+// Behind a reverse proxy the source is resolved through the trusted proxies.
+type ipAllowlist struct {
+	allowed []*net.IPNet
+	proxies *clientIPResolver
+}
+
+// newIPAllowlist parses comma-separated addresses and CIDRs
+func newIPAllowlist(allowed string, proxies *clientIPResolver) (*ipAllowlist, error) {
+	networks, err := parseNetworks(allowed)
+	if err != nil {
+		return nil, err
+	}
+	if len(networks) == 0 {
+		return nil, fmt.Errorf("no allowed addresses")
+	}
+	return &ipAllowlist{allowed: networks, proxies: proxies}, nil
+}
+
+// parseNetworks parses a comma-separated list of CIDRs; a bare address is
+// a single-host network
+func parseNetworks(list string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for _, raw := range strings.Split(list, ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		if !strings.Contains(raw, "/") {
+			ip := net.ParseIP(raw)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid address %q", raw)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid network %q", raw)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+func containsIP(networks []*net.IPNet, ip net.IP) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// allows reports whether the request may reach an allowlisted endpoint
+func (a *ipAllowlist) allows(r *http.Request) bool {
+	ip := a.proxies.resolve(r)
+	return ip != nil && containsIP(a.allowed, ip)
+}
+
+// isAllowlistedPath reports whether a path is restricted by the allowlist
+func isAllowlistedPath(path string) bool {
+	for _, prefix := range allowlistedPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowlistMiddleware rejects requests to admin and export endpoints from
+// addresses outside ADMIN_ALLOWED_IPS, before they are authenticated
+func (s *Server) allowlistMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.allowlist != nil && isAllowlistedPath(r.URL.Path) && !s.allowlist.allows(r) {
+			log.Printf("Rejected %s %s from %s: address not allowlisted", r.Method, r.URL.Path, requestIP(r))
+			http.Error(w, "forbidden from this address", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestIPAllowlistClientIP tests that X-Forwarded-For is only honoured from
+// trusted proxies and can't be spoofed by prepending addresses
+func TestIPAllowlistClientIP(t *testing.T) {
+	proxies, err := newClientIPResolver("10.0.0.0/24,10.0.1.7")
+	if err != nil {
+		t.Fatalf("Failed to parse trusted proxies: %v", err)
+	}
+	allowlist, err := newIPAllowlist("10.20.0.0/16, 192.168.1.5, fd00::/8", proxies)
+	if err != nil {
+		t.Fatalf("Failed to parse allowlist: %v", err)
+	}
+
+	tests := []struct {
+		remoteAddr string
+		forwarded  []string
+		allowed    bool
+	}{
+		{"10.20.3.4:51234", nil, true},
+		{"192.168.1.5:443", nil, true},
+		{"192.168.1.6:443", nil, false},
+		{"[fd00::12]:8080", nil, true},
+		{"203.0.113.9:1234", []string{"10.20.3.4"}, false},                      // untrusted source can't claim an address
+		{"10.0.0.3:1234", []string{"10.20.3.4"}, true},                          // forwarded by a trusted proxy
+		{"10.0.0.3:1234", []string{"10.20.3.4, 203.0.113.9"}, false},            // client appended by the proxy is the right-most
+		{"10.0.0.3:1234", []string{"203.0.113.9", "10.20.3.4, 10.0.1.7"}, true}, // proxy chain
+		{"10.0.0.3:1234", []string{"not-an-ip"}, false},
+		{"10.0.0.3:1234", nil, false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/api/admin/backup", nil)
+		r.RemoteAddr = tt.remoteAddr
+		for _, header := range tt.forwarded {
+			r.Header.Add("X-Forwarded-For", header)
+		}
+		if got := allowlist.allows(r); got != tt.allowed {
+			t.Errorf("Expected allowed=%v for %s via %v, got %v", tt.allowed, tt.remoteAddr, tt.forwarded, got)
+		}
+	}
+
+	for _, bad := range []string{"", "10.0.0.0/33", "host.local"} {
+		if _, err := newIPAllowlist(bad, nil); err == nil {
+			t.Errorf("Expected %q to be rejected", bad)
+		}
+	}
+}
+
+// TestAllowlistMiddleware tests that only admin and export endpoints are restricted
+func TestAllowlistMiddleware(t *testing.T) {
+	allowlist, _ := newIPAllowlist("10.20.0.0/16", nil)
+	server := &Server{allowlist: allowlist}
+	handler := server.allowlistMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		path       string
+		remoteAddr string
+		expected   int
+	}{
+		{"/api/status", "203.0.113.9:1234", http.StatusOK},
+		{"/api/admin/backup", "203.0.113.9:1234", http.StatusForbidden},
+		{"/api/audit", "203.0.113.9:1234", http.StatusForbidden},
+		{"/api/audit/access", "203.0.113.9:1234", http.StatusForbidden},
+		{"/api/reports/raw/icu/ai-model", "203.0.113.9:1234", http.StatusForbidden},
+		{"/api/admin/backup", "10.20.0.1:1234", http.StatusOK},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", tt.path, nil)
+		r.RemoteAddr = tt.remoteAddr
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != tt.expected {
+			t.Errorf("Expected %d for %s from %s, got %d", tt.expected, tt.path, tt.remoteAddr, w.Code)
+		}
+	}
+}
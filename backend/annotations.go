@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const annotationsDoc = "annotations"
+
+// Limits on operator annotations, which are returned with every workload
+const (
+	maxAnnotationNotes  = 4096
+	maxAnnotationLabels = 32
+	maxLabelValue       = 256
+)
+
+// labelKeyPattern allows Kubernetes-style label keys such as "owner" or
+// "hospital.org/team"
+var labelKeyPattern = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._/-]{0,61}[A-Za-z0-9])?$`)
+
+// AnnotationStore holds operator notes and labels per workload, persisted to
+// the store
+type AnnotationStore struct {
+	mu          sync.Mutex
+	annotations map[string]*Annotations
+	store       *Store
+}
+
+// newAnnotationStore loads persisted annotations
+func newAnnotationStore(store *Store) (*AnnotationStore, error) {
+	a := &AnnotationStore{
+		annotations: make(map[string]*Annotations),
+		store:       store,
+	}
+	if _, err := store.LoadDoc(annotationsDoc, &a.annotations); err != nil {
+		return nil, fmt.Errorf("failed to load annotations: %w", err)
+	}
+	return a, nil
+}
+
+// Get returns a copy of the annotations of a workload, or nil
+func (a *AnnotationStore) Get(key string) *Annotations {
+	if a == nil {
+		return nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	annotations, ok := a.annotations[key]
+	if !ok {
+		return nil
+	}
+	c := *annotations
+	c.Labels = make(map[string]string, len(annotations.Labels))
+	for k, v := range annotations.Labels {
+		c.Labels[k] = v
+	}
+	return &c
+}
+
+// Set replaces the annotations of a workload; nil removes them
+func (a *AnnotationStore) Set(key string, annotations *Annotations) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if annotations == nil {
+		delete(a.annotations, key)
+	} else {
+		a.annotations[key] = annotations
+	}
+	if err := a.store.SaveDoc(annotationsDoc, a.annotations); err != nil {
+		log.Printf("Failed to persist annotations: %v", err)
+	}
+}
+
+// validateAnnotations checks an annotations request against the size limits
+func validateAnnotations(req AnnotationsRequest) error {
+	if len(req.Notes) > maxAnnotationNotes {
+		return fmt.Errorf("notes exceed %d bytes", maxAnnotationNotes)
+	}
+	if len(req.Labels) > maxAnnotationLabels {
+		return fmt.Errorf("more than %d labels", maxAnnotationLabels)
+	}
+	for key, value := range req.Labels {
+		if !labelKeyPattern.MatchString(key) {
+			return fmt.Errorf("invalid label key %q", key)
+		}
+		if len(value) > maxLabelValue {
+			return fmt.Errorf("label %s exceeds %d bytes", key, maxLabelValue)
+		}
+	}
+	return nil
+}
+
+// handleAnnotations replaces the notes and labels of a workload
+// PUT /api/workload/{ns}/{name}/annotations
+func (s *Server) handleAnnotations(w http.ResponseWriter, r *http.Request, key string) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	identity := identityFromContext(r.Context())
+	if identity == nil {
+		http.Error(w, "annotations require an authenticated identity", http.StatusUnauthorized)
+		return
+	}
+	if s.annotations == nil {
+		http.Error(w, "annotations are not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req AnnotationsRequest
+	if !decodeValid(w, r, annotationsSchema, &req) {
+		return
+	}
+	if err := validateAnnotations(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.cacheMutex.RLock()
+	_, exists := s.statusCache[key]
+	s.cacheMutex.RUnlock()
+	if !exists {
+		http.Error(w, "workload not found", http.StatusNotFound)
+		return
+	}
+
+	if req.Notes == "" && len(req.Labels) == 0 {
+		s.annotations.Set(key, nil)
+		s.audit.RecordRequest(r, identity.Name, "annotations.delete", key, "")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	annotations := &Annotations{
+		Notes:     req.Notes,
+		Labels:    req.Labels,
+		UpdatedBy: identity.Name,
+		UpdatedAt: time.Now(),
+	}
+	s.annotations.Set(key, annotations)
+	s.audit.RecordRequest(r, identity.Name, "annotations.update", key, fmt.Sprintf("%d labels", len(req.Labels)))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(annotations)
+}
+
+// matchesLabels reports whether a decorated workload carries every label
+// selected with ?label=key=value (or ?label=key for any value)
+func matchesLabels(r *http.Request, status *WorkloadStatus) bool {
+	for _, selector := range r.URL.Query()["label"] {
+		key, value, hasValue := strings.Cut(selector, "=")
+		if status.Annotations == nil {
+			return false
+		}
+		actual, ok := status.Annotations.Labels[key]
+		if !ok || hasValue && actual != value {
+			return false
+		}
+	}
+	return true
+}
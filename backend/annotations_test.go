@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestHandleAnnotations tests setting, validating, persisting and clearing
+// workload annotations
+func TestHandleAnnotations(t *testing.T) {
+	store, err := openStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	annotations, err := newAnnotationStore(store)
+	if err != nil {
+		t.Fatalf("Failed to create annotation store: %v", err)
+	}
+	server := &Server{
+		annotations: annotations,
+		statusCache: map[string]*WorkloadStatus{"icu/broken": failedStatus("icu", "broken")},
+	}
+	raj := &Identity{Name: "raj"}
+	body := `{"notes":"Vendor image, see ticket","labels":{"owner":"radiology-platform","ticket":"INC-4711"}}`
+
+	w := httptest.NewRecorder()
+	server.handleWorkloadDetail(w, ackRequestAs(nil, "PUT", "/api/workload/icu/broken/annotations", body))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for anonymous annotations, got %d", w.Code)
+	}
+
+	for _, invalid := range []string{`{"labels":{"bad key":"x"}}`, `{"notes":"` + strings.Repeat("x", maxAnnotationNotes+1) + `"}`, `{"labels":{"owner":1}}`} {
+		w = httptest.NewRecorder()
+		server.handleWorkloadDetail(w, ackRequestAs(raj, "PUT", "/api/workload/icu/broken/annotations", invalid))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %.40s, got %d", invalid, w.Code)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	server.handleWorkloadDetail(w, ackRequestAs(raj, "PUT", "/api/workload/icu/missing/annotations", body))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown workload, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	server.handleWorkloadDetail(w, ackRequestAs(raj, "PUT", "/api/workload/icu/broken/annotations", body))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	reloaded, _ := newAnnotationStore(store)
+	server.annotations = reloaded
+	w = httptest.NewRecorder()
+	server.handleWorkloadDetail(w, httptest.NewRequest("GET", "/api/workload/icu/broken", nil))
+	var detail WorkloadStatus
+	json.NewDecoder(w.Body).Decode(&detail)
+	if detail.Annotations == nil || detail.Annotations.UpdatedBy != "raj" || detail.Annotations.Labels["owner"] != "radiology-platform" {
+		t.Fatalf("Expected persisted annotations with the workload, got %+v", detail.Annotations)
+	}
+
+	w = httptest.NewRecorder()
+	server.handleWorkloadDetail(w, ackRequestAs(raj, "PUT", "/api/workload/icu/broken/annotations", `{}`))
+	if w.Code != http.StatusNoContent || server.annotations.Get("icu/broken") != nil {
+		t.Errorf("Expected empty request to clear annotations, got %d", w.Code)
+	}
+}
+
+// TestLabelFilter tests filtering workload lists by annotation labels
+func TestLabelFilter(t *testing.T) {
+	annotations, _ := newAnnotationStore(nil)
+	annotations.Set("icu/a", &Annotations{Labels: map[string]string{"owner": "icu-team", "ticket": "INC-1"}})
+	annotations.Set("lab/b", &Annotations{Labels: map[string]string{"owner": "lab-team"}})
+	server := &Server{
+		annotations: annotations,
+		statusCache: map[string]*WorkloadStatus{
+			"icu/a": verifiedStatus("icu", "a"),
+			"lab/b": verifiedStatus("lab", "b"),
+			"lab/c": verifiedStatus("lab", "c"),
+		},
+	}
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"", 3},
+		{"?label=owner=icu-team", 1},
+		{"?label=owner", 2},
+		{"?label=owner&label=ticket", 1},
+		{"?label=owner=nobody", 0},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		server.handleWorkloads(w, httptest.NewRequest("GET", "/api/workloads"+tt.query, nil))
+		var workloads []WorkloadStatus
+		json.NewDecoder(w.Body).Decode(&workloads)
+		if len(workloads) != tt.want {
+			t.Errorf("Expected %d workloads for %q, got %d", tt.want, tt.query, len(workloads))
+		}
+	}
+}
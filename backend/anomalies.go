@@ -0,0 +1,198 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxAnomalySamples bounds the recent anomalous reports kept for inspection
+const maxAnomalySamples = 50
+
+// Kinds of report anomaly
+const (
+	anomalyUnknownField   = "unknown_field"     // a field the dashboard doesn't know, e.g. from a newer Collector
+	anomalyMissingField   = "missing_field"     // a mandatory field is absent
+	anomalyOutOfRangeTier = "out_of_range_tier" // a trust vector claim value the AR4SI registry doesn't define
+)
+
+// reportAnomaly is one way a report deviates from the schema the dashboard expects
+type reportAnomaly struct {
+	Kind   string `json:"kind"`
+	Field  string `json:"field"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// reportAnomalies compares a raw report with the Collector report schema.
+// Unlike schema validation, which rejects a report, it lists every deviation
+// - including unknown fields, which validation allows - so that schema
+// drift between the Collector and the dashboard shows before it breaks.
+func reportAnomalies(raw json.RawMessage) []reportAnomaly {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil // not an object; fails validation and is reported as such
+	}
+
+	var anomalies []reportAnomaly
+	for _, field := range collectorReportSchema.Required {
+		if _, ok := doc[field]; !ok {
+			anomalies = append(anomalies, reportAnomaly{Kind: anomalyMissingField, Field: field})
+		}
+	}
+	for field := range doc {
+		if _, ok := collectorReportSchema.Properties[field]; !ok {
+			anomalies = append(anomalies, reportAnomaly{Kind: anomalyUnknownField, Field: field})
+		}
+	}
+
+	var claims map[string]json.RawMessage
+	if json.Unmarshal(doc["trust_vector"], &claims) == nil {
+		for name, value := range claims {
+			field := "trust_vector." + name
+			registered, ok := ar4siClaims[name]
+			if !ok {
+				anomalies = append(anomalies, reportAnomaly{Kind: anomalyUnknownField, Field: field})
+				continue
+			}
+			var tier int
+			if err := json.Unmarshal(value, &tier); err != nil {
+				anomalies = append(anomalies, reportAnomaly{Kind: anomalyOutOfRangeTier, Field: field, Detail: fmt.Sprintf("%s is not an integer", value)})
+			} else if !containsInt(ar4siCommonValues, tier) && !containsInt(registered, tier) {
+				anomalies = append(anomalies, reportAnomaly{Kind: anomalyOutOfRangeTier, Field: field, Detail: fmt.Sprintf("%d is not a registered value", tier)})
+			}
+		}
+	}
+
+	sort.Slice(anomalies, func(i, j int) bool {
+		if anomalies[i].Kind != anomalies[j].Kind {
+			return anomalies[i].Kind < anomalies[j].Kind
+		}
+		return anomalies[i].Field < anomalies[j].Field
+	})
+	return anomalies
+}
+
+// anomalyCount counts one kind of anomaly of one field from one source
+type anomalyCount struct {
+	Source    string    `json:"source"`
+	Kind      string    `json:"kind"`
+	Field     string    `json:"field"`
+	Count     int       `json:"count"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// anomalySample is a recent anomalous report
+type anomalySample struct {
+	Time      time.Time       `json:"time"`
+	Source    string          `json:"source"`
+	Anomalies []reportAnomaly `json:"anomalies"`
+	Report    json.RawMessage `json:"report"`
+}
+
+// ingestAnomalies is the response of GET /api/admin/ingest-anomalies
+type ingestAnomalies struct {
+	Counts  []anomalyCount  `json:"counts"`
+	Samples []anomalySample `json:"samples"` // newest first
+}
+
+// anomalyTracker counts report anomalies since startup and keeps the most
+// recent anomalous reports
+type anomalyTracker struct {
+	mu      sync.Mutex
+	counts  map[string]*anomalyCount // by source/kind/field
+	samples []anomalySample          // oldest first
+}
+
+func newAnomalyTracker() *anomalyTracker {
+	return &anomalyTracker{counts: make(map[string]*anomalyCount)}
+}
+
+// record counts the anomalies of a report. The first occurrence of each
+// source, kind and field is logged.
+func (t *anomalyTracker) record(source string, raw json.RawMessage, anomalies []reportAnomaly, now time.Time) {
+	if t == nil || len(anomalies) == 0 {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, a := range anomalies {
+		key := source + "/" + a.Kind + "/" + a.Field
+		count, ok := t.counts[key]
+		if !ok {
+			count = &anomalyCount{Source: source, Kind: a.Kind, Field: a.Field, FirstSeen: now}
+			t.counts[key] = count
+			log.Printf("Report anomaly from %s: %s %s %s", source, a.Kind, a.Field, a.Detail)
+		}
+		count.Count++
+		count.LastSeen = now
+	}
+
+	t.samples = append(t.samples, anomalySample{Time: now, Source: source, Anomalies: anomalies, Report: raw})
+	if len(t.samples) > maxAnomalySamples {
+		t.samples = append([]anomalySample(nil), t.samples[len(t.samples)-maxAnomalySamples:]...)
+	}
+}
+
+// snapshot returns the counts, most frequent first, and the samples, newest first
+func (t *anomalyTracker) snapshot() ingestAnomalies {
+	result := ingestAnomalies{Counts: []anomalyCount{}, Samples: []anomalySample{}}
+	if t == nil {
+		return result
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, count := range t.counts {
+		result.Counts = append(result.Counts, *count)
+	}
+	sort.Slice(result.Counts, func(i, j int) bool {
+		a, b := result.Counts[i], result.Counts[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Source+"/"+a.Kind+"/"+a.Field < b.Source+"/"+b.Kind+"/"+b.Field
+	})
+	for i := len(t.samples) - 1; i >= 0; i-- {
+		result.Samples = append(result.Samples, t.samples[i])
+	}
+	return result
+}
+
+// inspectReport records the anomalies of a report received from source, a
+// cluster name or ingest source
+func (s *Server) inspectReport(source string, raw json.RawMessage) {
+	anomalies := reportAnomalies(raw)
+	for _, a := range anomalies {
+		s.metrics.Inc("dashboard_report_anomalies_total", "Reports deviating from the expected schema, by kind of anomaly and source.", "kind", a.Kind, "source", source)
+	}
+	s.anomalies.record(source, raw, anomalies, time.Now())
+}
+
+// handleIngestAnomalies reports schema anomalies in the reports received
+// since startup, with recent examples, so schema drift between the
+// Collectors, ingest sources and the dashboard is caught early
+// GET /api/admin/ingest-anomalies
+func (s *Server) handleIngestAnomalies(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if _, ok := requireAdmin(w, r); !ok {
+		return
+	}
+
+	anomalies := s.anomalies.snapshot()
+	for _, sample := range anomalies.Samples {
+		noteReports(r, sample.Report)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(anomalies)
+}
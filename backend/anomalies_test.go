@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestReportAnomalies tests detecting unknown fields, missing fields and
+// unregistered trust tiers
+func TestReportAnomalies(t *testing.T) {
+	if anomalies := reportAnomalies(json.RawMessage(`{"pod_name":"a","namespace":"icu","attested":true,"trust_vector":{"hardware":2}}`)); len(anomalies) != 0 {
+		t.Errorf("Expected no anomalies in a conforming report, got %+v", anomalies)
+	}
+
+	anomalies := reportAnomalies(json.RawMessage(`{"pod_name":"a","attested":true,"tdx_quote":"...","trust_vector":{"hardware":3,"executables":"2","firmware":2}}`))
+	want := []reportAnomaly{
+		{Kind: anomalyMissingField, Field: "namespace"},
+		{Kind: anomalyOutOfRangeTier, Field: "trust_vector.executables", Detail: `"2" is not an integer`},
+		{Kind: anomalyOutOfRangeTier, Field: "trust_vector.hardware", Detail: "3 is not a registered value"},
+		{Kind: anomalyUnknownField, Field: "tdx_quote"},
+		{Kind: anomalyUnknownField, Field: "trust_vector.firmware"},
+	}
+	if len(anomalies) != len(want) {
+		t.Fatalf("Expected %d anomalies, got %+v", len(want), anomalies)
+	}
+	for i := range want {
+		if anomalies[i] != want[i] {
+			t.Errorf("Anomaly %d: expected %+v, got %+v", i, want[i], anomalies[i])
+		}
+	}
+}
+
+// TestHandleIngestAnomalies tests counting and sampling anomalous reports
+func TestHandleIngestAnomalies(t *testing.T) {
+	server := &Server{anomalies: newAnomalyTracker(), metrics: newMetrics()}
+	for i := 0; i < maxAnomalySamples+5; i++ {
+		server.inspectReport("east", json.RawMessage(`{"pod_name":"a","namespace":"icu","attested":true,"nonce":"x"}`))
+	}
+	server.inspectReport("east", json.RawMessage(`{"pod_name":"a","namespace":"icu","attested":true}`))
+	server.inspectReport("west", json.RawMessage(`{"pod_name":"b","attested":false}`))
+
+	if got := server.metrics.Value("dashboard_report_anomalies_total", "kind", anomalyUnknownField, "source", "east"); got != maxAnomalySamples+5 {
+		t.Errorf("Expected %d unknown field anomalies counted, got %g", maxAnomalySamples+5, got)
+	}
+
+	w := httptest.NewRecorder()
+	server.handleIngestAnomalies(w, ackRequestAs(&Identity{Name: "raj"}, "GET", "/api/admin/ingest-anomalies", ""))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 without the admin role, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	server.handleIngestAnomalies(w, ackRequestAs(&Identity{Name: "raj", Roles: []string{adminRole}}, "GET", "/api/admin/ingest-anomalies", ""))
+	var result ingestAnomalies
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(result.Counts) != 2 || result.Counts[0].Field != "nonce" || result.Counts[0].Count != maxAnomalySamples+5 ||
+		result.Counts[1].Source != "west" || result.Counts[1].Kind != anomalyMissingField {
+		t.Errorf("Unexpected counts %+v", result.Counts)
+	}
+	if len(result.Samples) != maxAnomalySamples || result.Samples[0].Source != "west" {
+		t.Errorf("Expected %d samples, newest first, got %d starting with %+v", maxAnomalySamples, len(result.Samples), result.Samples[0])
+	}
+	if time.Since(result.Counts[0].FirstSeen) > time.Minute {
+		t.Errorf("Unexpected first_seen %s", result.Counts[0].FirstSeen)
+	}
+}
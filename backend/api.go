@@ -0,0 +1,59 @@
+package main
+
+import "github.com/rh-summit-coco/raj-hospital-dashboard/backend/pkg/api"
+
+// The types served by the API live in pkg/api so that Go clients
+// (pkg/client) use the same definitions as the server
+type (
+	DashboardResponse       = api.DashboardResponse
+	WorkloadStatus          = api.WorkloadStatus
+	GateResult              = api.GateResult
+	Check                   = api.Check
+	AckRequest              = api.AckRequest
+	Acknowledgement         = api.Acknowledgement
+	WorkloadSelector        = api.WorkloadSelector
+	BatchAckRequest         = api.BatchAckRequest
+	BatchAckResult          = api.BatchAckResult
+	AnnotationsRequest      = api.AnnotationsRequest
+	Annotations             = api.Annotations
+	ExpectedWorkloadRequest = api.ExpectedWorkloadRequest
+	ExpectedWorkload        = api.ExpectedWorkload
+	WatchRequest            = api.WatchRequest
+	WatchedWorkload         = api.WatchedWorkload
+	SuppressRequest         = api.SuppressRequest
+	SuppressedWorkload      = api.SuppressedWorkload
+	ShareRequest            = api.ShareRequest
+	ShareLink               = api.ShareLink
+	SharedWorkload          = api.SharedWorkload
+	DowntimeRequest         = api.DowntimeRequest
+	DowntimeWindow          = api.DowntimeWindow
+	SearchResult            = api.SearchResult
+	FleetDiff               = api.FleetDiff
+	WorkloadChange          = api.WorkloadChange
+	HistoryEvent            = api.HistoryEvent
+	WebhookPayload          = api.WebhookPayload
+	NodeSummary             = api.NodeSummary
+	NodeReport              = api.NodeReport
+	ClusterSummary          = api.ClusterSummary
+	SiteSummary             = api.SiteSummary
+	FederationResponse      = api.FederationResponse
+	StateSnapshot           = api.StateSnapshot
+	SignedSnapshot          = api.SignedSnapshot
+	FeaturesResponse        = api.FeaturesResponse
+	FeatureFlag             = api.FeatureFlag
+	Deprecation             = api.Deprecation
+	Explanation             = api.Explanation
+	ExplainedRule           = api.ExplainedRule
+	EvidenceField           = api.EvidenceField
+	WallboardResponse       = api.WallboardResponse
+	WallboardIssue          = api.WallboardIssue
+	WallboardCounts         = api.WallboardCounts
+	TEEInventory            = api.TEEInventory
+	TCBVersionCount         = api.TCBVersionCount
+	TrustVector             = api.TrustVector
+	Owner                   = api.Owner
+	TrustTrend              = api.TrustTrend
+	TrustSample             = api.TrustSample
+	TrustTier               = api.TrustTier
+	Session                 = api.Session
+)
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ar4siCommonValues are valid for every trustworthiness claim:
+// verifier malfunction, no claim, unexpected evidence, cryptographic failure
+var ar4siCommonValues = []int{-1, 0, 1, 99}
+
+// ar4siClaims lists the AR4SI trustworthiness claims with the values the
+// registry defines for each in addition to ar4siCommonValues
+var ar4siClaims = map[string][]int{
+	"instance_identity": {2, 96, 97},
+	"configuration":     {2, 3, 32, 96},
+	"executables":       {2, 3, 32, 33, 96, 97},
+	"file_system":       {2, 32, 96},
+	"hardware":          {2, 32, 96, 97},
+	"runtime_opaque":    {2, 32, 96},
+	"storage_opaque":    {2, 32, 96},
+	"sourced_data":      {2, 32, 96},
+}
+
+// ar4siProfiles lists the claims a trust vector must carry under each
+// AR4SI_PROFILE. With no profile only claim values are checked.
+var ar4siProfiles = map[string][]string{
+	"basic": {"hardware"},
+	"coco":  {"instance_identity", "configuration", "executables", "hardware"},
+}
+
+// validateEvidence checks a report's trust vector against the AR4SI claim
+// registry and the configured profile
+func (s *Server) validateEvidence(report *CollectorReport) error {
+	if report.malformed != "" {
+		return fmt.Errorf("%s", report.malformed)
+	}
+
+	claims, err := trustVectorClaims(report)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(claims))
+	for name := range claims {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		registered, ok := ar4siClaims[name]
+		if !ok {
+			return fmt.Errorf("unknown trust vector claim %q", name)
+		}
+		value := claims[name]
+		if value < -128 || value > 127 {
+			return fmt.Errorf("%s value %d outside the AR4SI range", name, value)
+		}
+		if !containsInt(ar4siCommonValues, value) && !containsInt(registered, value) {
+			return fmt.Errorf("%s value %d is not a registered AR4SI claim value", name, value)
+		}
+	}
+
+	var missing []string
+	for _, name := range ar4siProfiles[s.ar4siProfile] {
+		if _, ok := claims[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing mandatory claims for profile %s: %s", s.ar4siProfile, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// trustVectorClaims returns the claims present in a report's trust vector.
+// The raw report is used when available so that omitted claims can be told
+// apart from claims set to 0.
+func trustVectorClaims(report *CollectorReport) (map[string]int, error) {
+	claims := make(map[string]int)
+
+	if report.raw != nil {
+		var doc struct {
+			TrustVector map[string]json.RawMessage `json:"trust_vector"`
+		}
+		if err := json.Unmarshal(report.raw, &doc); err != nil {
+			return nil, fmt.Errorf("invalid trust vector: %w", err)
+		}
+		for name, raw := range doc.TrustVector {
+			var value int
+			if err := json.Unmarshal(raw, &value); err != nil {
+				return nil, fmt.Errorf("%s value %s is not an integer", name, raw)
+			}
+			claims[name] = value
+		}
+		return claims, nil
+	}
+
+	if tv := report.TrustVector; tv != nil {
+		claims = trustVectorValues(tv)
+	}
+	return claims, nil
+}
+
+// decodeMalformedReport salvages the identity of a report that failed to
+// decode so it can be shown as malformed evidence rather than dropped
+func decodeMalformedReport(raw json.RawMessage, decodeErr error) (CollectorReport, bool) {
+	var ident struct {
+		PodName   string `json:"pod_name"`
+		Namespace string `json:"namespace"`
+		NodeName  string `json:"node_name"`
+		Cluster   string `json:"cluster"`
+	}
+	if err := json.Unmarshal(raw, &ident); err != nil || ident.PodName == "" {
+		return CollectorReport{}, false
+	}
+	return CollectorReport{
+		PodName:   ident.PodName,
+		Namespace: ident.Namespace,
+		NodeName:  ident.NodeName,
+		Cluster:   ident.Cluster,
+		malformed: fmt.Sprintf("invalid report: %v", decodeErr),
+	}, true
+}
+
+func containsInt(list []int, value int) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
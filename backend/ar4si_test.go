@@ -0,0 +1,78 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestValidateEvidence tests trust vector validation against the AR4SI registry
+func TestValidateEvidence(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile string
+		raw     string
+		valid   bool
+	}{
+		{"registered values", "", `{"trust_vector":{"hardware":2,"executables":33}}`, true},
+		{"common values", "", `{"trust_vector":{"hardware":99,"configuration":-1}}`, true},
+		{"no trust vector without profile", "", `{}`, true},
+		{"unregistered value", "", `{"trust_vector":{"hardware":3}}`, false},
+		{"out of range", "", `{"trust_vector":{"hardware":1000}}`, false},
+		{"not an integer", "", `{"trust_vector":{"hardware":2.5}}`, false},
+		{"unknown claim", "", `{"trust_vector":{"firmware":2}}`, false},
+		{"profile satisfied", "coco", `{"trust_vector":{"instance_identity":2,"configuration":2,"executables":2,"hardware":2}}`, true},
+		{"profile missing claim", "coco", `{"trust_vector":{"hardware":2,"executables":2}}`, false},
+		{"profile with no trust vector", "basic", `{}`, false},
+	}
+
+	for _, tt := range tests {
+		server := &Server{ar4siProfile: tt.profile}
+		err := server.validateEvidence(&CollectorReport{raw: []byte(tt.raw)})
+		if tt.valid && err != nil {
+			t.Errorf("%s: expected valid, got %v", tt.name, err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("%s: expected validation error", tt.name)
+		}
+	}
+}
+
+// TestMalformedEvidenceStatus tests that invalid or undecodable reports are
+// shown as malformed evidence instead of verified
+func TestMalformedEvidenceStatus(t *testing.T) {
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[
+			{"pod_name":"good","namespace":"icu","attested":true,"trust_vector":{"hardware":2}},
+			{"pod_name":"bad-value","namespace":"icu","attested":true,"trust_vector":{"hardware":1000}},
+			{"pod_name":"bad-type","namespace":"icu","attested":true,"trust_vector":{"hardware":"affirming"}}
+		]`))
+	}))
+	defer collector.Close()
+
+	server := &Server{
+		collectorURL: collector.URL,
+		statusCache:  make(map[string]*WorkloadStatus),
+		httpClient:   &http.Client{Timeout: 10 * time.Second},
+	}
+	server.fetchFromCollector()
+
+	if len(server.statusCache) != 3 {
+		t.Fatalf("Expected 3 workloads, got %d", len(server.statusCache))
+	}
+	if status := server.statusCache["icu/good"]; status.AttestationStatus != "verified" {
+		t.Errorf("Expected 'verified', got '%s'", status.AttestationStatus)
+	}
+	for _, key := range []string{"icu/bad-value", "icu/bad-type"} {
+		status := server.statusCache[key]
+		if status.AttestationStatus != unknownStatus || status.Attested {
+			t.Errorf("Expected %s to be malformed evidence, got %+v", key, status)
+		}
+	}
+	if details := server.statusCache["icu/bad-type"].Details; !strings.Contains(details, "trust_vector.hardware: expected integer, got string") {
+		t.Errorf("Expected schema violation in details, got %q", details)
+	}
+}
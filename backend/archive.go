@@ -0,0 +1,79 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+const rawReportBucket = "raw_reports"
+
+var rawReportIDPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// rawReportID is the content address of a raw report: hex SHA-256 of its bytes
+func rawReportID(raw []byte) string {
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// archiveReports stores the unmodified bytes of every report (including the
+// EAR token) under its content address and records the ID on the report.
+// Identical reports are stored once.
+func (s *Server) archiveReports(reports []CollectorReport) {
+	if s.rawArchive == nil {
+		return
+	}
+
+	for i := range reports {
+		raw := reports[i].raw
+		if len(raw) == 0 {
+			// Reports that didn't come from a Collector response (e.g. tests)
+			var err error
+			if raw, err = json.Marshal(reports[i]); err != nil {
+				continue
+			}
+		}
+
+		id := rawReportID(raw)
+		if err := s.rawArchive.SaveBlob(rawReportBucket, id, raw); err != nil {
+			log.Printf("Failed to archive report for %s/%s: %v", reports[i].Namespace, reports[i].PodName, err)
+			continue
+		}
+		reports[i].rawID = id
+	}
+}
+
+// handleRawReport returns an archived report exactly as received
+// GET /api/reports/raw/{id}
+func (s *Server) handleRawReport(w http.ResponseWriter, r *http.Request) {
+	if s.rawArchive == nil {
+		http.Error(w, "raw report archive is not enabled", http.StatusNotFound)
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/api/reports/raw/")
+	if !rawReportIDPattern.MatchString(id) {
+		http.Error(w, "invalid report id", http.StatusBadRequest)
+		return
+	}
+
+	data, found, err := s.rawArchive.LoadBlob(rawReportBucket, id)
+	if err != nil {
+		log.Printf("Failed to read archived report %s: %v", id, err)
+		http.Error(w, "failed to read archived report", http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		http.Error(w, "report not found", http.StatusNotFound)
+		return
+	}
+
+	noteReports(r, data)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", `"`+id+`"`)
+	w.Write(data)
+}
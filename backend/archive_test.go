@@ -0,0 +1,54 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestRawReportArchive tests that reports are archived byte-for-byte and
+// retrievable by content address
+func TestRawReportArchive(t *testing.T) {
+	raw := `[{"pod_name":"ai-model","namespace":"icu","attested":true,"ear_token":"eyJhbGciOi.payload.sig","timestamp":"2024-05-01T10:00:00Z","extra_field":"kept"}]`
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(raw))
+	}))
+	defer collector.Close()
+
+	store, _ := openStore(t.TempDir())
+	server := &Server{
+		collectorURL: collector.URL,
+		statusCache:  make(map[string]*WorkloadStatus),
+		httpClient:   &http.Client{Timeout: 10 * time.Second},
+		rawArchive:   store,
+	}
+	server.fetchFromCollector()
+
+	status := server.statusCache["icu/ai-model"]
+	expectedID := rawReportID([]byte(raw[1 : len(raw)-1]))
+	if status.RawReportID != expectedID {
+		t.Fatalf("Expected raw report ID %s, got %s", expectedID, status.RawReportID)
+	}
+
+	w := httptest.NewRecorder()
+	server.handleRawReport(w, httptest.NewRequest("GET", "/api/reports/raw/"+expectedID, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", w.Code)
+	}
+	if w.Body.String() != raw[1:len(raw)-1] {
+		t.Errorf("Expected unmodified report, got %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	server.handleRawReport(w, httptest.NewRequest("GET", "/api/reports/raw/../../etc/passwd", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid id, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	server.handleRawReport(w, httptest.NewRequest("GET", "/api/reports/raw/"+rawReportID([]byte("other")), nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown id, got %d", w.Code)
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import "time"
+
+// attestationLatencyBuckets are the histogram buckets, in seconds, for the
+// time from pod start to first attestation: from attestation during
+// container start up to pods running unverified for hours
+var attestationLatencyBuckets = []float64{5, 15, 30, 60, 120, 300, 600, 1800, 3600, 14400}
+
+// trackAttestationLatency records when a workload's pod was first attested,
+// from the pod start time found by Kubernetes enrichment. A pod keeps its
+// first attestation for as long as it runs, whatever its later verdicts, so
+// the latency is observed once per pod. Caller must hold cacheMutex.
+func (s *Server) trackAttestationLatency(prev, status *WorkloadStatus) {
+	if status.PodStartedAt == nil || !s.featureEnabled("attestation-latency") {
+		return
+	}
+	if prev != nil && prev.FirstAttestedAt != nil && prev.PodStartedAt != nil && prev.PodStartedAt.Equal(*status.PodStartedAt) {
+		status.FirstAttestedAt = prev.FirstAttestedAt
+		status.TimeToAttest = prev.TimeToAttest
+		return
+	}
+	if !status.Attested {
+		return
+	}
+
+	// A report from before the pod started attested an earlier pod of the
+	// same name
+	attestedAt, err := time.Parse(time.RFC3339, status.Timestamp)
+	if err != nil || attestedAt.Before(*status.PodStartedAt) {
+		return
+	}
+	status.FirstAttestedAt = &attestedAt
+	status.TimeToAttest = attestedAt.Sub(*status.PodStartedAt).Seconds()
+	s.metrics.Observe("dashboard_attestation_latency_seconds",
+		"Time from a pod starting to its first successful attestation.",
+		attestationLatencyBuckets, status.TimeToAttest, "", "cluster", status.Cluster)
+}
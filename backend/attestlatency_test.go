@@ -0,0 +1,70 @@
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestTrackAttestationLatency tests that the first attestation of a pod is
+// recorded once and kept while the pod runs
+func TestTrackAttestationLatency(t *testing.T) {
+	server := &Server{metrics: newMetrics()}
+	started := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	status := func(attested bool, podStarted time.Time, reported time.Time) *WorkloadStatus {
+		s := failedStatus("icu", "pacs")
+		if attested {
+			s = verifiedStatus("icu", "pacs")
+		}
+		s.Cluster = "east"
+		s.PodStartedAt = &podStarted
+		s.Timestamp = reported.Format(time.RFC3339)
+		return s
+	}
+
+	pending := status(false, started, started.Add(30*time.Second))
+	server.trackAttestationLatency(nil, pending)
+	if pending.FirstAttestedAt != nil {
+		t.Fatalf("Expected no attestation before one succeeded, got %v", pending.FirstAttestedAt)
+	}
+
+	first := status(true, started, started.Add(90*time.Second))
+	server.trackAttestationLatency(pending, first)
+	if first.FirstAttestedAt == nil || first.TimeToAttest != 90 {
+		t.Fatalf("Expected 90s to first attestation, got %+v", first)
+	}
+
+	// Later verdicts keep the first attestation and aren't observed again
+	later := status(false, started, started.Add(10*time.Minute))
+	server.trackAttestationLatency(first, later)
+	if later.TimeToAttest != 90 || !later.FirstAttestedAt.Equal(*first.FirstAttestedAt) {
+		t.Errorf("Expected the first attestation kept, got %+v", later)
+	}
+
+	// A new pod of the same name starts over, and a report from before it
+	// started doesn't count
+	restarted := started.Add(time.Hour)
+	old := status(true, restarted, started.Add(10*time.Minute))
+	server.trackAttestationLatency(later, old)
+	if old.FirstAttestedAt != nil {
+		t.Errorf("Expected a report predating the pod ignored, got %+v", old)
+	}
+	fresh := status(true, restarted, restarted.Add(20*time.Second))
+	server.trackAttestationLatency(old, fresh)
+	if fresh.TimeToAttest != 20 {
+		t.Errorf("Expected 20s to attest the new pod, got %v", fresh.TimeToAttest)
+	}
+
+	w := httptest.NewRecorder()
+	server.handleMetrics(w, httptest.NewRequest("GET", "/metrics", nil))
+	for _, expected := range []string{
+		`dashboard_attestation_latency_seconds_bucket{cluster="east",le="30"} 1`,
+		`dashboard_attestation_latency_seconds_bucket{cluster="east",le="120"} 2`,
+		`dashboard_attestation_latency_seconds_count{cluster="east"} 2`,
+	} {
+		if !strings.Contains(w.Body.String(), expected) {
+			t.Errorf("Expected %s in metrics", expected)
+		}
+	}
+}
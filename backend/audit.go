@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const auditBucket = "audit"
+
+// AuditEntry records an operator action for incident-handling documentation
+type AuditEntry struct {
+	Time    time.Time `json:"time"`
+	Actor   string    `json:"actor"` // identity name, or "system" for automatic actions
+	Action  string    `json:"action"`
+	Target  string    `json:"target"`
+	Details string    `json:"details,omitempty"`
+	// SourceIP is the client address of the request, for operator actions
+	SourceIP string `json:"source_ip,omitempty"`
+	// LocalTime is time in the display time zone, set in exports only
+	LocalTime string `json:"local_time,omitempty"`
+}
+
+// AuditLog is an append-only log of operator actions, persisted to the store
+type AuditLog struct {
+	mu      sync.RWMutex
+	entries []AuditEntry
+	store   *Store
+}
+
+// newAuditLog loads persisted audit entries
+func newAuditLog(store *Store) (*AuditLog, error) {
+	a := &AuditLog{store: store}
+	err := store.Load(auditBucket, func(raw json.RawMessage) error {
+		var entry AuditEntry
+		if err := json.Unmarshal(raw, &entry); err != nil {
+			return err
+		}
+		a.entries = append(a.entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Record appends an entry to the audit log
+func (a *AuditLog) Record(actor, action, target, details string) {
+	a.record(AuditEntry{Time: time.Now(), Actor: actor, Action: action, Target: target, Details: details})
+}
+
+// RecordRequest appends an entry for an action taken through an API request,
+// with the client address it came from
+func (a *AuditLog) RecordRequest(r *http.Request, actor, action, target, details string) {
+	a.record(AuditEntry{Time: time.Now(), Actor: actor, Action: action, Target: target, Details: details, SourceIP: requestIP(r)})
+}
+
+func (a *AuditLog) record(entry AuditEntry) {
+	if a == nil {
+		return
+	}
+
+	a.mu.Lock()
+	a.entries = append(a.entries, entry)
+	a.mu.Unlock()
+
+	if err := a.store.Append(auditBucket, entry); err != nil {
+		log.Printf("Failed to persist audit entry: %v", err)
+	}
+}
+
+// Entries returns audit entries recorded at or after since
+func (a *AuditLog) Entries(since time.Time) []AuditEntry {
+	entries := []AuditEntry{}
+	if a == nil {
+		return entries
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	for _, entry := range a.entries {
+		if !entry.Time.Before(since) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// handleAudit exports the audit log
+// GET /api/audit?since=2024-05-01T00:00:00Z
+func (s *Server) handleAudit(w http.ResponseWriter, r *http.Request) {
+	zone, ok := s.requestZone(w, r)
+	if !ok {
+		return
+	}
+	var since time.Time
+	if raw := r.URL.Query().Get("since"); raw != "" {
+		var err error
+		if since, err = time.Parse(time.RFC3339, raw); err != nil {
+			http.Error(w, "invalid since parameter, expected RFC3339", http.StatusBadRequest)
+			return
+		}
+	}
+
+	entries := s.audit.Entries(since)
+	for i := range entries {
+		entries[i].Time = entries[i].Time.UTC()
+		entries[i].LocalTime = zone.format(entries[i].Time)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
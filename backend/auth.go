@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Identity is an authenticated API caller
+type Identity struct {
+	Name       string   `json:"name"`
+	Roles      []string `json:"roles,omitempty"`
+	Namespaces []string `json:"namespaces,omitempty"` // tenant scope for event streams; empty = all
+
+	permissions []string      // granted by RBAC_CONFIG; nil when no RBAC policy applies
+	session     *streamClaims // set when authenticated by a session cookie
+}
+
+// apiToken maps a bearer token to an identity in the AUTH_TOKENS_FILE
+type apiToken struct {
+	Token      string   `json:"token"`
+	Identity   string   `json:"identity"`
+	Roles      []string `json:"roles,omitempty"`
+	Namespaces []string `json:"namespaces,omitempty"`
+}
+
+// Authenticator resolves bearer tokens, Basic credentials when LDAP is
+// configured and session cookies, to identities.
+// A nil *Authenticator means authentication is disabled and every request is
+// anonymous.
+type Authenticator struct {
+	tokens   []apiToken
+	ldap     *ldapAuthenticator
+	sessions *streamTokens // signs browser sessions
+	revoked  *sessionRevocations
+	// secureCookies restricts sessions started at POST /api/session to HTTPS
+	secureCookies bool
+}
+
+type identityContextKey struct{}
+
+// loadAuthenticator reads the token list from a JSON file
+func loadAuthenticator(path string) (*Authenticator, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var tokens []apiToken
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		return nil, fmt.Errorf("invalid auth tokens file: %w", err)
+	}
+	for i, t := range tokens {
+		if t.Token == "" || t.Identity == "" {
+			return nil, fmt.Errorf("token %d: token and identity are required", i)
+		}
+	}
+	return &Authenticator{tokens: tokens}, nil
+}
+
+// authenticate returns the identity for the request's bearer token, LDAP
+// credentials or session cookie
+func (a *Authenticator) authenticate(r *http.Request) (*Identity, bool) {
+	header := r.Header.Get("Authorization")
+	if cookie, err := r.Cookie(sessionCookie); a.sessions != nil && header == "" && err == nil {
+		claims, err := a.sessions.verify(cookie.Value, time.Now())
+		if err != nil || a.revoked.Revoked(claims.ID) {
+			return nil, false
+		}
+		return &Identity{Name: claims.Subject, Roles: claims.Roles, Namespaces: claims.Namespaces, session: claims}, true
+	}
+	if a.ldap != nil && strings.HasPrefix(header, "Basic ") {
+		username, password, _ := r.BasicAuth()
+		identity, err := a.ldap.authenticate(username, password)
+		if err != nil {
+			log.Printf("LDAP authentication of %q failed: %v", username, err)
+			return nil, false
+		}
+		return identity, true
+	}
+	if !strings.HasPrefix(header, "Bearer ") {
+		return nil, false
+	}
+	presented := []byte(strings.TrimPrefix(header, "Bearer "))
+
+	for _, t := range a.tokens {
+		if subtle.ConstantTimeCompare(presented, []byte(t.Token)) == 1 {
+			return &Identity{Name: t.Identity, Roles: t.Roles, Namespaces: t.Namespaces}, true
+		}
+	}
+	return nil, false
+}
+
+// authMiddleware requires a valid bearer token for /api/ requests when
+// authentication is enabled, and attaches the caller's identity to the context.
+// Event streams, ingest sources and share links check their own tokens instead.
+func (s *Server) authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.auth == nil || r.Method == http.MethodOptions || !strings.HasPrefix(r.URL.Path, "/api/") || isStreamPath(r.URL.Path) || isSAMLPath(r.URL.Path) || isIngestPath(r.URL.Path) || isSharedPath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		identity, ok := s.auth.authenticate(r)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="dashboard"`)
+			if s.auth.ldap != nil {
+				w.Header().Add("WWW-Authenticate", `Basic realm="dashboard"`)
+			}
+			http.Error(w, "authentication required", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), identityContextKey{}, identity)))
+	})
+}
+
+// hasRole reports whether the identity was granted role
+func (i *Identity) hasRole(role string) bool {
+	return i != nil && containsString(i.Roles, role)
+}
+
+// identityFromContext returns the authenticated caller, or nil if anonymous
+func identityFromContext(ctx context.Context) *Identity {
+	identity, _ := ctx.Value(identityContextKey{}).(*Identity)
+	return identity
+}
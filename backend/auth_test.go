@@ -0,0 +1,56 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestAuthMiddleware tests bearer token enforcement on API routes
+func TestAuthMiddleware(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	os.WriteFile(path, []byte(`[{"token":"raj-token","identity":"raj","roles":["operator"]}]`), 0o600)
+
+	auth, err := loadAuthenticator(path)
+	if err != nil {
+		t.Fatalf("Failed to load tokens: %v", err)
+	}
+	server := &Server{auth: auth}
+
+	var seen *Identity
+	handler := server.authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = identityFromContext(r.Context())
+	}))
+
+	tests := []struct {
+		path   string
+		header string
+		code   int
+	}{
+		{"/api/status", "", http.StatusUnauthorized},
+		{"/api/status", "Bearer wrong", http.StatusUnauthorized},
+		{"/api/status", "Bearer raj-token", http.StatusOK},
+		{"/healthz", "", http.StatusOK},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest("GET", test.path, nil)
+		if test.header != "" {
+			req.Header.Set("Authorization", test.header)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != test.code {
+			t.Errorf("%s with %q: expected %d, got %d", test.path, test.header, test.code, w.Code)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/api/status", nil)
+	req.Header.Set("Authorization", "Bearer raj-token")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if seen == nil || seen.Name != "raj" || seen.Roles[0] != "operator" {
+		t.Errorf("Expected identity raj in context, got %+v", seen)
+	}
+}
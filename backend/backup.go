@@ -0,0 +1,452 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// backupVersion is the archive format produced by /api/admin/backup.
+// Archives of earlier versions restore with the sections they lack empty.
+const backupVersion = 6
+
+// maxBackupSize bounds the body accepted by /api/admin/restore
+const maxBackupSize = 512 << 20
+
+// adminRole is required for backup, restore and other administrative endpoints
+const adminRole = "admin"
+
+// Backup is a point-in-time snapshot of the dashboard's compliance records
+// and the operator state they are read with. Left out on purpose: live
+// workload status and the raw report archive, which are rebuilt from the
+// Collectors; registered Collectors, whose credentials don't belong in an
+// archive; share links and revoked sessions, which are tied to this
+// instance's secrets; and the Jira issue links and notification queue,
+// which are transient.
+type Backup struct {
+	Version          int                       `json:"version"`
+	CreatedAt        time.Time                 `json:"created_at"`
+	History          []HistoryEvent            `json:"history"`
+	Audit            []AuditEntry              `json:"audit"`
+	Acknowledgements []Acknowledgement         `json:"acknowledgements"`
+	Access           []AccessEntry             `json:"access"`
+	Annotations      map[string]Annotations    `json:"annotations"` // by workload key
+	Downtime         []DowntimeWindow          `json:"downtime"`
+	Suppressions     []SuppressedWorkload      `json:"suppressions"`
+	Stats            map[string]workloadRecord `json:"stats"` // lifetime records by workload key
+	Expected         []ExpectedWorkload        `json:"expected_workloads"`
+	Watchlist        []WatchedWorkload         `json:"watchlist"`
+	PolicyVersions   []PolicyVersion           `json:"policy_versions"` // all but the startup configuration
+	PolicyState      policyState               `json:"policy_state"`
+}
+
+// checkPolicies validates the backup's policy versions and that its rollout
+// state refers to them, before anything is restored
+func (b *Backup) checkPolicies() error {
+	known := map[int]bool{0: true}
+	for i := range b.PolicyVersions {
+		version := &b.PolicyVersions[i]
+		if known[version.Version] || version.Version < 0 {
+			return fmt.Errorf("duplicate or reserved policy version %d", version.Version)
+		}
+		if err := version.Policy.init(); err != nil {
+			return fmt.Errorf("policy version %d: %w", version.Version, err)
+		}
+		known[version.Version] = true
+	}
+	if !known[b.PolicyState.Active] {
+		return fmt.Errorf("active policy version %d does not exist", b.PolicyState.Active)
+	}
+	if b.PolicyState.Shadow != nil && !known[*b.PolicyState.Shadow] {
+		return fmt.Errorf("shadow policy version %d does not exist", *b.PolicyState.Shadow)
+	}
+	return nil
+}
+
+// snapshot copies every section but the access log while holding all their
+// locks, so the archive is consistent across them
+func (s *Server) snapshot(ctx context.Context) (Backup, error) {
+	backup := Backup{
+		Version:          backupVersion,
+		CreatedAt:        time.Now(),
+		History:          []HistoryEvent{},
+		Audit:            []AuditEntry{},
+		Acknowledgements: []Acknowledgement{},
+		Annotations:      map[string]Annotations{},
+		Downtime:         []DowntimeWindow{},
+		Suppressions:     []SuppressedWorkload{},
+		Stats:            map[string]workloadRecord{},
+		Expected:         []ExpectedWorkload{},
+		Watchlist:        []WatchedWorkload{},
+		PolicyVersions:   []PolicyVersion{},
+	}
+
+	// Read before taking the locks: a persisted access log is read from the
+	// store, and only grows
+	access, err := s.access.Entries(ctx, time.Time{}, "")
+	if err != nil {
+		return Backup{}, fmt.Errorf("failed to read access log: %w", err)
+	}
+	backup.Access = access
+
+	if s.history != nil {
+		s.history.mu.RLock()
+		defer s.history.mu.RUnlock()
+		backup.History = append(backup.History, s.history.events...)
+	}
+	if s.audit != nil {
+		s.audit.mu.RLock()
+		defer s.audit.mu.RUnlock()
+		backup.Audit = append(backup.Audit, s.audit.entries...)
+	}
+	if s.acks != nil {
+		s.acks.mu.Lock()
+		defer s.acks.mu.Unlock()
+		for _, ack := range s.acks.acks {
+			backup.Acknowledgements = append(backup.Acknowledgements, *ack)
+		}
+		sort.Slice(backup.Acknowledgements, func(i, j int) bool {
+			return backup.Acknowledgements[i].Key < backup.Acknowledgements[j].Key
+		})
+	}
+	if s.annotations != nil {
+		s.annotations.mu.Lock()
+		defer s.annotations.mu.Unlock()
+		for key, annotations := range s.annotations.annotations {
+			backup.Annotations[key] = *annotations
+		}
+	}
+	if s.downtime != nil {
+		s.downtime.mu.Lock()
+		defer s.downtime.mu.Unlock()
+		for _, window := range s.downtime.windows {
+			backup.Downtime = append(backup.Downtime, *window)
+		}
+		sort.Slice(backup.Downtime, func(i, j int) bool {
+			return backup.Downtime[i].ID < backup.Downtime[j].ID
+		})
+	}
+	if s.suppressions != nil {
+		s.suppressions.mu.Lock()
+		defer s.suppressions.mu.Unlock()
+		for _, workload := range s.suppressions.workloads {
+			backup.Suppressions = append(backup.Suppressions, *workload)
+		}
+		sort.Slice(backup.Suppressions, func(i, j int) bool {
+			return backup.Suppressions[i].Key < backup.Suppressions[j].Key
+		})
+	}
+	if s.stats != nil {
+		s.stats.mu.Lock()
+		defer s.stats.mu.Unlock()
+		for key, record := range s.stats.records {
+			backup.Stats[key] = *record
+		}
+	}
+	if s.expected != nil {
+		s.expected.mu.Lock()
+		defer s.expected.mu.Unlock()
+		for _, workload := range s.expected.workloads {
+			backup.Expected = append(backup.Expected, *workload)
+		}
+		sort.Slice(backup.Expected, func(i, j int) bool {
+			return backup.Expected[i].Key < backup.Expected[j].Key
+		})
+	}
+	if s.watchlist != nil {
+		s.watchlist.mu.Lock()
+		defer s.watchlist.mu.Unlock()
+		for _, workload := range s.watchlist.workloads {
+			backup.Watchlist = append(backup.Watchlist, *workload)
+		}
+		sort.Slice(backup.Watchlist, func(i, j int) bool {
+			return backup.Watchlist[i].Key < backup.Watchlist[j].Key
+		})
+	}
+	if s.policies != nil {
+		s.policies.mu.Lock()
+		defer s.policies.mu.Unlock()
+		backup.PolicyVersions = append(backup.PolicyVersions, s.policies.versions[1:]...)
+		backup.PolicyState = s.policies.state
+	}
+	return backup, nil
+}
+
+// restore replaces every section with the contents of a backup, in memory
+// and in the store. The policies must have passed checkPolicies. The
+// restored active policy and suppressions apply from the next poll on; this
+// instance's startup configuration stays policy version 0.
+func (s *Server) restore(backup Backup) error {
+	if h := s.history; h != nil {
+		events := append([]HistoryEvent(nil), backup.History...)
+		sort.SliceStable(events, func(i, j int) bool { return events[i].Time.Before(events[j].Time) })
+
+		h.mu.Lock()
+		h.events = events
+		h.prune(time.Now())
+		records := make([]interface{}, len(h.events))
+		for i := range h.events {
+			records[i] = h.events[i]
+		}
+		err := h.store.Rewrite(historyBucket, records)
+		h.mu.Unlock()
+		if err != nil {
+			return fmt.Errorf("failed to persist history: %w", err)
+		}
+	}
+
+	if a := s.audit; a != nil {
+		a.mu.Lock()
+		a.entries = append([]AuditEntry(nil), backup.Audit...)
+		records := make([]interface{}, len(a.entries))
+		for i := range a.entries {
+			records[i] = a.entries[i]
+		}
+		err := a.store.Rewrite(auditBucket, records)
+		a.mu.Unlock()
+		if err != nil {
+			return fmt.Errorf("failed to persist audit log: %w", err)
+		}
+	}
+
+	if a := s.acks; a != nil {
+		a.mu.Lock()
+		a.acks = make(map[string]*Acknowledgement, len(backup.Acknowledgements))
+		for i := range backup.Acknowledgements {
+			ack := backup.Acknowledgements[i]
+			a.acks[ack.Key] = &ack
+		}
+		err := a.store.SaveDoc(acksDoc, a.acks)
+		a.mu.Unlock()
+		if err != nil {
+			return fmt.Errorf("failed to persist acknowledgements: %w", err)
+		}
+	}
+
+	if a := s.annotations; a != nil {
+		a.mu.Lock()
+		a.annotations = make(map[string]*Annotations, len(backup.Annotations))
+		for key := range backup.Annotations {
+			annotations := backup.Annotations[key]
+			a.annotations[key] = &annotations
+		}
+		err := a.store.SaveDoc(annotationsDoc, a.annotations)
+		a.mu.Unlock()
+		if err != nil {
+			return fmt.Errorf("failed to persist annotations: %w", err)
+		}
+	}
+
+	if d := s.downtime; d != nil {
+		d.mu.Lock()
+		d.windows = make(map[string]*DowntimeWindow, len(backup.Downtime))
+		for i := range backup.Downtime {
+			window := backup.Downtime[i]
+			d.windows[window.ID] = &window
+		}
+		err := d.store.SaveDoc(downtimeDoc, d.windows)
+		d.mu.Unlock()
+		if err != nil {
+			return fmt.Errorf("failed to persist planned downtime: %w", err)
+		}
+	}
+
+	if ss := s.suppressions; ss != nil {
+		ss.mu.Lock()
+		ss.workloads = make(map[string]*SuppressedWorkload, len(backup.Suppressions))
+		for i := range backup.Suppressions {
+			workload := backup.Suppressions[i]
+			ss.workloads[workload.Key] = &workload
+		}
+		err := ss.store.SaveDoc(suppressionsDoc, ss.workloads)
+		ss.mu.Unlock()
+		if err != nil {
+			return fmt.Errorf("failed to persist suppressions: %w", err)
+		}
+	}
+
+	if ws := s.stats; ws != nil {
+		ws.mu.Lock()
+		ws.records = make(map[string]*workloadRecord, len(backup.Stats))
+		for key := range backup.Stats {
+			record := backup.Stats[key]
+			ws.records[key] = &record
+		}
+		err := ws.store.SaveDoc(workloadStatsDoc, ws.records)
+		ws.mu.Unlock()
+		if err != nil {
+			return fmt.Errorf("failed to persist workload stats: %w", err)
+		}
+	}
+
+	if e := s.expected; e != nil {
+		e.mu.Lock()
+		e.workloads = make(map[string]*ExpectedWorkload, len(backup.Expected))
+		for i := range backup.Expected {
+			workload := backup.Expected[i]
+			e.workloads[workload.Key] = &workload
+		}
+		err := e.store.SaveDoc(expectedWorkloadsDoc, e.workloads)
+		e.mu.Unlock()
+		if err != nil {
+			return fmt.Errorf("failed to persist expected workloads: %w", err)
+		}
+	}
+
+	if wl := s.watchlist; wl != nil {
+		wl.mu.Lock()
+		wl.workloads = make(map[string]*WatchedWorkload, len(backup.Watchlist))
+		for i := range backup.Watchlist {
+			workload := backup.Watchlist[i]
+			wl.workloads[workload.Key] = &workload
+		}
+		err := wl.store.SaveDoc(watchlistDoc, wl.workloads)
+		wl.mu.Unlock()
+		if err != nil {
+			return fmt.Errorf("failed to persist watchlist: %w", err)
+		}
+	}
+
+	if p := s.policies; p != nil {
+		versions := append([]PolicyVersion(nil), backup.PolicyVersions...)
+		sort.Slice(versions, func(i, j int) bool { return versions[i].Version < versions[j].Version })
+		records := make([]interface{}, len(versions))
+		for i := range versions {
+			records[i] = versions[i]
+		}
+
+		p.mu.Lock()
+		p.versions = append(p.versions[:1:1], versions...)
+		p.state = backup.PolicyState
+		p.report = nil
+		err := p.store.Rewrite(policyVersionsBucket, records)
+		if err == nil {
+			err = p.store.SaveDoc(policyStateDoc, p.state)
+		}
+		p.mu.Unlock()
+		if err != nil {
+			return fmt.Errorf("failed to persist policy versions: %w", err)
+		}
+	}
+
+	if a := s.access; a != nil {
+		entries := append([]AccessEntry(nil), backup.Access...)
+		if a.store != nil {
+			records := make([]interface{}, len(entries))
+			for i := range entries {
+				records[i] = entries[i]
+			}
+			if err := a.store.Rewrite(accessBucket, records); err != nil {
+				return fmt.Errorf("failed to persist access log: %w", err)
+			}
+		} else {
+			if len(entries) > maxRecentAccess {
+				entries = entries[len(entries)-maxRecentAccess:]
+			}
+			a.mu.Lock()
+			a.recent = entries
+			a.mu.Unlock()
+		}
+	}
+
+	return nil
+}
+
+// requireAdmin rejects callers without the admin role. Under an RBAC policy
+// rbacMiddleware has already checked the route's admin permission instead.
+func requireAdmin(w http.ResponseWriter, r *http.Request) (*Identity, bool) {
+	identity := identityFromContext(r.Context())
+	if identity == nil {
+		http.Error(w, "administrative endpoints require an authenticated identity", http.StatusUnauthorized)
+		return nil, false
+	}
+	if identity.permissions == nil && !identity.hasRole(adminRole) {
+		http.Error(w, "admin role required", http.StatusForbidden)
+		return nil, false
+	}
+	return identity, true
+}
+
+// handleBackup downloads a consistent snapshot of the compliance records and
+// operator state, as listed by Backup
+// POST /api/admin/backup
+func (s *Server) handleBackup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	identity, ok := requireAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	backup, err := s.snapshot(r.Context())
+	if err != nil {
+		log.Printf("Failed to take backup: %v", err)
+		http.Error(w, "failed to take backup", http.StatusInternalServerError)
+		return
+	}
+	keys := make(map[string]bool)
+	for _, event := range backup.History {
+		keys[event.Key] = true
+	}
+	for _, ack := range backup.Acknowledgements {
+		keys[ack.Key] = true
+	}
+	for key := range backup.Stats {
+		keys[key] = true
+	}
+	for key := range keys {
+		noteWorkloadKeys(r, key)
+	}
+	s.audit.RecordRequest(r, identity.Name, "admin.backup", "store", fmt.Sprintf("%d history events, %d audit entries", len(backup.History), len(backup.Audit)))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="dashboard-backup-%s.json"`, backup.CreatedAt.UTC().Format("20060102T150405Z")))
+	json.NewEncoder(w).Encode(backup)
+}
+
+// handleRestore replaces the dashboard's compliance records with a backup
+// POST /api/admin/restore
+func (s *Server) handleRestore(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	identity, ok := requireAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	var backup Backup
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxBackupSize)).Decode(&backup); err != nil {
+		http.Error(w, "invalid backup archive", http.StatusBadRequest)
+		return
+	}
+	if backup.Version < 1 || backup.Version > backupVersion {
+		http.Error(w, fmt.Sprintf("unsupported backup version %d", backup.Version), http.StatusBadRequest)
+		return
+	}
+	if err := backup.checkPolicies(); err != nil {
+		http.Error(w, "invalid backup archive: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.restore(backup); err != nil {
+		log.Printf("Failed to restore backup: %v", err)
+		http.Error(w, "failed to restore backup", http.StatusInternalServerError)
+		return
+	}
+
+	// Recorded after the restore so the entry survives in the restored log
+	s.audit.RecordRequest(r, identity.Name, "admin.restore", "store", fmt.Sprintf("backup from %s", backup.CreatedAt.Format(time.RFC3339)))
+	log.Printf("Restored backup from %s: %d history events, %d audit entries, %d acknowledgements, %d access entries",
+		backup.CreatedAt.Format(time.RFC3339), len(backup.History), len(backup.Audit), len(backup.Acknowledgements), len(backup.Access))
+
+	w.WriteHeader(http.StatusNoContent)
+}
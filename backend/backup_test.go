@@ -0,0 +1,283 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newBackupTestServer(t *testing.T) *Server {
+	t.Helper()
+	store, err := openStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	history, _ := newHistory(store, 0)
+	audit, _ := newAuditLog(store)
+	acks, _ := newAckStore(store, time.Hour, 4*time.Hour)
+	access, _ := newAccessLog(store, 0)
+	annotations, _ := newAnnotationStore(store)
+	downtime, _ := newDowntimeStore(store)
+	suppressions, _ := newSuppressionStore(store)
+	stats, _ := newWorkloadStats(store, history)
+	expected, _ := newExpectedWorkloadStore(store)
+	watchlist, _ := newWatchlist(store)
+	policies, _ := newPolicyStore(store, Policy{})
+	return &Server{history: history, audit: audit, acks: acks, access: access, annotations: annotations, downtime: downtime, suppressions: suppressions,
+		stats: stats, expected: expected, watchlist: watchlist, policies: policies}
+}
+
+// backupAndRestore takes a backup of source and restores it on target
+func backupAndRestore(t *testing.T, source, target *Server) Backup {
+	t.Helper()
+	admin := &Identity{Name: "sre", Roles: []string{adminRole}}
+
+	w := httptest.NewRecorder()
+	source.handleBackup(w, ackRequestAs(admin, "POST", "/api/admin/backup", ""))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	archive := w.Body.String()
+	var backup Backup
+	if err := json.Unmarshal([]byte(archive), &backup); err != nil {
+		t.Fatalf("Failed to decode backup: %v", err)
+	}
+
+	w = httptest.NewRecorder()
+	target.handleRestore(w, ackRequestAs(admin, "POST", "/api/admin/restore", archive))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+	return backup
+}
+
+// TestBackupRestore tests that a backup taken on one server restores its
+// history, audit log and acknowledgements on another
+func TestBackupRestore(t *testing.T) {
+	admin := &Identity{Name: "sre", Roles: []string{adminRole}}
+	now := time.Now()
+
+	source := newBackupTestServer(t)
+	source.history.Record([]HistoryEvent{{Time: now, Key: "icu/ai-model", Type: "added", Status: failedStatus("icu", "ai-model")}})
+	source.audit.Record("raj", "ack.create", "icu/ai-model", "investigating")
+	source.acks.Set(Acknowledgement{Key: "icu/ai-model", By: "raj", CreatedAt: now, ExpiresAt: now.Add(time.Hour)})
+
+	w := httptest.NewRecorder()
+	source.handleBackup(w, ackRequestAs(admin, "POST", "/api/admin/backup", ""))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	archive := w.Body.Bytes()
+
+	var backup Backup
+	if err := json.Unmarshal(archive, &backup); err != nil {
+		t.Fatalf("Failed to decode backup: %v", err)
+	}
+	if backup.Version != backupVersion || len(backup.History) != 1 || len(backup.Audit) != 1 || len(backup.Acknowledgements) != 1 {
+		t.Fatalf("Unexpected backup contents %+v", backup)
+	}
+
+	target := newBackupTestServer(t)
+	target.audit.Record("someone", "ack.delete", "icu/other", "")
+	w = httptest.NewRecorder()
+	target.handleRestore(w, ackRequestAs(admin, "POST", "/api/admin/restore", string(archive)))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if events := target.history.Events(time.Time{}, now.Add(time.Minute)); len(events) != 1 || events[0].Key != "icu/ai-model" {
+		t.Errorf("Expected restored history, got %+v", events)
+	}
+	if ack := target.acks.Get("icu/ai-model"); ack == nil || ack.By != "raj" {
+		t.Errorf("Expected restored acknowledgement, got %+v", ack)
+	}
+	entries := target.audit.Entries(time.Time{})
+	if len(entries) != 2 || entries[0].Action != "ack.create" || entries[1].Action != "admin.restore" {
+		t.Errorf("Expected restored audit log followed by the restore, got %+v", entries)
+	}
+
+	// The restore is persisted: a fresh load from the store sees it
+	reloaded, _ := newAuditLog(target.audit.store)
+	if len(reloaded.Entries(time.Time{})) != 2 {
+		t.Errorf("Expected restored audit log to be persisted, got %+v", reloaded.Entries(time.Time{}))
+	}
+}
+
+// TestBackupAccessLog tests that the access log survives a backup and
+// restore, in the store as well as in memory
+func TestBackupAccessLog(t *testing.T) {
+	source, target := newBackupTestServer(t), newBackupTestServer(t)
+	entry := AccessEntry{Time: time.Now().Truncate(time.Second), Identity: "raj", Method: "GET", Path: "/api/workload/icu/pacs", Status: http.StatusOK, Workloads: []string{"icu/pacs"}}
+	source.access.Record(entry)
+	target.access.Record(AccessEntry{Time: time.Now(), Identity: "someone"})
+
+	if backup := backupAndRestore(t, source, target); len(backup.Access) != 1 {
+		t.Fatalf("Expected the access entry in the backup, got %+v", backup.Access)
+	}
+	reloaded, _ := newAccessLog(target.access.store, 0)
+	entries, _ := reloaded.Entries(context.Background(), time.Time{}, "")
+	if len(entries) != 1 || !entries[0].Time.Equal(entry.Time) || entries[0].Identity != "raj" || entries[0].Workloads[0] != "icu/pacs" {
+		t.Errorf("Expected the restored access log persisted, got %+v", entries)
+	}
+
+	memory, _ := newAccessLog(nil, 0)
+	target.access = memory
+	backupAndRestore(t, source, target)
+	if entries, _ := memory.Entries(context.Background(), time.Time{}, ""); len(entries) != 1 || entries[0].Identity != "raj" {
+		t.Errorf("Expected the access log restored in memory, got %+v", entries)
+	}
+}
+
+// TestBackupAnnotations tests that annotations survive a backup and restore
+// and replace the target's
+func TestBackupAnnotations(t *testing.T) {
+	source, target := newBackupTestServer(t), newBackupTestServer(t)
+	updated := time.Now().Truncate(time.Second)
+	source.annotations.Set("icu/pacs", &Annotations{Notes: "vendor patch pending", Labels: map[string]string{"owner": "imaging"}, UpdatedBy: "raj", UpdatedAt: updated})
+	target.annotations.Set("icu/other", &Annotations{Notes: "stale"})
+
+	if backup := backupAndRestore(t, source, target); len(backup.Annotations) != 1 {
+		t.Fatalf("Expected the annotations in the backup, got %+v", backup.Annotations)
+	}
+	if target.annotations.Get("icu/other") != nil {
+		t.Error("Expected the target's annotations replaced")
+	}
+	reloaded, _ := newAnnotationStore(target.annotations.store)
+	got := reloaded.Get("icu/pacs")
+	if got == nil || got.Notes != "vendor patch pending" || got.Labels["owner"] != "imaging" || !got.UpdatedAt.Equal(updated) {
+		t.Errorf("Expected the restored annotations persisted, got %+v", got)
+	}
+}
+
+// TestBackupDowntime tests that planned downtime windows survive a backup
+// and restore and replace the target's
+func TestBackupDowntime(t *testing.T) {
+	source, target := newBackupTestServer(t), newBackupTestServer(t)
+	start := time.Now().Add(-48 * time.Hour).Truncate(time.Second)
+	source.downtime.Add(DowntimeWindow{ID: "a1", Start: start, End: start.Add(2 * time.Hour), Namespaces: []string{"icu"}, Reason: "firmware update", CreatedBy: "raj"})
+	target.downtime.Add(DowntimeWindow{ID: "b2", Start: start, End: start.Add(time.Hour)})
+
+	if backup := backupAndRestore(t, source, target); len(backup.Downtime) != 1 {
+		t.Fatalf("Expected the downtime window in the backup, got %+v", backup.Downtime)
+	}
+	reloaded, _ := newDowntimeStore(target.downtime.store)
+	windows := reloaded.List()
+	if len(windows) != 1 || windows[0].ID != "a1" || !windows[0].Start.Equal(start) || windows[0].Namespaces[0] != "icu" || windows[0].Reason != "firmware update" {
+		t.Errorf("Expected the restored downtime persisted in place of the target's, got %+v", windows)
+	}
+}
+
+// TestBackupSuppressions tests that suppressions survive a backup and
+// restore and replace the target's
+func TestBackupSuppressions(t *testing.T) {
+	source, target := newBackupTestServer(t), newBackupTestServer(t)
+	source.suppressions.Set(SuppressedWorkload{Key: "icu/old-pacs", Namespace: "icu", Name: "old-pacs", Reason: "replaced by icu/pacs", SuppressedBy: "raj", SuppressedAt: time.Now().Truncate(time.Second)})
+	target.suppressions.Set(SuppressedWorkload{Key: "icu/other", SuppressedBy: "someone"})
+
+	if backup := backupAndRestore(t, source, target); len(backup.Suppressions) != 1 {
+		t.Fatalf("Expected the suppression in the backup, got %+v", backup.Suppressions)
+	}
+	reloaded, _ := newSuppressionStore(target.suppressions.store)
+	if !reloaded.Suppressed("icu/old-pacs") || reloaded.Suppressed("icu/other") {
+		t.Errorf("Expected the restored suppressions persisted in place of the target's, got %+v", reloaded.List())
+	}
+}
+
+// TestBackupOperatorState tests that workload stats, expected workloads, the
+// watchlist and policy versions survive a backup and restore
+func TestBackupOperatorState(t *testing.T) {
+	source, target := newBackupTestServer(t), newBackupTestServer(t)
+	now := time.Now().Truncate(time.Second)
+	source.stats.Record([]HistoryEvent{{Time: now.Add(-time.Hour), Key: "icu/pacs", Type: "added", Status: failedStatus("icu", "pacs")}})
+	source.expected.Set(ExpectedWorkload{Key: "icu/pacs", Namespace: "icu", Name: "pacs", RegisteredBy: "raj", RegisteredAt: now})
+	source.watchlist.Set(WatchedWorkload{Key: "icu/monitor", Namespace: "icu", Name: "monitor", PinnedBy: "raj", PinnedAt: now})
+	coco := "coco"
+	version, _ := source.policies.Create(Policy{AR4SIProfile: &coco}, "require CoCo claims", "sre")
+	source.policies.Activate(version.Version)
+	target.watchlist.Set(WatchedWorkload{Key: "icu/other", PinnedBy: "someone"})
+
+	backup := backupAndRestore(t, source, target)
+	if len(backup.Stats) != 1 || len(backup.Expected) != 1 || len(backup.Watchlist) != 1 || len(backup.PolicyVersions) != 1 {
+		t.Fatalf("Unexpected backup contents %+v", backup)
+	}
+
+	store := target.history.store
+	stats, _ := newWorkloadStats(store, target.history)
+	detail := WorkloadStatus{Namespace: "icu", Name: "pacs"}
+	stats.apply(&detail)
+	if detail.TotalViolations != 1 || detail.FirstSeen == nil || !detail.FirstSeen.Equal(now.Add(-time.Hour)) {
+		t.Errorf("Expected the lifetime record restored, got %+v", detail)
+	}
+	if expected, _ := newExpectedWorkloadStore(store); len(expected.List()) != 1 || expected.List()[0].RegisteredBy != "raj" {
+		t.Errorf("Expected the expected workloads restored, got %+v", expected.List())
+	}
+	if watchlist, _ := newWatchlist(store); !watchlist.Pinned("icu/monitor", now) || watchlist.Pinned("icu/other", now) {
+		t.Errorf("Expected the watchlist restored in place of the target's, got %+v", watchlist.List(now))
+	}
+	policies, err := newPolicyStore(store, Policy{})
+	if err != nil {
+		t.Fatalf("Failed to reload policy versions: %v", err)
+	}
+	if profile, _ := policies.active(); profile != "coco" {
+		t.Errorf("Expected the restored policy version active, got profile %q", profile)
+	}
+}
+
+// TestRestoreOlderBackup tests that an archive of an earlier format restores,
+// with the sections it lacks empty, and that one whose policy state refers
+// to a missing version is rejected
+func TestRestoreOlderBackup(t *testing.T) {
+	admin := &Identity{Name: "sre", Roles: []string{adminRole}}
+	target := newBackupTestServer(t)
+	target.annotations.Set("icu/pacs", &Annotations{Notes: "stale"})
+
+	archive := `{"version":1,"created_at":"2024-05-01T00:00:00Z","history":[],"audit":[],"acknowledgements":[{"key":"icu/pacs","by":"raj"}]}`
+	w := httptest.NewRecorder()
+	target.handleRestore(w, ackRequestAs(admin, "POST", "/api/admin/restore", archive))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Expected a version 1 archive to restore, got %d: %s", w.Code, w.Body.String())
+	}
+	if target.acks.Get("icu/pacs") == nil || target.annotations.Get("icu/pacs") != nil {
+		t.Error("Expected the acknowledgement restored and the missing annotations empty")
+	}
+
+	w = httptest.NewRecorder()
+	target.handleRestore(w, ackRequestAs(admin, "POST", "/api/admin/restore", `{"version":6,"policy_state":{"active":3}}`))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an active policy version not in the archive, got %d", w.Code)
+	}
+}
+
+// TestBackupRequiresAdmin tests access control and input checks on the admin endpoints
+func TestBackupRequiresAdmin(t *testing.T) {
+	server := newBackupTestServer(t)
+
+	w := httptest.NewRecorder()
+	server.handleBackup(w, ackRequestAs(nil, "POST", "/api/admin/backup", ""))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for anonymous backup, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	server.handleRestore(w, ackRequestAs(&Identity{Name: "raj", Roles: []string{"operator"}}, "POST", "/api/admin/restore", "{}"))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for non-admin restore, got %d", w.Code)
+	}
+
+	admin := &Identity{Name: "sre", Roles: []string{adminRole}}
+	w = httptest.NewRecorder()
+	server.handleBackup(w, ackRequestAs(admin, "GET", "/api/admin/backup", ""))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405 for GET backup, got %d", w.Code)
+	}
+
+	body, _ := json.Marshal(Backup{Version: 99})
+	w = httptest.NewRecorder()
+	server.handleRestore(w, ackRequestAs(admin, "POST", "/api/admin/restore", string(body)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for unknown backup version, got %d", w.Code)
+	}
+}
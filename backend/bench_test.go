@@ -0,0 +1,208 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+// benchCollector serves n reports, flipping the verdict of every 100th
+// workload on each request so every cycle produces transitions
+func benchCollector(n int) *httptest.Server {
+	cycle := 0
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cycle++
+		reports := make([]CollectorReport, n)
+		now := time.Now()
+		for i := range reports {
+			reports[i] = CollectorReport{
+				PodName:     fmt.Sprintf("workload-%05d", i),
+				Namespace:   fmt.Sprintf("ns-%03d", i%20),
+				TEEType:     "tdx",
+				Attested:    i%100 != 0 || cycle%2 == 0,
+				TrustVector: &TrustVector{InstanceIdentity: 2, Configuration: 2, Executables: 2, Hardware: 2},
+				Timestamp:   now,
+			}
+		}
+		json.NewEncoder(w).Encode(reports)
+	}))
+}
+
+// benchServer returns a server whose cache holds n workloads
+func benchServer(b *testing.B, n int) (*Server, func()) {
+	b.Helper()
+	collector := benchCollector(n)
+	server := &Server{
+		collectorURL: collector.URL,
+		statusCache:  make(map[string]*WorkloadStatus),
+		httpClient:   &http.Client{Timeout: 10 * time.Second},
+		metrics:      newMetrics(),
+	}
+	server.fetchFromCollector()
+	if len(server.statusCache) != n {
+		b.Fatalf("Expected %d workloads, got %d", n, len(server.statusCache))
+	}
+	return server, collector.Close
+}
+
+// benchStatuses returns n statuses for a poll cycle; every 100th workload
+// fails in odd cycles
+func benchStatuses(n int, cycle int) []*WorkloadStatus {
+	statuses := make([]*WorkloadStatus, n)
+	for i := range statuses {
+		if i%100 == 0 && cycle%2 == 1 {
+			statuses[i] = failedStatus(fmt.Sprintf("ns-%03d", i%20), fmt.Sprintf("workload-%05d", i))
+		} else {
+			statuses[i] = verifiedStatus(fmt.Sprintf("ns-%03d", i%20), fmt.Sprintf("workload-%05d", i))
+		}
+	}
+	return statuses
+}
+
+// BenchmarkFetchFromCollector measures a whole poll cycle: fetching,
+// decoding, converting and applying the reports of 1000 workloads
+func BenchmarkFetchFromCollector(b *testing.B) {
+	server, closeCollector := benchServer(b, 1000)
+	defer closeCollector()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		server.fetchFromCollector()
+	}
+}
+
+// BenchmarkApplyStatuses measures replacing the cache, by cache size
+func BenchmarkApplyStatuses(b *testing.B) {
+	for _, n := range []int{1000, 10000} {
+		b.Run(fmt.Sprint(n), func(b *testing.B) { benchApplyStatuses(b, n) })
+	}
+}
+
+func benchApplyStatuses(b *testing.B, n int) {
+	server := &Server{statusCache: make(map[string]*WorkloadStatus)}
+	synced := map[string]bool{"": true}
+	cycles := [][]*WorkloadStatus{benchStatuses(n, 0), benchStatuses(n, 1)}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// applyStatuses keeps the statuses it is given, so each cycle gets
+		// fresh copies
+		b.StopTimer()
+		statuses := make([]*WorkloadStatus, n)
+		for j, status := range cycles[i%2] {
+			statuses[j] = copyStatus(status)
+		}
+		b.StartTimer()
+		server.applyStatuses(statuses, synced, nil)
+	}
+}
+
+// BenchmarkHandleStatus measures concurrent GET /api/status with 1000
+// workloads cached
+func BenchmarkHandleStatus(b *testing.B) {
+	server, closeCollector := benchServer(b, 1000)
+	defer closeCollector()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			w := httptest.NewRecorder()
+			server.handleStatus(w, httptest.NewRequest("GET", "/api/status", nil))
+			if w.Code != http.StatusOK {
+				b.Fatalf("Expected status 200, got %d", w.Code)
+			}
+		}
+	})
+}
+
+// BenchmarkHandleWorkloadDetail measures concurrent single workload
+// lookups with 1000 workloads cached
+func BenchmarkHandleWorkloadDetail(b *testing.B) {
+	server, closeCollector := benchServer(b, 1000)
+	defer closeCollector()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			i++
+			w := httptest.NewRecorder()
+			path := fmt.Sprintf("/api/workload/ns-%03d/workload-%05d", i%1000%20, i%1000)
+			server.handleWorkloadDetail(w, httptest.NewRequest("GET", path, nil))
+			if w.Code != http.StatusOK {
+				b.Fatalf("Expected status 200 for %s, got %d", path, w.Code)
+			}
+		}
+	})
+}
+
+// BenchmarkStatusDuringPolls measures GET /api/status while poll cycles
+// hold the cache lock, the contention the cache lock design has to handle
+func BenchmarkStatusDuringPolls(b *testing.B) {
+	server, closeCollector := benchServer(b, 1000)
+	defer closeCollector()
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				server.fetchFromCollector()
+			}
+		}
+	}()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			w := httptest.NewRecorder()
+			server.handleStatus(w, httptest.NewRequest("GET", "/api/status", nil))
+		}
+	})
+	b.StopTimer()
+	close(stop)
+	<-done
+}
+
+// perfBudgetPath lists the slowest acceptable ns/op of each benchmark
+const perfBudgetPath = "testdata/perf-budget.json"
+
+// TestPerformanceBudget runs the benchmarks and fails if any is slower than
+// its budget. It takes a while, so it only runs with PERF_BUDGET=1, e.g. in
+// a CI job of its own: PERF_BUDGET=1 go test -run PerformanceBudget
+func TestPerformanceBudget(t *testing.T) {
+	if os.Getenv("PERF_BUDGET") != "1" {
+		t.Skip("set PERF_BUDGET=1 to check benchmarks against " + perfBudgetPath)
+	}
+	data, err := os.ReadFile(perfBudgetPath)
+	if err != nil {
+		t.Fatalf("Failed to read budget: %v", err)
+	}
+	var budget map[string]int64
+	if err := json.Unmarshal(data, &budget); err != nil {
+		t.Fatalf("Invalid budget: %v", err)
+	}
+
+	benchmarks := map[string]func(*testing.B){
+		"FetchFromCollector":   BenchmarkFetchFromCollector,
+		"ApplyStatuses/1000":   func(b *testing.B) { benchApplyStatuses(b, 1000) },
+		"ApplyStatuses/10000":  func(b *testing.B) { benchApplyStatuses(b, 10000) },
+		"HandleStatus":         BenchmarkHandleStatus,
+		"HandleWorkloadDetail": BenchmarkHandleWorkloadDetail,
+		"StatusDuringPolls":    BenchmarkStatusDuringPolls,
+	}
+	for name, limit := range budget {
+		bench, ok := benchmarks[name]
+		if !ok {
+			t.Errorf("Budget for unknown benchmark %s", name)
+			continue
+		}
+		result := testing.Benchmark(bench)
+		t.Logf("%s: %d ns/op (budget %d)", name, result.NsPerOp(), limit)
+		if result.NsPerOp() > limit {
+			t.Errorf("%s regressed: %d ns/op exceeds the budget of %d", name, result.NsPerOp(), limit)
+		}
+	}
+}
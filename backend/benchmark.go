@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"runtime"
+	"time"
+)
+
+const (
+	// maxBenchmarkReports bounds the synthetic fleet, which is held in
+	// memory alongside the live cache
+	maxBenchmarkReports = 100000
+	maxBenchmarkPolls   = 10
+)
+
+// benchmarkRequest is the body of POST /api/admin/benchmark
+type benchmarkRequest struct {
+	Reports     int     `json:"reports"`                // synthetic workloads, as served per poll
+	Clusters    int     `json:"clusters,omitempty"`     // spread across this many clusters, 1 by default
+	Polls       int     `json:"polls,omitempty"`        // consecutive polls, 3 by default
+	FailureRate float64 `json:"failure_rate,omitempty"` // share of reports failing attestation, redrawn every poll
+}
+
+// benchmarkStage is the cost of one ingest step over all polls
+type benchmarkStage struct {
+	Name       string  `json:"name"`
+	DurationMs float64 `json:"duration_ms"`
+	AllocBytes uint64  `json:"alloc_bytes"`
+	Allocs     uint64  `json:"allocs"`
+}
+
+// benchmarkResult is the response of POST /api/admin/benchmark
+type benchmarkResult struct {
+	Reports                  int              `json:"reports"`
+	Clusters                 int              `json:"clusters"`
+	Polls                    int              `json:"polls"`
+	DurationMs               float64          `json:"duration_ms"`      // all stages of all polls
+	PollDurationMs           float64          `json:"poll_duration_ms"` // mean per poll
+	ReportsPerSecond         float64          `json:"reports_per_second"`
+	AllocBytesPerReport      float64          `json:"alloc_bytes_per_report"`
+	AllocsPerReport          float64          `json:"allocs_per_report"`
+	RetainedBytesPerWorkload float64          `json:"retained_bytes_per_workload"` // approximate, from the heap after GC
+	Events                   int              `json:"events"`                      // history events of the last poll
+	Stages                   []benchmarkStage `json:"stages"`
+	Excluded                 []string         `json:"excluded"`
+}
+
+// benchmarkExcluded are the ingest steps a benchmark skips: they call out to
+// other systems, whose latency is not the dashboard's to size
+var benchmarkExcluded = []string{"kubernetes enrichment", "ownership lookup", "gates", "image policies", "archiving"}
+
+// syntheticReports returns one poll's Collector response for a fleet of
+// n workloads. Verdicts are drawn from rng, so consecutive polls produce
+// the verdict changes a live fleet does.
+func syntheticReports(n, clusters int, failureRate float64, rng *rand.Rand, now time.Time) ([]byte, error) {
+	affirming := &TrustVector{InstanceIdentity: 2, Configuration: 2, Executables: 2, FileSystem: 2, Hardware: 2, RuntimeOpaque: 2, StorageOpaque: 2, SourcedData: 2}
+	reports := make([]CollectorReport, n)
+	for i := range reports {
+		reports[i] = CollectorReport{
+			PodName:     fmt.Sprintf("bench-%06d", i),
+			Namespace:   fmt.Sprintf("bench-%02d", i%50),
+			TEEType:     "SNP",
+			TCBVersion:  "3.7",
+			NodeName:    fmt.Sprintf("bench-node-%03d", i%200),
+			Cluster:     fmt.Sprintf("bench-site-%d", i%clusters),
+			Attested:    rng.Float64() >= failureRate,
+			TrustVector: affirming,
+			Timestamp:   now.Add(-time.Duration(rng.Intn(60)) * time.Second),
+		}
+		if !reports[i].Attested {
+			reports[i].Error = "TEE evidence verification failed"
+		}
+	}
+	return json.Marshal(reports)
+}
+
+// benchmarkRun accumulates stage costs across polls
+type benchmarkRun struct {
+	stages []benchmarkStage
+	index  map[string]int
+}
+
+// measure runs one ingest step and adds its duration and allocations to
+// the step's stage
+func (b *benchmarkRun) measure(name string, step func()) {
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	step()
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+
+	i, ok := b.index[name]
+	if !ok {
+		i = len(b.stages)
+		b.index[name] = i
+		b.stages = append(b.stages, benchmarkStage{Name: name})
+	}
+	b.stages[i].DurationMs += float64(elapsed.Microseconds()) / 1000
+	b.stages[i].AllocBytes += after.TotalAlloc - before.TotalAlloc
+	b.stages[i].Allocs += after.Mallocs - before.Mallocs
+}
+
+// benchmarkIngest runs a synthetic fleet through the ingest path - decoding,
+// schema inspection, deduplication, the enrichment pipeline and the cache
+// diff - with the live policy, but on a scratch cache. Nothing is cached,
+// recorded or sent to subscribers.
+func (s *Server) benchmarkIngest(req benchmarkRequest) (benchmarkResult, error) {
+	s.cacheMutex.RLock()
+	evaluator := &Server{ar4siProfile: s.ar4siProfile, clockSkew: s.clockSkew, localCluster: s.localCluster}
+	s.cacheMutex.RUnlock()
+
+	var baseline runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&baseline)
+
+	run := &benchmarkRun{index: make(map[string]int)}
+	cache := make(map[string]*WorkloadStatus)
+	var events []HistoryEvent
+	for poll := 0; poll < req.Polls; poll++ {
+		now := time.Now()
+		body, err := syntheticReports(req.Reports, req.Clusters, req.FailureRate, rand.New(rand.NewSource(int64(poll))), now)
+		if err != nil {
+			return benchmarkResult{}, err
+		}
+
+		var raws []json.RawMessage
+		var reports []CollectorReport
+		run.measure("decode", func() {
+			if err = json.NewDecoder(bytes.NewReader(body)).Decode(&raws); err != nil {
+				return
+			}
+			reports = make([]CollectorReport, len(raws))
+			for i, raw := range raws {
+				reportAnomalies(raw)
+				if reports[i], err = decodeCollectorReport(raw); err != nil {
+					return
+				}
+			}
+		})
+		if err != nil {
+			return benchmarkResult{}, fmt.Errorf("synthetic report rejected: %w", err)
+		}
+
+		var conflicts map[string]string
+		run.measure("dedupe", func() {
+			reports, conflicts = evaluator.dedupeReports(reports)
+		})
+
+		statuses := make([]*WorkloadStatus, 0, len(reports))
+		run.measure("enrich", func() {
+			for i := range reports {
+				statuses = append(statuses, evaluator.enrich(&reports[i]))
+			}
+			flagReportConflicts(statuses, conflicts)
+		})
+
+		run.measure("diff", func() {
+			updated := make(map[string]*WorkloadStatus, len(statuses))
+			for _, status := range statuses {
+				updated[status.Namespace+"/"+status.Name] = status
+			}
+			events = diffCaches(cache, updated, now)
+			cache = updated
+		})
+	}
+
+	var retained runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&retained)
+	runtime.KeepAlive(cache)
+
+	result := benchmarkResult{
+		Reports:  req.Reports,
+		Clusters: req.Clusters,
+		Polls:    req.Polls,
+		Events:   len(events),
+		Stages:   run.stages,
+		Excluded: benchmarkExcluded,
+	}
+	var allocBytes, allocs uint64
+	for _, stage := range run.stages {
+		result.DurationMs += stage.DurationMs
+		allocBytes += stage.AllocBytes
+		allocs += stage.Allocs
+	}
+	processed := float64(req.Reports * req.Polls)
+	result.PollDurationMs = result.DurationMs / float64(req.Polls)
+	if result.DurationMs > 0 {
+		result.ReportsPerSecond = processed / (result.DurationMs / 1000)
+	}
+	result.AllocBytesPerReport = float64(allocBytes) / processed
+	result.AllocsPerReport = float64(allocs) / processed
+	// Live traffic allocates meanwhile, so this is an estimate; a heap that
+	// shrank tells nothing
+	if retained.HeapAlloc > baseline.HeapAlloc {
+		result.RetainedBytesPerWorkload = float64(retained.HeapAlloc-baseline.HeapAlloc) / float64(len(cache))
+	}
+	return result, nil
+}
+
+// handleBenchmark generates a synthetic fleet and runs it through the ingest
+// path, reporting throughput and allocations, so the dashboard can be sized
+// before a large new site is connected. One benchmark runs at a time.
+// POST /api/admin/benchmark
+func (s *Server) handleBenchmark(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	identity, ok := requireAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	var req benchmarkRequest
+	if !decodeValid(w, r, benchmarkSchema, &req) {
+		return
+	}
+	if req.Clusters == 0 {
+		req.Clusters = 1
+	}
+	if req.Polls == 0 {
+		req.Polls = 3
+	}
+	switch {
+	case req.Reports < 1 || req.Reports > maxBenchmarkReports:
+		http.Error(w, fmt.Sprintf("reports must be between 1 and %d", maxBenchmarkReports), http.StatusBadRequest)
+		return
+	case req.Clusters < 1 || req.Clusters > req.Reports:
+		http.Error(w, "clusters must be between 1 and the number of reports", http.StatusBadRequest)
+		return
+	case req.Polls < 1 || req.Polls > maxBenchmarkPolls:
+		http.Error(w, fmt.Sprintf("polls must be between 1 and %d", maxBenchmarkPolls), http.StatusBadRequest)
+		return
+	case req.FailureRate < 0 || req.FailureRate > 1:
+		http.Error(w, "failure_rate must be between 0 and 1", http.StatusBadRequest)
+		return
+	}
+
+	if !s.benchmarking.TryLock() {
+		http.Error(w, "a benchmark is already running", http.StatusConflict)
+		return
+	}
+	defer s.benchmarking.Unlock()
+
+	s.audit.RecordRequest(r, identity.Name, "benchmark.run", "", fmt.Sprintf("%d reports, %d clusters, %d polls", req.Reports, req.Clusters, req.Polls))
+	result, err := s.benchmarkIngest(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestHandleBenchmark tests that a synthetic fleet runs through the ingest
+// path and is reported on, without touching the live cache
+func TestHandleBenchmark(t *testing.T) {
+	audit, _ := newAuditLog(nil)
+	server := &Server{statusCache: make(map[string]*WorkloadStatus), audit: audit, localCluster: "local"}
+	admin := &Identity{Name: "ops", Roles: []string{adminRole}}
+
+	tests := []struct {
+		identity *Identity
+		body     string
+		want     int
+	}{
+		{&Identity{Name: "raj"}, `{"reports":10}`, http.StatusForbidden},
+		{admin, `{}`, http.StatusBadRequest},
+		{admin, `{"reports":0}`, http.StatusBadRequest},
+		{admin, `{"reports":1000000}`, http.StatusBadRequest},
+		{admin, `{"reports":10,"clusters":11}`, http.StatusBadRequest},
+		{admin, `{"reports":10,"failure_rate":2}`, http.StatusBadRequest},
+		{admin, `{"reports":10,"polls":100}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		server.handleBenchmark(w, ackRequestAs(tt.identity, "POST", "/api/admin/benchmark", tt.body))
+		if w.Code != tt.want {
+			t.Errorf("%s: expected %d, got %d: %s", tt.body, tt.want, w.Code, w.Body.String())
+		}
+	}
+
+	w := httptest.NewRecorder()
+	server.handleBenchmark(w, ackRequestAs(admin, "POST", "/api/admin/benchmark", `{"reports":500,"clusters":4,"polls":2,"failure_rate":0.2}`))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var result benchmarkResult
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if result.Reports != 500 || result.Clusters != 4 || result.Polls != 2 || len(result.Stages) != 4 {
+		t.Errorf("Unexpected result %+v", result)
+	}
+	if result.ReportsPerSecond <= 0 || result.AllocsPerReport <= 0 {
+		t.Errorf("Expected throughput and allocation stats, got %+v", result)
+	}
+	// The second poll redraws verdicts, so some workloads change
+	if result.Events == 0 || result.Events == 500 {
+		t.Errorf("Expected verdict changes on the second poll only, got %d events", result.Events)
+	}
+	if len(server.statusCache) != 0 {
+		t.Errorf("Expected the live cache untouched, got %d entries", len(server.statusCache))
+	}
+}
+
+// TestSyntheticReportsValid tests that synthetic reports pass the schema
+// and the evidence checks, so a benchmark measures accepted reports
+func TestSyntheticReportsValid(t *testing.T) {
+	body, err := syntheticReports(20, 3, 0, rand.New(rand.NewSource(1)), time.Now())
+	if err != nil {
+		t.Fatalf("Failed to generate reports: %v", err)
+	}
+	var raws []json.RawMessage
+	if err := json.Unmarshal(body, &raws); err != nil {
+		t.Fatalf("Failed to decode reports: %v", err)
+	}
+	server := &Server{}
+	for i, raw := range raws {
+		report, err := decodeCollectorReport(raw)
+		if err != nil {
+			t.Fatalf("Report %d rejected: %v", i, err)
+		}
+		if status := server.convertCollectorReport(report); status.AttestationStatus != "verified" {
+			t.Errorf("Expected report %d verified, got %s: %s", i, status.AttestationStatus, status.Details)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"sort"
+	"unicode/utf8"
+)
+
+// truncationBudgets are the successively smaller lengths free-text fields are
+// cut to when a cache entry exceeds maxEntryBytes
+var truncationBudgets = []int{1024, 256, 64}
+
+// cacheLimits bounds the memory held by the status cache in clusters where
+// thousands of short-lived pods churn through. Zero disables a limit.
+type cacheLimits struct {
+	maxWorkloads  int // entries beyond this are evicted, least recently checked first
+	maxEntryBytes int // encoded size above which an entry's free text is truncated
+}
+
+// fitEntry truncates the free-text fields of a status until its encoded size
+// is within maxEntryBytes. Returns whether anything was truncated.
+func (l cacheLimits) fitEntry(status *WorkloadStatus) bool {
+	if l.maxEntryBytes <= 0 || entrySize(status) <= l.maxEntryBytes {
+		return false
+	}
+
+	for _, budget := range truncationBudgets {
+		status.Details = truncateText(status.Details, budget)
+		for i := range status.Gates {
+			status.Gates[i].Details = truncateText(status.Gates[i].Details, budget)
+		}
+		for i := range status.FailedChecks {
+			status.FailedChecks[i].Expected = truncateText(status.FailedChecks[i].Expected, budget)
+			status.FailedChecks[i].Actual = truncateText(status.FailedChecks[i].Actual, budget)
+		}
+		if entrySize(status) <= l.maxEntryBytes {
+			return true
+		}
+	}
+
+	log.Printf("Workload %s/%s exceeds %d bytes after truncation", status.Namespace, status.Name, l.maxEntryBytes)
+	return true
+}
+
+// evict removes the least recently checked entries until the cache holds at
+// most maxWorkloads. Workloads that vanished with an unreachable cluster go
+// first, as their entries stopped being refreshed. Returns the evicted keys.
+func (l cacheLimits) evict(cache map[string]*WorkloadStatus) []string {
+	if l.maxWorkloads <= 0 || len(cache) <= l.maxWorkloads {
+		return nil
+	}
+
+	keys := make([]string, 0, len(cache))
+	for key := range cache {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := cache[keys[i]].LastChecked, cache[keys[j]].LastChecked
+		if !a.Equal(b) {
+			return a.Before(b)
+		}
+		return keys[i] < keys[j]
+	})
+
+	evicted := keys[:len(keys)-l.maxWorkloads]
+	for _, key := range evicted {
+		delete(cache, key)
+	}
+	return evicted
+}
+
+// limitEntry applies the entry size limit to a status about to be cached
+func (s *Server) limitEntry(status *WorkloadStatus) {
+	if s.cacheLimits.fitEntry(status) {
+		s.metrics.Inc("dashboard_cache_truncations_total", "Cache entries whose details were truncated to fit the entry size limit.")
+	}
+}
+
+// evictEntries applies the workload limit to a freshly built cache
+func (s *Server) evictEntries(cache map[string]*WorkloadStatus) {
+	if evicted := s.cacheLimits.evict(cache); len(evicted) > 0 {
+		log.Printf("Evicted %d workloads from the status cache (limit %d)", len(evicted), s.cacheLimits.maxWorkloads)
+		s.metrics.Add("dashboard_cache_evictions_total", "Workloads evicted from the status cache by the size limit.", float64(len(evicted)))
+	}
+}
+
+// entrySize returns the encoded size of a cache entry
+func entrySize(status *WorkloadStatus) int {
+	data, err := json.Marshal(status)
+	if err != nil {
+		return 0
+	}
+	return len(data)
+}
+
+// truncateText shortens s to at most n bytes, marking the cut
+func truncateText(s string, n int) string {
+	const marker = "...(truncated)"
+	if len(s) <= n || n <= len(marker) {
+		return s
+	}
+	cut := n - len(marker)
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + marker
+}
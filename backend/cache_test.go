@@ -0,0 +1,79 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestCacheEviction tests that workloads of an unreachable cluster are
+// evicted before freshly polled ones once the cache is full
+func TestCacheEviction(t *testing.T) {
+	server := &Server{
+		statusCache: make(map[string]*WorkloadStatus),
+		metrics:     newMetrics(),
+		cacheLimits: cacheLimits{maxWorkloads: 3},
+	}
+
+	stale := verifiedStatus("dev", "old")
+	stale.Cluster = "site-b"
+	stale.LastChecked = time.Now().Add(-24 * time.Hour)
+	server.statusCache["dev/old"] = stale
+
+	var statuses []*WorkloadStatus
+	for _, name := range []string{"a", "b", "c"} {
+		status := verifiedStatus("icu", name)
+		status.Cluster = "site-a"
+		status.LastChecked = time.Now()
+		statuses = append(statuses, status)
+	}
+	server.applyStatuses(statuses, map[string]bool{"site-a": true}, nil)
+
+	if len(server.statusCache) != 3 {
+		t.Fatalf("Expected 3 cached workloads, got %d", len(server.statusCache))
+	}
+	if _, ok := server.statusCache["dev/old"]; ok {
+		t.Error("Expected the stale workload to be evicted")
+	}
+	if got := server.metrics.Value("dashboard_cache_evictions_total"); got != 1 {
+		t.Errorf("Expected 1 eviction, got %g", got)
+	}
+}
+
+// TestCacheEntryLimit tests that oversized entries have their details truncated
+func TestCacheEntryLimit(t *testing.T) {
+	limits := cacheLimits{maxEntryBytes: 600}
+
+	status := failedStatus("icu", "ai-model")
+	status.Details = strings.Repeat("x", 5000)
+	status.Gates = []GateResult{{Name: "cmdb", Status: "error", Details: strings.Repeat("y", 5000)}}
+
+	if !limits.fitEntry(status) {
+		t.Fatal("Expected oversized entry to be truncated")
+	}
+	if size := entrySize(status); size > 600 {
+		t.Errorf("Expected entry within 600 bytes, got %d", size)
+	}
+	if !strings.HasSuffix(status.Details, "...(truncated)") {
+		t.Errorf("Expected truncation marker, got %q", status.Details)
+	}
+
+	small := verifiedStatus("icu", "small")
+	if limits.fitEntry(small) {
+		t.Error("Expected small entry to be left alone")
+	}
+	if (cacheLimits{}).fitEntry(status) {
+		t.Error("Expected no truncation without a limit")
+	}
+}
+
+// TestTruncateText tests that truncation doesn't split multi-byte characters
+func TestTruncateText(t *testing.T) {
+	// 7 bytes remain for text, which would split the fourth 2-byte character
+	if got := truncateText(strings.Repeat("é", 20), 21); got != "ééé...(truncated)" {
+		t.Errorf("Unexpected truncation %q", got)
+	}
+	if got := truncateText("short", 21); got != "short" {
+		t.Errorf("Expected short text unchanged, got %q", got)
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/rh-summit-coco/raj-hospital-dashboard/backend/pkg/assets"
+)
+
+// cacheControlMiddleware sets Cache-Control on every response. API
+// responses carry live security posture - and with auth enabled, per-user
+// data - so they are never stored. Fingerprinted assets never change under
+// their name and are cached for a year; everything else, index.html in
+// particular, is revalidated on every load so an upgrade is picked up at once.
+// Handlers may override the header.
+func cacheControlMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/api/"), r.URL.Path == "/metrics", r.URL.Path == "/healthz":
+			w.Header().Set("Cache-Control", "no-store")
+		case assets.FingerprintPattern.MatchString(r.URL.Path):
+			w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		default:
+			w.Header().Set("Cache-Control", "no-cache")
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestCacheControlMiddleware tests the caching policy of API responses,
+// fingerprinted assets and pages
+func TestCacheControlMiddleware(t *testing.T) {
+	handler := cacheControlMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/events" {
+			w.Header().Set("Cache-Control", "no-cache")
+		}
+	}))
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"/api/status", "no-store"},
+		{"/api/workload/icu/ai-model", "no-store"},
+		{"/metrics", "no-store"},
+		{"/", "no-cache"},
+		{"/index.html", "no-cache"},
+		{"/js/app.js", "no-cache"},
+		{"/js/app.3f2a9c1b7d04.js", "public, max-age=31536000, immutable"},
+		{"/api/events", "no-cache"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+		if got := w.Header().Get("Cache-Control"); got != tt.expected {
+			t.Errorf("Expected %q for %s, got %q", tt.expected, tt.path, got)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// chaosConfig is the CHAOS_CONFIG file: the probability of each fault per
+// report received from a Collector. Only builds with the chaos tag accept it.
+type chaosConfig struct {
+	Seed           int64   `json:"seed,omitempty"` // 0 = random
+	Delay          float64 `json:"delay,omitempty"`
+	MaxDelayCycles int     `json:"max_delay_cycles,omitempty"` // default 3
+	Duplicate      float64 `json:"duplicate,omitempty"`
+	Reorder        float64 `json:"reorder,omitempty"` // probability of shuffling a whole batch
+	Corrupt        float64 `json:"corrupt,omitempty"`
+}
+
+// heldReport is a report delayed to a later poll cycle
+type heldReport struct {
+	raw    json.RawMessage
+	cycles int // poll cycles of its cluster left before release
+}
+
+// faultInjector mangles the reports of each Collector response - delaying
+// them to later cycles, duplicating, reordering and corrupting them - to
+// exercise the merge logic, and checks after every cycle that the cache
+// never went back to older data than it already showed.
+type faultInjector struct {
+	config  chaosConfig
+	metrics *Metrics
+
+	mu     sync.Mutex
+	rng    *rand.Rand
+	held   map[string][]heldReport // by cluster
+	seen   map[string]time.Time    // newest report timestamp shown per workload
+	counts map[string]int          // injected faults by kind
+}
+
+// loadFaultInjector reads a CHAOS_CONFIG file
+func loadFaultInjector(path string, metrics *Metrics) (*faultInjector, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var config chaosConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("invalid chaos config: %w", err)
+	}
+	return newFaultInjector(config, metrics)
+}
+
+// newFaultInjector validates a config and creates an injector
+func newFaultInjector(config chaosConfig, metrics *Metrics) (*faultInjector, error) {
+	for name, p := range map[string]float64{"delay": config.Delay, "duplicate": config.Duplicate, "reorder": config.Reorder, "corrupt": config.Corrupt} {
+		if p < 0 || p > 1 {
+			return nil, fmt.Errorf("%s must be a probability between 0 and 1, got %v", name, p)
+		}
+	}
+	if config.MaxDelayCycles <= 0 {
+		config.MaxDelayCycles = 3
+	}
+	if config.Seed == 0 {
+		config.Seed = time.Now().UnixNano()
+	}
+	return &faultInjector{
+		config:  config,
+		metrics: metrics,
+		rng:     rand.New(rand.NewSource(config.Seed)),
+		held:    make(map[string][]heldReport),
+		seen:    make(map[string]time.Time),
+		counts:  make(map[string]int),
+	}, nil
+}
+
+// inject applies faults to the reports of one Collector response, and
+// releases reports of the cluster delayed by earlier cycles
+func (f *faultInjector) inject(cluster string, raws []json.RawMessage) []json.RawMessage {
+	if f == nil {
+		return raws
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var out []json.RawMessage
+	var held []heldReport
+	for _, h := range f.held[cluster] {
+		if h.cycles--; h.cycles > 0 {
+			held = append(held, h)
+			continue
+		}
+		out = append(out, h.raw)
+	}
+
+	for _, raw := range raws {
+		if f.rng.Float64() < f.config.Delay {
+			held = append(held, heldReport{raw: raw, cycles: 1 + f.rng.Intn(f.config.MaxDelayCycles)})
+			f.fault("delay")
+			continue
+		}
+		if f.rng.Float64() < f.config.Corrupt {
+			raw = f.corrupt(raw)
+			f.fault("corrupt")
+		}
+		out = append(out, raw)
+		if f.rng.Float64() < f.config.Duplicate {
+			out = append(out, raw)
+			f.fault("duplicate")
+		}
+	}
+	f.held[cluster] = held
+
+	if len(out) > 1 && f.rng.Float64() < f.config.Reorder {
+		f.rng.Shuffle(len(out), func(i, j int) { out[i], out[j] = out[j], out[i] })
+		f.fault("reorder")
+	}
+	return out
+}
+
+// corrupt damages a report the way a faulty Collector or network could:
+// cut short, with a mistyped verdict, without its identity, or unparseable
+func (f *faultInjector) corrupt(raw json.RawMessage) json.RawMessage {
+	switch f.rng.Intn(4) {
+	case 0:
+		return append(json.RawMessage(nil), raw[:len(raw)/2]...)
+	case 1:
+		damaged := bytes.Replace(raw, []byte(`"attested":true`), []byte(`"attested":"yes"`), 1)
+		return bytes.Replace(damaged, []byte(`"attested":false`), []byte(`"attested":0`), 1)
+	case 2:
+		var fields map[string]interface{}
+		if json.Unmarshal(raw, &fields) != nil {
+			return raw
+		}
+		delete(fields, "pod_name")
+		damaged, _ := json.Marshal(fields)
+		return damaged
+	default:
+		return json.RawMessage(`"\u0000garbage"`)
+	}
+}
+
+// fault counts an injected fault. Caller must hold mu.
+func (f *faultInjector) fault(kind string) {
+	f.counts[kind]++
+	f.metrics.Inc("dashboard_chaos_faults_total", "Faults injected into Collector responses in chaos mode.", "kind", kind)
+}
+
+// checkInvariants returns a description of every cached workload showing
+// an older report than the cache showed before, and records the newest
+// report of each
+func (f *faultInjector) checkInvariants(cache map[string]*WorkloadStatus) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var violations []string
+	for key, status := range cache {
+		reported, err := time.Parse(time.RFC3339, status.Timestamp)
+		if err != nil || reported.IsZero() {
+			continue
+		}
+		if seen := f.seen[key]; reported.Before(seen) {
+			violations = append(violations, fmt.Sprintf("%s regressed from the report of %s to %s", key, seen.Format(time.RFC3339), status.Timestamp))
+			continue
+		}
+		f.seen[key] = reported
+	}
+	sort.Strings(violations)
+	return violations
+}
+
+// checkChaosInvariants checks the cache after a poll cycle in chaos mode
+func (s *Server) checkChaosInvariants() {
+	if s.chaos == nil {
+		return
+	}
+
+	s.cacheMutex.RLock()
+	violations := s.chaos.checkInvariants(s.statusCache)
+	s.cacheMutex.RUnlock()
+	for _, violation := range violations {
+		log.Printf("Chaos invariant violated: %s", violation)
+		s.metrics.Inc("dashboard_chaos_invariant_violations_total", "Cache regressions to older reports detected in chaos mode.")
+	}
+}
@@ -0,0 +1,7 @@
+//go:build !chaos
+
+package main
+
+// chaosBuild reports whether fault injection can be enabled; release builds
+// refuse CHAOS_CONFIG
+const chaosBuild = false
@@ -0,0 +1,7 @@
+//go:build chaos
+
+package main
+
+// Built with -tags chaos: CHAOS_CONFIG injects faults into Collector
+// responses. Never deploy such a build.
+const chaosBuild = true
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestChaosInvariants polls a Collector whose responses are delayed,
+// duplicated, reordered and corrupted, and checks that the cache never
+// shows an older report of a workload than it did before
+func TestChaosInvariants(t *testing.T) {
+	var cycle atomic.Int64
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := cycle.Add(1)
+		reports := make([]CollectorReport, 5)
+		for i := range reports {
+			reports[i] = CollectorReport{
+				PodName:   fmt.Sprintf("pod-%d", i),
+				Namespace: "icu",
+				Attested:  (int(n)+i)%3 != 0,
+				Timestamp: base.Add(time.Duration(n) * time.Minute),
+			}
+		}
+		json.NewEncoder(w).Encode(reports)
+	}))
+	defer collector.Close()
+
+	chaos, err := newFaultInjector(chaosConfig{Seed: 42, Delay: 0.3, Duplicate: 0.2, Reorder: 0.5, Corrupt: 0.05}, newMetrics())
+	if err != nil {
+		t.Fatalf("Failed to create injector: %v", err)
+	}
+	server := &Server{
+		statusCache:  make(map[string]*WorkloadStatus),
+		httpClient:   &http.Client{Timeout: 10 * time.Second},
+		collectorURL: collector.URL,
+		metrics:      chaos.metrics,
+		chaos:        chaos,
+	}
+
+	for i := 0; i < 200; i++ {
+		server.fetchFromCollector()
+	}
+
+	if violations := server.metrics.Value("dashboard_chaos_invariant_violations_total"); violations != 0 {
+		t.Errorf("Expected the cache never to regress, got %v violations", violations)
+	}
+	for _, kind := range []string{"delay", "duplicate", "reorder", "corrupt"} {
+		if chaos.counts[kind] == 0 {
+			t.Errorf("Expected %s faults to be injected, got %v", kind, chaos.counts)
+		}
+	}
+	if server.metrics.Value("dashboard_stale_reports_total", "cluster", "") == 0 {
+		t.Errorf("Expected delayed reports to be ignored as stale")
+	}
+}
+
+// TestChaosInvariantsDetectRegression tests that the invariant check itself
+// catches a cache going back to an older report
+func TestChaosInvariantsDetectRegression(t *testing.T) {
+	chaos, _ := newFaultInjector(chaosConfig{Seed: 1}, nil)
+	now := time.Now().UTC()
+	status := verifiedStatus("icu", "monitor")
+	status.Timestamp = now.Format(time.RFC3339)
+	if violations := chaos.checkInvariants(map[string]*WorkloadStatus{"icu/monitor": status}); len(violations) != 0 {
+		t.Fatalf("Unexpected violations %v", violations)
+	}
+	older := verifiedStatus("icu", "monitor")
+	older.Timestamp = now.Add(-time.Minute).Format(time.RFC3339)
+	if violations := chaos.checkInvariants(map[string]*WorkloadStatus{"icu/monitor": older}); len(violations) != 1 {
+		t.Errorf("Expected the regression to be detected, got %v", violations)
+	}
+
+	if _, err := newFaultInjector(chaosConfig{Delay: 1.5}, nil); err == nil {
+		t.Errorf("Expected a probability above 1 to be rejected")
+	}
+}
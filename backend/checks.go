@@ -0,0 +1,76 @@
+package main
+
+import "fmt"
+
+// Check severities, from most to least urgent
+const (
+	severityCritical = "critical"
+	severityHigh     = "high"
+	severityWarning  = "warning"
+)
+
+// severityRank orders severities; higher is more urgent
+var severityRank = map[string]int{severityWarning: 1, severityHigh: 2, severityCritical: 3}
+
+// highestSeverity returns the most urgent severity among checks, or "" if
+// there are none
+func highestSeverity(checks []Check) string {
+	highest := ""
+	for _, check := range checks {
+		if severityRank[check.Severity] > severityRank[highest] {
+			highest = check.Severity
+		}
+	}
+	return highest
+}
+
+// failCheck records a failed check on the workload
+func failCheck(status *WorkloadStatus, name, expected, actual, severity string) {
+	status.FailedChecks = append(status.FailedChecks, Check{
+		Name:     name,
+		Expected: expected,
+		Actual:   actual,
+		Severity: severity,
+	})
+}
+
+// trustVectorChecks records trust vector claims that are not affirming.
+// Claims in the warning tier don't fail attestation but are worth surfacing.
+func trustVectorChecks(status *WorkloadStatus, tv *TrustVector) {
+	claims := []struct {
+		name  string
+		value int
+	}{
+		{"hardware", tv.Hardware},
+		{"configuration", tv.Configuration},
+		{"executables", tv.Executables},
+	}
+
+	for _, claim := range claims {
+		severity := ""
+		switch {
+		case claim.value >= 96:
+			severity = severityCritical
+		case claim.value >= 32:
+			severity = severityWarning
+		default:
+			continue
+		}
+		failCheck(status, "trust_vector."+claim.name, trustTierToString(2),
+			fmt.Sprintf("%s (%d)", trustTierToString(claim.value), claim.value), severity)
+	}
+}
+
+// gateChecks records failed additional gates
+func gateChecks(status *WorkloadStatus) {
+	for _, gate := range status.Gates {
+		if gate.Status == "passing" {
+			continue
+		}
+		actual := gate.Status
+		if gate.Details != "" {
+			actual += ": " + gate.Details
+		}
+		failCheck(status, "gate:"+gate.Name, "passing", actual, severityHigh)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestFailedChecks tests that policy evaluation produces structured checks
+func TestFailedChecks(t *testing.T) {
+	server := &Server{}
+
+	failed := server.convertCollectorReport(CollectorReport{
+		PodName: "a", Namespace: "icu", Attested: false, Error: "quote verification failed", Timestamp: time.Now(),
+	})
+	if len(failed.FailedChecks) != 1 {
+		t.Fatalf("Expected 1 failed check, got %+v", failed.FailedChecks)
+	}
+	check := failed.FailedChecks[0]
+	if check.Name != "tee_attestation" || check.Actual != "quote verification failed" || check.Severity != severityCritical {
+		t.Errorf("Unexpected check: %+v", check)
+	}
+
+	warning := server.convertCollectorReport(CollectorReport{
+		PodName: "b", Namespace: "icu", Attested: true, Timestamp: time.Now(),
+		TrustVector: &TrustVector{Hardware: 2, Configuration: 32, Executables: 2},
+	})
+	if len(warning.FailedChecks) != 1 || warning.FailedChecks[0].Name != "trust_vector.configuration" || warning.FailedChecks[0].Severity != severityWarning {
+		t.Errorf("Expected configuration warning check, got %+v", warning.FailedChecks)
+	}
+
+	healthy := server.convertCollectorReport(CollectorReport{
+		PodName: "c", Namespace: "icu", Attested: true, Timestamp: time.Now(),
+		TrustVector: &TrustVector{Hardware: 2, Configuration: 2, Executables: 2},
+	})
+	if len(healthy.FailedChecks) != 0 {
+		t.Errorf("Expected no failed checks, got %+v", healthy.FailedChecks)
+	}
+
+	gated := &WorkloadStatus{Gates: []GateResult{
+		{Name: "cmdb", Status: "failed", Details: "not registered"},
+		{Name: "scan", Status: "passing"},
+	}}
+	gateChecks(gated)
+	if len(gated.FailedChecks) != 1 || gated.FailedChecks[0].Name != "gate:cmdb" || gated.FailedChecks[0].Actual != "failed: not registered" {
+		t.Errorf("Unexpected gate checks: %+v", gated.FailedChecks)
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// clientIPResolver determines the address a request originated from. Behind
+// the ingress controller every connection comes from a proxy, so the client
+// is taken from X-Forwarded-For or X-Real-IP - but only when the connection
+// comes from a trusted proxy, as otherwise the headers are client-controlled.
+type clientIPResolver struct {
+	trustedProxies []*net.IPNet
+}
+
+// newClientIPResolver parses TRUSTED_PROXIES, comma-separated addresses and
+// CIDRs. Without any, forwarding headers are ignored.
+func newClientIPResolver(trustedProxies string) (*clientIPResolver, error) {
+	networks, err := parseNetworks(trustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	return &clientIPResolver{trustedProxies: networks}, nil
+}
+
+// resolve returns the address the request originated from. X-Forwarded-For
+// is walked from the right, skipping trusted proxies, so a client can't
+// prepend a spoofed address; X-Real-IP is used when a trusted proxy sends
+// only that. Nil if no valid address is found.
+func (c *clientIPResolver) resolve(r *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if c == nil || ip == nil || !containsIP(c.trustedProxies, ip) {
+		return ip
+	}
+
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	if len(hops) == 0 {
+		if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); realIP != "" {
+			return net.ParseIP(realIP)
+		}
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(hops[i]))
+		if hop == nil {
+			return nil
+		}
+		if !containsIP(c.trustedProxies, hop) {
+			return hop
+		}
+		ip = hop
+	}
+	// Every hop is a trusted proxy - the request came from inside
+	return ip
+}
+
+type clientIPContextKey struct{}
+
+// clientIPMiddleware resolves the client address of every request once, for
+// logs, rate limiting, and audit records
+func (s *Server) clientIPMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := "unknown"
+		if resolved := s.clientIPs.resolve(r); resolved != nil {
+			ip = resolved.String()
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), clientIPContextKey{}, ip)))
+	})
+}
+
+// requestIP returns the client address resolved by clientIPMiddleware, or
+// the peer address of requests that didn't pass through it
+func requestIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(clientIPContextKey{}).(string); ok {
+		return ip
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// clientRateLimiter limits API requests per client address with a token
+// bucket each. Buckets of clients idle long enough to have refilled are
+// dropped.
+type clientRateLimiter struct {
+	limit  int
+	period time.Duration
+
+	mu      sync.Mutex
+	buckets map[string]*rateLimiter
+	pruned  time.Time
+}
+
+func newClientRateLimiter(limit int, period time.Duration) *clientRateLimiter {
+	return &clientRateLimiter{limit: limit, period: period, buckets: make(map[string]*rateLimiter)}
+}
+
+// newClientRateLimiterFromEnv reads API_RATE_LIMIT, requests per minute per
+// client address; nil when unset or 0
+func newClientRateLimiterFromEnv() (*clientRateLimiter, error) {
+	limit, err := strconv.Atoi(getEnv("API_RATE_LIMIT", "0"))
+	if err != nil || limit < 0 {
+		return nil, fmt.Errorf("invalid API_RATE_LIMIT %q", os.Getenv("API_RATE_LIMIT"))
+	}
+	if limit == 0 {
+		return nil, nil
+	}
+	return newClientRateLimiter(limit, time.Minute), nil
+}
+
+// reserve takes a token from the client's bucket, returning how long to wait
+// if there is none
+func (l *clientRateLimiter) reserve(ip string, now time.Time) time.Duration {
+	l.mu.Lock()
+	if now.Sub(l.pruned) > l.period {
+		for key, bucket := range l.buckets {
+			bucket.mu.Lock()
+			idle := now.Sub(bucket.last) > l.period
+			bucket.mu.Unlock()
+			if idle {
+				delete(l.buckets, key)
+			}
+		}
+		l.pruned = now
+	}
+	bucket := l.buckets[ip]
+	if bucket == nil {
+		bucket = newRateLimiter(l.limit, l.period)
+		l.buckets[ip] = bucket
+	}
+	l.mu.Unlock()
+	return bucket.reserve(now)
+}
+
+// rateLimitMiddleware rejects API requests of clients over API_RATE_LIMIT
+func (s *Server) rateLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.rateLimit == nil || !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ip := requestIP(r)
+		if wait := s.rateLimit.reserve(ip, time.Now()); wait > 0 {
+			s.metrics.Inc("dashboard_rate_limited_requests_total", "API requests rejected for exceeding the per-client rate limit.")
+			log.Printf("Rate limited %s %s from %s", r.Method, r.URL.Path, ip)
+			w.Header().Set("Retry-After", fmt.Sprint(int(math.Ceil(wait.Seconds()))))
+			http.Error(w, "too many requests", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
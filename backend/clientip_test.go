@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestClientIPResolver tests that forwarding headers are only honoured from
+// trusted proxies
+func TestClientIPResolver(t *testing.T) {
+	resolver, err := newClientIPResolver("10.0.0.0/24, 10.0.1.7")
+	if err != nil {
+		t.Fatalf("Failed to parse trusted proxies: %v", err)
+	}
+
+	tests := []struct {
+		remoteAddr string
+		forwarded  string
+		realIP     string
+		expected   string
+	}{
+		{"203.0.113.9:1234", "", "", "203.0.113.9"},
+		{"203.0.113.9:1234", "198.51.100.4", "198.51.100.4", "203.0.113.9"}, // untrusted peer
+		{"10.0.0.3:1234", "198.51.100.4", "", "198.51.100.4"},
+		{"10.0.0.3:1234", "192.0.2.1, 198.51.100.4, 10.0.1.7", "", "198.51.100.4"},
+		{"10.0.0.3:1234", "", "198.51.100.4", "198.51.100.4"},
+		{"10.0.0.3:1234", "198.51.100.4", "192.0.2.1", "198.51.100.4"}, // X-Forwarded-For wins
+		{"10.0.0.3:1234", "", "", "10.0.0.3"},
+		{"10.0.0.3:1234", "not-an-ip", "", "<nil>"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/api/status", nil)
+		r.RemoteAddr = tt.remoteAddr
+		if tt.forwarded != "" {
+			r.Header.Set("X-Forwarded-For", tt.forwarded)
+		}
+		if tt.realIP != "" {
+			r.Header.Set("X-Real-IP", tt.realIP)
+		}
+		if got := resolver.resolve(r).String(); got != tt.expected {
+			t.Errorf("Expected %s for %s via %q/%q, got %s", tt.expected, tt.remoteAddr, tt.forwarded, tt.realIP, got)
+		}
+	}
+
+	if _, err := newClientIPResolver("10.0.0.0/33"); err == nil {
+		t.Error("Expected an invalid network to be rejected")
+	}
+}
+
+// TestClientIPInAuditAndAccessLogs tests that audit and access entries
+// record the resolved client address rather than the proxy's
+func TestClientIPInAuditAndAccessLogs(t *testing.T) {
+	resolver, _ := newClientIPResolver("10.0.0.0/24")
+	audit, _ := newAuditLog(nil)
+	server := &Server{clientIPs: resolver, audit: audit, access: &AccessLog{}}
+	handler := server.clientIPMiddleware(server.accessLogMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.audit.RecordRequest(r, "raj", "collector.refresh", "", "")
+	})))
+
+	r := httptest.NewRequest("POST", "/api/refresh", nil)
+	r.RemoteAddr = "10.0.0.3:1234"
+	r.Header.Set("X-Forwarded-For", "198.51.100.4")
+	r = r.WithContext(context.WithValue(r.Context(), identityContextKey{}, &Identity{Name: "raj"}))
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	entries := audit.Entries(time.Time{})
+	if len(entries) != 1 || entries[0].SourceIP != "198.51.100.4" {
+		t.Errorf("Expected an audit entry from 198.51.100.4, got %+v", entries)
+	}
+	access, _ := server.access.Entries(context.Background(), time.Time{}, "")
+	if len(access) != 1 || access[0].SourceIP != "198.51.100.4" {
+		t.Errorf("Expected an access entry from 198.51.100.4, got %+v", access)
+	}
+
+	audit.Record("system", "ack.expired", "icu/ai-model", "")
+	if entries := audit.Entries(time.Time{}); entries[1].SourceIP != "" {
+		t.Errorf("Expected no address for system actions, got %s", entries[1].SourceIP)
+	}
+}
+
+// TestRateLimitMiddleware tests that clients are limited by their resolved
+// address, not that of the proxy they share
+func TestRateLimitMiddleware(t *testing.T) {
+	resolver, _ := newClientIPResolver("10.0.0.0/24")
+	server := &Server{clientIPs: resolver, rateLimit: newClientRateLimiter(2, time.Minute), metrics: newMetrics()}
+	handler := server.clientIPMiddleware(server.rateLimitMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+
+	request := func(path, client string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", path, nil)
+		r.RemoteAddr = "10.0.0.3:1234"
+		r.Header.Set("X-Forwarded-For", client)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	for i := 0; i < 2; i++ {
+		if w := request("/api/status", "198.51.100.4"); w.Code != http.StatusOK {
+			t.Fatalf("Expected request %d to pass, got %d", i, w.Code)
+		}
+	}
+	w := request("/api/status", "198.51.100.4")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429 over the limit, got %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") != "30" {
+		t.Errorf("Expected Retry-After 30, got %q", w.Header().Get("Retry-After"))
+	}
+	if w := request("/api/status", "198.51.100.5"); w.Code != http.StatusOK {
+		t.Errorf("Expected another client behind the same proxy to pass, got %d", w.Code)
+	}
+	if w := request("/index.html", "198.51.100.4"); w.Code != http.StatusOK {
+		t.Errorf("Expected static assets not to be limited, got %d", w.Code)
+	}
+	if got := server.metrics.Value("dashboard_rate_limited_requests_total"); got != 1 {
+		t.Errorf("Expected 1 rate limited request, got %v", got)
+	}
+}
+
+// TestClientRateLimiterPrunesIdleClients tests that buckets of clients gone
+// quiet are dropped
+func TestClientRateLimiterPrunesIdleClients(t *testing.T) {
+	limiter := newClientRateLimiter(10, time.Minute)
+	now := time.Now()
+	limiter.reserve("198.51.100.4", now)
+	limiter.reserve("198.51.100.5", now.Add(30*time.Second))
+	limiter.reserve("198.51.100.6", now.Add(80*time.Second))
+	if _, ok := limiter.buckets["198.51.100.4"]; ok {
+		t.Error("Expected the idle client's bucket to be dropped")
+	}
+	if _, ok := limiter.buckets["198.51.100.5"]; !ok {
+		t.Error("Expected the active client's bucket to be kept")
+	}
+}
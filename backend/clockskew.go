@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// clockSkewStatus is the AttestationStatus of a verified workload whose report
+// timestamp is in the future or too old to count as a fresh attestation
+const clockSkewStatus = "clock-skew"
+
+// clockSkewLimits bounds how far a report timestamp may drift from the server
+// clock. Zero disables a limit.
+type clockSkewLimits struct {
+	tolerance time.Duration // allowed lead of a timestamp over the server clock
+	maxAge    time.Duration // age beyond which a report no longer counts as fresh
+}
+
+// skew describes how a report timestamp falls outside the limits, or returns
+// "" if it is within them. Reports without a timestamp aren't judged.
+func (l clockSkewLimits) skew(timestamp, now time.Time) string {
+	if timestamp.IsZero() {
+		return ""
+	}
+	if l.tolerance > 0 && timestamp.Sub(now) > l.tolerance {
+		return fmt.Sprintf("timestamp %s is %s ahead of the server clock",
+			timestamp.UTC().Format(time.RFC3339), timestamp.Sub(now).Round(time.Second))
+	}
+	if l.maxAge > 0 && now.Sub(timestamp) > l.maxAge {
+		return fmt.Sprintf("timestamp %s is %s old",
+			timestamp.UTC().Format(time.RFC3339), now.Sub(timestamp).Round(time.Second))
+	}
+	return ""
+}
+
+// checkClockSkew flags a report whose timestamp doesn't fit the server clock.
+// A verified workload is downgraded to clockSkewStatus when its report is
+// ahead of the clock, as its attestation can't be shown to be current, and
+// to unknown when its report is too old, as it may no longer hold. A failed
+// one keeps its status.
+func (s *Server) checkClockSkew(report CollectorReport, status *WorkloadStatus, now time.Time) {
+	skew := s.clockSkew.skew(report.Timestamp, now)
+	if skew == "" {
+		return
+	}
+
+	switch {
+	case status.AttestationStatus != "verified":
+	case report.Timestamp.After(now):
+		status.AttestationStatus = clockSkewStatus
+		status.Details = fmt.Sprintf("Clock skew: %s - %s", skew, status.Details)
+	default:
+		markUnknown(status, unknownStale, fmt.Sprintf("Stale report: %s - %s", skew, status.Details))
+	}
+	failCheck(status, "clock_skew", "timestamp within tolerance of server clock", skew, severityWarning)
+}
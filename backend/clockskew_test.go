@@ -0,0 +1,56 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestClockSkew tests that reports from the future or older than the maximum
+// age are flagged, and that reports within tolerance stay verified
+func TestClockSkew(t *testing.T) {
+	server := &Server{clockSkew: clockSkewLimits{tolerance: 5 * time.Minute, maxAge: time.Hour}}
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		timestamp time.Time
+		attested  bool
+		want      string
+		flagged   bool
+	}{
+		{"current", now.Add(-time.Minute), true, "verified", false},
+		{"slightly ahead", now.Add(2 * time.Minute), true, "verified", false},
+		{"future", now.Add(time.Hour), true, clockSkewStatus, true},
+		{"too old", now.Add(-2 * time.Hour), true, unknownStatus, true},
+		{"no timestamp", time.Time{}, true, "verified", false},
+		{"failed and old", now.Add(-2 * time.Hour), false, "failed", true},
+	}
+
+	for _, tt := range tests {
+		status := server.convertCollectorReport(CollectorReport{PodName: "ai-model", Namespace: "icu", Attested: tt.attested, Timestamp: tt.timestamp})
+		if status.AttestationStatus != tt.want {
+			t.Errorf("%s: Expected status '%s', got '%s'", tt.name, tt.want, status.AttestationStatus)
+		}
+		flagged := false
+		for _, check := range status.FailedChecks {
+			if check.Name == "clock_skew" {
+				flagged = true
+				if check.Severity != severityWarning {
+					t.Errorf("%s: Expected warning severity, got %s", tt.name, check.Severity)
+				}
+			}
+		}
+		if flagged != tt.flagged {
+			t.Errorf("%s: Expected clock_skew check %v, got %v", tt.name, tt.flagged, flagged)
+		}
+	}
+}
+
+// TestClockSkewDisabled tests that zero limits accept any timestamp
+func TestClockSkewDisabled(t *testing.T) {
+	server := &Server{}
+	status := server.convertCollectorReport(CollectorReport{PodName: "ai-model", Namespace: "icu", Attested: true, Timestamp: time.Now().Add(24 * time.Hour)})
+	if status.AttestationStatus != "verified" || len(status.FailedChecks) != 0 {
+		t.Errorf("Expected verified status without checks, got %s %+v", status.AttestationStatus, status.FailedChecks)
+	}
+}
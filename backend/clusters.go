@@ -0,0 +1,200 @@
+package main
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ClusterConfig describes one cluster and the Collector serving its reports
+type ClusterConfig struct {
+	Name         string `json:"name"`
+	CollectorURL string `json:"collector_url"`
+	Token        string `json:"token,omitempty"`      // Bearer token for the Collector API
+	TokenFile    string `json:"token_file,omitempty"` // Alternative to Token, re-read on every poll
+	CAFile       string `json:"ca_file,omitempty"`    // CA bundle for a TLS-enabled Collector
+
+	// Optional second verifier whose verdicts are compared with the primary's
+	SecondaryCollectorURL string `json:"secondary_collector_url,omitempty"`
+	// Whether the Collector also serves node-level attestation reports
+	NodeReports bool `json:"node_reports,omitempty"`
+
+	httpClient *http.Client
+}
+
+// clusterSyncState tracks the outcome of the last poll of a cluster's Collector
+type clusterSyncState struct {
+	LastSync  time.Time
+	LastError string
+}
+
+// loadClusters reads the cluster list from a JSON file
+func loadClusters(path string) ([]ClusterConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var clusters []ClusterConfig
+	if err := json.Unmarshal(data, &clusters); err != nil {
+		return nil, fmt.Errorf("invalid clusters config: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	for i := range clusters {
+		c := &clusters[i]
+		if c.Name == "" || c.CollectorURL == "" {
+			return nil, fmt.Errorf("cluster %d: name and collector_url are required", i)
+		}
+		if seen[c.Name] {
+			return nil, fmt.Errorf("duplicate cluster name %q", c.Name)
+		}
+		seen[c.Name] = true
+
+		if err := c.configureTLS(); err != nil {
+			return nil, fmt.Errorf("cluster %s: %w", c.Name, err)
+		}
+	}
+
+	return clusters, nil
+}
+
+// configureTLS sets up a client trusting the cluster's CA bundle, if any
+func (c *ClusterConfig) configureTLS() error {
+	if c.CAFile == "" {
+		return nil
+	}
+	caPEM, err := os.ReadFile(c.CAFile)
+	if err != nil {
+		return err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return fmt.Errorf("no certificates in %s", c.CAFile)
+	}
+	tlsConfig := newTLSConfig()
+	tlsConfig.RootCAs = pool
+	c.httpClient = &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: &http.Transport{TLSClientConfig: tlsConfig},
+	}
+	return nil
+}
+
+// collectorTargets returns the configured clusters, falling back to the single
+// COLLECTOR_URL for deployments that don't use CLUSTERS_CONFIG, followed by
+// the Collectors registered at runtime
+func (s *Server) collectorTargets() []ClusterConfig {
+	targets := s.clusters
+	if len(targets) == 0 {
+		targets = []ClusterConfig{{
+			Name:                  s.localCluster,
+			CollectorURL:          s.collectorURL,
+			SecondaryCollectorURL: s.secondaryCollectorURL,
+			NodeReports:           s.nodeAttestation,
+		}}
+	}
+	if registered := s.collectors.List(); len(registered) > 0 {
+		targets = append(append([]ClusterConfig(nil), targets...), registered...)
+	}
+	return targets
+}
+
+// authorize adds the cluster's Collector credentials to a request
+func (c *ClusterConfig) authorize(req *http.Request) error {
+	token := c.Token
+	if c.TokenFile != "" {
+		data, err := os.ReadFile(c.TokenFile)
+		if err != nil {
+			return fmt.Errorf("failed to read token file: %w", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return nil
+}
+
+// recordClusterSync stores the outcome of polling a cluster. Caller must hold cacheMutex.
+func (s *Server) recordClusterSync(name string, err error) {
+	if s.clusterState == nil {
+		s.clusterState = make(map[string]*clusterSyncState)
+	}
+	state, ok := s.clusterState[name]
+	if !ok {
+		state = &clusterSyncState{}
+		s.clusterState[name] = state
+	}
+	if err != nil {
+		state.LastError = err.Error()
+		return
+	}
+	state.LastSync = time.Now()
+	state.LastError = ""
+}
+
+// handleClusters returns an attestation summary per cluster
+func (s *Server) handleClusters(w http.ResponseWriter, r *http.Request) {
+	s.cacheMutex.RLock()
+	summaries := s.clusterSummariesLocked()
+	s.cacheMutex.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summaries)
+}
+
+// clusterSummariesLocked summarizes the cache per cluster, by name. Caller
+// must hold cacheMutex.
+func (s *Server) clusterSummariesLocked() []ClusterSummary {
+	byName := make(map[string]*ClusterSummary)
+	for _, c := range s.collectorTargets() {
+		byName[c.Name] = &ClusterSummary{Name: c.Name, Status: "compliant"}
+	}
+
+	for _, status := range s.statusCache {
+		summary, ok := byName[status.Cluster]
+		if !ok {
+			summary = &ClusterSummary{Name: status.Cluster, Status: "compliant"}
+			byName[status.Cluster] = summary
+		}
+		summary.Workloads++
+		switch {
+		case status.Attested:
+			summary.Attested++
+		case isUnknown(status):
+			summary.Unknown++
+		default:
+			summary.Failed++
+			summary.Status = "violation"
+		}
+	}
+
+	summaries := make([]ClusterSummary, 0, len(byName))
+	for name, summary := range byName {
+		if state, ok := s.clusterState[name]; ok {
+			if !state.LastSync.IsZero() {
+				lastSync := state.LastSync
+				summary.LastSync = &lastSync
+			}
+			summary.LastError = state.LastError
+			if state.LastError != "" && summary.Status == "compliant" {
+				summary.Status = "unreachable"
+			}
+		}
+		summaries = append(summaries, *summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Name < summaries[j].Name })
+	return summaries
+}
+
+// matchesCluster reports whether a workload passes the optional ?cluster= filter
+func matchesCluster(r *http.Request, status *WorkloadStatus) bool {
+	cluster := r.URL.Query().Get("cluster")
+	return cluster == "" || status.Cluster == cluster
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newMockCollector returns a Collector serving the given reports and
+// requiring the given bearer token (if non-empty)
+func newMockCollector(t *testing.T, token string, reports []CollectorReport) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token != "" && r.Header.Get("Authorization") != "Bearer "+token {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(reports)
+	}))
+}
+
+// TestLoadClusters tests parsing and validation of the clusters config
+func TestLoadClusters(t *testing.T) {
+	dir := t.TempDir()
+
+	valid := filepath.Join(dir, "valid.json")
+	os.WriteFile(valid, []byte(`[{"name":"site-a","collector_url":"http://a:8080","token":"x"},{"name":"site-b","collector_url":"http://b:8080"}]`), 0o600)
+	clusters, err := loadClusters(valid)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(clusters) != 2 || clusters[0].Name != "site-a" || clusters[0].Token != "x" {
+		t.Errorf("Unexpected clusters: %+v", clusters)
+	}
+
+	duplicate := filepath.Join(dir, "duplicate.json")
+	os.WriteFile(duplicate, []byte(`[{"name":"a","collector_url":"http://a"},{"name":"a","collector_url":"http://b"}]`), 0o600)
+	if _, err := loadClusters(duplicate); err == nil {
+		t.Error("Expected error for duplicate cluster names")
+	}
+
+	missing := filepath.Join(dir, "missing.json")
+	os.WriteFile(missing, []byte(`[{"name":"a"}]`), 0o600)
+	if _, err := loadClusters(missing); err == nil {
+		t.Error("Expected error for missing collector_url")
+	}
+}
+
+// TestFetchFromMultipleClusters tests that reports are labelled with their
+// cluster and that an unreachable cluster keeps its last known workloads
+func TestFetchFromMultipleClusters(t *testing.T) {
+	siteA := newMockCollector(t, "token-a", []CollectorReport{
+		{PodName: "pod-a", Namespace: "icu", Attested: true, Timestamp: time.Now()},
+	})
+	defer siteA.Close()
+
+	siteB := newMockCollector(t, "", []CollectorReport{
+		{PodName: "pod-b", Namespace: "radiology", Attested: false, Timestamp: time.Now()},
+	})
+
+	server := &Server{
+		statusCache: make(map[string]*WorkloadStatus),
+		httpClient:  &http.Client{Timeout: 10 * time.Second},
+		clusters: []ClusterConfig{
+			{Name: "site-a", CollectorURL: siteA.URL, Token: "token-a"},
+			{Name: "site-b", CollectorURL: siteB.URL},
+		},
+	}
+
+	server.fetchFromCollector()
+
+	if len(server.statusCache) != 2 {
+		t.Fatalf("Expected 2 workloads, got %d", len(server.statusCache))
+	}
+	if server.statusCache["icu/pod-a"].Cluster != "site-a" {
+		t.Errorf("Expected cluster 'site-a', got '%s'", server.statusCache["icu/pod-a"].Cluster)
+	}
+
+	// site-b goes away - its workload must survive the next cycle
+	siteB.Close()
+	server.fetchFromCollector()
+
+	if _, ok := server.statusCache["radiology/pod-b"]; !ok {
+		t.Error("Expected site-b workload to be kept while its Collector is unreachable")
+	}
+	if server.clusterState["site-b"].LastError == "" {
+		t.Error("Expected site-b sync error to be recorded")
+	}
+
+	req := httptest.NewRequest("GET", "/api/clusters", nil)
+	w := httptest.NewRecorder()
+	server.handleClusters(w, req)
+
+	var summaries []ClusterSummary
+	if err := json.NewDecoder(w.Body).Decode(&summaries); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(summaries) != 2 {
+		t.Fatalf("Expected 2 cluster summaries, got %d", len(summaries))
+	}
+	if summaries[0].Status != "compliant" || summaries[0].LastSync == nil {
+		t.Errorf("Unexpected site-a summary: %+v", summaries[0])
+	}
+	if summaries[1].Status != "violation" || summaries[1].LastError == "" {
+		t.Errorf("Unexpected site-b summary: %+v", summaries[1])
+	}
+}
+
+// TestWorkloadsClusterFilter tests the ?cluster= filter on /api/workloads
+func TestWorkloadsClusterFilter(t *testing.T) {
+	server := &Server{
+		statusCache: map[string]*WorkloadStatus{
+			"icu/pod-a":       {Name: "pod-a", Namespace: "icu", Cluster: "site-a"},
+			"radiology/pod-b": {Name: "pod-b", Namespace: "radiology", Cluster: "site-b"},
+		},
+	}
+
+	req := httptest.NewRequest("GET", "/api/workloads?cluster=site-b", nil)
+	w := httptest.NewRecorder()
+	server.handleWorkloads(w, req)
+
+	var workloads []WorkloadStatus
+	if err := json.NewDecoder(w.Body).Decode(&workloads); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(workloads) != 1 || workloads[0].Name != "pod-b" {
+		t.Errorf("Expected only pod-b, got %+v", workloads)
+	}
+}
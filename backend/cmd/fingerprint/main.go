@@ -0,0 +1,33 @@
+// Command fingerprint renames the frontend's static assets in a directory to
+// content-hashed names and rewrites the HTML pages referencing them. Run at
+// image build time; see Dockerfile.backend.
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/rh-summit-coco/raj-hospital-dashboard/backend/pkg/assets"
+)
+
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Fprintln(os.Stderr, "usage: fingerprint <static dir>")
+		os.Exit(2)
+	}
+
+	renamed, err := assets.Fingerprint(os.Args[1])
+	if err != nil {
+		log.Fatalf("Failed to fingerprint assets: %v", err)
+	}
+	names := make([]string, 0, len(renamed))
+	for name := range renamed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Printf("%s -> %s\n", name, renamed[name])
+	}
+}
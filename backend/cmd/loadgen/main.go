@@ -0,0 +1,287 @@
+// Command loadgen load-tests a dashboard backend. It serves a simulated
+// Collector reporting N workloads, some of which change verdict on every
+// poll, and runs M concurrent API clients against the dashboard, reporting
+// throughput and latency per endpoint. With -max-p99, -min-rps or
+// -max-error-rate it exits non-zero when the run misses a threshold, for CI.
+//
+// Point the dashboard under test at the simulated Collector:
+//
+//	loadgen -collector-listen :9090 -target http://localhost:8080 -workloads 5000 -clients 50
+//	COLLECTOR_URL=http://localhost:9090 ./dashboard
+//
+// Without -target it only serves the Collector.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rh-summit-coco/raj-hospital-dashboard/backend/pkg/api"
+	"github.com/rh-summit-coco/raj-hospital-dashboard/backend/pkg/client"
+)
+
+// report is the wire format of a Collector report
+type report struct {
+	PodName     string           `json:"pod_name"`
+	Namespace   string           `json:"namespace"`
+	TEEType     string           `json:"tee_type"`
+	NodeName    string           `json:"node_name"`
+	Attested    bool             `json:"attested"`
+	TrustVector *api.TrustVector `json:"trust_vector,omitempty"`
+	Timestamp   time.Time        `json:"timestamp"`
+	Error       string           `json:"error,omitempty"`
+}
+
+// collector simulates a Collector with a fixed set of workloads
+type collector struct {
+	flip float64 // fraction of workloads changing verdict per request
+
+	mu       sync.Mutex
+	rng      *rand.Rand
+	reports  []report
+	requests atomic.Int64
+}
+
+func newCollector(workloads, namespaces int, flip float64, seed int64) *collector {
+	c := &collector{flip: flip, rng: rand.New(rand.NewSource(seed))}
+	tees := []string{"tdx", "sev-snp", "se"}
+	for i := 0; i < workloads; i++ {
+		c.reports = append(c.reports, report{
+			PodName:     fmt.Sprintf("workload-%05d", i),
+			Namespace:   fmt.Sprintf("ns-%03d", i%namespaces),
+			TEEType:     tees[i%len(tees)],
+			NodeName:    fmt.Sprintf("worker-%d", i%50),
+			Attested:    true,
+			TrustVector: &api.TrustVector{InstanceIdentity: 2, Configuration: 2, Executables: 2, Hardware: 2},
+		})
+	}
+	return c
+}
+
+// ServeHTTP answers GET /api/v1/reports, flipping verdicts as it goes
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/api/v1/reports" {
+		http.NotFound(w, r)
+		return
+	}
+	c.requests.Add(1)
+
+	c.mu.Lock()
+	now := time.Now().UTC()
+	for i := range c.reports {
+		rep := &c.reports[i]
+		rep.Timestamp = now
+		if c.rng.Float64() < c.flip {
+			rep.Attested = !rep.Attested
+			rep.Error = ""
+			rep.TrustVector.Hardware = 2
+			if !rep.Attested {
+				rep.Error = "quote verification failed"
+				rep.TrustVector.Hardware = 96
+			}
+		}
+	}
+	body, err := json.Marshal(c.reports)
+	c.mu.Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+// result collects the latencies of one endpoint
+type result struct {
+	mu        sync.Mutex
+	latencies []time.Duration
+	errors    int
+}
+
+func (r *result) record(d time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err != nil {
+		r.errors++
+		return
+	}
+	r.latencies = append(r.latencies, d)
+}
+
+// percentile returns the p-th percentile (0-100) of sorted latencies
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(float64(len(sorted)-1) * p / 100)
+	return sorted[i]
+}
+
+// endpoint is one API call made by the simulated clients
+type endpoint struct {
+	name   string
+	weight int // share of requests
+	call   func(ctx context.Context, c *client.Client, rng *rand.Rand) error
+}
+
+func main() {
+	target := flag.String("target", "", "base URL of the dashboard under test; empty = only serve the Collector")
+	listen := flag.String("collector-listen", ":9090", "address of the simulated Collector")
+	workloads := flag.Int("workloads", 1000, "simulated workloads")
+	namespaces := flag.Int("namespaces", 20, "namespaces the workloads are spread over")
+	flip := flag.Float64("flip", 0.01, "fraction of workloads changing verdict per Collector poll")
+	clients := flag.Int("clients", 10, "concurrent API clients")
+	duration := flag.Duration("duration", 30*time.Second, "measurement duration")
+	warmup := flag.Duration("warmup", 2*time.Minute, "maximum wait for the dashboard to show every workload")
+	token := flag.String("token", os.Getenv("DASHBOARD_TOKEN"), "bearer token for the dashboard API")
+	seed := flag.Int64("seed", 1, "random seed")
+	maxP99 := flag.Duration("max-p99", 0, "fail if any endpoint's p99 latency exceeds this; 0 = no limit")
+	minRPS := flag.Float64("min-rps", 0, "fail if total throughput is below this many requests/s; 0 = no limit")
+	maxErrorRate := flag.Float64("max-error-rate", 0.01, "fail if more than this fraction of requests fail")
+	flag.Parse()
+
+	sim := newCollector(*workloads, *namespaces, *flip, *seed)
+	listener, err := net.Listen("tcp", *listen)
+	if err != nil {
+		log.Fatalf("Failed to listen on %s: %v", *listen, err)
+	}
+	go http.Serve(listener, sim)
+	log.Printf("Simulated Collector with %d workloads on %s", *workloads, listener.Addr())
+
+	if *target == "" {
+		stop := make(chan os.Signal, 1)
+		signal.Notify(stop, os.Interrupt)
+		<-stop
+		return
+	}
+
+	opts := []client.Option{client.WithRetries(0, 0)}
+	if *token != "" {
+		opts = append(opts, client.WithToken(*token))
+	}
+	dashboard := client.New(*target, opts...)
+
+	if err := waitForWorkloads(dashboard, *workloads, *warmup); err != nil {
+		log.Fatalf("Dashboard not ready: %v", err)
+	}
+
+	endpoints := []endpoint{
+		{"GET /api/status", 5, func(ctx context.Context, c *client.Client, _ *rand.Rand) error {
+			_, err := c.Status(ctx, client.ListOptions{})
+			return err
+		}},
+		{"GET /api/workloads", 3, func(ctx context.Context, c *client.Client, _ *rand.Rand) error {
+			_, err := c.ListWorkloads(ctx, client.ListOptions{})
+			return err
+		}},
+		{"GET /api/workload/{ns}/{name}", 2, func(ctx context.Context, c *client.Client, rng *rand.Rand) error {
+			i := rng.Intn(*workloads)
+			_, err := c.GetWorkload(ctx, fmt.Sprintf("ns-%03d", i%*namespaces), fmt.Sprintf("workload-%05d", i))
+			return err
+		}},
+	}
+	var weighted []*endpoint
+	for i := range endpoints {
+		for j := 0; j < endpoints[i].weight; j++ {
+			weighted = append(weighted, &endpoints[i])
+		}
+	}
+	results := make(map[string]*result, len(endpoints))
+	for _, e := range endpoints {
+		results[e.name] = &result{}
+	}
+
+	log.Printf("Running %d clients against %s for %s", *clients, *target, *duration)
+	ctx, cancel := context.WithTimeout(context.Background(), *duration)
+	defer cancel()
+	var wg sync.WaitGroup
+	for i := 0; i < *clients; i++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(seed))
+			for ctx.Err() == nil {
+				e := weighted[rng.Intn(len(weighted))]
+				start := time.Now()
+				err := e.call(ctx, dashboard, rng)
+				if ctx.Err() != nil && errors.Is(err, context.DeadlineExceeded) {
+					return // cut short by the end of the run
+				}
+				results[e.name].record(time.Since(start), err)
+			}
+		}(*seed + int64(i))
+	}
+	wg.Wait()
+
+	if !printResults(endpoints, results, *duration, sim.requests.Load(), *maxP99, *minRPS, *maxErrorRate) {
+		os.Exit(1)
+	}
+}
+
+// waitForWorkloads waits until the dashboard shows every simulated workload
+func waitForWorkloads(dashboard *client.Client, workloads int, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		list, err := dashboard.ListWorkloads(ctx, client.ListOptions{})
+		cancel()
+		if err == nil && len(list) >= workloads {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			if err != nil {
+				return err
+			}
+			return fmt.Errorf("only %d of %d workloads after %s", len(list), workloads, timeout)
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// printResults prints the results and returns whether every threshold was met
+func printResults(endpoints []endpoint, results map[string]*result, duration time.Duration, polls int64, maxP99 time.Duration, minRPS, maxErrorRate float64) bool {
+	ok := true
+	total, failed := 0, 0
+	fmt.Printf("%-32s %9s %9s %9s %9s %9s %7s\n", "endpoint", "requests", "req/s", "p50", "p95", "p99", "errors")
+	for _, e := range endpoints {
+		r := results[e.name]
+		sort.Slice(r.latencies, func(i, j int) bool { return r.latencies[i] < r.latencies[j] })
+		p99 := percentile(r.latencies, 99)
+		fmt.Printf("%-32s %9d %9.1f %9s %9s %9s %7d\n", e.name, len(r.latencies),
+			float64(len(r.latencies))/duration.Seconds(),
+			percentile(r.latencies, 50).Round(time.Microsecond),
+			percentile(r.latencies, 95).Round(time.Microsecond),
+			p99.Round(time.Microsecond), r.errors)
+		total += len(r.latencies) + r.errors
+		failed += r.errors
+		if maxP99 > 0 && p99 > maxP99 {
+			fmt.Printf("FAIL: %s p99 %s exceeds %s\n", e.name, p99.Round(time.Microsecond), maxP99)
+			ok = false
+		}
+	}
+
+	rps := float64(total-failed) / duration.Seconds()
+	fmt.Printf("total: %d requests, %.1f req/s, %d errors; the dashboard polled the Collector %d times\n", total, rps, failed, polls)
+	if minRPS > 0 && rps < minRPS {
+		fmt.Printf("FAIL: throughput %.1f req/s is below %.1f\n", rps, minRPS)
+		ok = false
+	}
+	if total > 0 && float64(failed)/float64(total) > maxErrorRate {
+		fmt.Printf("FAIL: error rate %.3f exceeds %.3f\n", float64(failed)/float64(total), maxErrorRate)
+		ok = false
+	}
+	return ok
+}
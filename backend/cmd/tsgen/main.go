@@ -0,0 +1,13 @@
+// Command tsgen prints TypeScript interfaces for the dashboard API types.
+// Run via `make types` from the repository root.
+package main
+
+import (
+	"fmt"
+
+	"github.com/rh-summit-coco/raj-hospital-dashboard/backend/pkg/api"
+)
+
+func main() {
+	fmt.Print(api.TypeScript())
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Defaults of COLLECTOR_MAX_RESPONSE_BYTES and COLLECTOR_MAX_REPORTS, well
+// above what the largest sites' Collectors serve
+const (
+	defaultCollectorMaxResponseBytes = 64 << 20
+	defaultCollectorMaxReports       = 100000
+)
+
+// collectorLimits bounds what a single Collector response may make the
+// dashboard hold in memory, so a misconfigured or malicious Collector can't
+// exhaust it. Zero disables a limit.
+type collectorLimits struct {
+	maxResponseBytes int64
+	maxReports       int
+}
+
+// collectorLimitError reports a Collector response rejected for exceeding a limit
+type collectorLimitError struct {
+	reason string // "size" or "count", the metric label
+	limit  int64
+}
+
+func (e *collectorLimitError) Error() string {
+	if e.reason == "size" {
+		return fmt.Sprintf("collector response exceeds %d bytes (COLLECTOR_MAX_RESPONSE_BYTES)", e.limit)
+	}
+	return fmt.Sprintf("collector response exceeds %d reports (COLLECTOR_MAX_REPORTS)", e.limit)
+}
+
+// decodeArray reads a Collector response holding a JSON array element by
+// element, so the exact bytes of each can be kept, and stops reading as
+// soon as the response exceeds a limit instead of buffering it whole
+func (l collectorLimits) decodeArray(body io.Reader) ([]json.RawMessage, error) {
+	if l.maxResponseBytes > 0 {
+		body = http.MaxBytesReader(nil, io.NopCloser(body), l.maxResponseBytes)
+	}
+	raws, err := l.decodeElements(json.NewDecoder(body))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return nil, &collectorLimitError{reason: "size", limit: l.maxResponseBytes}
+	}
+	return raws, err
+}
+
+func (l collectorLimits) decodeElements(dec *json.Decoder) ([]json.RawMessage, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if token == nil {
+		return nil, nil
+	}
+	if token != json.Delim('[') {
+		return nil, fmt.Errorf("expected a JSON array, got %v", token)
+	}
+
+	var raws []json.RawMessage
+	for dec.More() {
+		if l.maxReports > 0 && len(raws) == l.maxReports {
+			return nil, &collectorLimitError{reason: "count", limit: int64(l.maxReports)}
+		}
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, err
+		}
+		raws = append(raws, raw)
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	return raws, nil
+}
+
+// observeCollectorLimit counts Collector responses rejected for a limit
+func (s *Server) observeCollectorLimit(cluster string, err error) {
+	var limitErr *collectorLimitError
+	if errors.As(err, &limitErr) {
+		s.metrics.Inc("dashboard_collector_responses_rejected_total",
+			"Collector responses rejected for exceeding COLLECTOR_MAX_RESPONSE_BYTES or COLLECTOR_MAX_REPORTS.",
+			"cluster", cluster, "reason", limitErr.reason)
+	}
+}
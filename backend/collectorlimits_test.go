@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestCollectorLimits tests that responses over the size or report count
+// limit are rejected, and counted, without being read in full
+func TestCollectorLimits(t *testing.T) {
+	report := `{"pod_name":"pacs","namespace":"icu","attested":true,"timestamp":"2024-05-01T12:00:00Z"}`
+	reports := func(n int) string {
+		return "[" + strings.TrimSuffix(strings.Repeat(report+",", n), ",") + "]"
+	}
+	var body string
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, body)
+	}))
+	defer collector.Close()
+
+	server := &Server{
+		metrics:         newMetrics(),
+		httpClient:      collector.Client(),
+		collectorLimits: collectorLimits{maxResponseBytes: 1024, maxReports: 5},
+	}
+	cluster := ClusterConfig{Name: "east", CollectorURL: collector.URL}
+
+	tests := []struct {
+		body    string
+		reports int
+		err     string
+	}{
+		{reports(5), 5, ""},
+		{"[]", 0, ""},
+		{reports(6), 0, "exceeds 5 reports"},
+		{"[" + report + "," + `{"pod_name":"` + strings.Repeat("x", 2048) + `"}]`, 0, "exceeds 1024 bytes"},
+		{`{"reports":[]}`, 0, "expected a JSON array"},
+	}
+	for _, tt := range tests {
+		body = tt.body
+		got, err := server.fetchClusterReports(context.Background(), cluster)
+		if tt.err == "" && (err != nil || len(got) != tt.reports) {
+			t.Errorf("Expected %d reports, got %d: %v", tt.reports, len(got), err)
+		}
+		if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+			t.Errorf("Expected an error containing %q, got %v", tt.err, err)
+		}
+	}
+
+	if got := server.metrics.Value("dashboard_collector_responses_rejected_total", "cluster", "east", "reason", "count"); got != 1 {
+		t.Errorf("Expected 1 response rejected for its count, got %v", got)
+	}
+	if got := server.metrics.Value("dashboard_collector_responses_rejected_total", "cluster", "east", "reason", "size"); got != 1 {
+		t.Errorf("Expected 1 response rejected for its size, got %v", got)
+	}
+}
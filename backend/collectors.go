@@ -0,0 +1,254 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const collectorsDoc = "collectors"
+
+// CollectorRegistry holds the Collectors registered at runtime through the
+// admin API, persisted to the store, so onboarding a cluster needs no
+// redeployment. They are polled alongside those of CLUSTERS_CONFIG.
+type CollectorRegistry struct {
+	mu       sync.Mutex
+	clusters map[string]*ClusterConfig
+	store    *Store
+}
+
+// newCollectorRegistry loads persisted registrations
+func newCollectorRegistry(store *Store) (*CollectorRegistry, error) {
+	cr := &CollectorRegistry{
+		clusters: make(map[string]*ClusterConfig),
+		store:    store,
+	}
+	if _, err := store.LoadDoc(collectorsDoc, &cr.clusters); err != nil {
+		return nil, fmt.Errorf("failed to load registered collectors: %w", err)
+	}
+	for name, cluster := range cr.clusters {
+		// Keep the registration; without its CA bundle, polls of a
+		// Collector with a private CA fail verification until it's fixed
+		if err := cluster.configureTLS(); err != nil {
+			log.Printf("Warning: registered collector %s: %v", name, err)
+		}
+	}
+	return cr, nil
+}
+
+// List returns the registered Collectors, sorted by cluster name
+func (cr *CollectorRegistry) List() []ClusterConfig {
+	if cr == nil {
+		return nil
+	}
+
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	list := make([]ClusterConfig, 0, len(cr.clusters))
+	for _, cluster := range cr.clusters {
+		list = append(list, *cluster)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Has reports whether a cluster's Collector was registered at runtime
+func (cr *CollectorRegistry) Has(name string) bool {
+	if cr == nil {
+		return false
+	}
+
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	_, ok := cr.clusters[name]
+	return ok
+}
+
+// Add registers a Collector. Returns false if its cluster is already registered.
+func (cr *CollectorRegistry) Add(cluster ClusterConfig) bool {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	if _, ok := cr.clusters[cluster.Name]; ok {
+		return false
+	}
+	cr.clusters[cluster.Name] = &cluster
+	cr.persistLocked()
+	return true
+}
+
+// Remove unregisters and returns a Collector
+func (cr *CollectorRegistry) Remove(name string) *ClusterConfig {
+	if cr == nil {
+		return nil
+	}
+
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	cluster, ok := cr.clusters[name]
+	if !ok {
+		return nil
+	}
+	delete(cr.clusters, name)
+	cr.persistLocked()
+	return cluster
+}
+
+// persistLocked saves the registrations to the store. Caller must hold mu.
+func (cr *CollectorRegistry) persistLocked() {
+	if err := cr.store.SaveDoc(collectorsDoc, cr.clusters); err != nil {
+		log.Printf("Failed to persist registered collectors: %v", err)
+	}
+}
+
+// validateCollector checks a registration against the configured clusters
+func (s *Server) validateCollector(cluster *ClusterConfig) error {
+	if cluster.Name == "" || strings.Contains(cluster.Name, "/") {
+		return fmt.Errorf("name is required and may not contain '/'")
+	}
+	urls := []string{cluster.CollectorURL}
+	if cluster.SecondaryCollectorURL != "" {
+		urls = append(urls, cluster.SecondaryCollectorURL)
+	}
+	for _, u := range urls {
+		parsed, err := url.Parse(u)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("invalid collector URL %q, expected http(s)://host[:port]", u)
+		}
+	}
+	cluster.CollectorURL = strings.TrimRight(cluster.CollectorURL, "/")
+	cluster.SecondaryCollectorURL = strings.TrimRight(cluster.SecondaryCollectorURL, "/")
+	return cluster.configureTLS()
+}
+
+// configuredCluster reports whether a cluster is defined by the deployment
+// itself rather than registered at runtime
+func (s *Server) configuredCluster(name string) bool {
+	if len(s.clusters) == 0 {
+		return name == s.localCluster
+	}
+	for _, cluster := range s.clusters {
+		if cluster.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// forgetCluster drops the cached workloads and sync state of a cluster that
+// is no longer polled, returning the resulting "removed" events. Otherwise
+// its last known workloads would be kept as if it were merely unreachable.
+func (s *Server) forgetCluster(name string) []HistoryEvent {
+	s.cacheMutex.Lock()
+	defer s.cacheMutex.Unlock()
+
+	delete(s.clusterState, name)
+	cache := make(map[string]*WorkloadStatus, len(s.statusCache))
+	for key, status := range s.statusCache {
+		if status.Cluster != name {
+			cache[key] = status
+		}
+	}
+	events := diffCaches(s.statusCache, cache, time.Now())
+	s.statusCache = cache
+	if len(events) > 0 {
+		s.bumpGenerationLocked()
+		s.observeOverallStatus()
+	}
+	return events
+}
+
+// withoutCredentials returns a registration fit for API responses
+func withoutCredentials(cluster ClusterConfig) ClusterConfig {
+	cluster.Token = ""
+	return cluster
+}
+
+// handleCollectors lists (GET) or registers (POST) Collectors at runtime.
+// A registered Collector is polled at once.
+// GET/POST /api/admin/collectors
+func (s *Server) handleCollectors(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	identity, ok := requireAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		registered := s.collectors.List()
+		list := make([]ClusterConfig, 0, len(registered))
+		for _, cluster := range registered {
+			list = append(list, withoutCredentials(cluster))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(list)
+		return
+	}
+
+	if s.collectors == nil {
+		http.Error(w, "runtime collector registration is not enabled", http.StatusServiceUnavailable)
+		return
+	}
+	var cluster ClusterConfig
+	if !decodeValid(w, r, collectorSchema, &cluster) {
+		return
+	}
+	if err := s.validateCollector(&cluster); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if s.configuredCluster(cluster.Name) || !s.collectors.Add(cluster) {
+		http.Error(w, fmt.Sprintf("cluster %q already exists", cluster.Name), http.StatusConflict)
+		return
+	}
+	s.audit.RecordRequest(r, identity.Name, "collector.register", cluster.Name, cluster.CollectorURL)
+	log.Printf("Registered cluster %s with Attestation Collector %s", cluster.Name, cluster.CollectorURL)
+
+	select {
+	case s.refresh <- struct{}{}:
+	default: // a refresh is already pending
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(withoutCredentials(cluster))
+}
+
+// handleCollector unregisters a Collector added at runtime and drops its
+// cluster's workloads
+// DELETE /api/admin/collectors/{name}
+func (s *Server) handleCollector(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	identity, ok := requireAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, "/api/admin/collectors/")
+	if s.configuredCluster(name) {
+		http.Error(w, "cluster is defined by the deployment configuration and can't be removed at runtime", http.StatusConflict)
+		return
+	}
+	if s.collectors.Remove(name) == nil {
+		http.Error(w, "collector not registered", http.StatusNotFound)
+		return
+	}
+	s.audit.RecordRequest(r, identity.Name, "collector.remove", name, "")
+	log.Printf("Removed cluster %s", name)
+	s.recordEvents(s.forgetCluster(name))
+	w.WriteHeader(http.StatusNoContent)
+}
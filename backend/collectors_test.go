@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRuntimeCollectors tests registering a Collector at runtime, polling
+// it alongside the configured one, and removing it again
+func TestRuntimeCollectors(t *testing.T) {
+	collectorFor := func(pod, token string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if token != "" && r.Header.Get("Authorization") != "Bearer "+token {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			json.NewEncoder(w).Encode([]CollectorReport{{PodName: pod, Namespace: "icu", Attested: true, Timestamp: time.Now()}})
+		}))
+	}
+	local := collectorFor("monitor", "")
+	defer local.Close()
+	north := collectorFor("sepsis-model", "north-token")
+	defer north.Close()
+
+	store, err := openStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	collectors, err := newCollectorRegistry(store)
+	if err != nil {
+		t.Fatalf("Failed to create collector registry: %v", err)
+	}
+	audit, _ := newAuditLog(nil)
+	server := &Server{
+		collectorURL: local.URL,
+		localCluster: "local",
+		httpClient:   &http.Client{Timeout: 5 * time.Second},
+		statusCache:  make(map[string]*WorkloadStatus),
+		collectors:   collectors,
+		audit:        audit,
+		refresh:      make(chan struct{}, 1),
+	}
+	admin := &Identity{Name: "ops", Roles: []string{adminRole}}
+
+	tests := []struct {
+		identity *Identity
+		body     string
+		want     int
+	}{
+		{&Identity{Name: "raj"}, `{"name":"north","collector_url":"` + north.URL + `"}`, http.StatusForbidden},
+		{admin, `{"name":"north"}`, http.StatusBadRequest},
+		{admin, `{"name":"north","collector_url":"ftp://collector"}`, http.StatusBadRequest},
+		{admin, `{"name":"north","collector_url":"` + north.URL + `","ca_file":"/nonexistent"}`, http.StatusBadRequest},
+		{admin, `{"name":"local","collector_url":"` + north.URL + `"}`, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		server.handleCollectors(w, ackRequestAs(tt.identity, "POST", "/api/admin/collectors", tt.body))
+		if w.Code != tt.want {
+			t.Errorf("POST %s: expected %d, got %d", tt.body, tt.want, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	server.handleCollectors(w, ackRequestAs(admin, "POST", "/api/admin/collectors", `{"name":"north","collector_url":"`+north.URL+`/","token":"north-token"}`))
+	if w.Code != http.StatusCreated || strings.Contains(w.Body.String(), "north-token") {
+		t.Fatalf("Expected 201 without the token echoed, got %d: %s", w.Code, w.Body.String())
+	}
+	select {
+	case <-server.refresh:
+	default:
+		t.Error("Expected registration to trigger a poll")
+	}
+	w = httptest.NewRecorder()
+	server.handleCollectors(w, ackRequestAs(admin, "POST", "/api/admin/collectors", `{"name":"north","collector_url":"`+north.URL+`"}`))
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected 409 for a second registration, got %d", w.Code)
+	}
+
+	server.fetchFromCollector()
+	if status := server.statusCache["icu/sepsis-model"]; status == nil || status.Cluster != "north" {
+		t.Errorf("Expected the registered cluster's workload, got %+v", status)
+	}
+	if _, ok := server.statusCache["icu/monitor"]; !ok {
+		t.Error("Expected the configured Collector to still be polled")
+	}
+
+	reloaded, _ := newCollectorRegistry(store)
+	if list := reloaded.List(); len(list) != 1 || list[0].Token != "north-token" || list[0].CollectorURL != north.URL {
+		t.Errorf("Expected the persisted registration, got %+v", list)
+	}
+	w = httptest.NewRecorder()
+	server.handleCollectors(w, ackRequestAs(admin, "GET", "/api/admin/collectors", ""))
+	if !strings.Contains(w.Body.String(), `"name":"north"`) || strings.Contains(w.Body.String(), "north-token") {
+		t.Errorf("Expected the registration listed without its token, got %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	server.handleCollector(w, ackRequestAs(admin, "DELETE", "/api/admin/collectors/local", ""))
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected 409 removing a configured cluster, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	server.handleCollector(w, ackRequestAs(admin, "DELETE", "/api/admin/collectors/north", ""))
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Expected 204, got %d", w.Code)
+	}
+	if _, ok := server.statusCache["icu/sepsis-model"]; ok {
+		t.Error("Expected the removed cluster's workloads to be dropped")
+	}
+	if len(server.collectorTargets()) != 1 {
+		t.Errorf("Expected only the configured Collector to remain, got %+v", server.collectorTargets())
+	}
+
+	entries := server.audit.Entries(time.Time{})
+	if len(entries) != 2 || entries[0].Action != "collector.register" || entries[1].Action != "collector.remove" {
+		t.Errorf("Expected register and remove audit entries, got %+v", entries)
+	}
+}
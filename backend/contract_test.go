@@ -0,0 +1,322 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rh-summit-coco/raj-hospital-dashboard/backend/pkg/api"
+)
+
+// collectorSpecPath is the vendored OpenAPI document of the Collector
+const collectorSpecPath = "testdata/collector-openapi.json"
+
+// collectorEndpoints maps the Collector endpoints the dashboard reads to
+// the schema the dashboard decodes their response items with
+var collectorEndpoints = map[string]*api.Schema{
+	"/api/v1/reports":      collectorReportSchema,
+	"/api/v1/node-reports": api.JSONSchema(NodeReport{}, false),
+}
+
+// ignoredCollectorFields are Collector fields the dashboard deliberately
+// doesn't decode, by "schema.field"
+var ignoredCollectorFields = map[string]bool{}
+
+// loadCollectorSpec reads the vendored spec and returns the item schema of
+// each endpoint's 200 response, with every $ref resolved
+func loadCollectorSpec(t *testing.T) map[string]map[string]interface{} {
+	t.Helper()
+	data, err := os.ReadFile(collectorSpecPath)
+	if err != nil {
+		t.Fatalf("Failed to read Collector spec: %v", err)
+	}
+	var spec map[string]interface{}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		t.Fatalf("Invalid Collector spec: %v", err)
+	}
+
+	items := make(map[string]map[string]interface{})
+	for path := range collectorEndpoints {
+		response, ok := lookupPointer(spec, "#/paths/"+strings.ReplaceAll(path, "/", "~1")+"/get/responses/200/content/application~1json/schema").(map[string]interface{})
+		if !ok {
+			t.Errorf("The Collector spec no longer defines GET %s", path)
+			continue
+		}
+		resolved := resolveRefs(t, spec, response, 0).(map[string]interface{})
+		item, ok := resolved["items"].(map[string]interface{})
+		if !ok {
+			t.Errorf("GET %s no longer returns an array", path)
+			continue
+		}
+		items[path] = item
+	}
+	return items
+}
+
+// lookupPointer resolves a local JSON pointer such as "#/components/schemas/X"
+func lookupPointer(doc interface{}, pointer string) interface{} {
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "#/"), "/") {
+		object, ok := doc.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		doc = object[strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")]
+	}
+	return doc
+}
+
+// resolveRefs returns a copy of node with every $ref replaced by its target
+func resolveRefs(t *testing.T, spec, node interface{}, depth int) interface{} {
+	if depth > 32 {
+		t.Fatalf("Collector spec schemas are recursive")
+	}
+	switch node := node.(type) {
+	case map[string]interface{}:
+		if ref, ok := node["$ref"].(string); ok {
+			target := lookupPointer(spec, ref)
+			if target == nil {
+				t.Fatalf("Unresolved $ref %s in Collector spec", ref)
+			}
+			return resolveRefs(t, spec, target, depth+1)
+		}
+		resolved := make(map[string]interface{}, len(node))
+		for key, value := range node {
+			resolved[key] = resolveRefs(t, spec, value, depth+1)
+		}
+		return resolved
+	case []interface{}:
+		resolved := make([]interface{}, len(node))
+		for i, value := range node {
+			resolved[i] = resolveRefs(t, spec, value, depth+1)
+		}
+		return resolved
+	default:
+		return node
+	}
+}
+
+// schemaTypeSet returns the types a schema allows, other than null
+func schemaTypeSet(schema map[string]interface{}) []string {
+	var types []string
+	switch value := schema["type"].(type) {
+	case string:
+		types = []string{value}
+	case []interface{}:
+		for _, v := range value {
+			types = append(types, fmt.Sprint(v))
+		}
+	}
+	var set []string
+	for _, typ := range types {
+		if typ != "null" {
+			set = append(set, typ)
+		}
+	}
+	sort.Strings(set)
+	return set
+}
+
+// schemaMap converts one of our schemas to the generic form of the spec
+func schemaMap(schema *api.Schema) map[string]interface{} {
+	data, _ := json.Marshal(schema)
+	var m map[string]interface{}
+	json.Unmarshal(data, &m)
+	return m
+}
+
+// compareSchemas lists incompatibilities between the Collector's schema of
+// a value and the schema the dashboard decodes it with
+func compareSchemas(path string, theirs, ours map[string]interface{}) []string {
+	var problems []string
+	theirTypes, ourTypes := schemaTypeSet(theirs), schemaTypeSet(ours)
+	for _, typ := range theirTypes {
+		if !containsString(ourTypes, typ) && !(typ == "integer" && containsString(ourTypes, "number")) {
+			problems = append(problems, fmt.Sprintf("%s: the Collector sends %v, the dashboard decodes %v", path, theirTypes, ourTypes))
+			break
+		}
+	}
+	if theirs["format"] != ours["format"] && ours["format"] != nil {
+		problems = append(problems, fmt.Sprintf("%s: the Collector sends format %v, the dashboard expects %v", path, theirs["format"], ours["format"]))
+	}
+
+	theirProps, _ := theirs["properties"].(map[string]interface{})
+	ourProps, _ := ours["properties"].(map[string]interface{})
+	for name, prop := range theirProps {
+		field := path + "." + name
+		ourProp, ok := ourProps[name].(map[string]interface{})
+		if !ok {
+			if !ignoredCollectorFields[field] {
+				problems = append(problems, fmt.Sprintf("%s: sent by the Collector but not decoded", field))
+			}
+			continue
+		}
+		problems = append(problems, compareSchemas(field, prop.(map[string]interface{}), ourProp)...)
+	}
+	for name := range ourProps {
+		if _, ok := theirProps[name]; !ok {
+			problems = append(problems, fmt.Sprintf("%s.%s: decoded by the dashboard but no longer sent", path, name))
+		}
+	}
+	theirRequired, _ := theirs["required"].([]interface{})
+	ourRequired, _ := ours["required"].([]interface{})
+	for _, name := range ourRequired {
+		if !containsInterface(theirRequired, name) {
+			problems = append(problems, fmt.Sprintf("%s.%s: required by the dashboard but optional for the Collector", path, name))
+		}
+	}
+
+	if theirItems, ok := theirs["items"].(map[string]interface{}); ok {
+		if ourItems, ok := ours["items"].(map[string]interface{}); ok {
+			problems = append(problems, compareSchemas(path+"[]", theirItems, ourItems)...)
+		}
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+func containsInterface(list []interface{}, value interface{}) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// exampleFor generates a payload from a spec schema: every property when
+// full, only the required ones otherwise. Examples and enums in the spec are
+// used where given.
+func exampleFor(schema map[string]interface{}, name string, full bool) interface{} {
+	if example, ok := schema["example"]; ok {
+		return example
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok && len(enum) > 0 {
+		return enum[len(enum)/2]
+	}
+	types := schemaTypeSet(schema)
+	if len(types) == 0 {
+		return nil
+	}
+	switch types[0] {
+	case "object":
+		object := make(map[string]interface{})
+		required, _ := schema["required"].([]interface{})
+		properties, _ := schema["properties"].(map[string]interface{})
+		for prop, propSchema := range properties {
+			if full || containsInterface(required, prop) {
+				object[prop] = exampleFor(propSchema.(map[string]interface{}), prop, full)
+			}
+		}
+		return object
+	case "array":
+		items, _ := schema["items"].(map[string]interface{})
+		return []interface{}{exampleFor(items, name, full)}
+	case "string":
+		if schema["format"] == "date-time" {
+			return "2026-03-01T12:00:00Z"
+		}
+		return "example-" + name
+	case "integer", "number":
+		return 2.0
+	case "boolean":
+		return true
+	}
+	return nil
+}
+
+// TestCollectorContract tests that every Collector field is decoded with a
+// compatible type, and that what the dashboard requires is always sent
+func TestCollectorContract(t *testing.T) {
+	for path, theirs := range loadCollectorSpec(t) {
+		for _, problem := range compareSchemas(path, theirs, schemaMap(collectorEndpoints[path])) {
+			t.Errorf("Incompatible Collector API: %s", problem)
+		}
+	}
+}
+
+// TestCollectorContractExamples decodes payloads generated from the spec
+// through the same code paths as live Collector responses, and checks that
+// no field is lost or flagged as anomalous
+func TestCollectorContractExamples(t *testing.T) {
+	items := loadCollectorSpec(t)
+	examples := make(map[string][]map[string]interface{})
+	for path, item := range items {
+		for _, full := range []bool{true, false} {
+			examples[path] = append(examples[path], exampleFor(item, "", full).(map[string]interface{}))
+		}
+	}
+
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(examples[r.URL.Path])
+	}))
+	defer collector.Close()
+	server := &Server{httpClient: &http.Client{Timeout: 10 * time.Second}}
+	cluster := ClusterConfig{CollectorURL: collector.URL}
+
+	reports, err := server.fetchClusterReports(context.Background(), cluster)
+	if err != nil {
+		t.Fatalf("Failed to decode the spec's reports: %v", err)
+	}
+	for i, report := range reports {
+		if report.malformed != "" {
+			t.Errorf("Report example %d decoded as malformed: %s", i, report.malformed)
+		}
+		if anomalies := reportAnomalies(report.raw); len(anomalies) > 0 {
+			t.Errorf("Report example %d has anomalies %+v", i, anomalies)
+		}
+		assertRoundTrip(t, "report", examples["/api/v1/reports"][i], report)
+	}
+
+	nodes, err := server.fetchNodeReports(context.Background(), cluster)
+	if err != nil {
+		t.Fatalf("Failed to decode the spec's node reports: %v", err)
+	}
+	for i, node := range nodes {
+		assertRoundTrip(t, "node report", examples["/api/v1/node-reports"][i], node)
+	}
+}
+
+// assertRoundTrip checks that every field of an example survived decoding
+func assertRoundTrip(t *testing.T, kind string, example map[string]interface{}, decoded interface{}) {
+	t.Helper()
+	data, _ := json.Marshal(decoded)
+	var encoded map[string]interface{}
+	json.Unmarshal(data, &encoded)
+	for field, value := range example {
+		if !reflect.DeepEqual(encoded[field], value) {
+			t.Errorf("%s field %s: sent %v, decoded %v", kind, field, value, encoded[field])
+		}
+	}
+}
+
+// TestCollectorContractDetectsDrift tests that typical breaking changes of
+// the Collector API are flagged
+func TestCollectorContractDetectsDrift(t *testing.T) {
+	ours := schemaMap(collectorReportSchema)
+	theirs := loadCollectorSpec(t)["/api/v1/reports"]
+	properties := theirs["properties"].(map[string]interface{})
+	properties["attested"] = map[string]interface{}{"type": "string"}
+	properties["pod_uid"] = map[string]interface{}{"type": "string"}
+	delete(properties, "tcb_version")
+	theirs["required"] = []interface{}{"pod_name", "attested"}
+
+	problems := strings.Join(compareSchemas("reports", theirs, ours), "\n")
+	for _, want := range []string{
+		"reports.attested: the Collector sends [string]",
+		"reports.pod_uid: sent by the Collector but not decoded",
+		"reports.tcb_version: decoded by the dashboard but no longer sent",
+		"reports.namespace: required by the dashboard but optional for the Collector",
+	} {
+		if !strings.Contains(problems, want) {
+			t.Errorf("Expected %q to be flagged, got:\n%s", want, problems)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"log"
+	"time"
+)
+
+// Data sources of /api/status, in order of precedence. A lower source is
+// only shown while no higher one has data.
+const (
+	// sourcePoll is the cache as of the latest successful Collector poll
+	sourcePoll = "poll"
+	// sourceSnapshot is the cache persisted before the last restart, shown
+	// until the first poll succeeds
+	sourceSnapshot = "snapshot"
+	// sourceDemo is the built-in demo data, shown while there are no workloads
+	sourceDemo = "demo"
+)
+
+const statusSnapshotDoc = "status-snapshot"
+
+// statusSnapshot is the workload cache persisted after every successful poll
+type statusSnapshot struct {
+	TakenAt   time.Time                  `json:"taken_at"`
+	Workloads map[string]*WorkloadStatus `json:"workloads"`
+}
+
+// saveSnapshot persists the workload cache, so a restart during a Collector
+// outage still shows the last known state. Caller must not hold cacheMutex.
+func (s *Server) saveSnapshot(now time.Time) {
+	if s.store == nil {
+		return
+	}
+
+	s.cacheMutex.RLock()
+	snapshot := statusSnapshot{TakenAt: now, Workloads: make(map[string]*WorkloadStatus, len(s.statusCache))}
+	for key, status := range s.statusCache {
+		snapshot.Workloads[key] = copyStatus(status)
+	}
+	s.cacheMutex.RUnlock()
+
+	if err := s.store.SaveDoc(statusSnapshotDoc, snapshot); err != nil {
+		log.Printf("Failed to persist status snapshot: %v", err)
+	}
+}
+
+// restoreSnapshot fills the empty cache from the persisted snapshot, if any
+func (s *Server) restoreSnapshot() error {
+	var snapshot statusSnapshot
+	found, err := s.store.LoadDoc(statusSnapshotDoc, &snapshot)
+	if err != nil || !found || len(snapshot.Workloads) == 0 {
+		return err
+	}
+
+	s.cacheMutex.Lock()
+	defer s.cacheMutex.Unlock()
+	s.statusCache = snapshot.Workloads
+	s.snapshotAt = snapshot.TakenAt
+	log.Printf("Restored %d workloads from the status snapshot of %s", len(snapshot.Workloads), snapshot.TakenAt.Format(time.RFC3339))
+	return nil
+}
+
+// dataSourceLocked returns the source of the cache and when its data was
+// current: the latest successful poll, or when the snapshot was taken.
+// Caller must hold cacheMutex.
+func (s *Server) dataSourceLocked() (string, *time.Time) {
+	if len(s.statusCache) == 0 {
+		return sourceDemo, nil
+	}
+
+	var lastSync time.Time
+	for _, state := range s.clusterState {
+		if state.LastSync.After(lastSync) {
+			lastSync = state.LastSync
+		}
+	}
+	if lastSync.IsZero() && !s.snapshotAt.IsZero() {
+		takenAt := s.snapshotAt
+		return sourceSnapshot, &takenAt
+	}
+	if lastSync.IsZero() {
+		return sourcePoll, nil
+	}
+	return sourcePoll, &lastSync
+}
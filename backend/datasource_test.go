@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// statusSource returns the data source and freshness /api/status reports
+func statusSource(t *testing.T, server *Server) (string, *time.Time) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	server.handleStatus(w, httptest.NewRequest("GET", "/api/status", nil))
+	var response DashboardResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return response.DataSource, response.DataAsOf
+}
+
+// TestDataSourcePrecedence tests that a restart shows the persisted snapshot
+// until the Collector is reachable again, and demo data only without either
+func TestDataSourcePrecedence(t *testing.T) {
+	dir := t.TempDir()
+	up := true
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !up {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode([]CollectorReport{{PodName: "pacs", Namespace: "icu", Attested: true, TEEType: "tdx", Timestamp: time.Now()}})
+	}))
+	defer collector.Close()
+
+	newServer := func() *Server {
+		store, err := openStore(dir)
+		if err != nil {
+			t.Fatalf("Failed to open store: %v", err)
+		}
+		return &Server{collectorURL: collector.URL, httpClient: collector.Client(), store: store}
+	}
+
+	server := newServer()
+	if source, asOf := statusSource(t, server); source != sourceDemo || asOf != nil {
+		t.Errorf("Expected demo data before the first poll, got %s as of %v", source, asOf)
+	}
+	server.fetchFromCollector()
+	source, asOf := statusSource(t, server)
+	if source != sourcePoll || asOf == nil || time.Since(*asOf) > time.Minute {
+		t.Errorf("Expected fresh poll data, got %s as of %v", source, asOf)
+	}
+
+	// Restarted during a Collector outage
+	up = false
+	restarted := newServer()
+	if err := restarted.restoreSnapshot(); err != nil {
+		t.Fatalf("Failed to restore snapshot: %v", err)
+	}
+	restarted.fetchFromCollector()
+	source, snapshotAsOf := statusSource(t, restarted)
+	if source != sourceSnapshot || snapshotAsOf == nil || snapshotAsOf.After(*asOf) {
+		t.Errorf("Expected the snapshot taken at the last poll, got %s as of %v", source, snapshotAsOf)
+	}
+	if len(restarted.statusCache) != 1 || restarted.statusCache["icu/pacs"].AttestationStatus != "verified" {
+		t.Errorf("Expected the last known state, got %+v", restarted.statusCache)
+	}
+
+	up = true
+	restarted.fetchFromCollector()
+	if source, _ := statusSource(t, restarted); source != sourcePoll {
+		t.Errorf("Expected poll data once the Collector is back, got %s", source)
+	}
+}
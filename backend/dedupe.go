@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// dedupeReports keeps the freshest report per namespace/pod - the cache
+// key - when the same pod is reported by several Collectors, replicas or
+// clusters. Sources whose verdict differs from the kept report's within the
+// clock skew tolerance are returned as conflicts by key: with timestamps
+// that close, neither can be trusted to be the current state.
+func (s *Server) dedupeReports(reports []CollectorReport) ([]CollectorReport, map[string]string) {
+	index := make(map[string]int)
+	byKey := make(map[string][]CollectorReport)
+	deduped := reports[:0:0]
+	for _, report := range reports {
+		key := report.Namespace + "/" + report.PodName
+		byKey[key] = append(byKey[key], report)
+		if i, ok := index[key]; ok {
+			if report.Timestamp.After(deduped[i].Timestamp) {
+				deduped[i] = report
+			}
+			continue
+		}
+		index[key] = len(deduped)
+		deduped = append(deduped, report)
+	}
+
+	conflicts := make(map[string]string)
+	for key, all := range byKey {
+		if len(all) < 2 {
+			continue
+		}
+		kept := deduped[index[key]]
+		var disagreeing []string
+		for _, other := range all {
+			skew := kept.Timestamp.Sub(other.Timestamp)
+			if other.Attested != kept.Attested && skew <= s.clockSkew.tolerance {
+				disagreeing = append(disagreeing, verdictString(other.Attested)+" from "+other.sourceName())
+			}
+		}
+		if len(disagreeing) > 0 {
+			sort.Strings(disagreeing)
+			conflicts[key] = fmt.Sprintf("%s from %s, but %s", verdictString(kept.Attested), kept.sourceName(), strings.Join(disagreeing, ", "))
+		}
+	}
+	return deduped, conflicts
+}
+
+// sourceName describes where a report came from for conflict details
+func (r *CollectorReport) sourceName() string {
+	if r.Cluster == "" {
+		return r.source
+	}
+	return r.Cluster + " (" + r.source + ")"
+}
+
+// flagReportConflicts records a warning check on every workload whose
+// sources disagreed
+func flagReportConflicts(statuses []*WorkloadStatus, conflicts map[string]string) {
+	for _, status := range statuses {
+		if conflict, ok := conflicts[status.Namespace+"/"+status.Name]; ok {
+			status.Details = "Conflicting reports: " + conflict + ". " + status.Details
+			failCheck(status, "report_conflict", "consistent verdicts from every source", conflict, severityWarning)
+		}
+	}
+}
+
+// reportTimeMemory is how long the newest report time of a workload that left
+// the cache is kept for staleReport
+const reportTimeMemory = 24 * time.Hour
+
+// staleReport reports whether a status carries an older report than one
+// already applied to its workload - a lagging Collector replica, or a report
+// delivered late or twice - so the cache keeps the newer state rather than
+// regressing. Statuses without a report timestamp are never stale. Caller
+// must hold cacheMutex.
+func (s *Server) staleReport(key string, status *WorkloadStatus) bool {
+	reported, err := time.Parse(time.RFC3339, status.Timestamp)
+	if err != nil || reported.IsZero() {
+		return false
+	}
+	if !reported.Before(s.reportTimes[key]) {
+		return false
+	}
+	s.metrics.Inc("dashboard_stale_reports_total", "Reports ignored because a newer report of the workload was already applied.", "cluster", status.Cluster)
+	return true
+}
+
+// advanceReportTimes records the newest report timestamp of each cached
+// workload. Workloads that left the cache are remembered for
+// reportTimeMemory, so a late report doesn't bring one back at an older
+// state. Caller must hold cacheMutex.
+func (s *Server) advanceReportTimes(cache map[string]*WorkloadStatus, now time.Time) {
+	times := make(map[string]time.Time, len(cache))
+	for key, reported := range s.reportTimes {
+		if _, ok := cache[key]; ok || now.Sub(reported) < reportTimeMemory {
+			times[key] = reported
+		}
+	}
+	for key, status := range cache {
+		if reported, err := time.Parse(time.RFC3339, status.Timestamp); err == nil && reported.After(times[key]) {
+			times[key] = reported
+		}
+	}
+	s.reportTimes = times
+}
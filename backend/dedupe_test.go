@@ -0,0 +1,78 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestDedupeReports tests that the freshest report per pod wins and that
+// disagreeing verdicts within the skew tolerance are flagged
+func TestDedupeReports(t *testing.T) {
+	now := time.Now()
+	server := &Server{clockSkew: clockSkewLimits{tolerance: 5 * time.Minute}}
+	reports := []CollectorReport{
+		{PodName: "ai-model", Namespace: "icu", Cluster: "east", Attested: true, Timestamp: now.Add(-time.Minute), source: "http://a"},
+		{PodName: "pacs", Namespace: "radiology", Cluster: "east", Attested: true, Timestamp: now, source: "http://a"},
+		{PodName: "ai-model", Namespace: "icu", Cluster: "west", Attested: false, Timestamp: now, source: "http://b"},
+		{PodName: "pacs", Namespace: "radiology", Cluster: "west", Attested: false, Timestamp: now.Add(-time.Hour), source: "http://b"},
+	}
+
+	deduped, conflicts := server.dedupeReports(reports)
+	if len(deduped) != 2 {
+		t.Fatalf("Expected 2 reports, got %+v", deduped)
+	}
+	if deduped[0].PodName != "ai-model" || deduped[0].Attested || deduped[0].source != "http://b" {
+		t.Errorf("Expected the fresher west ai-model report, got %+v", deduped[0])
+	}
+	if deduped[1].PodName != "pacs" || !deduped[1].Attested {
+		t.Errorf("Expected the fresher east pacs report, got %+v", deduped[1])
+	}
+
+	if len(conflicts) != 1 {
+		t.Fatalf("Expected only ai-model to conflict, got %v", conflicts)
+	}
+	if c := conflicts["icu/ai-model"]; c != "failed from west (http://b), but verified from east (http://a)" {
+		t.Errorf("Unexpected conflict %q", c)
+	}
+
+	statuses := []*WorkloadStatus{failedStatus("icu", "ai-model"), verifiedStatus("radiology", "pacs")}
+	flagReportConflicts(statuses, conflicts)
+	if len(statuses[0].FailedChecks) != 1 || statuses[0].FailedChecks[0].Name != "report_conflict" || !strings.HasPrefix(statuses[0].Details, "Conflicting reports") {
+		t.Errorf("Expected ai-model flagged, got %+v", statuses[0])
+	}
+	if len(statuses[1].FailedChecks) != 0 {
+		t.Errorf("Expected pacs unflagged, got %+v", statuses[1].FailedChecks)
+	}
+}
+
+// TestStaleReportIgnored tests that a report older than one already applied
+// doesn't replace the cached status
+func TestStaleReportIgnored(t *testing.T) {
+	now := time.Now()
+	server := &Server{statusCache: make(map[string]*WorkloadStatus), metrics: newMetrics()}
+	synced := map[string]bool{"": true}
+
+	fresh := verifiedStatus("icu", "monitor")
+	fresh.Timestamp = now.UTC().Format(time.RFC3339)
+	server.applyStatuses([]*WorkloadStatus{fresh}, synced, nil)
+
+	late := failedStatus("icu", "monitor")
+	late.Timestamp = now.Add(-time.Minute).UTC().Format(time.RFC3339)
+	if events := server.applyStatuses([]*WorkloadStatus{late}, synced, nil); len(events) != 0 {
+		t.Errorf("Expected no transition for a late report, got %+v", events)
+	}
+	if status := server.statusCache["icu/monitor"]; status.AttestationStatus != "verified" {
+		t.Errorf("Expected the newer status to be kept, got %s", status.AttestationStatus)
+	}
+	if stale := server.metrics.Value("dashboard_stale_reports_total", "cluster", ""); stale != 1 {
+		t.Errorf("Expected 1 stale report, got %v", stale)
+	}
+
+	// Statuses without a report timestamp, e.g. malformed evidence, still apply
+	malformed := failedStatus("icu", "monitor")
+	server.applyStatuses([]*WorkloadStatus{malformed}, synced, nil)
+	if status := server.statusCache["icu/monitor"]; status.AttestationStatus != "failed" {
+		t.Errorf("Expected the status without a timestamp to apply, got %s", status.AttestationStatus)
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"time"
+)
+
+// changedFields returns the JSON names of the verdict-relevant fields that
+// differ between two states of a workload. Details, timestamps and operator
+// state are left out: they change without the workload changing.
+func changedFields(before, after *WorkloadStatus) []string {
+	var fields []string
+	if before.Attested != after.Attested || before.AttestationStatus != after.AttestationStatus {
+		fields = append(fields, "attestation_status")
+	}
+	if before.GateOneStatus != after.GateOneStatus {
+		fields = append(fields, "gate_one_status")
+	}
+	if before.GateTwoStatus != after.GateTwoStatus {
+		fields = append(fields, "gate_two_status")
+	}
+	if !reflect.DeepEqual(before.Gates, after.Gates) {
+		fields = append(fields, "gates")
+	}
+	if !reflect.DeepEqual(before.TrustVector, after.TrustVector) {
+		fields = append(fields, "trust_vector")
+	}
+	if before.TCBVersion != after.TCBVersion {
+		fields = append(fields, "tcb_version")
+	}
+	if !reflect.DeepEqual(before.ImageDigests, after.ImageDigests) {
+		fields = append(fields, "image_digests")
+	}
+	return fields
+}
+
+// fleetDiff compares two fleet snapshots, each sorted by sortWorkloads
+func fleetDiff(before, after []WorkloadStatus) FleetDiff {
+	diff := FleetDiff{Added: []WorkloadStatus{}, Removed: []WorkloadStatus{}, Changed: []WorkloadChange{}}
+
+	old := make(map[string]*WorkloadStatus, len(before))
+	for i := range before {
+		old[before[i].Namespace+"/"+before[i].Name] = &before[i]
+	}
+	seen := make(map[string]bool, len(after))
+	for i := range after {
+		key := after[i].Namespace + "/" + after[i].Name
+		seen[key] = true
+		prev, ok := old[key]
+		if !ok {
+			diff.Added = append(diff.Added, after[i])
+			continue
+		}
+		if fields := changedFields(prev, &after[i]); len(fields) > 0 {
+			diff.Changed = append(diff.Changed, WorkloadChange{Key: key, Fields: fields, Before: *prev, After: after[i]})
+		}
+	}
+	for i := range before {
+		if !seen[before[i].Namespace+"/"+before[i].Name] {
+			diff.Removed = append(diff.Removed, before[i])
+		}
+	}
+	return diff
+}
+
+// handleDiff returns the workloads added, removed and changed between two
+// instants, e.g. to verify what a maintenance window changed
+// GET /api/diff?from=2024-05-01T02:00:00Z&to=2024-05-01T06:00:00Z
+func (s *Server) handleDiff(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	now := time.Now()
+	from, err := time.Parse(time.RFC3339, r.URL.Query().Get("from"))
+	if err != nil {
+		http.Error(w, "from parameter required (RFC3339)", http.StatusBadRequest)
+		return
+	}
+	to := now
+	if raw := r.URL.Query().Get("to"); raw != "" {
+		if to, err = time.Parse(time.RFC3339, raw); err != nil {
+			http.Error(w, "invalid to parameter, expected RFC3339", http.StatusBadRequest)
+			return
+		}
+	}
+	if !from.Before(to) {
+		http.Error(w, "from must be before to", http.StatusBadRequest)
+		return
+	}
+	if to.After(now) {
+		http.Error(w, "time must not be in the future", http.StatusBadRequest)
+		return
+	}
+
+	snapshot := func(at time.Time) []WorkloadStatus {
+		workloads := s.history.StatusAt(at)
+		filtered := workloads[:0]
+		for i := range workloads {
+			if matchesCluster(r, &workloads[i]) {
+				filtered = append(filtered, workloads[i])
+			}
+		}
+		return filtered
+	}
+	diff := fleetDiff(snapshot(from), snapshot(to))
+	diff.From, diff.To = from, to
+
+	noteWorkloads(r, diff.Added)
+	noteWorkloads(r, diff.Removed)
+	for _, change := range diff.Changed {
+		noteWorkloads(r, []WorkloadStatus{change.After})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(diff)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestHandleDiff tests the fleet diff between two instants of history
+func TestHandleDiff(t *testing.T) {
+	t0 := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	patched := verifiedStatus("icu", "pacs")
+	patched.TrustVector = &TrustVector{Hardware: 2, Configuration: 32}
+	detailsOnly := verifiedStatus("icu", "lims")
+	detailsOnly.Details = "re-attested"
+
+	history, _ := newHistory(nil, 0)
+	history.Record([]HistoryEvent{
+		{Time: t0, Key: "icu/ai-model", Type: "added", Status: verifiedStatus("icu", "ai-model")},
+		{Time: t0, Key: "icu/pacs", Type: "added", Status: verifiedStatus("icu", "pacs")},
+		{Time: t0, Key: "icu/old", Type: "added", Status: verifiedStatus("icu", "old")},
+		{Time: t0, Key: "icu/lims", Type: "added", Status: verifiedStatus("icu", "lims")},
+		{Time: t0.Add(time.Hour), Key: "icu/ai-model", Type: "changed", Status: failedStatus("icu", "ai-model")},
+		{Time: t0.Add(time.Hour), Key: "icu/pacs", Type: "changed", Status: patched},
+		{Time: t0.Add(time.Hour), Key: "icu/old", Type: "removed", Status: verifiedStatus("icu", "old")},
+		{Time: t0.Add(time.Hour), Key: "icu/lims", Type: "changed", Status: detailsOnly},
+		{Time: t0.Add(time.Hour), Key: "icu/new", Type: "added", Status: verifiedStatus("icu", "new")},
+	})
+	server := &Server{history: history}
+
+	w := httptest.NewRecorder()
+	server.handleDiff(w, httptest.NewRequest("GET", "/api/diff?from=2024-05-01T00:30:00Z&to=2024-05-01T02:00:00Z", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var diff FleetDiff
+	json.NewDecoder(w.Body).Decode(&diff)
+
+	if len(diff.Added) != 1 || diff.Added[0].Name != "new" {
+		t.Errorf("Expected icu/new added, got %+v", diff.Added)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0].Name != "old" {
+		t.Errorf("Expected icu/old removed, got %+v", diff.Removed)
+	}
+	if len(diff.Changed) != 2 {
+		t.Fatalf("Expected ai-model and pacs changed, got %+v", diff.Changed)
+	}
+	if c := diff.Changed[0]; c.Key != "icu/ai-model" || !containsString(c.Fields, "attestation_status") || c.Before.Attested == c.After.Attested {
+		t.Errorf("Expected ai-model verdict change, got %+v", c)
+	}
+	if c := diff.Changed[1]; c.Key != "icu/pacs" || len(c.Fields) != 1 || c.Fields[0] != "trust_vector" {
+		t.Errorf("Expected pacs trust vector change, got %+v", c)
+	}
+
+	for _, query := range []string{"", "?from=2024-05-01T02:00:00Z&to=2024-05-01T00:00:00Z", "?from=2024-05-01T00:00:00Z&to=2999-01-01T00:00:00Z"} {
+		w = httptest.NewRecorder()
+		server.handleDiff(w, httptest.NewRequest("GET", "/api/diff"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %q, got %d", query, w.Code)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// collectorDiscovery finds Collector replicas through the EndpointSlices of
+// their Service, so scaling the Collector needs no dashboard reconfiguration.
+// The slices are re-listed on every poll, which keeps up with scaling at
+// the poll interval without a long-running watch.
+type collectorDiscovery struct {
+	kube      *kubeClient
+	namespace string
+	selector  string // EndpointSlice label selector
+	port      string // port name; the first port if empty
+	scheme    string
+}
+
+// kubeEndpointSliceList is the subset of an EndpointSlice list the
+// dashboard cares about
+type kubeEndpointSliceList struct {
+	Items []struct {
+		AddressType string `json:"addressType"`
+		Endpoints   []struct {
+			Addresses  []string `json:"addresses"`
+			Conditions struct {
+				Ready *bool `json:"ready"`
+			} `json:"conditions"`
+		} `json:"endpoints"`
+		Ports []struct {
+			Name string `json:"name"`
+			Port *int   `json:"port"`
+		} `json:"ports"`
+	} `json:"items"`
+}
+
+// newCollectorDiscovery discovers Collectors in namespace, or in the
+// dashboard's own namespace if empty
+func newCollectorDiscovery(kube *kubeClient, namespace, selector, port, scheme string) (*collectorDiscovery, error) {
+	if selector == "" {
+		return nil, errors.New("a label selector is required")
+	}
+	if namespace == "" {
+		data, err := os.ReadFile(serviceAccountDir + "/namespace")
+		if err != nil {
+			return nil, fmt.Errorf("failed to determine namespace: %w", err)
+		}
+		namespace = strings.TrimSpace(string(data))
+	}
+	if scheme == "" {
+		scheme = "http"
+	}
+	return &collectorDiscovery{kube: kube, namespace: namespace, selector: selector, port: port, scheme: scheme}, nil
+}
+
+// endpoints returns the base URLs of every ready Collector endpoint
+func (d *collectorDiscovery) endpoints() ([]string, error) {
+	var slices kubeEndpointSliceList
+	path := fmt.Sprintf("/apis/discovery.k8s.io/v1/namespaces/%s/endpointslices?labelSelector=%s",
+		url.PathEscape(d.namespace), url.QueryEscape(d.selector))
+	if err := d.kube.get(path, &slices); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var urls []string
+	for _, slice := range slices.Items {
+		if slice.AddressType == "FQDN" {
+			continue
+		}
+		port := 0
+		for _, p := range slice.Ports {
+			if p.Port != nil && (d.port == "" || p.Name == d.port) {
+				port = *p.Port
+				break
+			}
+		}
+		if port == 0 {
+			continue
+		}
+		for _, endpoint := range slice.Endpoints {
+			// A nil ready condition means ready, per the EndpointSlice API
+			if endpoint.Conditions.Ready != nil && !*endpoint.Conditions.Ready {
+				continue
+			}
+			if len(endpoint.Addresses) == 0 {
+				continue
+			}
+			u := d.scheme + "://" + net.JoinHostPort(endpoint.Addresses[0], strconv.Itoa(port))
+			if !seen[u] {
+				seen[u] = true
+				urls = append(urls, u)
+			}
+		}
+	}
+	sort.Strings(urls)
+	return urls, nil
+}
+
+// fetchDiscoveredReports polls every discovered Collector replica for the
+// cluster and returns all their reports; duplicates are resolved with those
+// of other sources (see dedupeReports). Replicas that fail are skipped; it is
+// an error only if none answer.
+func (s *Server) fetchDiscoveredReports(ctx context.Context, cluster ClusterConfig) ([]CollectorReport, error) {
+	urls, err := s.discovery.endpoints()
+	if err != nil {
+		return nil, fmt.Errorf("collector discovery failed: %w", err)
+	}
+	if len(urls) == 0 {
+		return nil, errors.New("no ready collector endpoints discovered")
+	}
+
+	var reports []CollectorReport
+	var lastErr error
+	answered := 0
+	for _, u := range urls {
+		replica := cluster
+		replica.CollectorURL = u
+		replicaReports, err := s.fetchClusterReports(ctx, replica)
+		if err != nil {
+			log.Printf("Failed to fetch from Collector replica %s: %v", u, err)
+			lastErr = err
+			continue
+		}
+		answered++
+		reports = append(reports, replicaReports...)
+	}
+	if answered == 0 {
+		return nil, fmt.Errorf("no collector replica answered: %w", lastErr)
+	}
+	return reports, nil
+}
+
+// fetchCollectorReports fetches a cluster's reports, from every discovered
+// replica when discovery is enabled for the local cluster
+func (s *Server) fetchCollectorReports(ctx context.Context, cluster ClusterConfig) ([]CollectorReport, error) {
+	if s.discovery != nil && len(s.clusters) == 0 && !s.collectors.Has(cluster.Name) {
+		return s.fetchDiscoveredReports(ctx, cluster)
+	}
+	return s.fetchClusterReports(ctx, cluster)
+}
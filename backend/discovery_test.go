@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// mockEndpointSlices returns a Kubernetes API serving one EndpointSlice per
+// Collector, plus a not-ready endpoint that must be skipped
+func mockEndpointSlices(t *testing.T, collectors ...*httptest.Server) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/apis/discovery.k8s.io/v1/namespaces/attestation/endpointslices" ||
+			r.URL.Query().Get("labelSelector") != "kubernetes.io/service-name=collector" {
+			http.NotFound(w, r)
+			return
+		}
+		items := ""
+		for i, collector := range collectors {
+			u, _ := url.Parse(collector.URL)
+			if i > 0 {
+				items += ","
+			}
+			items += fmt.Sprintf(`{"addressType":"IPv4","ports":[{"name":"metrics","port":9090},{"name":"http","port":%s}],
+				"endpoints":[{"addresses":["%s"],"conditions":{"ready":true}},{"addresses":["10.0.0.99"],"conditions":{"ready":false}}]}`,
+				u.Port(), u.Hostname())
+		}
+		fmt.Fprintf(w, `{"items":[%s]}`, items)
+	}))
+}
+
+// TestCollectorDiscovery tests that every ready replica is polled and their
+// reports merged
+func TestCollectorDiscovery(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	replicaA := newMockCollector(t, "", []CollectorReport{
+		{PodName: "ai-model", Namespace: "icu", Attested: true, Timestamp: now.Add(-time.Minute)},
+		{PodName: "pacs", Namespace: "radiology", Attested: true, Timestamp: now},
+	})
+	defer replicaA.Close()
+	replicaB := newMockCollector(t, "", []CollectorReport{
+		{PodName: "ai-model", Namespace: "icu", Attested: false, Timestamp: now},
+	})
+	defer replicaB.Close()
+	api := mockEndpointSlices(t, replicaA, replicaB)
+	defer api.Close()
+
+	discovery, err := newCollectorDiscovery(&kubeClient{baseURL: api.URL, httpClient: api.Client()}, "attestation", "kubernetes.io/service-name=collector", "http", "")
+	if err != nil {
+		t.Fatalf("Failed to create discovery: %v", err)
+	}
+	urls, err := discovery.endpoints()
+	if err != nil {
+		t.Fatalf("Failed to list endpoints: %v", err)
+	}
+	if len(urls) != 2 {
+		t.Fatalf("Expected 2 ready endpoints, got %v", urls)
+	}
+
+	server := &Server{httpClient: &http.Client{Timeout: time.Second}, discovery: discovery}
+	reports, err := server.fetchCollectorReports(context.Background(), ClusterConfig{Name: "local"})
+	if err != nil {
+		t.Fatalf("Failed to fetch reports: %v", err)
+	}
+	if len(reports) != 3 {
+		t.Fatalf("Expected 3 reports from both replicas, got %+v", reports)
+	}
+	if reports[0].source != urls[0] || reports[2].source != urls[1] {
+		t.Errorf("Expected reports to record their replica, got %q and %q", reports[0].source, reports[2].source)
+	}
+
+	replicaB.Close()
+	if reports, err = server.fetchCollectorReports(context.Background(), ClusterConfig{Name: "local"}); err != nil || len(reports) != 2 {
+		t.Errorf("Expected the remaining replica's reports, got %d reports and %v", len(reports), err)
+	}
+	replicaA.Close()
+	if _, err := server.fetchCollectorReports(context.Background(), ClusterConfig{Name: "local"}); err == nil {
+		t.Error("Expected an error when no replica answers")
+	}
+}
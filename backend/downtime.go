@@ -0,0 +1,225 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const downtimeDoc = "planned-downtime"
+
+// DowntimeStore holds the planned downtime windows operators recorded,
+// persisted to the store. Unlike the recurring MAINTENANCE_CONFIG windows,
+// which only change how live violations are shown, planned downtime is
+// excluded from the MTTR and uptime reports after the fact, so compliance
+// figures reflect unplanned incidents only.
+type DowntimeStore struct {
+	mu      sync.Mutex
+	windows map[string]*DowntimeWindow
+	store   *Store
+}
+
+// newDowntimeStore loads persisted downtime windows
+func newDowntimeStore(store *Store) (*DowntimeStore, error) {
+	d := &DowntimeStore{
+		windows: make(map[string]*DowntimeWindow),
+		store:   store,
+	}
+	if _, err := store.LoadDoc(downtimeDoc, &d.windows); err != nil {
+		return nil, fmt.Errorf("failed to load planned downtime: %w", err)
+	}
+	return d, nil
+}
+
+// List returns all downtime windows, sorted by start
+func (d *DowntimeStore) List() []DowntimeWindow {
+	if d == nil {
+		return nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	list := make([]DowntimeWindow, 0, len(d.windows))
+	for _, window := range d.windows {
+		list = append(list, *window)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].Start.Equal(list[j].Start) {
+			return list[i].Start.Before(list[j].Start)
+		}
+		return list[i].ID < list[j].ID
+	})
+	return list
+}
+
+// Add records a downtime window
+func (d *DowntimeStore) Add(window DowntimeWindow) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.windows[window.ID] = &window
+	d.persistLocked()
+}
+
+// Remove deletes and returns a downtime window
+func (d *DowntimeStore) Remove(id string) *DowntimeWindow {
+	if d == nil {
+		return nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	window, ok := d.windows[id]
+	if !ok {
+		return nil
+	}
+	delete(d.windows, id)
+	d.persistLocked()
+	return window
+}
+
+// persistLocked saves downtime windows to the store. Caller must hold mu.
+func (d *DowntimeStore) persistLocked() {
+	if err := d.store.SaveDoc(downtimeDoc, d.windows); err != nil {
+		log.Printf("Failed to persist planned downtime: %v", err)
+	}
+}
+
+// plannedOverlap returns how much of [start, end) falls within the downtime
+// windows covering namespace. Overlapping windows are only counted once.
+func plannedOverlap(windows []DowntimeWindow, namespace string, start, end time.Time) time.Duration {
+	type span struct{ start, end time.Time }
+	var spans []span
+	for _, window := range windows {
+		if len(window.Namespaces) > 0 && !containsString(window.Namespaces, namespace) {
+			continue
+		}
+		from, to := window.Start, window.End
+		if from.Before(start) {
+			from = start
+		}
+		if to.After(end) {
+			to = end
+		}
+		if from.Before(to) {
+			spans = append(spans, span{from, to})
+		}
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start.Before(spans[j].start) })
+
+	var total time.Duration
+	var covered time.Time
+	for _, sp := range spans {
+		if sp.start.Before(covered) {
+			sp.start = covered
+		}
+		if sp.start.Before(sp.end) {
+			total += sp.end.Sub(sp.start)
+			covered = sp.end
+		}
+	}
+	return total
+}
+
+// handleDowntime lists (GET) or records (POST) planned downtime windows
+// GET/POST /api/downtime
+func (s *Server) handleDowntime(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		windows := s.downtime.List()
+		if windows == nil {
+			windows = []DowntimeWindow{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(windows)
+
+	case http.MethodPost:
+		identity := identityFromContext(r.Context())
+		if identity == nil {
+			http.Error(w, "recording planned downtime requires an authenticated identity", http.StatusUnauthorized)
+			return
+		}
+		if s.downtime == nil {
+			http.Error(w, "planned downtime is not enabled", http.StatusServiceUnavailable)
+			return
+		}
+
+		var req DowntimeRequest
+		if !decodeValid(w, r, downtimeSchema, &req) {
+			return
+		}
+		if !req.End.After(req.Start) {
+			http.Error(w, "end must be after start", http.StatusBadRequest)
+			return
+		}
+		if strings.TrimSpace(req.Reason) == "" {
+			http.Error(w, "reason is required", http.StatusBadRequest)
+			return
+		}
+		for _, ns := range req.Namespaces {
+			if ns == "" || strings.Contains(ns, "/") {
+				http.Error(w, fmt.Sprintf("invalid namespace %q", ns), http.StatusBadRequest)
+				return
+			}
+		}
+
+		id := make([]byte, 8)
+		rand.Read(id)
+		window := DowntimeWindow{
+			ID:         hex.EncodeToString(id),
+			Start:      req.Start.UTC(),
+			End:        req.End.UTC(),
+			Namespaces: req.Namespaces,
+			Reason:     req.Reason,
+			CreatedBy:  identity.Name,
+			CreatedAt:  time.Now(),
+		}
+		s.downtime.Add(window)
+		scope := "all namespaces"
+		if len(window.Namespaces) > 0 {
+			scope = strings.Join(window.Namespaces, ",")
+		}
+		s.audit.RecordRequest(r, identity.Name, "downtime.create", window.ID,
+			fmt.Sprintf("%s to %s (%s): %s", window.Start.Format(time.RFC3339), window.End.Format(time.RFC3339), scope, window.Reason))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(window)
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleDowntimeWindow removes a planned downtime window, which counts its
+// violations against MTTR and uptime again
+// DELETE /api/downtime/{id}
+func (s *Server) handleDowntimeWindow(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	identity := identityFromContext(r.Context())
+	if identity == nil {
+		http.Error(w, "removing planned downtime requires an authenticated identity", http.StatusUnauthorized)
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/api/downtime/")
+	window := s.downtime.Remove(id)
+	if window == nil {
+		http.Error(w, "downtime window not found", http.StatusNotFound)
+		return
+	}
+	s.audit.RecordRequest(r, identity.Name, "downtime.delete", id, window.Reason)
+	w.WriteHeader(http.StatusNoContent)
+}
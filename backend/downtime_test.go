@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestHandleDowntime tests recording, listing and removing planned downtime,
+// and that windows persist
+func TestHandleDowntime(t *testing.T) {
+	store, err := openStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	downtime, err := newDowntimeStore(store)
+	if err != nil {
+		t.Fatalf("Failed to create downtime store: %v", err)
+	}
+	audit, _ := newAuditLog(nil)
+	server := &Server{downtime: downtime, audit: audit}
+	raj := &Identity{Name: "raj"}
+
+	body := `{"start":"2024-05-04T22:00:00Z","end":"2024-05-05T02:00:00Z","namespaces":["icu"],"reason":"SEV firmware upgrade"}`
+	w := httptest.NewRecorder()
+	server.handleDowntime(w, ackRequestAs(nil, "POST", "/api/downtime", body))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for anonymous downtime, got %d", w.Code)
+	}
+
+	for _, invalid := range []string{
+		`{"start":"2024-05-04T22:00:00Z","end":"2024-05-05T02:00:00Z"}`,
+		`{"start":"2024-05-05T02:00:00Z","end":"2024-05-04T22:00:00Z","reason":"backwards"}`,
+		`{"start":"2024-05-04T22:00:00Z","end":"2024-05-05T02:00:00Z","namespaces":["icu/a"],"reason":"x"}`,
+	} {
+		w = httptest.NewRecorder()
+		server.handleDowntime(w, ackRequestAs(raj, "POST", "/api/downtime", invalid))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d", invalid, w.Code)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	server.handleDowntime(w, ackRequestAs(raj, "POST", "/api/downtime", body))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created DowntimeWindow
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if created.ID == "" || created.CreatedBy != "raj" {
+		t.Errorf("Unexpected window %+v", created)
+	}
+
+	reloaded, err := newDowntimeStore(store)
+	if err != nil {
+		t.Fatalf("Failed to reload planned downtime: %v", err)
+	}
+	if list := reloaded.List(); len(list) != 1 || list[0].ID != created.ID || list[0].Reason != "SEV firmware upgrade" {
+		t.Errorf("Expected persisted window, got %+v", list)
+	}
+
+	w = httptest.NewRecorder()
+	server.handleDowntime(w, httptest.NewRequest("GET", "/api/downtime", nil))
+	var listed []DowntimeWindow
+	if err := json.Unmarshal(w.Body.Bytes(), &listed); err != nil || len(listed) != 1 {
+		t.Errorf("Expected 1 listed window, got %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	server.handleDowntimeWindow(w, ackRequestAs(raj, "DELETE", "/api/downtime/"+created.ID, ""))
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Expected 204, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	server.handleDowntimeWindow(w, ackRequestAs(raj, "DELETE", "/api/downtime/"+created.ID, ""))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a removed window, got %d", w.Code)
+	}
+
+	entries := server.audit.Entries(time.Time{})
+	if len(entries) != 2 || entries[0].Action != "downtime.create" || entries[1].Action != "downtime.delete" {
+		t.Errorf("Expected create and delete audit entries, got %+v", entries)
+	}
+}
+
+// TestPlannedOverlap tests that overlapping windows are counted once and
+// only for the namespaces they cover
+func TestPlannedOverlap(t *testing.T) {
+	t0 := time.Date(2024, 5, 4, 0, 0, 0, 0, time.UTC)
+	windows := []DowntimeWindow{
+		{Start: t0, End: t0.Add(2 * time.Hour), Namespaces: []string{"icu"}},
+		{Start: t0.Add(time.Hour), End: t0.Add(3 * time.Hour)},
+	}
+
+	if got := plannedOverlap(windows, "icu", t0.Add(-time.Hour), t0.Add(4*time.Hour)); got != 3*time.Hour {
+		t.Errorf("Expected 3h for icu, got %s", got)
+	}
+	if got := plannedOverlap(windows, "lab", t0, t0.Add(90*time.Minute)); got != 30*time.Minute {
+		t.Errorf("Expected 30m for lab, got %s", got)
+	}
+	if got := plannedOverlap(nil, "icu", t0, t0.Add(time.Hour)); got != 0 {
+		t.Errorf("Expected no overlap without windows, got %s", got)
+	}
+}
+
+// TestBuildMTTRReportExcludesDowntime tests that violations during planned
+// downtime count against neither MTTR nor uptime
+func TestBuildMTTRReportExcludesDowntime(t *testing.T) {
+	history, _ := newHistory(nil, 0)
+	to := time.Now().Truncate(time.Second)
+	from := to.Add(-10 * time.Hour)
+	history.Record([]HistoryEvent{
+		{Time: from, Key: "icu/a", Type: "added", Status: verifiedStatus("icu", "a")},
+		{Time: from, Key: "icu/b", Type: "added", Status: verifiedStatus("icu", "b")},
+		// Entirely within planned downtime
+		{Time: from.Add(time.Hour), Key: "icu/a", Type: "changed", Status: failedStatus("icu", "a")},
+		{Time: from.Add(90 * time.Minute), Key: "icu/a", Type: "changed", Status: verifiedStatus("icu", "a")},
+		// Overruns planned downtime by 30m
+		{Time: from.Add(90 * time.Minute), Key: "icu/b", Type: "changed", Status: failedStatus("icu", "b")},
+		{Time: from.Add(150 * time.Minute), Key: "icu/b", Type: "changed", Status: verifiedStatus("icu", "b")},
+	})
+	store, _ := openStore(t.TempDir())
+	downtime, _ := newDowntimeStore(store)
+	downtime.Add(DowntimeWindow{ID: "fw", Start: from.Add(time.Hour), End: from.Add(2 * time.Hour), Reason: "firmware"})
+	server := &Server{history: history, downtime: downtime}
+
+	report := server.buildMTTRReport(from, to)
+
+	if report.Overall.Violations != 1 || report.Overall.MTTRSeconds != 30*60 {
+		t.Errorf("Expected one unplanned 30m violation, got %+v", report.Overall)
+	}
+	if report.Overall.PlannedSeconds != 60*60 {
+		t.Errorf("Expected 1h of planned violation time, got %g", report.Overall.PlannedSeconds)
+	}
+
+	// Two workloads for 10h, less 1h of downtime each; 30m in violation
+	uptime := report.NamespaceUptime["icu"]
+	if uptime == nil || uptime.ObservedSeconds != 18*3600 || uptime.ViolationSeconds != 30*60 {
+		t.Fatalf("Unexpected icu uptime %+v", uptime)
+	}
+	if want := 100 * (18*3600 - 30*60) / float64(18*3600); report.Uptime.UptimePercent != want {
+		t.Errorf("Expected uptime %g%%, got %g%%", want, report.Uptime.UptimePercent)
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sync"
+)
+
+// defaultEventQueue is how many batches of events a bus subscriber may fall
+// behind before its oldest batches are dropped
+const defaultEventQueue = 256
+
+// overflowPolicy decides what happens when a subscriber's buffer is full
+type overflowPolicy string
+
+const (
+	// overflowDropOldest discards the oldest buffered events to make room,
+	// counting them; later transitions supersede earlier ones
+	overflowDropOldest overflowPolicy = "drop-oldest"
+	// overflowDisconnect drops the subscriber, which reconnects and re-reads
+	// the current state
+	overflowDisconnect overflowPolicy = "disconnect"
+)
+
+// parseOverflowPolicy parses a policy name; empty selects fallback
+func parseOverflowPolicy(value string, fallback overflowPolicy) (overflowPolicy, error) {
+	switch policy := overflowPolicy(value); policy {
+	case "":
+		return fallback, nil
+	case overflowDropOldest, overflowDisconnect:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown overflow policy %q (want %s or %s)", value, overflowDropOldest, overflowDisconnect)
+	}
+}
+
+// eventHandler is a named consumer of cache transitions
+type eventHandler struct {
+	name   string
+	handle func(events []HistoryEvent)
+	// lossless handlers keep records that must not have gaps, so publishing
+	// waits for room in their queue rather than dropping a batch
+	lossless bool
+}
+
+// eventBus fans cache transitions out to its subscribers asynchronously, so a
+// slow store write or webhook never holds up a poll cycle. Each subscriber
+// has its own goroutine and bounded queue and sees batches in order. Internal
+// subscribers can't reconnect, so a full queue drops its oldest batch, unless
+// the subscriber is lossless: then publishing blocks until it catches up,
+// holding up the poll cycle instead.
+type eventBus struct {
+	mu          sync.Mutex
+	subscribers []*busSubscriber
+	queueSize   int
+	metrics     *Metrics
+	wg          sync.WaitGroup
+}
+
+// busSubscriber is one subscriber's queue
+type busSubscriber struct {
+	name     string
+	queue    chan []HistoryEvent
+	lossless bool
+}
+
+func newEventBus(queueSize int, metrics *Metrics) *eventBus {
+	if queueSize <= 0 {
+		queueSize = defaultEventQueue
+	}
+	return &eventBus{queueSize: queueSize, metrics: metrics}
+}
+
+// subscribe starts delivering published batches to a handler
+func (b *eventBus) subscribe(handler eventHandler) {
+	sub := &busSubscriber{name: handler.name, queue: make(chan []HistoryEvent, b.queueSize), lossless: handler.lossless}
+	b.mu.Lock()
+	b.subscribers = append(b.subscribers, sub)
+	b.mu.Unlock()
+
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		for events := range sub.queue {
+			handler.handle(events)
+		}
+	}()
+}
+
+// publish queues a batch for every subscriber, blocking only on a lossless
+// subscriber's full queue
+func (b *eventBus) publish(events []HistoryEvent) {
+	if len(events) == 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, sub := range b.subscribers {
+		select {
+		case sub.queue <- events:
+			continue
+		default:
+		}
+		if sub.lossless {
+			log.Printf("Waiting for slow subscriber %s", sub.name)
+			b.metrics.Inc("dashboard_event_bus_blocked_total", "Publishes held up because a lossless subscriber's queue was full.", "subscriber", sub.name)
+			sub.queue <- events
+			continue
+		}
+		// Only publish sends, so there is room once the oldest batch is
+		// dropped here or taken by the subscriber meanwhile
+		select {
+		case dropped := <-sub.queue:
+			log.Printf("Dropping %d events for slow subscriber %s", len(dropped), sub.name)
+			b.metrics.Add("dashboard_event_bus_dropped_total", "Workload events dropped because a subscriber's queue was full.",
+				float64(len(dropped)), "subscriber", sub.name)
+		default:
+		}
+		sub.queue <- events
+	}
+}
+
+// close stops accepting events and waits for subscribers to drain their queues
+func (b *eventBus) close() {
+	b.mu.Lock()
+	for _, sub := range b.subscribers {
+		close(sub.queue)
+	}
+	b.subscribers = nil
+	b.mu.Unlock()
+	b.wg.Wait()
+}
+
+// eventHandlers returns the subscribers to cache transitions: history and
+// statistics, which are lossless, live streams, notifications and metrics
+func (s *Server) eventHandlers() []eventHandler {
+	return []eventHandler{
+		{name: "history", handle: s.history.Record, lossless: true},
+		{name: "stats", handle: s.stats.Record, lossless: true},
+		{name: "stream", handle: s.stream.publish},
+		{name: "notifier", handle: func(events []HistoryEvent) { s.notifier.Notify(s.unacknowledged(events)) }},
+		{name: "metrics", handle: s.observeTransitions},
+	}
+}
+
+// recordEvents hands cache transitions to their subscribers: through the bus
+// where one is running, and in turn otherwise. Acknowledgements are then
+// expired and resolved, whether or not anything changed: most cycles of a
+// stable fleet have no transitions. Caller must not hold cacheMutex.
+func (s *Server) recordEvents(events []HistoryEvent) {
+	if s.bus != nil {
+		s.bus.publish(events)
+	} else {
+		for _, handler := range s.eventHandlers() {
+			handler.handle(events)
+		}
+	}
+	s.processAcks()
+}
+
+// observeTransitions counts cache transitions by type
+func (s *Server) observeTransitions(events []HistoryEvent) {
+	for _, event := range events {
+		s.metrics.Inc("dashboard_workload_transitions_total", "Workload state transitions by type.", "type", event.Type)
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestEventBusDelivers tests that every subscriber sees every batch, in order
+func TestEventBusDelivers(t *testing.T) {
+	bus := newEventBus(0, newMetrics())
+	var history, stream []string
+	bus.subscribe(eventHandler{name: "history", handle: func(events []HistoryEvent) {
+		history = append(history, events[0].Key)
+	}})
+	bus.subscribe(eventHandler{name: "stream", handle: func(events []HistoryEvent) {
+		stream = append(stream, events[0].Key)
+	}})
+
+	for _, key := range []string{"icu/pacs", "icu/monitor", "radiology/viewer"} {
+		bus.publish([]HistoryEvent{{Key: key, Type: "changed"}})
+	}
+	bus.publish(nil)
+	bus.close()
+
+	for name, seen := range map[string][]string{"history": history, "stream": stream} {
+		if len(seen) != 3 || seen[0] != "icu/pacs" || seen[2] != "radiology/viewer" {
+			t.Errorf("Expected %s to see all batches in order, got %v", name, seen)
+		}
+	}
+}
+
+// TestEventBusDropsForSlowSubscriber tests that a stuck subscriber loses its
+// oldest batches instead of blocking the publisher or the other subscribers
+func TestEventBusDropsForSlowSubscriber(t *testing.T) {
+	metrics := newMetrics()
+	bus := newEventBus(2, metrics)
+	started, release := make(chan struct{}, 1), make(chan struct{})
+	bus.subscribe(eventHandler{name: "notifier", handle: func(events []HistoryEvent) {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-release
+	}})
+	delivered := make(chan struct{}, 10)
+	bus.subscribe(eventHandler{name: "stream", handle: func(events []HistoryEvent) {
+		delivered <- struct{}{}
+	}})
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 5; i++ {
+			bus.publish([]HistoryEvent{{Key: "icu/pacs"}, {Key: "icu/monitor"}})
+			<-delivered
+			if i == 0 {
+				<-started
+			}
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Publishing blocked on a slow subscriber")
+	}
+
+	// The first batch is being handled and 2 are queued
+	if got := metrics.Value("dashboard_event_bus_dropped_total", "subscriber", "notifier"); got != 4 {
+		t.Errorf("Expected 4 dropped events, got %v", got)
+	}
+	if got := metrics.Value("dashboard_event_bus_dropped_total", "subscriber", "stream"); got != 0 {
+		t.Errorf("Expected no drops for a subscriber keeping up, got %v", got)
+	}
+	close(release)
+	bus.close()
+}
+
+// TestEventBusBlocksForLosslessSubscriber tests that publishing waits for a
+// stuck lossless subscriber rather than dropping its batches
+func TestEventBusBlocksForLosslessSubscriber(t *testing.T) {
+	metrics := newMetrics()
+	bus := newEventBus(1, metrics)
+	release := make(chan struct{})
+	var seen []string
+	bus.subscribe(eventHandler{name: "history", lossless: true, handle: func(events []HistoryEvent) {
+		<-release
+		seen = append(seen, events[0].Key)
+	}})
+
+	done := make(chan struct{})
+	go func() {
+		for _, key := range []string{"icu/pacs", "icu/monitor", "radiology/viewer"} {
+			bus.publish([]HistoryEvent{{Key: key}})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("Expected publishing to wait for the lossless subscriber")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	<-done
+	bus.close()
+	if len(seen) != 3 || seen[2] != "radiology/viewer" {
+		t.Errorf("Expected every batch delivered, got %v", seen)
+	}
+	if got := metrics.Value("dashboard_event_bus_dropped_total", "subscriber", "history"); got != 0 {
+		t.Errorf("Expected no drops for a lossless subscriber, got %v", got)
+	}
+}
+
+// TestRecordEventsExpiresAcks tests that acknowledgements expire in a cycle
+// without transitions, which the bus doesn't publish
+func TestRecordEventsExpiresAcks(t *testing.T) {
+	server := newAckTestServer(t)
+	server.bus = newEventBus(0, newMetrics())
+	past := time.Now().Add(-time.Minute)
+	server.acks.Set(Acknowledgement{Key: "icu/broken", By: "raj", CreatedAt: past.Add(-time.Hour), ExpiresAt: past})
+
+	server.recordEvents(nil)
+	server.bus.close()
+
+	if server.acks.Get("icu/broken") != nil {
+		t.Error("Expected the expired acknowledgement removed")
+	}
+	entries := server.audit.Entries(time.Time{})
+	if len(entries) != 1 || entries[0].Action != "ack.expired" {
+		t.Errorf("Expected the expiry audited, got %+v", entries)
+	}
+}
+
+// TestParseOverflowPolicy tests the policy names
+func TestParseOverflowPolicy(t *testing.T) {
+	if policy, err := parseOverflowPolicy("", overflowDisconnect); policy != overflowDisconnect || err != nil {
+		t.Errorf("Expected the fallback for an empty policy, got %q, %v", policy, err)
+	}
+	if policy, err := parseOverflowPolicy("drop-oldest", overflowDisconnect); policy != overflowDropOldest || err != nil {
+		t.Errorf("Expected drop-oldest, got %q, %v", policy, err)
+	}
+	if _, err := parseOverflowPolicy("block", overflowDisconnect); err == nil {
+		t.Error("Expected an unknown policy to be rejected")
+	}
+}
+
+// TestRecordEventsWithoutBus tests that transitions are delivered in turn
+// when no bus is running
+func TestRecordEventsWithoutBus(t *testing.T) {
+	server := &Server{metrics: newMetrics(), stream: newEventBroker()}
+	sub := server.stream.subscribe(nil, "")
+
+	server.recordEvents([]HistoryEvent{
+		{Key: "icu/pacs", Type: "changed", Status: failedStatus("icu", "pacs")},
+		{Key: "icu/monitor", Type: "added", Status: verifiedStatus("icu", "monitor")},
+	})
+	if len(sub.events) != 2 {
+		t.Errorf("Expected both events to be streamed, got %d", len(sub.events))
+	}
+	if got := server.metrics.Value("dashboard_workload_transitions_total", "type", "changed"); got != 1 {
+		t.Errorf("Expected 1 changed transition, got %v", got)
+	}
+}
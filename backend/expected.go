@@ -0,0 +1,227 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const expectedWorkloadsDoc = "expected-workloads"
+
+// noReportStatus is the AttestationStatus of an expected workload that no
+// Collector reported
+const noReportStatus = "no-report"
+
+// ExpectedWorkloadStore holds the workloads operators registered as required,
+// persisted to the store. It stands in for watching the cluster for teams
+// that can't be granted list/watch on their workloads.
+type ExpectedWorkloadStore struct {
+	mu        sync.Mutex
+	workloads map[string]*ExpectedWorkload
+	store     *Store
+}
+
+// newExpectedWorkloadStore loads persisted expected workloads
+func newExpectedWorkloadStore(store *Store) (*ExpectedWorkloadStore, error) {
+	e := &ExpectedWorkloadStore{
+		workloads: make(map[string]*ExpectedWorkload),
+		store:     store,
+	}
+	if _, err := store.LoadDoc(expectedWorkloadsDoc, &e.workloads); err != nil {
+		return nil, fmt.Errorf("failed to load expected workloads: %w", err)
+	}
+	return e, nil
+}
+
+// List returns all expected workloads, sorted by key
+func (e *ExpectedWorkloadStore) List() []ExpectedWorkload {
+	if e == nil {
+		return nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	list := make([]ExpectedWorkload, 0, len(e.workloads))
+	for _, workload := range e.workloads {
+		list = append(list, *workload)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Key < list[j].Key })
+	return list
+}
+
+// Set registers an expected workload, replacing any existing registration
+func (e *ExpectedWorkloadStore) Set(workload ExpectedWorkload) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.workloads[workload.Key] = &workload
+	e.persistLocked()
+}
+
+// Remove deletes and returns the registration of a workload
+func (e *ExpectedWorkloadStore) Remove(key string) *ExpectedWorkload {
+	if e == nil {
+		return nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	workload, ok := e.workloads[key]
+	if !ok {
+		return nil
+	}
+	delete(e.workloads, key)
+	e.persistLocked()
+	return workload
+}
+
+// persistLocked saves expected workloads to the store. Caller must hold mu.
+func (e *ExpectedWorkloadStore) persistLocked() {
+	if err := e.store.SaveDoc(expectedWorkloadsDoc, e.workloads); err != nil {
+		log.Printf("Failed to persist expected workloads: %v", err)
+	}
+}
+
+// missingWorkloads returns "no-report" statuses for expected workloads that
+// none of the statuses cover. A workload is only judged once its cluster has
+// synced this cycle - or, without a cluster, once every cluster has - so a
+// Collector outage isn't reported as missing workloads.
+func (s *Server) missingWorkloads(statuses []*WorkloadStatus, synced map[string]bool, allSynced bool) []*WorkloadStatus {
+	expected := s.expected.List()
+	if len(expected) == 0 {
+		return nil
+	}
+
+	reported := make(map[string]bool, len(statuses))
+	for _, status := range statuses {
+		reported[status.Namespace+"/"+status.Name] = true
+	}
+
+	now := time.Now()
+	var missing []*WorkloadStatus
+	for _, workload := range expected {
+		if reported[workload.Key] || s.suppressions.Suppressed(workload.Key) {
+			continue
+		}
+		if workload.Cluster == "" && !allSynced || workload.Cluster != "" && !synced[workload.Cluster] {
+			continue
+		}
+		if !s.evaluates(workload.Namespace, workload.Name) {
+			continue
+		}
+
+		status := &WorkloadStatus{
+			Name:              workload.Name,
+			Namespace:         workload.Namespace,
+			Cluster:           workload.Cluster,
+			AttestationStatus: noReportStatus,
+			GateOneStatus:     "unknown",
+			GateTwoStatus:     "failed",
+			LastChecked:       now,
+			Details:           fmt.Sprintf("Expected workload not reported by any Collector (registered by %s)", workload.RegisteredBy),
+		}
+		failCheck(status, "report_present", "attestation report", "none", severityCritical)
+		missing = append(missing, status)
+	}
+	return missing
+}
+
+// hasCluster reports whether a cluster is one the dashboard polls
+func (s *Server) hasCluster(name string) bool {
+	for _, cluster := range s.collectorTargets() {
+		if cluster.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// handleExpectedWorkloads lists (GET) or registers (POST) expected workloads
+// GET/POST /api/expected-workloads
+func (s *Server) handleExpectedWorkloads(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		workloads := s.expected.List()
+		if workloads == nil {
+			workloads = []ExpectedWorkload{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(workloads)
+
+	case http.MethodPost:
+		identity := identityFromContext(r.Context())
+		if identity == nil {
+			http.Error(w, "registering expected workloads requires an authenticated identity", http.StatusUnauthorized)
+			return
+		}
+		if s.expected == nil {
+			http.Error(w, "expected workloads are not enabled", http.StatusServiceUnavailable)
+			return
+		}
+
+		var req ExpectedWorkloadRequest
+		if !decodeValid(w, r, expectedWorkloadSchema, &req) {
+			return
+		}
+		if req.Namespace == "" || req.Name == "" || strings.Contains(req.Namespace, "/") || strings.Contains(req.Name, "/") {
+			http.Error(w, "namespace and name are required and may not contain '/'", http.StatusBadRequest)
+			return
+		}
+		if req.Cluster != "" && !s.hasCluster(req.Cluster) {
+			http.Error(w, fmt.Sprintf("unknown cluster %q", req.Cluster), http.StatusBadRequest)
+			return
+		}
+
+		workload := ExpectedWorkload{
+			Key:          req.Namespace + "/" + req.Name,
+			Namespace:    req.Namespace,
+			Name:         req.Name,
+			Cluster:      req.Cluster,
+			Comment:      req.Comment,
+			RegisteredBy: identity.Name,
+			RegisteredAt: time.Now(),
+		}
+		s.expected.Set(workload)
+		s.audit.RecordRequest(r, identity.Name, "expected.create", workload.Key, workload.Comment)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(workload)
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleExpectedWorkload removes the registration of an expected workload
+// DELETE /api/expected-workloads/{namespace}/{name}
+func (s *Server) handleExpectedWorkload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	identity := identityFromContext(r.Context())
+	if identity == nil {
+		http.Error(w, "removing expected workloads requires an authenticated identity", http.StatusUnauthorized)
+		return
+	}
+
+	key, action := splitWorkloadPath(strings.TrimPrefix(r.URL.Path, "/api/expected-workloads/"))
+	if !strings.Contains(key, "/") || action != "" {
+		http.NotFound(w, r)
+		return
+	}
+	if s.expected.Remove(key) == nil {
+		http.Error(w, "expected workload not found", http.StatusNotFound)
+		return
+	}
+	s.audit.RecordRequest(r, identity.Name, "expected.delete", key, "")
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestHandleExpectedWorkloads tests registering, listing and removing
+// expected workloads, and that registrations persist
+func TestHandleExpectedWorkloads(t *testing.T) {
+	store, err := openStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	expected, err := newExpectedWorkloadStore(store)
+	if err != nil {
+		t.Fatalf("Failed to create expected workload store: %v", err)
+	}
+	audit, _ := newAuditLog(nil)
+	server := &Server{expected: expected, audit: audit, localCluster: "east"}
+	raj := &Identity{Name: "raj"}
+
+	w := httptest.NewRecorder()
+	server.handleExpectedWorkloads(w, ackRequestAs(nil, "POST", "/api/expected-workloads", `{"namespace":"icu","name":"ai-model"}`))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for anonymous registration, got %d", w.Code)
+	}
+
+	for _, body := range []string{`{"namespace":"icu"}`, `{"namespace":"icu","name":"a/b"}`, `{"namespace":"icu","name":"ai-model","cluster":"west"}`} {
+		w = httptest.NewRecorder()
+		server.handleExpectedWorkloads(w, ackRequestAs(raj, "POST", "/api/expected-workloads", body))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d", body, w.Code)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	server.handleExpectedWorkloads(w, ackRequestAs(raj, "POST", "/api/expected-workloads", `{"namespace":"icu","name":"ai-model","cluster":"east","comment":"PACS integration"}`))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	reloaded, err := newExpectedWorkloadStore(store)
+	if err != nil {
+		t.Fatalf("Failed to reload expected workloads: %v", err)
+	}
+	if list := reloaded.List(); len(list) != 1 || list[0].Key != "icu/ai-model" || list[0].RegisteredBy != "raj" || list[0].Cluster != "east" {
+		t.Errorf("Expected persisted registration, got %+v", list)
+	}
+
+	w = httptest.NewRecorder()
+	server.handleExpectedWorkloads(w, httptest.NewRequest("GET", "/api/expected-workloads", nil))
+	var listed []ExpectedWorkload
+	if err := json.Unmarshal(w.Body.Bytes(), &listed); err != nil || len(listed) != 1 {
+		t.Errorf("Expected 1 listed workload, got %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	server.handleExpectedWorkload(w, ackRequestAs(raj, "DELETE", "/api/expected-workloads/icu/ai-model", ""))
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Expected 204, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	server.handleExpectedWorkload(w, ackRequestAs(raj, "DELETE", "/api/expected-workloads/icu/ai-model", ""))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a removed registration, got %d", w.Code)
+	}
+
+	entries := server.audit.Entries(time.Time{})
+	if len(entries) != 2 || entries[0].Action != "expected.create" || entries[1].Action != "expected.delete" {
+		t.Errorf("Expected create and delete audit entries, got %+v", entries)
+	}
+}
+
+// TestMissingWorkloads tests that unreported expected workloads become
+// no-report violations once their cluster has synced
+func TestMissingWorkloads(t *testing.T) {
+	expected, _ := newExpectedWorkloadStore(nil)
+	for _, w := range []ExpectedWorkload{
+		{Key: "icu/ai-model", Namespace: "icu", Name: "ai-model"},
+		{Key: "icu/pacs", Namespace: "icu", Name: "pacs"},
+		{Key: "lab/lims", Namespace: "lab", Name: "lims", Cluster: "west"},
+	} {
+		expected.Set(w)
+	}
+	server := &Server{expected: expected}
+	statuses := []*WorkloadStatus{verifiedStatus("icu", "ai-model")}
+
+	missing := server.missingWorkloads(statuses, map[string]bool{"east": true}, false)
+	if len(missing) != 0 {
+		t.Errorf("Expected no verdicts while a cluster is unsynced, got %+v", missing)
+	}
+
+	missing = server.missingWorkloads(statuses, map[string]bool{"east": true, "west": true}, true)
+	if len(missing) != 2 || missing[0].Name != "pacs" || missing[1].Name != "lims" {
+		t.Fatalf("Expected pacs and lims missing, got %+v", missing)
+	}
+	if !isViolation(missing[0]) || missing[0].AttestationStatus != noReportStatus {
+		t.Errorf("Expected a no-report violation, got %+v", missing[0])
+	}
+	if missing[1].Cluster != "west" || len(missing[1].FailedChecks) != 1 || missing[1].FailedChecks[0].Name != "report_present" {
+		t.Errorf("Expected a report_present check in west, got %+v", missing[1])
+	}
+
+	if got := (&Server{}).missingWorkloads(statuses, nil, true); got != nil {
+		t.Errorf("Expected nothing without registrations, got %+v", got)
+	}
+}
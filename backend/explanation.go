@@ -0,0 +1,344 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// explainInput is what a verdict is explained from: the cached status and,
+// while it is retained, the Collector report behind it
+type explainInput struct {
+	status *WorkloadStatus
+	report *CollectorReport // nil for expected workloads without a report or a restored cache
+	server *Server
+}
+
+// attested returns the verifier's verdict, from the report if retained
+func (in *explainInput) attested() bool {
+	if in.report != nil {
+		return in.report.Attested
+	}
+	return in.status.Attested
+}
+
+// explanationRule is one rule of the verdict as the pipeline applies it.
+// A rule failed if the status records a failed check of its name.
+type explanationRule struct {
+	name        string
+	description string
+	applies     func(in *explainInput) bool // whether the rule was evaluated
+	evidence    func(in *explainInput) []EvidenceField
+	decides     func(status *WorkloadStatus) bool // whether its failure alone decides the verdict; nil if it only warns
+}
+
+// explanationRules are the rules in the order the ingestion pipeline
+// evaluates them. Gates are added per workload.
+var explanationRules = []explanationRule{
+	{
+		name:        "report_present",
+		description: "An attestation report was received for the workload",
+		applies:     func(in *explainInput) bool { return true },
+		evidence: func(in *explainInput) []EvidenceField {
+			return []EvidenceField{reportField("source", orNone(in.status.Source))}
+		},
+		decides: func(status *WorkloadStatus) bool { return status.AttestationStatus == noReportStatus },
+	},
+	{
+		name:        "collector_reachable",
+		description: "The cluster's Collector answered within the staleness limit",
+		applies:     func(in *explainInput) bool { return in.status.AttestationStatus != noReportStatus },
+		evidence: func(in *explainInput) []EvidenceField {
+			return []EvidenceField{{Field: "cluster", Value: orNone(in.status.Cluster), Source: "config"}}
+		},
+		decides: func(status *WorkloadStatus) bool { return status.UnknownReason == unknownCollectorError },
+	},
+	{
+		name:        "evidence_format",
+		description: "The trust vector conforms to the AR4SI claim registry and the configured profile",
+		applies:     func(in *explainInput) bool { return in.status.AttestationStatus != noReportStatus },
+		evidence: func(in *explainInput) []EvidenceField {
+			vector := "absent"
+			if in.status.TrustVector != nil {
+				data, _ := json.Marshal(in.status.TrustVector)
+				vector = string(data)
+			}
+			return []EvidenceField{
+				reportField("trust_vector", vector),
+				{Field: "ar4si_profile", Value: orNone(in.server.ar4siProfile), Source: "config"},
+			}
+		},
+		decides: func(status *WorkloadStatus) bool { return status.UnknownReason == unknownMalformedEvidence },
+	},
+	{
+		name:        "tee_attestation",
+		description: "The verifier attested the workload's TEE",
+		applies: func(in *explainInput) bool {
+			return in.status.AttestationStatus != noReportStatus && in.status.UnknownReason != unknownMalformedEvidence
+		},
+		evidence: func(in *explainInput) []EvidenceField {
+			fields := []EvidenceField{
+				reportField("attested", strconv.FormatBool(in.attested())),
+				reportField("tee_type", orNone(in.status.TEEType)),
+			}
+			if in.report != nil && in.report.Error != "" {
+				fields = append(fields, reportField("error", in.report.Error))
+			}
+			return fields
+		},
+		decides: func(status *WorkloadStatus) bool {
+			return !status.Attested && !isUnknown(status) && status.AttestationStatus != noReportStatus
+		},
+	},
+	trustClaimRule("hardware", func(tv *TrustVector) int { return tv.Hardware }),
+	trustClaimRule("configuration", func(tv *TrustVector) int { return tv.Configuration }),
+	trustClaimRule("executables", func(tv *TrustVector) int { return tv.Executables }),
+	{
+		name:        "attestation_freshness",
+		description: "The attestation is newer than the last container restart",
+		applies:     func(in *explainInput) bool { return in.status.LastRestart != nil },
+		evidence: func(in *explainInput) []EvidenceField {
+			return []EvidenceField{
+				reportField("timestamp", in.status.Timestamp),
+				{Field: "last_restart", Value: in.status.LastRestart.UTC().Format(time.RFC3339), Source: "kubernetes"},
+			}
+		},
+	},
+	{
+		name:        "clock_skew",
+		description: "The report's timestamp is within the clock skew tolerance and maximum report age",
+		applies: func(in *explainInput) bool {
+			return in.status.AttestationStatus != noReportStatus && in.report != nil && !in.report.Timestamp.IsZero()
+		},
+		evidence: func(in *explainInput) []EvidenceField {
+			return []EvidenceField{
+				reportField("timestamp", in.status.Timestamp),
+				{Field: "last_checked", Value: in.status.LastChecked.UTC().Format(time.RFC3339), Source: "dashboard"},
+			}
+		},
+		decides: func(status *WorkloadStatus) bool { return status.UnknownReason == unknownStale },
+	},
+	{
+		name:        "report_conflict",
+		description: "Every source reporting the workload agrees on its verdict",
+		applies:     func(in *explainInput) bool { return in.status.AttestationStatus != noReportStatus },
+		evidence: func(in *explainInput) []EvidenceField {
+			return []EvidenceField{reportField("source", orNone(in.status.Source))}
+		},
+	},
+	{
+		name:        "verifier_agreement",
+		description: "The secondary verifier agrees with the primary",
+		applies:     func(in *explainInput) bool { return in.status.SecondaryVerdict != "" },
+		evidence: func(in *explainInput) []EvidenceField {
+			return []EvidenceField{
+				reportField("attested", strconv.FormatBool(in.attested())),
+				{Field: "secondary_verdict", Value: in.status.SecondaryVerdict, Source: "secondary-verifier"},
+			}
+		},
+		decides: func(status *WorkloadStatus) bool { return status.AttestationStatus == verifierSplitStatus },
+	},
+	{
+		name:        "host_attestation",
+		description: "The node running the workload passed platform attestation",
+		applies:     func(in *explainInput) bool { return in.status.HostStatus != "" },
+		evidence: func(in *explainInput) []EvidenceField {
+			return []EvidenceField{
+				{Field: "node_name", Value: in.status.NodeName, Source: "kubernetes"},
+				{Field: "host_status", Value: in.status.HostStatus, Source: "host"},
+			}
+		},
+	},
+	{
+		name:        "image_allowlist",
+		description: "The running image digests are allowlisted for the namespace",
+		applies: func(in *explainInput) bool {
+			return len(in.status.ImageDigests) > 0 && in.server.imagePolicyFor(in.status.Namespace) != nil
+		},
+		evidence: func(in *explainInput) []EvidenceField {
+			return []EvidenceField{{Field: "image_digests", Value: strings.Join(in.status.ImageDigests, ", "), Source: "kubernetes"}}
+		},
+		decides: func(status *WorkloadStatus) bool { return status.GateOneStatus == "failed" },
+	},
+}
+
+// trustClaimRule is the rule for one trust vector claim checked by
+// trustVectorChecks. Only attested reports have their claims assessed.
+func trustClaimRule(claim string, value func(*TrustVector) int) explanationRule {
+	return explanationRule{
+		name:        "trust_vector." + claim,
+		description: fmt.Sprintf("The %s claim of the trust vector is %s", claim, trustTierToString(2)),
+		applies: func(in *explainInput) bool {
+			return in.status.TrustVector != nil && in.attested() && in.status.UnknownReason != unknownMalformedEvidence
+		},
+		evidence: func(in *explainInput) []EvidenceField {
+			v := value(in.status.TrustVector)
+			return []EvidenceField{reportField("trust_vector."+claim, fmt.Sprintf("%d (%s)", v, trustTierToString(v)))}
+		},
+	}
+}
+
+func reportField(field, value string) EvidenceField {
+	return EvidenceField{Field: field, Value: value, Source: "report"}
+}
+
+func orNone(value string) string {
+	if value == "" {
+		return "none"
+	}
+	return value
+}
+
+// explain traces how a workload's verdict was reached, rule by rule
+func (s *Server) explain(status *WorkloadStatus, report *CollectorReport) Explanation {
+	in := &explainInput{status: status, report: report, server: s}
+	explanation := Explanation{
+		Key:               status.Namespace + "/" + status.Name,
+		Verdict:           "compliant",
+		AttestationStatus: status.AttestationStatus,
+		Profile:           s.ar4siProfile,
+		EvaluatedAt:       status.LastChecked,
+		Rules:             []ExplainedRule{},
+	}
+	switch {
+	case isViolation(status):
+		explanation.Verdict = "violation"
+	case isUnknown(status):
+		explanation.Verdict = "unknown"
+	}
+	if s.policies != nil {
+		s.policies.mu.Lock()
+		explanation.PolicyVersion = s.policies.state.Active
+		s.policies.mu.Unlock()
+	}
+
+	rules := append([]explanationRule(nil), explanationRules...)
+	for _, gate := range status.Gates {
+		gate := gate
+		rules = append(rules, explanationRule{
+			name:        "gate:" + gate.Name,
+			description: fmt.Sprintf("The %s gate passes", gate.Name),
+			applies:     func(*explainInput) bool { return true },
+			evidence: func(*explainInput) []EvidenceField {
+				return []EvidenceField{{Field: gate.Name, Value: strings.TrimSpace(gate.Status + " " + gate.Details), Source: "gate"}}
+			},
+			decides: func(*WorkloadStatus) bool { return gate.Status != "passing" },
+		})
+	}
+
+	warnings := 0
+	var cause *ExplainedRule
+	for _, rule := range rules {
+		explained := ExplainedRule{Rule: rule.name, Description: rule.description, Outcome: "pass"}
+		failed := failedCheck(status, rule.name)
+		switch {
+		case failed != nil:
+			explained.Outcome = "fail"
+			if failed.Severity == severityWarning {
+				explained.Outcome = "warn"
+				warnings++
+			}
+			explained.Expected, explained.Actual, explained.Severity = failed.Expected, failed.Actual, failed.Severity
+		case rule.name == "collector_reachable" && status.UnknownReason == unknownCollectorError:
+			// Collector outages are marked by the staleness sweep, not a check
+			explained.Outcome = "fail"
+			explained.Expected, explained.Actual = "Collector reachable", status.Details
+		case !rule.applies(in):
+			explained.Outcome = "skipped"
+		}
+		if explained.Outcome != "skipped" {
+			explained.Evidence = rule.evidence(in)
+		}
+		if explained.Outcome == "fail" && rule.decides != nil && rule.decides(status) {
+			explained.Decisive = true
+			if explanation.Cause == "" {
+				explanation.Cause = rule.name
+			}
+		}
+		explanation.Rules = append(explanation.Rules, explained)
+	}
+	for i := range explanation.Rules {
+		if explanation.Rules[i].Rule == explanation.Cause {
+			cause = &explanation.Rules[i]
+			break
+		}
+	}
+
+	switch {
+	case cause != nil:
+		explanation.Summary = fmt.Sprintf("%s decided by %s: expected %s, got %s", explanation.Verdict, cause.Rule, cause.Expected, cause.Actual)
+	case explanation.Verdict != "compliant":
+		explanation.Summary = fmt.Sprintf("%s: %s", explanation.Verdict, status.Details)
+	case warnings == 1:
+		explanation.Summary = "compliant, with 1 warning"
+	case warnings > 1:
+		explanation.Summary = fmt.Sprintf("compliant, with %d warnings", warnings)
+	default:
+		explanation.Summary = "compliant: every evaluated rule passed"
+	}
+	explanation.Notes = s.explanationNotes(status)
+	return explanation
+}
+
+// failedCheck returns the most severe failed check of a name, or nil
+func failedCheck(status *WorkloadStatus, name string) *Check {
+	var worst *Check
+	for i := range status.FailedChecks {
+		check := &status.FailedChecks[i]
+		if check.Name == name && (worst == nil || severityRank[check.Severity] > severityRank[worst.Severity]) {
+			worst = check
+		}
+	}
+	return worst
+}
+
+// explanationNotes lists what changes how a verdict is handled without
+// changing the verdict itself
+func (s *Server) explanationNotes(status *WorkloadStatus) []string {
+	var notes []string
+	if ack := s.acks.Get(status.Namespace + "/" + status.Name); ack != nil {
+		notes = append(notes, fmt.Sprintf("Acknowledged by %s until %s", ack.By, ack.ExpiresAt.UTC().Format(time.RFC3339)))
+	}
+	if status.Maintenance != "" {
+		notes = append(notes, "In maintenance window "+status.Maintenance)
+	}
+	if status.Flapping {
+		notes = append(notes, fmt.Sprintf("Flapping: %d verdict changes in the flap window", status.FlapCount))
+	}
+	return notes
+}
+
+// handleExplanation explains why a workload has its verdict: the rules
+// evaluated, the evidence each read, and which decided a violation
+// GET /api/workload/{ns}/{name}/explanation
+func (s *Server) handleExplanation(w http.ResponseWriter, r *http.Request, key string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.cacheMutex.RLock()
+	status, exists := s.statusCache[key]
+	var explanation Explanation
+	var workload WorkloadStatus
+	if exists {
+		var report *CollectorReport
+		if retained, ok := s.reports[key]; ok {
+			report = &retained
+		}
+		explanation = s.explain(status, report)
+		workload = *status
+	}
+	s.cacheMutex.RUnlock()
+
+	if !exists {
+		http.Error(w, "workload not found", http.StatusNotFound)
+		return
+	}
+	noteWorkloads(r, []WorkloadStatus{workload})
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(explanation)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestHandleExplanation tests that explanations name the rule deciding a
+// verdict, with the evidence it read, and are access logged
+func TestHandleExplanation(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	reports := []CollectorReport{
+		{PodName: "pacs", Namespace: "icu", Attested: false, Error: "SNP report signature invalid", TEEType: "snp", Timestamp: now},
+		{PodName: "monitor", Namespace: "icu", Attested: true, TEEType: "tdx", Timestamp: now,
+			TrustVector: &TrustVector{Hardware: 2, Configuration: 32, Executables: 2}},
+		{PodName: "lims", Namespace: "lab", Attested: true, Timestamp: now, TrustVector: &TrustVector{Hardware: 7}},
+	}
+	access, _ := newAccessLog(nil, time.Hour)
+	server := &Server{statusCache: map[string]*WorkloadStatus{}, reports: map[string]CollectorReport{}, access: access}
+	for _, report := range reports {
+		key := report.Namespace + "/" + report.PodName
+		server.statusCache[key] = server.convertCollectorReport(report)
+		server.reports[key] = report
+	}
+
+	explain := func(key string) (Explanation, map[string]ExplainedRule) {
+		w := httptest.NewRecorder()
+		server.handleWorkloadDetail(w, httptest.NewRequest("GET", "/api/workload/"+key+"/explanation", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d", key, w.Code)
+		}
+		var explanation Explanation
+		json.NewDecoder(w.Body).Decode(&explanation)
+		rules := make(map[string]ExplainedRule)
+		for _, rule := range explanation.Rules {
+			rules[rule.Rule] = rule
+		}
+		return explanation, rules
+	}
+
+	failed, rules := explain("icu/pacs")
+	if failed.Verdict != "violation" || failed.Cause != "tee_attestation" {
+		t.Errorf("Expected a violation caused by tee_attestation, got %+v", failed)
+	}
+	tee := rules["tee_attestation"]
+	if tee.Outcome != "fail" || !tee.Decisive || len(tee.Evidence) != 3 || tee.Evidence[2].Value != "SNP report signature invalid" {
+		t.Errorf("Unexpected tee_attestation rule %+v", tee)
+	}
+	if rules["trust_vector.hardware"].Outcome != "skipped" || rules["report_present"].Outcome != "pass" {
+		t.Errorf("Expected trust claims skipped for a failed attestation, got %+v", rules)
+	}
+
+	warned, rules := explain("icu/monitor")
+	if warned.Verdict != "compliant" || warned.Cause != "" || warned.Summary != "compliant, with 1 warning" {
+		t.Errorf("Unexpected explanation %+v", warned)
+	}
+	if claim := rules["trust_vector.configuration"]; claim.Outcome != "warn" || claim.Decisive || claim.Evidence[0].Value != "32 (Warning)" {
+		t.Errorf("Unexpected configuration rule %+v", claim)
+	}
+
+	malformed, rules := explain("lab/lims")
+	if malformed.Verdict != "unknown" || malformed.Cause != "evidence_format" || rules["tee_attestation"].Outcome != "skipped" {
+		t.Errorf("Expected malformed evidence to decide an unknown verdict, got %+v", malformed)
+	}
+
+	w := httptest.NewRecorder()
+	server.handleWorkloadDetail(w, httptest.NewRequest("GET", "/api/workload/icu/gone/explanation", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown workload, got %d", w.Code)
+	}
+
+	handler := server.accessLogMiddleware(http.HandlerFunc(server.handleWorkloadDetail))
+	handler.ServeHTTP(httptest.NewRecorder(), ackRequestAs(&Identity{Name: "raj"}, "GET", "/api/workload/icu/pacs/explanation", ""))
+	entries, _ := access.Entries(context.Background(), time.Time{}, "raj")
+	if len(entries) != 1 || len(entries[0].Workloads) != 1 || entries[0].Workloads[0] != "icu/pacs" {
+		t.Errorf("Expected icu/pacs in the access log, got %+v", entries)
+	}
+}
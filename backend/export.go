@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// writePostureSnapshot writes the state of each workload as OpenMetrics
+// families. Every sample carries the snapshot time, so a snapshot imported
+// hours later still lands at the time the posture was observed.
+func writePostureSnapshot(w io.Writer, workloads []WorkloadStatus, now time.Time) {
+	var attested, status, violation, checked, gates, trust, acknowledged []metricSample
+	byStatus := make(map[string]float64)
+	flag := func(set bool) float64 {
+		if set {
+			return 1
+		}
+		return 0
+	}
+
+	for i := range workloads {
+		wl := &workloads[i]
+		labels := []string{"cluster", wl.Cluster, "namespace", wl.Namespace, "workload", wl.Name}
+		at := func(value float64, extra ...string) metricSample {
+			s := sample(value, append(append([]string(nil), labels...), extra...)...)
+			s.timestamp = now
+			return s
+		}
+
+		attested = append(attested, at(flag(wl.Attested), "tee_type", wl.TEEType))
+		status = append(status, at(1, "status", wl.AttestationStatus))
+		violation = append(violation, at(flag(isViolation(wl))))
+		checked = append(checked, at(float64(wl.LastChecked.Unix())))
+		gates = append(gates,
+			at(flag(wl.GateOneStatus == "passing"), "gate", "code_integrity"),
+			at(flag(wl.GateTwoStatus == "passing"), "gate", "tee_attestation"))
+		for _, gate := range wl.Gates {
+			gates = append(gates, at(flag(gate.Status == "passing"), "gate", gate.Name))
+		}
+		if wl.TrustVector != nil {
+			for claim, value := range trustVectorValues(wl.TrustVector) {
+				trust = append(trust, at(float64(value), "claim", claim))
+			}
+		}
+		acknowledged = append(acknowledged, at(flag(wl.Acknowledgement != nil)))
+		byStatus[wl.AttestationStatus]++
+	}
+
+	var totals []metricSample
+	for name, count := range byStatus {
+		s := sample(count, "status", name)
+		s.timestamp = now
+		totals = append(totals, s)
+	}
+	taken := sample(float64(now.Unix()))
+	taken.timestamp = now
+
+	writeMetric(w, "dashboard_snapshot_timestamp_seconds", "gauge", "Time the snapshot was taken.", []metricSample{taken})
+	writeMetric(w, "dashboard_workloads", "gauge", "Number of workloads by attestation status.", totals)
+	writeMetric(w, "dashboard_workload_attested", "gauge", "Whether the workload's TEE attestation succeeded.", attested)
+	writeMetric(w, "dashboard_workload_status", "gauge", "Attestation status of the workload; the series with value 1 is current.", status)
+	writeMetric(w, "dashboard_workload_violation", "gauge", "Whether the workload is in violation of policy.", violation)
+	writeMetric(w, "dashboard_workload_last_checked_timestamp_seconds", "gauge", "When the workload's status was last evaluated.", checked)
+	writeMetric(w, "dashboard_workload_gate_passing", "gauge", "Whether each gate passes for the workload.", gates)
+	writeMetric(w, "dashboard_workload_trust_claim", "gauge", "AR4SI trust vector claim values reported for the workload.", trust)
+	writeMetric(w, "dashboard_workload_acknowledged", "gauge", "Whether the workload's violation is acknowledged.", acknowledged)
+	fmt.Fprintln(w, "# EOF")
+}
+
+// sortByCluster orders workloads by cluster, namespace and name
+func sortByCluster(workloads []WorkloadStatus) {
+	sort.Slice(workloads, func(i, j int) bool {
+		a, b := workloads[i], workloads[j]
+		if a.Cluster != b.Cluster {
+			return a.Cluster < b.Cluster
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+}
+
+// handleOpenMetricsExport returns a point-in-time OpenMetrics snapshot of
+// every workload's attestation posture, for pull-based ingestion by tools
+// that can't scrape /metrics continuously, such as compliance collectors in
+// air-gapped networks. Accepts the cluster and label filters of /api/workloads.
+// GET /api/export/openmetrics?cluster=east
+func (s *Server) handleOpenMetricsExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	now := time.Now().UTC()
+	s.cacheMutex.RLock()
+	workloads := make([]WorkloadStatus, 0, len(s.statusCache))
+	for _, status := range s.statusCache {
+		if !matchesCluster(r, status) {
+			continue
+		}
+		workload := s.decorate(*status)
+		if !matchesLabels(r, &workload) {
+			continue
+		}
+		workloads = append(workloads, workload)
+	}
+	s.cacheMutex.RUnlock()
+
+	sortByCluster(workloads)
+	noteWorkloads(r, workloads)
+
+	w.Header().Set("Content-Type", openMetricsContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="attestation-posture-%s.om.txt"`, now.Format("20060102T150405Z")))
+	writePostureSnapshot(openMetricsWriter{w}, workloads, now)
+}
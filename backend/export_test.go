@@ -0,0 +1,63 @@
+package main
+
+import (
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestHandleOpenMetricsExport tests the workload posture snapshot
+func TestHandleOpenMetricsExport(t *testing.T) {
+	verified := verifiedStatus("icu", "pacs")
+	verified.Cluster = "east"
+	verified.TrustVector = &TrustVector{Hardware: 2}
+	failed := failedStatus("lab", "lims")
+	failed.Cluster = "west"
+	server := &Server{statusCache: map[string]*WorkloadStatus{"icu/pacs": verified, "lab/lims": failed}}
+
+	w := httptest.NewRecorder()
+	server.handleOpenMetricsExport(w, httptest.NewRequest("GET", "/api/export/openmetrics", nil))
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/openmetrics-text") {
+		t.Errorf("Expected OpenMetrics content type, got %q", ct)
+	}
+
+	body := w.Body.String()
+	for _, want := range []string{
+		"# TYPE dashboard_workload_attested gauge",
+		`dashboard_workload_attested{cluster="east",namespace="icu",workload="pacs",tee_type=""} 1 `,
+		`dashboard_workload_violation{cluster="west",namespace="lab",workload="lims"} 1 `,
+		`dashboard_workload_status{cluster="west",namespace="lab",workload="lims",status="failed"} 1 `,
+		`dashboard_workload_trust_claim{cluster="east",namespace="icu",workload="pacs",claim="hardware"} 2 `,
+		`dashboard_workloads{status="verified"} 1 `,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %q in snapshot:\n%s", want, body)
+		}
+	}
+	if !strings.HasSuffix(body, "# EOF\n") {
+		t.Error("Expected the snapshot to end with # EOF")
+	}
+
+	// Every sample carries the snapshot time, in seconds
+	for _, line := range strings.Split(strings.TrimSpace(body), "\n") {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			t.Fatalf("Expected value and timestamp in %q", line)
+		}
+		ts, err := strconv.ParseFloat(fields[2], 64)
+		if err != nil || time.Since(time.Unix(int64(ts), 0)) > time.Minute {
+			t.Errorf("Expected the snapshot time in %q", line)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	server.handleOpenMetricsExport(w, httptest.NewRequest("GET", "/api/export/openmetrics?cluster=east", nil))
+	if strings.Contains(w.Body.String(), `workload="lims"`) {
+		t.Error("Expected the cluster filter to apply")
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// featureFlag gates a feature still being rolled out across sites. Sites
+// turn flags on or off with FEATURE_FLAGS; endpoints of a disabled flag
+// answer 404 as if this version didn't have them.
+type featureFlag struct {
+	description string
+	enabled     bool     // default
+	endpoints   []string // path.Match patterns served only while enabled
+}
+
+// knownFeatureFlags are the flags this version understands
+var knownFeatureFlags = map[string]featureFlag{
+	"share-links": {
+		description: "Time-limited links to a workload for people without dashboard access",
+		enabled:     true,
+		endpoints:   []string{"/api/workload/*/*/share", "/api/workload/*/*/share/*", "/api/shared/*"},
+	},
+	"state-snapshot": {
+		description: "Signed snapshots of the dashboard state for evidence archival",
+		enabled:     true,
+		endpoints:   []string{"/api/export/snapshot"},
+	},
+	"ingest-benchmark": {
+		description: "Ingest benchmark against synthetic fleets",
+		enabled:     true,
+		endpoints:   []string{"/api/admin/benchmark"},
+	},
+	"history-import": {
+		description: "Backfilling history from archived Collector reports",
+		enabled:     true,
+		endpoints:   []string{"/api/admin/import"},
+	},
+	"decision-explanations": {
+		description: "Rule-by-rule explanations of workload verdicts",
+		enabled:     true,
+		endpoints:   []string{"/api/workload/*/*/explanation"},
+	},
+	"wallboard": {
+		description: "Compact fleet summary for lobby displays",
+		enabled:     true,
+		endpoints:   []string{"/api/wallboard"},
+	},
+	"attestation-latency": {
+		description: "Tracking of the time from pod start to first attestation",
+		enabled:     true,
+	},
+}
+
+// apiDeprecations are the endpoints scheduled for removal. Their responses
+// carry Deprecation (RFC 9745) and Sunset (RFC 8594) headers, and a link to
+// their successor, so clients at every site learn of it before the version
+// that drops them is rolled out.
+var apiDeprecations = []Deprecation{}
+
+// parseFeatureFlags parses FEATURE_FLAGS, e.g. "share-links=off,state-snapshot=on"
+func parseFeatureFlags(spec string) (map[string]bool, error) {
+	flags := make(map[string]bool)
+	for _, entry := range strings.Split(spec, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(entry, "=")
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if !ok {
+			return nil, fmt.Errorf("invalid feature flag entry %q, expected name=on or name=off", entry)
+		}
+		if _, known := knownFeatureFlags[name]; !known {
+			return nil, fmt.Errorf("unknown feature flag %q", name)
+		}
+		switch value {
+		case "on":
+			flags[name] = true
+		case "off":
+			flags[name] = false
+		default:
+			return nil, fmt.Errorf("invalid value %q for feature flag %s, expected on or off", value, name)
+		}
+	}
+	return flags, nil
+}
+
+// featureEnabled reports whether a flag is on, by FEATURE_FLAGS or by default
+func (s *Server) featureEnabled(name string) bool {
+	if enabled, ok := s.featureFlags[name]; ok {
+		return enabled
+	}
+	return knownFeatureFlags[name].enabled
+}
+
+// disabledFeature returns the disabled flag gating a path, or ""
+func (s *Server) disabledFeature(urlPath string) string {
+	for name, flag := range knownFeatureFlags {
+		for _, pattern := range flag.endpoints {
+			if ok, _ := path.Match(pattern, urlPath); ok && !s.featureEnabled(name) {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// deprecationOf returns the deprecation of a path, if it is deprecated
+func deprecationOf(urlPath string) *Deprecation {
+	for i := range apiDeprecations {
+		if ok, _ := path.Match(apiDeprecations[i].Endpoint, urlPath); ok {
+			return &apiDeprecations[i]
+		}
+	}
+	return nil
+}
+
+// featureMiddleware hides the endpoints of disabled feature flags and
+// announces the deprecation of endpoints scheduled for removal. Calls to
+// deprecated endpoints are counted, to tell when they can go.
+func (s *Server) featureMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if name := s.disabledFeature(r.URL.Path); name != "" {
+			http.Error(w, fmt.Sprintf("not found: feature %s is disabled", name), http.StatusNotFound)
+			return
+		}
+		if d := deprecationOf(r.URL.Path); d != nil {
+			w.Header().Set("Deprecation", "@"+strconv.FormatInt(d.Deprecated.Unix(), 10))
+			if d.Sunset != nil {
+				w.Header().Set("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+			}
+			if d.Successor != "" {
+				w.Header().Add("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, d.Successor))
+			}
+			s.metrics.Inc("dashboard_deprecated_requests_total", "Requests to deprecated API endpoints.", "endpoint", d.Endpoint)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handleFeatures lists the feature flags and their state, and the
+// deprecated endpoints, so clients can adapt to the version and
+// configuration of each site
+// GET /api/features
+func (s *Server) handleFeatures(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	response := FeaturesResponse{Flags: []FeatureFlag{}, Deprecations: apiDeprecations}
+	for name, flag := range knownFeatureFlags {
+		response.Flags = append(response.Flags, FeatureFlag{
+			Name:        name,
+			Description: flag.description,
+			Enabled:     s.featureEnabled(name),
+			Default:     flag.enabled,
+			Endpoints:   flag.endpoints,
+		})
+	}
+	sort.Slice(response.Flags, func(i, j int) bool { return response.Flags[i].Name < response.Flags[j].Name })
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestParseFeatureFlags tests FEATURE_FLAGS parsing
+func TestParseFeatureFlags(t *testing.T) {
+	flags, err := parseFeatureFlags(" share-links=off, state-snapshot=on ")
+	if err != nil {
+		t.Fatalf("Failed to parse flags: %v", err)
+	}
+	if len(flags) != 2 || flags["share-links"] || !flags["state-snapshot"] {
+		t.Errorf("Unexpected flags %v", flags)
+	}
+
+	for _, spec := range []string{"share-links", "share-links=maybe", "time-travel=on"} {
+		if _, err := parseFeatureFlags(spec); err == nil {
+			t.Errorf("Expected %q to be rejected", spec)
+		}
+	}
+}
+
+// TestFeatureMiddleware tests that endpoints of disabled flags are hidden
+// and that deprecated endpoints announce their sunset
+func TestFeatureMiddleware(t *testing.T) {
+	sunset := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	defer func(saved []Deprecation) { apiDeprecations = saved }(apiDeprecations)
+	apiDeprecations = []Deprecation{{Endpoint: "/api/nodes", Deprecated: time.Unix(1780000000, 0), Sunset: &sunset, Successor: "/api/tee-inventory"}}
+
+	server := &Server{metrics: newMetrics(), featureFlags: map[string]bool{"share-links": false}}
+	handler := server.featureMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"/api/workload/icu/pacs/share", http.StatusNotFound},
+		{"/api/workload/icu/pacs/share/abc", http.StatusNotFound},
+		{"/api/shared/token", http.StatusNotFound},
+		{"/api/workload/icu/pacs", http.StatusOK},
+		{"/api/export/snapshot", http.StatusOK},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+		if w.Code != tt.want {
+			t.Errorf("%s: expected %d, got %d", tt.path, tt.want, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/nodes", nil))
+	if got := w.Header().Get("Deprecation"); got != "@1780000000" {
+		t.Errorf("Unexpected Deprecation header %q", got)
+	}
+	if got := w.Header().Get("Sunset"); got != "Fri, 01 Jan 2027 00:00:00 GMT" {
+		t.Errorf("Unexpected Sunset header %q", got)
+	}
+	if got := w.Header().Get("Link"); got != `</api/tee-inventory>; rel="successor-version"` {
+		t.Errorf("Unexpected Link header %q", got)
+	}
+	if got := server.metrics.Value("dashboard_deprecated_requests_total", "endpoint", "/api/nodes"); got != 1 {
+		t.Errorf("Expected the deprecated call counted, got %v", got)
+	}
+}
+
+// TestHandleFeatures tests that flags are listed with their state
+func TestHandleFeatures(t *testing.T) {
+	server := &Server{featureFlags: map[string]bool{"share-links": false}}
+	w := httptest.NewRecorder()
+	server.handleFeatures(w, httptest.NewRequest("GET", "/api/features", nil))
+
+	var response FeaturesResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Flags) != len(knownFeatureFlags) || response.Deprecations == nil {
+		t.Fatalf("Unexpected response %+v", response)
+	}
+	for _, flag := range response.Flags {
+		if flag.Name == "share-links" && (flag.Enabled || !flag.Default || len(flag.Endpoints) != 3) {
+			t.Errorf("Expected share-links disabled against its default, got %+v", flag)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rh-summit-coco/raj-hospital-dashboard/backend/pkg/client"
+)
+
+// siteConfig is one hospital's dashboard polled in federation mode
+type siteConfig struct {
+	Name         string `json:"name"`
+	URL          string `json:"url"`                     // base URL of the site's dashboard API
+	DashboardURL string `json:"dashboard_url,omitempty"` // link for drill-down, if the API URL is internal
+	Token        string `json:"token,omitempty"`         // Bearer token for the site's API
+	TokenFile    string `json:"token_file,omitempty"`    // Alternative to Token, re-read on every poll
+	CAFile       string `json:"ca_file,omitempty"`       // CA bundle for the site's TLS certificate
+
+	httpClient *http.Client
+}
+
+// federation polls other dashboard instances' /api/status, for a network
+// operations center overseeing several hospitals. The last known rollup of
+// an unreachable site is kept and flagged.
+type federation struct {
+	sites    []siteConfig
+	interval time.Duration
+	metrics  *Metrics
+
+	mu      sync.Mutex
+	summary map[string]*SiteSummary
+}
+
+// loadFederation reads the site list from a JSON file
+func loadFederation(path string, interval time.Duration) (*federation, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var sites []siteConfig
+	if err := json.Unmarshal(data, &sites); err != nil {
+		return nil, fmt.Errorf("invalid federation config: %w", err)
+	}
+	if len(sites) == 0 {
+		return nil, fmt.Errorf("federation config lists no sites")
+	}
+
+	f := &federation{interval: interval, summary: make(map[string]*SiteSummary)}
+	for i := range sites {
+		site := &sites[i]
+		if site.Name == "" || site.URL == "" {
+			return nil, fmt.Errorf("site %d: name and url are required", i)
+		}
+		if _, dup := f.summary[site.Name]; dup {
+			return nil, fmt.Errorf("duplicate site name %q", site.Name)
+		}
+		site.URL = strings.TrimRight(site.URL, "/")
+		if site.DashboardURL == "" {
+			site.DashboardURL = site.URL
+		}
+
+		site.httpClient = &http.Client{Timeout: 10 * time.Second}
+		if site.CAFile != "" {
+			caPEM, err := os.ReadFile(site.CAFile)
+			if err != nil {
+				return nil, fmt.Errorf("site %s: %w", site.Name, err)
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(caPEM) {
+				return nil, fmt.Errorf("site %s: no certificates in %s", site.Name, site.CAFile)
+			}
+			tlsConfig := newTLSConfig()
+			tlsConfig.RootCAs = pool
+			site.httpClient.Transport = &http.Transport{TLSClientConfig: tlsConfig}
+		}
+		f.summary[site.Name] = &SiteSummary{Name: site.Name, URL: site.DashboardURL, Status: "unreachable"}
+	}
+	f.sites = sites
+	return f, nil
+}
+
+// run polls every site until the process exits
+func (f *federation) run() {
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+
+	for {
+		f.pollSites()
+		<-ticker.C
+	}
+}
+
+// pollSites polls all sites concurrently, within one interval
+func (f *federation) pollSites() {
+	ctx, cancel := context.WithTimeout(context.Background(), f.interval)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for i := range f.sites {
+		wg.Add(1)
+		go func(site *siteConfig) {
+			defer wg.Done()
+			response, err := site.status(ctx)
+			f.record(site.Name, response, err, time.Now())
+		}(&f.sites[i])
+	}
+	wg.Wait()
+}
+
+// status fetches a site's /api/status
+func (site *siteConfig) status(ctx context.Context) (*DashboardResponse, error) {
+	token := site.Token
+	if site.TokenFile != "" {
+		data, err := os.ReadFile(site.TokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read token file: %w", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	c := client.New(site.URL, client.WithToken(token), client.WithHTTPClient(site.httpClient), client.WithRetries(1, time.Second))
+	return c.Status(ctx, client.ListOptions{})
+}
+
+// record updates a site's rollup from the outcome of polling it
+func (f *federation) record(name string, response *DashboardResponse, err error, now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	summary := f.summary[name]
+	if err != nil {
+		log.Printf("Failed to poll federated site %s: %v", name, err)
+		f.metrics.Inc("dashboard_federation_poll_failures_total", "Failed polls of federated sites.", "site", name)
+		summary.Status = "unreachable"
+		summary.LastError = err.Error()
+		return
+	}
+
+	summary.Status = response.OverallStatus
+	summary.Workloads, summary.Attested, summary.Failed, summary.Unknown = len(response.Workloads), 0, 0, 0
+	for _, workload := range response.Workloads {
+		switch {
+		case workload.Attested:
+			summary.Attested++
+		case isUnknown(&workload):
+			summary.Unknown++
+		default:
+			summary.Failed++
+		}
+	}
+	summary.DataSource, summary.DataAsOf = response.DataSource, response.DataAsOf
+	summary.LastPoll = &now
+	summary.LastError = ""
+}
+
+// rollup returns every site's summary and the network-wide status: the
+// worst of the sites, with an unreachable site counting as a warning
+func (f *federation) rollup(now time.Time) FederationResponse {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	response := FederationResponse{OverallStatus: "compliant", Sites: make([]SiteSummary, 0, len(f.summary)), LastUpdated: now}
+	for _, summary := range f.summary {
+		switch {
+		case summary.Status == "violation":
+			response.OverallStatus = "violation"
+		case summary.Status != "compliant" && response.OverallStatus == "compliant":
+			response.OverallStatus = "warning"
+		}
+		response.Sites = append(response.Sites, *summary)
+	}
+	sort.Slice(response.Sites, func(i, j int) bool { return response.Sites[i].Name < response.Sites[j].Name })
+	return response
+}
+
+// handleFederation returns the rollup of every federated site, with links to
+// drill down into each site's dashboard
+// GET /api/federation
+func (s *Server) handleFederation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.federation == nil {
+		http.Error(w, "federation is not enabled", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.federation.rollup(time.Now()))
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestFederationRollup tests that sites' own /api/status rollups are
+// aggregated, and that an unreachable site keeps its last known state
+func TestFederationRollup(t *testing.T) {
+	north := &Server{statusCache: map[string]*WorkloadStatus{
+		"icu/a": verifiedStatus("icu", "a"),
+		"icu/b": failedStatus("icu", "b"),
+	}}
+	northAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer north-token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		north.handleStatus(w, r)
+	}))
+	defer northAPI.Close()
+
+	south := &Server{statusCache: map[string]*WorkloadStatus{"radiology/pacs": verifiedStatus("radiology", "pacs")}}
+	southUp := true
+	southAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !southUp {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		south.handleStatus(w, r)
+	}))
+	defer southAPI.Close()
+
+	path := filepath.Join(t.TempDir(), "federation.json")
+	sites, _ := json.Marshal([]map[string]string{
+		{"name": "st-raj-north", "url": northAPI.URL + "/", "token": "north-token", "dashboard_url": "https://north.example.org"},
+		{"name": "st-raj-south", "url": southAPI.URL},
+	})
+	os.WriteFile(path, sites, 0o600)
+	f, err := loadFederation(path, 5*time.Second)
+	if err != nil {
+		t.Fatalf("Failed to load federation config: %v", err)
+	}
+	f.metrics = newMetrics()
+	server := &Server{federation: f}
+
+	f.pollSites()
+	response := federationResponse(t, server)
+	if response.OverallStatus != "violation" || len(response.Sites) != 2 {
+		t.Fatalf("Expected a violation across 2 sites, got %+v", response)
+	}
+	north0 := response.Sites[0]
+	if north0.Name != "st-raj-north" || north0.URL != "https://north.example.org" || north0.Status != "violation" ||
+		north0.Workloads != 2 || north0.Failed != 1 || north0.DataSource != sourcePoll || north0.LastPoll == nil {
+		t.Errorf("Unexpected north summary %+v", north0)
+	}
+	if south0 := response.Sites[1]; south0.URL != southAPI.URL || south0.Status != "compliant" || south0.Attested != 1 {
+		t.Errorf("Unexpected south summary %+v", south0)
+	}
+
+	// The north site recovers while the south one goes down
+	north.statusCache["icu/b"] = verifiedStatus("icu", "b")
+	southUp = false
+	f.pollSites()
+	response = federationResponse(t, server)
+	if response.OverallStatus != "warning" {
+		t.Errorf("Expected an unreachable site to be a warning, got %s", response.OverallStatus)
+	}
+	if south1 := response.Sites[1]; south1.Status != "unreachable" || south1.LastError == "" || south1.Attested != 1 {
+		t.Errorf("Expected the south site's last known state to be kept and flagged, got %+v", south1)
+	}
+	if got := f.metrics.Value("dashboard_federation_poll_failures_total", "site", "st-raj-south"); got != 1 {
+		t.Errorf("Expected 1 failed poll, got %v", got)
+	}
+}
+
+func federationResponse(t *testing.T, server *Server) FederationResponse {
+	t.Helper()
+	w := httptest.NewRecorder()
+	server.handleFederation(w, httptest.NewRequest("GET", "/api/federation", nil))
+	var response FederationResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode federation response: %v", err)
+	}
+	return response
+}
+
+// TestLoadFederationInvalid tests that incomplete site lists are rejected
+func TestLoadFederationInvalid(t *testing.T) {
+	for _, config := range []string{
+		`[]`,
+		`[{"name":"north"}]`,
+		`[{"name":"north","url":"https://a"},{"name":"north","url":"https://b"}]`,
+	} {
+		path := filepath.Join(t.TempDir(), "federation.json")
+		os.WriteFile(path, []byte(config), 0o600)
+		if _, err := loadFederation(path, time.Minute); err == nil {
+			t.Errorf("Expected %s to be rejected", config)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	(&Server{}).handleFederation(w, httptest.NewRequest("GET", "/api/federation", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 without federation, got %d", w.Code)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"crypto/rsa"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// fipsMode restricts TLS and token verification to FIPS 140-3 approved
+// algorithms. It is enabled with FIPS_MODE=true, and always in binaries
+// built with GOEXPERIMENT=boringcrypto (see fips_boring.go), and must be set
+// before any TLS client is configured.
+//
+// The algorithms the dashboard verifies tokens with are already approved -
+// HMAC-SHA256 for subscription, session and webhook signatures, RSA-SHA256
+// for SAML assertions - so FIPS mode adds the key size requirements of
+// SP 800-131A on top. SHA-1 remains in the WebSocket handshake, where the
+// protocol mandates it and it protects nothing.
+var fipsMode = fipsBuild
+
+// fipsMinHMACKeyBytes is the 112-bit minimum HMAC key strength of SP 800-131A
+const fipsMinHMACKeyBytes = 14
+
+// fipsMinRSABits is the minimum RSA modulus accepted for signature verification
+const fipsMinRSABits = 2048
+
+// fipsCipherSuites are the TLS 1.2 suites used in FIPS mode: ECDHE key
+// exchange with AES-GCM only
+var fipsCipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+}
+
+// newTLSConfig returns the base config for every outgoing TLS connection
+func newTLSConfig() *tls.Config {
+	if !fipsMode {
+		return &tls.Config{}
+	}
+	// crypto/tls doesn't allow restricting the TLS 1.3 suites, which include
+	// ChaCha20-Poly1305, so FIPS mode stays on TLS 1.2
+	return &tls.Config{
+		MinVersion:       tls.VersionTLS12,
+		MaxVersion:       tls.VersionTLS12,
+		CipherSuites:     fipsCipherSuites,
+		CurvePreferences: []tls.CurveID{tls.CurveP256, tls.CurveP384},
+	}
+}
+
+// verifyFIPS checks the loaded configuration for keys too weak for FIPS
+// mode, so a non-compliant deployment fails at startup rather than running
+// with weakened guarantees
+func (s *Server) verifyFIPS() error {
+	var problems []string
+	if s.streamTokens != nil && len(s.streamTokens.secret) < fipsMinHMACKeyBytes {
+		problems = append(problems, fmt.Sprintf("STREAM_TOKEN_SECRET is shorter than %d bytes", fipsMinHMACKeyBytes))
+	}
+	if s.auth != nil && s.auth.sessions != nil && len(s.auth.sessions.secret) < fipsMinHMACKeyBytes {
+		problems = append(problems, fmt.Sprintf("SESSION_SECRET is shorter than %d bytes", fipsMinHMACKeyBytes))
+	}
+	if s.saml != nil {
+		key, ok := s.saml.certificate.PublicKey.(*rsa.PublicKey)
+		if !ok || key.N.BitLen() < fipsMinRSABits {
+			problems = append(problems, fmt.Sprintf("SAML IdP certificate must have an RSA key of at least %d bits", fipsMinRSABits))
+		}
+	}
+	if s.signer != nil && s.signer.alg == "EdDSA" {
+		// Ed25519 is not served by the validated module
+		problems = append(problems, "response signing key must be ECDSA P-256 or RSA")
+	}
+	if s.notifier != nil {
+		for name, target := range s.notifier.targets {
+			if target.Secret != "" && len(target.Secret) < fipsMinHMACKeyBytes {
+				problems = append(problems, fmt.Sprintf("notification target %s: secret is shorter than %d bytes", name, fipsMinHMACKeyBytes))
+			}
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	sort.Strings(problems)
+	return errors.New(strings.Join(problems, "; "))
+}
@@ -0,0 +1,19 @@
+//go:build goexperiment.boringcrypto
+
+package main
+
+import (
+	"crypto/boring"
+
+	// Restricts crypto/tls to FIPS-approved settings process-wide
+	_ "crypto/tls/fipsonly"
+)
+
+// Built with GOEXPERIMENT=boringcrypto: the BoringCrypto module is FIPS 140
+// validated, so FIPS mode is always on
+const fipsBuild = true
+
+// fipsModule reports whether crypto operations are served by the module
+func fipsModule() bool {
+	return boring.Enabled()
+}
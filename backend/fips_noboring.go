@@ -0,0 +1,11 @@
+//go:build !goexperiment.boringcrypto
+
+package main
+
+const fipsBuild = false
+
+// fipsModule reports whether crypto operations are served by a FIPS 140
+// validated module; the standard Go crypto packages are not one
+func fipsModule() bool {
+	return false
+}
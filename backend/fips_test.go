@@ -0,0 +1,67 @@
+package main
+
+import (
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestNewTLSConfig tests that FIPS mode limits TLS to 1.2 with approved suites
+func TestNewTLSConfig(t *testing.T) {
+	defer func(mode bool) { fipsMode = mode }(fipsMode)
+
+	fipsMode = false
+	if config := newTLSConfig(); config.MaxVersion != 0 || config.CipherSuites != nil {
+		t.Errorf("Expected default TLS config outside FIPS mode, got %+v", config)
+	}
+
+	fipsMode = true
+	config := newTLSConfig()
+	if config.MinVersion != tls.VersionTLS12 || config.MaxVersion != tls.VersionTLS12 {
+		t.Errorf("Expected TLS 1.2 only, got %x-%x", config.MinVersion, config.MaxVersion)
+	}
+	for _, suite := range config.CipherSuites {
+		if name := tls.CipherSuiteName(suite); !strings.Contains(name, "ECDHE") || !strings.Contains(name, "GCM") {
+			t.Errorf("Expected only ECDHE AES-GCM suites, got %s", name)
+		}
+	}
+	config.ServerName = "collector"
+	if newTLSConfig().ServerName != "" {
+		t.Error("Expected a fresh config on every call")
+	}
+}
+
+// TestVerifyFIPS tests that weak secrets and signing keys are reported
+func TestVerifyFIPS(t *testing.T) {
+	streamTokens, _ := newStreamTokens("", time.Minute)
+	server := &Server{streamTokens: streamTokens}
+	if err := server.verifyFIPS(); err != nil {
+		t.Errorf("Expected generated secrets to be compliant, got %v", err)
+	}
+
+	sessions, _ := newStreamTokens("short", time.Hour)
+	weakKey := &rsa.PublicKey{N: new(big.Int).Lsh(big.NewInt(1), 1023), E: 65537}
+	server.auth = &Authenticator{sessions: sessions}
+	server.saml = newSAMLProvider(SAMLConfig{}, &x509.Certificate{PublicKey: weakKey})
+	server.notifier, _ = newNotifier([]notifyTarget{
+		{Name: "pager", URL: "http://pager.local", Secret: "secret"},
+		{Name: "chat", URL: "http://chat.local", Secret: "a-long-enough-secret"},
+	}, nil)
+
+	err := server.verifyFIPS()
+	if err == nil {
+		t.Fatal("Expected weak configuration to be rejected")
+	}
+	for _, want := range []string{"SESSION_SECRET", "SAML IdP certificate", "notification target pager"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %q to be reported, got %v", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), "chat") {
+		t.Errorf("Expected long secret to pass, got %v", err)
+	}
+}
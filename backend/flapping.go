@@ -0,0 +1,92 @@
+package main
+
+import "time"
+
+// flapDetector counts verdict transitions (violation <-> healthy) per
+// workload over a sliding window. A workload with more than threshold
+// transitions in the window is flapping: it is flagged, and its individual
+// transitions are replaced by a single "flapping" alert.
+type flapDetector struct {
+	threshold   int
+	window      time.Duration
+	transitions map[string][]time.Time
+}
+
+// newFlapDetector returns a detector, or nil if threshold is 0 (disabled)
+func newFlapDetector(threshold int, window time.Duration) *flapDetector {
+	if threshold <= 0 {
+		return nil
+	}
+	return &flapDetector{
+		threshold:   threshold,
+		window:      window,
+		transitions: make(map[string][]time.Time),
+	}
+}
+
+// observe records a transition of key at now if there was one, and returns
+// the number of transitions within the window
+func (f *flapDetector) observe(key string, transitioned bool, now time.Time) int {
+	times := f.transitions[key]
+	if transitioned {
+		times = append(times, now)
+	}
+
+	cutoff := now.Add(-f.window)
+	i := 0
+	for i < len(times) && !times[i].After(cutoff) {
+		i++
+	}
+	times = times[i:]
+
+	if len(times) == 0 {
+		delete(f.transitions, key)
+	} else {
+		f.transitions[key] = times
+	}
+	return len(times)
+}
+
+// retain forgets workloads that are no longer cached
+func (f *flapDetector) retain(cache map[string]*WorkloadStatus) {
+	for key := range f.transitions {
+		if _, ok := cache[key]; !ok {
+			delete(f.transitions, key)
+		}
+	}
+}
+
+// markFlapping updates the flapping state of a new status from its previous
+// snapshot (nil if new). Caller must hold cacheMutex.
+func (s *Server) markFlapping(key string, prev, status *WorkloadStatus, now time.Time) {
+	if s.flaps == nil {
+		return
+	}
+
+	transitioned := prev != nil && isViolation(prev) != isViolation(status)
+	count := s.flaps.observe(key, transitioned, now)
+	if count > s.flaps.threshold {
+		status.Flapping = true
+		status.FlapCount = count
+	}
+}
+
+// flappingEvents returns a "flapping" event for every workload that started
+// flapping between the old and new cache
+func flappingEvents(old, updated map[string]*WorkloadStatus, now time.Time) []HistoryEvent {
+	var events []HistoryEvent
+	for key, status := range updated {
+		if !status.Flapping {
+			continue
+		}
+		if prev, ok := old[key]; ok && prev.Flapping {
+			continue
+		}
+		event := HistoryEvent{Time: now, Key: key, Type: "flapping", Status: copyStatus(status)}
+		if prev, ok := old[key]; ok {
+			event.PreviousStatus = prev.AttestationStatus
+		}
+		events = append(events, event)
+	}
+	return events
+}
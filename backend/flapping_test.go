@@ -0,0 +1,60 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestFlappingDetection tests that a workload oscillating more than the
+// threshold is flagged and produces a single flapping alert
+func TestFlappingDetection(t *testing.T) {
+	server := &Server{
+		statusCache: make(map[string]*WorkloadStatus),
+		flaps:       newFlapDetector(2, time.Hour),
+	}
+	synced := map[string]bool{"": true}
+
+	var flappingAlerts, notified int
+	for i := 0; i < 6; i++ {
+		status := &WorkloadStatus{Name: "pod", Namespace: "icu", Attested: i%2 == 0}
+		events := server.applyStatuses([]*WorkloadStatus{status}, synced, nil)
+		for _, event := range events {
+			if event.Type == "flapping" {
+				flappingAlerts++
+			}
+			if notifiable(event) {
+				notified++
+			}
+		}
+	}
+
+	status := server.statusCache["icu/pod"]
+	if !status.Flapping || status.FlapCount != 5 {
+		t.Errorf("Expected workload flapping with 5 transitions, got flapping=%v count=%d", status.Flapping, status.FlapCount)
+	}
+	if flappingAlerts != 1 {
+		t.Errorf("Expected 1 flapping event, got %d", flappingAlerts)
+	}
+	// changed (fail), changed (recover) and the flapping alert; the rest are suppressed
+	if notified != 3 {
+		t.Errorf("Expected 3 notifiable events, got %d", notified)
+	}
+}
+
+// TestFlapWindow tests that transitions older than the window are forgotten
+func TestFlapWindow(t *testing.T) {
+	f := newFlapDetector(2, time.Hour)
+	start := time.Now()
+
+	f.observe("k", true, start)
+	f.observe("k", true, start.Add(10*time.Minute))
+	if n := f.observe("k", true, start.Add(20*time.Minute)); n != 3 {
+		t.Errorf("Expected 3 transitions, got %d", n)
+	}
+	if n := f.observe("k", false, start.Add(75*time.Minute)); n != 1 {
+		t.Errorf("Expected 1 transition after the window slides, got %d", n)
+	}
+	if n := f.observe("k", false, start.Add(3*time.Hour)); n != 0 || len(f.transitions) != 0 {
+		t.Errorf("Expected detector state to be cleared, got %d", n)
+	}
+}
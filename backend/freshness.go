@@ -0,0 +1,59 @@
+package main
+
+import "time"
+
+// lagBuckets are the histogram buckets, in seconds, for report freshness:
+// from within a poll interval up to reports an hour old
+var lagBuckets = []float64{1, 5, 15, 30, 60, 120, 300, 600, 1800, 3600}
+
+// observeReportAges records how old each newly received report was when
+// the dashboard ingested it. A report seen on an earlier poll isn't counted
+// again. Caller must not hold cacheMutex.
+func (s *Server) observeReportAges(reports []CollectorReport, now time.Time) {
+	s.cacheMutex.RLock()
+	defer s.cacheMutex.RUnlock()
+
+	for _, report := range reports {
+		if report.Timestamp.IsZero() {
+			continue
+		}
+		if previous, ok := s.reports[report.Namespace+"/"+report.PodName]; ok && previous.Timestamp.Equal(report.Timestamp) {
+			continue
+		}
+		s.metrics.Observe("dashboard_report_age_at_ingest_seconds",
+			"Age of attestation reports when the dashboard first received them.",
+			lagBuckets, lagSeconds(report.Timestamp, now), report.TraceID, "cluster", report.Cluster)
+	}
+}
+
+// observeDetectionLag records, for every workload that became visible or
+// changed state this cycle, the time from the report behind it to the
+// change showing on the dashboard
+func (s *Server) observeDetectionLag(reports []CollectorReport, events []HistoryEvent) {
+	byKey := make(map[string]CollectorReport, len(reports))
+	for _, report := range reports {
+		byKey[report.Namespace+"/"+report.PodName] = report
+	}
+
+	for _, event := range events {
+		if event.Type != "added" && event.Type != "changed" {
+			continue
+		}
+		report, ok := byKey[event.Key]
+		if !ok || report.Timestamp.IsZero() {
+			continue
+		}
+		s.metrics.Observe("dashboard_detection_lag_seconds",
+			"Time from an attestation report to the workload state change it caused being visible on the dashboard.",
+			lagBuckets, lagSeconds(report.Timestamp, event.Time), report.TraceID, "cluster", report.Cluster)
+	}
+}
+
+// lagSeconds returns the time from t to now in seconds. Reports from clocks
+// running ahead count as no lag rather than negative.
+func lagSeconds(t, now time.Time) float64 {
+	if lag := now.Sub(t).Seconds(); lag > 0 {
+		return lag
+	}
+	return 0
+}
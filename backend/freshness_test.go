@@ -0,0 +1,65 @@
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestFreshnessHistograms tests report age and detection lag histograms
+// with trace exemplars
+func TestFreshnessHistograms(t *testing.T) {
+	now := time.Now()
+	server := &Server{metrics: newMetrics(), statusCache: map[string]*WorkloadStatus{}}
+	fresh := CollectorReport{PodName: "pacs", Namespace: "icu", Cluster: "east", Timestamp: now.Add(-10 * time.Second), TraceID: "4bf92f3577b34da6a3ce929d0e0e4736"}
+	stale := CollectorReport{PodName: "lims", Namespace: "lab", Cluster: "east", Timestamp: now.Add(-20 * time.Minute)}
+	reports := []CollectorReport{fresh, stale}
+
+	server.observeReportAges(reports, now)
+	server.observeDetectionLag(reports, []HistoryEvent{
+		{Time: now, Key: "icu/pacs", Type: "added"},
+		{Time: now, Key: "lab/lims", Type: "removed"},
+	})
+
+	// Reports already seen on an earlier poll aren't observed again
+	server.reports = map[string]CollectorReport{"icu/pacs": fresh, "lab/lims": stale}
+	server.observeReportAges(reports, now.Add(30*time.Second))
+
+	w := httptest.NewRecorder()
+	server.handleMetrics(w, httptest.NewRequest("GET", "/metrics", nil))
+	body := w.Body.String()
+	for _, expected := range []string{
+		`# TYPE dashboard_report_age_at_ingest_seconds histogram`,
+		`dashboard_report_age_at_ingest_seconds_bucket{cluster="east",le="15"} 1`,
+		`dashboard_report_age_at_ingest_seconds_bucket{cluster="east",le="1800"} 2`,
+		`dashboard_report_age_at_ingest_seconds_count{cluster="east"} 2`,
+		`dashboard_detection_lag_seconds_bucket{cluster="east",le="+Inf"} 1`,
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("Expected metrics output to contain %q, got:\n%s", expected, body)
+		}
+	}
+	if strings.Contains(body, "trace_id") || strings.Contains(body, "# EOF") {
+		t.Errorf("Expected no exemplars in the Prometheus text format, got:\n%s", body)
+	}
+
+	server.metrics.Inc("dashboard_test_total", "Test counter.")
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text; version=1.0.0,text/plain;q=0.5")
+	w = httptest.NewRecorder()
+	server.handleMetrics(w, req)
+	body = w.Body.String()
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "application/openmetrics-text") || !strings.HasSuffix(body, "# EOF\n") {
+		t.Errorf("Expected OpenMetrics output, got %s:\n%s", w.Header().Get("Content-Type"), body)
+	}
+	for _, expected := range []string{
+		`dashboard_report_age_at_ingest_seconds_bucket{cluster="east",le="15"} 1 # {trace_id="4bf92f3577b34da6a3ce929d0e0e4736"} 10 `,
+		`# TYPE dashboard_test counter`,
+		`dashboard_test_total 1`,
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("Expected OpenMetrics output to contain %q, got:\n%s", expected, body)
+		}
+	}
+}
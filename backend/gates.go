@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// maxGateConcurrency bounds parallel gate checks per poll cycle
+const maxGateConcurrency = 8
+
+// gateInput is what a gate evaluates: the raw Collector report and the
+// workload status derived from it
+type gateInput struct {
+	Report   *CollectorReport `json:"report"`
+	Workload *WorkloadStatus  `json:"workload"`
+}
+
+// gate is an additional check evaluated for every workload each poll cycle
+type gate interface {
+	check(input gateInput) GateResult
+}
+
+// gatesConfig is the format of the GATES_CONFIG file
+type gatesConfig struct {
+	External []ExternalGate `json:"external"`
+	Exec     []ExecGate     `json:"exec"`
+}
+
+// ExternalGate is an organization-specific HTTP check (e.g. CMDB registration)
+// evaluated for every workload on each poll cycle
+type ExternalGate struct {
+	Name         string `json:"name"`
+	URLTemplate  string `json:"url_template"`            // text/template over the workload, e.g. https://cmdb/api/ci/{{.Namespace}}/{{.Name | urlquery}}
+	Method       string `json:"method,omitempty"`        // default GET
+	ExpectStatus int    `json:"expect_status,omitempty"` // default 200
+	ExpectBody   string `json:"expect_body,omitempty"`   // substring the response body must contain
+	Timeout      string `json:"timeout,omitempty"`       // default 5s
+	FailOpen     bool   `json:"fail_open,omitempty"`     // treat unreachable endpoints as passing
+
+	tmpl   *template.Template
+	client *http.Client
+}
+
+// loadGates reads gate definitions from a JSON file
+func loadGates(path string) ([]gate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var config gatesConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("invalid gates config: %w", err)
+	}
+
+	var gates []gate
+	for i := range config.External {
+		if err := config.External[i].init(); err != nil {
+			return nil, fmt.Errorf("external gate %q: %w", config.External[i].Name, err)
+		}
+		gates = append(gates, &config.External[i])
+	}
+	for i := range config.Exec {
+		if err := config.Exec[i].init(); err != nil {
+			return nil, fmt.Errorf("exec gate %q: %w", config.Exec[i].Name, err)
+		}
+		gates = append(gates, &config.Exec[i])
+	}
+	return gates, nil
+}
+
+// init validates the gate and compiles its URL template
+func (g *ExternalGate) init() error {
+	if g.Name == "" || g.URLTemplate == "" {
+		return fmt.Errorf("name and url_template are required")
+	}
+
+	tmpl, err := template.New(g.Name).Option("missingkey=error").Parse(g.URLTemplate)
+	if err != nil {
+		return fmt.Errorf("invalid url_template: %w", err)
+	}
+	g.tmpl = tmpl
+
+	if g.Method == "" {
+		g.Method = http.MethodGet
+	}
+	if g.ExpectStatus == 0 {
+		g.ExpectStatus = http.StatusOK
+	}
+
+	timeout := 5 * time.Second
+	if g.Timeout != "" {
+		if timeout, err = time.ParseDuration(g.Timeout); err != nil {
+			return fmt.Errorf("invalid timeout: %w", err)
+		}
+	}
+	g.client = &http.Client{Timeout: timeout}
+	return nil
+}
+
+// check evaluates the gate for one workload
+func (g *ExternalGate) check(input gateInput) GateResult {
+	result := GateResult{Name: g.Name}
+
+	var url bytes.Buffer
+	if err := g.tmpl.Execute(&url, input.Workload); err != nil {
+		result.Status = "error"
+		result.Details = fmt.Sprintf("failed to render URL: %v", err)
+		return result
+	}
+
+	req, err := http.NewRequest(g.Method, url.String(), nil)
+	if err != nil {
+		result.Status = "error"
+		result.Details = fmt.Sprintf("invalid request: %v", err)
+		return result
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		result.Status = "error"
+		result.Details = fmt.Sprintf("check unreachable: %v", err)
+		if g.FailOpen {
+			result.Status = "passing"
+		}
+		return result
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+
+	switch {
+	case resp.StatusCode != g.ExpectStatus:
+		result.Status = "failed"
+		result.Details = fmt.Sprintf("expected status %d, got %d", g.ExpectStatus, resp.StatusCode)
+	case g.ExpectBody != "" && !strings.Contains(string(body), g.ExpectBody):
+		result.Status = "failed"
+		result.Details = fmt.Sprintf("response does not contain %q", g.ExpectBody)
+	default:
+		result.Status = "passing"
+	}
+	return result
+}
+
+// evaluateGates runs every configured gate against every workload with
+// bounded concurrency, storing the results on the statuses. reports and
+// statuses are index-aligned.
+func (s *Server) evaluateGates(reports []CollectorReport, statuses []*WorkloadStatus) {
+	if len(s.gates) == 0 {
+		return
+	}
+
+	sem := make(chan struct{}, maxGateConcurrency)
+	var wg sync.WaitGroup
+
+	for n, status := range statuses {
+		status.Gates = make([]GateResult, len(s.gates))
+		input := gateInput{Report: &reports[n], Workload: copyStatus(status)}
+		for i := range s.gates {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(status *WorkloadStatus, input gateInput, i int) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				status.Gates[i] = s.gates[i].check(input)
+			}(status, input, i)
+		}
+	}
+
+	wg.Wait()
+
+	for _, status := range statuses {
+		gateChecks(status)
+	}
+}
+
+// gatesFailed reports whether any additional gate failed or errored
+func gatesFailed(gates []GateResult) bool {
+	for _, gate := range gates {
+		if gate.Status != "passing" {
+			return true
+		}
+	}
+	return false
+}
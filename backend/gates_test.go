@@ -0,0 +1,112 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestExternalGateCheck tests URL templating and response expectations
+func TestExternalGateCheck(t *testing.T) {
+	cmdb := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ci/icu/registered":
+			w.Write([]byte(`{"state":"registered"}`))
+		case "/ci/icu/pending":
+			w.Write([]byte(`{"state":"pending"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer cmdb.Close()
+
+	gate := ExternalGate{
+		Name:        "cmdb",
+		URLTemplate: cmdb.URL + "/ci/{{.Namespace}}/{{.Name | urlquery}}",
+		ExpectBody:  `"registered"`,
+	}
+	if err := gate.init(); err != nil {
+		t.Fatalf("Failed to init gate: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"registered", "passing"},
+		{"pending", "failed"},
+		{"unknown", "failed"},
+	}
+	for _, test := range tests {
+		result := gate.check(gateInput{Workload: &WorkloadStatus{Name: test.name, Namespace: "icu"}})
+		if result.Status != test.expected {
+			t.Errorf("check(%s) = %s (%s), expected %s", test.name, result.Status, result.Details, test.expected)
+		}
+	}
+
+	unreachable := ExternalGate{Name: "down", URLTemplate: "http://127.0.0.1:1/{{.Name}}", Timeout: "200ms"}
+	unreachable.init()
+	if result := unreachable.check(gateInput{Workload: &WorkloadStatus{Name: "x"}}); result.Status != "error" {
+		t.Errorf("Expected error for unreachable gate, got %s", result.Status)
+	}
+	unreachable.FailOpen = true
+	if result := unreachable.check(gateInput{Workload: &WorkloadStatus{Name: "x"}}); result.Status != "passing" {
+		t.Errorf("Expected fail-open gate to pass, got %s", result.Status)
+	}
+}
+
+// TestLoadGatesValidation tests gate config validation
+func TestLoadGatesValidation(t *testing.T) {
+	dir := t.TempDir()
+
+	bad := filepath.Join(dir, "bad.json")
+	os.WriteFile(bad, []byte(`{"external":[{"name":"x","url_template":"http://{{.Name"}]}`), 0o600)
+	if _, err := loadGates(bad); err == nil {
+		t.Error("Expected error for invalid template")
+	}
+
+	good := filepath.Join(dir, "good.json")
+	os.WriteFile(good, []byte(`{"external":[{"name":"cmdb","url_template":"http://cmdb/{{.Name}}","timeout":"2s"}]}`), 0o600)
+	gates, err := loadGates(good)
+	if err != nil || len(gates) != 1 {
+		t.Fatalf("Unexpected gates %+v (%v)", gates, err)
+	}
+	if g := gates[0].(*ExternalGate); g.ExpectStatus != 200 || g.Method != "GET" {
+		t.Errorf("Expected defaults to be applied, got %+v", g)
+	}
+}
+
+// TestFailedGateIsViolation tests that a failing external gate turns the workload red
+func TestFailedGateIsViolation(t *testing.T) {
+	collector := newMockCollector(t, "", []CollectorReport{
+		{PodName: "ai-model", Namespace: "icu", Attested: true, Timestamp: time.Now()},
+	})
+	defer collector.Close()
+
+	cmdb := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer cmdb.Close()
+
+	cmdbGate := ExternalGate{Name: "cmdb", URLTemplate: cmdb.URL + "/{{.Name}}"}
+	cmdbGate.init()
+
+	server := &Server{
+		collectorURL: collector.URL,
+		statusCache:  make(map[string]*WorkloadStatus),
+		httpClient:   &http.Client{Timeout: 10 * time.Second},
+		gates:        []gate{&cmdbGate},
+	}
+	server.fetchFromCollector()
+
+	status := server.statusCache["icu/ai-model"]
+	if len(status.Gates) != 1 || status.Gates[0].Status != "failed" {
+		t.Fatalf("Expected failed cmdb gate, got %+v", status.Gates)
+	}
+	if !isViolation(status) {
+		t.Error("Expected failed gate to be a violation")
+	}
+}
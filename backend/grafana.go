@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// grafanaAnnotationsPath is the Grafana HTTP API path of annotations
+const grafanaAnnotationsPath = "/api/annotations"
+
+// grafanaTarget is a notification target that posts workload transitions as
+// Grafana annotations, so attestation incidents line up with infrastructure
+// metrics on the same graphs. Without a dashboard the annotations are
+// organization-wide, for dashboards that query annotations by tag.
+type grafanaTarget struct {
+	URL          string   `json:"url"`                     // e.g. https://grafana.hospital.example
+	TokenFile    string   `json:"token_file"`              // service account token, re-read on every request
+	DashboardUID string   `json:"dashboard_uid,omitempty"` // empty = organization annotation
+	PanelID      int      `json:"panel_id,omitempty"`      // requires dashboard_uid; 0 = every panel
+	Tags         []string `json:"tags,omitempty"`          // added to every annotation
+}
+
+// init validates the target
+func (g *grafanaTarget) init() error {
+	if u, err := url.Parse(g.URL); err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("invalid url %q", g.URL)
+	}
+	g.URL = strings.TrimRight(g.URL, "/")
+	if g.TokenFile == "" {
+		return fmt.Errorf("token_file is required")
+	}
+	if g.PanelID != 0 && g.DashboardUID == "" {
+		return fmt.Errorf("panel_id requires dashboard_uid")
+	}
+	return nil
+}
+
+// grafanaAnnotation is a request of the annotations API
+type grafanaAnnotation struct {
+	DashboardUID string   `json:"dashboardUID,omitempty"`
+	PanelID      int      `json:"panelId,omitempty"`
+	Time         int64    `json:"time"` // epoch milliseconds
+	Tags         []string `json:"tags"`
+	Text         string   `json:"text"`
+}
+
+// grafanaAnnotationFor describes a transition as an annotation. Besides the
+// configured tags it is tagged with the event, the workload's namespace,
+// cluster and status, so panels can filter on them.
+func (n *Notifier) grafanaAnnotationFor(g *grafanaTarget, payload WebhookPayload) grafanaAnnotation {
+	annotation := grafanaAnnotation{
+		DashboardUID: g.DashboardUID,
+		PanelID:      g.PanelID,
+		Time:         payload.Time.UnixMilli(),
+		Tags:         append(append([]string(nil), g.Tags...), "attestation", payload.Event),
+	}
+
+	var text strings.Builder
+	if w := payload.Workload; w != nil {
+		annotation.Tags = append(annotation.Tags, "namespace:"+w.Namespace, "status:"+w.AttestationStatus)
+		if w.Cluster != "" {
+			annotation.Tags = append(annotation.Tags, "cluster:"+w.Cluster)
+		}
+		fmt.Fprintf(&text, "%s: %s", payload.Key, w.AttestationStatus)
+		if payload.PreviousStatus != "" {
+			fmt.Fprintf(&text, " (was %s)", payload.PreviousStatus)
+		}
+		if w.Details != "" {
+			fmt.Fprintf(&text, "<br>%s", w.Details)
+		}
+	} else {
+		fmt.Fprintf(&text, "%s: %s", payload.Key, strings.TrimPrefix(payload.Event, "workload."))
+	}
+	if payload.Summary != "" {
+		fmt.Fprintf(&text, "<br>%s", payload.Summary)
+	}
+	if link := n.workloadLink(payload.Key); link != "" {
+		fmt.Fprintf(&text, `<br><a href="%s">Dashboard</a>`, link)
+	}
+	annotation.Text = text.String()
+	return annotation
+}
+
+// sendGrafana posts a transition as an annotation
+func (n *Notifier) sendGrafana(target *notifyTarget, payload WebhookPayload) error {
+	g := target.Grafana
+	body, err := json.Marshal(n.grafanaAnnotationFor(g, payload))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, g.URL+grafanaAnnotationsPath, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	token, err := os.ReadFile(g.TokenFile)
+	if err != nil {
+		return fmt.Errorf("failed to read Grafana token: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("Grafana returned status %d", resp.StatusCode)
+	}
+	return nil
+}
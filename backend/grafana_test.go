@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestGrafanaTarget tests posting transitions as dashboard annotations
+func TestGrafanaTarget(t *testing.T) {
+	var annotations []grafanaAnnotation
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != grafanaAnnotationsPath || r.Header.Get("Authorization") != "Bearer glsa_token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var annotation grafanaAnnotation
+		json.NewDecoder(r.Body).Decode(&annotation)
+		annotations = append(annotations, annotation)
+		w.Write([]byte(`{"id":1,"message":"Annotation added"}`))
+	}))
+	defer srv.Close()
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	os.WriteFile(tokenFile, []byte("glsa_token\n"), 0o600)
+
+	notifier, err := newNotifier([]notifyTarget{{Name: "grafana", Grafana: &grafanaTarget{
+		URL:          srv.URL + "/",
+		TokenFile:    tokenFile,
+		DashboardUID: "cluster-overview",
+		PanelID:      4,
+		Tags:         []string{"prod"},
+	}}}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create notifier: %v", err)
+	}
+	notifier.dashboardURL = "https://dashboard.hospital.example"
+
+	event := violationEvent("radiology/pacs")
+	event.Time = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	notifier.Notify([]HistoryEvent{event})
+	notifier.deliverDue()
+	if pending := notifier.Pending(); pending != 0 {
+		t.Fatalf("Expected delivery, %d pending: %s", pending, notifier.queue[0].LastError)
+	}
+
+	if len(annotations) != 1 {
+		t.Fatalf("Expected one annotation, got %+v", annotations)
+	}
+	annotation := annotations[0]
+	if annotation.DashboardUID != "cluster-overview" || annotation.PanelID != 4 || annotation.Time != event.Time.UnixMilli() {
+		t.Errorf("Unexpected annotation %+v", annotation)
+	}
+	for _, tag := range []string{"prod", "attestation", "workload.changed", "namespace:icu", "status:failed"} {
+		if !containsString(annotation.Tags, tag) {
+			t.Errorf("Expected tag %q, got %v", tag, annotation.Tags)
+		}
+	}
+	if !strings.HasPrefix(annotation.Text, "radiology/pacs: failed (was verified)") ||
+		!strings.Contains(annotation.Text, "https://dashboard.hospital.example/api/workload/radiology/pacs") {
+		t.Errorf("Unexpected text %q", annotation.Text)
+	}
+}
+
+// TestGrafanaTargetValidation tests rejecting incomplete targets
+func TestGrafanaTargetValidation(t *testing.T) {
+	for _, g := range []grafanaTarget{
+		{URL: "grafana.hospital.example", TokenFile: "/token"},
+		{URL: "https://grafana.hospital.example"},
+		{URL: "https://grafana.hospital.example", TokenFile: "/token", PanelID: 2},
+	} {
+		if err := g.init(); err == nil {
+			t.Errorf("Expected %+v to be rejected", g)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// heartbeat is a dead man's switch: it pings an external monitor, e.g. a
+// healthchecks.io check, after every successful Collector sync. If the
+// dashboard hangs, crashes or loses its Collectors the pings stop and the
+// monitor alerts, instead of the UI showing its last, green, state.
+type heartbeat struct {
+	url        string // pinged after a sync of every Collector
+	failURL    string // pinged when a Collector could not be synced; optional
+	httpClient *http.Client
+	metrics    *Metrics
+
+	mu      sync.Mutex
+	pending *heartbeatPing // latest ping not sent yet
+	wake    chan struct{}
+}
+
+// heartbeatPing is one outcome of a sync to report
+type heartbeatPing struct {
+	ok      bool
+	message string // sent as the request body, shown in the monitor's log
+}
+
+// newHeartbeatFromEnv configures the heartbeat from HEARTBEAT_URL and
+// HEARTBEAT_FAIL_URL. Returns nil if HEARTBEAT_URL is unset.
+func newHeartbeatFromEnv(metrics *Metrics) (*heartbeat, error) {
+	h := &heartbeat{
+		url:        os.Getenv("HEARTBEAT_URL"),
+		failURL:    os.Getenv("HEARTBEAT_FAIL_URL"),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		metrics:    metrics,
+		wake:       make(chan struct{}, 1),
+	}
+	if h.url == "" {
+		if h.failURL != "" {
+			return nil, fmt.Errorf("HEARTBEAT_FAIL_URL requires HEARTBEAT_URL")
+		}
+		return nil, nil
+	}
+	for _, raw := range []string{h.url, h.failURL} {
+		if raw == "" {
+			continue
+		}
+		if u, err := url.Parse(raw); err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("invalid heartbeat URL %q", raw)
+		}
+	}
+	return h, nil
+}
+
+// beat queues a ping for the outcome of a sync. It never blocks the poll
+// loop: a ping still waiting to be sent is replaced by the newer one.
+func (h *heartbeat) beat(ping heartbeatPing) {
+	if h == nil || !ping.ok && h.failURL == "" {
+		return
+	}
+
+	h.mu.Lock()
+	h.pending = &ping
+	h.mu.Unlock()
+	select {
+	case h.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run sends queued pings
+func (h *heartbeat) run() {
+	for range h.wake {
+		h.mu.Lock()
+		ping := h.pending
+		h.pending = nil
+		h.mu.Unlock()
+		if ping == nil {
+			continue
+		}
+		if err := h.send(*ping); err != nil {
+			log.Printf("Failed to send heartbeat: %v", err)
+			h.metrics.Inc("dashboard_heartbeat_failures_total", "Heartbeat pings the external monitor did not accept.")
+		}
+	}
+}
+
+// send delivers one ping
+func (h *heartbeat) send(ping heartbeatPing) error {
+	target := h.url
+	if !ping.ok {
+		target = h.failURL
+	}
+	resp, err := h.httpClient.Post(target, "text/plain", strings.NewReader(ping.message))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("monitor returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// syncHeartbeat reports the outcome of a poll cycle to the heartbeat: a
+// ping when every Collector was synced, and a failure ping otherwise
+func (s *Server) syncHeartbeat(workloads int, synced map[string]bool, syncErrors map[string]error) {
+	if s.heartbeat == nil {
+		return
+	}
+	if len(syncErrors) == 0 && len(synced) > 0 {
+		s.heartbeat.beat(heartbeatPing{ok: true, message: fmt.Sprintf("synced %d collectors, %d workloads", len(synced), workloads)})
+		return
+	}
+
+	failures := make([]string, 0, len(syncErrors))
+	for _, err := range syncErrors {
+		failures = append(failures, err.Error())
+	}
+	sort.Strings(failures)
+	s.heartbeat.beat(heartbeatPing{message: fmt.Sprintf("failed to sync %d collectors\n%s", len(syncErrors), strings.Join(failures, "\n"))})
+}
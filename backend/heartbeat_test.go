@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestHeartbeat tests pinging the success and failure URLs after syncs, and
+// that unsent pings are replaced rather than queued
+func TestHeartbeat(t *testing.T) {
+	var mu sync.Mutex
+	var pings []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		pings = append(pings, r.URL.Path+" "+string(body))
+		mu.Unlock()
+		if r.URL.Path == "/down" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	t.Setenv("HEARTBEAT_URL", srv.URL+"/ping/check")
+	t.Setenv("HEARTBEAT_FAIL_URL", srv.URL+"/ping/check/fail")
+	beat, err := newHeartbeatFromEnv(newMetrics())
+	if err != nil {
+		t.Fatalf("Failed to configure heartbeat: %v", err)
+	}
+	server := &Server{heartbeat: beat}
+
+	// Only the latest outcome is sent
+	server.syncHeartbeat(3, map[string]bool{"prod": true}, map[string]error{"dr": errors.New("connection refused")})
+	server.syncHeartbeat(5, map[string]bool{"prod": true, "dr": true}, nil)
+	close(beat.wake)
+	beat.run()
+	if len(pings) != 1 || pings[0] != "/ping/check synced 2 collectors, 5 workloads" {
+		t.Errorf("Expected one success ping, got %q", pings)
+	}
+
+	beat.wake = make(chan struct{}, 1)
+	server.syncHeartbeat(0, nil, map[string]error{"prod": errors.New("connection refused")})
+	close(beat.wake)
+	beat.run()
+	if len(pings) != 2 || !strings.HasPrefix(pings[1], "/ping/check/fail failed to sync 1 collectors\nconnection refused") {
+		t.Errorf("Expected a failure ping, got %q", pings)
+	}
+
+	// A ping the monitor rejects is counted
+	beat.url = srv.URL + "/down"
+	beat.wake = make(chan struct{}, 1)
+	server.syncHeartbeat(1, map[string]bool{"prod": true}, nil)
+	close(beat.wake)
+	beat.run()
+	if failures := beat.metrics.Value("dashboard_heartbeat_failures_total"); failures != 1 {
+		t.Errorf("Expected 1 failed heartbeat, got %v", failures)
+	}
+}
+
+// TestHeartbeatConfig tests that the heartbeat is optional and validated
+func TestHeartbeatConfig(t *testing.T) {
+	if beat, err := newHeartbeatFromEnv(nil); beat != nil || err != nil {
+		t.Errorf("Expected no heartbeat without HEARTBEAT_URL, got %v, %v", beat, err)
+	}
+	t.Setenv("HEARTBEAT_FAIL_URL", "https://hc-ping.com/uuid/fail")
+	if _, err := newHeartbeatFromEnv(nil); err == nil {
+		t.Errorf("Expected HEARTBEAT_FAIL_URL alone to be rejected")
+	}
+	t.Setenv("HEARTBEAT_URL", "hc-ping.com/uuid")
+	if _, err := newHeartbeatFromEnv(nil); err == nil {
+		t.Errorf("Expected a URL without scheme to be rejected")
+	}
+}
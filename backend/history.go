@@ -0,0 +1,293 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+const historyBucket = "history"
+
+// History keeps the ordered list of workload transitions, optionally
+// persisted to a Store so it survives restarts
+type History struct {
+	mu        sync.RWMutex
+	events    []HistoryEvent
+	store     *Store
+	retention time.Duration
+}
+
+// newHistory creates a history, loading and compacting any persisted events
+func newHistory(store *Store, retention time.Duration) (*History, error) {
+	h := &History{store: store, retention: retention}
+
+	err := store.Load(historyBucket, func(raw json.RawMessage) error {
+		var event HistoryEvent
+		if err := json.Unmarshal(raw, &event); err != nil {
+			return err
+		}
+		h.events = append(h.events, event)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(h.events, func(i, j int) bool { return h.events[i].Time.Before(h.events[j].Time) })
+	if h.prune(time.Now()) {
+		records := make([]interface{}, len(h.events))
+		for i := range h.events {
+			records[i] = h.events[i]
+		}
+		if err := store.Rewrite(historyBucket, records); err != nil {
+			return nil, err
+		}
+	}
+
+	return h, nil
+}
+
+// prune drops events older than the retention period. Caller must hold mu.
+func (h *History) prune(now time.Time) bool {
+	if h.retention <= 0 {
+		return false
+	}
+	cutoff := now.Add(-h.retention)
+	i := sort.Search(len(h.events), func(i int) bool { return !h.events[i].Time.Before(cutoff) })
+	if i == 0 {
+		return false
+	}
+	h.events = append([]HistoryEvent(nil), h.events[i:]...)
+	return true
+}
+
+// Record appends events to the history and the backing store. The store is
+// appended to under mu, so an import or restore rewriting it in between
+// can't lose or duplicate the events.
+func (h *History) Record(events []HistoryEvent) {
+	if h == nil || len(events) == 0 {
+		return
+	}
+
+	records := make([]interface{}, len(events))
+	for i := range events {
+		records[i] = events[i]
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.events = append(h.events, events...)
+	h.prune(time.Now())
+	if err := h.store.Append(historyBucket, records...); err != nil {
+		log.Printf("Failed to persist history: %v", err)
+	}
+}
+
+// Import merges events from elsewhere, e.g. replayed from archived reports,
+// into the history in time order, and rewrites the backing store
+func (h *History) Import(events []HistoryEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.events = append(h.events, events...)
+	sort.SliceStable(h.events, func(i, j int) bool { return h.events[i].Time.Before(h.events[j].Time) })
+	h.prune(time.Now())
+	records := make([]interface{}, len(h.events))
+	for i := range h.events {
+		records[i] = h.events[i]
+	}
+	return h.store.Rewrite(historyBucket, records)
+}
+
+// FirstEvents returns the time of each workload's earliest event
+func (h *History) FirstEvents() map[string]time.Time {
+	first := make(map[string]time.Time)
+	if h == nil {
+		return first
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for _, event := range h.events {
+		if _, ok := first[event.Key]; !ok {
+			first[event.Key] = event.Time
+		}
+	}
+	return first
+}
+
+// Events returns a copy of all events between from and to (inclusive)
+func (h *History) Events(from, to time.Time) []HistoryEvent {
+	if h == nil {
+		return nil
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var events []HistoryEvent
+	for _, event := range h.events {
+		if event.Time.Before(from) || event.Time.After(to) {
+			continue
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+// StatusAt reconstructs the cached workloads as they were at instant t
+func (h *History) StatusAt(t time.Time) []WorkloadStatus {
+	if h == nil {
+		return []WorkloadStatus{}
+	}
+
+	h.mu.RLock()
+	latest := make(map[string]HistoryEvent)
+	for _, event := range h.events {
+		if event.Time.After(t) {
+			break
+		}
+		latest[event.Key] = event
+	}
+	h.mu.RUnlock()
+
+	workloads := make([]WorkloadStatus, 0, len(latest))
+	for _, event := range latest {
+		if event.Type == "removed" || event.Status == nil {
+			continue
+		}
+		workloads = append(workloads, *event.Status)
+	}
+	sortWorkloads(workloads)
+	return workloads
+}
+
+// diffCaches returns the events that turn the old cache into the new one
+func diffCaches(old, updated map[string]*WorkloadStatus, now time.Time) []HistoryEvent {
+	var events []HistoryEvent
+
+	for key, status := range updated {
+		prev, existed := old[key]
+		switch {
+		case !existed:
+			events = append(events, HistoryEvent{Time: now, Key: key, Type: "added", Status: copyStatus(status)})
+		case status.Lifecycle == lifecycleTerminating && prev.Lifecycle != lifecycleTerminating:
+			events = append(events, HistoryEvent{
+				Time:           now,
+				Key:            key,
+				Type:           "terminating",
+				PreviousStatus: prev.AttestationStatus,
+				Status:         copyStatus(status),
+			})
+		case statusChanged(prev, status):
+			events = append(events, HistoryEvent{
+				Time:           now,
+				Key:            key,
+				Type:           "changed",
+				PreviousStatus: prev.AttestationStatus,
+				Status:         copyStatus(status),
+			})
+		}
+	}
+
+	for key, prev := range old {
+		if _, ok := updated[key]; !ok {
+			removed := copyStatus(prev)
+			removed.Lifecycle = lifecycleRemoved
+			if removed.LastSeen == nil {
+				lastSeen := prev.LastChecked
+				removed.LastSeen = &lastSeen
+			}
+			events = append(events, HistoryEvent{
+				Time:           now,
+				Key:            key,
+				Type:           "removed",
+				PreviousStatus: prev.AttestationStatus,
+				Status:         removed,
+			})
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool { return events[i].Key < events[j].Key })
+	return events
+}
+
+// statusChanged reports whether two snapshots of a workload differ in a way
+// worth recording (LastChecked and Timestamp move every poll and are ignored)
+func statusChanged(a, b *WorkloadStatus) bool {
+	return a.Attested != b.Attested ||
+		a.AttestationStatus != b.AttestationStatus ||
+		a.GateOneStatus != b.GateOneStatus ||
+		a.GateTwoStatus != b.GateTwoStatus ||
+		a.Details != b.Details ||
+		a.Maintenance != b.Maintenance ||
+		a.Flapping != b.Flapping ||
+		a.Lifecycle != b.Lifecycle ||
+		a.RestartCount != b.RestartCount ||
+		a.HostStatus != b.HostStatus ||
+		!reflect.DeepEqual(a.TrustVector, b.TrustVector) ||
+		!reflect.DeepEqual(a.Gates, b.Gates) ||
+		!reflect.DeepEqual(a.FailedChecks, b.FailedChecks)
+}
+
+func copyStatus(status *WorkloadStatus) *WorkloadStatus {
+	c := *status
+	return &c
+}
+
+// handleStatusAt returns the fleet status as of a past instant
+// GET /api/status/at?time=2024-05-01T10:00:00Z
+func (s *Server) handleStatusAt(w http.ResponseWriter, r *http.Request) {
+	raw := r.URL.Query().Get("time")
+	if raw == "" {
+		http.Error(w, "time parameter required (RFC3339)", http.StatusBadRequest)
+		return
+	}
+
+	at, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		http.Error(w, "invalid time parameter, expected RFC3339", http.StatusBadRequest)
+		return
+	}
+	if at.After(time.Now()) {
+		http.Error(w, "time must not be in the future", http.StatusBadRequest)
+		return
+	}
+
+	workloads := s.history.StatusAt(at)
+	filtered := workloads[:0]
+	for i := range workloads {
+		if matchesCluster(r, &workloads[i]) {
+			filtered = append(filtered, workloads[i])
+		}
+	}
+
+	response := DashboardResponse{
+		OverallStatus: s.overallStatus(filtered),
+		Workloads:     filtered,
+		LastUpdated:   at,
+	}
+
+	noteWorkloads(r, filtered)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// sortWorkloads orders workloads by namespace and name for stable output
+func sortWorkloads(workloads []WorkloadStatus) {
+	sort.Slice(workloads, func(i, j int) bool {
+		if workloads[i].Namespace != workloads[j].Namespace {
+			return workloads[i].Namespace < workloads[j].Namespace
+		}
+		return workloads[i].Name < workloads[j].Name
+	})
+}
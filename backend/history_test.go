@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestDiffCaches tests detection of added, changed and removed workloads
+func TestDiffCaches(t *testing.T) {
+	old := map[string]*WorkloadStatus{
+		"ns/stable":  {Name: "stable", Attested: true, AttestationStatus: "verified"},
+		"ns/flipped": {Name: "flipped", Attested: true, AttestationStatus: "verified"},
+		"ns/gone":    {Name: "gone", Attested: true, AttestationStatus: "verified"},
+	}
+	updated := map[string]*WorkloadStatus{
+		"ns/stable":  {Name: "stable", Attested: true, AttestationStatus: "verified", LastChecked: time.Now()},
+		"ns/flipped": {Name: "flipped", Attested: false, AttestationStatus: "failed"},
+		"ns/new":     {Name: "new", Attested: true, AttestationStatus: "verified"},
+	}
+
+	events := diffCaches(old, updated, time.Now())
+
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 events, got %d: %+v", len(events), events)
+	}
+
+	expected := map[string]string{"ns/flipped": "changed", "ns/gone": "removed", "ns/new": "added"}
+	for _, event := range events {
+		if event.Type != expected[event.Key] {
+			t.Errorf("Expected %s to be '%s', got '%s'", event.Key, expected[event.Key], event.Type)
+		}
+	}
+
+	if events[0].Key != "ns/flipped" || events[0].PreviousStatus != "verified" {
+		t.Errorf("Expected previous status on change event, got %+v", events[0])
+	}
+}
+
+// TestHistoryStatusAt tests point-in-time reconstruction, including persistence
+func TestHistoryStatusAt(t *testing.T) {
+	store, _ := openStore(t.TempDir())
+	history, err := newHistory(store, 0)
+	if err != nil {
+		t.Fatalf("Failed to create history: %v", err)
+	}
+
+	t0 := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	history.Record([]HistoryEvent{
+		{Time: t0, Key: "ns/a", Type: "added", Status: &WorkloadStatus{Name: "a", Namespace: "ns", Attested: true, AttestationStatus: "verified"}},
+		{Time: t0, Key: "ns/b", Type: "added", Status: &WorkloadStatus{Name: "b", Namespace: "ns", Attested: true, AttestationStatus: "verified"}},
+	})
+	history.Record([]HistoryEvent{
+		{Time: t0.Add(time.Hour), Key: "ns/a", Type: "changed", Status: &WorkloadStatus{Name: "a", Namespace: "ns", Attested: false, AttestationStatus: "failed"}},
+		{Time: t0.Add(time.Hour), Key: "ns/b", Type: "removed", Status: &WorkloadStatus{Name: "b", Namespace: "ns"}},
+	})
+
+	before := history.StatusAt(t0.Add(30 * time.Minute))
+	if len(before) != 2 || !before[0].Attested {
+		t.Errorf("Expected two attested workloads before the incident, got %+v", before)
+	}
+
+	// Reload from the store to check persistence
+	reloaded, err := newHistory(store, 0)
+	if err != nil {
+		t.Fatalf("Failed to reload history: %v", err)
+	}
+
+	after := reloaded.StatusAt(t0.Add(2 * time.Hour))
+	if len(after) != 1 || after[0].Name != "a" || after[0].Attested {
+		t.Errorf("Expected only failed workload a after the incident, got %+v", after)
+	}
+
+	if len(reloaded.StatusAt(t0.Add(-time.Minute))) != 0 {
+		t.Error("Expected no workloads before the first event")
+	}
+}
+
+// TestHistoryRetention tests that old events are pruned
+func TestHistoryRetention(t *testing.T) {
+	history, _ := newHistory(nil, time.Hour)
+	history.Record([]HistoryEvent{
+		{Time: time.Now().Add(-2 * time.Hour), Key: "ns/old", Type: "added"},
+		{Time: time.Now(), Key: "ns/new", Type: "added"},
+	})
+
+	events := history.Events(time.Time{}, time.Now())
+	if len(events) != 1 || events[0].Key != "ns/new" {
+		t.Errorf("Expected only the recent event, got %+v", events)
+	}
+}
+
+// TestHandleStatusAt tests the /api/status/at endpoint
+func TestHandleStatusAt(t *testing.T) {
+	history, _ := newHistory(nil, 0)
+	t0 := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	history.Record([]HistoryEvent{
+		{Time: t0, Key: "ns/a", Type: "added", Status: &WorkloadStatus{Name: "a", Namespace: "ns", Attested: false, GateTwoStatus: "failed"}},
+	})
+	server := &Server{history: history}
+
+	req := httptest.NewRequest("GET", "/api/status/at?time=2024-05-01T10:00:00Z", nil)
+	w := httptest.NewRecorder()
+	server.handleStatusAt(w, req)
+
+	var response DashboardResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.OverallStatus != "violation" || len(response.Workloads) != 1 {
+		t.Errorf("Expected a violation with one workload, got %+v", response)
+	}
+
+	for _, query := range []string{"", "?time=yesterday", "?time=2999-01-01T00:00:00Z"} {
+		req := httptest.NewRequest("GET", "/api/status/at"+query, nil)
+		w := httptest.NewRecorder()
+		server.handleStatusAt(w, req)
+		if w.Code != 400 {
+			t.Errorf("Expected 400 for %q, got %d", query, w.Code)
+		}
+	}
+}
+
+// TestHistoryRecordPersistsUnderLock tests that Record appends to the store
+// under the history lock, so an import or restore rewriting the store can't
+// slip in between and lose or duplicate the events
+func TestHistoryRecordPersistsUnderLock(t *testing.T) {
+	store, err := openStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	history, _ := newHistory(store, 0)
+	now := time.Now()
+
+	// Hold up the store append
+	store.mu.Lock()
+	done := make(chan struct{})
+	go func() {
+		history.Record([]HistoryEvent{{Time: now, Key: "icu/pacs", Type: "changed"}})
+		close(done)
+	}()
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(time.Millisecond) {
+		if !history.mu.TryRLock() {
+			break
+		}
+		recorded := len(history.events)
+		history.mu.RUnlock()
+		if recorded > 0 {
+			t.Fatal("Expected the history lock held until the events are persisted")
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Record never took the history lock")
+		}
+	}
+	imported := make(chan error)
+	go func() {
+		imported <- history.Import([]HistoryEvent{{Time: now.Add(-time.Hour), Key: "icu/monitor", Type: "added", Imported: true}})
+	}()
+	store.mu.Unlock()
+	<-done
+	if err := <-imported; err != nil {
+		t.Fatalf("Failed to import: %v", err)
+	}
+
+	reloaded, _ := newHistory(store, 0)
+	if events := reloaded.Events(time.Time{}, now); len(events) != 2 || events[0].Key != "icu/monitor" || events[1].Key != "icu/pacs" {
+		t.Errorf("Expected the store to match the history, got %+v", events)
+	}
+}
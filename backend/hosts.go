@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// fetchNodeReports fetches the host attestation reports of a single cluster
+func (s *Server) fetchNodeReports(ctx context.Context, cluster ClusterConfig) ([]NodeReport, error) {
+	url := fmt.Sprintf("%s/api/v1/node-reports", cluster.CollectorURL)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := cluster.authorize(req); err != nil {
+		return nil, err
+	}
+
+	client := s.httpClient
+	if cluster.httpClient != nil {
+		client = cluster.httpClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("collector returned status %d", resp.StatusCode)
+	}
+
+	raws, err := s.collectorLimits.decodeArray(resp.Body)
+	if err != nil {
+		s.observeCollectorLimit(cluster.Name, err)
+		return nil, fmt.Errorf("failed to decode node reports: %w", err)
+	}
+	reports := make([]NodeReport, len(raws))
+	for i, raw := range raws {
+		if err := json.Unmarshal(raw, &reports[i]); err != nil {
+			return nil, fmt.Errorf("failed to decode node report %d: %w", i, err)
+		}
+		if reports[i].Cluster == "" {
+			reports[i].Cluster = cluster.Name
+		}
+	}
+	return reports, nil
+}
+
+// updateNodeReports replaces the host reports of the given clusters, keeping
+// the last known reports of the others, and returns a snapshot of all of them
+func (s *Server) updateNodeReports(byCluster map[string][]NodeReport) map[string]NodeReport {
+	s.cacheMutex.Lock()
+	defer s.cacheMutex.Unlock()
+
+	if s.nodeReports == nil {
+		s.nodeReports = make(map[string]NodeReport)
+	}
+	for key, report := range s.nodeReports {
+		if _, ok := byCluster[report.Cluster]; ok {
+			delete(s.nodeReports, key)
+		}
+	}
+	for _, reports := range byCluster {
+		for _, report := range reports {
+			s.nodeReports[report.Cluster+"/"+report.NodeName] = report
+		}
+	}
+
+	snapshot := make(map[string]NodeReport, len(s.nodeReports))
+	for key, report := range s.nodeReports {
+		snapshot[key] = report
+	}
+	return snapshot
+}
+
+// correlateHosts records each workload's host attestation state and points
+// failed workloads at a failed host, the likelier root cause
+func correlateHosts(statuses []*WorkloadStatus, hosts map[string]NodeReport) {
+	for _, status := range statuses {
+		host, ok := hosts[status.Cluster+"/"+status.NodeName]
+		if status.NodeName == "" || !ok {
+			continue
+		}
+
+		status.HostStatus = verdictString(host.Attested)
+		if !host.Attested && !status.Attested {
+			reason := host.Error
+			if reason == "" {
+				reason = "platform attestation failed"
+			}
+			status.Details = fmt.Sprintf("%s (host %s: %s)", status.Details, host.NodeName, reason)
+			failCheck(status, "host_attestation", "verified", host.NodeName+": "+reason, severityHigh)
+		}
+	}
+}
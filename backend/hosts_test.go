@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestNodeAttestation tests ingestion of host reports and their correlation
+// with workload failures
+func TestNodeAttestation(t *testing.T) {
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/reports":
+			json.NewEncoder(w).Encode([]CollectorReport{
+				{PodName: "pod-a", Namespace: "icu", NodeName: "worker-1", Attested: false, Timestamp: time.Now()},
+				{PodName: "pod-b", Namespace: "icu", NodeName: "worker-2", Attested: true, Timestamp: time.Now()},
+			})
+		case "/api/v1/node-reports":
+			json.NewEncoder(w).Encode([]NodeReport{
+				{NodeName: "worker-1", TEEType: "tdx", Attested: false, Error: "TCB out of date", Timestamp: time.Now()},
+				{NodeName: "worker-2", TEEType: "tdx", Attested: true, Timestamp: time.Now()},
+				{NodeName: "worker-3", TEEType: "snp", Attested: true, Timestamp: time.Now()},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer collector.Close()
+
+	server := &Server{
+		collectorURL:    collector.URL,
+		nodeAttestation: true,
+		statusCache:     make(map[string]*WorkloadStatus),
+		httpClient:      &http.Client{Timeout: 10 * time.Second},
+	}
+	server.fetchFromCollector()
+
+	podA := server.statusCache["icu/pod-a"]
+	if podA.HostStatus != "failed" {
+		t.Errorf("Expected host status 'failed', got '%s'", podA.HostStatus)
+	}
+	if !strings.Contains(podA.Details, "TCB out of date") {
+		t.Errorf("Expected host failure in details, got '%s'", podA.Details)
+	}
+	if server.statusCache["icu/pod-b"].HostStatus != "verified" {
+		t.Errorf("Expected host status 'verified', got '%s'", server.statusCache["icu/pod-b"].HostStatus)
+	}
+
+	req := httptest.NewRequest("GET", "/api/nodes", nil)
+	w := httptest.NewRecorder()
+	server.handleNodes(w, req)
+
+	var nodes []NodeSummary
+	if err := json.NewDecoder(w.Body).Decode(&nodes); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(nodes) != 3 {
+		t.Fatalf("Expected 3 nodes including the one without workloads, got %d", len(nodes))
+	}
+	if nodes[0].HostAttestation == nil || nodes[0].Status != "failing" {
+		t.Errorf("Expected worker-1 failing on host attestation, got %+v", nodes[0])
+	}
+	if nodes[2].Name != "worker-3" || nodes[2].Workloads != 0 || nodes[2].Status != "healthy" {
+		t.Errorf("Unexpected worker-3 summary: %+v", nodes[2])
+	}
+}
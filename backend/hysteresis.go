@@ -0,0 +1,127 @@
+package main
+
+import "net/http"
+
+// statusDebouncer delays overall_status flips until the new state has held
+// for a number of consecutive poll cycles, so that a single-poll blip doesn't
+// flash the wallboard red. State is kept per scope: one for the whole fleet
+// and one per cluster for ?cluster= views (see statusScope).
+type statusDebouncer struct {
+	violationCycles int // cycles a violation must persist before it is shown
+	recoveryCycles  int // clean cycles required before going back to compliant
+	scopes          map[string]*debounceState
+}
+
+type debounceState struct {
+	shown  string // status currently reported
+	streak int    // consecutive cycles the raw status has differed from shown
+}
+
+// newStatusDebouncer returns a debouncer, or nil if both thresholds are 1
+// (i.e. every flip is shown immediately)
+func newStatusDebouncer(violationCycles, recoveryCycles int) *statusDebouncer {
+	if violationCycles <= 1 && recoveryCycles <= 1 {
+		return nil
+	}
+	return &statusDebouncer{
+		violationCycles: violationCycles,
+		recoveryCycles:  recoveryCycles,
+		scopes:          make(map[string]*debounceState),
+	}
+}
+
+// observe feeds one poll cycle's raw status for a scope
+func (d *statusDebouncer) observe(scope, raw string) {
+	if d == nil {
+		return
+	}
+
+	state, ok := d.scopes[scope]
+	if !ok {
+		// Nothing to debounce against yet - show the first observation as is
+		d.scopes[scope] = &debounceState{shown: raw}
+		return
+	}
+
+	if raw == state.shown {
+		state.streak = 0
+		return
+	}
+
+	state.streak++
+	required := d.recoveryCycles
+	if statusSeverity(raw) > statusSeverity(state.shown) {
+		required = d.violationCycles
+	}
+	if state.streak >= required {
+		state.shown = raw
+		state.streak = 0
+	}
+}
+
+// status returns the debounced status of a scope, or raw if the scope has
+// not been observed yet
+func (d *statusDebouncer) status(scope, raw string) string {
+	if d == nil {
+		return raw
+	}
+	if state, ok := d.scopes[scope]; ok {
+		return state.shown
+	}
+	return raw
+}
+
+// observeOverallStatus feeds the current cache into the debouncer, decorated
+// as the handlers decorate it: a pinned workload is critical. Caller must
+// hold cacheMutex.
+func (s *Server) observeOverallStatus() {
+	if s.debounce == nil {
+		return
+	}
+
+	all := make([]WorkloadStatus, 0, len(s.statusCache))
+	byCluster := make(map[string][]WorkloadStatus)
+	for _, status := range s.statusCache {
+		workload := s.decorate(*status)
+		all = append(all, workload)
+		byCluster[status.Cluster] = append(byCluster[status.Cluster], workload)
+	}
+
+	s.debounce.observe(statusScope(""), s.overallStatus(all))
+	for cluster, workloads := range byCluster {
+		if cluster != "" {
+			s.debounce.observe(statusScope(cluster), s.overallStatus(workloads))
+		}
+	}
+}
+
+// statusSeverity orders overall statuses, so that any worsening - compliant
+// to warning as well as to violation - waits for the violation cycles
+func statusSeverity(status string) int {
+	switch status {
+	case "violation":
+		return 2
+	case "warning":
+		return 1
+	}
+	return 0
+}
+
+// requestStatus returns the overall status of the workloads a request's view
+// shows: debounced for the fleet and cluster views, raw for views filtered
+// by ?label=, whose workloads the debouncer doesn't track
+func (s *Server) requestStatus(r *http.Request, workloads []WorkloadStatus) string {
+	raw := s.overallStatus(workloads)
+	if len(r.URL.Query()["label"]) > 0 {
+		return raw
+	}
+	return s.debounce.status(statusScope(r.URL.Query().Get("cluster")), raw)
+}
+
+// statusScope returns the debounce scope of a ?cluster= filter value
+func statusScope(cluster string) string {
+	if cluster == "" {
+		return "fleet"
+	}
+	return "cluster:" + cluster
+}
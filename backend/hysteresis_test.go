@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestStatusDebouncer tests that status flips only after the configured
+// number of consecutive cycles
+func TestStatusDebouncer(t *testing.T) {
+	d := newStatusDebouncer(3, 2)
+
+	steps := []struct {
+		raw      string
+		expected string
+	}{
+		{"compliant", "compliant"},
+		{"violation", "compliant"},
+		{"compliant", "compliant"}, // blip - streak resets
+		{"violation", "compliant"},
+		{"violation", "compliant"},
+		{"violation", "violation"},
+		{"compliant", "violation"},
+		{"compliant", "compliant"},
+	}
+
+	for i, step := range steps {
+		d.observe("", step.raw)
+		if got := d.status("", step.raw); got != step.expected {
+			t.Errorf("Step %d: expected '%s', got '%s'", i, step.expected, got)
+		}
+	}
+
+	if newStatusDebouncer(1, 1) != nil {
+		t.Error("Expected no debouncer when both thresholds are 1")
+	}
+}
+
+// TestHandleStatusDebounced tests that /api/status reports the debounced status
+func TestHandleStatusDebounced(t *testing.T) {
+	server := &Server{
+		statusCache: map[string]*WorkloadStatus{
+			"icu/a": {Name: "a", Namespace: "icu", Attested: true},
+		},
+		debounce: newStatusDebouncer(2, 1),
+	}
+	server.observeOverallStatus()
+
+	server.statusCache["icu/a"].Attested = false
+	server.observeOverallStatus()
+
+	req := httptest.NewRequest("GET", "/api/status", nil)
+	w := httptest.NewRecorder()
+	server.handleStatus(w, req)
+
+	var response DashboardResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.OverallStatus != "compliant" {
+		t.Errorf("Expected single-cycle violation to be debounced, got '%s'", response.OverallStatus)
+	}
+	if len(response.Workloads) != 1 || response.Workloads[0].Attested {
+		t.Error("Expected the workload itself to show its real state")
+	}
+
+	server.observeOverallStatus()
+	w = httptest.NewRecorder()
+	server.handleStatus(w, req)
+	json.NewDecoder(w.Body).Decode(&response)
+	if response.OverallStatus != "violation" {
+		t.Errorf("Expected violation after 2 cycles, got '%s'", response.OverallStatus)
+	}
+}
+
+// TestStatusDebouncerWarning tests that degrading to warning waits for the
+// violation cycles and a violation after a warning does too
+func TestStatusDebouncerWarning(t *testing.T) {
+	d := newStatusDebouncer(2, 1)
+	d.observe("fleet", "compliant")
+	d.observe("fleet", "warning")
+	if got := d.status("fleet", ""); got != "compliant" {
+		t.Errorf("Expected warning to be debounced, got %s", got)
+	}
+	d.observe("fleet", "warning")
+	d.observe("fleet", "violation")
+	if got := d.status("fleet", ""); got != "warning" {
+		t.Errorf("Expected violation to be debounced, got %s", got)
+	}
+	d.observe("fleet", "compliant")
+	if got := d.status("fleet", ""); got != "compliant" {
+		t.Errorf("Expected recovery after 1 cycle, got %s", got)
+	}
+}
+
+// TestHandleStatusLabelFilterNotDebounced tests that a view filtered by label
+// shows the raw status of its own workloads, not the fleet's debounced one
+func TestHandleStatusLabelFilterNotDebounced(t *testing.T) {
+	annotations, _ := newAnnotationStore(nil)
+	annotations.Set("icu/b", &Annotations{Labels: map[string]string{"team": "imaging"}})
+	server := &Server{
+		annotations: annotations,
+		statusCache: map[string]*WorkloadStatus{
+			"icu/a": verifiedStatus("icu", "a"),
+			"icu/b": verifiedStatus("icu", "b"),
+		},
+		debounce: newStatusDebouncer(2, 1),
+	}
+	server.observeOverallStatus()
+	server.statusCache["icu/b"] = failedStatus("icu", "b")
+	server.observeOverallStatus()
+
+	status := func(target string) string {
+		w := httptest.NewRecorder()
+		server.handleStatus(w, httptest.NewRequest("GET", target, nil))
+		var response DashboardResponse
+		json.NewDecoder(w.Body).Decode(&response)
+		return response.OverallStatus
+	}
+	if got := status("/api/status"); got != "compliant" {
+		t.Errorf("Expected the fleet's violation debounced, got %s", got)
+	}
+	if got := status("/api/status?label=team=imaging"); got != "violation" {
+		t.Errorf("Expected the filtered view's raw status, got %s", got)
+	}
+}
+
+// TestObserveOverallStatusDecorated tests that the debouncer sees workloads
+// decorated as the handlers show them: a pinned workload is critical
+func TestObserveOverallStatusDecorated(t *testing.T) {
+	watchlist, _ := newWatchlist(nil)
+	watchlist.Set(WatchedWorkload{Key: "radiology/pacs", Namespace: "radiology", Name: "pacs"})
+	server := &Server{
+		watchlist:   watchlist,
+		rollup:      newRollupPolicy(1, "", 1),
+		statusCache: map[string]*WorkloadStatus{"radiology/pacs": failedStatus("radiology", "pacs")},
+		debounce:    newStatusDebouncer(2, 1),
+	}
+	server.observeOverallStatus()
+
+	if got := server.debounce.status(statusScope(""), ""); got != "violation" {
+		t.Errorf("Expected the pinned violation to count as critical, got %s", got)
+	}
+}
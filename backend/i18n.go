@@ -0,0 +1,165 @@
+package main
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// defaultLanguage is served when Accept-Language names no supported language
+const defaultLanguage = "en"
+
+// messageCatalog translates the status strings the backend generates into
+// one language. Details are composed from several fixed phrases around
+// values such as TEE types and Collector errors, so the catalog translates
+// phrase by phrase; text it has no phrase for (e.g. a Collector's own error
+// message) is left as is.
+type messageCatalog struct {
+	statuses map[string]string // AttestationStatus -> display label
+	phrases  []phrase          // applied to details in order
+}
+
+// phrase replaces matches of pattern; replacement may refer to its groups
+type phrase struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// phrases compiles pattern/replacement pairs
+func phrases(pairs ...string) []phrase {
+	list := make([]phrase, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		list = append(list, phrase{regexp.MustCompile(pairs[i]), pairs[i+1]})
+	}
+	return list
+}
+
+// catalogs are the supported languages, keyed by primary language subtag
+var catalogs = map[string]*messageCatalog{
+	"en": {
+		statuses: map[string]string{
+			"verified":            "Verified",
+			"failed":              "Failed",
+			clockSkewStatus:       "Clock skew",
+			noReportStatus:        "No report",
+			unknownStatus:         "Unknown",
+			predatesRestartStatus: "Attestation predates restart",
+			verifierSplitStatus:   "Verifier split",
+		},
+	},
+	"es": {
+		statuses: map[string]string{
+			"verified":            "Verificado",
+			"failed":              "Fallido",
+			clockSkewStatus:       "Desfase de reloj",
+			noReportStatus:        "Sin informe",
+			unknownStatus:         "Desconocido",
+			predatesRestartStatus: "Atestación anterior al reinicio",
+			verifierSplitStatus:   "Verificadores en desacuerdo",
+		},
+		phrases: phrases(
+			`TEE attestation failed - not running in genuine confidential environment`,
+			`Atestación TEE fallida: no se ejecuta en un entorno confidencial genuino`,
+			`Container signature verified, TEE attestation passed`,
+			`Firma del contenedor verificada, atestación TEE superada`,
+			`TEE attestation successful`, `Atestación TEE correcta`,
+			`, Config: `, `, Configuración: `,
+			`, Executables: `, `, Ejecutables: `,
+			`(Hardware|Configuración|Ejecutables): None\b`, `$1: Ninguno`,
+			`(Hardware|Configuración|Ejecutables): Affirming\b`, `$1: Afirmativo`,
+			`(Hardware|Configuración|Ejecutables): Warning\b`, `$1: Advertencia`,
+			`(Hardware|Configuración|Ejecutables): Contraindicated\b`, `$1: Contraindicado`,
+			`Clock skew: `, `Desfase de reloj: `,
+			`Expected workload not reported by any Collector \(registered by `,
+			`Carga de trabajo esperada sin informe de ningún Collector (registrada por `,
+			`Image digest not allowlisted: `, `Digest de imagen no permitido: `,
+			`Image allowlist check failed: `, `Falló la comprobación de imágenes permitidas: `,
+			`Malformed evidence: `, `Evidencia mal formada: `,
+			`Stale report: `, `Informe obsoleto: `,
+			`Collector unreachable: `, `Collector inaccesible: `,
+			` - last known status `, ` - último estado conocido `,
+			`Attestation predates container restart at `, `Atestación anterior al reinicio del contenedor en `,
+			`Verifier split - primary: (\S+), secondary: (\S+)\.`, `Verificadores en desacuerdo - primario: $1, secundario: $2.`,
+			`Conflicting reports: `, `Informes en conflicto: `,
+			`\(host ([^:()]+): platform attestation failed\)`, `(nodo $1: atestación de plataforma fallida)`,
+			`\(host ([^:()]+): `, `(nodo $1: `,
+			// Gate details
+			`check unreachable: `, `comprobación inaccesible: `,
+			`expected status (\d+), got (\d+)`, `se esperaba el estado $1, se obtuvo $2`,
+			`response does not contain `, `la respuesta no contiene `,
+			`plugin failed: `, `el plugin falló: `,
+		),
+	},
+}
+
+// negotiateLanguage picks the supported language the client prefers most
+// from an Accept-Language header, e.g. "es-MX,es;q=0.9,en;q=0.8"
+func negotiateLanguage(header string) string {
+	type candidate struct {
+		lang string
+		q    float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		primary, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if _, ok := catalogs[primary]; ok && q > 0 {
+			candidates = append(candidates, candidate{primary, q})
+		}
+	}
+	if len(candidates) == 0 {
+		return defaultLanguage
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	return candidates[0].lang
+}
+
+// translate applies the catalog's phrases to a message
+func (c *messageCatalog) translate(message string) string {
+	for _, p := range c.phrases {
+		message = p.pattern.ReplaceAllString(message, p.replacement)
+	}
+	return message
+}
+
+// localize sets the status label and translates the details of a decorated
+// copy of a status. Gates are copied, as they share the cached slice.
+func (c *messageCatalog) localize(status *WorkloadStatus) {
+	status.StatusLabel = c.statuses[status.AttestationStatus]
+	if status.StatusLabel == "" {
+		status.StatusLabel = status.AttestationStatus
+	}
+	if len(c.phrases) == 0 {
+		return
+	}
+	status.Details = c.translate(status.Details)
+	if len(status.Gates) > 0 {
+		gates := make([]GateResult, len(status.Gates))
+		for i, gate := range status.Gates {
+			gate.Details = c.translate(gate.Details)
+			gates[i] = gate
+		}
+		status.Gates = gates
+	}
+}
+
+// localizeWorkloads translates the human-facing strings of workloads into
+// the language negotiated from the request's Accept-Language header
+func localizeWorkloads(w http.ResponseWriter, r *http.Request, workloads []WorkloadStatus) {
+	lang := negotiateLanguage(r.Header.Get("Accept-Language"))
+	w.Header().Set("Content-Language", lang)
+	w.Header().Add("Vary", "Accept-Language")
+	for i := range workloads {
+		catalogs[lang].localize(&workloads[i])
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestNegotiateLanguage tests Accept-Language negotiation
+func TestNegotiateLanguage(t *testing.T) {
+	tests := map[string]string{
+		"":                        "en",
+		"es":                      "es",
+		"es-MX,es;q=0.9,en;q=0.8": "es",
+		"fr-FR,en;q=0.5,es;q=0.7": "es",
+		"en-US,es;q=0.9":          "en",
+		"de":                      "en",
+		"es;q=0,en":               "en",
+		"es;q=abc":                "en",
+		"ES-es":                   "es",
+	}
+	for header, want := range tests {
+		if got := negotiateLanguage(header); got != want {
+			t.Errorf("Expected %s for %q, got %s", want, header, got)
+		}
+	}
+}
+
+// TestLocalize tests translating status labels and composed details
+func TestLocalize(t *testing.T) {
+	es := catalogs["es"]
+	tests := map[string]string{
+		"Clock skew: 6m0s - TEE attestation successful (snp) - Hardware: Affirming, Config: Warning, Executables: None":         "Desfase de reloj: 6m0s - Atestación TEE correcta (snp) - Hardware: Afirmativo, Configuración: Advertencia, Ejecutables: Ninguno",
+		"TEE attestation failed - not running in genuine confidential environment (host worker-1: platform attestation failed)": "Atestación TEE fallida: no se ejecuta en un entorno confidencial genuino (nodo worker-1: atestación de plataforma fallida)",
+		"Verifier split - primary: verified, secondary: failed. connection refused":                                             "Verificadores en desacuerdo - primario: verified, secundario: failed. connection refused",
+	}
+	for english, want := range tests {
+		if got := es.translate(english); got != want {
+			t.Errorf("Expected %q, got %q", want, got)
+		}
+	}
+
+	cached := []GateResult{{Name: "cmdb", Status: "error", Details: "check unreachable: timeout"}}
+	status := WorkloadStatus{AttestationStatus: clockSkewStatus, Details: "Clock skew: 6m0s - x", Gates: cached}
+	es.localize(&status)
+	if status.StatusLabel != "Desfase de reloj" || status.Gates[0].Details != "comprobación inaccesible: timeout" {
+		t.Errorf("Unexpected localized status %+v", status)
+	}
+	if cached[0].Details != "check unreachable: timeout" {
+		t.Error("Expected the cached gates to be left untouched")
+	}
+
+	status = WorkloadStatus{AttestationStatus: "custom", Details: "Clock skew: 1s"}
+	catalogs["en"].localize(&status)
+	if status.StatusLabel != "custom" || status.Details != "Clock skew: 1s" {
+		t.Errorf("Expected English to be unchanged, got %+v", status)
+	}
+}
+
+// TestHandleWorkloadsLocalized tests Accept-Language on the workloads endpoint
+func TestHandleWorkloadsLocalized(t *testing.T) {
+	server := &Server{statusCache: map[string]*WorkloadStatus{"icu/pacs": failedStatus("icu", "pacs")}}
+	req := httptest.NewRequest("GET", "/api/workloads", nil)
+	req.Header.Set("Accept-Language", "es-ES,es;q=0.9")
+	w := httptest.NewRecorder()
+	server.handleWorkloads(w, req)
+
+	if w.Header().Get("Content-Language") != "es" || w.Header().Get("Vary") != "Accept-Language" {
+		t.Errorf("Expected Content-Language es and Vary, got %v", w.Header())
+	}
+	var workloads []WorkloadStatus
+	json.NewDecoder(w.Body).Decode(&workloads)
+	if len(workloads) != 1 || workloads[0].StatusLabel != "Fallido" || workloads[0].AttestationStatus != "failed" {
+		t.Errorf("Expected a Spanish label next to the unchanged status, got %+v", workloads)
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// ImagePolicy restricts the image digests allowed to run in a namespace,
+// either as a static list or by asking an external allowlist service
+type ImagePolicy struct {
+	Namespace    string   `json:"namespace"`               // "*" applies to namespaces without their own policy
+	Digests      []string `json:"digests,omitempty"`       // e.g. "sha256:3b1f..."
+	AllowlistURL string   `json:"allowlist_url,omitempty"` // GET <url>?namespace=..&digest=..; 200 = allowed, 403/404 = not
+	Timeout      string   `json:"timeout,omitempty"`       // default 5s
+	FailOpen     bool     `json:"fail_open,omitempty"`     // treat an unreachable service as allowing the digest
+
+	client *http.Client
+}
+
+// loadImagePolicies reads and validates image policies from a JSON file
+func loadImagePolicies(path string) ([]ImagePolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var policies []ImagePolicy
+	if err := json.Unmarshal(data, &policies); err != nil {
+		return nil, fmt.Errorf("invalid image policy config: %w", err)
+	}
+	if err := initImagePolicies(policies); err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+// initImagePolicies validates image policies and sets up their HTTP clients
+func initImagePolicies(policies []ImagePolicy) error {
+	seen := make(map[string]bool)
+	for i := range policies {
+		p := &policies[i]
+		if p.Namespace == "" {
+			return fmt.Errorf("image policy %d: namespace is required", i)
+		}
+		if seen[p.Namespace] {
+			return fmt.Errorf("duplicate image policy for namespace %q", p.Namespace)
+		}
+		seen[p.Namespace] = true
+
+		if len(p.Digests) == 0 && p.AllowlistURL == "" {
+			return fmt.Errorf("image policy %s: digests or allowlist_url is required", p.Namespace)
+		}
+
+		timeout := 5 * time.Second
+		if p.Timeout != "" {
+			var err error
+			if timeout, err = time.ParseDuration(p.Timeout); err != nil {
+				return fmt.Errorf("image policy %s: invalid timeout: %w", p.Namespace, err)
+			}
+		}
+		p.client = &http.Client{Timeout: timeout}
+	}
+	return nil
+}
+
+// imagePolicyFor returns the policy for a namespace, or nil if none applies
+func (s *Server) imagePolicyFor(namespace string) *ImagePolicy {
+	var fallback *ImagePolicy
+	for i := range s.imagePolicies {
+		switch s.imagePolicies[i].Namespace {
+		case namespace:
+			return &s.imagePolicies[i]
+		case "*":
+			fallback = &s.imagePolicies[i]
+		}
+	}
+	return fallback
+}
+
+// allows reports whether digest may run in namespace under the policy
+func (p *ImagePolicy) allows(namespace, digest string) (bool, error) {
+	if containsString(p.Digests, digest) {
+		return true, nil
+	}
+	if p.AllowlistURL == "" {
+		return false, nil
+	}
+
+	query := url.Values{"namespace": {namespace}, "digest": {digest}}
+	target := p.AllowlistURL + "?" + query.Encode()
+	if strings.Contains(p.AllowlistURL, "?") {
+		target = p.AllowlistURL + "&" + query.Encode()
+	}
+
+	resp, err := p.client.Get(target)
+	if err != nil {
+		return p.FailOpen, fmt.Errorf("allowlist service unreachable: %w", err)
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusForbidden, http.StatusNotFound:
+		return false, nil
+	default:
+		return p.FailOpen, fmt.Errorf("allowlist service returned status %d", resp.StatusCode)
+	}
+}
+
+// checkImagePolicies fails Gate One for workloads running an image digest
+// that is not allowlisted for their namespace. Workloads without digest
+// information (not enriched) are left untouched.
+func (s *Server) checkImagePolicies(statuses []*WorkloadStatus) {
+	if len(s.imagePolicies) == 0 {
+		return
+	}
+
+	// The same digest usually runs in many pods - ask the service once per cycle
+	verdicts := make(map[string]bool)
+	for _, status := range statuses {
+		policy := s.imagePolicyFor(status.Namespace)
+		if policy == nil {
+			continue
+		}
+
+		var rejected, failures []string
+		for _, digest := range status.ImageDigests {
+			key := status.Namespace + "@" + digest
+			allowed, ok := verdicts[key]
+			if !ok {
+				var err error
+				allowed, err = policy.allows(status.Namespace, digest)
+				if err != nil {
+					failures = append(failures, err.Error())
+					if !allowed {
+						continue
+					}
+				}
+				verdicts[key] = allowed
+			}
+			if !allowed {
+				rejected = append(rejected, digest)
+			}
+		}
+
+		switch {
+		case len(rejected) > 0:
+			status.GateOneStatus = "failed"
+			status.Details = fmt.Sprintf("Image digest not allowlisted: %s - %s", strings.Join(rejected, ", "), status.Details)
+			for _, digest := range rejected {
+				failCheck(status, "image_allowlist", "allowlisted digest", digest, severityCritical)
+			}
+		case len(failures) > 0 && !policy.FailOpen:
+			status.GateOneStatus = "failed"
+			status.Details = fmt.Sprintf("Image allowlist check failed: %s - %s", failures[0], status.Details)
+			failCheck(status, "image_allowlist", "allowlist service reachable", failures[0], severityHigh)
+		}
+	}
+}
+
+// imageDigest extracts the digest from a container status imageID such as
+// "docker-pullable://quay.io/org/app@sha256:..."
+func imageDigest(imageID string) string {
+	if i := strings.LastIndex(imageID, "@"); i >= 0 {
+		return imageID[i+1:]
+	}
+	if strings.HasPrefix(imageID, "sha256:") {
+		return imageID
+	}
+	return ""
+}
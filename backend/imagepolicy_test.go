@@ -0,0 +1,77 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestLoadImagePolicies tests parsing and validation of the image policy config
+func TestLoadImagePolicies(t *testing.T) {
+	dir := t.TempDir()
+
+	valid := filepath.Join(dir, "valid.json")
+	os.WriteFile(valid, []byte(`[{"namespace":"icu","digests":["sha256:aaa"]},{"namespace":"*","allowlist_url":"http://allowlist/check"}]`), 0o600)
+	policies, err := loadImagePolicies(valid)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(policies) != 2 {
+		t.Errorf("Expected 2 policies, got %d", len(policies))
+	}
+
+	empty := filepath.Join(dir, "empty.json")
+	os.WriteFile(empty, []byte(`[{"namespace":"icu"}]`), 0o600)
+	if _, err := loadImagePolicies(empty); err == nil {
+		t.Error("Expected error for policy without digests or allowlist_url")
+	}
+}
+
+// TestCheckImagePolicies tests that non-allowlisted digests fail Gate One
+func TestCheckImagePolicies(t *testing.T) {
+	calls := 0
+	allowlist := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.URL.Query().Get("digest") == "sha256:remote-ok" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer allowlist.Close()
+
+	server := &Server{
+		imagePolicies: []ImagePolicy{
+			{Namespace: "icu", Digests: []string{"sha256:static-ok"}},
+			{Namespace: "*", AllowlistURL: allowlist.URL, client: allowlist.Client()},
+		},
+	}
+
+	statuses := []*WorkloadStatus{
+		{Name: "a", Namespace: "icu", GateOneStatus: "passing", ImageDigests: []string{"sha256:static-ok"}},
+		{Name: "b", Namespace: "icu", GateOneStatus: "passing", ImageDigests: []string{"sha256:remote-ok"}},
+		{Name: "c", Namespace: "radiology", GateOneStatus: "passing", ImageDigests: []string{"sha256:remote-ok"}},
+		{Name: "d", Namespace: "radiology", GateOneStatus: "passing", ImageDigests: []string{"sha256:remote-ok"}},
+		{Name: "e", Namespace: "radiology", GateOneStatus: "passing"},
+	}
+	server.checkImagePolicies(statuses)
+
+	expected := []string{"passing", "failed", "passing", "passing", "passing"}
+	for i, status := range statuses {
+		if status.GateOneStatus != expected[i] {
+			t.Errorf("Expected %s Gate One '%s', got '%s'", status.Name, expected[i], status.GateOneStatus)
+		}
+	}
+	if !isViolation(statuses[1]) {
+		t.Error("Expected a failed Gate One to count as a violation")
+	}
+	if calls != 1 {
+		t.Errorf("Expected the allowlist service to be asked once per digest, got %d calls", calls)
+	}
+
+	if digest := imageDigest("docker-pullable://quay.io/org/app@sha256:abc"); digest != "sha256:abc" {
+		t.Errorf("Expected digest 'sha256:abc', got '%s'", digest)
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// A dashboard deployed next to a running Collector starts with an empty
+// history, so SLA and trend reports have nothing to show for the first
+// retention period. An import replays the reports the Collector archived
+// before the dashboard existed into history events, as if the dashboard had
+// polled them then.
+
+// maxImportSize bounds the body accepted by /api/admin/import, and its size
+// once decompressed
+const maxImportSize = 512 << 20
+
+// importResult is the response of POST /api/admin/import
+type importResult struct {
+	Reports     int        `json:"reports"`     // reports in the archive
+	Imported    int        `json:"imported"`    // reports replayed into history
+	Events      int        `json:"events"`      // history events recorded from them
+	Workloads   int        `json:"workloads"`   // workloads with imported events
+	Overlapping int        `json:"overlapping"` // skipped, at or after the workload's recorded history
+	Expired     int        `json:"expired"`     // skipped, older than the history retention
+	Rejected    int        `json:"rejected"`    // skipped, invalid or without a timestamp
+	From        *time.Time `json:"from,omitempty"`
+	To          *time.Time `json:"to,omitempty"`
+}
+
+// readImportArchive reads the reports of an archive: a JSON array as served
+// by a Collector, newline-delimited reports as kept in its store, or a
+// sequence of either. Gzip-compressed archives are detected and inflated.
+func readImportArchive(body io.Reader) ([]json.RawMessage, error) {
+	br := bufio.NewReader(body)
+	if magic, _ := br.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		br = bufio.NewReader(io.LimitReader(gz, maxImportSize))
+	}
+
+	var raws []json.RawMessage
+	dec := json.NewDecoder(br)
+	for {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err == io.EOF {
+			return raws, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if raw[0] != '[' {
+			raws = append(raws, raw)
+			continue
+		}
+		var batch []json.RawMessage
+		if err := json.Unmarshal(raw, &batch); err != nil {
+			return nil, err
+		}
+		raws = append(raws, batch...)
+	}
+}
+
+// importReports replays archived reports into history events. Each
+// workload's reports are evaluated in timestamp order under the live AR4SI
+// profile, recording an event where its status changed. Reports at or after
+// a workload's earliest recorded event are skipped, so an import never
+// rewrites what the dashboard observed itself and importing an archive twice
+// adds nothing. Reports without a cluster are attributed to cluster.
+func (s *Server) importReports(raws []json.RawMessage, cluster string, now time.Time) ([]HistoryEvent, importResult) {
+	s.cacheMutex.RLock()
+	// Kubernetes, owners and gates describe the workload as it is now, not
+	// as it was then; a report's age is not a clock skew
+	evaluator := &Server{ar4siProfile: s.ar4siProfile, localCluster: s.localCluster}
+	s.cacheMutex.RUnlock()
+
+	result := importResult{Reports: len(raws)}
+	var cutoff time.Time
+	if s.history != nil && s.history.retention > 0 {
+		cutoff = now.Add(-s.history.retention)
+	}
+	recorded := s.history.FirstEvents()
+
+	byKey := make(map[string][]CollectorReport)
+	for _, raw := range raws {
+		report, err := decodeCollectorReport(raw)
+		if err != nil || report.Timestamp.IsZero() {
+			result.Rejected++
+			continue
+		}
+		key := report.Namespace + "/" + report.PodName
+		if first, ok := recorded[key]; (ok && !report.Timestamp.Before(first)) || report.Timestamp.After(now) {
+			result.Overlapping++
+			continue
+		}
+		if report.Timestamp.Before(cutoff) {
+			result.Expired++
+			continue
+		}
+		if report.Cluster == "" {
+			report.Cluster = cluster
+		}
+		byKey[key] = append(byKey[key], report)
+	}
+
+	var events []HistoryEvent
+	for key, reports := range byKey {
+		sort.SliceStable(reports, func(i, j int) bool { return reports[i].Timestamp.Before(reports[j].Timestamp) })
+		var prev *WorkloadStatus
+		for i := range reports {
+			status := evaluator.enrich(&reports[i])
+			status.LastChecked = reports[i].Timestamp
+			event := HistoryEvent{Time: reports[i].Timestamp, Key: key, Type: "added", Status: status, Imported: true}
+			switch {
+			case prev == nil:
+			case statusChanged(prev, status):
+				event.Type = "changed"
+				event.PreviousStatus = prev.AttestationStatus
+			default:
+				prev = status
+				continue
+			}
+			events = append(events, event)
+			prev = status
+		}
+		result.Imported += len(reports)
+
+		first, last := reports[0].Timestamp, reports[len(reports)-1].Timestamp
+		if result.From == nil || first.Before(*result.From) {
+			result.From = &first
+		}
+		if result.To == nil || last.After(*result.To) {
+			result.To = &last
+		}
+	}
+	result.Events = len(events)
+	result.Workloads = len(byKey)
+	return events, result
+}
+
+// handleImport backfills history from an archive of past Collector reports.
+// The imported events are not notified nor streamed: they are news to no one.
+// POST /api/admin/import?cluster=north
+func (s *Server) handleImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	identity, ok := requireAdmin(w, r)
+	if !ok {
+		return
+	}
+	if s.history == nil {
+		http.Error(w, "history is not enabled", http.StatusServiceUnavailable)
+		return
+	}
+	cluster := r.URL.Query().Get("cluster")
+	if cluster == "" {
+		cluster = s.localCluster
+	}
+
+	raws, err := readImportArchive(http.MaxBytesReader(w, r.Body, maxImportSize))
+	if err != nil {
+		http.Error(w, "invalid report archive: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	events, result := s.importReports(raws, cluster, time.Now())
+	if err := s.history.Import(events); err != nil {
+		log.Printf("Failed to import history: %v", err)
+		http.Error(w, "failed to import history", http.StatusInternalServerError)
+		return
+	}
+
+	s.audit.RecordRequest(r, identity.Name, "admin.import", "history",
+		fmt.Sprintf("%d of %d reports from cluster %s, %d events for %d workloads", result.Imported, result.Reports, cluster, result.Events, result.Workloads))
+	log.Printf("Imported %d of %d archived reports: %d history events for %d workloads", result.Imported, result.Reports, result.Events, result.Workloads)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestHandleImport tests that archived reports are replayed into history
+// before the recorded events, and that importing them again adds nothing
+func TestHandleImport(t *testing.T) {
+	store, err := openStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	history, _ := newHistory(store, 90*24*time.Hour)
+	now := time.Now()
+	live := verifiedStatus("icu", "pacs")
+	history.Record([]HistoryEvent{{Time: now.Add(-time.Hour), Key: "icu/pacs", Type: "added", Status: live}})
+	audit, _ := newAuditLog(nil)
+	server := &Server{history: history, audit: audit, localCluster: "local"}
+	admin := &Identity{Name: "ops", Roles: []string{adminRole}}
+
+	report := func(name string, attested bool, age time.Duration) CollectorReport {
+		return CollectorReport{PodName: name, Namespace: "icu", TEEType: "SNP", Attested: attested, Timestamp: now.Add(-age).Truncate(time.Second)}
+	}
+	reports := []CollectorReport{
+		report("pacs", true, 72*time.Hour),
+		report("pacs", true, 48*time.Hour), // unchanged
+		report("pacs", false, 24*time.Hour),
+		report("pacs", true, 30*time.Minute), // after the recorded history
+		report("monitor", true, 100*24*time.Hour),
+		report("monitor", true, 10*24*time.Hour),
+	}
+	var ndjson bytes.Buffer
+	for _, r := range reports {
+		line, _ := json.Marshal(r)
+		ndjson.Write(append(line, '\n'))
+	}
+	ndjson.WriteString(`{"namespace":"icu"}` + "\n")
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	gz.Write(ndjson.Bytes())
+	gz.Close()
+
+	w := httptest.NewRecorder()
+	server.handleImport(w, ackRequestAs(&Identity{Name: "raj"}, "POST", "/api/admin/import", archive.String()))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected non-admins to be refused, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	server.handleImport(w, ackRequestAs(admin, "POST", "/api/admin/import", "[{"))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected an invalid archive to be rejected, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	server.handleImport(w, ackRequestAs(admin, "POST", "/api/admin/import?cluster=north", archive.String()))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var result importResult
+	json.NewDecoder(w.Body).Decode(&result)
+	want := importResult{Reports: 7, Imported: 4, Events: 3, Workloads: 2, Overlapping: 1, Expired: 1, Rejected: 1}
+	result.From, result.To = nil, nil
+	if result != want {
+		t.Errorf("Expected %+v, got %+v", want, result)
+	}
+
+	var got []string
+	for _, event := range history.Events(time.Time{}, now) {
+		got = append(got, event.Key+" "+event.Type+" "+event.Status.AttestationStatus)
+		if event.Imported && (event.Status.Cluster != "north" || !event.Status.LastChecked.Equal(event.Time)) {
+			t.Errorf("Expected imported events in cluster north as of their report, got %+v", event.Status)
+		}
+	}
+	expected := "icu/monitor added verified,icu/pacs added verified,icu/pacs changed failed,icu/pacs added verified"
+	if strings.Join(got, ",") != expected {
+		t.Errorf("Unexpected history %v", got)
+	}
+
+	// The store holds the merged history
+	reloaded, _ := newHistory(store, 90*24*time.Hour)
+	if n := len(reloaded.Events(time.Time{}, now)); n != 4 {
+		t.Errorf("Expected 4 persisted events, got %d", n)
+	}
+
+	// Everything in the archive now precedes nothing
+	w = httptest.NewRecorder()
+	server.handleImport(w, ackRequestAs(admin, "POST", "/api/admin/import", archive.String()))
+	json.NewDecoder(w.Body).Decode(&result)
+	if result.Events != 0 || len(history.Events(time.Time{}, now)) != 4 {
+		t.Errorf("Expected a second import to add nothing, got %+v", result)
+	}
+
+	entries := audit.Entries(time.Time{})
+	if len(entries) != 2 || entries[0].Action != "admin.import" {
+		t.Errorf("Unexpected audit trail %+v", entries)
+	}
+}
+
+// TestReadImportArchive tests that a Collector response, its store's
+// newline-delimited reports and a sequence of responses are all read
+func TestReadImportArchive(t *testing.T) {
+	tests := []struct {
+		body string
+		want int
+	}{
+		{`[{"a":1},{"a":2}]`, 2},
+		{"{\"a\":1}\n{\"a\":2}\n{\"a\":3}\n", 3},
+		{"[{\"a\":1}]\n[{\"a\":2},{\"a\":3}]", 3},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		raws, err := readImportArchive(strings.NewReader(tt.body))
+		if err != nil || len(raws) != tt.want {
+			t.Errorf("%q: expected %d reports, got %d: %v", tt.body, tt.want, len(raws), err)
+		}
+	}
+	if _, err := readImportArchive(strings.NewReader(`[{"a":1},`)); err == nil {
+		t.Error("Expected a truncated archive to be rejected")
+	}
+}
@@ -0,0 +1,332 @@
+package main
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rh-summit-coco/raj-hospital-dashboard/backend/pkg/api"
+)
+
+// ingestSourcePrefix marks the Source of reports pushed to the ingest endpoint
+const ingestSourcePrefix = "ingest:"
+
+// defaultIngestTTL is how long a pushed report stands in for its workload
+// when the source doesn't configure a ttl
+const defaultIngestTTL = 10 * time.Minute
+
+// ingestFields are the Collector report fields a source mapping can fill
+var ingestFields = map[string]bool{
+	"pod_name": true, "namespace": true, "attested": true, "tee_type": true, "tcb_version": true,
+	"node_name": true, "cluster": true, "trust_vector": true, "timestamp": true, "error": true, "trace_id": true,
+}
+
+// IngestSource configures a third-party attestation source - for example a
+// legacy SEV attestation service - that pushes its own payload format to
+// POST /api/ingest/{name}. Each item of the payload is mapped onto a
+// Collector report and then handled like one.
+type IngestSource struct {
+	Name      string      `json:"name"`
+	Token     string      `json:"token,omitempty"`      // Bearer token the source authenticates with
+	TokenFile string      `json:"token_file,omitempty"` // Alternative to Token, re-read on every request
+	Schema    *api.Schema `json:"schema,omitempty"`     // JSON Schema the request body must satisfy
+	// Items is a JSON Pointer to the array of items in the body. Without it
+	// the body is a single item or an array of items.
+	Items string `json:"items,omitempty"`
+	// Mapping maps Collector report fields (pod_name, namespace, attested,
+	// tee_type, ...) to JSON Pointers into an item
+	Mapping map[string]string `json:"mapping"`
+	// AttestedValues are the values of the mapped attested field that mean
+	// success, for sources that don't report a boolean
+	AttestedValues []string `json:"attested_values,omitempty"`
+	// Defaults fill report fields the item doesn't provide, e.g. tee_type
+	Defaults map[string]interface{} `json:"defaults,omitempty"`
+	TTL      string                 `json:"ttl,omitempty"` // how long a pushed report stays current; default 10m
+
+	ttl time.Duration
+}
+
+// loadIngestSources reads the ingest source list from a JSON file
+func loadIngestSources(path string) (map[string]*IngestSource, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []IngestSource
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("invalid ingest config: %w", err)
+	}
+
+	sources := make(map[string]*IngestSource, len(list))
+	for i := range list {
+		src := &list[i]
+		if src.Name == "" || strings.Contains(src.Name, "/") {
+			return nil, fmt.Errorf("source %d: a name without '/' is required", i)
+		}
+		if sources[src.Name] != nil {
+			return nil, fmt.Errorf("duplicate source name %q", src.Name)
+		}
+		if src.Token == "" && src.TokenFile == "" {
+			return nil, fmt.Errorf("source %s: token or token_file is required", src.Name)
+		}
+		for _, field := range []string{"pod_name", "namespace", "attested"} {
+			if src.Mapping[field] == "" && src.Defaults[field] == nil {
+				return nil, fmt.Errorf("source %s: no mapping for %s", src.Name, field)
+			}
+		}
+		for field := range src.Mapping {
+			if !ingestFields[field] {
+				return nil, fmt.Errorf("source %s: unknown report field %q", src.Name, field)
+			}
+		}
+		src.ttl = defaultIngestTTL
+		if src.TTL != "" {
+			if src.ttl, err = time.ParseDuration(src.TTL); err != nil || src.ttl <= 0 {
+				return nil, fmt.Errorf("source %s: invalid ttl %q", src.Name, src.TTL)
+			}
+		}
+		sources[src.Name] = src
+	}
+	return sources, nil
+}
+
+// authorized reports whether a request carries the source's token
+func (src *IngestSource) authorized(r *http.Request) bool {
+	token := src.Token
+	if src.TokenFile != "" {
+		data, err := os.ReadFile(src.TokenFile)
+		if err != nil {
+			log.Printf("Failed to read token file of ingest source %s: %v", src.Name, err)
+			return false
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	presented, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && token != "" && subtle.ConstantTimeCompare([]byte(presented), []byte(token)) == 1
+}
+
+// resolvePointer evaluates an RFC 6901 JSON Pointer against a decoded document
+func resolvePointer(doc interface{}, pointer string) (interface{}, bool) {
+	if pointer == "" {
+		return doc, true
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, false
+	}
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		switch v := doc.(type) {
+		case map[string]interface{}:
+			var ok bool
+			if doc, ok = v[token]; !ok {
+				return nil, false
+			}
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			doc = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return doc, true
+}
+
+// mapItem maps one source item onto the JSON of a Collector report
+func (src *IngestSource) mapItem(item interface{}) (json.RawMessage, error) {
+	report := make(map[string]interface{}, len(src.Mapping)+len(src.Defaults))
+	for field, value := range src.Defaults {
+		report[field] = value
+	}
+	for field, pointer := range src.Mapping {
+		if value, ok := resolvePointer(item, pointer); ok && value != nil {
+			report[field] = value
+		}
+	}
+
+	if len(src.AttestedValues) > 0 {
+		if value, ok := report["attested"]; ok {
+			report["attested"] = containsString(src.AttestedValues, fmt.Sprint(value))
+		}
+	}
+	return json.Marshal(report)
+}
+
+// ingest maps a request body to Collector reports. Items that don't map to
+// a valid report fail the whole request, so the source learns of the problem.
+func (src *IngestSource) ingest(body []byte) ([]CollectorReport, []string) {
+	if src.Schema != nil {
+		if errs := src.Schema.Validate(body); len(errs) > 0 {
+			return nil, errs
+		}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, []string{fmt.Sprintf("invalid JSON: %v", err)}
+	}
+
+	items, ok := resolvePointer(doc, src.Items)
+	if !ok {
+		return nil, []string{fmt.Sprintf("%s: not found", src.Items)}
+	}
+	list, isList := items.([]interface{})
+	if !isList {
+		if src.Items != "" {
+			return nil, []string{fmt.Sprintf("%s: expected array", src.Items)}
+		}
+		list = []interface{}{items}
+	}
+
+	var reports []CollectorReport
+	var errs []string
+	for i, item := range list {
+		raw, err := src.mapItem(item)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("item %d: %v", i, err))
+			continue
+		}
+		var report CollectorReport
+		if validation := collectorReportSchema.Validate(raw); len(validation) > 0 {
+			for _, e := range validation {
+				errs = append(errs, fmt.Sprintf("item %d: mapped %s", i, e))
+			}
+			continue
+		}
+		if err := json.Unmarshal(raw, &report); err != nil {
+			errs = append(errs, fmt.Sprintf("item %d: %v", i, err))
+			continue
+		}
+		report.raw = raw
+		report.source = ingestSourcePrefix + src.Name
+		reports = append(reports, report)
+	}
+	return reports, errs
+}
+
+// ingestedReport is a pushed report and when it stops standing in for its workload
+type ingestedReport struct {
+	report  CollectorReport
+	expires time.Time
+}
+
+// ingestStore holds the latest report pushed for each workload, per source,
+// until it expires
+type ingestStore struct {
+	mu      sync.Mutex
+	sources map[string]*IngestSource
+	reports map[string]ingestedReport // by source/namespace/pod
+}
+
+func newIngestStore(sources map[string]*IngestSource) *ingestStore {
+	return &ingestStore{sources: sources, reports: make(map[string]ingestedReport)}
+}
+
+// add stores pushed reports, replacing earlier ones for the same workloads
+func (st *ingestStore) add(src *IngestSource, reports []CollectorReport, now time.Time) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	for _, report := range reports {
+		if report.Timestamp.IsZero() {
+			report.Timestamp = now
+		}
+		key := src.Name + "/" + report.Namespace + "/" + report.PodName
+		st.reports[key] = ingestedReport{report: report, expires: now.Add(src.ttl)}
+	}
+}
+
+// current returns the unexpired pushed reports and drops the rest
+func (st *ingestStore) current(now time.Time) []CollectorReport {
+	if st == nil {
+		return nil
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	var reports []CollectorReport
+	for key, ingested := range st.reports {
+		if !now.Before(ingested.expires) {
+			delete(st.reports, key)
+			continue
+		}
+		reports = append(reports, ingested.report)
+	}
+	return reports
+}
+
+// isIngestPath reports whether a path authenticates with an ingest source
+// token instead of a dashboard bearer token
+func isIngestPath(path string) bool {
+	return strings.HasPrefix(path, "/api/ingest/")
+}
+
+// handleIngest accepts attestation results from a configured third-party
+// source. They are merged with the Collector reports on the next poll.
+// POST /api/ingest/{source}
+func (s *Server) handleIngest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.ingest == nil {
+		http.Error(w, "ingest is not enabled", http.StatusNotFound)
+		return
+	}
+	src, ok := s.ingest.sources[strings.TrimPrefix(r.URL.Path, "/api/ingest/")]
+	if !ok {
+		http.Error(w, "unknown ingest source", http.StatusNotFound)
+		return
+	}
+	if !src.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="ingest"`)
+		http.Error(w, "authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestBody))
+	if err != nil {
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return
+	}
+	reports, errs := src.ingest(body)
+	if len(errs) > 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(validationError{Error: "invalid request body", Details: errs})
+		return
+	}
+
+	for i := range reports {
+		if reports[i].Cluster == "" {
+			reports[i].Cluster = s.localCluster
+		}
+		if !s.hasCluster(reports[i].Cluster) {
+			http.Error(w, fmt.Sprintf("item %d: unknown cluster %q", i, reports[i].Cluster), http.StatusBadRequest)
+			return
+		}
+	}
+	for i := range reports {
+		s.inspectReport(reports[i].source, reports[i].raw)
+	}
+	s.ingest.add(src, reports, time.Now())
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]int{"accepted": len(reports)})
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// legacySEVConfig maps a legacy SEV attestation service's payload
+const legacySEVConfig = `[{
+	"name": "legacy-sev",
+	"token": "s3cret",
+	"items": "/results",
+	"schema": {"type": "object", "required": ["results"], "properties": {"results": {"type": "array"}}},
+	"mapping": {"pod_name": "/vm/name", "namespace": "/vm/tenant", "attested": "/verdict", "error": "/reason", "timestamp": "/checked_at"},
+	"attested_values": ["PASS"],
+	"defaults": {"tee_type": "sev"},
+	"ttl": "5m"
+}]`
+
+func newIngestTestServer(t *testing.T) *Server {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ingest.json")
+	os.WriteFile(path, []byte(legacySEVConfig), 0o600)
+	sources, err := loadIngestSources(path)
+	if err != nil {
+		t.Fatalf("Failed to load ingest config: %v", err)
+	}
+	return &Server{ingest: newIngestStore(sources), localCluster: "east"}
+}
+
+func ingestRequest(token, body string) *http.Request {
+	req := httptest.NewRequest("POST", "/api/ingest/legacy-sev", strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req
+}
+
+// TestHandleIngest tests mapping a third-party payload onto Collector reports
+func TestHandleIngest(t *testing.T) {
+	server := newIngestTestServer(t)
+	body := `{"results": [
+		{"vm": {"name": "pacs", "tenant": "radiology"}, "verdict": "PASS", "checked_at": "2024-05-01T10:00:00Z"},
+		{"vm": {"name": "lims", "tenant": "lab"}, "verdict": "FAIL", "reason": "measurement mismatch"}
+	]}`
+
+	w := httptest.NewRecorder()
+	server.handleIngest(w, ingestRequest("wrong", body))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for a wrong token, got %d", w.Code)
+	}
+
+	for _, invalid := range []string{`{"items": []}`, `{"results": [{"vm": {"name": "pacs"}, "verdict": "PASS"}]}`} {
+		w = httptest.NewRecorder()
+		server.handleIngest(w, ingestRequest("s3cret", invalid))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d", invalid, w.Code)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	server.handleIngest(w, ingestRequest("s3cret", body))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected 202, got %d: %s", w.Code, w.Body.String())
+	}
+
+	reports := server.ingest.current(time.Now())
+	if len(reports) != 2 {
+		t.Fatalf("Expected 2 ingested reports, got %+v", reports)
+	}
+	byPod := map[string]CollectorReport{}
+	for _, report := range reports {
+		byPod[report.PodName] = report
+	}
+	pacs, lims := byPod["pacs"], byPod["lims"]
+	if !pacs.Attested || pacs.Namespace != "radiology" || pacs.TEEType != "sev" || pacs.Cluster != "east" || pacs.source != "ingest:legacy-sev" {
+		t.Errorf("Unexpected pacs report %+v", pacs)
+	}
+	if !pacs.Timestamp.Equal(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected mapped timestamp, got %s", pacs.Timestamp)
+	}
+	if lims.Attested || lims.Error != "measurement mismatch" || lims.Timestamp.IsZero() {
+		t.Errorf("Unexpected lims report %+v", lims)
+	}
+
+	// Reports expire after the source's ttl
+	if reports := server.ingest.current(time.Now().Add(6 * time.Minute)); len(reports) != 0 {
+		t.Errorf("Expected ingested reports to expire, got %+v", reports)
+	}
+}
+
+// TestLoadIngestSources tests that incomplete sources are rejected
+func TestLoadIngestSources(t *testing.T) {
+	for _, config := range []string{
+		`[{"name": "a", "mapping": {"pod_name": "/p", "namespace": "/n", "attested": "/a"}}]`,
+		`[{"name": "a", "token": "t", "mapping": {"pod_name": "/p", "namespace": "/n"}}]`,
+		`[{"name": "a", "token": "t", "mapping": {"pod_name": "/p", "namespace": "/n", "attested": "/a", "colour": "/c"}}]`,
+	} {
+		path := filepath.Join(t.TempDir(), "ingest.json")
+		os.WriteFile(path, []byte(config), 0o600)
+		if _, err := loadIngestSources(path); err == nil {
+			t.Errorf("Expected %s to be rejected", config)
+		}
+	}
+}
+
+// TestResolvePointer tests JSON Pointer evaluation
+func TestResolvePointer(t *testing.T) {
+	doc := map[string]interface{}{"a/b": map[string]interface{}{"list": []interface{}{"x", "y"}}}
+	if v, ok := resolvePointer(doc, "/a~1b/list/1"); !ok || v != "y" {
+		t.Errorf("Expected y, got %v", v)
+	}
+	if _, ok := resolvePointer(doc, "/a~1b/list/2"); ok {
+		t.Error("Expected out-of-range index to fail")
+	}
+	if v, _ := resolvePointer(doc, ""); v == nil {
+		t.Error("Expected empty pointer to return the document")
+	}
+}
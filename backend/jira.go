@@ -0,0 +1,320 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const jiraIssuesDoc = "jira-issues"
+
+// Defaults of the JIRA_CONFIG file
+const (
+	defaultJiraWindow        = 24 * time.Hour
+	defaultJiraCheckInterval = 5 * time.Minute
+	defaultJiraHistoryLines  = 10
+)
+
+// jiraConfig is the JIRA_CONFIG file. A workload is chronic, and gets an
+// issue, when it entered violation at least Violations times within Window,
+// or has been in violation for FailedFor.
+type jiraConfig struct {
+	URL       string `json:"url"` // e.g. https://hospital.atlassian.net
+	Project   string `json:"project"`
+	IssueType string `json:"issue_type,omitempty"` // default "Task"
+	// Username with the token is basic auth (a Jira Cloud API token);
+	// without a username the token is sent as a personal access token
+	Username      string   `json:"username,omitempty"`
+	TokenFile     string   `json:"token_file"` // re-read on every request
+	Labels        []string `json:"labels,omitempty"`
+	Violations    int      `json:"violations,omitempty"`
+	Window        string   `json:"window,omitempty"`         // default 24h
+	FailedFor     string   `json:"failed_for,omitempty"`     // e.g. "4h"
+	CheckInterval string   `json:"check_interval,omitempty"` // default 5m
+	HistoryLines  int      `json:"history_lines,omitempty"`  // history events quoted in the issue; default 10
+
+	window, failedFor, checkInterval time.Duration
+}
+
+// jiraIssue is an issue filed for a chronic workload
+type jiraIssue struct {
+	Key     string    `json:"key"` // e.g. SEC-123
+	Reason  string    `json:"reason"`
+	FiledAt time.Time `json:"filed_at"`
+}
+
+// jiraAutomation files a Jira issue for each workload that becomes chronic.
+// Filed issues are persisted, so a workload gets one issue until it stops
+// being chronic, also across restarts.
+type jiraAutomation struct {
+	config       jiraConfig
+	httpClient   *http.Client
+	store        *Store
+	redact       *redactor // PHI-safe mode; nil files issues as they are
+	dashboardURL string
+
+	mu     sync.Mutex
+	issues map[string]*jiraIssue // by workload key
+}
+
+// loadJiraAutomation reads JIRA_CONFIG and restores the filed issues
+func loadJiraAutomation(path string, store *Store) (*jiraAutomation, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	j := &jiraAutomation{
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		store:      store,
+		issues:     make(map[string]*jiraIssue),
+	}
+	config := &j.config
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("invalid Jira config: %w", err)
+	}
+	if u, err := url.Parse(config.URL); err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid url %q", config.URL)
+	}
+	config.URL = strings.TrimRight(config.URL, "/")
+	if config.Project == "" || config.TokenFile == "" {
+		return nil, fmt.Errorf("project and token_file are required")
+	}
+	if config.IssueType == "" {
+		config.IssueType = "Task"
+	}
+	if config.HistoryLines <= 0 {
+		config.HistoryLines = defaultJiraHistoryLines
+	}
+	for _, d := range []struct {
+		name  string
+		raw   string
+		value *time.Duration
+		def   time.Duration
+	}{
+		{"window", config.Window, &config.window, defaultJiraWindow},
+		{"failed_for", config.FailedFor, &config.failedFor, 0},
+		{"check_interval", config.CheckInterval, &config.checkInterval, defaultJiraCheckInterval},
+	} {
+		*d.value = d.def
+		if d.raw == "" {
+			continue
+		}
+		if *d.value, err = time.ParseDuration(d.raw); err != nil || *d.value <= 0 {
+			return nil, fmt.Errorf("invalid %s %q", d.name, d.raw)
+		}
+	}
+	if config.Violations <= 0 && config.failedFor == 0 {
+		return nil, fmt.Errorf("violations or failed_for is required")
+	}
+
+	if _, err := store.LoadDoc(jiraIssuesDoc, &j.issues); err != nil {
+		return nil, fmt.Errorf("failed to load filed Jira issues: %w", err)
+	}
+	return j, nil
+}
+
+// chronicReason returns why a workload with the given violation incidents
+// (ordered by start) is chronic at now, or "" if it isn't
+func (j *jiraAutomation) chronicReason(incidents []violationIncident, now time.Time) string {
+	if j.config.failedFor > 0 && len(incidents) > 0 {
+		last := incidents[len(incidents)-1]
+		if last.End.IsZero() && now.Sub(last.Start) >= j.config.failedFor {
+			return fmt.Sprintf("in violation for %s, since %s", now.Sub(last.Start).Round(time.Minute), last.Start.UTC().Format(time.RFC3339))
+		}
+	}
+	if j.config.Violations > 0 {
+		recent := 0
+		for _, incident := range incidents {
+			if !incident.Start.Before(now.Add(-j.config.window)) {
+				recent++
+			}
+		}
+		if recent >= j.config.Violations {
+			return fmt.Sprintf("%d violations in the last %s", recent, j.config.window)
+		}
+	}
+	return ""
+}
+
+// historyExcerpt renders the last lines of a workload's history
+func historyExcerpt(events []HistoryEvent, key string, lines int) []string {
+	var excerpt []string
+	for _, event := range events {
+		if event.Key != key {
+			continue
+		}
+		line := event.Time.UTC().Format(time.RFC3339) + " " + event.Type
+		if event.Status != nil {
+			line += ": " + event.Status.AttestationStatus
+			if event.Status.Details != "" {
+				line += " - " + event.Status.Details
+			}
+		}
+		excerpt = append(excerpt, line)
+	}
+	if len(excerpt) > lines {
+		excerpt = excerpt[len(excerpt)-lines:]
+	}
+	return excerpt
+}
+
+// file creates the issue of a chronic workload and records it
+func (j *jiraAutomation) file(key, reason string, status *WorkloadStatus, excerpt []string, now time.Time) (*jiraIssue, error) {
+	var description strings.Builder
+	fmt.Fprintf(&description, "Workload %s is chronically failing attestation: %s.\n\n", key, reason)
+	if status != nil {
+		fmt.Fprintf(&description, "Current status: %s\nDetails: %s\n", status.AttestationStatus, status.Details)
+		if status.Owner != nil {
+			fmt.Fprintf(&description, "Owner: %s %s\n", status.Owner.Team, status.Owner.Contact)
+		}
+	}
+	if j.dashboardURL != "" {
+		fmt.Fprintf(&description, "Dashboard: %s/api/workload/%s\n", j.dashboardURL, key)
+	}
+	fmt.Fprintf(&description, "\nRecent history:\n{noformat}\n%s\n{noformat}\n", strings.Join(excerpt, "\n"))
+
+	fields := map[string]interface{}{
+		"project":     map[string]string{"key": j.config.Project},
+		"issuetype":   map[string]string{"name": j.config.IssueType},
+		"summary":     j.redact.text(fmt.Sprintf("Chronic attestation violation: %s", key)),
+		"description": j.redact.text(description.String()),
+	}
+	if len(j.config.Labels) > 0 {
+		fields["labels"] = j.config.Labels
+	}
+	body, err := json.Marshal(map[string]interface{}{"fields": fields})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, j.config.URL+"/rest/api/2/issue", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	token, err := os.ReadFile(j.config.TokenFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Jira token: %w", err)
+	}
+	if j.config.Username != "" {
+		req.SetBasicAuth(j.config.Username, strings.TrimSpace(string(token)))
+	} else {
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+
+	resp, err := j.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Jira returned status %d", resp.StatusCode)
+	}
+	var created struct {
+		Key string `json:"key"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		return nil, fmt.Errorf("invalid Jira response: %w", err)
+	}
+
+	issue := &jiraIssue{Key: created.Key, Reason: reason, FiledAt: now}
+	j.mu.Lock()
+	j.issues[key] = issue
+	j.persistLocked()
+	j.mu.Unlock()
+	return issue, nil
+}
+
+// retain forgets the issues of workloads that are no longer chronic, so they
+// get a new issue if they become chronic again. Returns the workloads that
+// still have an issue.
+func (j *jiraAutomation) retain(chronic map[string]string) map[string]bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	filed := make(map[string]bool, len(j.issues))
+	changed := false
+	for key := range j.issues {
+		if chronic[key] == "" {
+			delete(j.issues, key)
+			changed = true
+			continue
+		}
+		filed[key] = true
+	}
+	if changed {
+		j.persistLocked()
+	}
+	return filed
+}
+
+// persistLocked saves filed issues to the store. Caller must hold mu.
+func (j *jiraAutomation) persistLocked() {
+	if err := j.store.SaveDoc(jiraIssuesDoc, j.issues); err != nil {
+		log.Printf("Failed to persist filed Jira issues: %v", err)
+	}
+}
+
+// runJiraAutomation checks for chronic workloads every check_interval
+func (s *Server) runJiraAutomation() {
+	ticker := time.NewTicker(s.jira.config.checkInterval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		s.fileChronicViolations(now)
+	}
+}
+
+// fileChronicViolations files an issue for each chronic workload that has
+// none yet. Failed attempts are retried on the next check.
+func (s *Server) fileChronicViolations(now time.Time) {
+	// Replay from the beginning so long-running violations are seen
+	events := s.history.Events(time.Time{}, now)
+	byKey := make(map[string][]violationIncident)
+	for _, incident := range violationIncidents(events) {
+		byKey[incident.Key] = append(byKey[incident.Key], incident)
+	}
+
+	chronic := make(map[string]string)
+	for key, incidents := range byKey {
+		if reason := s.jira.chronicReason(incidents, now); reason != "" {
+			chronic[key] = reason
+		}
+	}
+	filed := s.jira.retain(chronic)
+
+	keys := make([]string, 0, len(chronic))
+	for key := range chronic {
+		if !filed[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		s.cacheMutex.RLock()
+		var status *WorkloadStatus
+		if cached, ok := s.statusCache[key]; ok {
+			status = copyStatus(cached)
+		}
+		s.cacheMutex.RUnlock()
+
+		issue, err := s.jira.file(key, chronic[key], status, historyExcerpt(events, key, s.jira.config.HistoryLines), now)
+		if err != nil {
+			log.Printf("Failed to file Jira issue for %s: %v", key, err)
+			continue
+		}
+		log.Printf("Filed Jira issue %s for %s: %s", issue.Key, key, chronic[key])
+		s.audit.Record("system", "jira.create", key, issue.Key+": "+chronic[key])
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeJira records issues created through the REST API
+type fakeJira struct {
+	mu     sync.Mutex
+	issues []map[string]interface{} // fields of each issue
+}
+
+func (f *fakeJira) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.Method != http.MethodPost || r.URL.Path != "/rest/api/2/issue" || r.Header.Get("Authorization") != "Bearer pat-token" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	var body struct {
+		Fields map[string]interface{} `json:"fields"`
+	}
+	json.NewDecoder(r.Body).Decode(&body)
+	f.issues = append(f.issues, body.Fields)
+	w.WriteHeader(http.StatusCreated)
+	fmt.Fprintf(w, `{"id":"1000%d","key":"SEC-%d"}`, len(f.issues), len(f.issues))
+}
+
+// writeJiraConfig writes a JIRA_CONFIG file and a token for a fake Jira
+func writeJiraConfig(t *testing.T, url, extra string) string {
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "token")
+	os.WriteFile(tokenFile, []byte("pat-token\n"), 0o600)
+	path := filepath.Join(dir, "jira.json")
+	os.WriteFile(path, []byte(`{"url":"`+url+`","project":"SEC","token_file":"`+tokenFile+`"`+extra+`}`), 0o600)
+	return path
+}
+
+// TestJiraChronicViolations tests filing one issue per chronic workload, by
+// violation count and by duration, and filing again after recovery
+func TestJiraChronicViolations(t *testing.T) {
+	fake := &fakeJira{}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	store, err := openStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	jira, err := loadJiraAutomation(writeJiraConfig(t, srv.URL, `,"violations":3,"window":"1h","failed_for":"4h","labels":["attestation"]`), store)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	jira.dashboardURL = "https://dashboard.hospital.example"
+	audit, _ := newAuditLog(nil)
+	history, _ := newHistory(nil, 0)
+	server := &Server{history: history, jira: jira, audit: audit, statusCache: make(map[string]*WorkloadStatus)}
+
+	now := time.Now()
+	record := func(at time.Time, status *WorkloadStatus) {
+		server.history.Record([]HistoryEvent{{Time: at, Key: status.Namespace + "/" + status.Name, Type: "changed", Status: status}})
+	}
+	// lab/lims fails once and stays failed; icu/monitor flaps three times
+	// within the hour
+	record(now.Add(-5*time.Hour), failedStatus("lab", "lims"))
+	record(now.Add(-2*time.Hour), failedStatus("radiology", "pacs"))
+	for i := 3; i > 0; i-- {
+		at := now.Add(-time.Duration(i) * 15 * time.Minute)
+		record(at, failedStatus("icu", "monitor"))
+		record(at.Add(5*time.Minute), verifiedStatus("icu", "monitor"))
+	}
+
+	server.fileChronicViolations(now)
+	if len(fake.issues) != 2 {
+		t.Fatalf("Expected 2 issues, got %+v", fake.issues)
+	}
+	monitor, lims := fake.issues[0], fake.issues[1]
+	if lims["summary"] != "Chronic attestation violation: lab/lims" || !strings.Contains(lims["description"].(string), "in violation for 5h0m0s") {
+		t.Errorf("Unexpected issue for lab/lims: %+v", lims)
+	}
+	description := monitor["description"].(string)
+	if !strings.Contains(description, "3 violations in the last 1h0m0s") ||
+		!strings.Contains(description, "https://dashboard.hospital.example/api/workload/icu/monitor") ||
+		strings.Count(description, " changed: ") != 6 {
+		t.Errorf("Unexpected description for icu/monitor: %s", description)
+	}
+	if labels, _ := monitor["labels"].([]interface{}); len(labels) != 1 || monitor["issuetype"].(map[string]interface{})["name"] != "Task" {
+		t.Errorf("Unexpected issue fields: %+v", monitor)
+	}
+
+	// Filed issues survive a restart and aren't filed twice
+	jira, err = loadJiraAutomation(writeJiraConfig(t, srv.URL, `,"violations":3,"window":"1h","failed_for":"4h"`), store)
+	if err != nil {
+		t.Fatalf("Failed to reload config: %v", err)
+	}
+	server.jira = jira
+	server.fileChronicViolations(now.Add(time.Minute))
+	if len(fake.issues) != 2 || jira.issues["icu/monitor"].Key != "SEC-1" {
+		t.Errorf("Expected no new issues, got %+v", fake.issues)
+	}
+
+	// After recovering, lab/lims gets a new issue when it is chronic again
+	record(now.Add(2*time.Minute), verifiedStatus("lab", "lims"))
+	server.fileChronicViolations(now.Add(3 * time.Minute))
+	if _, ok := jira.issues["lab/lims"]; ok {
+		t.Errorf("Expected the recovered workload's issue to be forgotten")
+	}
+	record(now.Add(4*time.Minute), failedStatus("lab", "lims"))
+	server.fileChronicViolations(now.Add(5 * time.Hour))
+	if len(fake.issues) != 4 || jira.issues["lab/lims"].Key == "SEC-2" {
+		t.Errorf("Expected lab/lims and radiology/pacs to be filed, got %d issues", len(fake.issues))
+	}
+}
+
+// TestJiraConfigValidation tests rejecting incomplete configs
+func TestJiraConfigValidation(t *testing.T) {
+	for _, extra := range []string{
+		``,
+		`,"violations":3,"window":"soon"`,
+		`,"failed_for":"-1h"`,
+		`,"project":"","violations":3`,
+	} {
+		if _, err := loadJiraAutomation(writeJiraConfig(t, "https://hospital.atlassian.net", extra), nil); err == nil {
+			t.Errorf("Expected config with %s to be rejected", extra)
+		}
+	}
+	if _, err := loadJiraAutomation(writeJiraConfig(t, "hospital.atlassian.net", `,"violations":3`), nil); err == nil {
+		t.Errorf("Expected a URL without scheme to be rejected")
+	}
+}
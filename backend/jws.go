@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// signatureHeader carries the detached JWS (RFC 7515 appendix F) of a
+// signed response: "<protected header>..<signature>". The payload is the
+// response body, so clients that don't verify see no difference; verifiers
+// base64url-encode the body and insert it between the two dots.
+const signatureHeader = "X-JWS-Signature"
+
+// signedPaths are the responses downstream compliance systems ingest: the
+// workload status and the exports. A trailing slash matches the subtree.
+var signedPaths = []string{
+	"/api/status",
+	"/api/audit",
+	"/api/audit/access",
+	"/api/reports/",
+}
+
+// responseSigner signs API responses with the server key from
+// RESPONSE_SIGNING_KEY, so their origin and integrity can be verified
+// against the key published at /api/signing-keys
+type responseSigner struct {
+	key crypto.Signer
+	alg string // JWS algorithm: ES256, RS256 or EdDSA
+	kid string // RFC 7638 thumbprint of the public key
+	jwk map[string]string
+}
+
+// loadResponseSigner reads a PEM private key (PKCS#8, SEC 1 or PKCS#1)
+func loadResponseSigner(path string) (*responseSigner, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM key in %s", path)
+	}
+
+	var key interface{}
+	switch block.Type {
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid signing key: %w", err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("unsupported signing key type")
+	}
+	return newResponseSigner(signer)
+}
+
+// newResponseSigner picks the JWS algorithm for the key and derives its JWK
+func newResponseSigner(key crypto.Signer) (*responseSigner, error) {
+	s := &responseSigner{key: key}
+	b64 := base64.RawURLEncoding.EncodeToString
+	switch pub := key.Public().(type) {
+	case *ecdsa.PublicKey:
+		if pub.Curve != elliptic.P256() {
+			return nil, errors.New("ECDSA signing keys must use P-256")
+		}
+		s.alg = "ES256"
+		s.jwk = map[string]string{"kty": "EC", "crv": "P-256", "x": b64(pub.X.FillBytes(make([]byte, 32))), "y": b64(pub.Y.FillBytes(make([]byte, 32)))}
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < 2048 {
+			return nil, errors.New("RSA signing keys must have at least 2048 bits")
+		}
+		s.alg = "RS256"
+		s.jwk = map[string]string{"kty": "RSA", "e": b64(big.NewInt(int64(pub.E)).Bytes()), "n": b64(pub.N.Bytes())}
+	case ed25519.PublicKey:
+		s.alg = "EdDSA"
+		s.jwk = map[string]string{"kty": "OKP", "crv": "Ed25519", "x": b64(pub)}
+	default:
+		return nil, errors.New("unsupported signing key type")
+	}
+
+	// The thumbprint hashes the required members in lexicographic order,
+	// which is how encoding/json serializes a map
+	thumbprint, _ := json.Marshal(s.jwk)
+	sum := sha256.Sum256(thumbprint)
+	s.kid = b64(sum[:])
+	return s, nil
+}
+
+// sign returns the detached JWS of a payload
+func (s *responseSigner) sign(payload []byte) (string, error) {
+	header, _ := json.Marshal(map[string]string{"alg": s.alg, "kid": s.kid})
+	input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	var signature []byte
+	var err error
+	switch s.alg {
+	case "EdDSA":
+		signature, err = s.key.Sign(rand.Reader, []byte(input), crypto.Hash(0))
+	case "ES256":
+		digest := sha256.Sum256([]byte(input))
+		var r, t *big.Int
+		if r, t, err = ecdsa.Sign(rand.Reader, s.key.(*ecdsa.PrivateKey), digest[:]); err == nil {
+			// JWS uses the fixed-width R || S encoding, not ASN.1
+			signature = append(r.FillBytes(make([]byte, 32)), t.FillBytes(make([]byte, 32))...)
+		}
+	default:
+		digest := sha256.Sum256([]byte(input))
+		signature, err = s.key.Sign(rand.Reader, digest[:], crypto.SHA256)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	protected, _, _ := strings.Cut(input, ".")
+	return protected + ".." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// isSignedPath reports whether responses for a path are signed
+func isSignedPath(path string) bool {
+	for _, p := range signedPaths {
+		if path == p || (strings.HasSuffix(p, "/") && strings.HasPrefix(path, p)) {
+			return true
+		}
+	}
+	return false
+}
+
+// signingWriter buffers a response so its body can be signed before sending
+type signingWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *signingWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+}
+
+func (w *signingWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(b)
+}
+
+// signingMiddleware adds the X-JWS-Signature header to successful responses
+// on signed paths when a signing key is configured
+func (s *Server) signingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.signer == nil || r.Method != http.MethodGet || !isSignedPath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		recorder := &signingWriter{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+		if recorder.status == http.StatusOK {
+			signature, err := s.signer.sign(recorder.body.Bytes())
+			if err != nil {
+				http.Error(w, "failed to sign response", http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set(signatureHeader, signature)
+		}
+		w.WriteHeader(recorder.status)
+		w.Write(recorder.body.Bytes())
+	})
+}
+
+// handleSigningKeys publishes the response signing key as a JWK Set
+// GET /api/signing-keys
+func (s *Server) handleSigningKeys(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.signer == nil {
+		http.Error(w, "response signing is not enabled", http.StatusNotFound)
+		return
+	}
+
+	jwk := map[string]string{"kid": s.signer.kid, "alg": s.signer.alg, "use": "sig"}
+	for k, v := range s.signer.jwk {
+		jwk[k] = v
+	}
+	w.Header().Set("Content-Type", "application/jwk-set+json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{jwk}})
+}
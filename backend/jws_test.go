@@ -0,0 +1,159 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// verifyDetachedJWS checks a detached JWS over body with the public key, as
+// a downstream verifier would
+func verifyDetachedJWS(t *testing.T, jws string, body []byte, public crypto.PublicKey) bool {
+	t.Helper()
+	protected, signature, ok := strings.Cut(jws, "..")
+	if !ok {
+		t.Fatalf("Expected detached JWS, got %q", jws)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil {
+		t.Fatalf("Invalid signature encoding: %v", err)
+	}
+	input := []byte(protected + "." + base64.RawURLEncoding.EncodeToString(body))
+	digest := sha256.Sum256(input)
+
+	switch key := public.(type) {
+	case *ecdsa.PublicKey:
+		return len(sig) == 64 && ecdsa.Verify(key, digest[:], new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:]))
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig) == nil
+	case ed25519.PublicKey:
+		return ed25519.Verify(key, input, sig)
+	}
+	return false
+}
+
+// TestResponseSignerSign tests that signatures verify for every key type
+func TestResponseSignerSign(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	body := []byte(`{"workloads":[]}`)
+	for _, key := range []crypto.Signer{ecKey, rsaKey, edKey} {
+		signer, err := newResponseSigner(key)
+		if err != nil {
+			t.Fatalf("Failed to create signer: %v", err)
+		}
+		jws, err := signer.sign(body)
+		if err != nil {
+			t.Fatalf("Failed to sign with %s: %v", signer.alg, err)
+		}
+		if !verifyDetachedJWS(t, jws, body, key.Public()) {
+			t.Errorf("Expected %s signature to verify", signer.alg)
+		}
+		if verifyDetachedJWS(t, jws, []byte(`{"workloads":null}`), key.Public()) {
+			t.Errorf("Expected %s signature to fail for a modified body", signer.alg)
+		}
+
+		header, _ := base64.RawURLEncoding.DecodeString(strings.Split(jws, ".")[0])
+		var fields map[string]string
+		json.Unmarshal(header, &fields)
+		if fields["alg"] != signer.alg || fields["kid"] != signer.kid {
+			t.Errorf("Unexpected protected header %s", header)
+		}
+	}
+
+	weakKey, _ := rsa.GenerateKey(rand.Reader, 1024)
+	p384Key, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	for _, key := range []crypto.Signer{weakKey, p384Key} {
+		if _, err := newResponseSigner(key); err == nil {
+			t.Errorf("Expected %T key to be rejected", key.Public())
+		}
+	}
+}
+
+// TestLoadResponseSigner tests loading PEM keys and the RFC 7638 thumbprint
+func TestLoadResponseSigner(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, _ := x509.MarshalECPrivateKey(key)
+	path := filepath.Join(t.TempDir(), "signing.pem")
+	os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0600)
+
+	signer, err := loadResponseSigner(path)
+	if err != nil {
+		t.Fatalf("Failed to load key: %v", err)
+	}
+	b64 := base64.RawURLEncoding.EncodeToString
+	thumbprint := `{"crv":"P-256","kty":"EC","x":"` + b64(key.X.FillBytes(make([]byte, 32))) + `","y":"` + b64(key.Y.FillBytes(make([]byte, 32))) + `"}`
+	sum := sha256.Sum256([]byte(thumbprint))
+	if signer.kid != b64(sum[:]) {
+		t.Errorf("Expected thumbprint kid %s, got %s", b64(sum[:]), signer.kid)
+	}
+
+	os.WriteFile(path, []byte("not a key"), 0600)
+	if _, err := loadResponseSigner(path); err == nil {
+		t.Error("Expected invalid key file to be rejected")
+	}
+}
+
+// TestSigningMiddleware tests that status responses carry a verifiable
+// signature and other responses are passed through unsigned
+func TestSigningMiddleware(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	signer, _ := newResponseSigner(key)
+	server := &Server{
+		signer:      signer,
+		statusCache: map[string]*WorkloadStatus{"icu/ai-model": failedStatus("icu", "ai-model")},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/status", server.handleStatus)
+	mux.HandleFunc("/api/workloads", server.handleWorkloads)
+	mux.HandleFunc("/api/reports/raw/", server.handleRawReport)
+	handler := server.signingMiddleware(mux)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/status", nil))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("Expected JSON 200, got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	if !verifyDetachedJWS(t, w.Header().Get(signatureHeader), w.Body.Bytes(), key.Public()) {
+		t.Error("Expected status response signature to verify")
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/workloads", nil))
+	if w.Header().Get(signatureHeader) != "" {
+		t.Error("Expected workloads response to be unsigned")
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/reports/raw/missing", nil))
+	if w.Code != http.StatusNotFound || w.Header().Get(signatureHeader) != "" {
+		t.Errorf("Expected unsigned 404, got %d with %q", w.Code, w.Header().Get(signatureHeader))
+	}
+
+	w = httptest.NewRecorder()
+	server.handleSigningKeys(w, httptest.NewRequest("GET", "/api/signing-keys", nil))
+	var jwks struct {
+		Keys []map[string]string `json:"keys"`
+	}
+	json.NewDecoder(w.Body).Decode(&jwks)
+	if len(jwks.Keys) != 1 || jwks.Keys[0]["kid"] != signer.kid || jwks.Keys[0]["alg"] != "ES256" || jwks.Keys[0]["x"] == "" {
+		t.Errorf("Unexpected JWK set %+v", jwks)
+	}
+}
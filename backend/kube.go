@@ -0,0 +1,128 @@
+package main
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// kubeClient is a minimal Kubernetes API client used to enrich Collector
+// reports with pod metadata (stdlib only, no client-go)
+type kubeClient struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+// kubePod is the subset of the Pod object the dashboard cares about
+type kubePod struct {
+	Metadata struct {
+		Labels map[string]string `json:"labels"`
+	} `json:"metadata"`
+	Spec struct {
+		NodeName string `json:"nodeName"`
+	} `json:"spec"`
+	Status struct {
+		StartTime         time.Time             `json:"startTime"`
+		ContainerStatuses []kubeContainerStatus `json:"containerStatuses"`
+	} `json:"status"`
+}
+
+// kubeContainerStatus is the subset of a container's status used for
+// restart correlation
+type kubeContainerStatus struct {
+	Name         string `json:"name"`
+	RestartCount int    `json:"restartCount"`
+	ImageID      string `json:"imageID"`
+	State        struct {
+		Running *struct {
+			StartedAt time.Time `json:"startedAt"`
+		} `json:"running,omitempty"`
+	} `json:"state"`
+}
+
+// newInClusterKubeClient builds a client from the pod's service account
+func newInClusterKubeClient() (*kubeClient, error) {
+	host := os.Getenv("KUBERNETES_SERVICE_HOST")
+	port := os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, fmt.Errorf("not running in a Kubernetes cluster")
+	}
+
+	token, err := os.ReadFile(serviceAccountDir + "/token")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account token: %w", err)
+	}
+
+	caPEM, err := os.ReadFile(serviceAccountDir + "/ca.crt")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no certificates found in service account CA")
+	}
+	tlsConfig := newTLSConfig()
+	tlsConfig.RootCAs = pool
+
+	return &kubeClient{
+		baseURL: "https://" + host + ":" + port,
+		token:   strings.TrimSpace(string(token)),
+		httpClient: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		},
+	}, nil
+}
+
+// get fetches a Kubernetes API path and decodes the JSON response into v
+func (k *kubeClient) get(path string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, k.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	if k.token != "" {
+		req.Header.Set("Authorization", "Bearer "+k.token)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := k.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("kubernetes API returned status %d for %s", resp.StatusCode, path)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// getPod fetches a single pod by namespace and name
+func (k *kubeClient) getPod(namespace, name string) (*kubePod, error) {
+	var pod kubePod
+	path := fmt.Sprintf("/api/v1/namespaces/%s/pods/%s", url.PathEscape(namespace), url.PathEscape(name))
+	if err := k.get(path, &pod); err != nil {
+		return nil, err
+	}
+	return &pod, nil
+}
+
+// podImageDigests returns the distinct image digests a pod's containers run
+func podImageDigests(pod *kubePod) []string {
+	var digests []string
+	for _, cs := range pod.Status.ContainerStatuses {
+		if digest := imageDigest(cs.ImageID); digest != "" && !containsString(digests, digest) {
+			digests = append(digests, digest)
+		}
+	}
+	return digests
+}
@@ -0,0 +1,511 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ldapTimeout bounds a whole LDAP login: connect, bind and group lookup
+const ldapTimeout = 10 * time.Second
+
+// ldapCacheTTL is how long a successful login is reused before the directory
+// is asked again, so a dashboard polling every few seconds doesn't bind on
+// every request
+const ldapCacheTTL = time.Minute
+
+// ldapMaxMessage bounds the size of a single LDAP response
+const ldapMaxMessage = 1 << 20
+
+// LDAPConfig configures LDAP/Active Directory bind authentication
+type LDAPConfig struct {
+	URL      string `json:"url"`                 // ldap://host:389 or ldaps://host:636
+	StartTLS bool   `json:"start_tls,omitempty"` // upgrade ldap:// connections before sending credentials
+	CAFile   string `json:"ca_file,omitempty"`   // CA bundle for the directory's certificate
+	// BindDN is the DN users bind as, with %s replaced by the username, e.g.
+	// "uid=%s,ou=people,dc=hospital,dc=org" or "%s@HOSPITAL.LOCAL" for AD
+	BindDN string `json:"bind_dn"`
+	// BaseDN is searched for the user's entry to read their groups
+	BaseDN         string `json:"base_dn"`
+	UserAttribute  string `json:"user_attribute,omitempty"`  // attribute holding the username, default sAMAccountName
+	GroupAttribute string `json:"group_attribute,omitempty"` // attribute listing group DNs, default memberOf
+	// GroupRoles maps group DNs to the roles granted to their members.
+	// DNs are compared case-insensitively and must otherwise match exactly.
+	GroupRoles map[string][]string `json:"group_roles,omitempty"`
+}
+
+// ldapAuthenticator checks HTTP Basic credentials by binding to a directory
+type ldapAuthenticator struct {
+	config    LDAPConfig
+	address   string
+	tlsConfig *tls.Config // nil for plain ldap:// without StartTLS
+	implicit  bool        // ldaps:// - TLS from the first byte
+
+	salt  []byte // keys the login cache, so it holds no unsalted password hashes
+	mu    sync.Mutex
+	cache map[[32]byte]ldapLogin
+}
+
+type ldapLogin struct {
+	identity *Identity
+	expires  time.Time
+}
+
+// loadLDAPAuthenticator reads the LDAP configuration from a JSON file
+func loadLDAPAuthenticator(path string) (*ldapAuthenticator, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var config LDAPConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("invalid LDAP config: %w", err)
+	}
+	return newLDAPAuthenticator(config)
+}
+
+// newLDAPAuthenticator validates an LDAP configuration
+func newLDAPAuthenticator(config LDAPConfig) (*ldapAuthenticator, error) {
+	if config.URL == "" || config.BindDN == "" || config.BaseDN == "" {
+		return nil, errors.New("url, bind_dn and base_dn are required")
+	}
+	if strings.Count(config.BindDN, "%s") != 1 {
+		return nil, errors.New("bind_dn must contain %s exactly once")
+	}
+	if config.UserAttribute == "" {
+		config.UserAttribute = "sAMAccountName"
+	}
+	if config.GroupAttribute == "" {
+		config.GroupAttribute = "memberOf"
+	}
+	groupRoles := make(map[string][]string, len(config.GroupRoles))
+	for group, roles := range config.GroupRoles {
+		groupRoles[strings.ToLower(group)] = roles
+	}
+	config.GroupRoles = groupRoles
+
+	u, err := url.Parse(config.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	a := &ldapAuthenticator{config: config, cache: make(map[[32]byte]ldapLogin)}
+	port := ""
+	switch u.Scheme {
+	case "ldap":
+		port = "389"
+	case "ldaps":
+		port = "636"
+		a.implicit = true
+	default:
+		return nil, fmt.Errorf("unsupported url scheme %q", u.Scheme)
+	}
+	if config.StartTLS && a.implicit {
+		return nil, errors.New("start_tls applies to ldap:// urls only")
+	}
+	a.address = u.Host
+	if u.Port() == "" {
+		a.address = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	if a.implicit || config.StartTLS {
+		a.tlsConfig = newTLSConfig()
+		a.tlsConfig.ServerName = u.Hostname()
+		a.tlsConfig.MinVersion = tls.VersionTLS12
+		if config.CAFile != "" {
+			caPEM, err := os.ReadFile(config.CAFile)
+			if err != nil {
+				return nil, err
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(caPEM) {
+				return nil, fmt.Errorf("no certificates in %s", config.CAFile)
+			}
+			a.tlsConfig.RootCAs = pool
+		}
+	} else {
+		log.Printf("Warning: LDAP credentials are sent to %s unencrypted - use ldaps:// or start_tls", a.address)
+	}
+
+	a.salt = make([]byte, 32)
+	if _, err := rand.Read(a.salt); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// authenticate binds as the user and maps their groups to roles
+func (a *ldapAuthenticator) authenticate(username, password string) (*Identity, error) {
+	if !validLDAPUsername(username) {
+		return nil, fmt.Errorf("invalid username %q", username)
+	}
+	// An empty password would be an unauthenticated bind, which many
+	// directories accept for any DN
+	if password == "" {
+		return nil, errors.New("empty password")
+	}
+
+	key := a.cacheKey(username, password)
+	now := time.Now()
+	a.mu.Lock()
+	login, ok := a.cache[key]
+	a.mu.Unlock()
+	if ok && now.Before(login.expires) {
+		return login.identity, nil
+	}
+
+	groups, err := a.lookup(username, password)
+	if err != nil {
+		return nil, err
+	}
+
+	identity := &Identity{Name: username}
+	for _, group := range groups {
+		for _, role := range a.config.GroupRoles[strings.ToLower(group)] {
+			if !containsString(identity.Roles, role) {
+				identity.Roles = append(identity.Roles, role)
+			}
+		}
+	}
+
+	a.mu.Lock()
+	for k, l := range a.cache {
+		if now.After(l.expires) {
+			delete(a.cache, k)
+		}
+	}
+	a.cache[key] = ldapLogin{identity: identity, expires: now.Add(ldapCacheTTL)}
+	a.mu.Unlock()
+	return identity, nil
+}
+
+func (a *ldapAuthenticator) cacheKey(username, password string) [32]byte {
+	h := sha256.New()
+	h.Write(a.salt)
+	h.Write([]byte(username))
+	h.Write([]byte{0})
+	h.Write([]byte(password))
+	var key [32]byte
+	copy(key[:], h.Sum(nil))
+	return key
+}
+
+// lookup binds as the user and returns the groups listed on their entry
+func (a *ldapAuthenticator) lookup(username, password string) ([]string, error) {
+	conn, err := net.DialTimeout("tcp", a.address, ldapTimeout)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(ldapTimeout))
+	if a.implicit {
+		conn = tls.Client(conn, a.tlsConfig)
+	}
+	c := &ldapConn{conn: conn, r: bufio.NewReader(conn)}
+	defer c.close()
+
+	if a.config.StartTLS {
+		if err := c.startTLS(a.tlsConfig); err != nil {
+			return nil, fmt.Errorf("StartTLS failed: %w", err)
+		}
+	}
+
+	if err := c.bind(strings.Replace(a.config.BindDN, "%s", username, 1), password); err != nil {
+		return nil, err
+	}
+	return c.searchAttribute(a.config.BaseDN, a.config.UserAttribute, username, a.config.GroupAttribute)
+}
+
+// validLDAPUsername rejects usernames that could alter the bind DN
+func validLDAPUsername(username string) bool {
+	return username != "" && len(username) <= 256 && !strings.ContainsAny(username, ",+\"\\<>;=#*()\x00")
+}
+
+// LDAP protocol operations and result codes (RFC 4511)
+const (
+	ldapBindRequest       = 0x60
+	ldapBindResponse      = 0x61
+	ldapUnbindRequest     = 0x42
+	ldapSearchRequest     = 0x63
+	ldapSearchEntry       = 0x64
+	ldapSearchDone        = 0x65
+	ldapSearchReference   = 0x73
+	ldapExtendedRequest   = 0x77
+	ldapExtendedResponse  = 0x78
+	ldapEqualityFilter    = 0xa3
+	ldapSimpleAuth        = 0x80
+	ldapExtendedName      = 0x80
+	ldapStartTLSOID       = "1.3.6.1.4.1.1466.20037"
+	ldapSuccess           = 0
+	ldapInvalidCredential = 49
+)
+
+// BER universal tags
+const (
+	berBoolean     = 0x01
+	berInteger     = 0x02
+	berOctetString = 0x04
+	berEnumerated  = 0x0a
+	berSequence    = 0x30
+	berSet         = 0x31
+)
+
+// ldapConn is a minimal LDAPv3 client connection: simple bind, StartTLS and
+// an equality search are all the dashboard needs
+type ldapConn struct {
+	conn      net.Conn
+	r         *bufio.Reader
+	messageID int
+}
+
+func (c *ldapConn) send(op []byte) error {
+	c.messageID++
+	_, err := c.conn.Write(berEncode(berSequence, berInt(berInteger, c.messageID), op))
+	return err
+}
+
+// receive returns the protocol operation of the next message
+func (c *ldapConn) receive() (berElement, error) {
+	message, err := readBER(c.r)
+	if err != nil {
+		return berElement{}, err
+	}
+	if message.tag != berSequence || len(message.children) < 2 {
+		return berElement{}, errors.New("malformed LDAP message")
+	}
+	return message.children[1], nil
+}
+
+// result checks the LDAPResult of a response operation
+func ldapResult(op berElement, tag byte) error {
+	if op.tag != tag || len(op.children) < 3 {
+		return fmt.Errorf("unexpected LDAP response 0x%02x", op.tag)
+	}
+	code := op.children[0].int()
+	switch code {
+	case ldapSuccess:
+		return nil
+	case ldapInvalidCredential:
+		return errors.New("invalid credentials")
+	}
+	if message := string(op.children[2].value); message != "" {
+		return fmt.Errorf("LDAP result %d: %s", code, message)
+	}
+	return fmt.Errorf("LDAP result %d", code)
+}
+
+func (c *ldapConn) startTLS(config *tls.Config) error {
+	if err := c.send(berEncode(ldapExtendedRequest, berString(ldapExtendedName, ldapStartTLSOID))); err != nil {
+		return err
+	}
+	op, err := c.receive()
+	if err != nil {
+		return err
+	}
+	if err := ldapResult(op, ldapExtendedResponse); err != nil {
+		return err
+	}
+	tlsConn := tls.Client(c.conn, config)
+	if err := tlsConn.Handshake(); err != nil {
+		return err
+	}
+	c.conn = tlsConn
+	c.r = bufio.NewReader(tlsConn)
+	return nil
+}
+
+func (c *ldapConn) bind(dn, password string) error {
+	err := c.send(berEncode(ldapBindRequest,
+		berInt(berInteger, 3),
+		berString(berOctetString, dn),
+		berString(ldapSimpleAuth, password)))
+	if err != nil {
+		return err
+	}
+	op, err := c.receive()
+	if err != nil {
+		return err
+	}
+	return ldapResult(op, ldapBindResponse)
+}
+
+// searchAttribute finds the single entry under base whose attr equals value
+// and returns the values of its returned attribute
+func (c *ldapConn) searchAttribute(base, attr, value, returned string) ([]string, error) {
+	err := c.send(berEncode(ldapSearchRequest,
+		berString(berOctetString, base),
+		berInt(berEnumerated, 2), // wholeSubtree
+		berInt(berEnumerated, 0), // neverDerefAliases
+		berInt(berInteger, 2),    // size limit - more than one match is an error anyway
+		berInt(berInteger, int(ldapTimeout/time.Second)),
+		berEncode(berBoolean, []byte{0}),
+		berEncode(ldapEqualityFilter, berString(berOctetString, attr), berString(berOctetString, value)),
+		berEncode(berSequence, berString(berOctetString, returned))))
+	if err != nil {
+		return nil, err
+	}
+
+	var entries int
+	var values []string
+	for {
+		op, err := c.receive()
+		if err != nil {
+			return nil, err
+		}
+		switch op.tag {
+		case ldapSearchEntry:
+			entries++
+			if len(op.children) < 2 {
+				return nil, errors.New("malformed search entry")
+			}
+			for _, attribute := range op.children[1].children {
+				if len(attribute.children) < 2 || !strings.EqualFold(string(attribute.children[0].value), returned) {
+					continue
+				}
+				for _, v := range attribute.children[1].children {
+					values = append(values, string(v.value))
+				}
+			}
+		case ldapSearchReference:
+			// Referrals to other directories are not followed
+		case ldapSearchDone:
+			if entries > 1 {
+				return nil, fmt.Errorf("%s=%s matches %d entries", attr, value, entries)
+			}
+			if err := ldapResult(op, ldapSearchDone); err != nil {
+				return nil, err
+			}
+			if entries == 0 {
+				return nil, fmt.Errorf("no entry with %s=%s under %s", attr, value, base)
+			}
+			return values, nil
+		default:
+			return nil, fmt.Errorf("unexpected LDAP response 0x%02x", op.tag)
+		}
+	}
+}
+
+func (c *ldapConn) close() {
+	c.send(berEncode(ldapUnbindRequest))
+	c.conn.Close()
+}
+
+// berElement is a decoded BER element. Constructed elements have children,
+// primitive ones a value.
+type berElement struct {
+	tag      byte
+	value    []byte
+	children []berElement
+}
+
+// int decodes an INTEGER or ENUMERATED value
+func (e berElement) int() int {
+	n := 0
+	for i, b := range e.value {
+		if i == 0 && b&0x80 != 0 {
+			n = -1
+		}
+		n = n<<8 | int(b)
+	}
+	return n
+}
+
+// berEncode encodes an element from its tag and concatenated contents
+func berEncode(tag byte, contents ...[]byte) []byte {
+	var content []byte
+	for _, c := range contents {
+		content = append(content, c...)
+	}
+
+	out := []byte{tag}
+	switch n := len(content); {
+	case n < 0x80:
+		out = append(out, byte(n))
+	case n <= 0xff:
+		out = append(out, 0x81, byte(n))
+	case n <= 0xffff:
+		out = append(out, 0x82, byte(n>>8), byte(n))
+	default:
+		out = append(out, 0x84, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	}
+	return append(out, content...)
+}
+
+func berString(tag byte, s string) []byte {
+	return berEncode(tag, []byte(s))
+}
+
+// berInt encodes a non-negative integer in its minimal two's complement form
+func berInt(tag byte, n int) []byte {
+	content := []byte{byte(n)}
+	for n >>= 8; n > 0; n >>= 8 {
+		content = append([]byte{byte(n)}, content...)
+	}
+	if content[0]&0x80 != 0 {
+		content = append([]byte{0}, content...)
+	}
+	return berEncode(tag, content)
+}
+
+// readBER reads one element from a stream
+func readBER(r *bufio.Reader) (berElement, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return berElement{}, err
+	}
+	length := int(header[1])
+	if length&0x80 != 0 {
+		n := length & 0x7f
+		if n == 0 || n > 4 {
+			return berElement{}, errors.New("unsupported BER length")
+		}
+		lengthBytes := make([]byte, n)
+		if _, err := io.ReadFull(r, lengthBytes); err != nil {
+			return berElement{}, err
+		}
+		length = 0
+		for _, b := range lengthBytes {
+			length = length<<8 | int(b)
+		}
+	}
+	if length > ldapMaxMessage {
+		return berElement{}, fmt.Errorf("LDAP message of %d bytes exceeds limit", length)
+	}
+	content := make([]byte, length)
+	if _, err := io.ReadFull(r, content); err != nil {
+		return berElement{}, err
+	}
+	return berDecodeContent(header[0], content)
+}
+
+// berDecodeContent decodes an element's content, recursing into constructed ones
+func berDecodeContent(tag byte, content []byte) (berElement, error) {
+	element := berElement{tag: tag, value: content}
+	if tag&0x20 == 0 {
+		return element, nil
+	}
+	r := bufio.NewReader(bytes.NewReader(content))
+	for {
+		if _, err := r.Peek(1); err == io.EOF {
+			return element, nil
+		}
+		child, err := readBER(r)
+		if err != nil {
+			return berElement{}, fmt.Errorf("malformed BER element: %w", err)
+		}
+		element.children = append(element.children, child)
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// fakeLDAP is a directory serving simple binds and equality searches for a
+// fixed set of users
+type fakeLDAP struct {
+	listener  net.Listener
+	passwords map[string]string   // bind DN -> password
+	groups    map[string][]string // username -> memberOf
+	binds     int32
+}
+
+func newFakeLDAP(t *testing.T) *fakeLDAP {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	f := &fakeLDAP{
+		listener:  listener,
+		passwords: map[string]string{"uid=raj,ou=people,dc=hospital,dc=org": "secret"},
+		groups:    map[string][]string{"raj": {"CN=Dashboard Admins,OU=Groups,DC=hospital,DC=org", "cn=nurses,ou=groups,dc=hospital,dc=org"}},
+	}
+	go f.serve()
+	t.Cleanup(func() { listener.Close() })
+	return f
+}
+
+func (f *fakeLDAP) serve() {
+	for {
+		conn, err := f.listener.Accept()
+		if err != nil {
+			return
+		}
+		go f.handle(conn)
+	}
+}
+
+func ldapTestResult(tag byte, code int) []byte {
+	return berEncode(tag, berInt(berEnumerated, code), berString(berOctetString, ""), berString(berOctetString, ""))
+}
+
+func (f *fakeLDAP) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(id int, op []byte) { conn.Write(berEncode(berSequence, berInt(berInteger, id), op)) }
+
+	for {
+		message, err := readBER(r)
+		if err != nil {
+			return
+		}
+		id, op := message.children[0].int(), message.children[1]
+		switch op.tag {
+		case ldapBindRequest:
+			atomic.AddInt32(&f.binds, 1)
+			dn, password := string(op.children[1].value), string(op.children[2].value)
+			code := ldapInvalidCredential
+			if want, ok := f.passwords[dn]; ok && want == password {
+				code = ldapSuccess
+			}
+			reply(id, ldapTestResult(ldapBindResponse, code))
+		case ldapSearchRequest:
+			filter := op.children[6]
+			username := string(filter.children[1].value)
+			if groups, ok := f.groups[username]; ok {
+				var values [][]byte
+				for _, g := range groups {
+					values = append(values, berString(berOctetString, g))
+				}
+				reply(id, berEncode(ldapSearchEntry,
+					berString(berOctetString, "uid="+username+",ou=people,dc=hospital,dc=org"),
+					berEncode(berSequence, berEncode(berSequence,
+						berString(berOctetString, "memberOf"),
+						berEncode(berSet, values...)))))
+			}
+			reply(id, ldapTestResult(ldapSearchDone, ldapSuccess))
+		case ldapUnbindRequest:
+			return
+		}
+	}
+}
+
+func newTestLDAPAuthenticator(t *testing.T, f *fakeLDAP) *ldapAuthenticator {
+	t.Helper()
+	a, err := newLDAPAuthenticator(LDAPConfig{
+		URL:           "ldap://" + f.listener.Addr().String(),
+		BindDN:        "uid=%s,ou=people,dc=hospital,dc=org",
+		BaseDN:        "dc=hospital,dc=org",
+		UserAttribute: "uid",
+		GroupRoles:    map[string][]string{"cn=dashboard admins,ou=groups,dc=hospital,dc=org": {adminRole, "operator"}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create LDAP authenticator: %v", err)
+	}
+	return a
+}
+
+// TestLDAPAuthenticate tests binding, group-to-role mapping and the login cache
+func TestLDAPAuthenticate(t *testing.T) {
+	f := newFakeLDAP(t)
+	a := newTestLDAPAuthenticator(t, f)
+
+	identity, err := a.authenticate("raj", "secret")
+	if err != nil {
+		t.Fatalf("Expected successful login, got %v", err)
+	}
+	if identity.Name != "raj" || !identity.hasRole(adminRole) || !identity.hasRole("operator") || len(identity.Roles) != 2 {
+		t.Errorf("Expected raj with admin and operator roles, got %+v", identity)
+	}
+
+	if _, err := a.authenticate("raj", "secret"); err != nil {
+		t.Errorf("Expected cached login, got %v", err)
+	}
+	if binds := atomic.LoadInt32(&f.binds); binds != 1 {
+		t.Errorf("Expected 1 bind with the login cached, got %d", binds)
+	}
+
+	if _, err := a.authenticate("raj", "wrong"); err == nil || !strings.Contains(err.Error(), "invalid credentials") {
+		t.Errorf("Expected invalid credentials, got %v", err)
+	}
+	if _, err := a.authenticate("raj", ""); err == nil {
+		t.Error("Expected empty password to be rejected")
+	}
+	if _, err := a.authenticate("raj,ou=admins", "secret"); err == nil {
+		t.Error("Expected username with DN syntax to be rejected")
+	}
+}
+
+// TestLDAPConfigValidation tests that incomplete configurations are rejected
+func TestLDAPConfigValidation(t *testing.T) {
+	configs := []LDAPConfig{
+		{URL: "ldap://ad:389", BaseDN: "dc=hospital"},
+		{URL: "ldap://ad:389", BindDN: "uid=raj", BaseDN: "dc=hospital"},
+		{URL: "http://ad", BindDN: "uid=%s", BaseDN: "dc=hospital"},
+		{URL: "ldaps://ad", BindDN: "uid=%s", BaseDN: "dc=hospital", StartTLS: true},
+	}
+	for _, config := range configs {
+		if _, err := newLDAPAuthenticator(config); err == nil {
+			t.Errorf("Expected config %+v to be rejected", config)
+		}
+	}
+}
+
+// TestLDAPAuthMiddleware tests that Basic credentials are accepted alongside
+// bearer tokens when LDAP is configured
+func TestLDAPAuthMiddleware(t *testing.T) {
+	f := newFakeLDAP(t)
+	server := &Server{auth: &Authenticator{ldap: newTestLDAPAuthenticator(t, f)}}
+
+	var seen *Identity
+	handler := server.authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = identityFromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest("GET", "/api/status", nil)
+	req.SetBasicAuth("raj", "secret")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || seen == nil || seen.Name != "raj" {
+		t.Errorf("Expected raj to be authenticated, got %d %+v", w.Code, seen)
+	}
+
+	req = httptest.NewRequest("GET", "/api/status", nil)
+	req.SetBasicAuth("raj", "wrong")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401, got %d", w.Code)
+	}
+	if challenges := w.Header().Values("WWW-Authenticate"); len(challenges) != 2 {
+		t.Errorf("Expected Bearer and Basic challenges, got %v", challenges)
+	}
+}
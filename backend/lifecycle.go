@@ -0,0 +1,41 @@
+package main
+
+import "time"
+
+// Workload lifecycle states. A workload that disappears from a synced
+// Collector stays "terminating" for the grace period (WORKLOAD_GRACE_PERIOD)
+// with its last known status, then is dropped with a "removed" event. A
+// scale-down thus shows as a terminating workload that goes away, while a
+// reporting gap shorter than the grace period shows as nothing at all.
+const (
+	lifecycleActive      = "active"
+	lifecycleTerminating = "terminating"
+	lifecycleRemoved     = "removed"
+)
+
+// retainVanished keeps workloads of synced clusters that are missing from
+// the new cache as terminating, until the grace period has passed since they
+// were last reported
+func (s *Server) retainVanished(cache map[string]*WorkloadStatus, synced map[string]bool, now time.Time) {
+	if s.gracePeriod <= 0 {
+		return
+	}
+
+	for key, prev := range s.statusCache {
+		if _, ok := cache[key]; ok || !synced[prev.Cluster] {
+			continue
+		}
+		lastSeen := prev.LastChecked
+		if prev.LastSeen != nil {
+			lastSeen = *prev.LastSeen
+		}
+		if now.Sub(lastSeen) >= s.gracePeriod {
+			continue
+		}
+
+		status := copyStatus(prev)
+		status.Lifecycle = lifecycleTerminating
+		status.LastSeen = &lastSeen
+		cache[key] = status
+	}
+}
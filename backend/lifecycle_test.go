@@ -0,0 +1,76 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestWorkloadGracePeriod tests that a vanished workload stays terminating
+// for the grace period before it is removed, and that a workload reported
+// again within the grace period becomes active without a removal
+func TestWorkloadGracePeriod(t *testing.T) {
+	server := &Server{
+		statusCache: make(map[string]*WorkloadStatus),
+		gracePeriod: time.Hour,
+	}
+	synced := map[string]bool{"": true}
+	reported := func(name string) *WorkloadStatus {
+		status := verifiedStatus("icu", name)
+		status.LastChecked = time.Now()
+		return status
+	}
+
+	events := server.applyStatuses([]*WorkloadStatus{reported("a"), reported("b")}, synced, nil)
+	if len(events) != 2 || server.statusCache["icu/a"].Lifecycle != lifecycleActive {
+		t.Fatalf("Expected 2 active workloads, got %+v", events)
+	}
+
+	// b disappears - terminating, not removed
+	events = server.applyStatuses([]*WorkloadStatus{reported("a")}, synced, nil)
+	if len(events) != 1 || events[0].Type != "terminating" || events[0].Key != "icu/b" {
+		t.Fatalf("Expected a terminating event for icu/b, got %+v", events)
+	}
+	b := server.statusCache["icu/b"]
+	if b == nil || b.Lifecycle != lifecycleTerminating || b.LastSeen == nil || !b.Attested {
+		t.Fatalf("Expected icu/b terminating with its last status, got %+v", b)
+	}
+
+	// Still missing - no further event
+	if events = server.applyStatuses([]*WorkloadStatus{reported("a")}, synced, nil); len(events) != 0 {
+		t.Errorf("Expected no events within the grace period, got %+v", events)
+	}
+
+	// Reported again - back to active
+	events = server.applyStatuses([]*WorkloadStatus{reported("a"), reported("b")}, synced, nil)
+	if len(events) != 1 || events[0].Type != "changed" || server.statusCache["icu/b"].Lifecycle != lifecycleActive {
+		t.Errorf("Expected icu/b back to active, got %+v", events)
+	}
+
+	// Gone past the grace period - removed
+	server.applyStatuses([]*WorkloadStatus{reported("a")}, synced, nil)
+	lastSeen := time.Now().Add(-2 * time.Hour)
+	server.statusCache["icu/b"].LastSeen = &lastSeen
+	events = server.applyStatuses([]*WorkloadStatus{reported("a")}, synced, nil)
+	if len(events) != 1 || events[0].Type != "removed" || events[0].Status.Lifecycle != lifecycleRemoved {
+		t.Fatalf("Expected icu/b removed, got %+v", events)
+	}
+	if !events[0].Status.LastSeen.Equal(lastSeen) {
+		t.Errorf("Expected last seen %s, got %s", lastSeen, events[0].Status.LastSeen)
+	}
+	if _, ok := server.statusCache["icu/b"]; ok {
+		t.Error("Expected icu/b dropped from the cache")
+	}
+}
+
+// TestWorkloadNoGracePeriod tests that vanished workloads are removed at once
+// without a grace period
+func TestWorkloadNoGracePeriod(t *testing.T) {
+	server := &Server{statusCache: make(map[string]*WorkloadStatus)}
+	synced := map[string]bool{"": true}
+
+	server.applyStatuses([]*WorkloadStatus{verifiedStatus("icu", "a")}, synced, nil)
+	events := server.applyStatuses(nil, synced, nil)
+	if len(events) != 1 || events[0].Type != "removed" || events[0].Status.LastSeen == nil {
+		t.Errorf("Expected icu/a removed, got %+v", events)
+	}
+}
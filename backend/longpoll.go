@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxLongPollTimeout bounds how long /api/status/wait holds a request,
+// staying below common proxy idle timeouts
+const maxLongPollTimeout = 60 * time.Second
+
+// bumpGenerationLocked advances the cache generation and wakes long-poll
+// waiters. Caller must hold cacheMutex for writing.
+func (s *Server) bumpGenerationLocked() {
+	s.generation++
+	if s.generationChanged != nil {
+		close(s.generationChanged)
+	}
+	s.generationChanged = make(chan struct{})
+}
+
+// generationWatch returns the current cache generation and a channel that is
+// closed when it next changes
+func (s *Server) generationWatch() (uint64, <-chan struct{}) {
+	s.cacheMutex.Lock()
+	defer s.cacheMutex.Unlock()
+	if s.generationChanged == nil {
+		s.generationChanged = make(chan struct{})
+	}
+	return s.generation, s.generationChanged
+}
+
+// handleStatusWait is a long-polling variant of /api/status for clients
+// behind proxies that break WebSocket and SSE. It returns as soon as the
+// cache generation differs from ?since= (or changes, if since is omitted),
+// or with the unchanged status after ?timeout= (default 30s).
+// GET /api/status/wait?since=42&timeout=30s
+func (s *Server) handleStatusWait(w http.ResponseWriter, r *http.Request) {
+	timeout := 30 * time.Second
+	if raw := r.URL.Query().Get("timeout"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d <= 0 {
+			http.Error(w, "invalid timeout parameter", http.StatusBadRequest)
+			return
+		}
+		timeout = d
+	}
+	if timeout > maxLongPollTimeout {
+		timeout = maxLongPollTimeout
+	}
+
+	generation, changed := s.generationWatch()
+	if raw := r.URL.Query().Get("since"); raw != "" {
+		since, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			http.Error(w, "invalid since parameter", http.StatusBadRequest)
+			return
+		}
+		if since != generation {
+			s.handleStatus(w, r)
+			return
+		}
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-changed:
+	case <-timer.C:
+	case <-r.Context().Done():
+		return
+	}
+	s.handleStatus(w, r)
+}
+
+// writeStatusGeneration exposes the cache generation to clients so they can
+// resume long-polling with ?since=. Caller must hold cacheMutex.
+func (s *Server) writeStatusGeneration(w http.ResponseWriter, response DashboardResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Cache-Generation", strconv.FormatUint(s.generation, 10))
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestStatusWait tests that long-polling returns on a cache change, on a
+// stale ?since=, and after the timeout
+func TestStatusWait(t *testing.T) {
+	server := &Server{
+		statusCache: make(map[string]*WorkloadStatus),
+	}
+	synced := map[string]bool{"": true}
+	server.applyStatuses([]*WorkloadStatus{{Name: "a", Namespace: "icu", Attested: true}}, synced, nil)
+
+	// Stale generation - immediate response
+	req := httptest.NewRequest("GET", "/api/status/wait?since=0&timeout=10s", nil)
+	w := httptest.NewRecorder()
+	start := time.Now()
+	server.handleStatusWait(w, req)
+	if time.Since(start) > time.Second {
+		t.Error("Expected immediate response for a stale generation")
+	}
+	var response DashboardResponse
+	json.NewDecoder(w.Body).Decode(&response)
+	if response.Generation != 1 || w.Header().Get("X-Cache-Generation") != "1" {
+		t.Errorf("Expected generation 1, got %d (header %q)", response.Generation, w.Header().Get("X-Cache-Generation"))
+	}
+
+	// Current generation - returns when the cache changes
+	done := make(chan DashboardResponse)
+	go func() {
+		req := httptest.NewRequest("GET", "/api/status/wait?since=1&timeout=10s", nil)
+		w := httptest.NewRecorder()
+		server.handleStatusWait(w, req)
+		var response DashboardResponse
+		json.NewDecoder(w.Body).Decode(&response)
+		done <- response
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	server.applyStatuses([]*WorkloadStatus{{Name: "a", Namespace: "icu", Attested: false}}, synced, nil)
+
+	select {
+	case response := <-done:
+		if response.Generation != 2 || response.OverallStatus != "violation" {
+			t.Errorf("Expected generation 2 in violation, got %d %s", response.Generation, response.OverallStatus)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected long poll to return after the cache changed")
+	}
+
+	// No change - returns after the timeout
+	req = httptest.NewRequest("GET", "/api/status/wait?since=2&timeout=50ms", nil)
+	w = httptest.NewRecorder()
+	start = time.Now()
+	server.handleStatusWait(w, req)
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("Expected to wait for the timeout, returned after %s", elapsed)
+	}
+	if w.Code != 200 {
+		t.Errorf("Expected 200 on timeout, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest("GET", "/api/status/wait?timeout=forever", nil)
+	w = httptest.NewRecorder()
+	server.handleStatusWait(w, req)
+	if w.Code != 400 {
+		t.Errorf("Expected 400 for invalid timeout, got %d", w.Code)
+	}
+}
@@ -1,94 +1,687 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
 
-// WorkloadStatus represents the attestation status of a CoCo workload
-type WorkloadStatus struct {
-	Name              string    `json:"name"`
-	Namespace         string    `json:"namespace"`
-	Attested          bool      `json:"attested"`
-	AttestationStatus string    `json:"attestation_status"`
-	Timestamp         string    `json:"timestamp"`
-	Details           string    `json:"details"`
-	GateOneStatus     string    `json:"gate_one_status"`  // Code Integrity
-	GateTwoStatus     string    `json:"gate_two_status"`  // TEE Attestation
-	LastChecked       time.Time `json:"last_checked"`
-	TEEType           string    `json:"tee_type,omitempty"`
-}
-
-// DashboardResponse is the API response for the dashboard
-type DashboardResponse struct {
-	OverallStatus string           `json:"overall_status"` // "compliant" or "violation"
-	Workloads     []WorkloadStatus `json:"workloads"`
-	LastUpdated   time.Time        `json:"last_updated"`
-}
-
-// TrustVector represents EAR trust tier values from Collector
-type TrustVector struct {
-	InstanceIdentity int `json:"instance_identity"`
-	Configuration    int `json:"configuration"`
-	Executables      int `json:"executables"`
-	FileSystem       int `json:"file_system"`
-	Hardware         int `json:"hardware"`
-	RuntimeOpaque    int `json:"runtime_opaque"`
-	StorageOpaque    int `json:"storage_opaque"`
-	SourcedData      int `json:"sourced_data"`
-}
-
 // CollectorReport matches the Attestation Collector's report format
 type CollectorReport struct {
 	PodName     string       `json:"pod_name"`
 	Namespace   string       `json:"namespace"`
 	TEEType     string       `json:"tee_type,omitempty"`
+	TCBVersion  string       `json:"tcb_version,omitempty"`
+	NodeName    string       `json:"node_name,omitempty"`
+	Cluster     string       `json:"cluster,omitempty"`
 	Attested    bool         `json:"attested"`
 	TrustVector *TrustVector `json:"trust_vector,omitempty"`
 	EARToken    string       `json:"ear_token,omitempty"`
-	Timestamp   time.Time    `json:"timestamp"`
+	Timestamp   time.Time    `json:"timestamp" jsonschema:"optional"`
 	Error       string       `json:"error,omitempty"`
+	TraceID     string       `json:"trace_id,omitempty"` // trace of the attestation, linked from lag metric exemplars
+
+	raw       json.RawMessage // exact bytes received from the Collector
+	rawID     string          // content address in the raw report archive
+	source    string          // URL of the Collector the report was fetched from
+	malformed string          // why the report could not be decoded, if it couldn't
+
+	podStarted   time.Time // when the pod was started, from Kubernetes enrichment
+	restartCount int       // container restarts, from Kubernetes enrichment
+	lastRestart  time.Time // start of the most recently restarted container
+	imageDigests []string  // digests of the running container images
+	app          string    // app label of the pod, for the ownership directory
 }
 
 // Server holds the dashboard backend state
 type Server struct {
 	collectorURL string
-	statusCache  map[string]*WorkloadStatus
-	cacheMutex   sync.RWMutex
-	httpClient   *http.Client
-	pollInterval time.Duration
+	// secondaryCollectorURL is compared with collectorURL in single-cluster mode
+	secondaryCollectorURL string
+	// ar4siProfile selects the trust vector claims every report must carry
+	ar4siProfile string
+	// nodeAttestation enables host reports in single-cluster mode
+	nodeAttestation bool
+	statusCache     map[string]*WorkloadStatus
+	cacheMutex      sync.RWMutex
+	httpClient      *http.Client
+	pollInterval    time.Duration
+	schedule        *pollSchedule // per-namespace intervals; nil evaluates everything every poll
+	kube            *kubeClient
+	clusters        []ClusterConfig
+	localCluster    string
+	dashboardURL    string // external base URL (DASHBOARD_URL) for links; "" for relative links
+	siteName        string // SITE_NAME, identifying this dashboard in exports
+	clusterState    map[string]*clusterSyncState
+	nodeReports     map[string]NodeReport      // host attestation by cluster/node, guarded by cacheMutex
+	reports         map[string]CollectorReport // latest report per cached workload, guarded by cacheMutex
+	reportTimes     map[string]time.Time       // newest report timestamp applied per cached workload, guarded by cacheMutex
+	policies        *PolicyStore
+	store           *Store // nil when STORE_DIR is unset
+	history         *History
+	stats           *WorkloadStats
+	metrics         *Metrics
+	anomalies       *anomalyTracker
+	pipelineHealth  *pipelineHealth
+	notifier        *Notifier
+	jira            *jiraAutomation
+	heartbeat       *heartbeat
+	otlp            *otlpExporter
+	chaos           *faultInjector // chaos builds only
+	auth            *Authenticator
+	rbac            *rbacPolicy // per-route permissions; nil accepts any authenticated caller
+	audit           *AuditLog
+	access          *AccessLog
+	acks            *AckStore
+	annotations     *AnnotationStore
+	expected        *ExpectedWorkloadStore
+	watchlist       *Watchlist
+	suppressions    *SuppressionStore
+	shares          *ShareStore
+	collectors      *CollectorRegistry // registered at runtime, polled after clusters
+	federation      *federation        // other sites' dashboards, in federation mode
+	downtime        *DowntimeStore
+	maintenance     []MaintenanceWindow
+	owners          *ownerDirectory
+	gates           []gate
+	remediations    remediations
+	fieldFilter     *fieldFilter // redacts sensitive fields for viewers; nil sends everything
+	imagePolicies   []ImagePolicy
+	debounce        *statusDebouncer
+	gracePeriod     time.Duration // how long vanished workloads stay terminating
+	rollup          rollupPolicy
+	flaps           *flapDetector
+	benchmarking    sync.Mutex // held while an ingest benchmark runs
+	stream          *eventBroker
+	snapshotAt      time.Time // when the restored status snapshot was taken; zero if none
+	bus             *eventBus // nil delivers transitions synchronously
+	// generation counts cache changes; generationChanged is closed on each change
+	generation        uint64
+	generationChanged chan struct{}
+	streamTokens      *streamTokens
+	saml              *samlProvider
+	rawArchive        *Store
+	cacheLimits       cacheLimits
+	collectorLimits   collectorLimits
+	featureFlags      map[string]bool // FEATURE_FLAGS overrides of the flag defaults
+	clockSkew         clockSkewLimits
+	allowlist         *ipAllowlist // restricts admin and export endpoints; nil allows all
+	clientIPs         *clientIPResolver
+	rateLimit         *clientRateLimiter // per client address; nil = unlimited
+	requestTimeout    time.Duration      // of API requests without an endpoint timeout
+	signer            *responseSigner
+	discovery         *collectorDiscovery // finds Collector replicas in single-cluster mode
+	ingest            *ingestStore        // reports pushed by third-party sources
+	enrichers         []Enricher          // ingestion pipeline; nil = defaultEnrichers
+	refresh           chan struct{}       // requests an immediate poll
+	configHash        string              // reported by /api/version
+	displayZone       displayZone         // for human-facing timestamps
+	// readOnly disables acknowledgements and admin endpoints on replicas
+	readOnly bool
 }
 
 func main() {
+	// Optional OTLP export of metrics and logs to an OpenTelemetry collector.
+	// Log lines are exported as written to stderr, after any redaction.
+	otlp, err := newOTLPExporterFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to configure OTLP export: %v", err)
+	}
+	var logOutput io.Writer = os.Stderr
+	if otlp != nil && otlp.exportLogs {
+		logOutput = io.MultiWriter(os.Stderr, otlp)
+		log.SetOutput(logOutput)
+	}
+
+	// PHI-safe mode redacts identifying text from all log output and notifications
+	var redact *redactor
+	if getEnv("PHI_SAFE_LOGS", "false") == "true" {
+		var err error
+		redact, err = loadRedactor(os.Getenv("REDACTION_CONFIG"))
+		if err != nil {
+			log.Fatalf("Failed to configure log redaction: %v", err)
+		}
+		log.SetOutput(&redactingWriter{out: logOutput, redact: redact})
+	}
+
 	log.Println("Starting Hospital Dashboard Backend...")
 
+	// FIPS mode must be settled before any TLS client is configured
+	if getEnv("FIPS_MODE", "false") == "true" {
+		fipsMode = true
+	}
+	if fipsMode {
+		http.DefaultTransport.(*http.Transport).TLSClientConfig = newTLSConfig()
+		if !fipsModule() {
+			log.Println("Warning: FIPS mode restricts algorithms, but the binary was not built with a validated crypto module (GOEXPERIMENT=boringcrypto)")
+		}
+	}
+
 	// Load configuration - get Collector URL from environment
 	collectorURL := getEnv("COLLECTOR_URL", "http://attestation-collector:8080")
 
 	server := &Server{
-		collectorURL: collectorURL,
-		statusCache:  make(map[string]*WorkloadStatus),
-		pollInterval: 30 * time.Second,
-		httpClient:   &http.Client{Timeout: 10 * time.Second},
+		collectorURL:          collectorURL,
+		secondaryCollectorURL: getEnv("SECONDARY_COLLECTOR_URL", ""),
+		statusCache:           make(map[string]*WorkloadStatus),
+		pollInterval:          30 * time.Second,
+		httpClient:            &http.Client{Timeout: 10 * time.Second},
+		localCluster:          getEnv("CLUSTER_NAME", ""),
+		dashboardURL:          strings.TrimRight(os.Getenv("DASHBOARD_URL"), "/"),
+		siteName:              getEnv("SITE_NAME", ""),
+		readOnly:              getEnv("READ_ONLY", "false") == "true",
+		ar4siProfile:          getEnv("AR4SI_PROFILE", ""),
+		nodeAttestation:       getEnv("NODE_ATTESTATION", "false") == "true",
+		metrics:               newMetrics(),
+		anomalies:             newAnomalyTracker(),
+		pipelineHealth:        newPipelineHealth(),
+		fieldFilter:           parseFieldFilter(getEnv("RESPONSE_REDACTED_FIELDS", defaultRedactedFields)),
+		debounce:              newStatusDebouncer(getEnvInt("STATUS_VIOLATION_CYCLES", 1), getEnvInt("STATUS_RECOVERY_CYCLES", 1)),
+		rollup:                newRollupPolicy(getEnvInt("STATUS_TOLERATED_VIOLATIONS", 0), getEnv("STATUS_IGNORED_NAMESPACES", ""), getEnvInt("STATUS_VERIFIER_QUORUM", 1)),
+		flaps:                 newFlapDetector(getEnvInt("FLAP_THRESHOLD", 0), getEnvDuration("FLAP_WINDOW", time.Hour)),
+		gracePeriod:           getEnvDuration("WORKLOAD_GRACE_PERIOD", 0),
+		refresh:               make(chan struct{}, 1),
+		collectorLimits: collectorLimits{
+			maxResponseBytes: int64(getEnvInt("COLLECTOR_MAX_RESPONSE_BYTES", defaultCollectorMaxResponseBytes)),
+			maxReports:       getEnvInt("COLLECTOR_MAX_REPORTS", defaultCollectorMaxReports),
+		},
+		cacheLimits: cacheLimits{
+			maxWorkloads:  getEnvInt("CACHE_MAX_WORKLOADS", 0),
+			maxEntryBytes: getEnvInt("CACHE_MAX_ENTRY_BYTES", 0),
+		},
+		// Reports are judged by age only if REPORT_MAX_AGE is set, as some
+		// Collectors attest a pod once at startup and never again
+		clockSkew: clockSkewLimits{
+			tolerance: getEnvDuration("CLOCK_SKEW_TOLERANCE", 5*time.Minute),
+			maxAge:    getEnvDuration("REPORT_MAX_AGE", 0),
+		},
+	}
+
+	criticality, err := parseCriticality(os.Getenv("NAMESPACE_CRITICALITY"))
+	if err != nil {
+		log.Fatalf("Failed to parse NAMESPACE_CRITICALITY: %v", err)
+	}
+	server.rollup.criticality = criticality
+	if server.rollup.unknown, err = parseUnknownRollup(os.Getenv("STATUS_UNKNOWN_ROLLUP")); err != nil {
+		log.Fatalf("Failed to parse STATUS_UNKNOWN_ROLLUP: %v", err)
+	}
+	if server.featureFlags, err = parseFeatureFlags(os.Getenv("FEATURE_FLAGS")); err != nil {
+		log.Fatalf("Failed to parse FEATURE_FLAGS: %v", err)
+	}
+
+	streamTokens, err := newStreamTokens(os.Getenv("STREAM_TOKEN_SECRET"), getEnvDuration("STREAM_TOKEN_TTL", 2*time.Minute))
+	if err != nil {
+		log.Fatalf("Failed to initialize stream tokens: %v", err)
+	}
+	server.streamTokens = streamTokens
+
+	stream, err := newEventBrokerFromEnv(server.metrics)
+	if err != nil {
+		log.Fatalf("Failed to configure event streams: %v", err)
+	}
+	server.stream = stream
+
+	if _, ok := ar4siProfiles[server.ar4siProfile]; server.ar4siProfile != "" && !ok {
+		log.Fatalf("Unknown AR4SI_PROFILE %q", server.ar4siProfile)
+	}
+
+	// Multi-cluster mode - one Collector per named cluster
+	if path := os.Getenv("CLUSTERS_CONFIG"); path != "" {
+		clusters, err := loadClusters(path)
+		if err != nil {
+			log.Fatalf("Failed to load clusters config: %v", err)
+		}
+		server.clusters = clusters
+		for _, c := range clusters {
+			log.Printf("Configured to fetch cluster %s from Attestation Collector: %s", c.Name, c.CollectorURL)
+		}
+	} else {
+		log.Printf("Configured to fetch from Attestation Collector: %s", collectorURL)
+	}
+
+	// Optional persistent store for history; in-memory only if unset
+	var store *Store
+	if dir := os.Getenv("STORE_DIR"); dir != "" {
+		var err error
+		store, err = openStore(dir)
+		if err != nil {
+			log.Fatalf("Failed to open store: %v", err)
+		}
+		log.Printf("Persisting dashboard state to %s", dir)
+	}
+
+	server.store = store
+
+	history, err := newHistory(store, getEnvDuration("HISTORY_RETENTION", 90*24*time.Hour))
+	if err != nil {
+		log.Fatalf("Failed to load history: %v", err)
+	}
+	server.history = history
+
+	stats, err := newWorkloadStats(store, history)
+	if err != nil {
+		log.Fatalf("Failed to load workload stats: %v", err)
+	}
+	server.stats = stats
+
+	audit, err := newAuditLog(store)
+	if err != nil {
+		log.Fatalf("Failed to load audit log: %v", err)
+	}
+	server.audit = audit
+
+	access, err := newAccessLog(store, getEnvDuration("ACCESS_LOG_RETENTION", 365*24*time.Hour))
+	if err != nil {
+		log.Fatalf("Failed to load access log: %v", err)
+	}
+	server.access = access
+
+	acks, err := newAckStore(store, getEnvDuration("ACK_DEFAULT_TTL", 4*time.Hour), getEnvDuration("ACK_MAX_TTL", 24*time.Hour))
+	if err != nil {
+		log.Fatalf("Failed to load acknowledgements: %v", err)
+	}
+	server.acks = acks
+
+	annotations, err := newAnnotationStore(store)
+	if err != nil {
+		log.Fatalf("Failed to load annotations: %v", err)
+	}
+	server.annotations = annotations
+
+	expected, err := newExpectedWorkloadStore(store)
+	if err != nil {
+		log.Fatalf("Failed to load expected workloads: %v", err)
+	}
+	server.expected = expected
+
+	watchlist, err := newWatchlist(store)
+	if err != nil {
+		log.Fatalf("Failed to load watchlist: %v", err)
+	}
+	server.watchlist = watchlist
+
+	suppressions, err := newSuppressionStore(store)
+	if err != nil {
+		log.Fatalf("Failed to load suppressions: %v", err)
+	}
+	server.suppressions = suppressions
+
+	shares, err := newShareStore(store, os.Getenv("SHARE_TOKEN_SECRET"), getEnvDuration("SHARE_LINK_MAX_TTL", defaultShareMaxTTL))
+	if err != nil {
+		log.Fatalf("Failed to load share links: %v", err)
+	}
+	server.shares = shares
+
+	collectors, err := newCollectorRegistry(store)
+	if err != nil {
+		log.Fatalf("Failed to load registered collectors: %v", err)
+	}
+	server.collectors = collectors
+	for _, c := range collectors.List() {
+		log.Printf("Configured to fetch registered cluster %s from Attestation Collector: %s", c.Name, c.CollectorURL)
+	}
+
+	downtime, err := newDowntimeStore(store)
+	if err != nil {
+		log.Fatalf("Failed to load planned downtime: %v", err)
+	}
+	server.downtime = downtime
+
+	// Optional recurring maintenance windows
+	if path := os.Getenv("MAINTENANCE_CONFIG"); path != "" {
+		windows, err := loadMaintenanceWindows(path)
+		if err != nil {
+			log.Fatalf("Failed to load maintenance windows: %v", err)
+		}
+		server.maintenance = windows
+		log.Printf("Loaded %d maintenance windows", len(windows))
+	}
+
+	// Optional additional gates (external HTTP checks and exec plugins)
+	if path := os.Getenv("GATES_CONFIG"); path != "" {
+		gates, err := loadGates(path)
+		if err != nil {
+			log.Fatalf("Failed to load gates config: %v", err)
+		}
+		server.gates = gates
+		log.Printf("Loaded %d additional gates", len(gates))
+	}
+
+	if path := os.Getenv("TRUST_TIER_CONFIG"); path != "" {
+		tiers, err := loadTrustTiers(path)
+		if err != nil {
+			log.Fatalf("Failed to load trust tier config: %v", err)
+		}
+		trustTiers = tiers
+	}
+
+	if path := os.Getenv("REMEDIATION_CONFIG"); path != "" {
+		remediations, err := loadRemediations(path)
+		if err != nil {
+			log.Fatalf("Failed to load remediation config: %v", err)
+		}
+		server.remediations = remediations
+	}
+
+	// Optional per-namespace image digest allowlists, enforced as part of Gate One
+	if path := os.Getenv("IMAGE_POLICY_CONFIG"); path != "" {
+		policies, err := loadImagePolicies(path)
+		if err != nil {
+			log.Fatalf("Failed to load image policy config: %v", err)
+		}
+		server.imagePolicies = policies
+		log.Printf("Loaded image policies for %d namespaces", len(policies))
+	}
+
+	// Policy versions override the startup AR4SI profile and image policies once activated
+	startupProfile := server.ar4siProfile
+	policies, err := newPolicyStore(store, Policy{AR4SIProfile: &startupProfile, ImagePolicies: server.imagePolicies})
+	if err != nil {
+		log.Fatalf("Failed to load policy versions: %v", err)
+	}
+	server.policies = policies
+
+	// Optional bearer-token authentication for the API
+	if path := os.Getenv("AUTH_TOKENS_FILE"); path != "" {
+		auth, err := loadAuthenticator(path)
+		if err != nil {
+			log.Fatalf("Failed to load auth tokens: %v", err)
+		}
+		server.auth = auth
+		log.Printf("API authentication enabled (%d tokens)", len(auth.tokens))
+	}
+
+	// Optional LDAP/Active Directory login with HTTP Basic credentials
+	if path := os.Getenv("LDAP_CONFIG"); path != "" {
+		ldap, err := loadLDAPAuthenticator(path)
+		if err != nil {
+			log.Fatalf("Failed to load LDAP config: %v", err)
+		}
+		if server.auth == nil {
+			server.auth = &Authenticator{}
+		}
+		server.auth.ldap = ldap
+		log.Printf("LDAP authentication enabled against %s", ldap.address)
+	}
+
+	// Optional SAML 2.0 single sign-on for the UI, with signed session cookies
+	if path := os.Getenv("SAML_CONFIG"); path != "" {
+		saml, err := loadSAMLProvider(path)
+		if err != nil {
+			log.Fatalf("Failed to load SAML config: %v", err)
+		}
+		sessions, err := newStreamTokens(os.Getenv("SESSION_SECRET"), getEnvDuration("SESSION_TTL", 8*time.Hour))
+		if err != nil {
+			log.Fatalf("Failed to initialize sessions: %v", err)
+		}
+		saml.sessions = sessions
+		server.saml = saml
+		if server.auth == nil {
+			server.auth = &Authenticator{}
+		}
+		server.auth.sessions = sessions
+		log.Printf("SAML login enabled with identity provider %s", saml.config.IdPEntityID)
+	}
+
+	// Browser sessions, started at POST /api/session with any accepted credential
+	if server.auth != nil {
+		if server.auth.sessions == nil {
+			sessions, err := newStreamTokens(os.Getenv("SESSION_SECRET"), getEnvDuration("SESSION_TTL", 8*time.Hour))
+			if err != nil {
+				log.Fatalf("Failed to initialize sessions: %v", err)
+			}
+			server.auth.sessions = sessions
+		}
+		revoked, err := newSessionRevocations(store)
+		if err != nil {
+			log.Fatalf("Failed to load revoked sessions: %v", err)
+		}
+		server.auth.revoked = revoked
+		server.auth.secureCookies = getEnv("SESSION_COOKIE_SECURE", "true") == "true"
+	}
+
+	// Optional RBAC policy granting per-route permissions to identities and groups
+	if path := os.Getenv("RBAC_CONFIG"); path != "" {
+		if server.auth == nil {
+			log.Fatal("RBAC_CONFIG requires AUTH_TOKENS_FILE, LDAP_CONFIG or SAML_CONFIG")
+		}
+		rbac, err := loadRBACPolicy(path)
+		if err != nil {
+			log.Fatalf("Failed to load RBAC config: %v", err)
+		}
+		server.rbac = rbac
+		log.Printf("RBAC enabled with %d bindings", len(rbac.bindings))
+	}
+
+	// Optional archive of every raw Collector report as forensic evidence
+	if getEnv("RAW_REPORT_ARCHIVE", "false") == "true" {
+		if store == nil {
+			log.Fatal("RAW_REPORT_ARCHIVE requires STORE_DIR")
+		}
+		server.rawArchive = store
+		log.Println("Archiving raw Collector reports")
+	}
+
+	// Optional webhook notifications for workload transitions
+	notifier, err := newNotifierFromEnv(store)
+	if err != nil {
+		log.Fatalf("Failed to configure notifications: %v", err)
+	}
+	if notifier != nil {
+		notifier.redact = redact
+		notifier.metrics = server.metrics
+		server.notifier = notifier
+		go notifier.run()
+		log.Printf("Sending webhook notifications to %d targets", len(notifier.targets))
+	}
+
+	// Optional dead man's switch, so a stuck dashboard doesn't go unnoticed
+	beat, err := newHeartbeatFromEnv(server.metrics)
+	if err != nil {
+		log.Fatalf("Failed to configure heartbeat: %v", err)
+	}
+	if beat != nil {
+		server.heartbeat = beat
+		go beat.run()
+		log.Printf("Sending a heartbeat after every Collector sync")
+	}
+
+	if otlp != nil {
+		otlp.metrics = server.metrics
+		otlp.gauges = server.otlpGauges
+		server.otlp = otlp
+		go otlp.run()
+		log.Printf("Exporting metrics (%v) and logs (%v) over OTLP to %s", otlp.exportMetrics, otlp.exportLogs, otlp.endpoint)
+	}
+
+	// Fault injection into Collector responses, for testing the merge logic
+	if path := os.Getenv("CHAOS_CONFIG"); path != "" {
+		if !chaosBuild {
+			log.Fatalf("CHAOS_CONFIG requires a build with -tags chaos")
+		}
+		chaos, err := loadFaultInjector(path, server.metrics)
+		if err != nil {
+			log.Fatalf("Failed to configure fault injection: %v", err)
+		}
+		server.chaos = chaos
+		log.Printf("Warning: injecting faults into Collector responses (seed %d)", chaos.config.Seed)
+	}
+
+	// Optional Jira issues for chronically violating workloads
+	if path := os.Getenv("JIRA_CONFIG"); path != "" {
+		jira, err := loadJiraAutomation(path, store)
+		if err != nil {
+			log.Fatalf("Failed to configure Jira automation: %v", err)
+		}
+		jira.redact = redact
+		jira.dashboardURL = strings.TrimRight(os.Getenv("DASHBOARD_URL"), "/")
+		server.jira = jira
+		go server.runJiraAutomation()
+		log.Printf("Filing Jira issues in project %s for chronic violations", jira.config.Project)
+	}
+
+	// Optional JWS signing of status and export responses for downstream verification
+	if path := os.Getenv("RESPONSE_SIGNING_KEY"); path != "" {
+		signer, err := loadResponseSigner(path)
+		if err != nil {
+			log.Fatalf("Failed to load response signing key: %v", err)
+		}
+		server.signer = signer
+		log.Printf("Signing status and export responses with %s key %s", signer.alg, signer.kid)
+	}
+
+	// Client addresses behind the ingress controller, for logs, rate limiting,
+	// audit records, and the admin allowlist
+	clientIPs, err := newClientIPResolver(os.Getenv("TRUSTED_PROXIES"))
+	if err != nil {
+		log.Fatalf("Failed to configure trusted proxies: %v", err)
+	}
+	server.clientIPs = clientIPs
+	if len(clientIPs.trustedProxies) > 0 {
+		log.Printf("Resolving client addresses through %d trusted proxy networks", len(clientIPs.trustedProxies))
+	}
+
+	server.requestTimeout = getEnvDuration("REQUEST_TIMEOUT", defaultRequestTimeout)
+	if server.requestTimeout <= 0 {
+		log.Fatalf("REQUEST_TIMEOUT must be positive, got %s", server.requestTimeout)
+	}
+
+	// Optional per-client rate limit of API requests
+	rateLimit, err := newClientRateLimiterFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to configure rate limit: %v", err)
+	}
+	server.rateLimit = rateLimit
+
+	// Optional source address allowlist for admin and export endpoints
+	if allowed := os.Getenv("ADMIN_ALLOWED_IPS"); allowed != "" {
+		allowlist, err := newIPAllowlist(allowed, clientIPs)
+		if err != nil {
+			log.Fatalf("Failed to configure admin allowlist: %v", err)
+		}
+		server.allowlist = allowlist
+		log.Printf("Admin and export endpoints restricted to %d networks", len(allowlist.allowed))
+	}
+
+	// Optional Collector replica discovery through Kubernetes EndpointSlices
+	if selector := os.Getenv("COLLECTOR_DISCOVERY_SELECTOR"); selector != "" {
+		if len(server.clusters) > 0 {
+			log.Fatalf("COLLECTOR_DISCOVERY_SELECTOR applies to single-cluster mode only")
+		}
+		kube, err := newInClusterKubeClient()
+		if err != nil {
+			log.Fatalf("Failed to configure collector discovery: %v", err)
+		}
+		discovery, err := newCollectorDiscovery(kube, os.Getenv("COLLECTOR_DISCOVERY_NAMESPACE"), selector,
+			os.Getenv("COLLECTOR_DISCOVERY_PORT"), getEnv("COLLECTOR_DISCOVERY_SCHEME", "http"))
+		if err != nil {
+			log.Fatalf("Failed to configure collector discovery: %v", err)
+		}
+		server.discovery = discovery
+		log.Printf("Discovering Collector replicas in %s with selector %s", discovery.namespace, selector)
+	}
+
+	// Optional third-party attestation sources pushing to /api/ingest/{source}
+	if path := os.Getenv("INGEST_CONFIG"); path != "" {
+		sources, err := loadIngestSources(path)
+		if err != nil {
+			log.Fatalf("Failed to load ingest config: %v", err)
+		}
+		server.ingest = newIngestStore(sources)
+		log.Printf("Accepting reports from %d ingest sources", len(sources))
+	}
+
+	// Optional ownership directory, to show and route to the owning team
+	owners, err := newOwnerDirectory()
+	if err != nil {
+		log.Fatalf("Failed to configure the ownership directory: %v", err)
+	}
+	server.owners = owners
+
+	// Optional pod metadata enrichment (node name etc.) from the Kubernetes API
+	if getEnv("K8S_ENRICHMENT", "false") == "true" {
+		kube, err := newInClusterKubeClient()
+		if err != nil {
+			log.Printf("Kubernetes enrichment disabled: %v", err)
+		} else {
+			server.kube = kube
+			log.Println("Kubernetes enrichment enabled")
+		}
+	}
+
+	if fipsMode {
+		if err := server.verifyFIPS(); err != nil {
+			log.Fatalf("Configuration is not FIPS compliant: %v", err)
+		}
+		log.Println("FIPS mode: TLS and token verification restricted to approved algorithms")
+	}
+
+	// Fan transitions out to history, streams, notifications and metrics
+	// without holding up the poll cycle
+	server.bus = newEventBus(getEnvInt("EVENT_QUEUE_SIZE", defaultEventQueue), server.metrics)
+	for _, handler := range server.eventHandlers() {
+		server.bus.subscribe(handler)
+	}
+
+	// Optional faster or slower evaluation of some namespaces
+	schedule, err := parsePollIntervals(os.Getenv("NAMESPACE_POLL_INTERVALS"), server.pollInterval)
+	if err != nil {
+		log.Fatalf("Failed to parse NAMESPACE_POLL_INTERVALS: %v", err)
+	}
+	if schedule != nil {
+		server.schedule = schedule
+		log.Printf("Polling every %s for namespace intervals %v", schedule.tick(), schedule.describe())
+	}
+
+	// Until the first poll succeeds, show the state persisted before the restart
+	if err := server.restoreSnapshot(); err != nil {
+		log.Fatalf("Failed to restore status snapshot: %v", err)
 	}
 
-	log.Printf("Configured to fetch from Attestation Collector: %s", collectorURL)
+	// Optional federation mode, rolling up other sites' dashboards
+	if path := os.Getenv("FEDERATION_CONFIG"); path != "" {
+		federation, err := loadFederation(path, getEnvDuration("FEDERATION_POLL_INTERVAL", server.pollInterval))
+		if err != nil {
+			log.Fatalf("Failed to load federation config: %v", err)
+		}
+		federation.metrics = server.metrics
+		server.federation = federation
+		go federation.run()
+		log.Printf("Federating %d sites every %s", len(federation.sites), federation.interval)
+	}
 
-	// Start background polling from Collector
-	go server.pollCollector()
+	// Start background polling from Collector, unless this instance only
+	// federates other sites
+	if server.federation == nil || os.Getenv("COLLECTOR_URL") != "" || len(server.clusters) > 0 || len(server.collectors.List()) > 0 {
+		go server.pollCollector()
+	}
+
+	// Between polls, age reports out, mark the workloads of Collectors out for
+	// too long and end grace periods as soon as they pass
+	if interval := getEnvDuration("STALENESS_SWEEP_INTERVAL", defaultSweepInterval); interval > 0 {
+		go server.runStalenessSweeper(interval)
+		log.Printf("Sweeping cached workloads for staleness every %s", interval)
+	}
 
 	// Setup HTTP routes
 	mux := http.NewServeMux()
 
 	// API endpoints
-	mux.HandleFunc("/api/status", server.handleStatus)
-	mux.HandleFunc("/api/workloads", server.handleWorkloads)
-	mux.HandleFunc("/api/workload/", server.handleWorkloadDetail)
+	for pattern, handler := range server.apiRoutes() {
+		mux.HandleFunc(pattern, handler)
+	}
+
+	// Prometheus metrics
+	mux.HandleFunc("/metrics", server.handleMetrics)
 
 	// Health check
 	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
@@ -100,186 +693,549 @@ func main() {
 	fs := http.FileServer(http.Dir("/app/static"))
 	mux.Handle("/", fs)
 
+	zone, err := newDisplayZone(os.Getenv("DISPLAY_TIMEZONE"), os.Getenv("DISPLAY_TIME_FORMAT"))
+	if err != nil {
+		log.Fatalf("Invalid display time settings: %v", err)
+	}
+	server.displayZone = zone
+
+	server.configHash = configHash(os.Getenv)
+	log.Printf("Version %s (commit %s, built %s), config hash %s", version, commit, buildDate, server.configHash)
+
 	port := getEnv("PORT", "8080")
+	if server.readOnly {
+		log.Println("Read-only mode: acknowledgements and admin endpoints are disabled")
+	}
 	log.Printf("Dashboard backend listening on :%s", port)
-	log.Fatal(http.ListenAndServe(":"+port, loggingMiddleware(corsMiddleware(mux))))
+	log.Fatal(http.ListenAndServe(":"+port, server.clientIPMiddleware(loggingMiddleware(server.rateLimitMiddleware(cacheControlMiddleware(corsMiddleware(server.allowlistMiddleware(server.readOnlyMiddleware(server.authMiddleware(server.rbacMiddleware(server.accessLogMiddleware(server.signingMiddleware(server.fieldFilterMiddleware(server.timeoutMiddleware(server.featureMiddleware(mux))))))))))))))))
+}
+
+// apiRoutes maps the API's mux patterns to their handlers
+func (s *Server) apiRoutes() map[string]http.HandlerFunc {
+	return map[string]http.HandlerFunc{
+		"/api/status":                  s.handleStatus,
+		"/api/status/at":               s.handleStatusAt,
+		"/api/status/wait":             s.handleStatusWait,
+		"/api/diff":                    s.handleDiff,
+		"/api/workloads":               s.handleWorkloads,
+		"/api/workloads/ack-batch":     s.handleBatchAck,
+		"/api/workloads/silence-batch": s.handleBatchAck,
+		"/api/workload/":               s.handleWorkloadDetail,
+		"/api/expected-workloads":      s.handleExpectedWorkloads,
+		"/api/expected-workloads/":     s.handleExpectedWorkload,
+		"/api/watchlist":               s.handleWatchlist,
+		"/api/watchlist/":              s.handleWatchedWorkload,
+		"/api/suppressions":            s.handleSuppressions,
+		"/api/shared/":                 s.handleShared,
+		"/api/downtime":                s.handleDowntime,
+		"/api/downtime/":               s.handleDowntimeWindow,
+		"/api/search":                  s.handleSearch,
+		"/api/nodes":                   s.handleNodes,
+		"/api/tee-inventory":           s.handleTEEInventory,
+		"/api/trust-tiers":             s.handleTrustTiers,
+		"/api/wallboard":               s.handleWallboard,
+		"/api/clusters":                s.handleClusters,
+		"/api/federation":              s.handleFederation,
+		"/api/pipeline/health":         s.handlePipelineHealth,
+		"/api/reports/mttr":            s.handleMTTRReport,
+		"/api/reports/heatmap":         s.handleHeatmapReport,
+		"/api/reports/raw/":            s.handleRawReport,
+		"/api/export/openmetrics":      s.handleOpenMetricsExport,
+		"/api/export/snapshot":         s.handleStateSnapshot,
+		"/api/ingest/":                 s.handleIngest,
+		"/api/audit":                   s.handleAudit,
+		"/api/audit/access":            s.handleAccessLog,
+		"/api/maintenance":             s.handleMaintenance,
+		"/api/events":                  s.handleEvents,
+		"/api/ws":                      s.handleWebSocket,
+		"/api/stream-token":            s.handleStreamToken,
+		"/api/session":                 s.handleSession,
+		"/api/auth/saml/metadata":      s.handleSAMLMetadata,
+		"/api/auth/saml/login":         s.handleSAMLLogin,
+		"/api/auth/saml/acs":           s.handleSAMLACS,
+		"/api/auth/saml/logout":        s.handleSAMLLogout,
+		"/api/schema":                  s.handleSchema,
+		"/api/schemas/":                s.handleJSONSchema,
+		"/api/policy/evaluate":         s.handlePolicyEvaluate,
+		"/api/simulate/report":         s.handleSimulateReport,
+		"/api/policies":                s.handlePolicies,
+		"/api/policies/":               s.handlePolicyAction,
+		"/api/admin/backup":            s.handleBackup,
+		"/api/admin/restore":           s.handleRestore,
+		"/api/admin/selftest":          s.handleSelftest,
+		"/api/admin/runtime":           s.handleRuntime,
+		"/api/admin/refresh":           s.handleRefresh,
+		"/api/admin/collectors":        s.handleCollectors,
+		"/api/admin/collectors/":       s.handleCollector,
+		"/api/admin/ingest-anomalies":  s.handleIngestAnomalies,
+		"/api/admin/benchmark":         s.handleBenchmark,
+		"/api/admin/import":            s.handleImport,
+		"/api/version":                 s.handleVersion,
+		"/api/features":                s.handleFeatures,
+		"/api/signing-keys":            s.handleSigningKeys,
+	}
 }
 
 // handleStatus returns the overall dashboard status
 func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	zone, ok := s.requestZone(w, r)
+	if !ok {
+		return
+	}
+
 	s.cacheMutex.RLock()
 	defer s.cacheMutex.RUnlock()
 
 	response := DashboardResponse{
-		OverallStatus: "compliant",
-		Workloads:     make([]WorkloadStatus, 0, len(s.statusCache)),
-		LastUpdated:   time.Now(),
+		Workloads:   make([]WorkloadStatus, 0, len(s.statusCache)),
+		LastUpdated: time.Now(),
+		Generation:  s.generation,
 	}
 
 	for _, status := range s.statusCache {
-		response.Workloads = append(response.Workloads, *status)
-		if !status.Attested || status.GateTwoStatus == "failed" {
-			response.OverallStatus = "violation"
+		if !matchesCluster(r, status) {
+			continue
 		}
+		workload := s.decorate(*status)
+		if !matchesLabels(r, &workload) {
+			continue
+		}
+		response.Workloads = append(response.Workloads, workload)
+	}
+	sortPinnedFirst(response.Workloads)
+	response.OverallStatus = s.requestStatus(r, response.Workloads)
+	if s.rollup.criticalViolation(response.Workloads) {
+		// Critical namespaces aren't debounced
+		response.OverallStatus = "violation"
 	}
 
 	// If no workloads configured, return demo data
-	if len(response.Workloads) == 0 {
+	if len(s.statusCache) == 0 {
 		response = getDemoResponse()
 	}
+	response.DataSource, response.DataAsOf = s.dataSourceLocked()
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	noteWorkloads(r, response.Workloads)
+	localizeWorkloads(w, r, response.Workloads)
+	displayWorkloadTimes(zone, response.Workloads)
+	s.writeStatusGeneration(w, response)
+}
+
+// overallStatus rolls workload states up into "compliant", "warning" or
+// "violation" according to the rollup policy
+func (s *Server) overallStatus(workloads []WorkloadStatus) string {
+	return s.rollup.status(workloads)
+}
+
+// isViolation reports whether a single workload is in violation. An unknown
+// attestation is not one, though a failed gate still is.
+func isViolation(status *WorkloadStatus) bool {
+	return (!status.Attested && !isUnknown(status)) ||
+		status.GateOneStatus == "failed" ||
+		status.GateTwoStatus == "failed" ||
+		status.AttestationStatus == verifierSplitStatus ||
+		gatesFailed(status.Gates)
 }
 
 // handleWorkloads returns all workload statuses
 func (s *Server) handleWorkloads(w http.ResponseWriter, r *http.Request) {
+	zone, ok := s.requestZone(w, r)
+	if !ok {
+		return
+	}
+
 	s.cacheMutex.RLock()
 	defer s.cacheMutex.RUnlock()
 
 	workloads := make([]WorkloadStatus, 0, len(s.statusCache))
 	for _, status := range s.statusCache {
-		workloads = append(workloads, *status)
+		if !matchesCluster(r, status) {
+			continue
+		}
+		workload := s.decorate(*status)
+		if !matchesLabels(r, &workload) {
+			continue
+		}
+		workloads = append(workloads, workload)
 	}
+	sortPinnedFirst(workloads)
 
 	// If no workloads configured, return demo data
-	if len(workloads) == 0 {
+	if len(s.statusCache) == 0 {
 		workloads = getDemoResponse().Workloads
 	}
 
+	noteWorkloads(r, workloads)
+	localizeWorkloads(w, r, workloads)
+	displayWorkloadTimes(zone, workloads)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(workloads)
 }
 
 // handleWorkloadDetail returns details for a specific workload
 func (s *Server) handleWorkloadDetail(w http.ResponseWriter, r *http.Request) {
-	// Extract workload name from path: /api/workload/{name}
-	name := r.URL.Path[len("/api/workload/"):]
-	if name == "" {
+	// Extract workload key from path: /api/workload/{namespace}/{name}[/{action}]
+	key, action := splitWorkloadPath(r.URL.Path[len("/api/workload/"):])
+	if key == "" {
 		http.Error(w, "workload name required", http.StatusBadRequest)
 		return
 	}
 
+	switch action {
+	case "":
+	case "ack":
+		s.handleAck(w, r, key)
+		return
+	case "annotations":
+		s.handleAnnotations(w, r, key)
+		return
+	case "suppress":
+		s.handleSuppress(w, r, key)
+		return
+	case "share":
+		s.handleShare(w, r, key, "")
+		return
+	case "trust-trend":
+		s.handleTrustTrend(w, r, key)
+		return
+	case "explanation":
+		s.handleExplanation(w, r, key)
+		return
+	default:
+		if id, ok := strings.CutPrefix(action, "share/"); ok {
+			s.handleShare(w, r, key, id)
+			return
+		}
+		http.NotFound(w, r)
+		return
+	}
+	zone, ok := s.requestZone(w, r)
+	if !ok {
+		return
+	}
+
 	s.cacheMutex.RLock()
-	status, exists := s.statusCache[name]
+	status, exists := s.statusCache[key]
+	var detail WorkloadStatus
+	if exists {
+		detail = s.decorate(*status)
+	}
 	s.cacheMutex.RUnlock()
+	s.stats.apply(&detail)
 
 	if !exists {
 		http.Error(w, "workload not found", http.StatusNotFound)
 		return
 	}
 
+	workloads := []WorkloadStatus{detail}
+	noteWorkloads(r, workloads)
+	localizeWorkloads(w, r, workloads)
+	displayWorkloadTimes(zone, workloads)
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(status)
+	json.NewEncoder(w).Encode(workloads[0])
+}
+
+// splitWorkloadPath splits "{namespace}/{name}/{action...}" into the cache
+// key ("{namespace}/{name}") and the optional action
+func splitWorkloadPath(path string) (key, action string) {
+	parts := strings.SplitN(strings.Trim(path, "/"), "/", 3)
+	switch len(parts) {
+	case 1:
+		return parts[0], ""
+	case 2:
+		return parts[0] + "/" + parts[1], ""
+	default:
+		return parts[0] + "/" + parts[1], parts[2]
+	}
+}
+
+// decorate attaches operator state (acknowledgements and annotations) to a
+// copy of a cached status
+func (s *Server) decorate(status WorkloadStatus) WorkloadStatus {
+	status.Acknowledgement = s.acks.Get(status.Namespace + "/" + status.Name)
+	status.Annotations = s.annotations.Get(status.Namespace + "/" + status.Name)
+	status.Pinned = s.watchlist.Pinned(status.Namespace+"/"+status.Name, time.Now())
+	status.FailedChecks = s.remediate(&status)
+	return status
 }
 
 // pollCollector periodically fetches attestation reports from the Collector
 func (s *Server) pollCollector() {
-	ticker := time.NewTicker(s.pollInterval)
+	interval := s.pollInterval
+	if s.schedule != nil {
+		interval = s.schedule.tick()
+	}
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	// Initial fetch
 	s.fetchFromCollector()
 
-	for range ticker.C {
+	for {
+		select {
+		case <-ticker.C:
+		case <-s.refresh:
+			s.schedule.reset()
+		}
 		s.fetchFromCollector()
 	}
 }
 
-// fetchFromCollector fetches all attestation reports from the Collector API
-func (s *Server) fetchFromCollector() {
-	url := fmt.Sprintf("%s/api/v1/reports", s.collectorURL)
+// cycleTimeout bounds the Collector fetches of a poll cycle to the poll
+// interval
+func (s *Server) cycleTimeout() time.Duration {
+	if s.pollInterval > 0 {
+		return s.pollInterval
+	}
+	return 30 * time.Second
+}
 
-	resp, err := s.httpClient.Get(url)
-	if err != nil {
-		log.Printf("Failed to fetch from Collector: %v", err)
+// handleRefresh polls the Collectors now rather than at the next interval,
+// e.g. right after a workload was redeployed
+// POST /api/admin/refresh
+func (s *Server) handleRefresh(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	identity, ok := requireAdmin(w, r)
+	if !ok {
 		return
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		log.Printf("Collector returned status %d", resp.StatusCode)
+	select {
+	case s.refresh <- struct{}{}:
+		s.audit.RecordRequest(r, identity.Name, "collector.refresh", "", "")
+	default:
+		// A refresh is already pending
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// fetchFromCollector fetches all attestation reports from every configured
+// Collector. Fetches still running at the next poll are cancelled, so a hung
+// Collector can't hold up every later cycle.
+func (s *Server) fetchFromCollector() {
+	if now := time.Now(); !s.schedule.begin(now) && len(s.watchlist.List(now)) == 0 {
 		return
 	}
+	s.applyActivePolicy()
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.cycleTimeout())
+	defer cancel()
 
 	var reports []CollectorReport
-	if err := json.NewDecoder(resp.Body).Decode(&reports); err != nil {
-		log.Printf("Failed to decode Collector response: %v", err)
-		return
+	secondary := make(map[string]CollectorReport)
+	nodeReports := make(map[string][]NodeReport)
+	synced := make(map[string]bool)
+	syncErrors := make(map[string]error)
+
+	for _, cluster := range s.collectorTargets() {
+		start := time.Now()
+		clusterReports, err := s.fetchCollectorReports(ctx, cluster)
+		s.observeCollectorPoll(cluster.Name, time.Since(start), len(clusterReports), err)
+		if err != nil {
+			log.Printf("Failed to fetch from Collector %s: %v", cluster.CollectorURL, err)
+			syncErrors[cluster.Name] = err
+			continue
+		}
+		synced[cluster.Name] = true
+		reports = append(reports, clusterReports...)
+
+		if cluster.SecondaryCollectorURL != "" {
+			s.fetchSecondaryReports(ctx, cluster, secondary)
+		}
+
+		if cluster.NodeReports {
+			if hosts, err := s.fetchNodeReports(ctx, cluster); err != nil {
+				log.Printf("Failed to fetch node reports from Collector %s: %v", cluster.CollectorURL, err)
+			} else {
+				nodeReports[cluster.Name] = hosts
+			}
+		}
+	}
+
+	if len(synced) > 0 {
+		log.Printf("Fetched %d reports from Collector", len(reports))
+		s.pipelineHealth.observeStaleness(reports, time.Now())
+		reports = append(reports, s.ingest.current(time.Now())...)
 	}
+	reports, conflicts := s.dedupeReports(s.dropSuppressed(reports))
+	reports = s.dueReports(reports)
 
-	log.Printf("Fetched %d reports from Collector", len(reports))
+	// Archive and run the ingestion pipeline outside the cache lock - these do I/O
+	s.archiveReports(reports)
+	statuses := make([]*WorkloadStatus, 0, len(reports))
+	for i := range reports {
+		statuses = append(statuses, s.enrich(&reports[i]))
+	}
+
+	// Additional gates may call out to external systems - also outside the lock
+	s.evaluateGates(reports, statuses)
+	s.checkImagePolicies(statuses)
+	s.evaluateShadowPolicy(reports, statuses)
+	s.compareVerifiers(statuses, secondary)
+	flagReportConflicts(statuses, conflicts)
+	correlateHosts(statuses, s.updateNodeReports(nodeReports))
+	for _, status := range s.missingWorkloads(statuses, synced, len(syncErrors) == 0) {
+		s.assignOwner(status, "")
+		statuses = append(statuses, status)
+	}
+
+	// Update cache
+	now := time.Now()
+	s.observeReportAges(reports, now)
+	events := s.applyStatuses(statuses, synced, syncErrors)
+	if len(synced) > 0 {
+		s.saveSnapshot(now)
+	}
+	s.observeDetectionLag(reports, events)
+	s.retainReports(reports)
+	s.checkChaosInvariants()
+	s.syncHeartbeat(len(statuses), synced, syncErrors)
 
-	// Convert Collector reports to WorkloadStatus and update cache
+	// Hand transitions to their subscribers outside the cache lock - these
+	// may write to the store
+	s.recordEvents(events)
+}
+
+// applyStatuses replaces the cache entries of the synced clusters with the
+// given statuses and returns the resulting history events
+func (s *Server) applyStatuses(statuses []*WorkloadStatus, synced map[string]bool, syncErrors map[string]error) []HistoryEvent {
 	s.cacheMutex.Lock()
 	defer s.cacheMutex.Unlock()
 
-	// Clear old cache and repopulate
-	s.statusCache = make(map[string]*WorkloadStatus)
+	for name, err := range syncErrors {
+		s.recordClusterSync(name, err)
+	}
+	if len(synced) == 0 {
+		return nil
+	}
 
-	for _, report := range reports {
-		status := s.convertCollectorReport(report)
-		key := report.Namespace + "/" + report.PodName
-		s.statusCache[key] = status
+	// Repopulate the cache, keeping the last known entries of clusters
+	// whose Collector could not be reached this cycle and of namespaces
+	// not due for evaluation
+	cache := make(map[string]*WorkloadStatus)
+	for key, status := range s.statusCache {
+		if !synced[status.Cluster] || !s.evaluates(status.Namespace, status.Name) {
+			cache[key] = status
+		}
 	}
-}
 
-// convertCollectorReport converts a Collector report to WorkloadStatus
-func (s *Server) convertCollectorReport(report CollectorReport) *WorkloadStatus {
-	status := &WorkloadStatus{
-		Name:        report.PodName,
-		Namespace:   report.Namespace,
-		Attested:    report.Attested,
-		Timestamp:   report.Timestamp.Format(time.RFC3339),
-		LastChecked: time.Now(),
-		TEEType:     report.TEEType,
-	}
-
-	// Determine attestation status and details
-	if report.Attested {
-		status.AttestationStatus = "verified"
-		status.GateOneStatus = "passing"
-		status.GateTwoStatus = "passing"
-
-		// Build details from trust vector
-		if report.TrustVector != nil {
-			status.Details = fmt.Sprintf("TEE attestation successful (%s) - Hardware: %s, Config: %s, Executables: %s",
-				report.TEEType,
-				trustTierToString(report.TrustVector.Hardware),
-				trustTierToString(report.TrustVector.Configuration),
-				trustTierToString(report.TrustVector.Executables))
-		} else {
-			status.Details = fmt.Sprintf("TEE attestation successful (%s)", report.TEEType)
+	now := time.Now()
+	for _, status := range statuses {
+		key := status.Namespace + "/" + status.Name
+		if s.staleReport(key, status) {
+			if previous, ok := s.statusCache[key]; ok {
+				cache[key] = previous
+			}
+			continue
 		}
-	} else {
-		status.AttestationStatus = "failed"
-		status.GateOneStatus = "passing" // Assume code integrity passes if pod exists
-		status.GateTwoStatus = "failed"
+		if isViolation(status) || isUnknown(status) {
+			status.Maintenance = s.activeMaintenance(status.Namespace, now)
+		}
+		s.markFlapping(key, s.statusCache[key], status, now)
+		s.trackAttestationLatency(s.statusCache[key], status)
+		s.limitEntry(status)
+		status.Lifecycle = lifecycleActive
+		cache[key] = status
+	}
+	s.retainVanished(cache, synced, now)
+	s.evictEntries(cache)
+	s.advanceReportTimes(cache, now)
+	events := diffCaches(s.statusCache, cache, now)
+	events = append(events, flappingEvents(s.statusCache, cache, now)...)
+	s.statusCache = cache
+	if len(events) > 0 {
+		s.bumpGenerationLocked()
+	}
+	if s.flaps != nil {
+		s.flaps.retain(cache)
+	}
 
-		if report.Error != "" {
-			status.Details = report.Error
-		} else {
-			status.Details = "TEE attestation failed - not running in genuine confidential environment"
+	for name := range synced {
+		s.recordClusterSync(name, nil)
+	}
+	s.observeOverallStatus()
+	return events
+}
+
+// fetchClusterReports fetches the attestation reports of a single cluster
+func (s *Server) fetchClusterReports(ctx context.Context, cluster ClusterConfig) ([]CollectorReport, error) {
+	url := fmt.Sprintf("%s/api/v1/reports", cluster.CollectorURL)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := cluster.authorize(req); err != nil {
+		return nil, err
+	}
+
+	client := s.httpClient
+	if cluster.httpClient != nil {
+		client = cluster.httpClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("collector returned status %d", resp.StatusCode)
+	}
+
+	raws, err := s.collectorLimits.decodeArray(resp.Body)
+	if err != nil {
+		s.observeCollectorLimit(cluster.Name, err)
+		return nil, fmt.Errorf("failed to decode Collector response: %w", err)
+	}
+	raws = s.chaos.inject(cluster.Name, raws)
+
+	reports := make([]CollectorReport, len(raws))
+	for i, raw := range raws {
+		s.inspectReport(cluster.Name, raw)
+		if reports[i], err = decodeCollectorReport(raw); err != nil {
+			return nil, fmt.Errorf("invalid Collector report %d: %w", i, err)
 		}
+		if reports[i].Cluster == "" {
+			reports[i].Cluster = cluster.Name
+		}
+		reports[i].source = cluster.CollectorURL
 	}
+	return reports, nil
+}
 
-	return status
+// decodeCollectorReport validates and decodes one Collector report. Reports
+// that don't conform are kept as malformed evidence if they identify a pod.
+func decodeCollectorReport(raw json.RawMessage) (CollectorReport, error) {
+	var report CollectorReport
+	var err error
+	if errs := collectorReportSchema.Validate(raw); len(errs) > 0 {
+		err = errors.New(strings.Join(errs, "; "))
+	} else {
+		err = json.Unmarshal(raw, &report)
+	}
+	if err != nil {
+		salvaged, ok := decodeMalformedReport(raw, err)
+		if !ok {
+			return CollectorReport{}, err
+		}
+		report = salvaged
+	}
+	report.raw = raw
+	return report, nil
 }
 
-// trustTierToString converts EAR trust tier value to human-readable string
+// trustTierToString converts EAR trust tier value to its configured label
 func trustTierToString(tier int) string {
-	switch tier {
-	case 0:
-		return "None"
-	case 2:
-		return "Affirming"
-	case 32:
-		return "Warning"
-	case 96:
-		return "Contraindicated"
-	default:
-		return fmt.Sprintf("Unknown(%d)", tier)
+	if t, ok := trustTiers[tier]; ok {
+		return t.Label
 	}
+	return fmt.Sprintf("Unknown(%d)", tier)
 }
 
 // getDemoResponse returns demo data when no real workloads are configured
@@ -321,9 +1277,33 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			log.Printf("Invalid duration for %s: %q, using default %s", key, value, defaultValue)
+			return defaultValue
+		}
+		return d
+	}
+	return defaultValue
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			log.Printf("Invalid integer for %s: %q, using default %d", key, value, defaultValue)
+			return defaultValue
+		}
+		return n
+	}
+	return defaultValue
+}
+
 func loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		log.Printf("%s %s", r.Method, r.URL.Path)
+		log.Printf("%s %s from %s", r.Method, r.URL.Path, requestIP(r))
 		next.ServeHTTP(w, r)
 	})
 }
@@ -331,8 +1311,9 @@ func loggingMiddleware(next http.Handler) http.Handler {
 func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Expose-Headers", signatureHeader)
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
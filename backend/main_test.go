@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 )
@@ -245,3 +246,30 @@ func TestServerHasCollectorURL(t *testing.T) {
 		t.Errorf("Expected collectorURL, got '%s'", server.collectorURL)
 	}
 }
+
+// TestCORSPreflight tests that a cross-origin preflight allows the write
+// methods and credentials the API takes, without reaching the handler
+func TestCORSPreflight(t *testing.T) {
+	reached := false
+	handler := corsMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { reached = true }))
+
+	req := httptest.NewRequest("OPTIONS", "/api/workload/icu/pacs/annotations", nil)
+	req.Header.Set("Origin", "https://portal.example.org")
+	req.Header.Set("Access-Control-Request-Method", "PUT")
+	req.Header.Set("Access-Control-Request-Headers", "authorization, content-type")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK || reached {
+		t.Fatalf("Expected the preflight answered by the middleware, got %d", w.Code)
+	}
+	methods := w.Header().Get("Access-Control-Allow-Methods")
+	for _, method := range []string{"PUT", "DELETE"} {
+		if !strings.Contains(methods, method) {
+			t.Errorf("Expected %s allowed, got %q", method, methods)
+		}
+	}
+	if headers := w.Header().Get("Access-Control-Allow-Headers"); !strings.Contains(headers, "Authorization") {
+		t.Errorf("Expected the Authorization header allowed, got %q", headers)
+	}
+}
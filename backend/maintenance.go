@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// MaintenanceWindow is a recurring period during which violations in the
+// listed namespaces are recorded but flagged as maintenance, and excluded
+// from overall_status and alerting (e.g. the weekly node firmware update slot)
+type MaintenanceWindow struct {
+	Name       string   `json:"name"`
+	Namespaces []string `json:"namespaces,omitempty"` // empty = all namespaces
+	Days       []string `json:"days,omitempty"`       // weekday names; empty = every day
+	Start      string   `json:"start"`                // "HH:MM" in Timezone
+	Duration   string   `json:"duration"`             // e.g. "2h"
+	Timezone   string   `json:"timezone,omitempty"`   // IANA name, default UTC
+
+	days     map[time.Weekday]bool
+	startMin int
+	duration time.Duration
+	location *time.Location
+}
+
+// loadMaintenanceWindows reads and validates maintenance windows from a JSON file
+func loadMaintenanceWindows(path string) ([]MaintenanceWindow, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var windows []MaintenanceWindow
+	if err := json.Unmarshal(data, &windows); err != nil {
+		return nil, fmt.Errorf("invalid maintenance config: %w", err)
+	}
+
+	for i := range windows {
+		if err := windows[i].init(); err != nil {
+			return nil, fmt.Errorf("maintenance window %q: %w", windows[i].Name, err)
+		}
+	}
+	return windows, nil
+}
+
+// init parses the window's textual fields
+func (mw *MaintenanceWindow) init() error {
+	if mw.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+
+	var hour, minute int
+	if _, err := fmt.Sscanf(mw.Start, "%d:%d", &hour, &minute); err != nil || hour < 0 || hour > 23 || minute < 0 || minute > 59 {
+		return fmt.Errorf("invalid start %q, expected HH:MM", mw.Start)
+	}
+	mw.startMin = hour*60 + minute
+
+	d, err := time.ParseDuration(mw.Duration)
+	if err != nil || d <= 0 || d > 7*24*time.Hour {
+		return fmt.Errorf("invalid duration %q", mw.Duration)
+	}
+	mw.duration = d
+
+	mw.location = time.UTC
+	if mw.Timezone != "" {
+		if mw.location, err = time.LoadLocation(mw.Timezone); err != nil {
+			return fmt.Errorf("invalid timezone: %w", err)
+		}
+	}
+
+	if len(mw.Days) > 0 {
+		mw.days = make(map[time.Weekday]bool)
+		for _, name := range mw.Days {
+			day, ok := parseWeekday(name)
+			if !ok {
+				return fmt.Errorf("invalid day %q", name)
+			}
+			mw.days[day] = true
+		}
+	}
+	return nil
+}
+
+// covers reports whether the window applies to namespace at instant t
+func (mw *MaintenanceWindow) covers(namespace string, t time.Time) bool {
+	if len(mw.Namespaces) > 0 && !containsString(mw.Namespaces, namespace) {
+		return false
+	}
+
+	local := t.In(mw.location)
+	// Check occurrences starting on previous days too, for windows spanning midnight
+	maxDays := int(mw.duration/(24*time.Hour)) + 1
+	for back := 0; back <= maxDays; back++ {
+		day := local.AddDate(0, 0, -back)
+		if mw.days != nil && !mw.days[day.Weekday()] {
+			continue
+		}
+		start := time.Date(day.Year(), day.Month(), day.Day(), 0, mw.startMin, 0, 0, mw.location)
+		if !local.Before(start) && local.Before(start.Add(mw.duration)) {
+			return true
+		}
+	}
+	return false
+}
+
+// activeMaintenance returns the name of the maintenance window covering
+// namespace at t, or "" if none
+func (s *Server) activeMaintenance(namespace string, t time.Time) string {
+	for i := range s.maintenance {
+		if s.maintenance[i].covers(namespace, t) {
+			return s.maintenance[i].Name
+		}
+	}
+	return ""
+}
+
+// handleMaintenance lists configured maintenance windows and whether each is active
+func (s *Server) handleMaintenance(w http.ResponseWriter, r *http.Request) {
+	type windowStatus struct {
+		MaintenanceWindow
+		Active bool `json:"active"`
+	}
+
+	now := time.Now()
+	windows := make([]windowStatus, 0, len(s.maintenance))
+	for _, mw := range s.maintenance {
+		active := false
+		if len(mw.Namespaces) == 0 {
+			active = mw.covers("", now)
+		}
+		for _, ns := range mw.Namespaces {
+			active = active || mw.covers(ns, now)
+		}
+		windows = append(windows, windowStatus{MaintenanceWindow: mw, Active: active})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(windows)
+}
+
+func parseWeekday(name string) (time.Weekday, bool) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(name, d.String()) || strings.EqualFold(name, d.String()[:3]) {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
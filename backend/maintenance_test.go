@@ -0,0 +1,111 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMaintenanceWindowCovers tests recurring window matching, including
+// windows spanning midnight and namespace scoping
+func TestMaintenanceWindowCovers(t *testing.T) {
+	mw := MaintenanceWindow{
+		Name:       "firmware",
+		Namespaces: []string{"icu"},
+		Days:       []string{"Sunday"},
+		Start:      "23:00",
+		Duration:   "3h",
+	}
+	if err := mw.init(); err != nil {
+		t.Fatalf("Failed to init window: %v", err)
+	}
+
+	// 2024-05-05 is a Sunday
+	tests := []struct {
+		namespace string
+		at        time.Time
+		expected  bool
+	}{
+		{"icu", time.Date(2024, 5, 5, 23, 30, 0, 0, time.UTC), true},
+		{"icu", time.Date(2024, 5, 6, 1, 59, 0, 0, time.UTC), true},
+		{"icu", time.Date(2024, 5, 6, 2, 0, 0, 0, time.UTC), false},
+		{"icu", time.Date(2024, 5, 5, 22, 59, 0, 0, time.UTC), false},
+		{"icu", time.Date(2024, 5, 6, 23, 30, 0, 0, time.UTC), false},
+		{"radiology", time.Date(2024, 5, 5, 23, 30, 0, 0, time.UTC), false},
+	}
+
+	for _, test := range tests {
+		if got := mw.covers(test.namespace, test.at); got != test.expected {
+			t.Errorf("covers(%s, %s) = %v, expected %v", test.namespace, test.at, got, test.expected)
+		}
+	}
+}
+
+// TestLoadMaintenanceWindowsValidation tests config validation
+func TestLoadMaintenanceWindowsValidation(t *testing.T) {
+	dir := t.TempDir()
+	for name, config := range map[string]string{
+		"bad-start":    `[{"name":"a","start":"25:00","duration":"1h"}]`,
+		"bad-duration": `[{"name":"a","start":"01:00","duration":"soon"}]`,
+		"bad-day":      `[{"name":"a","start":"01:00","duration":"1h","days":["Funday"]}]`,
+		"bad-tz":       `[{"name":"a","start":"01:00","duration":"1h","timezone":"Mars/Olympus"}]`,
+	} {
+		path := filepath.Join(dir, name+".json")
+		os.WriteFile(path, []byte(config), 0o600)
+		if _, err := loadMaintenanceWindows(path); err == nil {
+			t.Errorf("Expected error for %s", name)
+		}
+	}
+
+	path := filepath.Join(dir, "valid.json")
+	os.WriteFile(path, []byte(`[{"name":"nightly","start":"02:00","duration":"1h","days":["Tue","Wednesday"],"timezone":"America/Chicago"}]`), 0o600)
+	windows, err := loadMaintenanceWindows(path)
+	if err != nil || len(windows) != 1 {
+		t.Fatalf("Expected valid window, got %v (%v)", windows, err)
+	}
+}
+
+// TestMaintenanceExcludedFromStatusAndAlerts tests that violations during
+// maintenance are recorded but don't affect overall status or notifications
+func TestMaintenanceExcludedFromStatusAndAlerts(t *testing.T) {
+	collector := newMockCollector(t, "", []CollectorReport{
+		{PodName: "ai-model", Namespace: "icu", Attested: false, Timestamp: time.Now()},
+	})
+	defer collector.Close()
+
+	mw := MaintenanceWindow{Name: "always", Start: "00:00", Duration: "24h"}
+	mw.init()
+
+	history, _ := newHistory(nil, 0)
+	server := &Server{
+		collectorURL: collector.URL,
+		statusCache:  make(map[string]*WorkloadStatus),
+		httpClient:   &http.Client{Timeout: 10 * time.Second},
+		history:      history,
+		maintenance:  []MaintenanceWindow{mw},
+	}
+	server.fetchFromCollector()
+
+	status := server.statusCache["icu/ai-model"]
+	if status.Maintenance != "always" || status.AttestationStatus != "failed" {
+		t.Fatalf("Expected failed workload flagged as maintenance, got %+v", status)
+	}
+
+	events := history.Events(time.Time{}, time.Now())
+	if len(events) != 1 || events[0].Status.Maintenance != "always" {
+		t.Errorf("Expected violation recorded in history with maintenance flag, got %+v", events)
+	}
+	if notifiable(events[0]) {
+		t.Error("Expected maintenance violation not to be notified")
+	}
+
+	w := httptest.NewRecorder()
+	server.handleStatus(w, httptest.NewRequest("GET", "/api/status", nil))
+	if !strings.Contains(w.Body.String(), `"overall_status":"compliant"`) {
+		t.Errorf("Expected compliant overall status during maintenance, got %s", w.Body.String())
+	}
+}
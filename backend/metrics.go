@@ -0,0 +1,298 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Metrics holds process-lifetime counters and histograms exported at
+// /metrics in the Prometheus text format, or OpenMetrics when the scraper
+// asks for it. A nil *Metrics discards everything.
+type Metrics struct {
+	mu         sync.Mutex
+	help       map[string]string
+	counters   map[string]map[string]float64 // name -> rendered labels -> value
+	labels     map[string][]string           // rendered labels -> name/value pairs
+	histograms map[string]*histogram
+}
+
+func newMetrics() *Metrics {
+	return &Metrics{
+		help:       make(map[string]string),
+		counters:   make(map[string]map[string]float64),
+		labels:     make(map[string][]string),
+		histograms: make(map[string]*histogram),
+	}
+}
+
+// histogram is a Prometheus histogram with one series per label set
+type histogram struct {
+	buckets []float64 // upper bounds, ascending; +Inf is implied
+	series  map[string]*histogramSeries
+}
+
+// histogramSeries counts observations per bucket. Each bucket keeps the
+// latest observation that carried a trace ID as its exemplar.
+type histogramSeries struct {
+	labels    []string
+	counts    []uint64 // per bucket, not cumulative; the last is +Inf
+	exemplars []*exemplar
+	sum       float64
+	count     uint64
+}
+
+// exemplar links a histogram bucket to the trace of one observation
+type exemplar struct {
+	traceID string
+	value   float64
+	at      time.Time
+}
+
+// Add increments a counter. labels are alternating name/value pairs.
+func (m *Metrics) Add(name, help string, value float64, labels ...string) {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.help[name] = help
+	series, ok := m.counters[name]
+	if !ok {
+		series = make(map[string]float64)
+		m.counters[name] = series
+	}
+	key := formatLabels(labels...)
+	series[key] += value
+	if _, ok := m.labels[key]; !ok {
+		m.labels[key] = append([]string(nil), labels...)
+	}
+}
+
+// Inc increments a counter by one
+func (m *Metrics) Inc(name, help string, labels ...string) {
+	m.Add(name, help, 1, labels...)
+}
+
+// Observe records a histogram observation. traceID, if set, becomes the
+// exemplar of the observation's bucket. labels are alternating name/value
+// pairs; buckets must be the same on every call for a name.
+func (m *Metrics) Observe(name, help string, buckets []float64, value float64, traceID string, labels ...string) {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.help[name] = help
+	h, ok := m.histograms[name]
+	if !ok {
+		h = &histogram{buckets: buckets, series: make(map[string]*histogramSeries)}
+		m.histograms[name] = h
+	}
+	key := formatLabels(labels...)
+	series, ok := h.series[key]
+	if !ok {
+		series = &histogramSeries{
+			labels:    labels,
+			counts:    make([]uint64, len(buckets)+1),
+			exemplars: make([]*exemplar, len(buckets)+1),
+		}
+		h.series[key] = series
+	}
+
+	i := sort.SearchFloat64s(h.buckets, value)
+	series.counts[i]++
+	series.sum += value
+	series.count++
+	if traceID != "" {
+		series.exemplars[i] = &exemplar{traceID: traceID, value: value, at: time.Now()}
+	}
+}
+
+// Value returns the current value of a counter series
+func (m *Metrics) Value(name string, labels ...string) float64 {
+	if m == nil {
+		return 0
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.counters[name][formatLabels(labels...)]
+}
+
+// writeCounters writes all counters in the Prometheus text format
+func (m *Metrics) writeCounters(w io.Writer) {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	names := make([]string, 0, len(m.counters))
+	for name := range m.counters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		samples := make([]metricSample, 0, len(m.counters[name]))
+		for labels, value := range m.counters[name] {
+			samples = append(samples, metricSample{labels: labels, value: value})
+		}
+		writeMetric(w, name, "counter", m.help[name], samples)
+	}
+}
+
+// writeHistograms writes all histograms, with exemplars in OpenMetrics
+func (m *Metrics) writeHistograms(w io.Writer) {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	names := make([]string, 0, len(m.histograms))
+	for name := range m.histograms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	_, withExemplars := w.(openMetricsWriter)
+	for _, name := range names {
+		h := m.histograms[name]
+		fmt.Fprintf(w, "# HELP %s %s\n", name, m.help[name])
+		fmt.Fprintf(w, "# TYPE %s histogram\n", name)
+
+		keys := make([]string, 0, len(h.series))
+		for key := range h.series {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			series := h.series[key]
+			var cumulative uint64
+			for i, count := range series.counts {
+				cumulative += count
+				le := "+Inf"
+				if i < len(h.buckets) {
+					le = strconv.FormatFloat(h.buckets[i], 'g', -1, 64)
+				}
+				bucketLabels := append(append([]string{}, series.labels...), "le", le)
+				fmt.Fprintf(w, "%s_bucket%s %d", name, formatLabels(bucketLabels...), cumulative)
+				if ex := series.exemplars[i]; withExemplars && ex != nil {
+					fmt.Fprintf(w, " # {trace_id=%q} %g %.3f", ex.traceID, ex.value, float64(ex.at.UnixMilli())/1000)
+				}
+				fmt.Fprintln(w)
+			}
+			fmt.Fprintf(w, "%s_sum%s %g\n", name, key, series.sum)
+			fmt.Fprintf(w, "%s_count%s %d\n", name, key, series.count)
+		}
+	}
+}
+
+// openMetricsContentType is the media type of the OpenMetrics text format
+const openMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+// openMetricsWriter marks a response in the OpenMetrics text format, the
+// only exposition format that carries exemplars
+type openMetricsWriter struct {
+	io.Writer
+}
+
+// wantsOpenMetrics reports whether the scraper accepts OpenMetrics
+func wantsOpenMetrics(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "application/openmetrics-text")
+}
+
+// metricSample is one series of a metric with pre-rendered labels
+type metricSample struct {
+	labels    string
+	value     float64
+	timestamp time.Time // zero = the time of the scrape
+}
+
+// sample builds a metricSample from alternating label name/value pairs
+func sample(value float64, labels ...string) metricSample {
+	return metricSample{labels: formatLabels(labels...), value: value}
+}
+
+// writeMetric writes one metric family in the Prometheus text format
+func writeMetric(w io.Writer, name, typ, help string, samples []metricSample) {
+	sort.Slice(samples, func(i, j int) bool { return samples[i].labels < samples[j].labels })
+	family := name
+	_, openMetrics := w.(openMetricsWriter)
+	if openMetrics && typ == "counter" {
+		// OpenMetrics names counter families without the _total suffix
+		family = strings.TrimSuffix(name, "_total")
+	}
+	fmt.Fprintf(w, "# HELP %s %s\n", family, help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", family, typ)
+	for _, s := range samples {
+		switch {
+		case s.timestamp.IsZero():
+			fmt.Fprintf(w, "%s%s %g\n", name, s.labels, s.value)
+		case openMetrics:
+			// OpenMetrics timestamps are in seconds, Prometheus text in milliseconds
+			fmt.Fprintf(w, "%s%s %g %s\n", name, s.labels, s.value, strconv.FormatFloat(float64(s.timestamp.UnixMilli())/1000, 'f', -1, 64))
+		default:
+			fmt.Fprintf(w, "%s%s %g %d\n", name, s.labels, s.value, s.timestamp.UnixMilli())
+		}
+	}
+}
+
+// formatLabels renders alternating name/value pairs as {a="b",c="d"}
+func formatLabels(labels ...string) string {
+	if len(labels) < 2 {
+		return ""
+	}
+
+	parts := make([]string, 0, len(labels)/2)
+	for i := 0; i+1 < len(labels); i += 2 {
+		parts = append(parts, fmt.Sprintf("%s=%q", labels[i], labels[i+1]))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// handleMetrics exposes dashboard metrics for Prometheus scraping
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	var out io.Writer = w
+	if wantsOpenMetrics(r) {
+		w.Header().Set("Content-Type", openMetricsContentType)
+		out = openMetricsWriter{w}
+	} else {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	}
+
+	s.cacheMutex.RLock()
+	byStatus := make(map[string]float64)
+	for _, status := range s.statusCache {
+		byStatus[status.AttestationStatus]++
+	}
+	s.cacheMutex.RUnlock()
+
+	var samples []metricSample
+	for status, count := range byStatus {
+		samples = append(samples, sample(count, "status", status))
+	}
+	writeMetric(out, "dashboard_workloads", "gauge", "Number of cached workloads by attestation status.", samples)
+
+	s.writeMTTRMetrics(out)
+	s.writePolicyMetrics(out)
+	s.metrics.writeCounters(out)
+	s.metrics.writeHistograms(out)
+	if _, ok := out.(openMetricsWriter); ok {
+		fmt.Fprintln(out, "# EOF")
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+)
+
+// unknownNode groups workloads whose node could not be determined
+const unknownNode = "unknown"
+
+// handleNodes returns attestation results summarized per node
+func (s *Server) handleNodes(w http.ResponseWriter, r *http.Request) {
+	s.cacheMutex.RLock()
+	workloads := make([]WorkloadStatus, 0, len(s.statusCache))
+	for _, status := range s.statusCache {
+		if !matchesCluster(r, status) {
+			continue
+		}
+		workloads = append(workloads, *status)
+	}
+	hosts := make([]NodeReport, 0, len(s.nodeReports))
+	for _, report := range s.nodeReports {
+		if cluster := r.URL.Query().Get("cluster"); cluster != "" && report.Cluster != cluster {
+			continue
+		}
+		hosts = append(hosts, report)
+	}
+	s.cacheMutex.RUnlock()
+
+	noteWorkloads(r, workloads)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summarizeNodes(workloads, hosts))
+}
+
+// summarizeNodes groups workloads by node. A node where every workload fails
+// is "failing", which usually points at the host rather than the workloads;
+// so is a node whose own host attestation failed.
+func summarizeNodes(workloads []WorkloadStatus, hosts []NodeReport) []NodeSummary {
+	byNode := make(map[string]*NodeSummary)
+	teeSeen := make(map[string]map[string]bool)
+
+	for _, wl := range workloads {
+		name := wl.NodeName
+		if name == "" {
+			name = unknownNode
+		}
+
+		node, ok := byNode[name]
+		if !ok {
+			node = &NodeSummary{Name: name, TEETypes: []string{}, FailedWorkloads: []string{}}
+			byNode[name] = node
+			teeSeen[name] = make(map[string]bool)
+		}
+
+		node.Workloads++
+		if wl.Attested {
+			node.Attested++
+		} else {
+			node.Failed++
+			node.FailedWorkloads = append(node.FailedWorkloads, wl.Namespace+"/"+wl.Name)
+		}
+
+		if wl.TEEType != "" && !teeSeen[name][wl.TEEType] {
+			teeSeen[name][wl.TEEType] = true
+			node.TEETypes = append(node.TEETypes, wl.TEEType)
+		}
+	}
+
+	for i := range hosts {
+		node, ok := byNode[hosts[i].NodeName]
+		if !ok {
+			node = &NodeSummary{Name: hosts[i].NodeName, TEETypes: []string{}, FailedWorkloads: []string{}}
+			byNode[hosts[i].NodeName] = node
+		}
+		host := hosts[i]
+		node.HostAttestation = &host
+	}
+
+	nodes := make([]NodeSummary, 0, len(byNode))
+	for _, node := range byNode {
+		switch {
+		case node.HostAttestation != nil && !node.HostAttestation.Attested:
+			node.Status = "failing"
+		case node.Failed == 0:
+			node.Status = "healthy"
+		case node.Failed == node.Workloads:
+			node.Status = "failing"
+		default:
+			node.Status = "degraded"
+		}
+		sort.Strings(node.TEETypes)
+		sort.Strings(node.FailedWorkloads)
+		nodes = append(nodes, *node)
+	}
+
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+	return nodes
+}
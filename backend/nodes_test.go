@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestSummarizeNodes tests per-node aggregation and status classification
+func TestSummarizeNodes(t *testing.T) {
+	workloads := []WorkloadStatus{
+		{Name: "a", Namespace: "ns", NodeName: "worker-1", Attested: true, TEEType: "tdx"},
+		{Name: "b", Namespace: "ns", NodeName: "worker-1", Attested: false, TEEType: "tdx"},
+		{Name: "c", Namespace: "ns", NodeName: "worker-2", Attested: false, TEEType: "snp"},
+		{Name: "d", Namespace: "ns", NodeName: "worker-3", Attested: true, TEEType: "snp"},
+		{Name: "e", Namespace: "ns", Attested: true},
+	}
+
+	nodes := summarizeNodes(workloads, nil)
+
+	if len(nodes) != 4 {
+		t.Fatalf("Expected 4 nodes, got %d", len(nodes))
+	}
+
+	expected := map[string]string{
+		"unknown":  "healthy",
+		"worker-1": "degraded",
+		"worker-2": "failing",
+		"worker-3": "healthy",
+	}
+	for _, node := range nodes {
+		if node.Status != expected[node.Name] {
+			t.Errorf("Expected node %s status '%s', got '%s'", node.Name, expected[node.Name], node.Status)
+		}
+	}
+
+	if nodes[1].Name != "worker-1" || nodes[1].Workloads != 2 || nodes[1].Failed != 1 {
+		t.Errorf("Unexpected worker-1 summary: %+v", nodes[1])
+	}
+
+	if len(nodes[1].FailedWorkloads) != 1 || nodes[1].FailedWorkloads[0] != "ns/b" {
+		t.Errorf("Expected failed workload ns/b on worker-1, got %v", nodes[1].FailedWorkloads)
+	}
+}
+
+// TestEnrichReportsFromKubernetes tests node name enrichment from the pod spec
+func TestEnrichReportsFromKubernetes(t *testing.T) {
+	mockAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/namespaces/janine-app/pods/pod-1" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"spec":{"nodeName":"worker-7"}}`))
+	}))
+	defer mockAPI.Close()
+
+	server := &Server{
+		kube: &kubeClient{baseURL: mockAPI.URL, httpClient: mockAPI.Client()},
+	}
+
+	reports := []CollectorReport{
+		{PodName: "pod-1", Namespace: "janine-app"},
+		{PodName: "pod-2", Namespace: "janine-app", NodeName: "worker-1"},
+	}
+	for i := range reports {
+		kubeEnricher{server}.Enrich(&reports[i], &WorkloadStatus{})
+	}
+
+	if reports[0].NodeName != "worker-7" {
+		t.Errorf("Expected NodeName 'worker-7', got '%s'", reports[0].NodeName)
+	}
+
+	if reports[1].NodeName != "worker-1" {
+		t.Errorf("Expected Collector-provided NodeName to be kept, got '%s'", reports[1].NodeName)
+	}
+}
+
+// TestHandleNodes tests the /api/nodes endpoint
+func TestHandleNodes(t *testing.T) {
+	server := &Server{
+		statusCache: map[string]*WorkloadStatus{
+			"ns/a": {Name: "a", Namespace: "ns", NodeName: "worker-1", Attested: false},
+		},
+	}
+
+	req := httptest.NewRequest("GET", "/api/nodes", nil)
+	w := httptest.NewRecorder()
+	server.handleNodes(w, req)
+
+	var nodes []NodeSummary
+	if err := json.NewDecoder(w.Body).Decode(&nodes); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if len(nodes) != 1 || nodes[0].Status != "failing" {
+		t.Errorf("Expected one failing node, got %+v", nodes)
+	}
+}
@@ -0,0 +1,592 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const notificationQueueDoc = "notification_queue"
+
+// Retry policy for undelivered notifications
+const (
+	notifyRetryBase    = 10 * time.Second
+	notifyRetryMax     = time.Hour
+	notifyMaxAge       = 24 * time.Hour
+	notifyDeliveryTick = 5 * time.Second
+)
+
+// notifyTarget is a webhook receiver
+type notifyTarget struct {
+	Name    string   `json:"name"`
+	URL     string   `json:"url,omitempty"`
+	Command []string `json:"command,omitempty"` // exec notifier plugin, alternative to url
+	// ServiceNow manages incidents through the ServiceNow REST API, alternative to url
+	ServiceNow *serviceNowTarget `json:"servicenow,omitempty"`
+	// Grafana posts transitions as Grafana annotations, alternative to url
+	Grafana            *grafanaTarget `json:"grafana,omitempty"`
+	CommandTimeout     string         `json:"command_timeout,omitempty"`
+	Secret             string         `json:"secret,omitempty"`                // HMAC-SHA256 key for the X-Signature header; unsigned if empty
+	RateLimitPerMinute int            `json:"rate_limit_per_minute,omitempty"` // 0 = unlimited
+	DedupWindow        string         `json:"dedup_window,omitempty"`          // e.g. "5m"; 0 = no dedup
+	// MaxQueued bounds the undelivered notifications kept for the target,
+	// dropping the oldest beyond it; 0 = defaultNotifyMaxQueued
+	MaxQueued int `json:"max_queued,omitempty"`
+	// Teams restricts the target to workloads owned by these teams, per the
+	// ownership directory; empty = every workload
+	Teams []string `json:"teams,omitempty"`
+
+	dedupWindow    time.Duration
+	commandTimeout time.Duration
+	limiter        *rateLimiter
+}
+
+// notifyConfig is the format of the NOTIFY_CONFIG file
+type notifyConfig struct {
+	Targets []notifyTarget `json:"targets"`
+}
+
+// Notification is one payload queued for delivery to one target
+type Notification struct {
+	ID          string         `json:"id"`
+	Target      string         `json:"target"`
+	Payload     WebhookPayload `json:"payload"`
+	CreatedAt   time.Time      `json:"created_at"`
+	Attempts    int            `json:"attempts"`
+	NextAttempt time.Time      `json:"next_attempt"`
+	LastError   string         `json:"last_error,omitempty"`
+}
+
+// defaultNotifyMaxQueued is how many undelivered notifications are kept per
+// target, so a receiver that is down for days can't grow the queue unbounded
+const defaultNotifyMaxQueued = 1000
+
+// Notifier delivers workload transitions to webhook and exec plugin targets.
+// Undelivered notifications are kept in a queue persisted to the store and
+// retried with exponential backoff, including across restarts.
+type Notifier struct {
+	targets    map[string]*notifyTarget
+	httpClient *http.Client
+	store      *Store
+	wake       chan struct{}
+	redact     *redactor // PHI-safe mode; nil sends payloads as they are
+	metrics    *Metrics
+	// dashboardURL is the external base URL of the dashboard (DASHBOARD_URL),
+	// for links to workloads in tickets
+	dashboardURL string
+
+	deliverMu sync.Mutex // serializes delivery rounds
+	mu        sync.Mutex // guards queue, lastSent and inFlight
+	queue     []*Notification
+	lastSent  map[string]time.Time // target + "|" + workload key -> last delivery
+	inFlight  map[string]bool      // IDs of the notifications being sent
+}
+
+// newNotifierFromEnv configures webhook targets from the NOTIFY_CONFIG file,
+// or from WEBHOOK_URLS (comma separated) with WEBHOOK_SECRET,
+// NOTIFY_RATE_LIMIT, NOTIFY_DEDUP_WINDOW and NOTIFY_MAX_QUEUED applied to all
+// of them.
+// Returns nil if no targets are configured.
+func newNotifierFromEnv(store *Store) (*Notifier, error) {
+	if path := os.Getenv("NOTIFY_CONFIG"); path != "" {
+		var config notifyConfig
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf("invalid notify config: %w", err)
+		}
+		n, err := newNotifier(config.Targets, store)
+		if err != nil {
+			return nil, err
+		}
+		n.dashboardURL = strings.TrimRight(os.Getenv("DASHBOARD_URL"), "/")
+		return n, nil
+	}
+
+	var targets []notifyTarget
+	secret := getEnv("WEBHOOK_SECRET", "")
+	rateLimit, err := strconv.Atoi(getEnv("NOTIFY_RATE_LIMIT", "0"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid NOTIFY_RATE_LIMIT: %w", err)
+	}
+	dedupWindow := getEnv("NOTIFY_DEDUP_WINDOW", "")
+	maxQueued := getEnvInt("NOTIFY_MAX_QUEUED", 0)
+	for _, raw := range strings.Split(getEnv("WEBHOOK_URLS", ""), ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		u, err := url.Parse(raw)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid webhook URL %q", raw)
+		}
+		targets = append(targets, notifyTarget{
+			Name:               u.Host,
+			URL:                raw,
+			Secret:             secret,
+			RateLimitPerMinute: rateLimit,
+			DedupWindow:        dedupWindow,
+			MaxQueued:          maxQueued,
+		})
+	}
+	if len(targets) == 0 {
+		return nil, nil
+	}
+	return newNotifier(targets, store)
+}
+
+// newNotifier creates a notifier and restores any queued notifications
+func newNotifier(targets []notifyTarget, store *Store) (*Notifier, error) {
+	n := &Notifier{
+		targets:    make(map[string]*notifyTarget),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		store:      store,
+		wake:       make(chan struct{}, 1),
+		lastSent:   make(map[string]time.Time),
+		inFlight:   make(map[string]bool),
+	}
+	for i := range targets {
+		target := targets[i]
+		kinds := 0
+		for _, set := range []bool{target.URL != "", len(target.Command) > 0, target.ServiceNow != nil, target.Grafana != nil} {
+			if set {
+				kinds++
+			}
+		}
+		if target.Name == "" || kinds != 1 {
+			return nil, fmt.Errorf("notification target %d: name and exactly one of url, command, servicenow or grafana are required", i)
+		}
+		if target.ServiceNow != nil {
+			sn := *target.ServiceNow
+			if err := sn.init(); err != nil {
+				return nil, fmt.Errorf("target %s: %w", target.Name, err)
+			}
+			target.ServiceNow = &sn
+		}
+		if target.Grafana != nil {
+			g := *target.Grafana
+			if err := g.init(); err != nil {
+				return nil, fmt.Errorf("target %s: %w", target.Name, err)
+			}
+			target.Grafana = &g
+		}
+		target.commandTimeout = 10 * time.Second
+		if target.CommandTimeout != "" {
+			d, err := time.ParseDuration(target.CommandTimeout)
+			if err != nil {
+				return nil, fmt.Errorf("target %s: invalid command_timeout: %w", target.Name, err)
+			}
+			target.commandTimeout = d
+		}
+		if _, dup := n.targets[target.Name]; dup {
+			return nil, fmt.Errorf("duplicate notification target %q", target.Name)
+		}
+		if target.DedupWindow != "" {
+			d, err := time.ParseDuration(target.DedupWindow)
+			if err != nil {
+				return nil, fmt.Errorf("target %s: invalid dedup_window: %w", target.Name, err)
+			}
+			target.dedupWindow = d
+		}
+		if target.RateLimitPerMinute > 0 {
+			target.limiter = newRateLimiter(target.RateLimitPerMinute, time.Minute)
+		}
+		if target.MaxQueued < 0 {
+			return nil, fmt.Errorf("target %s: max_queued must not be negative", target.Name)
+		}
+		if target.MaxQueued == 0 {
+			target.MaxQueued = defaultNotifyMaxQueued
+		}
+		n.targets[target.Name] = &target
+	}
+
+	if _, err := store.LoadDoc(notificationQueueDoc, &n.queue); err != nil {
+		return nil, fmt.Errorf("failed to load notification queue: %w", err)
+	}
+	if len(n.queue) > 0 {
+		log.Printf("Restored %d undelivered notifications", len(n.queue))
+	}
+	return n, nil
+}
+
+// Notify queues the given history events for delivery to every target.
+// Within a target's dedup window, further transitions of the same workload
+// are folded into one pending alert whose FlapCount records how many
+// transitions it covers.
+func (n *Notifier) Notify(events []HistoryEvent) {
+	if n == nil {
+		return
+	}
+
+	now := time.Now()
+	queued := 0
+
+	n.mu.Lock()
+	for _, event := range events {
+		if !notifiable(event) {
+			continue
+		}
+		payload := WebhookPayload{
+			Event:          "workload." + event.Type,
+			Time:           event.Time,
+			Key:            event.Key,
+			PreviousStatus: event.PreviousStatus,
+			Workload:       event.Status,
+			FlapCount:      1,
+		}
+		if event.Type == "flapping" {
+			payload.FlapCount = event.Status.FlapCount
+			payload.Summary = fmt.Sprintf("%s is flapping: %d verdict changes, currently %s; further changes are suppressed until it stabilizes",
+				event.Key, event.Status.FlapCount, event.Status.AttestationStatus)
+		}
+		// Redacted before queueing, so the persisted queue holds no PHI either
+		payload = n.redact.payload(payload)
+		for name, target := range n.targets {
+			if !target.routes(payload) {
+				continue
+			}
+			if n.consolidateLocked(target, payload, now) {
+				continue
+			}
+			notification := &Notification{
+				ID:          newNotificationID(),
+				Target:      name,
+				Payload:     payload,
+				CreatedAt:   now,
+				NextAttempt: now,
+			}
+			// Hold until the dedup window since the last delivery has passed
+			if last, ok := n.lastSent[name+"|"+payload.Key]; ok && target.dedupWindow > 0 {
+				if release := last.Add(target.dedupWindow); release.After(now) {
+					notification.NextAttempt = release
+				}
+			}
+			n.queue = append(n.queue, notification)
+			n.boundQueueLocked(target)
+			queued++
+		}
+	}
+	if queued > 0 {
+		n.persistLocked()
+	}
+	n.mu.Unlock()
+
+	// Deliver from the run loop so a slow receiver never blocks the poller
+	select {
+	case n.wake <- struct{}{}:
+	default:
+	}
+}
+
+// boundQueueLocked drops a target's oldest undelivered notification once it
+// has more than its max_queued. Caller must hold mu.
+func (n *Notifier) boundQueueLocked(target *notifyTarget) {
+	count, oldest := 0, -1
+	for i, pending := range n.queue {
+		if pending.Target != target.Name {
+			continue
+		}
+		if oldest < 0 {
+			oldest = i
+		}
+		count++
+	}
+	if count <= target.MaxQueued {
+		return
+	}
+	dropped := n.queue[oldest]
+	n.queue = append(n.queue[:oldest], n.queue[oldest+1:]...)
+	log.Printf("Dropping undelivered notification %s for %s to %s: more than %d queued", dropped.ID, dropped.Payload.Key, target.Name, target.MaxQueued)
+	n.metrics.Inc("dashboard_notifications_dropped_total", "Undelivered notifications dropped because a target's queue was full.", "target", target.Name)
+}
+
+// routes reports whether a payload goes to the target, given the teams the
+// target is restricted to
+func (t *notifyTarget) routes(payload WebhookPayload) bool {
+	if len(t.Teams) == 0 {
+		return true
+	}
+	workload := payload.Workload
+	return workload != nil && workload.Owner != nil && containsString(t.Teams, workload.Owner.Team)
+}
+
+// consolidateLocked folds payload into a not-yet-attempted notification for
+// the same workload and target, if the target dedups. A notification being
+// sent is already on its way with its payload, so it takes no more
+// transitions. Caller must hold mu.
+func (n *Notifier) consolidateLocked(target *notifyTarget, payload WebhookPayload, now time.Time) bool {
+	if target.dedupWindow <= 0 {
+		return false
+	}
+	for _, pending := range n.queue {
+		if pending.Target != target.Name || pending.Payload.Key != payload.Key || pending.Attempts > 0 || n.inFlight[pending.ID] {
+			continue
+		}
+		if now.Sub(pending.CreatedAt) > target.dedupWindow {
+			continue
+		}
+		flaps := pending.Payload.FlapCount + payload.FlapCount
+		previous := pending.Payload.PreviousStatus
+		pending.Payload = payload
+		pending.Payload.PreviousStatus = previous
+		pending.Payload.FlapCount = flaps
+		return true
+	}
+	return false
+}
+
+// notifiable filters out events nobody needs to be paged for: new workloads
+// that are healthy on arrival, workloads entering their removal grace
+// period, violations during a maintenance window, and the individual
+// transitions of a flapping workload (covered by its single "flapping" alert)
+func notifiable(event HistoryEvent) bool {
+	if event.Type == "terminating" {
+		return false
+	}
+	if event.Type != "removed" && event.Status != nil && event.Status.Maintenance != "" {
+		return false
+	}
+	if event.Type != "removed" && event.Type != "flapping" && event.Status != nil && event.Status.Flapping {
+		return false
+	}
+	if event.Type == "added" {
+		return event.Status != nil && isViolation(event.Status)
+	}
+	return true
+}
+
+// run retries queued notifications until the process exits
+func (n *Notifier) run() {
+	if n == nil {
+		return
+	}
+
+	ticker := time.NewTicker(notifyDeliveryTick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-n.wake:
+		}
+		n.deliverDue()
+	}
+}
+
+// deliverDue attempts delivery of every notification whose retry time has come
+func (n *Notifier) deliverDue() {
+	n.deliverMu.Lock()
+	defer n.deliverMu.Unlock()
+
+	n.mu.Lock()
+	now := time.Now()
+	var due []*Notification
+	for _, notification := range n.queue {
+		if !notification.NextAttempt.After(now) {
+			due = append(due, notification)
+		}
+	}
+	n.mu.Unlock()
+
+	if len(due) == 0 {
+		return
+	}
+
+	delivered := make(map[string]bool)
+	for _, notification := range due {
+		if target, ok := n.targets[notification.Target]; ok && target.limiter != nil {
+			if wait := target.limiter.reserve(time.Now()); wait > 0 {
+				n.mu.Lock()
+				notification.NextAttempt = time.Now().Add(wait)
+				n.mu.Unlock()
+				continue
+			}
+		}
+
+		// Sent from a copy, marked in flight so no transition is folded into
+		// a payload that has already gone out
+		n.mu.Lock()
+		n.inFlight[notification.ID] = true
+		sending := *notification
+		n.mu.Unlock()
+
+		err := n.send(sending)
+
+		n.mu.Lock()
+		delete(n.inFlight, notification.ID)
+		notification.Attempts++
+		if err == nil {
+			delivered[notification.ID] = true
+			n.lastSent[notification.Target+"|"+notification.Payload.Key] = time.Now()
+		} else {
+			notification.LastError = err.Error()
+			notification.NextAttempt = time.Now().Add(retryBackoff(notification.Attempts))
+			log.Printf("Notification %s to %s failed (attempt %d): %v", notification.ID, notification.Target, notification.Attempts, err)
+		}
+		n.mu.Unlock()
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	remaining := n.queue[:0]
+	for _, notification := range n.queue {
+		if delivered[notification.ID] {
+			continue
+		}
+		if time.Since(notification.CreatedAt) > notifyMaxAge {
+			log.Printf("Dropping notification %s to %s after %d attempts: %s", notification.ID, notification.Target, notification.Attempts, notification.LastError)
+			continue
+		}
+		remaining = append(remaining, notification)
+	}
+	n.queue = remaining
+	n.persistLocked()
+}
+
+// send posts a copy of a queued notification to its target
+func (n *Notifier) send(notification Notification) error {
+	target, ok := n.targets[notification.Target]
+	if !ok {
+		return fmt.Errorf("target %s is no longer configured", notification.Target)
+	}
+	payload := notification.Payload
+
+	if len(target.Command) > 0 {
+		return sendExec(target, payload)
+	}
+	if target.ServiceNow != nil {
+		return n.sendServiceNow(target, payload)
+	}
+	if target.Grafana != nil {
+		return n.sendGrafana(target, payload)
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, target.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Notification-ID", notification.ID)
+	if target.Secret != "" {
+		req.Header.Set("X-Signature", signPayload(target.Secret, body))
+	}
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("receiver returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// workloadLink returns the dashboard URL of a workload, or "" without DASHBOARD_URL
+func (n *Notifier) workloadLink(key string) string {
+	if n.dashboardURL == "" {
+		return ""
+	}
+	return n.dashboardURL + "/api/workload/" + key
+}
+
+// persistLocked saves the queue to the store. Caller must hold mu.
+func (n *Notifier) persistLocked() {
+	if err := n.store.SaveDoc(notificationQueueDoc, n.queue); err != nil {
+		log.Printf("Failed to persist notification queue: %v", err)
+	}
+}
+
+// Pending returns the number of undelivered notifications
+func (n *Notifier) Pending() int {
+	if n == nil {
+		return 0
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return len(n.queue)
+}
+
+// signPayload returns the X-Signature header value: "sha256=" + hex HMAC of the body
+func signPayload(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// retryBackoff doubles the delay per attempt, capped at notifyRetryMax
+func retryBackoff(attempts int) time.Duration {
+	delay := notifyRetryBase
+	for i := 1; i < attempts && delay < notifyRetryMax; i++ {
+		delay *= 2
+	}
+	if delay > notifyRetryMax {
+		delay = notifyRetryMax
+	}
+	return delay
+}
+
+// rateLimiter is a token bucket allowing limit events per period
+type rateLimiter struct {
+	mu       sync.Mutex
+	capacity float64
+	tokens   float64
+	rate     float64 // tokens per second
+	last     time.Time
+}
+
+func newRateLimiter(limit int, period time.Duration) *rateLimiter {
+	return &rateLimiter{
+		capacity: float64(limit),
+		tokens:   float64(limit),
+		rate:     float64(limit) / period.Seconds(),
+	}
+}
+
+// reserve takes a token if available and returns 0, otherwise returns how
+// long to wait until one will be
+func (l *rateLimiter) reserve(now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.capacity {
+			l.tokens = l.capacity
+		}
+	}
+	l.last = now
+
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+	return time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+}
+
+func newNotificationID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// webhookReceiver records deliveries and can be switched to fail
+type webhookReceiver struct {
+	mu       sync.Mutex
+	fail     bool
+	bodies   [][]byte
+	headers  []http.Header
+	received int
+}
+
+func (rec *webhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if rec.fail {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+	rec.bodies = append(rec.bodies, body)
+	rec.headers = append(rec.headers, r.Header.Clone())
+	rec.received++
+}
+
+func violationEvent(key string) HistoryEvent {
+	return HistoryEvent{
+		Time:           time.Now(),
+		Key:            key,
+		Type:           "changed",
+		PreviousStatus: "verified",
+		Status:         &WorkloadStatus{Name: "a", Namespace: "icu", Attested: false, AttestationStatus: "failed"},
+	}
+}
+
+// TestNotifierSignsPayloads tests the X-Signature HMAC header
+func TestNotifierSignsPayloads(t *testing.T) {
+	receiver := &webhookReceiver{}
+	srv := httptest.NewServer(receiver)
+	defer srv.Close()
+
+	notifier, err := newNotifier([]notifyTarget{{Name: "hook", URL: srv.URL, Secret: "s3cret"}}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create notifier: %v", err)
+	}
+
+	notifier.Notify([]HistoryEvent{violationEvent("icu/a")})
+	notifier.deliverDue()
+
+	if receiver.received != 1 {
+		t.Fatalf("Expected 1 delivery, got %d", receiver.received)
+	}
+
+	expected := signPayload("s3cret", receiver.bodies[0])
+	if got := receiver.headers[0].Get("X-Signature"); got != expected {
+		t.Errorf("Expected X-Signature %s, got %s", expected, got)
+	}
+
+	var payload WebhookPayload
+	if err := json.Unmarshal(receiver.bodies[0], &payload); err != nil {
+		t.Fatalf("Failed to decode payload: %v", err)
+	}
+	if payload.Event != "workload.changed" || payload.Key != "icu/a" {
+		t.Errorf("Unexpected payload: %+v", payload)
+	}
+
+	if notifier.Pending() != 0 {
+		t.Errorf("Expected empty queue after delivery, got %d", notifier.Pending())
+	}
+}
+
+// TestNotifierRetriesAcrossRestart tests that undelivered notifications are
+// persisted and delivered by a new notifier instance
+func TestNotifierRetriesAcrossRestart(t *testing.T) {
+	receiver := &webhookReceiver{fail: true}
+	srv := httptest.NewServer(receiver)
+	defer srv.Close()
+
+	store, _ := openStore(t.TempDir())
+	targets := []notifyTarget{{Name: "hook", URL: srv.URL}}
+
+	first, _ := newNotifier(targets, store)
+	first.Notify([]HistoryEvent{violationEvent("icu/a")})
+	first.deliverDue()
+
+	if first.Pending() != 1 {
+		t.Fatalf("Expected 1 pending notification, got %d", first.Pending())
+	}
+	if first.queue[0].Attempts != 1 || !first.queue[0].NextAttempt.After(time.Now()) {
+		t.Errorf("Expected backoff after failed attempt, got %+v", first.queue[0])
+	}
+
+	// Simulate a restart with the receiver back up
+	receiver.fail = false
+	second, _ := newNotifier(targets, store)
+	if second.Pending() != 1 {
+		t.Fatalf("Expected restored notification, got %d", second.Pending())
+	}
+	second.queue[0].NextAttempt = time.Now()
+	second.deliverDue()
+
+	if receiver.received != 1 || second.Pending() != 0 {
+		t.Errorf("Expected delivery after restart, received=%d pending=%d", receiver.received, second.Pending())
+	}
+}
+
+// TestNotifiable tests that healthy new workloads don't trigger notifications
+func TestNotifiable(t *testing.T) {
+	healthy := HistoryEvent{Type: "added", Status: &WorkloadStatus{Attested: true}}
+	broken := HistoryEvent{Type: "added", Status: &WorkloadStatus{Attested: false}}
+
+	if notifiable(healthy) {
+		t.Error("Expected healthy new workload to be skipped")
+	}
+	if !notifiable(broken) || !notifiable(violationEvent("x")) {
+		t.Error("Expected violations and changes to be notified")
+	}
+	if notifiable(HistoryEvent{Type: "terminating", Status: &WorkloadStatus{Attested: false}}) {
+		t.Error("Expected terminating workload to be skipped")
+	}
+}
+
+// TestRetryBackoff tests exponential backoff with a cap
+func TestRetryBackoff(t *testing.T) {
+	if retryBackoff(1) != notifyRetryBase || retryBackoff(3) != 4*notifyRetryBase {
+		t.Errorf("Unexpected backoff: %s, %s", retryBackoff(1), retryBackoff(3))
+	}
+	if retryBackoff(50) != notifyRetryMax {
+		t.Errorf("Expected backoff capped at %s, got %s", notifyRetryMax, retryBackoff(50))
+	}
+}
+
+// TestNotifierDedupWindow tests that a flapping workload produces a single
+// consolidated alert per window
+func TestNotifierDedupWindow(t *testing.T) {
+	receiver := &webhookReceiver{}
+	srv := httptest.NewServer(receiver)
+	defer srv.Close()
+
+	notifier, err := newNotifier([]notifyTarget{{Name: "slack", URL: srv.URL, DedupWindow: "5m"}}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create notifier: %v", err)
+	}
+
+	// First transition goes out immediately
+	notifier.Notify([]HistoryEvent{violationEvent("icu/a")})
+	notifier.deliverDue()
+
+	// Subsequent flaps within the window are held and merged
+	for i := 0; i < 5; i++ {
+		notifier.Notify([]HistoryEvent{violationEvent("icu/a")})
+	}
+	notifier.deliverDue()
+
+	if receiver.received != 1 {
+		t.Fatalf("Expected 1 delivery inside the window, got %d", receiver.received)
+	}
+	if notifier.Pending() != 1 {
+		t.Fatalf("Expected 1 consolidated pending alert, got %d", notifier.Pending())
+	}
+	if flaps := notifier.queue[0].Payload.FlapCount; flaps != 5 {
+		t.Errorf("Expected flap count 5, got %d", flaps)
+	}
+	if !notifier.queue[0].NextAttempt.After(time.Now().Add(4 * time.Minute)) {
+		t.Errorf("Expected alert to be held until the window ends, got %s", notifier.queue[0].NextAttempt)
+	}
+
+	// Other workloads are not affected
+	notifier.Notify([]HistoryEvent{violationEvent("icu/b")})
+	notifier.deliverDue()
+	if receiver.received != 2 {
+		t.Errorf("Expected icu/b to be delivered immediately, got %d deliveries", receiver.received)
+	}
+}
+
+// TestNotifierDedupDuringSend tests that a transition arriving while the
+// previous one is being sent is delivered after it, not folded into the
+// payload already on its way
+func TestNotifierDedupDuringSend(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	var mu sync.Mutex
+	var events []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload WebhookPayload
+		json.NewDecoder(r.Body).Decode(&payload)
+		mu.Lock()
+		events = append(events, payload.Workload.AttestationStatus)
+		first := len(events) == 1
+		mu.Unlock()
+		if first {
+			close(started)
+			<-release
+		}
+	}))
+	defer srv.Close()
+
+	notifier, err := newNotifier([]notifyTarget{{Name: "slack", URL: srv.URL, DedupWindow: "5m"}}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create notifier: %v", err)
+	}
+
+	notifier.Notify([]HistoryEvent{violationEvent("icu/a")})
+	done := make(chan struct{})
+	go func() {
+		notifier.deliverDue()
+		close(done)
+	}()
+	<-started
+
+	recovered := violationEvent("icu/a")
+	recovered.PreviousStatus = "failed"
+	recovered.Status = verifiedStatus("icu", "a")
+	notifier.Notify([]HistoryEvent{recovered})
+	close(release)
+	<-done
+
+	if notifier.Pending() != 1 {
+		t.Fatalf("Expected the recovery queued on its own, got %d pending", notifier.Pending())
+	}
+	notifier.deliverDue()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != 2 || events[0] != "failed" || events[1] != "verified" {
+		t.Errorf("Expected the violation then the recovery delivered, got %v", events)
+	}
+}
+
+// TestNotifierRateLimit tests per-target rate limiting
+func TestNotifierRateLimit(t *testing.T) {
+	receiver := &webhookReceiver{}
+	srv := httptest.NewServer(receiver)
+	defer srv.Close()
+
+	notifier, _ := newNotifier([]notifyTarget{{Name: "slack", URL: srv.URL, RateLimitPerMinute: 2}}, nil)
+
+	notifier.Notify([]HistoryEvent{violationEvent("icu/a"), violationEvent("icu/b"), violationEvent("icu/c")})
+	notifier.deliverDue()
+
+	if receiver.received != 2 {
+		t.Errorf("Expected 2 deliveries within the rate limit, got %d", receiver.received)
+	}
+	if notifier.Pending() != 1 || notifier.queue[0].Attempts != 0 {
+		t.Errorf("Expected 1 postponed notification without a failed attempt, got %+v", notifier.queue)
+	}
+}
+
+// TestNotifierMaxQueued tests that an unreachable target keeps only its
+// newest undelivered notifications
+func TestNotifierMaxQueued(t *testing.T) {
+	notifier, err := newNotifier([]notifyTarget{
+		{Name: "down", URL: "http://127.0.0.1:1", MaxQueued: 2},
+		{Name: "pager", URL: "http://127.0.0.1:1"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create notifier: %v", err)
+	}
+	notifier.metrics = newMetrics()
+
+	notifier.Notify([]HistoryEvent{violationEvent("icu/a"), violationEvent("icu/b"), violationEvent("icu/c")})
+
+	var kept []string
+	for _, pending := range notifier.queue {
+		if pending.Target == "down" {
+			kept = append(kept, pending.Payload.Key)
+		}
+	}
+	if len(kept) != 2 || kept[0] != "icu/b" || kept[1] != "icu/c" {
+		t.Errorf("Expected the 2 newest notifications to be kept, got %v", kept)
+	}
+	if notifier.Pending() != 5 {
+		t.Errorf("Expected the other target's queue to be unaffected, got %d pending", notifier.Pending())
+	}
+	if got := notifier.metrics.Value("dashboard_notifications_dropped_total", "target", "down"); got != 1 {
+		t.Errorf("Expected 1 dropped notification, got %v", got)
+	}
+
+	if _, err := newNotifier([]notifyTarget{{Name: "down", URL: "http://127.0.0.1:1", MaxQueued: -1}}, nil); err == nil {
+		t.Error("Expected a negative max_queued to be rejected")
+	}
+}
+
+// TestRateLimiter tests token bucket refill
+func TestRateLimiter(t *testing.T) {
+	limiter := newRateLimiter(1, time.Minute)
+	now := time.Now()
+
+	if limiter.reserve(now) != 0 {
+		t.Error("Expected first reservation to succeed")
+	}
+	if wait := limiter.reserve(now); wait <= 0 || wait > time.Minute {
+		t.Errorf("Expected to wait up to a minute, got %s", wait)
+	}
+	if limiter.reserve(now.Add(time.Minute)) != 0 {
+		t.Error("Expected token to be refilled after a minute")
+	}
+}
@@ -0,0 +1,457 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// otlpScope names the instrumentation scope of exported metrics and logs
+	otlpScope = "github.com/rh-summit-coco/raj-hospital-dashboard/backend"
+	// otlpLogInterval is how often queued log records are exported
+	otlpLogInterval = 5 * time.Second
+	// otlpMaxQueuedLogs bounds the log records kept while the collector is
+	// unreachable; the oldest are dropped
+	otlpMaxQueuedLogs = 2048
+)
+
+// otlpExporter pushes metrics and log lines to an OpenTelemetry collector
+// over OTLP/HTTP with JSON encoding, for sites that collect telemetry with
+// an OpenTelemetry collector rather than by scraping /metrics and tailing
+// container logs. It is configured with the standard OTEL_* variables.
+type otlpExporter struct {
+	endpoint       string // base URL; signals are posted to /v1/metrics and /v1/logs
+	headers        map[string]string
+	resource       []otlpKeyValue
+	exportMetrics  bool
+	exportLogs     bool
+	metricInterval time.Duration
+	httpClient     *http.Client
+
+	// Set once the server is created
+	metrics *Metrics
+	gauges  func() []otlpMetric // metrics computed at export time
+
+	mu      sync.Mutex
+	logs    []otlpLogRecord
+	dropped int
+	failing map[string]bool // by signal, so failures are logged once
+}
+
+// newOTLPExporterFromEnv configures the exporter from
+// OTEL_EXPORTER_OTLP_ENDPOINT and related variables. Returns nil if no
+// endpoint is set.
+func newOTLPExporterFromEnv() (*otlpExporter, error) {
+	endpoint := strings.TrimRight(os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"), "/")
+	if endpoint == "" {
+		return nil, nil
+	}
+	if u, err := url.Parse(endpoint); err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid OTEL_EXPORTER_OTLP_ENDPOINT %q", endpoint)
+	}
+	if protocol := getEnv("OTEL_EXPORTER_OTLP_PROTOCOL", "http/json"); protocol != "http/json" {
+		return nil, fmt.Errorf("unsupported OTEL_EXPORTER_OTLP_PROTOCOL %q, only http/json is supported", protocol)
+	}
+
+	e := &otlpExporter{
+		endpoint:      endpoint,
+		headers:       parseOTLPPairs(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS")),
+		exportMetrics: getEnv("OTEL_METRICS_EXPORTER", "otlp") != "none",
+		exportLogs:    getEnv("OTEL_LOGS_EXPORTER", "otlp") != "none",
+		httpClient:    &http.Client{Timeout: 10 * time.Second},
+		failing:       make(map[string]bool),
+	}
+
+	// The specification gives the interval in milliseconds
+	interval, err := strconv.Atoi(getEnv("OTEL_METRIC_EXPORT_INTERVAL", "60000"))
+	if err != nil || interval <= 0 {
+		return nil, fmt.Errorf("invalid OTEL_METRIC_EXPORT_INTERVAL %q, expected milliseconds", os.Getenv("OTEL_METRIC_EXPORT_INTERVAL"))
+	}
+	e.metricInterval = time.Duration(interval) * time.Millisecond
+
+	attributes := map[string]string{
+		"service.name":    getEnv("OTEL_SERVICE_NAME", "hospital-dashboard-backend"),
+		"service.version": version,
+	}
+	if cluster := os.Getenv("CLUSTER_NAME"); cluster != "" {
+		attributes["k8s.cluster.name"] = cluster
+	}
+	if site := os.Getenv("SITE_NAME"); site != "" {
+		attributes["site"] = site
+	}
+	for key, value := range parseOTLPPairs(os.Getenv("OTEL_RESOURCE_ATTRIBUTES")) {
+		attributes[key] = value
+	}
+	e.resource = otlpAttributes(attributes)
+	return e, nil
+}
+
+// parseOTLPPairs parses the key=value,key=value lists of OTEL_* variables,
+// whose values may be percent-encoded
+func parseOTLPPairs(list string) map[string]string {
+	pairs := make(map[string]string)
+	for _, item := range strings.Split(list, ",") {
+		key, value, ok := strings.Cut(item, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			continue
+		}
+		if decoded, err := url.PathUnescape(strings.TrimSpace(value)); err == nil {
+			value = decoded
+		}
+		pairs[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return pairs
+}
+
+// OTLP JSON encoding. 64-bit integers are strings, as in the protobuf JSON
+// mapping.
+type otlpKeyValue struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpInstrumentationScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type otlpMetric struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Sum         *otlpSum       `json:"sum,omitempty"`
+	Gauge       *otlpGauge     `json:"gauge,omitempty"`
+	Histogram   *otlpHistogram `json:"histogram,omitempty"`
+}
+
+// otlpCumulative is AGGREGATION_TEMPORALITY_CUMULATIVE
+const otlpCumulative = 2
+
+type otlpSum struct {
+	DataPoints             []otlpNumberDataPoint `json:"dataPoints"`
+	AggregationTemporality int                   `json:"aggregationTemporality"`
+	IsMonotonic            bool                  `json:"isMonotonic"`
+}
+
+type otlpGauge struct {
+	DataPoints []otlpNumberDataPoint `json:"dataPoints"`
+}
+
+type otlpNumberDataPoint struct {
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano,omitempty"`
+	TimeUnixNano      string         `json:"timeUnixNano"`
+	AsDouble          float64        `json:"asDouble"`
+}
+
+type otlpHistogram struct {
+	DataPoints             []otlpHistogramDataPoint `json:"dataPoints"`
+	AggregationTemporality int                      `json:"aggregationTemporality"`
+}
+
+type otlpHistogramDataPoint struct {
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	TimeUnixNano      string         `json:"timeUnixNano"`
+	Count             string         `json:"count"`
+	Sum               float64        `json:"sum"`
+	BucketCounts      []string       `json:"bucketCounts"`
+	ExplicitBounds    []float64      `json:"explicitBounds"`
+	Exemplars         []otlpExemplar `json:"exemplars,omitempty"`
+}
+
+type otlpExemplar struct {
+	TimeUnixNano string  `json:"timeUnixNano"`
+	AsDouble     float64 `json:"asDouble"`
+	TraceID      string  `json:"traceId"` // hex, as in the OTLP JSON encoding
+}
+
+type otlpMetricsRequest struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource     otlpResource       `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpScopeMetrics struct {
+	Scope   otlpInstrumentationScope `json:"scope"`
+	Metrics []otlpMetric             `json:"metrics"`
+}
+
+type otlpLogRecord struct {
+	TimeUnixNano         string    `json:"timeUnixNano"`
+	ObservedTimeUnixNano string    `json:"observedTimeUnixNano"`
+	SeverityNumber       int       `json:"severityNumber"`
+	SeverityText         string    `json:"severityText"`
+	Body                 otlpValue `json:"body"`
+}
+
+type otlpLogsRequest struct {
+	ResourceLogs []otlpResourceLogs `json:"resourceLogs"`
+}
+
+type otlpResourceLogs struct {
+	Resource  otlpResource    `json:"resource"`
+	ScopeLogs []otlpScopeLogs `json:"scopeLogs"`
+}
+
+type otlpScopeLogs struct {
+	Scope      otlpInstrumentationScope `json:"scope"`
+	LogRecords []otlpLogRecord          `json:"logRecords"`
+}
+
+// otlpAttributes converts a map to sorted attributes
+func otlpAttributes(m map[string]string) []otlpKeyValue {
+	attributes := make([]otlpKeyValue, 0, len(m))
+	for key, value := range m {
+		attributes = append(attributes, otlpKeyValue{Key: key, Value: otlpValue{StringValue: value}})
+	}
+	sort.Slice(attributes, func(i, j int) bool { return attributes[i].Key < attributes[j].Key })
+	return attributes
+}
+
+// otlpLabelAttributes converts alternating label name/value pairs
+func otlpLabelAttributes(labels []string) []otlpKeyValue {
+	m := make(map[string]string, len(labels)/2)
+	for i := 0; i+1 < len(labels); i += 2 {
+		m[labels[i]] = labels[i+1]
+	}
+	return otlpAttributes(m)
+}
+
+func otlpTime(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// otlpMetrics converts every counter and histogram to OTLP metrics with
+// cumulative temporality since the process started. Histogram exemplars
+// keep their trace IDs.
+func (m *Metrics) otlpMetrics(now time.Time) []otlpMetric {
+	if m == nil {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	start, at := otlpTime(processStart), otlpTime(now)
+	var metrics []otlpMetric
+	for name, series := range m.counters {
+		sum := &otlpSum{AggregationTemporality: otlpCumulative, IsMonotonic: true}
+		for key, value := range series {
+			sum.DataPoints = append(sum.DataPoints, otlpNumberDataPoint{
+				Attributes:        otlpLabelAttributes(m.labels[key]),
+				StartTimeUnixNano: start,
+				TimeUnixNano:      at,
+				AsDouble:          value,
+			})
+		}
+		metrics = append(metrics, otlpMetric{Name: name, Description: m.help[name], Sum: sum})
+	}
+	for name, h := range m.histograms {
+		histogram := &otlpHistogram{AggregationTemporality: otlpCumulative}
+		for _, series := range h.series {
+			point := otlpHistogramDataPoint{
+				Attributes:        otlpLabelAttributes(series.labels),
+				StartTimeUnixNano: start,
+				TimeUnixNano:      at,
+				Count:             strconv.FormatUint(series.count, 10),
+				Sum:               series.sum,
+				ExplicitBounds:    h.buckets,
+			}
+			for _, count := range series.counts {
+				point.BucketCounts = append(point.BucketCounts, strconv.FormatUint(count, 10))
+			}
+			for _, ex := range series.exemplars {
+				if ex != nil {
+					point.Exemplars = append(point.Exemplars, otlpExemplar{TimeUnixNano: otlpTime(ex.at), AsDouble: ex.value, TraceID: ex.traceID})
+				}
+			}
+			histogram.DataPoints = append(histogram.DataPoints, point)
+		}
+		metrics = append(metrics, otlpMetric{Name: name, Description: m.help[name], Histogram: histogram})
+	}
+	sort.Slice(metrics, func(i, j int) bool { return metrics[i].Name < metrics[j].Name })
+	return metrics
+}
+
+// otlpGauges returns the metrics /metrics computes at scrape time
+func (s *Server) otlpGauges() []otlpMetric {
+	s.cacheMutex.RLock()
+	byStatus := make(map[string]float64)
+	for _, status := range s.statusCache {
+		byStatus[status.AttestationStatus]++
+	}
+	s.cacheMutex.RUnlock()
+
+	gauge := &otlpGauge{}
+	at := otlpTime(time.Now())
+	for status, count := range byStatus {
+		gauge.DataPoints = append(gauge.DataPoints, otlpNumberDataPoint{
+			Attributes:   otlpLabelAttributes([]string{"status", status}),
+			TimeUnixNano: at,
+			AsDouble:     count,
+		})
+	}
+	sort.Slice(gauge.DataPoints, func(i, j int) bool {
+		return gauge.DataPoints[i].Attributes[0].Value.StringValue < gauge.DataPoints[j].Attributes[0].Value.StringValue
+	})
+	return []otlpMetric{{Name: "dashboard_workloads", Description: "Number of cached workloads by attestation status.", Gauge: gauge}}
+}
+
+// Write queues a line of log output as a log record. It is installed as
+// part of the log output and never blocks on the network.
+func (e *otlpExporter) Write(p []byte) (int, error) {
+	now := time.Now()
+	line := strings.TrimRight(string(p), "\n")
+	logged := now
+	// Strip the standard log prefix, keeping its time
+	if len(line) > 20 && line[19] == ' ' {
+		if t, err := time.ParseInLocation("2006/01/02 15:04:05", line[:19], time.Local); err == nil {
+			logged, line = t, line[20:]
+		}
+	}
+	severity, number := "INFO", 9
+	if strings.HasPrefix(line, "Failed") || strings.HasPrefix(line, "Warning") {
+		severity, number = "WARN", 13
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.logs = append(e.logs, otlpLogRecord{
+		TimeUnixNano:         otlpTime(logged),
+		ObservedTimeUnixNano: otlpTime(now),
+		SeverityNumber:       number,
+		SeverityText:         severity,
+		Body:                 otlpValue{StringValue: line},
+	})
+	if len(e.logs) > otlpMaxQueuedLogs {
+		e.dropped += len(e.logs) - otlpMaxQueuedLogs
+		e.logs = append([]otlpLogRecord(nil), e.logs[len(e.logs)-otlpMaxQueuedLogs:]...)
+	}
+	return len(p), nil
+}
+
+// run exports metrics and logs at their intervals
+func (e *otlpExporter) run() {
+	metricTicker := time.NewTicker(e.metricInterval)
+	defer metricTicker.Stop()
+	logTicker := time.NewTicker(otlpLogInterval)
+	defer logTicker.Stop()
+
+	for {
+		select {
+		case <-metricTicker.C:
+			if e.exportMetrics {
+				e.flushMetrics()
+			}
+		case <-logTicker.C:
+			if e.exportLogs {
+				e.flushLogs()
+			}
+		}
+	}
+}
+
+// flushMetrics exports the current value of every metric
+func (e *otlpExporter) flushMetrics() {
+	metrics := e.metrics.otlpMetrics(time.Now())
+	if e.gauges != nil {
+		metrics = append(metrics, e.gauges()...)
+	}
+	if len(metrics) == 0 {
+		return
+	}
+
+	e.export("metrics", otlpMetricsRequest{ResourceMetrics: []otlpResourceMetrics{{
+		Resource:     otlpResource{Attributes: e.resource},
+		ScopeMetrics: []otlpScopeMetrics{{Scope: otlpInstrumentationScope{Name: otlpScope, Version: version}, Metrics: metrics}},
+	}}})
+}
+
+// flushLogs exports the queued log records. Records are dropped if the
+// export fails, as the lines were also written to stderr.
+func (e *otlpExporter) flushLogs() {
+	e.mu.Lock()
+	records, dropped := e.logs, e.dropped
+	e.logs, e.dropped = nil, 0
+	e.mu.Unlock()
+	if dropped > 0 {
+		e.metrics.Add("dashboard_otlp_dropped_logs_total", "Log records dropped because the OTLP export queue was full.", float64(dropped))
+	}
+	if len(records) == 0 {
+		return
+	}
+
+	e.export("logs", otlpLogsRequest{ResourceLogs: []otlpResourceLogs{{
+		Resource:  otlpResource{Attributes: e.resource},
+		ScopeLogs: []otlpScopeLogs{{Scope: otlpInstrumentationScope{Name: otlpScope, Version: version}, LogRecords: records}},
+	}}})
+}
+
+// export posts a request to the signal's endpoint. Failures are counted,
+// but only logged when a signal starts failing, so an unreachable collector
+// doesn't flood the logs it would receive.
+func (e *otlpExporter) export(signal string, request interface{}) {
+	err := e.post("/v1/"+signal, request)
+
+	e.mu.Lock()
+	wasFailing := e.failing[signal]
+	e.failing[signal] = err != nil
+	e.mu.Unlock()
+
+	switch {
+	case err != nil:
+		e.metrics.Inc("dashboard_otlp_export_failures_total", "Failed OTLP exports.", "signal", signal)
+		if !wasFailing {
+			log.Printf("Failed to export %s over OTLP: %v", signal, err)
+		}
+	case wasFailing:
+		log.Printf("Exporting %s over OTLP again", signal)
+	}
+}
+
+func (e *otlpExporter) post(path string, request interface{}) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, e.endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range e.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("collector returned status %d", resp.StatusCode)
+	}
+	return nil
+}
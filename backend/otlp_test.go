@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// otlpReceiver records the requests of each signal
+type otlpReceiver struct {
+	*httptest.Server
+	requests map[string][]map[string]interface{}
+	headers  http.Header
+	status   int
+}
+
+func newOTLPReceiver(t *testing.T) *otlpReceiver {
+	receiver := &otlpReceiver{requests: make(map[string][]map[string]interface{}), status: http.StatusOK}
+	receiver.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Invalid OTLP request: %v", err)
+		}
+		receiver.requests[r.URL.Path] = append(receiver.requests[r.URL.Path], body)
+		receiver.headers = r.Header
+		w.WriteHeader(receiver.status)
+	}))
+	t.Cleanup(receiver.Close)
+	return receiver
+}
+
+// TestOTLPExporterFromEnv tests the standard OTEL_* configuration and the
+// resource attributes
+func TestOTLPExporterFromEnv(t *testing.T) {
+	if e, err := newOTLPExporterFromEnv(); e != nil || err != nil {
+		t.Fatalf("Expected no exporter without an endpoint, got %v, %v", e, err)
+	}
+
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://otel-collector:4318/")
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "Authorization=Bearer%20secret, X-Scope-OrgID=hospital")
+	t.Setenv("OTEL_METRIC_EXPORT_INTERVAL", "15000")
+	t.Setenv("OTEL_LOGS_EXPORTER", "none")
+	t.Setenv("CLUSTER_NAME", "east")
+	t.Setenv("SITE_NAME", "st-raj")
+	t.Setenv("OTEL_RESOURCE_ATTRIBUTES", "deployment.environment=prod,site=st-raj-north")
+	e, err := newOTLPExporterFromEnv()
+	if err != nil {
+		t.Fatalf("Failed to configure exporter: %v", err)
+	}
+	if e.endpoint != "http://otel-collector:4318" || e.metricInterval != 15*time.Second || !e.exportMetrics || e.exportLogs {
+		t.Errorf("Unexpected exporter %+v", e)
+	}
+	if e.headers["Authorization"] != "Bearer secret" || e.headers["X-Scope-OrgID"] != "hospital" {
+		t.Errorf("Unexpected headers %v", e.headers)
+	}
+	resource := make(map[string]string)
+	for _, attribute := range e.resource {
+		resource[attribute.Key] = attribute.Value.StringValue
+	}
+	for key, expected := range map[string]string{
+		"service.name":           "hospital-dashboard-backend",
+		"service.version":        version,
+		"k8s.cluster.name":       "east",
+		"site":                   "st-raj-north",
+		"deployment.environment": "prod",
+	} {
+		if resource[key] != expected {
+			t.Errorf("Expected resource attribute %s=%q, got %q", key, expected, resource[key])
+		}
+	}
+
+	for key, value := range map[string]string{
+		"OTEL_EXPORTER_OTLP_PROTOCOL": "grpc",
+		"OTEL_METRIC_EXPORT_INTERVAL": "1m",
+	} {
+		t.Run(key, func(t *testing.T) {
+			t.Setenv(key, value)
+			if _, err := newOTLPExporterFromEnv(); err == nil {
+				t.Errorf("Expected %s=%s to be rejected", key, value)
+			}
+		})
+	}
+}
+
+// TestOTLPMetricsExport tests that counters, histograms with their trace
+// exemplars and the workload gauge are exported
+func TestOTLPMetricsExport(t *testing.T) {
+	receiver := newOTLPReceiver(t)
+	metrics := newMetrics()
+	metrics.Inc("dashboard_notifications_total", "Notifications sent.", "target", "oncall")
+	metrics.Inc("dashboard_notifications_total", "Notifications sent.", "target", "oncall")
+	metrics.Observe("dashboard_detection_lag_seconds", "Lag.", []float64{1, 10}, 4, "4bf92f3577b34da6a3ce929d0e0e4736", "cluster", "east")
+
+	server := &Server{statusCache: map[string]*WorkloadStatus{"icu/pacs": failedStatus("icu", "pacs")}}
+	e := &otlpExporter{
+		endpoint:   receiver.URL,
+		headers:    map[string]string{"Authorization": "Bearer secret"},
+		resource:   otlpAttributes(map[string]string{"site": "st-raj"}),
+		metrics:    metrics,
+		gauges:     server.otlpGauges,
+		failing:    make(map[string]bool),
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+	}
+	e.flushMetrics()
+
+	requests := receiver.requests["/v1/metrics"]
+	if len(requests) != 1 {
+		t.Fatalf("Expected 1 metrics request, got %d", len(requests))
+	}
+	if receiver.headers.Get("Authorization") != "Bearer secret" {
+		t.Errorf("Expected configured headers to be sent, got %v", receiver.headers)
+	}
+	body, _ := json.Marshal(requests[0])
+	for _, want := range []string{
+		`"resource":{"attributes":[{"key":"site","value":{"stringValue":"st-raj"}}]}`,
+		`"name":"dashboard_notifications_total"`,
+		`"asDouble":2`,
+		`"isMonotonic":true`,
+		`"bucketCounts":["0","1","0"]`,
+		`"explicitBounds":[1,10]`,
+		`"traceId":"4bf92f3577b34da6a3ce929d0e0e4736"`,
+		`"name":"dashboard_workloads"`,
+		`{"key":"status","value":{"stringValue":"failed"}}`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("Expected %s in metrics request:\n%s", want, body)
+		}
+	}
+}
+
+// TestOTLPLogsExport tests that log lines are queued without blocking and
+// exported with their original time
+func TestOTLPLogsExport(t *testing.T) {
+	receiver := newOTLPReceiver(t)
+	e := &otlpExporter{endpoint: receiver.URL, metrics: newMetrics(), failing: make(map[string]bool), httpClient: &http.Client{Timeout: 5 * time.Second}}
+
+	fmt.Fprintln(e, "2026/03/01 12:00:00 Fetched 12 reports from Collector")
+	fmt.Fprintln(e, "2026/03/01 12:00:01 Failed to fetch from Collector http://collector: timeout")
+	e.flushLogs()
+
+	requests := receiver.requests["/v1/logs"]
+	if len(requests) != 1 {
+		t.Fatalf("Expected 1 logs request, got %d", len(requests))
+	}
+	var request otlpLogsRequest
+	body, _ := json.Marshal(requests[0])
+	json.Unmarshal(body, &request)
+	records := request.ResourceLogs[0].ScopeLogs[0].LogRecords
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 log records, got %+v", records)
+	}
+	logged := time.Date(2026, 3, 1, 12, 0, 0, 0, time.Local)
+	if records[0].Body.StringValue != "Fetched 12 reports from Collector" || records[0].TimeUnixNano != otlpTime(logged) || records[0].SeverityText != "INFO" {
+		t.Errorf("Unexpected record %+v", records[0])
+	}
+	if records[1].SeverityText != "WARN" {
+		t.Errorf("Expected failures as warnings, got %+v", records[1])
+	}
+
+	// Nothing is sent without new lines
+	e.flushLogs()
+	if len(receiver.requests["/v1/logs"]) != 1 {
+		t.Errorf("Expected no request without log lines")
+	}
+}
+
+// TestOTLPLogQueueBounded tests that the oldest log lines are dropped while
+// the collector can't keep up
+func TestOTLPLogQueueBounded(t *testing.T) {
+	receiver := newOTLPReceiver(t)
+	receiver.status = http.StatusServiceUnavailable
+	e := &otlpExporter{endpoint: receiver.URL, metrics: newMetrics(), failing: make(map[string]bool), httpClient: &http.Client{Timeout: 5 * time.Second}}
+
+	for i := 0; i < otlpMaxQueuedLogs+10; i++ {
+		fmt.Fprintf(e, "line %d\n", i)
+	}
+	if len(e.logs) != otlpMaxQueuedLogs || e.logs[0].Body.StringValue != "line 10" {
+		t.Fatalf("Expected the %d newest lines to be kept, got %d starting with %q", otlpMaxQueuedLogs, len(e.logs), e.logs[0].Body.StringValue)
+	}
+
+	e.flushLogs()
+	e.flushLogs()
+	if got := e.metrics.Value("dashboard_otlp_dropped_logs_total"); got != 10 {
+		t.Errorf("Expected 10 dropped lines, got %v", got)
+	}
+	if got := e.metrics.Value("dashboard_otlp_export_failures_total", "signal", "logs"); got != 1 {
+		t.Errorf("Expected 1 failed export, got %v", got)
+	}
+	if !e.failing["logs"] {
+		t.Error("Expected the logs signal to be marked failing")
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sync"
+	"time"
+)
+
+// defaultOwnershipCacheTTL is how long a directory lookup is reused
+const defaultOwnershipCacheTTL = 10 * time.Minute
+
+// OwnershipRule maps workloads to the team that owns them. Namespace and App
+// are glob patterns; App is matched against the pod's app label when
+// Kubernetes enrichment provides one, and the pod name otherwise.
+type OwnershipRule struct {
+	Namespace string `json:"namespace"`
+	App       string `json:"app,omitempty"` // empty = every workload in the namespace
+	Team      string `json:"team"`
+	Contact   string `json:"contact,omitempty"`
+}
+
+// matches reports whether the rule covers a workload
+func (rule *OwnershipRule) matches(namespace, app string) bool {
+	if ok, _ := path.Match(rule.Namespace, namespace); !ok {
+		return false
+	}
+	if rule.App == "" {
+		return true
+	}
+	ok, _ := path.Match(rule.App, app)
+	return ok
+}
+
+// ownerLookup is a cached directory response; owner is nil when the
+// directory knows no owner
+type ownerLookup struct {
+	owner   *Owner
+	expires time.Time
+}
+
+// ownerDirectory resolves the team owning a workload from the static rules
+// of OWNERSHIP_CONFIG, first match wins, and then from the OWNERSHIP_URL
+// lookup endpoint. The endpoint is queried with ?namespace=&app= and answers
+// {"team": ..., "contact": ...}, or 404 for no owner.
+type ownerDirectory struct {
+	rules      []OwnershipRule
+	url        string
+	ttl        time.Duration
+	httpClient *http.Client
+
+	mu    sync.Mutex
+	cache map[string]ownerLookup // by namespace/app
+}
+
+// loadOwnershipRules reads the static ownership mapping from a JSON file
+func loadOwnershipRules(file string) ([]OwnershipRule, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []OwnershipRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("invalid ownership config: %w", err)
+	}
+	for i, rule := range rules {
+		if rule.Namespace == "" || rule.Team == "" {
+			return nil, fmt.Errorf("rule %d: namespace and team are required", i)
+		}
+		for _, pattern := range []string{rule.Namespace, rule.App} {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("rule %d: invalid pattern %q", i, pattern)
+			}
+		}
+	}
+	return rules, nil
+}
+
+// newOwnerDirectory configures the directory from OWNERSHIP_CONFIG,
+// OWNERSHIP_URL and OWNERSHIP_CACHE_TTL. Returns nil if neither source is set.
+func newOwnerDirectory() (*ownerDirectory, error) {
+	d := &ownerDirectory{
+		url:        os.Getenv("OWNERSHIP_URL"),
+		ttl:        getEnvDuration("OWNERSHIP_CACHE_TTL", defaultOwnershipCacheTTL),
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+		cache:      make(map[string]ownerLookup),
+	}
+	if file := os.Getenv("OWNERSHIP_CONFIG"); file != "" {
+		rules, err := loadOwnershipRules(file)
+		if err != nil {
+			return nil, err
+		}
+		d.rules = rules
+	}
+	if d.url != "" {
+		if u, err := url.Parse(d.url); err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid OWNERSHIP_URL %q", d.url)
+		}
+	}
+	if len(d.rules) == 0 && d.url == "" {
+		return nil, nil
+	}
+	return d, nil
+}
+
+// lookup returns the owner of a workload, or nil if it has none
+func (d *ownerDirectory) lookup(namespace, app string, now time.Time) *Owner {
+	for i := range d.rules {
+		if rule := &d.rules[i]; rule.matches(namespace, app) {
+			return &Owner{Team: rule.Team, Contact: rule.Contact}
+		}
+	}
+	if d.url == "" {
+		return nil
+	}
+
+	key := namespace + "/" + app
+	d.mu.Lock()
+	cached, ok := d.cache[key]
+	d.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.owner
+	}
+
+	owner, err := d.query(namespace, app)
+	if err != nil {
+		// Keep using the last answer rather than dropping the owner, and
+		// don't retry before the TTL passes
+		log.Printf("Failed to look up the owner of %s: %v", key, err)
+		owner = cached.owner
+	}
+	d.mu.Lock()
+	d.cache[key] = ownerLookup{owner: owner, expires: now.Add(d.ttl)}
+	d.mu.Unlock()
+	return owner
+}
+
+// query asks the lookup endpoint for the owner of a workload
+func (d *ownerDirectory) query(namespace, app string) (*Owner, error) {
+	u, _ := url.Parse(d.url)
+	query := u.Query()
+	query.Set("namespace", namespace)
+	query.Set("app", app)
+	u.RawQuery = query.Encode()
+
+	resp, err := d.httpClient.Get(u.String())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("directory returned status %d", resp.StatusCode)
+	}
+
+	var owner Owner
+	if err := json.NewDecoder(resp.Body).Decode(&owner); err != nil {
+		return nil, fmt.Errorf("invalid directory response: %w", err)
+	}
+	if owner.Team == "" {
+		return nil, nil
+	}
+	return &owner, nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestOwnerDirectory tests static rules taking precedence over the lookup
+// endpoint, and caching of endpoint answers
+func TestOwnerDirectory(t *testing.T) {
+	var queries, failing atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries.Add(1)
+		switch {
+		case failing.Load() == 1:
+			w.WriteHeader(http.StatusBadGateway)
+		case r.URL.Query().Get("namespace") == "lab":
+			w.Write([]byte(`{"team":"lab-informatics","contact":"lab-oncall@hospital.example"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "owners.json")
+	os.WriteFile(path, []byte(`[{"namespace":"radiology-*","app":"pacs*","team":"imaging","contact":"#imaging-oncall"}]`), 0o600)
+	t.Setenv("OWNERSHIP_CONFIG", path)
+	t.Setenv("OWNERSHIP_URL", srv.URL+"/owners")
+	directory, err := newOwnerDirectory()
+	if err != nil {
+		t.Fatalf("Failed to configure directory: %v", err)
+	}
+
+	now := time.Now()
+	if owner := directory.lookup("radiology-east", "pacs-viewer", now); owner == nil || owner.Team != "imaging" {
+		t.Errorf("Expected the static rule to match, got %+v", owner)
+	}
+	if owner := directory.lookup("lab", "lims", now); owner == nil || owner.Team != "lab-informatics" || owner.Contact == "" {
+		t.Errorf("Expected the directory owner, got %+v", owner)
+	}
+	if owner := directory.lookup("dev", "scratch", now); owner != nil {
+		t.Errorf("Expected no owner, got %+v", owner)
+	}
+
+	// Answers are reused until the TTL passes; after a failed lookup the last
+	// answer stands
+	directory.lookup("lab", "lims", now.Add(time.Minute))
+	if queries.Load() != 2 {
+		t.Errorf("Expected the cached answer to be reused, got %d queries", queries.Load())
+	}
+	failing.Store(1)
+	if owner := directory.lookup("lab", "lims", now.Add(defaultOwnershipCacheTTL+time.Minute)); owner == nil || owner.Team != "lab-informatics" {
+		t.Errorf("Expected the stale owner while the directory fails, got %+v", owner)
+	}
+
+	t.Setenv("OWNERSHIP_CONFIG", "")
+	t.Setenv("OWNERSHIP_URL", "")
+	if directory, err := newOwnerDirectory(); directory != nil || err != nil {
+		t.Errorf("Expected no directory without configuration, got %v, %v", directory, err)
+	}
+}
+
+// TestOwnershipEnricher tests resolving owners by the pod's app label
+func TestOwnershipEnricher(t *testing.T) {
+	server := &Server{owners: &ownerDirectory{rules: []OwnershipRule{
+		{Namespace: "icu", App: "monitor", Team: "critical-care"},
+		{Namespace: "icu", Team: "clinical-platform"},
+	}}}
+	statuses := []*WorkloadStatus{failedStatus("icu", "monitor-7f9c"), failedStatus("icu", "ai-model")}
+	reports := []CollectorReport{{PodName: "monitor-7f9c", Namespace: "icu", app: "monitor"}, {PodName: "ai-model", Namespace: "icu"}}
+
+	for i := range reports {
+		ownershipEnricher{server}.Enrich(&reports[i], statuses[i])
+	}
+	if statuses[0].Owner == nil || statuses[0].Owner.Team != "critical-care" {
+		t.Errorf("Expected the app label to select the owner, got %+v", statuses[0].Owner)
+	}
+	if statuses[1].Owner == nil || statuses[1].Owner.Team != "clinical-platform" {
+		t.Errorf("Expected the namespace owner, got %+v", statuses[1].Owner)
+	}
+}
+
+// TestNotifierRoutesByTeam tests that team targets only receive
+// notifications about the workloads their team owns
+func TestNotifierRoutesByTeam(t *testing.T) {
+	imaging, everyone := &webhookReceiver{}, &webhookReceiver{}
+	imagingSrv, everyoneSrv := httptest.NewServer(imaging), httptest.NewServer(everyone)
+	defer imagingSrv.Close()
+	defer everyoneSrv.Close()
+
+	notifier, err := newNotifier([]notifyTarget{
+		{Name: "imaging", URL: imagingSrv.URL, Teams: []string{"imaging"}},
+		{Name: "soc", URL: everyoneSrv.URL},
+	}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create notifier: %v", err)
+	}
+
+	owned := violationEvent("radiology/pacs")
+	owned.Status.Owner = &Owner{Team: "imaging"}
+	notifier.Notify([]HistoryEvent{owned, violationEvent("icu/a")})
+	notifier.deliverDue()
+
+	if imaging.received != 1 || everyone.received != 2 {
+		t.Errorf("Expected 1 delivery to the team and 2 to the catch-all target, got %d and %d", imaging.received, everyone.received)
+	}
+}
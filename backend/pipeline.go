@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// Enricher is one step of the report ingestion pipeline. Each step adds
+// what it knows to the status converted from a report, and may fill in
+// report fields for the steps after it. Steps run in registration order,
+// outside the cache lock.
+type Enricher interface {
+	Enrich(report *CollectorReport, status *WorkloadStatus)
+}
+
+// defaultEnrichers returns the built-in steps in the order they must run:
+// the verdict first, then the pod metadata the checks and owner lookup
+// depend on. Steps whose feature is not configured do nothing.
+func defaultEnrichers(s *Server) []Enricher {
+	return []Enricher{
+		policyEnricher{s},
+		kubeEnricher{s},
+		severityEnricher{s},
+		ownershipEnricher{s},
+	}
+}
+
+// registerEnricher appends a step to the pipeline, after the built-in ones
+func (s *Server) registerEnricher(e Enricher) {
+	if s.enrichers == nil {
+		s.enrichers = defaultEnrichers(s)
+	}
+	s.enrichers = append(s.enrichers, e)
+}
+
+// pipeline returns the registered steps
+func (s *Server) pipeline() []Enricher {
+	if s.enrichers == nil {
+		return defaultEnrichers(s)
+	}
+	return s.enrichers
+}
+
+// enrich runs a report through the pipeline. Report fields filled in by
+// the steps are kept, for retained reports and later policy evaluations.
+func (s *Server) enrich(report *CollectorReport) *WorkloadStatus {
+	status := newWorkloadStatus(*report, time.Now())
+	for _, e := range s.pipeline() {
+		e.Enrich(report, status)
+	}
+	return status
+}
+
+// convertCollectorReport converts a Collector report to WorkloadStatus
+func (s *Server) convertCollectorReport(report CollectorReport) *WorkloadStatus {
+	return s.enrich(&report)
+}
+
+// newWorkloadStatus copies a report's identity and evidence to a new status
+func newWorkloadStatus(report CollectorReport, now time.Time) *WorkloadStatus {
+	return &WorkloadStatus{
+		Name:         report.PodName,
+		Namespace:    report.Namespace,
+		Attested:     report.Attested,
+		Timestamp:    report.Timestamp.UTC().Format(time.RFC3339),
+		LastChecked:  now,
+		TEEType:      report.TEEType,
+		TCBVersion:   report.TCBVersion,
+		NodeName:     report.NodeName,
+		Cluster:      report.Cluster,
+		TrustVector:  report.TrustVector,
+		RawReportID:  report.rawID,
+		Source:       report.source,
+		ImageDigests: report.imageDigests,
+	}
+}
+
+// policyEnricher decides the attestation verdict under the active AR4SI
+// profile. Evidence that doesn't conform is never reported as verified: the
+// attestation is unknown, as the verdict can't be read from it.
+type policyEnricher struct {
+	server *Server
+}
+
+func (e policyEnricher) Enrich(report *CollectorReport, status *WorkloadStatus) {
+	if err := e.server.validateEvidence(report); err != nil {
+		status.GateOneStatus = "passing"
+		markUnknown(status, unknownMalformedEvidence, fmt.Sprintf("Malformed evidence: %v", err))
+		failCheck(status, "evidence_format", "AR4SI-conformant trust vector", err.Error(), severityCritical)
+		return
+	}
+
+	if report.Attested {
+		status.AttestationStatus = "verified"
+		status.GateOneStatus = "passing"
+		status.GateTwoStatus = "passing"
+
+		// Build details from trust vector
+		if report.TrustVector != nil {
+			status.Details = fmt.Sprintf("TEE attestation successful (%s) - Hardware: %s, Config: %s, Executables: %s",
+				report.TEEType,
+				trustTierToString(report.TrustVector.Hardware),
+				trustTierToString(report.TrustVector.Configuration),
+				trustTierToString(report.TrustVector.Executables))
+		} else {
+			status.Details = fmt.Sprintf("TEE attestation successful (%s)", report.TEEType)
+		}
+	} else {
+		status.AttestationStatus = "failed"
+		status.GateOneStatus = "passing" // Assume code integrity passes if pod exists
+		status.GateTwoStatus = "failed"
+
+		if report.Error != "" {
+			status.Details = report.Error
+		} else {
+			status.Details = "TEE attestation failed - not running in genuine confidential environment"
+		}
+	}
+}
+
+// kubeEnricher fills in pod metadata the Collector did not provide: node,
+// start time, restarts, image digests and app label. Only pods in the local cluster can
+// be looked up; lookup failures are logged and leave the workload untouched.
+type kubeEnricher struct {
+	server *Server
+}
+
+func (e kubeEnricher) Enrich(report *CollectorReport, status *WorkloadStatus) {
+	s := e.server
+	if s.kube == nil || report.Cluster != s.localCluster {
+		return
+	}
+
+	pod, err := s.kube.getPod(report.Namespace, report.PodName)
+	if err != nil {
+		log.Printf("Failed to enrich %s/%s from Kubernetes: %v", report.Namespace, report.PodName, err)
+		return
+	}
+	if report.NodeName == "" {
+		report.NodeName = pod.Spec.NodeName
+	}
+	report.podStarted = pod.Status.StartTime
+	report.restartCount, report.lastRestart = podRestarts(pod)
+	report.imageDigests = podImageDigests(pod)
+	report.app = pod.Metadata.Labels["app.kubernetes.io/name"]
+	if report.app == "" {
+		report.app = pod.Metadata.Labels["app"]
+	}
+
+	status.NodeName = report.NodeName
+	status.ImageDigests = report.imageDigests
+	if !report.podStarted.IsZero() {
+		started := report.podStarted
+		status.PodStartedAt = &started
+	}
+}
+
+// severityEnricher records the failed checks behind the verdict, each with
+// its severity: non-affirming trust vector claims, attestations older than
+// the latest restart, and clock skew. Malformed evidence has its check
+// already and is not assessed further.
+type severityEnricher struct {
+	server *Server
+}
+
+func (e severityEnricher) Enrich(report *CollectorReport, status *WorkloadStatus) {
+	switch status.AttestationStatus {
+	case unknownStatus:
+		return
+	case "failed":
+		failCheck(status, "tee_attestation", "attested", status.Details, severityCritical)
+	default:
+		if report.TrustVector != nil {
+			trustVectorChecks(status, report.TrustVector)
+		}
+	}
+
+	correlateRestarts(*report, status)
+	e.server.checkClockSkew(*report, status, status.LastChecked)
+}
+
+// ownershipEnricher attaches the owning team. A workload's app is its pod's
+// app label where Kubernetes enrichment found one, and its name otherwise.
+// Lookups may call the directory.
+type ownershipEnricher struct {
+	server *Server
+}
+
+func (e ownershipEnricher) Enrich(report *CollectorReport, status *WorkloadStatus) {
+	e.server.assignOwner(status, report.app)
+}
+
+// assignOwner attaches the owning team of a workload's app to its status
+func (s *Server) assignOwner(status *WorkloadStatus, app string) {
+	if s.owners == nil {
+		return
+	}
+	if app == "" {
+		app = status.Name
+	}
+	status.Owner = s.owners.lookup(status.Namespace, app, time.Now())
+}
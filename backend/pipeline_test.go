@@ -0,0 +1,114 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// recordingEnricher records the status it saw, to check the order of steps
+type recordingEnricher struct {
+	seen *[]string
+	name string
+}
+
+func (e recordingEnricher) Enrich(report *CollectorReport, status *WorkloadStatus) {
+	*e.seen = append(*e.seen, e.name+":"+status.AttestationStatus)
+}
+
+// TestRegisterEnricher tests that registered steps run after the built-in
+// ones, in registration order
+func TestRegisterEnricher(t *testing.T) {
+	var seen []string
+	server := &Server{}
+	server.registerEnricher(recordingEnricher{&seen, "first"})
+	server.registerEnricher(recordingEnricher{&seen, "second"})
+
+	if len(server.pipeline()) != len(defaultEnrichers(server))+2 {
+		t.Fatalf("Expected the built-in steps and 2 registered ones, got %d", len(server.pipeline()))
+	}
+	server.convertCollectorReport(CollectorReport{PodName: "ai-model", Namespace: "icu", Attested: false})
+	if len(seen) != 2 || seen[0] != "first:failed" || seen[1] != "second:failed" {
+		t.Errorf("Expected both steps to see the verdict in order, got %v", seen)
+	}
+}
+
+// TestPolicyEnricher tests the verdict on its own, without any checks
+func TestPolicyEnricher(t *testing.T) {
+	server := &Server{}
+	tests := []struct {
+		report   CollectorReport
+		expected string
+	}{
+		{CollectorReport{Attested: true, TEEType: "tdx"}, "verified"},
+		{CollectorReport{Attested: false, Error: "quote verification failed"}, "failed"},
+		{CollectorReport{Attested: true, TrustVector: &TrustVector{Hardware: 7}}, unknownStatus},
+	}
+	for _, tt := range tests {
+		report := tt.report
+		status := newWorkloadStatus(report, time.Now())
+		policyEnricher{server}.Enrich(&report, status)
+		if status.AttestationStatus != tt.expected {
+			t.Errorf("Expected %s for %+v, got %s", tt.expected, tt.report, status.AttestationStatus)
+		}
+	}
+}
+
+// TestSeverityEnricher tests that checks are recorded for a given verdict
+func TestSeverityEnricher(t *testing.T) {
+	server := &Server{}
+	report := CollectorReport{Attested: true, TrustVector: &TrustVector{Hardware: 2, Configuration: 32}, Timestamp: time.Now()}
+	status := newWorkloadStatus(report, time.Now())
+	status.AttestationStatus = "verified"
+	severityEnricher{server}.Enrich(&report, status)
+	if highestSeverity(status.FailedChecks) != severityWarning {
+		t.Errorf("Expected a warning for the configuration claim, got %+v", status.FailedChecks)
+	}
+
+	failed := newWorkloadStatus(report, time.Now())
+	failed.AttestationStatus, failed.Details = "failed", "quote verification failed"
+	severityEnricher{server}.Enrich(&report, failed)
+	if len(failed.FailedChecks) != 1 || failed.FailedChecks[0].Name != "tee_attestation" {
+		t.Errorf("Expected only the attestation check for a failed workload, got %+v", failed.FailedChecks)
+	}
+
+	malformed := newWorkloadStatus(report, time.Now())
+	malformed.AttestationStatus = unknownStatus
+	severityEnricher{server}.Enrich(&report, malformed)
+	if len(malformed.FailedChecks) != 0 {
+		t.Errorf("Expected malformed evidence not to be assessed further, got %+v", malformed.FailedChecks)
+	}
+}
+
+// TestPipelineKubeMetadataReachesLaterSteps tests that pod metadata looked
+// up by one step is used by the checks and owner lookup after it, and kept
+// on the report
+func TestPipelineKubeMetadataReachesLaterSteps(t *testing.T) {
+	mockAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"metadata":{"labels":{"app":"monitor"}},"spec":{"nodeName":"worker-3"},"status":{"containerStatuses":[
+			{"name":"app","restartCount":1,"imageID":"quay.io/icu/monitor@sha256:1111111111111111111111111111111111111111111111111111111111111111","state":{"running":{"startedAt":"2024-05-01T12:00:00Z"}}}
+		]}}`))
+	}))
+	defer mockAPI.Close()
+
+	server := &Server{
+		kube:   &kubeClient{baseURL: mockAPI.URL, httpClient: mockAPI.Client()},
+		owners: &ownerDirectory{rules: []OwnershipRule{{Namespace: "icu", App: "monitor", Team: "critical-care"}}},
+	}
+	report := CollectorReport{PodName: "monitor-7f9c", Namespace: "icu", Attested: true, Timestamp: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)}
+	status := server.enrich(&report)
+
+	if status.NodeName != "worker-3" || len(status.ImageDigests) != 1 {
+		t.Errorf("Expected pod metadata on the status, got node %q and digests %v", status.NodeName, status.ImageDigests)
+	}
+	if status.AttestationStatus != predatesRestartStatus {
+		t.Errorf("Expected the restart to be checked, got %s", status.AttestationStatus)
+	}
+	if status.Owner == nil || status.Owner.Team != "critical-care" {
+		t.Errorf("Expected the app label to select the owner, got %+v", status.Owner)
+	}
+	if report.restartCount != 1 || report.app != "monitor" {
+		t.Errorf("Expected the metadata to be kept on the report, got %+v", report)
+	}
+}
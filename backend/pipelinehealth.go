@@ -0,0 +1,241 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxPipelineSamples bounds the Collector polls kept for pipeline health,
+// an hour at the default poll interval
+const maxPipelineSamples = 120
+
+// collectorLatencyBuckets are the histogram buckets for Collector requests, in seconds
+var collectorLatencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// pipelineSample is the outcome of polling one Collector once
+type pipelineSample struct {
+	cluster string
+	latency time.Duration
+	failed  bool
+	reports int
+}
+
+// pipelineLatency summarizes Collector response times, in milliseconds
+type pipelineLatency struct {
+	P50 float64 `json:"p50_ms"`
+	P95 float64 `json:"p95_ms"`
+	Max float64 `json:"max_ms"`
+}
+
+// pipelineStaleness summarizes the age of the reports of the last poll, in seconds
+type pipelineStaleness struct {
+	P50 float64 `json:"p50_seconds"`
+	P90 float64 `json:"p90_seconds"`
+	Max float64 `json:"max_seconds"`
+}
+
+// pipelineReportCount summarizes how many reports each Collector returns.
+// A Collector whose count swings between polls is losing or duplicating
+// reports, whatever the workloads are doing.
+type pipelineReportCount struct {
+	Last                   int     `json:"last"`
+	Mean                   float64 `json:"mean"`
+	StdDev                 float64 `json:"std_dev"`
+	CoefficientOfVariation float64 `json:"coefficient_of_variation"`
+}
+
+// pipelineHealthReport is the response of GET /api/pipeline/health
+type pipelineHealthReport struct {
+	Score       int                 `json:"score"`  // 0-100
+	Status      string              `json:"status"` // healthy, degraded, unhealthy or unknown
+	Polls       int                 `json:"polls"`
+	ErrorRate   float64             `json:"error_rate"`
+	Latency     pipelineLatency     `json:"latency"`
+	Staleness   pipelineStaleness   `json:"staleness"`
+	ReportCount pipelineReportCount `json:"report_count"`
+	Issues      []string            `json:"issues"`
+}
+
+// pipelineHealth tracks how well the Collector pipeline is measuring, as
+// opposed to what it measures: a sick pipeline shows as failing or stale
+// workloads that are in fact fine
+type pipelineHealth struct {
+	mu        sync.Mutex
+	samples   []pipelineSample // oldest first
+	staleness []float64        // report ages of the last poll in seconds, sorted
+}
+
+func newPipelineHealth() *pipelineHealth {
+	return &pipelineHealth{}
+}
+
+// observePoll records the outcome of polling a Collector
+func (p *pipelineHealth) observePoll(sample pipelineSample) {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.samples = append(p.samples, sample)
+	if len(p.samples) > maxPipelineSamples {
+		p.samples = append([]pipelineSample(nil), p.samples[len(p.samples)-maxPipelineSamples:]...)
+	}
+}
+
+// observeStaleness records the age of the reports of a poll
+func (p *pipelineHealth) observeStaleness(reports []CollectorReport, now time.Time) {
+	if p == nil {
+		return
+	}
+
+	ages := make([]float64, 0, len(reports))
+	for _, report := range reports {
+		if !report.Timestamp.IsZero() {
+			ages = append(ages, lagSeconds(report.Timestamp, now))
+		}
+	}
+	sort.Float64s(ages)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.staleness = ages
+}
+
+// report scores the pipeline. timeout is the Collector request budget and
+// staleAfter the report age from which reports count as stale.
+func (p *pipelineHealth) report(timeout, staleAfter time.Duration) pipelineHealthReport {
+	result := pipelineHealthReport{Status: "unknown", Issues: []string{}}
+	if p == nil {
+		return result
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.samples) == 0 {
+		return result
+	}
+
+	var latencies []float64
+	failed := 0
+	counts := make(map[string][]int)
+	for _, sample := range p.samples {
+		latencies = append(latencies, float64(sample.latency)/float64(time.Millisecond))
+		if sample.failed {
+			failed++
+			continue
+		}
+		counts[sample.cluster] = append(counts[sample.cluster], sample.reports)
+	}
+	sort.Float64s(latencies)
+
+	result.Polls = len(p.samples)
+	result.ErrorRate = float64(failed) / float64(len(p.samples))
+	result.Latency = pipelineLatency{P50: quantile(latencies, 0.5), P95: quantile(latencies, 0.95), Max: latencies[len(latencies)-1]}
+	if len(p.staleness) > 0 {
+		result.Staleness = pipelineStaleness{P50: quantile(p.staleness, 0.5), P90: quantile(p.staleness, 0.9), Max: p.staleness[len(p.staleness)-1]}
+	}
+	// Report the Collector whose count varies most
+	clusters := make([]string, 0, len(counts))
+	for cluster := range counts {
+		clusters = append(clusters, cluster)
+	}
+	sort.Strings(clusters)
+	for i, cluster := range clusters {
+		if count := reportCountOf(counts[cluster]); i == 0 || count.CoefficientOfVariation > result.ReportCount.CoefficientOfVariation {
+			result.ReportCount = count
+		}
+	}
+
+	score := 100.0
+	if result.ErrorRate > 0 {
+		score -= 50 * result.ErrorRate
+		result.Issues = append(result.Issues, fmt.Sprintf("%.0f%% of Collector polls failed", 100*result.ErrorRate))
+	}
+	if ratio := result.Latency.P95 / float64(timeout/time.Millisecond); timeout > 0 && ratio >= 0.5 {
+		score -= 20 * math.Min(1, ratio)
+		result.Issues = append(result.Issues, fmt.Sprintf("Collector p95 latency is %.0fms of a %s budget", result.Latency.P95, timeout))
+	}
+	if staleAfter > 0 && result.Staleness.P90 >= staleAfter.Seconds()/2 {
+		score -= 20 * math.Min(1, result.Staleness.P90/staleAfter.Seconds())
+		result.Issues = append(result.Issues, fmt.Sprintf("p90 report age is %.0fs, reports go stale after %s", result.Staleness.P90, staleAfter))
+	}
+	if cv := result.ReportCount.CoefficientOfVariation; cv >= 0.1 {
+		score -= 10 * math.Min(1, cv*2)
+		result.Issues = append(result.Issues, fmt.Sprintf("Report count varies by %.0f%% between polls", 100*cv))
+	}
+
+	result.Score = int(math.Round(math.Max(0, score)))
+	switch {
+	case result.Score >= 80:
+		result.Status = "healthy"
+	case result.Score >= 50:
+		result.Status = "degraded"
+	default:
+		result.Status = "unhealthy"
+	}
+	return result
+}
+
+// reportCountOf summarizes the report counts of one Collector's polls
+func reportCountOf(counts []int) pipelineReportCount {
+	result := pipelineReportCount{Last: counts[len(counts)-1]}
+	for _, count := range counts {
+		result.Mean += float64(count)
+	}
+	result.Mean /= float64(len(counts))
+	for _, count := range counts {
+		result.StdDev += (float64(count) - result.Mean) * (float64(count) - result.Mean)
+	}
+	result.StdDev = math.Sqrt(result.StdDev / float64(len(counts)))
+	if result.Mean > 0 {
+		result.CoefficientOfVariation = result.StdDev / result.Mean
+	}
+	return result
+}
+
+// quantile returns the q-th quantile (0-1) of sorted values
+func quantile(sorted []float64, q float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	return sorted[int(math.Max(0, math.Ceil(q*float64(len(sorted)))-1))]
+}
+
+// observeCollectorPoll records the latency and outcome of polling a Collector
+func (s *Server) observeCollectorPoll(cluster string, latency time.Duration, reports int, err error) {
+	s.metrics.Observe("dashboard_collector_request_seconds", "Time taken by Collector report requests.",
+		collectorLatencyBuckets, latency.Seconds(), "", "cluster", cluster)
+	if err != nil {
+		s.metrics.Inc("dashboard_collector_errors_total", "Failed Collector report requests.", "cluster", cluster)
+	}
+	s.pipelineHealth.observePoll(pipelineSample{cluster: cluster, latency: latency, failed: err != nil, reports: reports})
+}
+
+// staleAfter is the report age from which the pipeline counts reports as
+// stale: REPORT_MAX_AGE if set, otherwise three poll intervals
+func (s *Server) staleAfter() time.Duration {
+	if s.clockSkew.maxAge > 0 {
+		return s.clockSkew.maxAge
+	}
+	return 3 * s.pollInterval
+}
+
+// handlePipelineHealth scores the Collector pipeline from its recent polls,
+// to tell failing workloads apart from a failing measurement pipeline
+// GET /api/pipeline/health
+func (s *Server) handlePipelineHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.pipelineHealth.report(s.cycleTimeout(), s.staleAfter()))
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestPipelineHealthScore tests that a steady pipeline scores as healthy and
+// that failures, slow responses, stale reports and swinging report counts
+// each lower the score
+func TestPipelineHealthScore(t *testing.T) {
+	if report := (*pipelineHealth)(nil).report(time.Second, time.Minute); report.Status != "unknown" {
+		t.Errorf("Expected unknown health without polls, got %s", report.Status)
+	}
+
+	now := time.Now()
+	steady := newPipelineHealth()
+	for i := 0; i < 10; i++ {
+		steady.observePoll(pipelineSample{cluster: "north", latency: 20 * time.Millisecond, reports: 40})
+	}
+	steady.observeStaleness([]CollectorReport{{Timestamp: now.Add(-5 * time.Second)}, {Timestamp: now.Add(-10 * time.Second)}}, now)
+	report := steady.report(30*time.Second, 90*time.Second)
+	if report.Score != 100 || report.Status != "healthy" || len(report.Issues) != 0 {
+		t.Errorf("Expected a healthy pipeline, got %+v", report)
+	}
+	if report.Staleness.P50 != 5 || report.Staleness.Max != 10 || report.ReportCount.Mean != 40 {
+		t.Errorf("Unexpected summaries %+v", report)
+	}
+
+	sick := newPipelineHealth()
+	for i := 0; i < 10; i++ {
+		sick.observePoll(pipelineSample{cluster: "north", latency: 25 * time.Second, reports: 40 - 30*(i%2), failed: i < 5})
+	}
+	sick.observeStaleness([]CollectorReport{{Timestamp: now.Add(-2 * time.Minute)}}, now)
+	report = sick.report(30*time.Second, 90*time.Second)
+	if report.ErrorRate != 0.5 || report.Status != "unhealthy" || len(report.Issues) != 4 {
+		t.Errorf("Expected an unhealthy pipeline with 4 issues, got %+v", report)
+	}
+}
+
+// TestPipelineHealthFromPolls tests that Collector polls feed the endpoint
+func TestPipelineHealthFromPolls(t *testing.T) {
+	up := true
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !up {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode([]CollectorReport{{PodName: "a", Namespace: "icu", Attested: true, Timestamp: time.Now()}})
+	}))
+	defer collector.Close()
+
+	server := &Server{collectorURL: collector.URL, httpClient: collector.Client(), pollInterval: time.Minute, metrics: newMetrics(), pipelineHealth: newPipelineHealth()}
+	server.fetchFromCollector()
+	up = false
+	server.fetchFromCollector()
+
+	w := httptest.NewRecorder()
+	server.handlePipelineHealth(w, httptest.NewRequest("GET", "/api/pipeline/health", nil))
+	var report pipelineHealthReport
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if report.Polls != 2 || report.ErrorRate != 0.5 || report.ReportCount.Last != 1 || report.Status != "degraded" {
+		t.Errorf("Expected one good and one failed poll, got %+v", report)
+	}
+	if got := server.metrics.Value("dashboard_collector_errors_total", "cluster", ""); got != 1 {
+		t.Errorf("Expected 1 Collector error, got %v", got)
+	}
+}
@@ -0,0 +1,210 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// schemaDialect is the JSON Schema draft the generated schemas declare
+const schemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is the subset of JSON Schema generated for the API types and
+// enforced on inbound payloads
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	ID                   string             `json:"$id,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Type                 schemaTypes        `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"` // false, or the schema of map values
+}
+
+// schemaTypes encodes as a bare string when there is a single type
+type schemaTypes []string
+
+func (t schemaTypes) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+func (t *schemaTypes) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = schemaTypes{single}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(t))
+}
+
+// JSONSchema generates the schema of a struct from its JSON encoding. Fields
+// without omitempty are required unless tagged `jsonschema:"optional"`. A
+// strict schema rejects properties it doesn't declare.
+func JSONSchema(v interface{}, strict bool) *Schema {
+	t := reflect.TypeOf(v)
+	schema := schemaFor(t, strict)
+	schema.Schema = schemaDialect
+	schema.Title = t.Name()
+	return schema
+}
+
+// schemaFor maps a Go type to a schema, recursing into nested types
+func schemaFor(t reflect.Type, strict bool) *Schema {
+	if t == timeType {
+		return &Schema{Type: schemaTypes{"string"}, Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		schema := schemaFor(t.Elem(), strict)
+		schema.Type = append(schema.Type, "null")
+		return schema
+	case reflect.String:
+		return &Schema{Type: schemaTypes{"string"}}
+	case reflect.Bool:
+		return &Schema{Type: schemaTypes{"boolean"}}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: schemaTypes{"integer"}}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: schemaTypes{"number"}}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: schemaTypes{"array", "null"}, Items: schemaFor(t.Elem(), strict)}
+	case reflect.Map:
+		return &Schema{Type: schemaTypes{"object", "null"}, AdditionalProperties: schemaFor(t.Elem(), strict)}
+	case reflect.Struct:
+		schema := &Schema{Type: schemaTypes{"object"}, Properties: make(map[string]*Schema)}
+		for _, field := range jsonFields(t) {
+			schema.Properties[field.name] = schemaFor(field.typ, strict)
+			if !field.omitEmpty && !field.optional {
+				schema.Required = append(schema.Required, field.name)
+			}
+		}
+		if strict {
+			schema.AdditionalProperties = false
+		}
+		return schema
+	default:
+		return &Schema{} // accepts anything
+	}
+}
+
+// Validate checks a JSON document against the schema and returns one
+// message per violation, each prefixed with the path of the offending value
+func (s *Schema) Validate(data []byte) []string {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return []string{fmt.Sprintf("invalid JSON: %v", err)}
+	}
+	if dec.More() {
+		return []string{"invalid JSON: trailing data after document"}
+	}
+
+	var errs []string
+	s.validate(value, "", &errs)
+	return errs
+}
+
+func (s *Schema) validate(value interface{}, path string, errs *[]string) {
+	actual := jsonType(value)
+	if len(s.Type) > 0 && !s.allows(actual) {
+		*errs = append(*errs, fmt.Sprintf("%s: expected %s, got %s", displayPath(path), strings.Join(s.Type, " or "), actual))
+		return
+	}
+
+	switch v := value.(type) {
+	case string:
+		if s.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, v); err != nil {
+				*errs = append(*errs, fmt.Sprintf("%s: expected an RFC 3339 date-time, got %q", displayPath(path), v))
+			}
+		}
+	case []interface{}:
+		if s.Items != nil {
+			for i, item := range v {
+				s.Items.validate(item, fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+		}
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				*errs = append(*errs, fmt.Sprintf("%s: missing required property", displayPath(joinPath(path, name))))
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if prop, ok := s.Properties[name]; ok {
+				prop.validate(v[name], joinPath(path, name), errs)
+				continue
+			}
+			switch extra := s.AdditionalProperties.(type) {
+			case bool:
+				if !extra {
+					*errs = append(*errs, fmt.Sprintf("%s: unknown property", displayPath(joinPath(path, name))))
+				}
+			case *Schema:
+				extra.validate(v[name], joinPath(path, name), errs)
+			}
+		}
+	}
+}
+
+// allows reports whether a value of JSON type actual satisfies the schema's type
+func (s *Schema) allows(actual string) bool {
+	for _, t := range s.Type {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonType names the JSON type of a value decoded with UseNumber
+func jsonType(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func displayPath(path string) string {
+	if path == "" {
+		return "(root)"
+	}
+	return path
+}
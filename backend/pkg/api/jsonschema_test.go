@@ -0,0 +1,69 @@
+package api
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestJSONSchema tests the generated schema for types, formats and required fields
+func TestJSONSchema(t *testing.T) {
+	schema := JSONSchema(WorkloadStatus{}, false)
+
+	if schema.Title != "WorkloadStatus" || schema.Schema != schemaDialect {
+		t.Errorf("Unexpected schema header %q %q", schema.Title, schema.Schema)
+	}
+	if got := schema.Properties["last_checked"]; got.Format != "date-time" {
+		t.Errorf("Expected date-time format for last_checked, got %+v", got)
+	}
+	if got := schema.Properties["acknowledgement"].Type; len(got) != 2 || got[0] != "object" || got[1] != "null" {
+		t.Errorf("Expected nullable object for acknowledgement, got %v", got)
+	}
+	if got := schema.Properties["gates"].Items; got == nil || got.Properties["status"] == nil {
+		t.Errorf("Expected GateResult items for gates, got %+v", got)
+	}
+
+	required := strings.Join(schema.Required, ",")
+	if !strings.Contains(required, "attested") || strings.Contains(required, "tee_type") {
+		t.Errorf("Expected non-omitempty fields to be required, got %s", required)
+	}
+
+	data, err := json.Marshal(JSONSchema(AckRequest{}, true))
+	if err != nil {
+		t.Fatalf("Failed to encode schema: %v", err)
+	}
+	if !strings.Contains(string(data), `"type":"object"`) || !strings.Contains(string(data), `"additionalProperties":false`) {
+		t.Errorf("Unexpected strict schema encoding: %s", data)
+	}
+}
+
+// TestSchemaValidate tests that violations are reported with their paths
+func TestSchemaValidate(t *testing.T) {
+	schema := JSONSchema(WorkloadStatus{}, true)
+
+	valid, _ := json.Marshal(WorkloadStatus{Name: "ai-model", Namespace: "icu"})
+	if errs := schema.Validate(valid); len(errs) != 0 {
+		t.Errorf("Expected encoded status to validate, got %v", errs)
+	}
+
+	tests := map[string]string{
+		`{"name":"a","attested":"yes"}`:       "attested: expected boolean, got string",
+		`{"name":"a"}`:                        "namespace: missing required property",
+		`{"name":"a","restart_count":1.5}`:    "restart_count: expected integer, got number",
+		`{"name":"a","last_checked":"today"}`: `last_checked: expected an RFC 3339 date-time, got "today"`,
+		`{"name":"a","gates":[{"name":1}]}`:   "gates[0].name: expected string, got integer",
+		`{"name":"a","colour":"red"}`:         "colour: unknown property",
+		`[]`:                                  "(root): expected object, got array",
+		`{"name":`:                            "invalid JSON",
+	}
+	for body, expected := range tests {
+		errs := schema.Validate([]byte(body))
+		if !strings.Contains(strings.Join(errs, "\n"), expected) {
+			t.Errorf("Expected %q in errors for %s, got %v", expected, body, errs)
+		}
+	}
+
+	if errs := JSONSchema(WorkloadStatus{}, false).Validate([]byte(`{"colour":"red"}`)); strings.Contains(strings.Join(errs, "\n"), "colour") {
+		t.Errorf("Expected non-strict schema to allow unknown properties, got %v", errs)
+	}
+}
@@ -0,0 +1,544 @@
+// Package api defines the JSON types served by the dashboard backend's REST
+// API and event streams. The backend and Go clients share these definitions.
+package api
+
+import "time"
+
+// DashboardResponse is the API response for the dashboard
+type DashboardResponse struct {
+	OverallStatus string           `json:"overall_status"` // "compliant", "warning" or "violation"
+	Workloads     []WorkloadStatus `json:"workloads"`
+	LastUpdated   time.Time        `json:"last_updated"`
+	Generation    uint64           `json:"generation,omitempty"`  // cache generation, for /api/status/wait?since=
+	DataSource    string           `json:"data_source,omitempty"` // "poll", "snapshot" or "demo", in order of precedence
+	DataAsOf      *time.Time       `json:"data_as_of,omitempty"`  // latest successful poll, or when the snapshot was taken
+}
+
+// WorkloadStatus represents the attestation status of a CoCo workload
+type WorkloadStatus struct {
+	Name              string       `json:"name"`
+	Namespace         string       `json:"namespace"`
+	Attested          bool         `json:"attested"`
+	AttestationStatus string       `json:"attestation_status"`
+	UnknownReason     string       `json:"unknown_reason,omitempty"`  // why attestation_status is "unknown": "collector-error", "malformed-evidence" or "stale"
+	StatusLabel       string       `json:"status_label,omitempty"`    // attestation_status for display, in the negotiated language
+	Timestamp         string       `json:"timestamp"`                 // of the Collector report, RFC3339 UTC
+	TimestampLocal    string       `json:"timestamp_local,omitempty"` // timestamp in the display time zone
+	Details           string       `json:"details"`
+	GateOneStatus     string       `json:"gate_one_status"` // Code Integrity
+	GateTwoStatus     string       `json:"gate_two_status"` // TEE Attestation
+	LastChecked       time.Time    `json:"last_checked"`
+	LastCheckedLocal  string       `json:"last_checked_local,omitempty"` // last_checked in the display time zone
+	TEEType           string       `json:"tee_type,omitempty"`
+	TCBVersion        string       `json:"tcb_version,omitempty"` // platform TCB level, when the Collector reports it
+	TrustVector       *TrustVector `json:"trust_vector,omitempty"`
+	NodeName          string       `json:"node_name,omitempty"`
+	Cluster           string       `json:"cluster,omitempty"`
+	Maintenance       string       `json:"maintenance,omitempty"` // active maintenance window, set only for violations and unknown attestations
+	Gates             []GateResult `json:"gates,omitempty"`       // additional configured gates
+	RawReportID       string       `json:"raw_report_id,omitempty"`
+	Source            string       `json:"source,omitempty"`                 // URL of the Collector whose report is shown
+	SecondaryVerdict  string       `json:"secondary_verdict,omitempty"`      // "verified", "failed" or "missing" when a second verifier is configured
+	PodStartedAt      *time.Time   `json:"pod_started_at,omitempty"`         // from Kubernetes enrichment
+	FirstAttestedAt   *time.Time   `json:"first_attested_at,omitempty"`      // first successful attestation of the running pod
+	TimeToAttest      float64      `json:"time_to_attest_seconds,omitempty"` // from pod start to first_attested_at
+	RestartCount      int          `json:"restart_count,omitempty"`
+	LastRestart       *time.Time   `json:"last_restart,omitempty"`
+	HostStatus        string       `json:"host_status,omitempty"` // "verified" or "failed" when the Collector attests nodes
+	ImageDigests      []string     `json:"image_digests,omitempty"`
+	FailedChecks      []Check      `json:"failed_checks,omitempty"`
+	Flapping          bool         `json:"flapping,omitempty"`
+	FlapCount         int          `json:"flap_count,omitempty"` // verdict transitions in the flap window, while flapping
+	Lifecycle         string       `json:"lifecycle,omitempty"`  // "active", "terminating" (no longer reported, within the grace period) or "removed"
+	LastSeen          *time.Time   `json:"last_seen,omitempty"`  // last report of a terminating or removed workload
+	Owner             *Owner       `json:"owner,omitempty"`      // from the ownership directory
+	Pinned            bool         `json:"pinned,omitempty"`     // on the watchlist
+
+	// Lifetime record of the workload, in the detail response only
+	FirstSeen       *time.Time `json:"first_seen,omitempty"`
+	TotalViolations int        `json:"total_violations,omitempty"` // times the workload entered violation
+	LastViolationAt *time.Time `json:"last_violation_at,omitempty"`
+
+	Acknowledgement *Acknowledgement `json:"acknowledgement,omitempty"`
+	Annotations     *Annotations     `json:"annotations,omitempty"`
+}
+
+// Owner is the team responsible for a workload, resolved from the
+// ownership directory
+type Owner struct {
+	Team    string `json:"team"`
+	Contact string `json:"contact,omitempty"` // e.g. an on-call email or chat channel
+}
+
+// TrustVector represents EAR trust tier values from Collector
+type TrustVector struct {
+	InstanceIdentity int `json:"instance_identity" jsonschema:"optional"`
+	Configuration    int `json:"configuration" jsonschema:"optional"`
+	Executables      int `json:"executables" jsonschema:"optional"`
+	FileSystem       int `json:"file_system" jsonschema:"optional"`
+	Hardware         int `json:"hardware" jsonschema:"optional"`
+	RuntimeOpaque    int `json:"runtime_opaque" jsonschema:"optional"`
+	StorageOpaque    int `json:"storage_opaque" jsonschema:"optional"`
+	SourcedData      int `json:"sourced_data" jsonschema:"optional"`
+}
+
+// TrustTrend is the response of GET /api/workload/{ns}/{name}/trust-trend:
+// the history of each trust vector claim, one sample per change
+type TrustTrend struct {
+	Key        string                   `json:"key"`
+	Dimensions map[string][]TrustSample `json:"dimensions"` // keyed by claim, e.g. "configuration"
+}
+
+// TrustSample is the value of one trust vector claim from Time on
+type TrustSample struct {
+	Time  time.Time `json:"time"`
+	Value int       `json:"value"`
+	Tier  string    `json:"tier"`            // "None", "Affirming", "Warning" or "Contraindicated", or as relabeled by TRUST_TIER_CONFIG
+	Color string    `json:"color,omitempty"` // CSS color of the tier
+}
+
+// TrustTier is one EAR trust tier as the dashboard labels it, listed by
+// GET /api/trust-tiers
+type TrustTier struct {
+	Name  string `json:"name"` // "none", "affirming", "warning" or "contraindicated"
+	Value int    `json:"value"`
+	Label string `json:"label"`
+	Color string `json:"color"`
+}
+
+// GateResult is the outcome of one additional gate for a workload
+type GateResult struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"` // "passing", "failed" or "error"
+	Details string `json:"details,omitempty"`
+}
+
+// Check is one failed policy check on a workload, structured so that clients
+// can act on it without parsing the free-text Details
+type Check struct {
+	Name     string `json:"name"` // e.g. "tee_attestation", "image_allowlist", "gate:cmdb"
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+	Severity string `json:"severity"` // "critical", "high" or "warning"
+
+	// Guidance for first responders, from the built-in hints and REMEDIATION_CONFIG
+	Remediation string `json:"remediation,omitempty"`
+	RunbookURL  string `json:"runbook_url,omitempty"`
+}
+
+// AckRequest is the body of POST /api/workload/{ns}/{name}/ack
+type AckRequest struct {
+	Comment  string `json:"comment,omitempty"`
+	Duration string `json:"duration,omitempty"` // e.g. "2h"; defaults to ACK_DEFAULT_TTL
+}
+
+// Acknowledgement records that an operator has taken ownership of a violation.
+// Notifications for the workload are suppressed until it recovers or the
+// acknowledgement expires, at which point a persisting violation re-alerts.
+type Acknowledgement struct {
+	Key       string    `json:"key"`
+	By        string    `json:"by"`
+	Comment   string    `json:"comment,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	// Silence suppresses every notification for the workload, violation or
+	// not, and isn't resolved when the workload recovers
+	Silence bool `json:"silence,omitempty"`
+}
+
+// WorkloadSelector selects workloads for batch operations. Every field that
+// is set must match; at least one must be set.
+type WorkloadSelector struct {
+	Namespaces []string          `json:"namespaces,omitempty"`
+	Labels     map[string]string `json:"labels,omitempty"`   // an empty value matches any value of the label
+	Statuses   []string          `json:"statuses,omitempty"` // attestation_status values
+}
+
+// BatchAckRequest is the body of POST /api/workloads/ack-batch and
+// POST /api/workloads/silence-batch
+type BatchAckRequest struct {
+	Selector WorkloadSelector `json:"selector"`
+	Comment  string           `json:"comment,omitempty"`
+	Duration string           `json:"duration,omitempty"` // e.g. "2h"; defaults to ACK_DEFAULT_TTL
+}
+
+// BatchAckResult lists the acknowledgements a batch created
+type BatchAckResult struct {
+	Acknowledged []Acknowledgement `json:"acknowledged"`
+	Skipped      []string          `json:"skipped,omitempty"` // matched workloads not in violation, for ack-batch
+}
+
+// AnnotationsRequest is the body of PUT /api/workload/{ns}/{name}/annotations.
+// It replaces the workload's annotations; an empty request removes them.
+type AnnotationsRequest struct {
+	Notes  string            `json:"notes,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"` // e.g. {"owner": "radiology-platform", "ticket": "https://..."}
+}
+
+// Annotations are operator notes and labels attached to a workload. Labels
+// can be used to filter the workload lists with ?label=key=value.
+type Annotations struct {
+	Notes     string            `json:"notes,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	UpdatedBy string            `json:"updated_by"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// ExpectedWorkloadRequest is the body of POST /api/expected-workloads
+type ExpectedWorkloadRequest struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Cluster   string `json:"cluster,omitempty"` // empty = any cluster
+	Comment   string `json:"comment,omitempty"`
+}
+
+// ExpectedWorkload is a workload an operator registered as required to be
+// attested. While no Collector reports it, it is shown as a "no-report"
+// violation.
+type ExpectedWorkload struct {
+	Key          string    `json:"key"` // namespace/name
+	Namespace    string    `json:"namespace"`
+	Name         string    `json:"name"`
+	Cluster      string    `json:"cluster,omitempty"`
+	Comment      string    `json:"comment,omitempty"`
+	RegisteredBy string    `json:"registered_by"`
+	RegisteredAt time.Time `json:"registered_at"`
+}
+
+// WatchRequest is the body of POST /api/watchlist
+type WatchRequest struct {
+	Namespace string     `json:"namespace"`
+	Name      string     `json:"name"`
+	Reason    string     `json:"reason,omitempty"`
+	Until     *time.Time `json:"until,omitempty"` // empty = until unpinned
+}
+
+// WatchedWorkload is a workload pinned to the watchlist, e.g. during the
+// go-live of a new clinical service. Pinned workloads are evaluated every
+// poll, listed first, and any violation of theirs turns the overall status
+// to violation at once.
+type WatchedWorkload struct {
+	Key       string     `json:"key"` // namespace/name
+	Namespace string     `json:"namespace"`
+	Name      string     `json:"name"`
+	Reason    string     `json:"reason,omitempty"`
+	PinnedBy  string     `json:"pinned_by"`
+	PinnedAt  time.Time  `json:"pinned_at"`
+	Until     *time.Time `json:"until,omitempty"`
+}
+
+// SuppressRequest is the body of POST /api/workload/{ns}/{name}/suppress
+type SuppressRequest struct {
+	Reason string `json:"reason"` // e.g. the change ticket retiring the service
+}
+
+// SuppressedWorkload is a decommissioned workload. Lingering reports for it
+// are ignored, so a retired service doesn't count towards violations.
+type SuppressedWorkload struct {
+	Key          string    `json:"key"` // namespace/name
+	Namespace    string    `json:"namespace"`
+	Name         string    `json:"name"`
+	Reason       string    `json:"reason"`
+	SuppressedBy string    `json:"suppressed_by"`
+	SuppressedAt time.Time `json:"suppressed_at"`
+}
+
+// ShareRequest is the body of POST /api/workload/{ns}/{name}/share
+type ShareRequest struct {
+	TTL string `json:"ttl,omitempty"` // how long the link is valid, e.g. "4h"; 24h by default
+}
+
+// ShareLink is a time-limited, read-only link to one workload's detail and
+// history, for people without dashboard access
+type ShareLink struct {
+	ID        string    `json:"id"`
+	Key       string    `json:"key"`           // namespace/name
+	URL       string    `json:"url,omitempty"` // only when created: absolute with DASHBOARD_URL, a path otherwise
+	SharedBy  string    `json:"shared_by"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// SharedWorkload is the response of GET /api/shared/{token}
+type SharedWorkload struct {
+	Workload  WorkloadStatus `json:"workload"`
+	History   []HistoryEvent `json:"history"`
+	SharedBy  string         `json:"shared_by"`
+	ExpiresAt time.Time      `json:"expires_at"`
+}
+
+// DowntimeRequest is the body of POST /api/downtime
+type DowntimeRequest struct {
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	Namespaces []string  `json:"namespaces,omitempty"` // empty = every namespace
+	Reason     string    `json:"reason"`
+}
+
+// DowntimeWindow is a planned downtime an operator recorded, e.g. a TEE
+// firmware upgrade. Violations during it don't count against MTTR and uptime.
+type DowntimeWindow struct {
+	ID         string    `json:"id"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	Namespaces []string  `json:"namespaces,omitempty"`
+	Reason     string    `json:"reason"`
+	CreatedBy  string    `json:"created_by"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// FleetDiff is the response of GET /api/diff: how the fleet changed between
+// two instants
+type FleetDiff struct {
+	From    time.Time        `json:"from"`
+	To      time.Time        `json:"to"`
+	Added   []WorkloadStatus `json:"added"`   // state at To
+	Removed []WorkloadStatus `json:"removed"` // state at From
+	Changed []WorkloadChange `json:"changed"`
+}
+
+// WorkloadChange is a workload whose status or trust vector differs
+// between the two instants of a FleetDiff
+type WorkloadChange struct {
+	Key    string         `json:"key"`
+	Fields []string       `json:"fields"` // JSON names of the fields that differ, e.g. "attestation_status", "trust_vector"
+	Before WorkloadStatus `json:"before"`
+	After  WorkloadStatus `json:"after"`
+}
+
+// SearchResult is one match of GET /api/search: a current workload or a
+// history event, ranked by where the query terms matched
+type SearchResult struct {
+	Kind     string          `json:"kind"` // "workload" or "event"
+	Key      string          `json:"key"`  // namespace/name
+	Score    int             `json:"score"`
+	Matched  []string        `json:"matched"` // fields the terms matched: "name", "namespace", "notes", "labels" or "details"
+	Workload *WorkloadStatus `json:"workload,omitempty"`
+	Event    *HistoryEvent   `json:"event,omitempty"`
+}
+
+// HistoryEvent records a workload appearing, changing state, or disappearing
+type HistoryEvent struct {
+	Time           time.Time       `json:"time"`
+	Key            string          `json:"key"`  // namespace/name
+	Type           string          `json:"type"` // "added", "changed", "terminating", "removed" or "flapping"
+	PreviousStatus string          `json:"previous_status,omitempty"`
+	Status         *WorkloadStatus `json:"status,omitempty"`   // state after the event; last known state for "removed"
+	Imported       bool            `json:"imported,omitempty"` // replayed from archived Collector reports rather than observed
+}
+
+// WebhookPayload is the JSON body posted to webhook targets
+type WebhookPayload struct {
+	Event          string          `json:"event"` // "workload.added", "workload.changed", "workload.terminating", "workload.removed" or "workload.flapping"
+	Time           time.Time       `json:"time"`
+	Key            string          `json:"key"`
+	PreviousStatus string          `json:"previous_status,omitempty"`
+	Workload       *WorkloadStatus `json:"workload,omitempty"`
+	FlapCount      int             `json:"flap_count,omitempty"` // transitions consolidated into this alert
+	Summary        string          `json:"summary,omitempty"`
+}
+
+// NodeSummary aggregates attestation results for all workloads on one node
+type NodeSummary struct {
+	Name            string   `json:"name"`
+	Status          string   `json:"status"` // "healthy", "degraded" or "failing"
+	Workloads       int      `json:"workloads"`
+	Attested        int      `json:"attested"`
+	Failed          int      `json:"failed"`
+	TEETypes        []string `json:"tee_types"`
+	FailedWorkloads []string `json:"failed_workloads"`
+
+	// Host-level attestation, when the Collector reports it
+	HostAttestation *NodeReport `json:"host_attestation,omitempty"`
+}
+
+// NodeReport is the Collector's attestation of a node's TEE platform,
+// independent of the workloads running on it
+type NodeReport struct {
+	NodeName   string    `json:"node_name"`
+	Cluster    string    `json:"cluster,omitempty"`
+	TEEType    string    `json:"tee_type,omitempty"`
+	TCBVersion string    `json:"tcb_version,omitempty"`
+	Attested   bool      `json:"attested"`
+	Timestamp  time.Time `json:"timestamp"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// TEEInventory summarizes one TEE type across the fleet, returned by
+// /api/tee-inventory to track platform migrations
+type TEEInventory struct {
+	TEEType     string            `json:"tee_type"` // "unknown" when the Collector doesn't report it
+	Workloads   int               `json:"workloads"`
+	Attested    int               `json:"attested"`
+	Nodes       int               `json:"nodes"` // distinct nodes with a workload or host report of this type
+	Clusters    []string          `json:"clusters"`
+	TCBVersions []TCBVersionCount `json:"tcb_versions"`
+}
+
+// TCBVersionCount counts the workloads and nodes at one TCB level of a TEE type
+type TCBVersionCount struct {
+	Version   string `json:"version"` // "unknown" when not reported
+	Workloads int    `json:"workloads"`
+	Nodes     int    `json:"nodes"`
+}
+
+// ClusterSummary is the per-cluster rollup returned by /api/clusters
+type ClusterSummary struct {
+	Name      string     `json:"name"`
+	Status    string     `json:"status"` // "compliant", "violation" or "unreachable"
+	Workloads int        `json:"workloads"`
+	Attested  int        `json:"attested"`
+	Failed    int        `json:"failed"`
+	Unknown   int        `json:"unknown"` // could not be checked
+	LastSync  *time.Time `json:"last_sync,omitempty"`
+	LastError string     `json:"last_error,omitempty"`
+}
+
+// SiteSummary is the rollup of one site's dashboard in federation mode
+type SiteSummary struct {
+	Name       string     `json:"name"`
+	URL        string     `json:"url"`    // the site's dashboard, for drill-down
+	Status     string     `json:"status"` // the site's overall_status, or "unreachable"
+	Workloads  int        `json:"workloads"`
+	Attested   int        `json:"attested"`
+	Failed     int        `json:"failed"`
+	Unknown    int        `json:"unknown"`               // could not be checked
+	DataSource string     `json:"data_source,omitempty"` // the site's own data source
+	DataAsOf   *time.Time `json:"data_as_of,omitempty"`
+	LastPoll   *time.Time `json:"last_poll,omitempty"` // last successful poll of the site
+	LastError  string     `json:"last_error,omitempty"`
+}
+
+// FederationResponse is the network-wide rollup returned by /api/federation
+type FederationResponse struct {
+	OverallStatus string        `json:"overall_status"` // "compliant", "warning" or "violation"
+	Sites         []SiteSummary `json:"sites"`
+	LastUpdated   time.Time     `json:"last_updated"`
+}
+
+// StateSnapshot is the full dashboard state at a point in time, the
+// payload of GET /api/export/snapshot
+type StateSnapshot struct {
+	TakenAt       time.Time        `json:"taken_at"`
+	TakenBy       string           `json:"taken_by,omitempty"`
+	Site          string           `json:"site,omitempty"`
+	Version       string           `json:"version"`
+	Commit        string           `json:"commit"`
+	ConfigHash    string           `json:"config_hash,omitempty"`
+	Generation    uint64           `json:"generation"`
+	OverallStatus string           `json:"overall_status"` // "compliant", "warning" or "violation"
+	DataSource    string           `json:"data_source"`
+	DataAsOf      *time.Time       `json:"data_as_of,omitempty"`
+	Clusters      []ClusterSummary `json:"clusters"`
+	Workloads     []WorkloadStatus `json:"workloads"` // by cluster, namespace and name
+}
+
+// SignedSnapshot is the response of GET /api/export/snapshot: a
+// StateSnapshot in the flattened JWS JSON serialization (RFC 7515 section
+// 7.2.2), verifiable against GET /api/signing-keys
+type SignedSnapshot struct {
+	Payload   string `json:"payload"` // base64url of the StateSnapshot JSON
+	Protected string `json:"protected"`
+	Signature string `json:"signature"`
+}
+
+// Explanation is the response of GET /api/workload/{ns}/{name}/explanation:
+// how the workload's verdict was reached, rule by rule
+type Explanation struct {
+	Key               string          `json:"key"`
+	Verdict           string          `json:"verdict"` // "compliant", "violation" or "unknown"
+	AttestationStatus string          `json:"attestation_status"`
+	Cause             string          `json:"cause,omitempty"` // the first rule that decided a violation or unknown verdict
+	Summary           string          `json:"summary"`
+	Profile           string          `json:"ar4si_profile,omitempty"`
+	PolicyVersion     int             `json:"policy_version,omitempty"`
+	EvaluatedAt       time.Time       `json:"evaluated_at"`
+	Rules             []ExplainedRule `json:"rules"`           // in evaluation order
+	Notes             []string        `json:"notes,omitempty"` // what changes how the verdict is handled, e.g. an acknowledgement
+}
+
+// ExplainedRule is one rule evaluated for a verdict and the evidence it read
+type ExplainedRule struct {
+	Rule        string          `json:"rule"` // check name, e.g. "tee_attestation" or "gate:cmdb"
+	Description string          `json:"description"`
+	Outcome     string          `json:"outcome"`            // "pass", "warn", "fail" or "skipped"
+	Decisive    bool            `json:"decisive,omitempty"` // its failure alone decides the verdict
+	Evidence    []EvidenceField `json:"evidence,omitempty"`
+	Expected    string          `json:"expected,omitempty"`
+	Actual      string          `json:"actual,omitempty"`
+	Severity    string          `json:"severity,omitempty"`
+}
+
+// EvidenceField is one input a rule read
+type EvidenceField struct {
+	Field  string `json:"field"` // e.g. "trust_vector.hardware"
+	Value  string `json:"value"`
+	Source string `json:"source"` // "report", "kubernetes", "secondary-verifier", "host", "gate", "dashboard" or "config"
+}
+
+// FeaturesResponse is the response of GET /api/features
+type FeaturesResponse struct {
+	Flags        []FeatureFlag `json:"flags"`
+	Deprecations []Deprecation `json:"deprecations"`
+}
+
+// WallboardResponse is the response of GET /api/wallboard: the fleet at a
+// glance for lobby displays, without the workload list
+type WallboardResponse struct {
+	OverallStatus string           `json:"overall_status"`
+	Color         string           `json:"color"`  // of the overall status, from the trust tier palette
+	Issues        []WallboardIssue `json:"issues"` // the most severe, at most 3
+	Counts        WallboardCounts  `json:"counts"`
+	DataSource    string           `json:"data_source"`
+	DataAsOf      *time.Time       `json:"data_as_of,omitempty"`
+	Stale         bool             `json:"stale"` // no Collector synced within the staleness limit
+	LastUpdated   time.Time        `json:"last_updated"`
+}
+
+// WallboardIssue is one workload's most urgent problem, in a line of text
+type WallboardIssue struct {
+	Workload     string `json:"workload"` // namespace/name
+	Cluster      string `json:"cluster,omitempty"`
+	Severity     string `json:"severity"` // "critical", "high" or "warning"
+	Text         string `json:"text"`
+	Acknowledged bool   `json:"acknowledged,omitempty"`
+}
+
+// WallboardCounts counts the workloads behind the wallboard
+type WallboardCounts struct {
+	Workloads    int `json:"workloads"`
+	Issues       int `json:"issues"` // workloads with a problem outside maintenance windows and ignored namespaces
+	Violations   int `json:"violations"`
+	Unknown      int `json:"unknown"`
+	Acknowledged int `json:"acknowledged"` // issues someone is working on
+}
+
+// FeatureFlag is a feature being rolled out, and whether it is enabled at
+// this site
+type FeatureFlag struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Enabled     bool     `json:"enabled"`
+	Default     bool     `json:"default"`             // without a FEATURE_FLAGS setting
+	Endpoints   []string `json:"endpoints,omitempty"` // path patterns served only while enabled
+}
+
+// Deprecation announces the removal of an endpoint, as its Deprecation and
+// Sunset response headers do
+type Deprecation struct {
+	Endpoint   string     `json:"endpoint"` // path pattern
+	Deprecated time.Time  `json:"deprecated"`
+	Sunset     *time.Time `json:"sunset,omitempty"` // from when the endpoint may be removed
+	Successor  string     `json:"successor,omitempty"`
+}
+
+// Session describes the caller of GET /api/session, so the web UI can show
+// who is signed in and hide actions they may not take
+type Session struct {
+	Authenticated bool       `json:"authenticated"` // false when authentication is disabled
+	Name          string     `json:"name,omitempty"`
+	Roles         []string   `json:"roles,omitempty"`
+	Namespaces    []string   `json:"namespaces,omitempty"`  // tenant scope; empty = all
+	Permissions   []string   `json:"permissions,omitempty"` // granted by the RBAC policy, if any
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`  // set for cookie sessions
+}
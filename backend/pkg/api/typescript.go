@@ -0,0 +1,146 @@
+package api
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Types lists the API types in the order they are published to clients
+var Types = []interface{}{
+	DashboardResponse{},
+	WorkloadStatus{},
+	GateResult{},
+	Check{},
+	AckRequest{},
+	Acknowledgement{},
+	BatchAckRequest{},
+	BatchAckResult{},
+	AnnotationsRequest{},
+	ExpectedWorkload{},
+	WatchedWorkload{},
+	SuppressedWorkload{},
+	ShareLink{},
+	SharedWorkload{},
+	DowntimeWindow{},
+	SearchResult{},
+	FleetDiff{},
+	HistoryEvent{},
+	WebhookPayload{},
+	NodeSummary{},
+	NodeReport{},
+	ClusterSummary{},
+	FederationResponse{},
+	StateSnapshot{},
+	SignedSnapshot{},
+	FeaturesResponse{},
+	Explanation{},
+	WallboardResponse{},
+	TEEInventory{},
+	TrustTrend{},
+	TrustTier{},
+	Session{},
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// TypeScript renders TypeScript interfaces for Types from their JSON
+// encoding. omitempty fields become optional properties.
+func TypeScript() string {
+	var b strings.Builder
+	b.WriteString("// Code generated from backend/pkg/api by `make types`. DO NOT EDIT.\n")
+
+	seen := make(map[reflect.Type]bool)
+	queue := make([]reflect.Type, 0, len(Types))
+	for _, v := range Types {
+		queue = append(queue, reflect.TypeOf(v))
+	}
+
+	for len(queue) > 0 {
+		t := queue[0]
+		queue = queue[1:]
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+
+		fmt.Fprintf(&b, "\nexport interface %s {\n", t.Name())
+		for _, field := range jsonFields(t) {
+			optional := ""
+			if field.omitEmpty {
+				optional = "?"
+			}
+			fmt.Fprintf(&b, "  %s%s: %s;\n", field.name, optional, tsType(field.typ, &queue))
+		}
+		b.WriteString("}\n")
+	}
+	return b.String()
+}
+
+// jsonField is a struct field as it appears in the JSON encoding
+type jsonField struct {
+	name      string
+	typ       reflect.Type
+	omitEmpty bool
+	optional  bool // tagged `jsonschema:"optional"`
+}
+
+// jsonFields returns the exported, JSON-encoded fields of a struct type
+func jsonFields(t reflect.Type) []jsonField {
+	var fields []jsonField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, jsonField{
+			name:      name,
+			typ:       f.Type,
+			omitEmpty: strings.Contains(options, "omitempty"),
+			optional:  f.Tag.Get("jsonschema") == "optional",
+		})
+	}
+	return fields
+}
+
+// tsType maps a Go type to TypeScript, queueing nested structs for rendering
+func tsType(t reflect.Type, queue *[]reflect.Type) string {
+	if t == timeType {
+		return "string" // RFC 3339
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return tsType(t.Elem(), queue) + " | null"
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		elem := tsType(t.Elem(), queue)
+		if strings.Contains(elem, " ") {
+			elem = "(" + elem + ")"
+		}
+		return elem + "[]"
+	case reflect.Map:
+		return fmt.Sprintf("Record<string, %s>", tsType(t.Elem(), queue))
+	case reflect.Struct:
+		*queue = append(*queue, t)
+		return t.Name()
+	default:
+		return "unknown"
+	}
+}
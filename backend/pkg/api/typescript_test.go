@@ -0,0 +1,45 @@
+package api
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestTypeScript tests the generated interfaces for field mapping
+func TestTypeScript(t *testing.T) {
+	ts := TypeScript()
+
+	expected := []string{
+		"export interface WorkloadStatus {",
+		"  attested: boolean;",
+		"  tee_type?: string;",
+		"  gates?: GateResult[];",
+		"  last_restart?: string | null;",
+		"  workloads: WorkloadStatus[];",
+		"  generation?: number;",
+		"export interface HistoryEvent {",
+		"  status?: WorkloadStatus | null;",
+	}
+	for _, line := range expected {
+		if !strings.Contains(ts, line+"\n") {
+			t.Errorf("Expected generated TypeScript to contain %q", line)
+		}
+	}
+
+	if strings.Count(ts, "export interface WorkloadStatus {") != 1 {
+		t.Error("Expected each interface to be rendered once")
+	}
+}
+
+// TestTypeScriptUpToDate tests that the checked-in api-types.d.ts matches
+// the Go structs (run `make types` to regenerate)
+func TestTypeScriptUpToDate(t *testing.T) {
+	checkedIn, err := os.ReadFile("../../../api-types.d.ts")
+	if err != nil {
+		t.Skipf("api-types.d.ts not available: %v", err)
+	}
+	if string(checkedIn) != TypeScript() {
+		t.Error("api-types.d.ts is out of date - run `make types`")
+	}
+}
@@ -0,0 +1,104 @@
+// Package assets fingerprints the frontend's static files at build time, so
+// they can be served with long-lived immutable caching: each file is renamed
+// to carry a hash of its content, and the HTML pages referencing it are
+// rewritten to the new name. A changed file gets a new URL, so browsers never
+// run a stale frontend after an upgrade.
+package assets
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// FingerprintPattern matches file names carrying a content hash, e.g.
+// app.3f2a9c1b7d04.js
+var FingerprintPattern = regexp.MustCompile(`\.[0-9a-f]{12}\.[A-Za-z0-9]+$`)
+
+// referencePattern matches quoted or url()-wrapped paths in HTML, with an
+// optional leading "/" or "./"
+var referencePattern = regexp.MustCompile(`(["'(])(/|\./)?([^"'()\s?#]+)`)
+
+// FingerprintedName returns name with the content hash inserted before the
+// extension
+func FingerprintedName(name string, content []byte) string {
+	sum := sha256.Sum256(content)
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + hex.EncodeToString(sum[:6]) + ext
+}
+
+// Fingerprint renames every asset under dir except HTML pages, which are
+// entry points and keep their names, and rewrites references to the renamed
+// assets in the pages. It returns the renames as slash-separated paths
+// relative to dir. Files already fingerprinted are left alone, so running
+// it twice is harmless.
+func Fingerprint(dir string) (map[string]string, error) {
+	renamed := make(map[string]string)
+	var pages []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if strings.EqualFold(path.Ext(rel), ".html") {
+			pages = append(pages, p)
+			return nil
+		}
+		if FingerprintPattern.MatchString(rel) {
+			return nil
+		}
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		renamed[rel] = FingerprintedName(rel, content)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for from, to := range renamed {
+		if err := os.Rename(filepath.Join(dir, filepath.FromSlash(from)), filepath.Join(dir, filepath.FromSlash(to))); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, page := range pages {
+		content, err := os.ReadFile(page)
+		if err != nil {
+			return nil, err
+		}
+		// References are relative to the page, or to dir if rooted
+		pageDir, _ := filepath.Rel(dir, filepath.Dir(page))
+		pageDir = filepath.ToSlash(pageDir)
+		rewritten := referencePattern.ReplaceAllStringFunc(string(content), func(ref string) string {
+			m := referencePattern.FindStringSubmatch(ref)
+			target := m[3]
+			if m[2] != "/" {
+				target = path.Join(pageDir, target)
+			}
+			to, ok := renamed[target]
+			if !ok {
+				return ref
+			}
+			return m[1] + m[2] + path.Join(path.Dir(m[3]), path.Base(to))
+		})
+		if rewritten != string(content) {
+			if err := os.WriteFile(page, []byte(rewritten), 0644); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return renamed, nil
+}
@@ -0,0 +1,49 @@
+package assets
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestFingerprint tests that assets are renamed by content and references
+// in pages are rewritten, while pages keep their names
+func TestFingerprint(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "js"), 0755)
+	os.WriteFile(filepath.Join(dir, "js", "app.js"), []byte("console.log(1)"), 0644)
+	os.WriteFile(filepath.Join(dir, "style.css"), []byte("body{}"), 0644)
+	os.WriteFile(filepath.Join(dir, "index.html"), []byte(`<link href="style.css"><script src="/js/app.js"></script><a href="https://example.org/style.css">x</a><img src="./missing.png">`), 0644)
+
+	renamed, err := Fingerprint(dir)
+	if err != nil {
+		t.Fatalf("Failed to fingerprint: %v", err)
+	}
+	app := renamed["js/app.js"]
+	if app != FingerprintedName("js/app.js", []byte("console.log(1)")) || !FingerprintPattern.MatchString(app) {
+		t.Errorf("Unexpected name for js/app.js: %q", app)
+	}
+	if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(app))); err != nil {
+		t.Errorf("Expected %s to exist: %v", app, err)
+	}
+	if _, ok := renamed["index.html"]; ok {
+		t.Error("Expected index.html to keep its name")
+	}
+
+	page, _ := os.ReadFile(filepath.Join(dir, "index.html"))
+	expected := `<link href="` + renamed["style.css"] + `"><script src="/` + app + `"></script><a href="https://example.org/style.css">x</a><img src="./missing.png">`
+	if string(page) != expected {
+		t.Errorf("Expected page %s, got %s", expected, page)
+	}
+
+	again, err := Fingerprint(dir)
+	if err != nil || len(again) != 0 {
+		t.Errorf("Expected second run to rename nothing, got %v %v", again, err)
+	}
+	if page2, _ := os.ReadFile(filepath.Join(dir, "index.html")); string(page2) != expected {
+		t.Errorf("Expected page unchanged on second run, got %s", page2)
+	}
+	if FingerprintedName("a.js", []byte("1")) == FingerprintedName("a.js", []byte("2")) {
+		t.Error("Expected different content to get different names")
+	}
+}
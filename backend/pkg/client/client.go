@@ -0,0 +1,276 @@
+// Package client is a Go client for the dashboard backend API. It handles
+// bearer-token authentication, retries idempotent requests on transient
+// failures, and follows the live event stream.
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/rh-summit-coco/raj-hospital-dashboard/backend/pkg/api"
+)
+
+// Client talks to one dashboard backend
+type Client struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+	maxRetries int
+	backoff    time.Duration
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithToken authenticates requests with a bearer token from AUTH_TOKENS_FILE
+func WithToken(token string) Option {
+	return func(c *Client) { c.token = token }
+}
+
+// WithHTTPClient replaces the default HTTP client (e.g. for custom TLS)
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) { c.httpClient = httpClient }
+}
+
+// WithRetries sets how often a failed request is retried and the initial
+// backoff, which doubles after each attempt
+func WithRetries(maxRetries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.backoff = backoff
+	}
+}
+
+// New returns a client for the backend at baseURL (e.g. "https://dashboard.example.com")
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		maxRetries: 3,
+		backoff:    500 * time.Millisecond,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// APIError is a non-2xx response from the backend
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("dashboard API returned %d: %s", e.StatusCode, e.Message)
+}
+
+// ListOptions filters list requests
+type ListOptions struct {
+	Cluster string
+}
+
+// Status returns the overall dashboard status and all workloads
+func (c *Client) Status(ctx context.Context, opts ListOptions) (*api.DashboardResponse, error) {
+	var response api.DashboardResponse
+	if err := c.get(ctx, "/api/status", opts.query(), &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ListWorkloads returns the status of every workload
+func (c *Client) ListWorkloads(ctx context.Context, opts ListOptions) ([]api.WorkloadStatus, error) {
+	var workloads []api.WorkloadStatus
+	if err := c.get(ctx, "/api/workloads", opts.query(), &workloads); err != nil {
+		return nil, err
+	}
+	return workloads, nil
+}
+
+// GetWorkload returns the status of one workload. A missing workload is an
+// *APIError with StatusCode 404.
+func (c *Client) GetWorkload(ctx context.Context, namespace, name string) (*api.WorkloadStatus, error) {
+	var workload api.WorkloadStatus
+	path := "/api/workload/" + url.PathEscape(namespace) + "/" + url.PathEscape(name)
+	if err := c.get(ctx, path, nil, &workload); err != nil {
+		return nil, err
+	}
+	return &workload, nil
+}
+
+// WatchEvents follows the server-sent event stream, calling handler for each
+// workload event until ctx is cancelled. Dropped connections are re-established
+// with backoff; events that occur while disconnected are not replayed, so
+// callers that need a consistent view should re-read Status after reconnecting.
+func (c *Client) WatchEvents(ctx context.Context, opts ListOptions, handler func(api.HistoryEvent)) error {
+	backoff := c.backoff
+	for {
+		connected, err := c.streamEvents(ctx, opts, handler)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode < 500 {
+			return err
+		}
+		if connected {
+			backoff = c.backoff
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		if backoff < time.Minute {
+			backoff *= 2
+		}
+	}
+}
+
+// streamEvents runs one connection to the event stream. connected reports
+// whether the stream was established before it ended.
+func (c *Client) streamEvents(ctx context.Context, opts ListOptions, handler func(api.HistoryEvent)) (connected bool, err error) {
+	query := opts.query()
+	if c.token != "" {
+		token, err := c.streamToken(ctx)
+		if err != nil {
+			return false, err
+		}
+		query.Set("token", token)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/api/events?"+query.Encode(), nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	// The stream is long-lived - don't apply the client's request timeout
+	streamClient := *c.httpClient
+	streamClient.Timeout = 0
+	resp, err := streamClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, responseError(resp)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	var data strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if data.Len() > 0 {
+				var event api.HistoryEvent
+				if err := json.Unmarshal([]byte(data.String()), &event); err == nil {
+					handler(event)
+				}
+				data.Reset()
+			}
+		case strings.HasPrefix(line, "data:"):
+			data.WriteString(strings.TrimSpace(strings.TrimPrefix(line, "data:")))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return true, err
+	}
+	return true, io.ErrUnexpectedEOF
+}
+
+// streamToken exchanges the bearer token for a short-lived subscription token
+func (c *Client) streamToken(ctx context.Context) (string, error) {
+	var response struct {
+		Token string `json:"token"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/api/stream-token", nil, &response); err != nil {
+		return "", err
+	}
+	return response.Token, nil
+}
+
+// get performs a GET, retrying network errors, 429 and 5xx responses
+func (c *Client) get(ctx context.Context, path string, query url.Values, v interface{}) error {
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	backoff := c.backoff
+	for attempt := 0; ; attempt++ {
+		err := c.do(ctx, http.MethodGet, path, nil, v)
+		if err == nil || attempt >= c.maxRetries || !retryable(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// do performs a single request and decodes a JSON response into v
+func (c *Client) do(ctx context.Context, method, path string, body io.Reader, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return responseError(resp)
+	}
+	if v == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+func responseError(resp *http.Response) error {
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	return &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(message))}
+}
+
+// retryable reports whether a failed request may succeed if repeated
+func retryable(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= 500
+	}
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+func (opts ListOptions) query() url.Values {
+	query := url.Values{}
+	if opts.Cluster != "" {
+		query.Set("cluster", opts.Cluster)
+	}
+	return query
+}
@@ -0,0 +1,110 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rh-summit-coco/raj-hospital-dashboard/backend/pkg/api"
+)
+
+// TestListWorkloadsRetries tests that transient failures are retried with
+// the bearer token on every attempt
+func TestListWorkloadsRetries(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("Expected bearer token, got %q", r.Header.Get("Authorization"))
+		}
+		if r.URL.Query().Get("cluster") != "site-a" {
+			t.Errorf("Expected cluster filter, got %q", r.URL.RawQuery)
+		}
+		if attempts < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode([]api.WorkloadStatus{{Name: "pod", Namespace: "icu", Attested: true}})
+	}))
+	defer server.Close()
+
+	c := New(server.URL, WithToken("secret"), WithRetries(3, time.Millisecond))
+	workloads, err := c.ListWorkloads(context.Background(), ListOptions{Cluster: "site-a"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if attempts != 3 || len(workloads) != 1 || workloads[0].Name != "pod" {
+		t.Errorf("Expected 1 workload after 3 attempts, got %d workloads after %d", len(workloads), attempts)
+	}
+}
+
+// TestGetWorkloadNotFound tests that client errors are returned as APIError
+// without retrying
+func TestGetWorkloadNotFound(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if r.URL.Path != "/api/workload/icu/missing" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		http.Error(w, "workload not found", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	_, err := New(server.URL, WithRetries(3, time.Millisecond)).GetWorkload(context.Background(), "icu", "missing")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound || apiErr.Message != "workload not found" {
+		t.Errorf("Expected 404 APIError, got %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("Expected no retries for 404, got %d attempts", attempts)
+	}
+}
+
+// TestWatchEvents tests following the event stream with a subscription token
+func TestWatchEvents(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/stream-token":
+			if r.Method != http.MethodPost || r.Header.Get("Authorization") != "Bearer secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{"token": "sub-token"})
+		case "/api/events":
+			if r.URL.Query().Get("token") != "sub-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, ": keepalive\n\n")
+			fmt.Fprint(w, "event: changed\ndata: {\"key\":\"icu/a\",\"type\":\"changed\"}\n\n")
+			fmt.Fprint(w, "event: removed\ndata: {\"key\":\"icu/b\",\"type\":\"removed\"}\n\n")
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		}
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var keys []string
+	err := New(server.URL, WithToken("secret")).WatchEvents(ctx, ListOptions{}, func(event api.HistoryEvent) {
+		keys = append(keys, event.Key)
+		if len(keys) == 2 {
+			cancel()
+		}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if len(keys) != 2 || keys[0] != "icu/a" || keys[1] != "icu/b" {
+		t.Errorf("Expected events icu/a and icu/b, got %v", keys)
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// maxPluginOutput bounds how much stdout a plugin may produce
+const maxPluginOutput = 1 << 20
+
+// Exec plugins let site integrators extend the dashboard in any language:
+// the dashboard runs the configured executable with a JSON document on stdin
+// and reads a single JSON document from stdout. A non-zero exit status is an
+// error; stderr is included in the error message.
+
+// runPlugin executes command with input encoded as JSON on stdin and decodes
+// stdout into output (if non-nil)
+func runPlugin(command []string, timeout time.Duration, input, output interface{}) error {
+	if len(command) == 0 {
+		return fmt.Errorf("no command configured")
+	}
+
+	stdin, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	// Don't wait on grandchildren still holding stdout after a timeout kill
+	cmd.WaitDelay = time.Second
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &limitedBuffer{buf: &stdout, limit: maxPluginOutput}
+	cmd.Stderr = &limitedBuffer{buf: &stderr, limit: 4096}
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("plugin timed out after %s", timeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%v: %s", err, msg)
+		}
+		return err
+	}
+
+	if output == nil || strings.TrimSpace(stdout.String()) == "" {
+		return nil
+	}
+	if err := json.Unmarshal(stdout.Bytes(), output); err != nil {
+		return fmt.Errorf("invalid plugin output: %w", err)
+	}
+	return nil
+}
+
+// limitedBuffer discards writes beyond limit bytes
+type limitedBuffer struct {
+	buf   *bytes.Buffer
+	limit int
+}
+
+func (l *limitedBuffer) Write(p []byte) (int, error) {
+	if room := l.limit - l.buf.Len(); room > 0 {
+		if len(p) > room {
+			l.buf.Write(p[:room])
+		} else {
+			l.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+// ExecGate is a gate implemented by an external executable. It receives a
+// gateInput ({"report": ..., "workload": ...}) on stdin and must print an
+// execVerdict ({"status": "passing"|"failed", "details": "..."}) on stdout.
+type ExecGate struct {
+	Name     string   `json:"name"`
+	Command  []string `json:"command"`
+	Timeout  string   `json:"timeout,omitempty"`   // default 5s
+	FailOpen bool     `json:"fail_open,omitempty"` // treat plugin errors as passing
+
+	timeout time.Duration
+}
+
+// execVerdict is the stdout document of a gate plugin
+type execVerdict struct {
+	Status  string `json:"status"`
+	Details string `json:"details,omitempty"`
+}
+
+// init validates the gate definition
+func (g *ExecGate) init() error {
+	if g.Name == "" || len(g.Command) == 0 {
+		return fmt.Errorf("name and command are required")
+	}
+
+	g.timeout = 5 * time.Second
+	if g.Timeout != "" {
+		var err error
+		if g.timeout, err = time.ParseDuration(g.Timeout); err != nil {
+			return fmt.Errorf("invalid timeout: %w", err)
+		}
+	}
+	return nil
+}
+
+// check runs the plugin for one workload
+func (g *ExecGate) check(input gateInput) GateResult {
+	result := GateResult{Name: g.Name}
+
+	var verdict execVerdict
+	if err := runPlugin(g.Command, g.timeout, input, &verdict); err != nil {
+		result.Status = "error"
+		result.Details = fmt.Sprintf("plugin failed: %v", err)
+		if g.FailOpen {
+			result.Status = "passing"
+		}
+		return result
+	}
+
+	switch verdict.Status {
+	case "passing", "failed":
+		result.Status = verdict.Status
+		result.Details = verdict.Details
+	default:
+		result.Status = "error"
+		result.Details = fmt.Sprintf("plugin returned unknown status %q", verdict.Status)
+	}
+	return result
+}
+
+// execNotifyResult is the optional stdout document of a notifier plugin
+type execNotifyResult struct {
+	Delivered *bool  `json:"delivered,omitempty"` // false requests a retry
+	Error     string `json:"error,omitempty"`
+}
+
+// sendExec delivers a notification through a notifier plugin: the
+// WebhookPayload is passed on stdin. A zero exit status means delivered
+// unless stdout reports {"delivered": false}.
+func sendExec(target *notifyTarget, payload WebhookPayload) error {
+	var result execNotifyResult
+	if err := runPlugin(target.Command, target.commandTimeout, payload, &result); err != nil {
+		return err
+	}
+	if result.Delivered != nil && !*result.Delivered {
+		if result.Error != "" {
+			return fmt.Errorf("plugin reported failure: %s", result.Error)
+		}
+		return fmt.Errorf("plugin reported failure")
+	}
+	return nil
+}
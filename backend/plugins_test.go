@@ -0,0 +1,94 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestExecGate tests gate verdicts from an exec plugin
+func TestExecGate(t *testing.T) {
+	// The plugin fails workloads in the "quarantine" namespace
+	script := `input=$(cat); case "$input" in *'"namespace":"quarantine"'*) echo '{"status":"failed","details":"namespace quarantined"}';; *) echo '{"status":"passing"}';; esac`
+	g := ExecGate{Name: "quarantine", Command: []string{"/bin/sh", "-c", script}}
+	if err := g.init(); err != nil {
+		t.Fatalf("Failed to init gate: %v", err)
+	}
+
+	report := &CollectorReport{PodName: "a"}
+	result := g.check(gateInput{Report: report, Workload: &WorkloadStatus{Name: "a", Namespace: "quarantine"}})
+	if result.Status != "failed" || result.Details != "namespace quarantined" {
+		t.Errorf("Expected failed verdict, got %+v", result)
+	}
+
+	result = g.check(gateInput{Report: report, Workload: &WorkloadStatus{Name: "a", Namespace: "icu"}})
+	if result.Status != "passing" {
+		t.Errorf("Expected passing verdict, got %+v", result)
+	}
+}
+
+// TestExecGateErrors tests plugin failures, bad output and timeouts
+func TestExecGateErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+	}{
+		{"exit", `echo boom >&2; exit 3`},
+		{"garbage", `echo not-json`},
+		{"unknown", `echo '{"status":"maybe"}'`},
+		{"slow", `sleep 5`},
+	}
+
+	for _, test := range tests {
+		g := ExecGate{Name: test.name, Command: []string{"/bin/sh", "-c", test.script}, Timeout: "300ms"}
+		g.init()
+		result := g.check(gateInput{Workload: &WorkloadStatus{}})
+		if result.Status != "error" {
+			t.Errorf("%s: expected error, got %+v", test.name, result)
+		}
+		if test.name == "exit" && !strings.Contains(result.Details, "boom") {
+			t.Errorf("Expected stderr in details, got %q", result.Details)
+		}
+	}
+}
+
+// TestExecNotifier tests delivery through a notifier plugin
+func TestExecNotifier(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "received.json")
+	notifier, err := newNotifier([]notifyTarget{
+		{Name: "pager", Command: []string{"/bin/sh", "-c", "cat > " + out}},
+		{Name: "refusing", Command: []string{"/bin/sh", "-c", `echo '{"delivered":false,"error":"pager offline"}'`}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create notifier: %v", err)
+	}
+
+	notifier.Notify([]HistoryEvent{violationEvent("icu/a")})
+	notifier.deliverDue()
+
+	data, err := os.ReadFile(out)
+	if err != nil || !strings.Contains(string(data), `"key":"icu/a"`) {
+		t.Errorf("Expected payload on plugin stdin, got %q (%v)", data, err)
+	}
+
+	if notifier.Pending() != 1 || notifier.queue[0].Target != "refusing" {
+		t.Fatalf("Expected refused notification to stay queued, got %+v", notifier.queue)
+	}
+	if !strings.Contains(notifier.queue[0].LastError, "pager offline") {
+		t.Errorf("Expected plugin error to be recorded, got %q", notifier.queue[0].LastError)
+	}
+}
+
+// TestNotifyTargetValidation tests that a target needs exactly one of url or command
+func TestNotifyTargetValidation(t *testing.T) {
+	if _, err := newNotifier([]notifyTarget{{Name: "x"}}, nil); err == nil {
+		t.Error("Expected error for target without url or command")
+	}
+	if _, err := newNotifier([]notifyTarget{{Name: "x", URL: "http://a", Command: []string{"b"}}}, nil); err == nil {
+		t.Error("Expected error for target with both url and command")
+	}
+	if _, err := newNotifier([]notifyTarget{{Name: "x", Command: []string{"b"}, CommandTimeout: "soon"}}, nil); err == nil {
+		t.Error("Expected error for invalid command_timeout")
+	}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// Policy is a set of policy settings, evaluated in a dry run or stored as a
+// version. Omitted (null) settings keep the startup configuration.
+type Policy struct {
+	AR4SIProfile  *string       `json:"ar4si_profile,omitempty"`              // "" requires no particular claims
+	ImagePolicies []ImagePolicy `json:"image_policies" jsonschema:"optional"` // an empty list removes all image policies
+}
+
+// policyVerdict is a workload's status under one policy
+type policyVerdict struct {
+	AttestationStatus string  `json:"attestation_status"`
+	GateOneStatus     string  `json:"gate_one_status"`
+	GateTwoStatus     string  `json:"gate_two_status"`
+	Violation         bool    `json:"violation"`
+	FailedChecks      []Check `json:"failed_checks,omitempty"`
+}
+
+// policyOutcome compares a workload under the live and the candidate policy
+type policyOutcome struct {
+	Key       string        `json:"key"`
+	Cluster   string        `json:"cluster,omitempty"`
+	Current   policyVerdict `json:"current"`
+	Candidate policyVerdict `json:"candidate"`
+	Changed   bool          `json:"changed"`
+}
+
+// policyEvaluation is the response of POST /api/policy/evaluate
+type policyEvaluation struct {
+	Evaluated      int             `json:"evaluated"`
+	Changed        int             `json:"changed"`
+	NewlyViolating int             `json:"newly_violating"`
+	NewlyCompliant int             `json:"newly_compliant"`
+	Workloads      []policyOutcome `json:"workloads"`
+}
+
+// retainReports keeps the latest Collector report of every cached workload,
+// so policy dry runs can re-evaluate them. Reports of clusters that weren't
+// synced this cycle are kept alongside their cache entries.
+func (s *Server) retainReports(reports []CollectorReport) {
+	fresh := make(map[string]CollectorReport, len(reports))
+	for _, report := range reports {
+		fresh[report.Namespace+"/"+report.PodName] = report
+	}
+
+	s.cacheMutex.Lock()
+	defer s.cacheMutex.Unlock()
+
+	retained := make(map[string]CollectorReport, len(s.statusCache))
+	for key := range s.statusCache {
+		if report, ok := fresh[key]; ok && !report.Timestamp.Before(s.reportTimes[key]) {
+			retained[key] = report
+		} else if report, ok := s.reports[key]; ok {
+			retained[key] = report
+		}
+	}
+	s.reports = retained
+}
+
+// evaluatePolicy derives workload statuses from reports under the given
+// policy settings. Additional gates aren't part of the policy, so their live
+// results are reused rather than called again; verifier and host
+// correlation are not re-run. live is index-aligned with reports.
+func (s *Server) evaluatePolicy(ar4siProfile string, imagePolicies []ImagePolicy, reports []CollectorReport, live []*WorkloadStatus) []*WorkloadStatus {
+	evaluator := &Server{ar4siProfile: ar4siProfile, imagePolicies: imagePolicies, clockSkew: s.clockSkew}
+
+	statuses := make([]*WorkloadStatus, len(reports))
+	for i, report := range reports {
+		statuses[i] = evaluator.convertCollectorReport(report)
+		statuses[i].ImageDigests = live[i].ImageDigests
+		statuses[i].Gates = live[i].Gates
+		gateChecks(statuses[i])
+	}
+	evaluator.checkImagePolicies(statuses)
+	return statuses
+}
+
+// keys returns the namespace/name of every workload evaluated
+func (e *policyEvaluation) keys() []string {
+	keys := make([]string, len(e.Workloads))
+	for i, outcome := range e.Workloads {
+		keys[i] = outcome.Key
+	}
+	return keys
+}
+
+func verdictOf(status *WorkloadStatus) policyVerdict {
+	return policyVerdict{
+		AttestationStatus: status.AttestationStatus,
+		GateOneStatus:     status.GateOneStatus,
+		GateTwoStatus:     status.GateTwoStatus,
+		Violation:         isViolation(status),
+		FailedChecks:      status.FailedChecks,
+	}
+}
+
+// handlePolicyEvaluate previews what every cached workload's status would
+// become under a candidate policy, without applying it
+// POST /api/policy/evaluate
+func (s *Server) handlePolicyEvaluate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var policy Policy
+	if !decodeValid(w, r, policySchema, &policy) {
+		return
+	}
+
+	if err := policy.init(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Snapshot the reports, live statuses and live policy - evaluation may
+	// call out to allowlist services and must not hold the cache lock
+	s.cacheMutex.RLock()
+	liveProfile, liveImagePolicies := s.ar4siProfile, s.imagePolicies
+	keys := make([]string, 0, len(s.reports))
+	for key := range s.reports {
+		if _, ok := s.statusCache[key]; ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	reports := make([]CollectorReport, len(keys))
+	live := make([]*WorkloadStatus, len(keys))
+	for i, key := range keys {
+		reports[i] = s.reports[key]
+		live[i] = copyStatus(s.statusCache[key])
+	}
+	s.cacheMutex.RUnlock()
+
+	// Both sides go through the same pipeline, so differences come from the
+	// policy alone and not from the stages a dry run skips
+	current := s.evaluatePolicy(liveProfile, liveImagePolicies, reports, live)
+	profile, imagePolicies := policy.resolve(liveProfile, liveImagePolicies)
+	candidate := s.evaluatePolicy(profile, imagePolicies, reports, live)
+
+	evaluation := comparePolicies(keys, current, candidate)
+	noteWorkloadKeys(r, evaluation.keys()...)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(evaluation)
+}
+
+// init validates the policy's settings and prepares its image policies
+func (p *Policy) init() error {
+	if p.AR4SIProfile != nil {
+		if _, ok := ar4siProfiles[*p.AR4SIProfile]; *p.AR4SIProfile != "" && !ok {
+			return fmt.Errorf("unknown ar4si_profile %q", *p.AR4SIProfile)
+		}
+	}
+	if p.ImagePolicies != nil {
+		return initImagePolicies(p.ImagePolicies)
+	}
+	return nil
+}
+
+// resolve returns the policy's settings, falling back to the given ones for
+// settings it omits
+func (p Policy) resolve(profile string, imagePolicies []ImagePolicy) (string, []ImagePolicy) {
+	if p.AR4SIProfile != nil {
+		profile = *p.AR4SIProfile
+	}
+	if p.ImagePolicies != nil {
+		imagePolicies = p.ImagePolicies
+	}
+	return profile, imagePolicies
+}
+
+// comparePolicies diffs the statuses of the same workloads under two
+// policies. keys, current and candidate are index-aligned.
+func comparePolicies(keys []string, current, candidate []*WorkloadStatus) policyEvaluation {
+	result := policyEvaluation{Evaluated: len(keys), Workloads: make([]policyOutcome, len(keys))}
+	for i, key := range keys {
+		outcome := policyOutcome{
+			Key:       key,
+			Cluster:   current[i].Cluster,
+			Current:   verdictOf(current[i]),
+			Candidate: verdictOf(candidate[i]),
+		}
+		outcome.Changed = outcome.Current.AttestationStatus != outcome.Candidate.AttestationStatus ||
+			outcome.Current.GateOneStatus != outcome.Candidate.GateOneStatus ||
+			outcome.Current.GateTwoStatus != outcome.Candidate.GateTwoStatus ||
+			outcome.Current.Violation != outcome.Candidate.Violation
+		if outcome.Changed {
+			result.Changed++
+		}
+		switch {
+		case !outcome.Current.Violation && outcome.Candidate.Violation:
+			result.NewlyViolating++
+		case outcome.Current.Violation && !outcome.Candidate.Violation:
+			result.NewlyCompliant++
+		}
+		result.Workloads[i] = outcome
+	}
+	return result
+}
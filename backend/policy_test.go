@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newPolicyTestServer(t *testing.T) *Server {
+	t.Helper()
+	server := &Server{statusCache: make(map[string]*WorkloadStatus)}
+
+	reports := []CollectorReport{
+		{PodName: "full", Namespace: "icu", Attested: true, TrustVector: &TrustVector{InstanceIdentity: 2, Configuration: 2, Executables: 2, Hardware: 2},
+			raw: json.RawMessage(`{"trust_vector":{"instance_identity":2,"configuration":2,"executables":2,"hardware":2}}`)},
+		{PodName: "hw-only", Namespace: "icu", Attested: true, TrustVector: &TrustVector{Hardware: 2},
+			raw: json.RawMessage(`{"trust_vector":{"hardware":2}}`)},
+		{PodName: "imaged", Namespace: "lab", Attested: true, imageDigests: []string{"sha256:bbb"}},
+	}
+	var statuses []*WorkloadStatus
+	for _, report := range reports {
+		statuses = append(statuses, server.convertCollectorReport(report))
+	}
+	server.applyStatuses(statuses, map[string]bool{"": true}, nil)
+	server.retainReports(reports)
+	return server
+}
+
+// TestPolicyEvaluate tests previewing a stricter policy against cached workloads
+func TestPolicyEvaluate(t *testing.T) {
+	server := newPolicyTestServer(t)
+
+	body := `{"ar4si_profile":"coco","image_policies":[{"namespace":"lab","digests":["sha256:aaa"]}]}`
+	w := httptest.NewRecorder()
+	server.handlePolicyEvaluate(w, httptest.NewRequest("POST", "/api/policy/evaluate", strings.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var result policyEvaluation
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode evaluation: %v", err)
+	}
+	// Malformed evidence makes icu/hw-only unknown, which is no violation
+	if result.Evaluated != 3 || result.Changed != 2 || result.NewlyViolating != 1 || result.NewlyCompliant != 0 {
+		t.Errorf("Unexpected summary %+v", result)
+	}
+
+	outcomes := make(map[string]policyOutcome)
+	for _, outcome := range result.Workloads {
+		outcomes[outcome.Key] = outcome
+	}
+	if outcome := outcomes["icu/full"]; outcome.Changed || outcome.Candidate.Violation {
+		t.Errorf("Expected icu/full to be unaffected, got %+v", outcome)
+	}
+	if outcome := outcomes["icu/hw-only"]; outcome.Candidate.AttestationStatus != unknownStatus || outcome.Candidate.Violation {
+		t.Errorf("Expected icu/hw-only to become malformed evidence, got %+v", outcome)
+	}
+	if outcome := outcomes["lab/imaged"]; outcome.Candidate.GateOneStatus != "failed" || outcome.Current.GateOneStatus != "passing" {
+		t.Errorf("Expected lab/imaged to fail the image allowlist, got %+v", outcome)
+	}
+
+	// The live configuration is untouched
+	if server.ar4siProfile != "" || len(server.imagePolicies) != 0 {
+		t.Error("Expected dry run not to change the live policy")
+	}
+	if status := server.statusCache["icu/hw-only"]; status.AttestationStatus != "verified" {
+		t.Errorf("Expected cached status unchanged, got %s", status.AttestationStatus)
+	}
+}
+
+// TestPolicyEvaluateInvalid tests rejection of malformed candidate policies
+func TestPolicyEvaluateInvalid(t *testing.T) {
+	server := newPolicyTestServer(t)
+
+	for _, body := range []string{
+		`{"ar4si_profile":"nope"}`,
+		`{"image_policies":[{"namespace":"lab"}]}`,
+		`{"thresholds":{"hardware":2}}`,
+	} {
+		w := httptest.NewRecorder()
+		server.handlePolicyEvaluate(w, httptest.NewRequest("POST", "/api/policy/evaluate", strings.NewReader(body)))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d", body, w.Code)
+		}
+	}
+}
@@ -0,0 +1,388 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	policyVersionsBucket = "policy_versions"
+	policyStateDoc       = "policy_state"
+)
+
+// PolicyVersion is a stored, numbered policy. Version 0 is the startup
+// configuration (AR4SI_PROFILE and IMAGE_POLICY_CONFIG) and is not persisted.
+type PolicyVersion struct {
+	Version   int       `json:"version"`
+	Policy    Policy    `json:"policy"`
+	Comment   string    `json:"comment,omitempty"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// policyVersionRequest is the body of POST /api/policies
+type policyVersionRequest struct {
+	Policy  Policy `json:"policy"`
+	Comment string `json:"comment,omitempty"`
+}
+
+// policyState records which version is authoritative and which, if any, is
+// evaluated in shadow mode alongside it
+type policyState struct {
+	Active int  `json:"active"`
+	Shadow *int `json:"shadow,omitempty"`
+}
+
+// shadowReport is the latest comparison of the shadow policy with the
+// authoritative one. Only workloads whose verdict would change are listed.
+type shadowReport struct {
+	Version     int       `json:"version"`
+	EvaluatedAt time.Time `json:"evaluated_at"`
+	policyEvaluation
+}
+
+// PolicyStore keeps policy versions and the rollout state, persisted to the
+// store. A nil *PolicyStore means the startup configuration always applies.
+type PolicyStore struct {
+	mu       sync.Mutex
+	versions []PolicyVersion
+	state    policyState
+	store    *Store
+	report   *shadowReport
+}
+
+// newPolicyStore loads persisted policy versions on top of the startup
+// configuration, which becomes version 0
+func newPolicyStore(store *Store, base Policy) (*PolicyStore, error) {
+	p := &PolicyStore{
+		versions: []PolicyVersion{{Version: 0, Policy: base, Comment: "startup configuration", CreatedAt: time.Now()}},
+		store:    store,
+	}
+
+	err := store.Load(policyVersionsBucket, func(raw json.RawMessage) error {
+		var version PolicyVersion
+		if err := json.Unmarshal(raw, &version); err != nil {
+			return err
+		}
+		if err := version.Policy.init(); err != nil {
+			return fmt.Errorf("policy version %d: %w", version.Version, err)
+		}
+		p.versions = append(p.versions, version)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load policy versions: %w", err)
+	}
+
+	if _, err := store.LoadDoc(policyStateDoc, &p.state); err != nil {
+		return nil, fmt.Errorf("failed to load policy state: %w", err)
+	}
+	if p.find(p.state.Active) == nil {
+		return nil, fmt.Errorf("active policy version %d does not exist", p.state.Active)
+	}
+	if p.state.Shadow != nil && p.find(*p.state.Shadow) == nil {
+		return nil, fmt.Errorf("shadow policy version %d does not exist", *p.state.Shadow)
+	}
+	return p, nil
+}
+
+// find returns a version by number. Caller must hold mu.
+func (p *PolicyStore) find(version int) *PolicyVersion {
+	for i := range p.versions {
+		if p.versions[i].Version == version {
+			return &p.versions[i]
+		}
+	}
+	return nil
+}
+
+// settings resolves a version against the startup configuration. Caller must hold mu.
+func (p *PolicyStore) settings(version *PolicyVersion) (string, []ImagePolicy) {
+	base := p.versions[0].Policy
+	profile, imagePolicies := base.resolve("", nil)
+	return version.Policy.resolve(profile, imagePolicies)
+}
+
+// Has reports whether a policy version exists
+func (p *PolicyStore) Has(version int) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.find(version) != nil
+}
+
+// Create stores a new policy version. It takes effect only once activated.
+func (p *PolicyStore) Create(policy Policy, comment, by string) (PolicyVersion, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	version := PolicyVersion{
+		Version:   p.versions[len(p.versions)-1].Version + 1,
+		Policy:    policy,
+		Comment:   comment,
+		CreatedBy: by,
+		CreatedAt: time.Now(),
+	}
+	if err := p.store.Append(policyVersionsBucket, version); err != nil {
+		return PolicyVersion{}, err
+	}
+	p.versions = append(p.versions, version)
+	return version, nil
+}
+
+// Activate makes a version authoritative, ending its shadow evaluation
+func (p *PolicyStore) Activate(version int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.find(version) == nil {
+		return fmt.Errorf("policy version %d does not exist", version)
+	}
+	p.state.Active = version
+	if p.state.Shadow != nil && *p.state.Shadow == version {
+		p.state.Shadow = nil
+		p.report = nil
+	}
+	return p.store.SaveDoc(policyStateDoc, p.state)
+}
+
+// SetShadow evaluates a version alongside the active one, or stops shadow
+// evaluation when version is nil
+func (p *PolicyStore) SetShadow(version *int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if version != nil && p.find(*version) == nil {
+		return fmt.Errorf("policy version %d does not exist", *version)
+	}
+	p.state.Shadow = version
+	p.report = nil
+	return p.store.SaveDoc(policyStateDoc, p.state)
+}
+
+// active returns the settings of the authoritative version
+func (p *PolicyStore) active() (string, []ImagePolicy) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.settings(p.find(p.state.Active))
+}
+
+// shadow returns the shadow version number and its settings, if any
+func (p *PolicyStore) shadow() (int, string, []ImagePolicy, bool) {
+	if p == nil {
+		return 0, "", nil, false
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.state.Shadow == nil {
+		return 0, "", nil, false
+	}
+	profile, imagePolicies := p.settings(p.find(*p.state.Shadow))
+	return *p.state.Shadow, profile, imagePolicies, true
+}
+
+// recordShadow stores a shadow comparison, unless the shadow version
+// changed while it was being evaluated
+func (p *PolicyStore) recordShadow(report *shadowReport) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.state.Shadow != nil && *p.state.Shadow == report.Version {
+		p.report = report
+	}
+}
+
+// applyActivePolicy switches the evaluation pipeline to the authoritative
+// policy version. Called by the poll loop at the start of each cycle.
+func (s *Server) applyActivePolicy() {
+	if s.policies == nil {
+		return
+	}
+
+	profile, imagePolicies := s.policies.active()
+	s.cacheMutex.Lock()
+	s.ar4siProfile, s.imagePolicies = profile, imagePolicies
+	s.cacheMutex.Unlock()
+}
+
+// evaluateShadowPolicy computes the shadow version's verdicts for this
+// cycle's workloads and diffs them with the authoritative ones. reports and
+// statuses are index-aligned.
+func (s *Server) evaluateShadowPolicy(reports []CollectorReport, statuses []*WorkloadStatus) {
+	version, profile, imagePolicies, ok := s.policies.shadow()
+	if !ok {
+		return
+	}
+
+	keys := make([]string, len(statuses))
+	for i, status := range statuses {
+		keys[i] = status.Namespace + "/" + status.Name
+	}
+	evaluation := comparePolicies(keys, statuses, s.evaluatePolicy(profile, imagePolicies, reports, statuses))
+
+	changed := make([]policyOutcome, 0, evaluation.Changed)
+	for _, outcome := range evaluation.Workloads {
+		if outcome.Changed {
+			changed = append(changed, outcome)
+		}
+	}
+	sort.Slice(changed, func(i, j int) bool { return changed[i].Key < changed[j].Key })
+	evaluation.Workloads = changed
+
+	s.policies.recordShadow(&shadowReport{Version: version, EvaluatedAt: time.Now(), policyEvaluation: evaluation})
+	s.metrics.Inc("dashboard_policy_shadow_evaluations_total", "Poll cycles evaluated under the shadow policy.", "version", strconv.Itoa(version))
+}
+
+// writePolicyMetrics writes the active and shadow policy versions and the
+// latest shadow diff
+func (s *Server) writePolicyMetrics(w io.Writer) {
+	if s.policies == nil {
+		return
+	}
+
+	s.policies.mu.Lock()
+	active := s.policies.state.Active
+	report := s.policies.report
+	s.policies.mu.Unlock()
+
+	writeMetric(w, "dashboard_policy_active_version", "gauge", "Authoritative policy version.", []metricSample{sample(float64(active))})
+	if report != nil {
+		version := strconv.Itoa(report.Version)
+		writeMetric(w, "dashboard_policy_shadow_diff", "gauge", "Workloads whose verdict differs under the shadow policy, as of the last poll.", []metricSample{
+			sample(float64(report.Changed), "version", version, "kind", "changed"),
+			sample(float64(report.NewlyViolating), "version", version, "kind", "newly_violating"),
+			sample(float64(report.NewlyCompliant), "version", version, "kind", "newly_compliant"),
+		})
+	}
+}
+
+// handlePolicies lists policy versions and the rollout state (GET) or
+// stores a new version (POST)
+// GET|POST /api/policies
+func (s *Server) handlePolicies(w http.ResponseWriter, r *http.Request) {
+	if s.policies == nil {
+		http.Error(w, "policy versioning is not enabled", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		p := s.policies
+		p.mu.Lock()
+		response := struct {
+			policyState
+			Versions     []PolicyVersion `json:"versions"`
+			ShadowReport *shadowReport   `json:"shadow_report,omitempty"`
+		}{
+			policyState:  p.state,
+			Versions:     append([]PolicyVersion(nil), p.versions...),
+			ShadowReport: p.report,
+		}
+		p.mu.Unlock()
+		if response.ShadowReport != nil {
+			noteWorkloadKeys(r, response.ShadowReport.keys()...)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+
+	case http.MethodPost:
+		identity, ok := requireAdmin(w, r)
+		if !ok {
+			return
+		}
+		var req policyVersionRequest
+		if !decodeValid(w, r, policyVersionSchema, &req) {
+			return
+		}
+		if err := req.Policy.init(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		version, err := s.policies.Create(req.Policy, req.Comment, identity.Name)
+		if err != nil {
+			log.Printf("Failed to store policy version: %v", err)
+			http.Error(w, "failed to store policy version", http.StatusInternalServerError)
+			return
+		}
+		s.audit.RecordRequest(r, identity.Name, "policy.create", fmt.Sprintf("policy/%d", version.Version), req.Comment)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(version)
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handlePolicyAction changes the rollout state. Activation takes effect on
+// the next poll cycle.
+// POST /api/policies/{version}/shadow
+// POST /api/policies/{version}/activate
+// DELETE /api/policies/shadow
+func (s *Server) handlePolicyAction(w http.ResponseWriter, r *http.Request) {
+	if s.policies == nil {
+		http.Error(w, "policy versioning is not enabled", http.StatusNotFound)
+		return
+	}
+	identity, ok := requireAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/api/policies/")
+	if path == "shadow" {
+		if r.Method != http.MethodDelete {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if err := s.policies.SetShadow(nil); err != nil {
+			log.Printf("Failed to persist policy state: %v", err)
+			http.Error(w, "failed to update policy state", http.StatusInternalServerError)
+			return
+		}
+		s.audit.RecordRequest(r, identity.Name, "policy.shadow.stop", "policy", "")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	rawVersion, action, _ := strings.Cut(path, "/")
+	version, err := strconv.Atoi(rawVersion)
+	if err != nil || (action != "shadow" && action != "activate") {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !s.policies.Has(version) {
+		http.Error(w, "policy version not found", http.StatusNotFound)
+		return
+	}
+
+	if action == "shadow" {
+		err = s.policies.SetShadow(&version)
+	} else {
+		err = s.policies.Activate(version)
+	}
+	if err != nil {
+		log.Printf("Failed to persist policy state: %v", err)
+		http.Error(w, "failed to update policy state", http.StatusInternalServerError)
+		return
+	}
+	s.audit.RecordRequest(r, identity.Name, "policy."+action, fmt.Sprintf("policy/%d", version), "")
+	w.WriteHeader(http.StatusNoContent)
+}
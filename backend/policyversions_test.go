@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestPolicyStorePersistence tests that versions and rollout state survive a reload
+func TestPolicyStorePersistence(t *testing.T) {
+	store, _ := openStore(t.TempDir())
+	startup := ""
+	policies, err := newPolicyStore(store, Policy{AR4SIProfile: &startup})
+	if err != nil {
+		t.Fatalf("Failed to create policy store: %v", err)
+	}
+
+	coco := "coco"
+	v1, err := policies.Create(Policy{AR4SIProfile: &coco}, "require CoCo claims", "sre")
+	if err != nil || v1.Version != 1 {
+		t.Fatalf("Expected version 1, got %+v (%v)", v1, err)
+	}
+	v2, _ := policies.Create(Policy{ImagePolicies: []ImagePolicy{{Namespace: "icu", Digests: []string{"sha256:aaa"}}}}, "", "sre")
+	policies.Activate(v1.Version)
+	policies.SetShadow(&v2.Version)
+
+	reloaded, err := newPolicyStore(store, Policy{AR4SIProfile: &startup})
+	if err != nil {
+		t.Fatalf("Failed to reload policy store: %v", err)
+	}
+	if profile, _ := reloaded.active(); profile != "coco" {
+		t.Errorf("Expected active profile coco, got %q", profile)
+	}
+	version, profile, images, ok := reloaded.shadow()
+	if !ok || version != 2 || profile != "" || len(images) != 1 || images[0].client == nil {
+		t.Errorf("Expected initialized shadow version 2 on the startup profile, got %d %q %+v", version, profile, images)
+	}
+
+	// Activating the shadow version ends shadow evaluation
+	reloaded.Activate(2)
+	if _, _, _, ok := reloaded.shadow(); ok {
+		t.Error("Expected shadow to end once activated")
+	}
+}
+
+// TestShadowPolicy tests that shadow verdicts are diffed without affecting
+// the authoritative statuses
+func TestShadowPolicy(t *testing.T) {
+	startup := ""
+	policies, _ := newPolicyStore(nil, Policy{AR4SIProfile: &startup})
+	coco := "coco"
+	v1, _ := policies.Create(Policy{AR4SIProfile: &coco}, "", "sre")
+	policies.SetShadow(&v1.Version)
+
+	server := &Server{policies: policies, metrics: newMetrics()}
+	reports := []CollectorReport{{PodName: "hw-only", Namespace: "icu", Attested: true, TrustVector: &TrustVector{Hardware: 2},
+		raw: json.RawMessage(`{"trust_vector":{"hardware":2}}`)}}
+	statuses := []*WorkloadStatus{server.convertCollectorReport(reports[0])}
+
+	server.evaluateShadowPolicy(reports, statuses)
+
+	if statuses[0].AttestationStatus != "verified" {
+		t.Errorf("Expected authoritative status to stay verified, got %s", statuses[0].AttestationStatus)
+	}
+	report := policies.report
+	if report == nil || report.Version != 1 || report.Changed != 1 || report.NewlyViolating != 0 || len(report.Workloads) != 1 {
+		t.Fatalf("Unexpected shadow report %+v", report)
+	}
+	if got := report.Workloads[0].Candidate.AttestationStatus; got != unknownStatus {
+		t.Errorf("Expected shadow verdict %s, got %s", unknownStatus, got)
+	}
+
+	w := httptest.NewRecorder()
+	server.handleMetrics(w, httptest.NewRequest("GET", "/metrics", nil))
+	if !strings.Contains(w.Body.String(), `dashboard_policy_shadow_diff{version="1",kind="changed"} 1`) {
+		t.Errorf("Expected shadow diff metric, got:\n%s", w.Body.String())
+	}
+}
+
+// TestPolicyRollout tests creating, shadowing and activating versions over the API
+func TestPolicyRollout(t *testing.T) {
+	startup := ""
+	policies, _ := newPolicyStore(nil, Policy{AR4SIProfile: &startup})
+	audit, _ := newAuditLog(nil)
+	server := &Server{policies: policies, audit: audit}
+	admin := &Identity{Name: "sre", Roles: []string{adminRole}}
+
+	w := httptest.NewRecorder()
+	server.handlePolicies(w, ackRequestAs(&Identity{Name: "raj"}, "POST", "/api/policies", `{"policy":{"ar4si_profile":"coco"}}`))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for non-admin, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	server.handlePolicies(w, ackRequestAs(admin, "POST", "/api/policies", `{"policy":{"ar4si_profile":"nope"}}`))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for unknown profile, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	server.handlePolicies(w, ackRequestAs(admin, "POST", "/api/policies", `{"policy":{"ar4si_profile":"coco"},"comment":"stricter"}`))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	for _, step := range []struct {
+		method, path string
+		expected     int
+	}{
+		{"POST", "/api/policies/1/shadow", http.StatusNoContent},
+		{"POST", "/api/policies/9/activate", http.StatusNotFound},
+		{"POST", "/api/policies/1/activate", http.StatusNoContent},
+		{"DELETE", "/api/policies/shadow", http.StatusNoContent},
+	} {
+		w = httptest.NewRecorder()
+		server.handlePolicyAction(w, ackRequestAs(admin, step.method, step.path, ""))
+		if w.Code != step.expected {
+			t.Errorf("%s %s: expected %d, got %d", step.method, step.path, step.expected, w.Code)
+		}
+	}
+
+	server.applyActivePolicy()
+	if server.ar4siProfile != "coco" {
+		t.Errorf("Expected active policy to apply on the next cycle, got %q", server.ar4siProfile)
+	}
+
+	w = httptest.NewRecorder()
+	server.handlePolicies(w, ackRequestAs(admin, "GET", "/api/policies", ""))
+	var overview struct {
+		Active   int             `json:"active"`
+		Versions []PolicyVersion `json:"versions"`
+	}
+	json.NewDecoder(w.Body).Decode(&overview)
+	if overview.Active != 1 || len(overview.Versions) != 2 || overview.Versions[1].CreatedBy != "sre" {
+		t.Errorf("Unexpected policy overview %+v", overview)
+	}
+
+	if entries := audit.Entries(time.Time{}); len(entries) != 4 {
+		t.Errorf("Expected 4 audited policy actions, got %+v", entries)
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Permissions granted by an RBAC policy. A binding may also grant "*" for
+// everything, or "admin:*" for every permission with that prefix.
+const (
+	permReadWorkloads    = "read:workloads"    // status, workloads, reports, search
+	permReadSensitive    = "read:sensitive"    // measurements, tokens and error internals otherwise redacted
+	permWriteAck         = "write:ack"         // acknowledge violations
+	permWriteAnnotations = "write:annotations" // notes, labels, expected workloads, the watchlist and suppressions
+	permWriteDowntime    = "write:downtime"    // record planned downtime excluded from MTTR and uptime
+	permExportEvidence   = "export:evidence"   // audit log, access log, raw reports and state snapshots
+	permAdminRefresh     = "admin:refresh"     // trigger an immediate Collector poll
+	permAdminPolicies    = "admin:policies"    // create, shadow and activate policy versions
+	permAdminBackup      = "admin:backup"      // backup, restore and history import
+	permAdminDiagnostics = "admin:diagnostics" // selftest and runtime internals
+	permAdminCollectors  = "admin:collectors"  // register and remove Collectors at runtime
+	permShareWorkloads   = "share:workloads"   // create and revoke read-only share links to workloads
+)
+
+// knownPermissions are the permissions a binding may name
+var knownPermissions = map[string]bool{
+	permReadWorkloads: true, permReadSensitive: true, permWriteAck: true, permWriteAnnotations: true, permWriteDowntime: true, permExportEvidence: true,
+	permAdminRefresh: true, permAdminPolicies: true, permAdminBackup: true, permAdminDiagnostics: true, permAdminCollectors: true,
+	permShareWorkloads: true,
+}
+
+// publicAPIPaths need no permission: they describe the deployment or the
+// caller, not the workloads
+var publicAPIPaths = map[string]bool{
+	"/api/version":      true,
+	"/api/signing-keys": true,
+	"/api/schema":       true,
+	"/api/session":      true,
+	"/api/features":     true,
+}
+
+// RBACBinding grants permissions to identities by name, or to every member
+// of a group. Groups are matched against the identity's roles, so they cover
+// token roles as well as LDAP group_roles and SAML role attributes.
+type RBACBinding struct {
+	Identities  []string `json:"identities,omitempty"`
+	Groups      []string `json:"groups,omitempty"`
+	Permissions []string `json:"permissions"`
+}
+
+// rbacPolicy is the RBAC_CONFIG policy. With a policy, every /api/ request
+// needs the permission of its route instead of just a valid credential.
+type rbacPolicy struct {
+	bindings []RBACBinding
+}
+
+// loadRBACPolicy reads the binding list from a JSON file
+func loadRBACPolicy(path string) (*rbacPolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var config struct {
+		Bindings []RBACBinding `json:"bindings"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("invalid RBAC config: %w", err)
+	}
+	for i, b := range config.Bindings {
+		if len(b.Identities) == 0 && len(b.Groups) == 0 {
+			return nil, fmt.Errorf("binding %d: identities or groups are required", i)
+		}
+		if len(b.Permissions) == 0 {
+			return nil, fmt.Errorf("binding %d: permissions are required", i)
+		}
+		for _, perm := range b.Permissions {
+			if !validPermission(perm) {
+				return nil, fmt.Errorf("binding %d: unknown permission %q", i, perm)
+			}
+		}
+	}
+	return &rbacPolicy{bindings: config.Bindings}, nil
+}
+
+// validPermission reports whether perm is a known permission or a wildcard
+// matching at least one
+func validPermission(perm string) bool {
+	if knownPermissions[perm] || perm == "*" {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(perm, "*")
+	if !ok {
+		return false
+	}
+	for known := range knownPermissions {
+		if strings.HasPrefix(known, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// permissionsFor returns the permissions bound to an identity. The result is
+// never nil, so an identity without any binding is still marked as checked.
+func (p *rbacPolicy) permissionsFor(identity *Identity) []string {
+	granted := []string{}
+	for _, b := range p.bindings {
+		if !containsString(b.Identities, identity.Name) && !anyRole(identity, b.Groups) {
+			continue
+		}
+		for _, perm := range b.Permissions {
+			if !containsString(granted, perm) {
+				granted = append(granted, perm)
+			}
+		}
+	}
+	return granted
+}
+
+// anyRole reports whether the identity has one of roles
+func anyRole(identity *Identity, roles []string) bool {
+	for _, role := range roles {
+		if identity.hasRole(role) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasPermission reports whether the identity was granted perm by the RBAC
+// policy, directly or through a wildcard
+func (i *Identity) hasPermission(perm string) bool {
+	if i == nil {
+		return false
+	}
+	for _, granted := range i.permissions {
+		if granted == "*" || granted == perm {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(granted, "*"); ok && strings.HasPrefix(perm, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// requiredPermission returns the permission a request needs, or "" if the
+// route is public. Routes not listed here only read workload state.
+func requiredPermission(r *http.Request) string {
+	path := r.URL.Path
+	read := r.Method == http.MethodGet || r.Method == http.MethodHead
+
+	switch {
+	case publicAPIPaths[path] || strings.HasPrefix(path, "/api/schemas/"):
+		return ""
+	case path == "/api/admin/refresh":
+		return permAdminRefresh
+	case path == "/api/admin/backup" || path == "/api/admin/restore" || path == "/api/admin/import":
+		return permAdminBackup
+	case path == "/api/admin/collectors" || strings.HasPrefix(path, "/api/admin/collectors/"):
+		return permAdminCollectors
+	case strings.HasPrefix(path, "/api/admin/"):
+		return permAdminDiagnostics
+	case strings.HasPrefix(path, "/api/audit") || strings.HasPrefix(path, "/api/reports/raw/") || path == "/api/export/snapshot":
+		return permExportEvidence
+	case strings.HasPrefix(path, "/api/policies") && !read:
+		return permAdminPolicies
+	case strings.HasPrefix(path, "/api/workload/") && strings.HasSuffix(path, "/ack") && !read,
+		strings.HasPrefix(path, "/api/workloads/") && !read:
+		return permWriteAck
+	case strings.HasPrefix(path, "/api/workload/") && strings.HasSuffix(path, "/annotations") && !read,
+		strings.HasPrefix(path, "/api/workload/") && strings.HasSuffix(path, "/suppress") && !read,
+		strings.HasPrefix(path, "/api/expected-workloads") && !read,
+		strings.HasPrefix(path, "/api/watchlist") && !read:
+		return permWriteAnnotations
+	case strings.HasPrefix(path, "/api/downtime") && !read:
+		return permWriteDowntime
+	case strings.HasPrefix(path, "/api/workload/") && (strings.HasSuffix(path, "/share") || strings.Contains(path, "/share/")) && !read:
+		return permShareWorkloads
+	}
+	return permReadWorkloads
+}
+
+// rbacMiddleware enforces the RBAC policy on authenticated requests and
+// records the caller's permissions on their identity. Requests without an
+// identity were either let through by authMiddleware on purpose (streams,
+// SAML, ingest, share links) or authentication is disabled.
+func (s *Server) rbacMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity := identityFromContext(r.Context())
+		if s.rbac == nil || identity == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		scoped := *identity
+		scoped.permissions = s.rbac.permissionsFor(identity)
+		if perm := requiredPermission(r); perm != "" && !scoped.hasPermission(perm) {
+			log.Printf("Denied %s %s to %s: missing permission %s", r.Method, r.URL.Path, identity.Name, perm)
+			http.Error(w, "permission "+perm+" required", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), identityContextKey{}, &scoped)))
+	})
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const rbacTestConfig = `{"bindings": [
+	{"groups": ["icu-oncall"], "permissions": ["read:workloads", "write:ack"]},
+	{"identities": ["auditor"], "permissions": ["read:workloads", "export:evidence"]},
+	{"groups": ["platform"], "permissions": ["admin:*"]}
+]}`
+
+func newRBACTestServer(t *testing.T) *Server {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "rbac.json")
+	os.WriteFile(path, []byte(rbacTestConfig), 0o600)
+	rbac, err := loadRBACPolicy(path)
+	if err != nil {
+		t.Fatalf("Failed to load RBAC config: %v", err)
+	}
+	return &Server{rbac: rbac}
+}
+
+// TestRBACMiddleware tests enforcing per-route permissions
+func TestRBACMiddleware(t *testing.T) {
+	server := newRBACTestServer(t)
+	var seen *Identity
+	handler := server.rbacMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = identityFromContext(r.Context())
+	}))
+
+	nurse := &Identity{Name: "raj", Roles: []string{"icu-oncall"}}
+	auditor := &Identity{Name: "auditor"}
+	platform := &Identity{Name: "ops", Roles: []string{"platform"}}
+	stranger := &Identity{Name: "stranger"}
+
+	tests := []struct {
+		identity *Identity
+		method   string
+		path     string
+		want     int
+	}{
+		{nurse, "GET", "/api/status", http.StatusOK},
+		{nurse, "POST", "/api/workload/icu/pacs/ack", http.StatusOK},
+		{nurse, "PUT", "/api/workload/icu/pacs/annotations", http.StatusForbidden},
+		{nurse, "GET", "/api/audit", http.StatusForbidden},
+		{auditor, "GET", "/api/audit/access", http.StatusOK},
+		{auditor, "GET", "/api/reports/raw/icu/pacs", http.StatusOK},
+		{auditor, "DELETE", "/api/workload/icu/pacs/ack", http.StatusForbidden},
+		{platform, "POST", "/api/admin/refresh", http.StatusOK},
+		{platform, "POST", "/api/policies", http.StatusOK},
+		{platform, "GET", "/api/workloads", http.StatusForbidden},
+		{stranger, "GET", "/api/workloads", http.StatusForbidden},
+		{stranger, "GET", "/api/version", http.StatusOK},
+		{nil, "GET", "/api/events", http.StatusOK},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, ackRequestAs(tt.identity, tt.method, tt.path, ""))
+		if w.Code != tt.want {
+			t.Errorf("Expected %d for %v %s %s, got %d", tt.want, tt.identity, tt.method, tt.path, w.Code)
+		}
+	}
+
+	// Handlers see the granted permissions, and requireAdmin defers to them
+	handler.ServeHTTP(httptest.NewRecorder(), ackRequestAs(platform, "POST", "/api/admin/refresh", ""))
+	if !seen.hasPermission(permAdminRefresh) || seen.hasPermission(permReadWorkloads) {
+		t.Errorf("Expected admin permissions only, got %v", seen.permissions)
+	}
+	w := httptest.NewRecorder()
+	server.handleRefresh(w, ackRequestAs(seen, "POST", "/api/admin/refresh", ""))
+	if w.Code != http.StatusAccepted {
+		t.Errorf("Expected 202 from refresh, got %d", w.Code)
+	}
+}
+
+// TestRequireAdminWithoutRBAC tests that the admin role still applies
+// without an RBAC policy
+func TestRequireAdminWithoutRBAC(t *testing.T) {
+	server := &Server{refresh: make(chan struct{}, 1)}
+	w := httptest.NewRecorder()
+	server.handleRefresh(w, ackRequestAs(&Identity{Name: "raj"}, "POST", "/api/admin/refresh", ""))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 without the admin role, got %d", w.Code)
+	}
+
+	admin := &Identity{Name: "ops", Roles: []string{adminRole}}
+	for i := 0; i < 2; i++ {
+		w = httptest.NewRecorder()
+		server.handleRefresh(w, ackRequestAs(admin, "POST", "/api/admin/refresh", ""))
+		if w.Code != http.StatusAccepted {
+			t.Errorf("Expected 202, got %d", w.Code)
+		}
+	}
+	if len(server.refresh) != 1 {
+		t.Errorf("Expected one pending refresh, got %d", len(server.refresh))
+	}
+}
+
+// TestLoadRBACPolicy tests that invalid bindings are rejected
+func TestLoadRBACPolicy(t *testing.T) {
+	for _, config := range []string{
+		`{"bindings": [{"permissions": ["read:workloads"]}]}`,
+		`{"bindings": [{"groups": ["a"]}]}`,
+		`{"bindings": [{"groups": ["a"], "permissions": ["write:everything"]}]}`,
+		`{"bindings": [{"groups": ["a"], "permissions": ["delete:*"]}]}`,
+	} {
+		path := filepath.Join(t.TempDir(), "rbac.json")
+		os.WriteFile(path, []byte(config), 0o600)
+		if _, err := loadRBACPolicy(path); err == nil {
+			t.Errorf("Expected %s to be rejected", config)
+		}
+	}
+}
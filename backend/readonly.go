@@ -0,0 +1,43 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+)
+
+// readOnlySafePaths accept POST in read-only mode because they don't change
+// dashboard state
+var readOnlySafePaths = map[string]bool{
+	"/api/stream-token":     true,
+	"/api/policy/evaluate":  true, // dry run only
+	"/api/simulate/report":  true, // dry run only
+	"/api/auth/saml/acs":    true, // starts a session, which is not dashboard state
+	"/api/auth/saml/logout": true,
+	"/api/session":          true, // login and logout
+}
+
+// readOnlyMiddleware rejects requests that would change dashboard state when
+// the backend runs as a read-only replica (READ_ONLY=true)
+func (s *Server) readOnlyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.readOnly && isMutatingRequest(r) {
+			http.Error(w, "dashboard is read-only", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isMutatingRequest reports whether a request changes state or needs
+// administrative access: acknowledgements, admin endpoints and any
+// non-read method outside readOnlySafePaths
+func isMutatingRequest(r *http.Request) bool {
+	if strings.HasPrefix(r.URL.Path, "/api/admin/") {
+		return true
+	}
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return !readOnlySafePaths[r.URL.Path]
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestReadOnlyMiddleware tests that mutating and admin requests are rejected
+// on a read-only replica while reads pass through
+func TestReadOnlyMiddleware(t *testing.T) {
+	server := &Server{readOnly: true}
+	handler := server.readOnlyMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		method, path string
+		expected     int
+	}{
+		{"GET", "/api/status", http.StatusOK},
+		{"GET", "/api/workload/icu/ai-model", http.StatusOK},
+		{"POST", "/api/stream-token", http.StatusOK},
+		{"POST", "/api/workload/icu/ai-model/ack", http.StatusForbidden},
+		{"DELETE", "/api/workload/icu/ai-model/ack", http.StatusForbidden},
+		{"POST", "/api/admin/restore", http.StatusForbidden},
+		{"GET", "/api/admin/backup", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		if w.Code != tt.expected {
+			t.Errorf("%s %s: expected %d, got %d", tt.method, tt.path, tt.expected, w.Code)
+		}
+	}
+
+	server.readOnly = false
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", "/api/workload/icu/ai-model/ack", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected writes to pass when not read-only, got %d", w.Code)
+	}
+}
+
+// TestReadOnlyHidesMutationSchemas tests that request schemas of disabled
+// endpoints are not published in read-only mode
+func TestReadOnlyHidesMutationSchemas(t *testing.T) {
+	server := &Server{readOnly: true}
+
+	w := httptest.NewRecorder()
+	server.handleJSONSchema(w, httptest.NewRequest("GET", "/api/schemas/", nil))
+	var names []string
+	json.NewDecoder(w.Body).Decode(&names)
+	if containsString(names, "AckRequest") || !containsString(names, "WorkloadStatus") {
+		t.Errorf("Expected AckRequest to be hidden, got %v", names)
+	}
+
+	w = httptest.NewRecorder()
+	server.handleJSONSchema(w, httptest.NewRequest("GET", "/api/schemas/AckRequest", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for hidden schema, got %d", w.Code)
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+)
+
+// connectionPatterns match connection details in error strings - URLs,
+// addresses and host:port pairs - which are always redacted in PHI-safe mode
+var connectionPatterns = []string{
+	`[a-zA-Z][a-zA-Z0-9+.-]*://[^\s"'<>]*[^\s"'<>.,:;)]`,
+	`\[[0-9a-fA-F:]+\](?::\d+)?`,
+	`\b(?:\d{1,3}\.){3}\d{1,3}(?::\d+)?\b`,
+	`\b[a-zA-Z][a-zA-Z0-9-]*(?:\.[a-zA-Z0-9-]+)*:\d{2,5}\b`,
+}
+
+// redactionConfig is the format of the REDACTION_CONFIG file
+type redactionConfig struct {
+	Mode     string   `json:"mode,omitempty"`     // "hash" (default) or "strip"
+	Patterns []string `json:"patterns,omitempty"` // e.g. pod naming schemes that embed patient identifiers
+	HashKey  string   `json:"hash_key,omitempty"` // keeps hashes stable across restarts and replicas
+}
+
+// redactor masks potentially identifying text in logs and notifications
+// when PHI_SAFE_LOGS is enabled. Hashing keeps redacted values correlatable
+// - the same pod name always becomes the same token - without revealing
+// them; the hash is keyed so short names can't be recovered by brute force.
+// A nil *redactor leaves text unchanged.
+type redactor struct {
+	patterns []*regexp.Regexp
+	strip    bool
+	key      []byte
+}
+
+// loadRedactor reads custom patterns from a JSON file, if path is set
+func loadRedactor(path string) (*redactor, error) {
+	var config redactionConfig
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf("invalid redaction config: %w", err)
+		}
+	}
+	return newRedactor(config)
+}
+
+// newRedactor compiles the connection patterns and any custom ones
+func newRedactor(config redactionConfig) (*redactor, error) {
+	r := &redactor{key: []byte(config.HashKey)}
+	switch config.Mode {
+	case "", "hash":
+	case "strip":
+		r.strip = true
+	default:
+		return nil, fmt.Errorf("unknown redaction mode %q", config.Mode)
+	}
+
+	// Custom patterns first, so a pod name is hashed whole before a
+	// connection pattern could match part of it
+	for _, pattern := range append(append([]string(nil), config.Patterns...), connectionPatterns...) {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", pattern, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+
+	if len(r.key) == 0 {
+		r.key = make([]byte, 32)
+		if _, err := rand.Read(r.key); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// text redacts every pattern match in s
+func (r *redactor) text(s string) string {
+	if r == nil || s == "" {
+		return s
+	}
+	for _, re := range r.patterns {
+		s = re.ReplaceAllStringFunc(s, r.token)
+	}
+	return s
+}
+
+// token is the replacement for one redacted value
+func (r *redactor) token(value string) string {
+	if r.strip {
+		return "[redacted]"
+	}
+	mac := hmac.New(sha256.New, r.key)
+	mac.Write([]byte(value))
+	return "[redacted:" + hex.EncodeToString(mac.Sum(nil))[:12] + "]"
+}
+
+// payload returns a copy of a webhook payload with its free text and
+// workload identifiers redacted
+func (r *redactor) payload(p WebhookPayload) WebhookPayload {
+	if r == nil {
+		return p
+	}
+
+	p.Key = r.text(p.Key)
+	p.Summary = r.text(p.Summary)
+	if p.Workload != nil {
+		workload := *p.Workload
+		workload.Name = r.text(workload.Name)
+		workload.Details = r.text(workload.Details)
+		workload.NodeName = r.text(workload.NodeName)
+		workload.Gates = append([]GateResult(nil), workload.Gates...)
+		for i := range workload.Gates {
+			workload.Gates[i].Details = r.text(workload.Gates[i].Details)
+		}
+		workload.FailedChecks = append([]Check(nil), workload.FailedChecks...)
+		for i := range workload.FailedChecks {
+			workload.FailedChecks[i].Expected = r.text(workload.FailedChecks[i].Expected)
+			workload.FailedChecks[i].Actual = r.text(workload.FailedChecks[i].Actual)
+		}
+		if workload.Acknowledgement != nil {
+			ack := *workload.Acknowledgement
+			ack.Key = r.text(ack.Key)
+			ack.Comment = r.text(ack.Comment)
+			workload.Acknowledgement = &ack
+		}
+		p.Workload = &workload
+	}
+	return p
+}
+
+// redactingWriter redacts log output before writing it
+type redactingWriter struct {
+	out    io.Writer
+	redact *redactor
+}
+
+func (w *redactingWriter) Write(p []byte) (int, error) {
+	if _, err := w.out.Write([]byte(w.redact.text(string(p)))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
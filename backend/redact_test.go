@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRedactorText tests that connection details and custom patterns are
+// replaced with stable tokens
+func TestRedactorText(t *testing.T) {
+	r, err := newRedactor(redactionConfig{Patterns: []string{`patient-[a-z0-9]+`}, HashKey: "k"})
+	if err != nil {
+		t.Fatalf("Failed to create redactor: %v", err)
+	}
+
+	tests := []struct {
+		input   string
+		removed []string
+		kept    []string
+	}{
+		{`Failed to fetch from Collector http://collector.icu.svc:8080: Get "http://collector.icu.svc:8080/api/v1/reports": dial tcp 10.0.3.7:8080: connect: connection refused`,
+			[]string{"collector.icu.svc", "10.0.3.7"}, []string{"Failed to fetch from Collector", "connection refused"}},
+		{"Workload icu/patient-4711 exceeds 4096 bytes after truncation", []string{"patient-4711"}, []string{"icu/", "4096 bytes"}},
+		{"dial tcp [fd00::1]:443: i/o timeout", []string{"fd00::1"}, []string{"i/o timeout"}},
+		{"lookup ldap:389 failed", []string{"ldap:389"}, nil},
+		{"Fetched 12 reports at 12:30:45", nil, []string{"12 reports", "12:30:45"}},
+	}
+	for _, tt := range tests {
+		got := r.text(tt.input)
+		for _, s := range tt.removed {
+			if strings.Contains(got, s) {
+				t.Errorf("Expected %q to be redacted from %q", s, got)
+			}
+		}
+		for _, s := range tt.kept {
+			if !strings.Contains(got, s) {
+				t.Errorf("Expected %q to be kept in %q", s, got)
+			}
+		}
+	}
+
+	if a, b := r.text("patient-4711"), r.text("see patient-4711"); !strings.HasSuffix(b, a) {
+		t.Errorf("Expected stable hash, got %q and %q", a, b)
+	}
+	if r.text("patient-4711") == r.text("patient-4712") {
+		t.Error("Expected different values to hash differently")
+	}
+
+	strip, _ := newRedactor(redactionConfig{Mode: "strip"})
+	if got := strip.text("dial tcp 10.0.3.7:8080"); got != "dial tcp [redacted]" {
+		t.Errorf("Expected stripped address, got %q", got)
+	}
+
+	var nilRedactor *redactor
+	if got := nilRedactor.text("10.0.3.7"); got != "10.0.3.7" {
+		t.Errorf("Expected nil redactor to leave text unchanged, got %q", got)
+	}
+	if _, err := newRedactor(redactionConfig{Patterns: []string{"("}}); err == nil {
+		t.Error("Expected invalid pattern to be rejected")
+	}
+}
+
+// TestRedactingWriter tests that log output passes through the redactor
+func TestRedactingWriter(t *testing.T) {
+	r, _ := newRedactor(redactionConfig{Mode: "strip"})
+	var out bytes.Buffer
+	logger := log.New(&redactingWriter{out: &out, redact: r}, "", 0)
+	logger.Printf("Failed to fetch from Collector %s: %v", "https://collector:8443", "EOF")
+	if got := out.String(); got != "Failed to fetch from Collector [redacted]: EOF\n" {
+		t.Errorf("Unexpected log output %q", got)
+	}
+}
+
+// TestNotifyRedactsPayload tests that queued notifications carry redacted
+// workload identifiers and details, without altering the history event
+func TestNotifyRedactsPayload(t *testing.T) {
+	n, err := newNotifier([]notifyTarget{{Name: "pager", URL: "http://pager.local"}}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create notifier: %v", err)
+	}
+	n.redact, _ = newRedactor(redactionConfig{Patterns: []string{`patient-[a-z0-9]+`}})
+
+	status := failedStatus("icu", "patient-4711")
+	status.Details = "gate cmdb: Get http://cmdb.hospital.org/api: timeout"
+	n.Notify([]HistoryEvent{{Time: time.Now(), Key: "icu/patient-4711", Type: "added", Status: status}})
+
+	if len(n.queue) != 1 {
+		t.Fatalf("Expected 1 queued notification, got %d", len(n.queue))
+	}
+	payload := n.queue[0].Payload
+	if strings.Contains(payload.Key, "patient-4711") || strings.Contains(payload.Workload.Name, "patient-4711") {
+		t.Errorf("Expected workload name redacted, got key %q name %q", payload.Key, payload.Workload.Name)
+	}
+	if strings.Contains(payload.Workload.Details, "cmdb.hospital.org") {
+		t.Errorf("Expected URL redacted from details, got %q", payload.Workload.Details)
+	}
+	if status.Name != "patient-4711" {
+		t.Errorf("Expected history status untouched, got %q", status.Name)
+	}
+}
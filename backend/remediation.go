@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"text/template"
+)
+
+// remediationHint is the guidance shown with one kind of failed check. Both
+// fields are text/templates over a remediationInput, e.g.
+// https://wiki.example.org/runbooks/{{.Check}}?ns={{.Namespace | urlquery}}
+type remediationHint struct {
+	Remediation string `json:"remediation,omitempty"`
+	RunbookURL  string `json:"runbook_url,omitempty"`
+
+	remediation *template.Template
+	runbookURL  *template.Template
+}
+
+// remediationInput is what remediation templates can refer to
+type remediationInput struct {
+	Namespace string
+	Name      string
+	Cluster   string
+	Check     string // e.g. "tee_attestation" or "gate:cmdb"
+	Gate      string // the gate name for gate checks, e.g. "cmdb"
+	Expected  string
+	Actual    string
+	Severity  string
+}
+
+// defaultRemediations are the hints for the checks the dashboard itself
+// performs. REMEDIATION_CONFIG adds runbook URLs and site-specific advice.
+var defaultRemediations = map[string]*remediationHint{
+	"tee_attestation": {Remediation: "Confirm the node supports confidential computing, the pod uses the confidential runtime class and " +
+		"its attestation agent can reach the Key Broker Service. Redeploying the pod re-runs attestation."},
+	"evidence_format": {Remediation: "The verifier's evidence can't be interpreted. Check that the Collector and verifier versions " +
+		"match the configured AR4SI profile."},
+	"trust_vector.hardware": {Remediation: "The TEE hardware or firmware is not affirmed ({{.Actual}}). Compare the node's firmware " +
+		"and TCB version with the verifier's reference values, and move the workload to an up-to-date node."},
+	"trust_vector.configuration": {Remediation: "The workload's configuration is not affirmed ({{.Actual}}). Look for unapproved " +
+		"changes to the pod spec or its init data since the reference values were recorded."},
+	"trust_vector.executables": {Remediation: "Unapproved executables were measured ({{.Actual}}). Compare the image digest with " +
+		"the reference values and redeploy from a signed release image."},
+	"gate:*": {Remediation: "The {{.Gate}} gate reports {{.Actual}}. Check the workload's record in the system behind the gate."},
+	"image_allowlist": {Remediation: "{{if eq .Expected \"allowlisted digest\"}}Image {{.Actual}} is not allowlisted for {{.Namespace}}. " +
+		"Redeploy from an approved image, or have the digest allowlisted through change control." +
+		"{{else}}The image allowlist service could not be reached; the workload's images were not checked.{{end}}"},
+	"verifier_agreement": {Remediation: "The primary and secondary verifiers disagree. Treat the workload as untrusted until their " +
+		"reference values are reconciled."},
+	"host_attestation": {Remediation: "The node running this workload failed attestation. Cordon the node and reschedule the " +
+		"workload onto an attested node."},
+	"attestation_freshness": {Remediation: "A container restarted after the last attestation. If the next report doesn't clear " +
+		"this, check the attestation agent in the pod."},
+	"clock_skew": {Remediation: "The report's timestamp is far from the dashboard's clock. Check time synchronization (NTP) on " +
+		"the node and on the Collector."},
+	"report_conflict": {Remediation: "Sources disagree on this workload's verdict. Look for a stale ingest source or a second " +
+		"Collector reporting the same pod."},
+	"report_present": {Remediation: "No attestation report was received for this expected workload. Check that the pod is " +
+		"running and that its attestation agent and the Collector are healthy."},
+}
+
+// remediations maps check names, or path.Match patterns such as "gate:*",
+// to hints
+type remediations map[string]*remediationHint
+
+// builtinRemediations are the compiled defaults, used when no
+// REMEDIATION_CONFIG is set
+var builtinRemediations = mustCompileRemediations(defaultRemediations)
+
+// loadRemediations reads hints from a JSON file mapping check names or
+// patterns to hints. A configured hint replaces the default of the same
+// name; a field left empty keeps the default's.
+func loadRemediations(path string) (remediations, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var configured map[string]*remediationHint
+	if err := json.Unmarshal(data, &configured); err != nil {
+		return nil, fmt.Errorf("invalid remediation config: %w", err)
+	}
+
+	hints := make(map[string]*remediationHint, len(defaultRemediations)+len(configured))
+	for name, hint := range defaultRemediations {
+		hints[name] = &remediationHint{Remediation: hint.Remediation}
+	}
+	for name, hint := range configured {
+		if hint == nil {
+			return nil, fmt.Errorf("check %q: hint is null", name)
+		}
+		if defaults, ok := hints[name]; ok && hint.Remediation == "" {
+			hint.Remediation = defaults.Remediation
+		}
+		hints[name] = hint
+	}
+	return compileRemediations(hints)
+}
+
+// compileRemediations validates and compiles the templates of hints
+func compileRemediations(hints map[string]*remediationHint) (remediations, error) {
+	for name, hint := range hints {
+		if _, err := path.Match(name, ""); err != nil {
+			return nil, fmt.Errorf("invalid check pattern %q", name)
+		}
+		var err error
+		if hint.remediation, err = parseHintTemplate(name, hint.Remediation); err != nil {
+			return nil, fmt.Errorf("check %q: invalid remediation: %w", name, err)
+		}
+		if hint.runbookURL, err = parseHintTemplate(name, hint.RunbookURL); err != nil {
+			return nil, fmt.Errorf("check %q: invalid runbook_url: %w", name, err)
+		}
+	}
+	return remediations(hints), nil
+}
+
+func mustCompileRemediations(hints map[string]*remediationHint) remediations {
+	compiled, err := compileRemediations(hints)
+	if err != nil {
+		panic(err)
+	}
+	return compiled
+}
+
+// parseHintTemplate compiles a hint template. Executing it once catches
+// references to fields remediationInput doesn't have, so rendering can't
+// fail later.
+func parseHintTemplate(name, text string) (*template.Template, error) {
+	if text == "" {
+		return nil, nil
+	}
+	tmpl, err := template.New(name).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	if err := tmpl.Execute(&bytes.Buffer{}, remediationInput{}); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// hintFor returns the hint for a check: an exact entry wins over patterns,
+// and among patterns the longest wins
+func (r remediations) hintFor(check string) *remediationHint {
+	if hint, ok := r[check]; ok {
+		return hint
+	}
+	var best string
+	for pattern := range r {
+		if matched, _ := path.Match(pattern, check); matched && (len(pattern) > len(best) || len(pattern) == len(best) && pattern < best) {
+			best = pattern
+		}
+	}
+	if best == "" {
+		return nil
+	}
+	return r[best]
+}
+
+// remediate returns the failed checks of a workload with their remediation
+// text and runbook URL filled in. The checks are copied, as status may be a
+// cached entry.
+func (s *Server) remediate(status *WorkloadStatus) []Check {
+	if len(status.FailedChecks) == 0 {
+		return status.FailedChecks
+	}
+	hints := s.remediations
+	if hints == nil {
+		hints = builtinRemediations
+	}
+
+	checks := make([]Check, len(status.FailedChecks))
+	for i, check := range status.FailedChecks {
+		checks[i] = check
+		hint := hints.hintFor(check.Name)
+		if hint == nil {
+			continue
+		}
+		gate, isGate := strings.CutPrefix(check.Name, "gate:")
+		if !isGate {
+			gate = ""
+		}
+		input := remediationInput{
+			Namespace: status.Namespace,
+			Name:      status.Name,
+			Cluster:   status.Cluster,
+			Check:     check.Name,
+			Gate:      gate,
+			Expected:  check.Expected,
+			Actual:    check.Actual,
+			Severity:  check.Severity,
+		}
+		checks[i].Remediation = renderHint(hint.remediation, input)
+		checks[i].RunbookURL = renderHint(hint.runbookURL, input)
+	}
+	return checks
+}
+
+// renderHint executes a hint template, if any
+func renderHint(tmpl *template.Template, input remediationInput) string {
+	if tmpl == nil {
+		return ""
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, input); err != nil {
+		return ""
+	}
+	return buf.String()
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestRemediate tests that failed checks get the built-in hints, and that
+// REMEDIATION_CONFIG adds runbook URLs templated per check type
+func TestRemediate(t *testing.T) {
+	status := failedStatus("icu", "sepsis-model")
+	status.FailedChecks = []Check{
+		{Name: "tee_attestation", Expected: "attested", Actual: "quote verification failed", Severity: severityCritical},
+		{Name: "gate:cmdb", Expected: "passing", Actual: "failed: not registered", Severity: severityHigh},
+		{Name: "custom_check"},
+	}
+
+	checks := (&Server{}).remediate(status)
+	if !strings.Contains(checks[0].Remediation, "Key Broker Service") || checks[0].RunbookURL != "" {
+		t.Errorf("Expected the built-in TEE hint without a runbook, got %+v", checks[0])
+	}
+	if checks[1].Remediation != "The cmdb gate reports failed: not registered. Check the workload's record in the system behind the gate." {
+		t.Errorf("Expected the gate hint, got %q", checks[1].Remediation)
+	}
+	if checks[2].Remediation != "" || status.FailedChecks[0].Remediation != "" {
+		t.Error("Expected no hint for an unknown check, and the cached checks left alone")
+	}
+
+	path := filepath.Join(t.TempDir(), "remediation.json")
+	os.WriteFile(path, []byte(`{
+		"tee_attestation": {"runbook_url": "https://wiki.example.org/runbooks/tee?ns={{.Namespace | urlquery}}"},
+		"gate:cmdb": {"remediation": "Register {{.Name}} in the CMDB.", "runbook_url": "https://wiki.example.org/runbooks/{{.Gate}}"},
+		"custom_*": {"remediation": "Page the {{.Cluster}} platform team."}
+	}`), 0o600)
+	remediations, err := loadRemediations(path)
+	if err != nil {
+		t.Fatalf("Failed to load remediation config: %v", err)
+	}
+
+	checks = (&Server{remediations: remediations}).remediate(status)
+	if !strings.Contains(checks[0].Remediation, "Key Broker Service") || checks[0].RunbookURL != "https://wiki.example.org/runbooks/tee?ns=icu" {
+		t.Errorf("Expected the default hint with the configured runbook, got %+v", checks[0])
+	}
+	if checks[1].Remediation != "Register sepsis-model in the CMDB." || checks[1].RunbookURL != "https://wiki.example.org/runbooks/cmdb" {
+		t.Errorf("Expected the gate-specific hint to win over gate:*, got %+v", checks[1])
+	}
+	if checks[2].Remediation == "" {
+		t.Error("Expected the pattern hint for the custom check")
+	}
+}
+
+// TestLoadRemediationsInvalid tests that broken templates are rejected at startup
+func TestLoadRemediationsInvalid(t *testing.T) {
+	for _, config := range []string{
+		`{"tee_attestation": {"remediation": "{{.Namespace"}}`,
+		`{"tee_attestation": {"runbook_url": "https://wiki/{{.Pod}}"}}`,
+		`{"[": {"remediation": "x"}}`,
+		`{"tee_attestation": null}`,
+	} {
+		path := filepath.Join(t.TempDir(), "remediation.json")
+		os.WriteFile(path, []byte(config), 0o600)
+		if _, err := loadRemediations(path); err == nil {
+			t.Errorf("Expected %s to be rejected", config)
+		}
+	}
+}
@@ -0,0 +1,367 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultReportWindow is used by report endpoints when no ?window= is given
+const defaultReportWindow = 30 * 24 * time.Hour
+
+// violationIncident is one continuous period during which a workload was in violation
+type violationIncident struct {
+	Key       string
+	Namespace string
+	Start     time.Time
+	End       time.Time // zero while ongoing
+	Recovered bool      // false if the incident ended because the workload was removed
+}
+
+// violationIncidents replays history events (ordered by time) into incidents
+func violationIncidents(events []HistoryEvent) []violationIncident {
+	open := make(map[string]*violationIncident)
+	var incidents []violationIncident
+
+	for _, event := range events {
+		incident, inViolation := open[event.Key]
+
+		switch {
+		case event.Type == "removed":
+			if inViolation {
+				incident.End = event.Time
+				incidents = append(incidents, *incident)
+				delete(open, event.Key)
+			}
+		case event.Status == nil:
+			continue
+		case isViolation(event.Status) && !inViolation:
+			open[event.Key] = &violationIncident{
+				Key:       event.Key,
+				Namespace: event.Status.Namespace,
+				Start:     event.Time,
+			}
+		case !isViolation(event.Status) && inViolation:
+			incident.End = event.Time
+			incident.Recovered = true
+			incidents = append(incidents, *incident)
+			delete(open, event.Key)
+		}
+	}
+
+	for _, incident := range open {
+		incidents = append(incidents, *incident)
+	}
+	sort.Slice(incidents, func(i, j int) bool { return incidents[i].Start.Before(incidents[j].Start) })
+	return incidents
+}
+
+// MTTRStats summarizes violation incidents for a workload, namespace or the fleet
+type MTTRStats struct {
+	Violations              int     `json:"violations"`
+	Recovered               int     `json:"recovered"`
+	Ongoing                 int     `json:"ongoing"`
+	MTTRSeconds             float64 `json:"mttr_seconds"` // mean over recovered incidents
+	TotalViolationSeconds   float64 `json:"total_violation_seconds"`
+	LongestViolationSeconds float64 `json:"longest_violation_seconds"`
+	// PlannedSeconds is violation time excluded because it fell within
+	// planned downtime; incidents entirely within it aren't counted at all
+	PlannedSeconds float64 `json:"planned_downtime_seconds"`
+
+	recoverySeconds float64
+}
+
+// add counts an incident, less the part of it in planned downtime
+func (m *MTTRStats) add(incident violationIncident, now time.Time, planned time.Duration) {
+	end := incident.End
+	if end.IsZero() {
+		end = now
+	}
+	m.PlannedSeconds += planned.Seconds()
+	duration := (end.Sub(incident.Start) - planned).Seconds()
+	if duration <= 0 {
+		return
+	}
+
+	m.Violations++
+	if incident.End.IsZero() {
+		m.Ongoing++
+	}
+	m.TotalViolationSeconds += duration
+	if duration > m.LongestViolationSeconds {
+		m.LongestViolationSeconds = duration
+	}
+	if incident.Recovered {
+		m.Recovered++
+		m.recoverySeconds += duration
+		m.MTTRSeconds = m.recoverySeconds / float64(m.Recovered)
+	}
+}
+
+// UptimeStats is the share of the time workloads were reported during which
+// they were not in violation. Planned downtime is left out of both.
+type UptimeStats struct {
+	ObservedSeconds  float64 `json:"observed_seconds"`
+	ViolationSeconds float64 `json:"violation_seconds"`
+	PlannedSeconds   float64 `json:"planned_downtime_seconds"`
+	UptimePercent    float64 `json:"uptime_percent"`
+}
+
+// finish computes the uptime percentage; with nothing observed it is 100
+func (u *UptimeStats) finish() {
+	u.UptimePercent = 100
+	if u.ObservedSeconds > 0 {
+		u.UptimePercent = 100 * (u.ObservedSeconds - u.ViolationSeconds) / u.ObservedSeconds
+	}
+}
+
+// MTTRReport is the response of /api/reports/mttr
+type MTTRReport struct {
+	From            time.Time               `json:"from"`
+	To              time.Time               `json:"to"`
+	Overall         MTTRStats               `json:"overall"`
+	Namespaces      map[string]*MTTRStats   `json:"namespaces"`
+	Workloads       map[string]*MTTRStats   `json:"workloads"`
+	Uptime          UptimeStats             `json:"uptime"`
+	NamespaceUptime map[string]*UptimeStats `json:"namespace_uptime"`
+}
+
+// reportedSpans replays history events into the periods each workload was
+// reported, clipped to [from, to]. Spans reuse violationIncident; End is never zero.
+func reportedSpans(events []HistoryEvent, from, to time.Time) []violationIncident {
+	open := make(map[string]*violationIncident)
+	var spans []violationIncident
+	closeSpan := func(span *violationIncident, end time.Time) {
+		if span.Start.Before(from) {
+			span.Start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+		if span.Start.Before(end) {
+			span.End = end
+			spans = append(spans, *span)
+		}
+	}
+
+	for _, event := range events {
+		span, reported := open[event.Key]
+		switch {
+		case event.Type == "removed":
+			if reported {
+				closeSpan(span, event.Time)
+				delete(open, event.Key)
+			}
+		case event.Status != nil && !reported:
+			open[event.Key] = &violationIncident{Key: event.Key, Namespace: event.Status.Namespace, Start: event.Time}
+		}
+	}
+	for _, span := range open {
+		closeSpan(span, to)
+	}
+	return spans
+}
+
+// buildMTTRReport computes MTTR statistics for incidents that started within
+// [from, to], and uptime over [from, to], leaving out planned downtime
+func (s *Server) buildMTTRReport(from, to time.Time) MTTRReport {
+	report := MTTRReport{
+		From:            from,
+		To:              to,
+		Namespaces:      make(map[string]*MTTRStats),
+		Workloads:       make(map[string]*MTTRStats),
+		NamespaceUptime: make(map[string]*UptimeStats),
+	}
+	downtime := s.downtime.List()
+	uptimeOf := func(namespace string) *UptimeStats {
+		uptime, ok := report.NamespaceUptime[namespace]
+		if !ok {
+			uptime = &UptimeStats{}
+			report.NamespaceUptime[namespace] = uptime
+		}
+		return uptime
+	}
+
+	// Replay from the beginning so violations open before the window are tracked correctly
+	events := s.history.Events(time.Time{}, to)
+	for _, span := range reportedSpans(events, from, to) {
+		planned := plannedOverlap(downtime, span.Namespace, span.Start, span.End).Seconds()
+		observed := span.End.Sub(span.Start).Seconds() - planned
+		for _, uptime := range []*UptimeStats{&report.Uptime, uptimeOf(span.Namespace)} {
+			uptime.ObservedSeconds += observed
+			uptime.PlannedSeconds += planned
+		}
+	}
+
+	for _, incident := range violationIncidents(events) {
+		end := incident.End
+		if end.IsZero() || end.After(to) {
+			end = to
+		}
+		start := incident.Start
+		if start.Before(from) {
+			start = from
+		}
+		if start.Before(end) {
+			violation := (end.Sub(start) - plannedOverlap(downtime, incident.Namespace, start, end)).Seconds()
+			report.Uptime.ViolationSeconds += violation
+			uptimeOf(incident.Namespace).ViolationSeconds += violation
+		}
+
+		if incident.Start.Before(from) {
+			continue
+		}
+		planned := plannedOverlap(downtime, incident.Namespace, incident.Start, end)
+
+		report.Overall.add(incident, to, planned)
+
+		ns, ok := report.Namespaces[incident.Namespace]
+		if !ok {
+			ns = &MTTRStats{}
+			report.Namespaces[incident.Namespace] = ns
+		}
+		ns.add(incident, to, planned)
+
+		wl, ok := report.Workloads[incident.Key]
+		if !ok {
+			wl = &MTTRStats{}
+			report.Workloads[incident.Key] = wl
+		}
+		wl.add(incident, to, planned)
+	}
+
+	report.Uptime.finish()
+	for _, uptime := range report.NamespaceUptime {
+		uptime.finish()
+	}
+	return report
+}
+
+// parseReportWindow reads the ?window= (duration) and ?to= (RFC3339) parameters
+func parseReportWindow(r *http.Request) (from, to time.Time, err error) {
+	to = time.Now()
+	if raw := r.URL.Query().Get("to"); raw != "" {
+		if to, err = time.Parse(time.RFC3339, raw); err != nil {
+			return from, to, err
+		}
+	}
+
+	window := defaultReportWindow
+	if raw := r.URL.Query().Get("window"); raw != "" {
+		if window, err = time.ParseDuration(raw); err != nil {
+			return from, to, err
+		}
+	}
+
+	return to.Add(-window), to, nil
+}
+
+// handleMTTRReport returns MTTR and violation statistics per workload and namespace
+// GET /api/reports/mttr?window=720h
+func (s *Server) handleMTTRReport(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseReportWindow(r)
+	if err != nil {
+		http.Error(w, "invalid window or to parameter", http.StatusBadRequest)
+		return
+	}
+
+	report := s.buildMTTRReport(from, to)
+	keys := make([]string, 0, len(report.Workloads))
+	for key := range report.Workloads {
+		keys = append(keys, key)
+	}
+	noteWorkloadKeys(r, keys...)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// writeMTTRMetrics exports MTTR statistics over the default report window.
+// Only namespace-level series are exported to keep label cardinality bounded
+// as pods churn; per-workload figures are available from /api/reports/mttr.
+func (s *Server) writeMTTRMetrics(w io.Writer) {
+	now := time.Now()
+	report := s.buildMTTRReport(now.Add(-defaultReportWindow), now)
+
+	var mttr, violations, ongoing, duration []metricSample
+	namespaces := make([]string, 0, len(report.Namespaces))
+	for ns := range report.Namespaces {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+
+	for _, ns := range namespaces {
+		stats := report.Namespaces[ns]
+		mttr = append(mttr, sample(stats.MTTRSeconds, "namespace", ns))
+		violations = append(violations, sample(float64(stats.Violations), "namespace", ns))
+		ongoing = append(ongoing, sample(float64(stats.Ongoing), "namespace", ns))
+		duration = append(duration, sample(stats.TotalViolationSeconds, "namespace", ns))
+	}
+	mttr = append(mttr, sample(report.Overall.MTTRSeconds))
+
+	var uptime []metricSample
+	namespaces = namespaces[:0]
+	for ns := range report.NamespaceUptime {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+	for _, ns := range namespaces {
+		uptime = append(uptime, sample(report.NamespaceUptime[ns].UptimePercent, "namespace", ns))
+	}
+	uptime = append(uptime, sample(report.Uptime.UptimePercent))
+
+	window := strings.TrimSuffix(defaultReportWindow.String(), "0m0s")
+	writeMetric(w, "dashboard_attestation_mttr_seconds", "gauge", "Mean time to recover from attestation violations over the last "+window+".", mttr)
+	writeMetric(w, "dashboard_attestation_violations", "gauge", "Attestation violation incidents started in the last "+window+".", violations)
+	writeMetric(w, "dashboard_attestation_violations_ongoing", "gauge", "Attestation violation incidents not yet recovered.", ongoing)
+	writeMetric(w, "dashboard_attestation_violation_seconds", "gauge", "Total time spent in violation over the last "+window+".", duration)
+	writeMetric(w, "dashboard_attestation_uptime_percent", "gauge", "Share of reported workload time not in violation over the last "+window+", excluding planned downtime.", uptime)
+}
+
+// HeatmapReport counts violation starts by day-of-week and hour-of-day (UTC)
+type HeatmapReport struct {
+	From     time.Time  `json:"from"`
+	To       time.Time  `json:"to"`
+	Timezone string     `json:"timezone"`
+	Days     []string   `json:"days"`
+	Cells    [7][24]int `json:"cells"` // [day][hour], day 0 is Sunday
+	Total    int        `json:"total"`
+}
+
+// buildHeatmap buckets violations that started within [from, to], optionally
+// restricted to one namespace
+func (s *Server) buildHeatmap(from, to time.Time, namespace string) HeatmapReport {
+	report := HeatmapReport{From: from, To: to, Timezone: "UTC"}
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		report.Days = append(report.Days, d.String())
+	}
+
+	for _, incident := range violationIncidents(s.history.Events(time.Time{}, to)) {
+		if incident.Start.Before(from) {
+			continue
+		}
+		if namespace != "" && incident.Namespace != namespace {
+			continue
+		}
+		start := incident.Start.UTC()
+		report.Cells[start.Weekday()][start.Hour()]++
+		report.Total++
+	}
+
+	return report
+}
+
+// handleHeatmapReport returns violation counts by hour-of-day and day-of-week
+// GET /api/reports/heatmap?window=720h&namespace=icu
+func (s *Server) handleHeatmapReport(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseReportWindow(r)
+	if err != nil {
+		http.Error(w, "invalid window or to parameter", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.buildHeatmap(from, to, r.URL.Query().Get("namespace")))
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func failedStatus(ns, name string) *WorkloadStatus {
+	return &WorkloadStatus{Name: name, Namespace: ns, Attested: false, AttestationStatus: "failed", GateTwoStatus: "failed"}
+}
+
+func verifiedStatus(ns, name string) *WorkloadStatus {
+	return &WorkloadStatus{Name: name, Namespace: ns, Attested: true, AttestationStatus: "verified", GateTwoStatus: "passing"}
+}
+
+// TestViolationIncidents tests replaying history into violation incidents
+func TestViolationIncidents(t *testing.T) {
+	t0 := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	events := []HistoryEvent{
+		{Time: t0, Key: "icu/a", Type: "added", Status: verifiedStatus("icu", "a")},
+		{Time: t0.Add(time.Hour), Key: "icu/a", Type: "changed", Status: failedStatus("icu", "a")},
+		{Time: t0.Add(90 * time.Minute), Key: "icu/a", Type: "changed", Status: verifiedStatus("icu", "a")},
+		{Time: t0.Add(2 * time.Hour), Key: "dev/b", Type: "added", Status: failedStatus("dev", "b")},
+		{Time: t0.Add(3 * time.Hour), Key: "dev/b", Type: "removed", Status: failedStatus("dev", "b")},
+		{Time: t0.Add(4 * time.Hour), Key: "dev/c", Type: "added", Status: failedStatus("dev", "c")},
+	}
+
+	incidents := violationIncidents(events)
+	if len(incidents) != 3 {
+		t.Fatalf("Expected 3 incidents, got %d: %+v", len(incidents), incidents)
+	}
+
+	if !incidents[0].Recovered || incidents[0].End.Sub(incidents[0].Start) != 30*time.Minute {
+		t.Errorf("Expected a recovered 30m incident, got %+v", incidents[0])
+	}
+	if incidents[1].Recovered || incidents[1].End.IsZero() {
+		t.Errorf("Expected incident closed by removal, got %+v", incidents[1])
+	}
+	if !incidents[2].End.IsZero() {
+		t.Errorf("Expected ongoing incident, got %+v", incidents[2])
+	}
+}
+
+// TestBuildMTTRReport tests MTTR aggregation per namespace and workload
+func TestBuildMTTRReport(t *testing.T) {
+	history, _ := newHistory(nil, 0)
+	t0 := time.Now().Add(-10 * time.Hour)
+	history.Record([]HistoryEvent{
+		{Time: t0, Key: "icu/a", Type: "added", Status: failedStatus("icu", "a")},
+		{Time: t0.Add(10 * time.Minute), Key: "icu/a", Type: "changed", Status: verifiedStatus("icu", "a")},
+		{Time: t0.Add(time.Hour), Key: "icu/a", Type: "changed", Status: failedStatus("icu", "a")},
+		{Time: t0.Add(90 * time.Minute), Key: "icu/a", Type: "changed", Status: verifiedStatus("icu", "a")},
+	})
+	server := &Server{history: history}
+
+	report := server.buildMTTRReport(t0.Add(-time.Hour), time.Now())
+
+	if report.Overall.Violations != 2 || report.Overall.Recovered != 2 {
+		t.Fatalf("Expected 2 recovered violations, got %+v", report.Overall)
+	}
+	if report.Overall.MTTRSeconds != 20*60 {
+		t.Errorf("Expected MTTR of 1200s, got %g", report.Overall.MTTRSeconds)
+	}
+	if report.Namespaces["icu"].LongestViolationSeconds != 30*60 {
+		t.Errorf("Expected longest violation of 1800s, got %g", report.Namespaces["icu"].LongestViolationSeconds)
+	}
+	if report.Workloads["icu/a"].Violations != 2 {
+		t.Errorf("Expected 2 violations for icu/a, got %d", report.Workloads["icu/a"].Violations)
+	}
+}
+
+// TestHandleMetricsIncludesMTTR tests the Prometheus exposition
+func TestHandleMetricsIncludesMTTR(t *testing.T) {
+	history, _ := newHistory(nil, 0)
+	history.Record([]HistoryEvent{
+		{Time: time.Now().Add(-time.Hour), Key: "icu/a", Type: "added", Status: failedStatus("icu", "a")},
+	})
+	metrics := newMetrics()
+	metrics.Inc("dashboard_test_total", "Test counter.", "kind", "x")
+
+	server := &Server{
+		history:     history,
+		metrics:     metrics,
+		statusCache: map[string]*WorkloadStatus{"icu/a": failedStatus("icu", "a")},
+	}
+
+	w := httptest.NewRecorder()
+	server.handleMetrics(w, httptest.NewRequest("GET", "/metrics", nil))
+	body := w.Body.String()
+
+	for _, expected := range []string{
+		`dashboard_workloads{status="failed"} 1`,
+		`dashboard_attestation_violations_ongoing{namespace="icu"} 1`,
+		`# TYPE dashboard_attestation_mttr_seconds gauge`,
+		`dashboard_test_total{kind="x"} 1`,
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("Expected metrics output to contain %q, got:\n%s", expected, body)
+		}
+	}
+}
+
+// TestBuildHeatmap tests bucketing of violation starts
+func TestBuildHeatmap(t *testing.T) {
+	history, _ := newHistory(nil, 0)
+	// 2024-05-07 is a Tuesday
+	nightly := time.Date(2024, 5, 7, 2, 15, 0, 0, time.UTC)
+	history.Record([]HistoryEvent{
+		{Time: nightly, Key: "icu/a", Type: "added", Status: failedStatus("icu", "a")},
+		{Time: nightly, Key: "dev/b", Type: "added", Status: failedStatus("dev", "b")},
+		{Time: nightly.Add(time.Hour), Key: "icu/a", Type: "changed", Status: verifiedStatus("icu", "a")},
+		{Time: nightly.Add(7 * 24 * time.Hour), Key: "icu/a", Type: "changed", Status: failedStatus("icu", "a")},
+	})
+	server := &Server{history: history}
+
+	from := nightly.Add(-time.Hour)
+	to := nightly.Add(8 * 24 * time.Hour)
+
+	report := server.buildHeatmap(from, to, "")
+	if report.Total != 3 || report.Cells[time.Tuesday][2] != 3 {
+		t.Errorf("Expected 3 violations on Tuesday 02:00, got total=%d cell=%d", report.Total, report.Cells[time.Tuesday][2])
+	}
+
+	icu := server.buildHeatmap(from, to, "icu")
+	if icu.Total != 2 {
+		t.Errorf("Expected 2 icu violations, got %d", icu.Total)
+	}
+
+	if report.Days[0] != "Sunday" || len(report.Days) != 7 {
+		t.Errorf("Unexpected day labels: %v", report.Days)
+	}
+}
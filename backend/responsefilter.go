@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// auditorRole sees API responses in full, like admins, without any other
+// administrative rights
+const auditorRole = "auditor"
+
+// defaultRedactedFields are withheld from callers who may not see sensitive
+// fields: raw tokens, measurement values and error internals
+const defaultRedactedFields = "ear_token,trust_vector,tcb_version,image_digests,raw_report_id,source,actual,error,last_error"
+
+// fieldFilter removes sensitive fields from API responses for viewer
+// callers. It works on the encoded JSON, wherever a field appears, so new
+// handlers are covered without each having to redact its own types.
+// A nil *fieldFilter leaves responses unchanged.
+type fieldFilter struct {
+	fields map[string]bool
+}
+
+// parseFieldFilter parses RESPONSE_REDACTED_FIELDS, a comma-separated list
+// of JSON field names. "none" disables redaction.
+func parseFieldFilter(spec string) *fieldFilter {
+	if strings.TrimSpace(spec) == "none" {
+		return nil
+	}
+	f := &fieldFilter{fields: make(map[string]bool)}
+	for _, field := range strings.Split(spec, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			f.fields[field] = true
+		}
+	}
+	if len(f.fields) == 0 {
+		return nil
+	}
+	return f
+}
+
+// seesSensitiveFields reports whether a caller gets responses in full: with
+// an RBAC policy when granted read:sensitive, otherwise with the admin or
+// auditor role. Without authentication there are no roles to tell callers
+// apart, so everyone does.
+func (s *Server) seesSensitiveFields(identity *Identity) bool {
+	switch {
+	case s.auth == nil:
+		return true
+	case identity == nil:
+		return false
+	case identity.permissions != nil:
+		return identity.hasPermission(permReadSensitive)
+	}
+	return identity.hasRole(adminRole) || identity.hasRole(auditorRole)
+}
+
+// redactJSON removes the filtered fields from an encoded JSON document.
+// Documents that don't parse are returned as they are.
+func (f *fieldFilter) redactJSON(data []byte) []byte {
+	if f == nil {
+		return data
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber() // keep large integers exact
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return data
+	}
+	if !f.strip(doc) {
+		return data
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(doc); err != nil {
+		return data
+	}
+	// Keep the handler's trailing newline, or its absence
+	if !bytes.HasSuffix(data, []byte("\n")) {
+		return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+	}
+	return buf.Bytes()
+}
+
+// strip removes the filtered fields from a decoded document in place and
+// reports whether any were found
+func (f *fieldFilter) strip(doc interface{}) bool {
+	found := false
+	switch v := doc.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if f.fields[key] {
+				delete(v, key)
+				found = true
+			} else if f.strip(value) {
+				found = true
+			}
+		}
+	case []interface{}:
+		for _, value := range v {
+			if f.strip(value) {
+				found = true
+			}
+		}
+	}
+	return found
+}
+
+// filteredResponse buffers a response so its fields can be redacted before sending
+type filteredResponse struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *filteredResponse) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+}
+
+func (w *filteredResponse) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(b)
+}
+
+// fieldFilterMiddleware redacts sensitive fields from the JSON API responses
+// of callers who may not see them. Event streams are filtered per event by
+// their handlers instead.
+func (s *Server) fieldFilterMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.fieldFilter == nil || !strings.HasPrefix(r.URL.Path, "/api/") || isStreamPath(r.URL.Path) ||
+			s.seesSensitiveFields(identityFromContext(r.Context())) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		recorder := &filteredResponse{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+		body := recorder.body.Bytes()
+		if recorder.status < 300 && strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+			body = s.fieldFilter.redactJSON(body)
+			if w.Header().Get("Content-Length") != "" {
+				w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+			}
+		}
+		w.WriteHeader(recorder.status)
+		w.Write(body)
+	})
+}
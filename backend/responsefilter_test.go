@@ -0,0 +1,104 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestFieldFilterMiddleware tests that viewers get sensitive fields redacted
+// while auditors, admins and callers granted read:sensitive get them in full
+func TestFieldFilterMiddleware(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	os.WriteFile(path, []byte(`[{"token":"raj-token","identity":"raj"}]`), 0o600)
+	auth, err := loadAuthenticator(path)
+	if err != nil {
+		t.Fatalf("Failed to load tokens: %v", err)
+	}
+
+	status := failedStatus("icu", "sepsis-model")
+	status.TCBVersion = "0x1234"
+	status.ImageDigests = []string{"sha256:abc"}
+	status.TrustVector = &TrustVector{Hardware: 2, Executables: 33}
+	status.FailedChecks = []Check{{Name: "trust_vector.executables", Expected: "Affirming", Actual: "Warning (33)", Severity: severityWarning}}
+	server := &Server{
+		auth:        auth,
+		fieldFilter: parseFieldFilter(defaultRedactedFields),
+		statusCache: map[string]*WorkloadStatus{"icu/sepsis-model": status},
+	}
+	handler := server.fieldFilterMiddleware(http.HandlerFunc(server.handleStatus))
+
+	tests := []struct {
+		name     string
+		identity *Identity
+		full     bool
+	}{
+		{"viewer", &Identity{Name: "raj", Roles: []string{"viewer"}}, false},
+		{"anonymous", nil, false},
+		{"auditor", &Identity{Name: "qa", Roles: []string{auditorRole}}, true},
+		{"admin", &Identity{Name: "ops", Roles: []string{adminRole}}, true},
+		{"rbac viewer", &Identity{Name: "raj", Roles: []string{adminRole}, permissions: []string{"read:workloads"}}, false},
+		{"rbac read:sensitive", &Identity{Name: "qa", permissions: []string{"read:*"}}, true},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, ackRequestAs(tt.identity, "GET", "/api/status", ""))
+		body := w.Body.String()
+		if w.Code != http.StatusOK || !strings.Contains(body, `"name":"sepsis-model"`) || !strings.Contains(body, `"expected":"Affirming"`) {
+			t.Errorf("%s: expected the workload, got %d: %s", tt.name, w.Code, body)
+		}
+		for _, field := range []string{`"trust_vector"`, `"tcb_version"`, `"image_digests"`, `"actual"`} {
+			if strings.Contains(body, field) != tt.full {
+				t.Errorf("%s: expected %s present=%v, got %s", tt.name, field, tt.full, body)
+			}
+		}
+	}
+
+	// Without authentication nobody is told apart, so responses are sent in full
+	server.auth = nil
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/status", nil))
+	if !strings.Contains(w.Body.String(), `"trust_vector"`) {
+		t.Errorf("Expected full responses without auth, got %s", w.Body.String())
+	}
+}
+
+// TestRedactJSON tests removing fields at any depth, leaving other documents alone
+func TestRedactJSON(t *testing.T) {
+	filter := parseFieldFilter(" error, last_error ")
+	got := string(filter.redactJSON([]byte(`{"sites":[{"name":"north","last_error":"dial tcp 10.0.0.1:443","polls":12345678901234567}],"error":"x"}` + "\n")))
+	if want := `{"sites":[{"name":"north","polls":12345678901234567}]}` + "\n"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+	if got := string(filter.redactJSON([]byte(`not json`))); got != "not json" {
+		t.Errorf("Expected invalid JSON unchanged, got %s", got)
+	}
+	if parseFieldFilter("none") != nil || (*fieldFilter)(nil).redactJSON([]byte(`{"error":1}`)) == nil {
+		t.Error(`Expected "none" to disable redaction`)
+	}
+}
+
+// TestStreamFieldFilter tests that stream subscribers are scoped by the
+// roles in their subscription token
+func TestStreamFieldFilter(t *testing.T) {
+	tokens, _ := newStreamTokens("secret", time.Minute)
+	server := &Server{auth: &Authenticator{}, streamTokens: tokens, fieldFilter: parseFieldFilter(defaultRedactedFields)}
+
+	for _, tt := range []struct {
+		identity *Identity
+		redacted bool
+	}{
+		{&Identity{Name: "raj", Roles: []string{"viewer"}}, true},
+		{&Identity{Name: "qa", Roles: []string{auditorRole}}, false},
+	} {
+		token, _ := tokens.issue(tt.identity, time.Now())
+		_, redact, err := server.subscriptionFilter(httptest.NewRequest("GET", "/api/events?token="+token, nil))
+		if err != nil || (redact != nil) != tt.redacted {
+			t.Errorf("%s: expected redaction %v, got %v (%v)", tt.identity.Name, tt.redacted, redact != nil, err)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// predatesRestartStatus is the AttestationStatus of a verified workload whose
+// container restarted after the report was produced, so the report may no
+// longer describe the code that is running
+const predatesRestartStatus = "attestation-predates-restart"
+
+// podRestarts returns the total restart count of a pod's containers and the
+// time the most recently restarted one came back up
+func podRestarts(pod *kubePod) (int, time.Time) {
+	var count int
+	var last time.Time
+	for _, cs := range pod.Status.ContainerStatuses {
+		count += cs.RestartCount
+		if cs.RestartCount == 0 || cs.State.Running == nil {
+			continue
+		}
+		if cs.State.Running.StartedAt.After(last) {
+			last = cs.State.Running.StartedAt
+		}
+	}
+	return count, last
+}
+
+// correlateRestarts records restart information on a status and flags a
+// verified workload whose attestation is older than its latest restart
+func correlateRestarts(report CollectorReport, status *WorkloadStatus) {
+	if report.restartCount == 0 {
+		return
+	}
+
+	status.RestartCount = report.restartCount
+	if report.lastRestart.IsZero() {
+		return
+	}
+	lastRestart := report.lastRestart
+	status.LastRestart = &lastRestart
+
+	if status.AttestationStatus == "verified" && lastRestart.After(report.Timestamp) {
+		status.AttestationStatus = predatesRestartStatus
+		status.Details = fmt.Sprintf("Attestation predates container restart at %s - %s",
+			lastRestart.UTC().Format(time.RFC3339), status.Details)
+		failCheck(status, "attestation_freshness", "attested after last container restart",
+			"container restarted at "+lastRestart.UTC().Format(time.RFC3339), severityWarning)
+	}
+}
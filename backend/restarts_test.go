@@ -0,0 +1,50 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestRestartCorrelation tests that a container restart after the last
+// attestation is flagged on the workload
+func TestRestartCorrelation(t *testing.T) {
+	mockAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"spec":{"nodeName":"worker-1"},"status":{"containerStatuses":[
+			{"name":"app","restartCount":2,"state":{"running":{"startedAt":"2024-05-01T12:00:00Z"}}},
+			{"name":"sidecar","restartCount":0,"state":{"running":{"startedAt":"2024-05-01T08:00:00Z"}}}
+		]}}`))
+	}))
+	defer mockAPI.Close()
+
+	server := &Server{
+		kube: &kubeClient{baseURL: mockAPI.URL, httpClient: mockAPI.Client()},
+	}
+
+	reports := []CollectorReport{
+		{PodName: "stale", Namespace: "icu", Attested: true, Timestamp: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)},
+		{PodName: "fresh", Namespace: "icu", Attested: true, Timestamp: time.Date(2024, 5, 1, 13, 0, 0, 0, time.UTC)},
+	}
+	for i := range reports {
+		kubeEnricher{server}.Enrich(&reports[i], &WorkloadStatus{})
+	}
+
+	if reports[0].restartCount != 2 {
+		t.Errorf("Expected restart count 2, got %d", reports[0].restartCount)
+	}
+
+	stale := server.convertCollectorReport(reports[0])
+	if stale.AttestationStatus != predatesRestartStatus {
+		t.Errorf("Expected status '%s', got '%s'", predatesRestartStatus, stale.AttestationStatus)
+	}
+	if stale.RestartCount != 2 || stale.LastRestart == nil {
+		t.Errorf("Expected restart info on status, got %+v", stale)
+	}
+
+	fresh := server.convertCollectorReport(reports[1])
+	if fresh.AttestationStatus != "verified" {
+		t.Errorf("Expected attestation after restart to stay verified, got '%s'", fresh.AttestationStatus)
+	}
+}
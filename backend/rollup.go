@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Namespace criticality levels. A violation in a critical namespace, or of a
+// pinned workload, turns the overall status to violation at once, bypassing
+// the tolerance and the debounce; violations in dev namespaces only degrade
+// it to warning.
+const (
+	criticalityCritical = "critical"
+	criticalityStandard = "standard"
+	criticalityDev      = "dev"
+)
+
+// rollupPolicy decides how workload violations roll up into the overall
+// status, so a single flaky dev pod needn't turn the whole wallboard red.
+// The zero value counts every violation.
+type rollupPolicy struct {
+	// tolerated is how many counted violations still roll up to compliant
+	tolerated int
+	// ignoredNamespaces never affect the overall status; their workloads
+	// are still listed
+	ignoredNamespaces map[string]bool
+	// quorum is how many verifiers must report a workload failed before its
+	// attestation failure counts. Workloads with fewer verdicts available
+	// need all of them, so a cluster without a secondary verifier still
+	// counts its failures.
+	quorum int
+	// criticality maps namespaces, or path.Match patterns, to a criticality
+	// level; unlisted namespaces are standard
+	criticality map[string]string
+	// unknown is how workloads whose attestation is unknown roll up; the
+	// zero value rolls them up to warning
+	unknown string
+}
+
+// newRollupPolicy parses the comma-separated ignored namespace list
+func newRollupPolicy(tolerated int, ignoredNamespaces string, quorum int) rollupPolicy {
+	p := rollupPolicy{tolerated: tolerated, quorum: quorum}
+	for _, ns := range strings.Split(ignoredNamespaces, ",") {
+		if ns = strings.TrimSpace(ns); ns != "" {
+			if p.ignoredNamespaces == nil {
+				p.ignoredNamespaces = make(map[string]bool)
+			}
+			p.ignoredNamespaces[ns] = true
+		}
+	}
+	return p
+}
+
+// parseCriticality parses NAMESPACE_CRITICALITY, e.g. "icu=critical,dev-*=dev"
+func parseCriticality(spec string) (map[string]string, error) {
+	criticality := make(map[string]string)
+	for _, entry := range strings.Split(spec, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		namespace, level, ok := strings.Cut(entry, "=")
+		namespace, level = strings.TrimSpace(namespace), strings.TrimSpace(level)
+		if !ok || namespace == "" {
+			return nil, fmt.Errorf("invalid criticality entry %q, expected namespace=level", entry)
+		}
+		if _, err := path.Match(namespace, ""); err != nil {
+			return nil, fmt.Errorf("invalid namespace pattern %q", namespace)
+		}
+		switch level {
+		case criticalityCritical, criticalityStandard, criticalityDev:
+		default:
+			return nil, fmt.Errorf("unknown criticality %q for %s", level, namespace)
+		}
+		criticality[namespace] = level
+	}
+	return criticality, nil
+}
+
+// criticalityOf returns the criticality of a namespace. An exact entry wins
+// over patterns; among patterns the most critical match wins.
+func (p rollupPolicy) criticalityOf(namespace string) string {
+	if level, ok := p.criticality[namespace]; ok {
+		return level
+	}
+	level := ""
+	for pattern, l := range p.criticality {
+		if matched, _ := path.Match(pattern, namespace); matched {
+			if level == "" || l == criticalityCritical || (l == criticalityStandard && level == criticalityDev) {
+				level = l
+			}
+		}
+	}
+	if level == "" {
+		return criticalityStandard
+	}
+	return level
+}
+
+// status rolls workload states up into "compliant", "warning" or "violation"
+func (p rollupPolicy) status(workloads []WorkloadStatus) string {
+	violations, warnings := 0, 0
+	for i := range workloads {
+		if p.unknownWarns(&workloads[i]) {
+			warnings++
+			continue
+		}
+		if !p.counts(&workloads[i]) {
+			continue
+		}
+		switch p.workloadCriticality(&workloads[i]) {
+		case criticalityCritical:
+			return "violation"
+		case criticalityDev:
+			warnings++
+		default:
+			violations++
+		}
+	}
+	if violations > p.tolerated {
+		return "violation"
+	}
+	if warnings > 0 {
+		return "warning"
+	}
+	return "compliant"
+}
+
+// workloadCriticality returns the criticality of a workload: critical while
+// it is pinned, that of its namespace otherwise
+func (p rollupPolicy) workloadCriticality(status *WorkloadStatus) string {
+	if status.Pinned {
+		return criticalityCritical
+	}
+	return p.criticalityOf(status.Namespace)
+}
+
+// criticalViolation reports whether a critical workload is in violation
+func (p rollupPolicy) criticalViolation(workloads []WorkloadStatus) bool {
+	for i := range workloads {
+		if p.counts(&workloads[i]) && p.workloadCriticality(&workloads[i]) == criticalityCritical {
+			return true
+		}
+	}
+	return false
+}
+
+// unknownWarns reports whether a workload whose attestation is unknown
+// degrades the rollup to warning, whatever its namespace's criticality
+func (p rollupPolicy) unknownWarns(status *WorkloadStatus) bool {
+	return isUnknown(status) && !isViolation(status) && (p.unknown == "" || p.unknown == unknownRollupWarning) &&
+		status.Maintenance == "" && !p.ignoredNamespaces[status.Namespace]
+}
+
+// counts reports whether a workload's violation counts towards the rollup.
+// Violations inside a maintenance window don't count, and unknown
+// attestations only when they roll up as violations.
+func (p rollupPolicy) counts(status *WorkloadStatus) bool {
+	if status.Maintenance != "" || p.ignoredNamespaces[status.Namespace] {
+		return false
+	}
+	if !isViolation(status) {
+		return isUnknown(status) && p.unknown == unknownRollupViolation
+	}
+	if p.quorum <= 1 {
+		return true
+	}
+
+	// Gate failures are not verifier verdicts and always count. The TEE
+	// attestation gate is the primary verifier's verdict, decided below.
+	if status.GateOneStatus == "failed" || gatesFailed(status.Gates) {
+		return true
+	}
+	verdicts, failed := 1, 0
+	if !status.Attested && !isUnknown(status) {
+		failed++
+	}
+	switch status.SecondaryVerdict {
+	case "verified":
+		verdicts++
+	case "failed":
+		verdicts++
+		failed++
+	}
+	required := p.quorum
+	if verdicts < required {
+		required = verdicts
+	}
+	return failed >= required
+}
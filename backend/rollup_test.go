@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestRollupPolicy tests tolerance, ignored namespaces and verifier quorum
+func TestRollupPolicy(t *testing.T) {
+	// Verdicts as the pipeline derives them, secondary "" for none
+	server := &Server{secondaryCollectorURL: "http://secondary-collector"}
+	verify := func(ns, name string, primary bool, secondary string) *WorkloadStatus {
+		status := server.convertCollectorReport(CollectorReport{PodName: name, Namespace: ns, TEEType: "SNP", Attested: primary, Timestamp: time.Now()})
+		if secondary != "" {
+			server.compareVerifiers([]*WorkloadStatus{status}, map[string]CollectorReport{ns + "/" + name: {Attested: secondary == "verified"}})
+		}
+		return status
+	}
+	split := verify("icu", "split", true, "failed")
+	primarySplit := verify("icu", "primary-split", false, "verified")
+	bothFailed := verify("icu", "both", false, "failed")
+	primaryOnly := verify("radiology", "pacs", false, "")
+
+	gateFailed := verifiedStatus("icu", "gated")
+	gateFailed.GateOneStatus = "failed"
+	gateFailed.SecondaryVerdict = "verified"
+
+	maintenance := failedStatus("icu", "patching")
+	maintenance.Maintenance = "monthly patching"
+
+	tests := []struct {
+		name      string
+		policy    rollupPolicy
+		workloads []*WorkloadStatus
+		expected  string
+	}{
+		{"any violation", rollupPolicy{}, []*WorkloadStatus{verifiedStatus("icu", "a"), failedStatus("dev", "b")}, "violation"},
+		{"maintenance", rollupPolicy{}, []*WorkloadStatus{maintenance}, "compliant"},
+		{"tolerated", newRollupPolicy(1, "", 1), []*WorkloadStatus{failedStatus("dev", "b")}, "compliant"},
+		{"over tolerance", newRollupPolicy(1, "", 1), []*WorkloadStatus{failedStatus("dev", "b"), failedStatus("icu", "c")}, "violation"},
+		{"ignored namespace", newRollupPolicy(0, "dev, sandbox", 1), []*WorkloadStatus{failedStatus("dev", "b"), failedStatus("sandbox", "c")}, "compliant"},
+		{"split below quorum", newRollupPolicy(0, "", 2), []*WorkloadStatus{split}, "compliant"},
+		{"split without quorum", rollupPolicy{}, []*WorkloadStatus{split}, "violation"},
+		{"primary split below quorum", newRollupPolicy(0, "", 2), []*WorkloadStatus{primarySplit}, "compliant"},
+		{"primary split without quorum", rollupPolicy{}, []*WorkloadStatus{primarySplit}, "violation"},
+		{"quorum reached", newRollupPolicy(0, "", 2), []*WorkloadStatus{bothFailed}, "violation"},
+		{"single verifier", newRollupPolicy(0, "", 2), []*WorkloadStatus{primaryOnly}, "violation"},
+		{"gate failure", newRollupPolicy(0, "", 2), []*WorkloadStatus{gateFailed}, "violation"},
+	}
+	for _, tt := range tests {
+		workloads := make([]WorkloadStatus, len(tt.workloads))
+		for i, w := range tt.workloads {
+			workloads[i] = *w
+		}
+		if got := tt.policy.status(workloads); got != tt.expected {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.expected, got)
+		}
+	}
+}
+
+// TestRollupCriticality tests that critical namespaces force a violation and
+// dev namespaces only degrade the status to warning
+func TestRollupCriticality(t *testing.T) {
+	criticality, err := parseCriticality("icu=critical, dev-*=dev, dev-shared=standard")
+	if err != nil {
+		t.Fatalf("Failed to parse criticality: %v", err)
+	}
+	policy := newRollupPolicy(1, "", 1)
+	policy.criticality = criticality
+
+	for ns, expected := range map[string]string{"icu": "critical", "dev-janine": "dev", "dev-shared": "standard", "radiology": "standard"} {
+		if got := policy.criticalityOf(ns); got != expected {
+			t.Errorf("Expected %s to be %s, got %s", ns, expected, got)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		workloads []WorkloadStatus
+		expected  string
+	}{
+		{"critical bypasses tolerance", []WorkloadStatus{*failedStatus("icu", "a")}, "violation"},
+		{"dev only warns", []WorkloadStatus{*failedStatus("dev-janine", "a"), *failedStatus("dev-bob", "b")}, "warning"},
+		{"standard within tolerance", []WorkloadStatus{*failedStatus("radiology", "a")}, "compliant"},
+		{"standard over tolerance", []WorkloadStatus{*failedStatus("radiology", "a"), *failedStatus("dev-shared", "b")}, "violation"},
+		{"standard and dev", []WorkloadStatus{*failedStatus("radiology", "a"), *failedStatus("dev-janine", "b")}, "warning"},
+	}
+	for _, tt := range tests {
+		if got := policy.status(tt.workloads); got != tt.expected {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.expected, got)
+		}
+	}
+
+	for _, spec := range []string{"icu", "icu=urgent", "[=dev"} {
+		if _, err := parseCriticality(spec); err == nil {
+			t.Errorf("Expected %q to be rejected", spec)
+		}
+	}
+}
+
+// TestHandleStatusCriticalBypassesDebounce tests that a critical violation
+// is shown before the debounce would let it through
+func TestHandleStatusCriticalBypassesDebounce(t *testing.T) {
+	criticality, _ := parseCriticality("icu=critical")
+	server := &Server{
+		debounce:    newStatusDebouncer(3, 1),
+		rollup:      rollupPolicy{criticality: criticality},
+		statusCache: map[string]*WorkloadStatus{"radiology/pacs": verifiedStatus("radiology", "pacs")},
+	}
+	server.observeOverallStatus()
+	decodeStatus := func() string {
+		w := httptest.NewRecorder()
+		server.handleStatus(w, httptest.NewRequest("GET", "/api/status", nil))
+		var response DashboardResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return response.OverallStatus
+	}
+
+	server.statusCache["radiology/pacs"] = failedStatus("radiology", "pacs")
+	server.observeOverallStatus()
+	if status := decodeStatus(); status != "compliant" {
+		t.Errorf("Expected standard violation to be debounced, got %s", status)
+	}
+
+	server.statusCache["icu/ai-model"] = failedStatus("icu", "ai-model")
+	server.observeOverallStatus()
+	if status := decodeStatus(); status != "violation" {
+		t.Errorf("Expected critical violation immediately, got %s", status)
+	}
+}
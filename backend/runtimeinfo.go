@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"sort"
+	"time"
+)
+
+// processStart is when the backend started, for uptime
+var processStart = time.Now()
+
+// runtimeHeap is a subset of runtime.MemStats useful for spotting leaks
+type runtimeHeap struct {
+	AllocBytes   uint64 `json:"alloc_bytes"`
+	InuseBytes   uint64 `json:"inuse_bytes"`
+	SysBytes     uint64 `json:"sys_bytes"`
+	Objects      uint64 `json:"objects"`
+	NumGC        uint32 `json:"num_gc"`
+	GCPauseTotal string `json:"gc_pause_total"`
+}
+
+// runtimePoller is the state of polling one Collector
+type runtimePoller struct {
+	Cluster   string     `json:"cluster"`
+	URL       string     `json:"url"`
+	LastSync  *time.Time `json:"last_sync,omitempty"`
+	LastError string     `json:"last_error,omitempty"`
+}
+
+// runtimeInfo is the response of GET /api/admin/runtime
+type runtimeInfo struct {
+	Uptime               string            `json:"uptime"`
+	Goroutines           int               `json:"goroutines"`
+	Heap                 runtimeHeap       `json:"heap"`
+	CacheGeneration      uint64            `json:"cache_generation"`
+	CachedWorkloads      int               `json:"cached_workloads"`
+	PollInterval         string            `json:"poll_interval"`
+	NamespaceIntervals   map[string]string `json:"namespace_poll_intervals,omitempty"`
+	Pollers              []runtimePoller   `json:"pollers"`
+	PendingNotifications int               `json:"pending_notifications"`
+	StreamSubscribers    int               `json:"stream_subscribers"`
+}
+
+// handleRuntime reports process and poller internals, for debugging where
+// there is no shell access to the container
+// GET /api/admin/runtime
+func (s *Server) handleRuntime(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if _, ok := requireAdmin(w, r); !ok {
+		return
+	}
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	info := runtimeInfo{
+		Uptime:     time.Since(processStart).Round(time.Second).String(),
+		Goroutines: runtime.NumGoroutine(),
+		Heap: runtimeHeap{
+			AllocBytes:   mem.HeapAlloc,
+			InuseBytes:   mem.HeapInuse,
+			SysBytes:     mem.Sys,
+			Objects:      mem.HeapObjects,
+			NumGC:        mem.NumGC,
+			GCPauseTotal: time.Duration(mem.PauseTotalNs).String(),
+		},
+		PollInterval:         s.pollInterval.String(),
+		NamespaceIntervals:   s.schedule.describe(),
+		Pollers:              []runtimePoller{},
+		PendingNotifications: s.notifier.Pending(),
+		StreamSubscribers:    s.stream.count(),
+	}
+
+	s.cacheMutex.RLock()
+	info.CacheGeneration = s.generation
+	info.CachedWorkloads = len(s.statusCache)
+	for _, cluster := range s.collectorTargets() {
+		poller := runtimePoller{Cluster: cluster.Name, URL: cluster.CollectorURL}
+		if state, ok := s.clusterState[cluster.Name]; ok {
+			if !state.LastSync.IsZero() {
+				lastSync := state.LastSync
+				poller.LastSync = &lastSync
+			}
+			poller.LastError = state.LastError
+		}
+		info.Pollers = append(info.Pollers, poller)
+	}
+	s.cacheMutex.RUnlock()
+	sort.Slice(info.Pollers, func(i, j int) bool { return info.Pollers[i].Cluster < info.Pollers[j].Cluster })
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(info)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestHandleRuntime tests that runtime internals are reported to admins only
+func TestHandleRuntime(t *testing.T) {
+	server := &Server{
+		collectorURL: "http://collector:8080",
+		localCluster: "east",
+		pollInterval: 30 * time.Second,
+		generation:   7,
+		stream:       newEventBroker(),
+		statusCache:  map[string]*WorkloadStatus{"icu/ai-model": failedStatus("icu", "ai-model")},
+	}
+	server.recordClusterSync("east", errors.New("connection refused"))
+	server.stream.subscribe(nil, "")
+
+	w := httptest.NewRecorder()
+	server.handleRuntime(w, ackRequestAs(&Identity{Name: "raj"}, "GET", "/api/admin/runtime", ""))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 without admin role, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	server.handleRuntime(w, ackRequestAs(&Identity{Name: "sre", Roles: []string{adminRole}}, "GET", "/api/admin/runtime", ""))
+	var info runtimeInfo
+	if err := json.NewDecoder(w.Body).Decode(&info); err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+	if info.Goroutines == 0 || info.Heap.AllocBytes == 0 || info.Uptime == "" {
+		t.Errorf("Expected process stats, got %+v", info)
+	}
+	if info.CacheGeneration != 7 || info.CachedWorkloads != 1 || info.StreamSubscribers != 1 || info.PollInterval != "30s" {
+		t.Errorf("Unexpected server state %+v", info)
+	}
+	if len(info.Pollers) != 1 || info.Pollers[0].Cluster != "east" || info.Pollers[0].LastError != "connection refused" || info.Pollers[0].LastSync != nil {
+		t.Errorf("Unexpected pollers %+v", info.Pollers)
+	}
+}
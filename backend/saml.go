@@ -0,0 +1,515 @@
+package main
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SAML 2.0 namespaces, bindings and status codes
+const (
+	samlAssertionNamespace = "urn:oasis:names:tc:SAML:2.0:assertion"
+	samlProtocolNamespace  = "urn:oasis:names:tc:SAML:2.0:protocol"
+	samlPostBinding        = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST"
+	samlBearerMethod       = "urn:oasis:names:tc:SAML:2.0:cm:bearer"
+	samlStatusSuccess      = "urn:oasis:names:tc:SAML:2.0:status:Success"
+)
+
+// samlClockSkew is the clock difference tolerated with the identity provider
+const samlClockSkew = 2 * time.Minute
+
+// samlRequestTTL is how long a login may take at the identity provider
+const samlRequestTTL = 10 * time.Minute
+
+// maxSAMLResponse bounds the decoded size of a posted SAML response
+const maxSAMLResponse = 256 << 10
+
+// sessionCookie carries the signed session of a SAML-authenticated browser
+const sessionCookie = "dashboard_session"
+
+// SAMLConfig configures the dashboard as a SAML 2.0 service provider
+type SAMLConfig struct {
+	EntityID           string `json:"entity_id"`            // this dashboard's entity ID
+	ACSURL             string `json:"acs_url"`              // public URL of /api/auth/saml/acs
+	IdPEntityID        string `json:"idp_entity_id"`        // expected assertion issuer
+	IdPSSOURL          string `json:"idp_sso_url"`          // IdP endpoint for the HTTP-Redirect binding
+	IdPCertificateFile string `json:"idp_certificate_file"` // PEM certificate the IdP signs with
+	// NameAttribute names the attribute used as the identity name; the
+	// subject's NameID when empty
+	NameAttribute string `json:"name_attribute,omitempty"`
+	// RoleAttribute names the attribute whose values are mapped to roles
+	// through AttributeRoles, e.g. "groups" or "Role"
+	RoleAttribute  string              `json:"role_attribute,omitempty"`
+	AttributeRoles map[string][]string `json:"attribute_roles,omitempty"`
+}
+
+// samlProvider implements SP-initiated login with the HTTP-Redirect binding
+// for requests and the HTTP-POST binding for responses. Only signed,
+// unencrypted assertions are accepted.
+type samlProvider struct {
+	config      SAMLConfig
+	certificate *x509.Certificate
+	sessions    *streamTokens
+	secure      bool // whether the session cookie is restricted to HTTPS
+
+	mu       sync.Mutex
+	requests map[string]time.Time // outstanding AuthnRequest IDs -> expiry
+	seen     map[string]time.Time // consumed assertion IDs -> expiry, against replay
+}
+
+// samlAssertion is the subset of a verified assertion the dashboard reads
+type samlAssertion struct {
+	XMLName xml.Name `xml:"urn:oasis:names:tc:SAML:2.0:assertion Assertion"`
+	ID      string   `xml:"ID,attr"`
+	Issuer  string   `xml:"Issuer"`
+	Subject struct {
+		NameID        string `xml:"NameID"`
+		Confirmations []struct {
+			Method string `xml:"Method,attr"`
+			Data   struct {
+				Recipient    string `xml:"Recipient,attr"`
+				InResponseTo string `xml:"InResponseTo,attr"`
+				NotOnOrAfter string `xml:"NotOnOrAfter,attr"`
+			} `xml:"SubjectConfirmationData"`
+		} `xml:"SubjectConfirmation"`
+	} `xml:"Subject"`
+	Conditions struct {
+		NotBefore    string   `xml:"NotBefore,attr"`
+		NotOnOrAfter string   `xml:"NotOnOrAfter,attr"`
+		Audiences    []string `xml:"AudienceRestriction>Audience"`
+	} `xml:"Conditions"`
+	Attributes []struct {
+		Name   string   `xml:"Name,attr"`
+		Values []string `xml:"AttributeValue"`
+	} `xml:"AttributeStatement>Attribute"`
+}
+
+// samlSignedResponse is a verified response, when the IdP signs the
+// response rather than the assertion
+type samlSignedResponse struct {
+	XMLName    xml.Name        `xml:"urn:oasis:names:tc:SAML:2.0:protocol Response"`
+	Assertions []samlAssertion `xml:"urn:oasis:names:tc:SAML:2.0:assertion Assertion"`
+}
+
+// loadSAMLProvider reads the service provider configuration from a JSON file
+func loadSAMLProvider(path string) (*samlProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var config SAMLConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("invalid SAML config: %w", err)
+	}
+	if config.EntityID == "" || config.ACSURL == "" || config.IdPEntityID == "" || config.IdPSSOURL == "" || config.IdPCertificateFile == "" {
+		return nil, errors.New("entity_id, acs_url, idp_entity_id, idp_sso_url and idp_certificate_file are required")
+	}
+
+	certPEM, err := os.ReadFile(config.IdPCertificateFile)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(certPEM)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("no certificate in %s", config.IdPCertificateFile)
+	}
+	certificate, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid IdP certificate: %w", err)
+	}
+	return newSAMLProvider(config, certificate), nil
+}
+
+func newSAMLProvider(config SAMLConfig, certificate *x509.Certificate) *samlProvider {
+	return &samlProvider{
+		config:      config,
+		certificate: certificate,
+		secure:      strings.HasPrefix(config.ACSURL, "https://"),
+		requests:    make(map[string]time.Time),
+		seen:        make(map[string]time.Time),
+	}
+}
+
+// isSAMLPath reports whether a path is part of the login flow, which must be
+// reachable without a session
+func isSAMLPath(path string) bool {
+	return strings.HasPrefix(path, "/api/auth/saml/")
+}
+
+// samlID returns a random identifier; XML IDs must not start with a digit
+func samlID() string {
+	b := make([]byte, 20)
+	rand.Read(b)
+	return "_" + hex.EncodeToString(b)
+}
+
+// authnRequestURL starts a login: it records a new request ID and returns the
+// IdP URL carrying the AuthnRequest
+func (p *samlProvider) authnRequestURL(relayState string, now time.Time) (string, error) {
+	id := samlID()
+	request := fmt.Sprintf(`<samlp:AuthnRequest xmlns:samlp="%s" xmlns:saml="%s" ID="%s" Version="2.0" IssueInstant="%s" Destination="%s" AssertionConsumerServiceURL="%s" ProtocolBinding="%s"><saml:Issuer>%s</saml:Issuer><samlp:NameIDPolicy AllowCreate="true"></samlp:NameIDPolicy></samlp:AuthnRequest>`,
+		samlProtocolNamespace, samlAssertionNamespace, id, now.UTC().Format(time.RFC3339),
+		xmlEscape(p.config.IdPSSOURL), xmlEscape(p.config.ACSURL), samlPostBinding, xmlEscape(p.config.EntityID))
+
+	var deflated bytes.Buffer
+	writer, _ := flate.NewWriter(&deflated, flate.DefaultCompression)
+	writer.Write([]byte(request))
+	writer.Close()
+
+	target, err := url.Parse(p.config.IdPSSOURL)
+	if err != nil {
+		return "", err
+	}
+	query := target.Query()
+	query.Set("SAMLRequest", base64.StdEncoding.EncodeToString(deflated.Bytes()))
+	if relayState != "" {
+		query.Set("RelayState", relayState)
+	}
+	target.RawQuery = query.Encode()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pruneExpired(p.requests, now)
+	p.requests[id] = now.Add(samlRequestTTL)
+	return target.String(), nil
+}
+
+func xmlEscape(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+func pruneExpired(m map[string]time.Time, now time.Time) {
+	for key, expires := range m {
+		if !now.Before(expires) {
+			delete(m, key)
+		}
+	}
+}
+
+// consume validates a base64 SAMLResponse and returns the identity it asserts
+func (p *samlProvider) consume(encoded string, now time.Time) (*Identity, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(encoded), ""))
+	if err != nil {
+		return nil, errors.New("SAMLResponse is not base64")
+	}
+	if len(data) > maxSAMLResponse {
+		return nil, errors.New("SAMLResponse too large")
+	}
+
+	response, err := parseXMLTree(data)
+	if err != nil {
+		return nil, fmt.Errorf("malformed response: %w", err)
+	}
+	if !response.is(samlProtocolNamespace, "Response") {
+		return nil, errors.New("not a SAML response")
+	}
+	if destination := response.attr("Destination"); destination != "" && destination != p.config.ACSURL {
+		return nil, fmt.Errorf("response is destined for %s", destination)
+	}
+	if status, err := response.child(samlProtocolNamespace, "Status"); err != nil {
+		return nil, err
+	} else if code, err := status.child(samlProtocolNamespace, "StatusCode"); err != nil {
+		return nil, err
+	} else if value := code.attr("Value"); value != samlStatusSuccess {
+		return nil, fmt.Errorf("identity provider returned status %s", value)
+	}
+
+	// Duplicate IDs are how signature wrapping smuggles a second assertion
+	// past a verified reference
+	ids := make(map[string]int)
+	countIDs(response, ids)
+	for id, count := range ids {
+		if count > 1 {
+			return nil, fmt.Errorf("duplicate ID %q", id)
+		}
+	}
+
+	if len(response.childElements(samlAssertionNamespace, "EncryptedAssertion")) > 0 {
+		return nil, errors.New("encrypted assertions are not supported")
+	}
+	element, err := response.child(samlAssertionNamespace, "Assertion")
+	if err != nil {
+		return nil, err
+	}
+
+	var assertion samlAssertion
+	if len(element.childElements(dsigNamespace, "Signature")) > 0 {
+		signed, err := verifyEnvelopedSignature(element, p.certificate)
+		if err != nil {
+			return nil, fmt.Errorf("assertion signature: %w", err)
+		}
+		if err := xml.Unmarshal(signed, &assertion); err != nil {
+			return nil, err
+		}
+	} else {
+		signed, err := verifyEnvelopedSignature(response, p.certificate)
+		if err != nil {
+			return nil, fmt.Errorf("response signature: %w", err)
+		}
+		var verified samlSignedResponse
+		if err := xml.Unmarshal(signed, &verified); err != nil {
+			return nil, err
+		}
+		if len(verified.Assertions) != 1 {
+			return nil, errors.New("expected one assertion")
+		}
+		assertion = verified.Assertions[0]
+	}
+
+	if err := p.validate(&assertion, now); err != nil {
+		return nil, err
+	}
+	return p.identity(&assertion)
+}
+
+// validate checks the assertion's issuer, validity window, audience and
+// bearer confirmation, and guards against replay
+func (p *samlProvider) validate(assertion *samlAssertion, now time.Time) error {
+	if assertion.Issuer != p.config.IdPEntityID {
+		return fmt.Errorf("unexpected issuer %q", assertion.Issuer)
+	}
+
+	conditions := assertion.Conditions
+	if notBefore, err := parseSAMLTime(conditions.NotBefore); err != nil {
+		return err
+	} else if !notBefore.IsZero() && now.Add(samlClockSkew).Before(notBefore) {
+		return errors.New("assertion is not yet valid")
+	}
+	expires, err := parseSAMLTime(conditions.NotOnOrAfter)
+	if err != nil {
+		return err
+	}
+	if !expires.IsZero() && !now.Add(-samlClockSkew).Before(expires) {
+		return errors.New("assertion has expired")
+	}
+	if !containsString(conditions.Audiences, p.config.EntityID) {
+		return fmt.Errorf("assertion is not addressed to %s", p.config.EntityID)
+	}
+
+	var inResponseTo string
+	confirmed := false
+	for _, confirmation := range assertion.Subject.Confirmations {
+		data := confirmation.Data
+		if confirmation.Method != samlBearerMethod || data.Recipient != p.config.ACSURL {
+			continue
+		}
+		notOnOrAfter, err := parseSAMLTime(data.NotOnOrAfter)
+		if err != nil || notOnOrAfter.IsZero() || !now.Add(-samlClockSkew).Before(notOnOrAfter) {
+			continue
+		}
+		if expires.IsZero() || notOnOrAfter.Before(expires) {
+			expires = notOnOrAfter
+		}
+		inResponseTo = data.InResponseTo
+		confirmed = true
+		break
+	}
+	if !confirmed {
+		return errors.New("no valid bearer subject confirmation for this service provider")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pruneExpired(p.requests, now)
+	pruneExpired(p.seen, now)
+	// Unsolicited (IdP-initiated) responses are rejected: they can't be
+	// tied to a login started in this browser
+	if _, ok := p.requests[inResponseTo]; !ok {
+		return errors.New("response does not answer a pending login request")
+	}
+	if _, ok := p.seen[assertion.ID]; ok || assertion.ID == "" {
+		return errors.New("assertion has already been used")
+	}
+	delete(p.requests, inResponseTo)
+	p.seen[assertion.ID] = expires.Add(samlClockSkew)
+	return nil
+}
+
+func parseSAMLTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q", value)
+	}
+	return t, nil
+}
+
+// identity maps the assertion's subject and attributes to a dashboard identity
+func (p *samlProvider) identity(assertion *samlAssertion) (*Identity, error) {
+	identity := &Identity{Name: strings.TrimSpace(assertion.Subject.NameID)}
+	for _, attribute := range assertion.Attributes {
+		switch attribute.Name {
+		case p.config.NameAttribute:
+			if len(attribute.Values) > 0 {
+				identity.Name = strings.TrimSpace(attribute.Values[0])
+			}
+		case p.config.RoleAttribute:
+			for _, value := range attribute.Values {
+				for _, role := range p.config.AttributeRoles[strings.TrimSpace(value)] {
+					if !containsString(identity.Roles, role) {
+						identity.Roles = append(identity.Roles, role)
+					}
+				}
+			}
+		}
+	}
+	if identity.Name == "" {
+		return nil, errors.New("assertion names no subject")
+	}
+	return identity, nil
+}
+
+// samlMetadata is the service provider's metadata document
+type samlMetadata struct {
+	XMLName  xml.Name `xml:"urn:oasis:names:tc:SAML:2.0:metadata EntityDescriptor"`
+	EntityID string   `xml:"entityID,attr"`
+	SP       struct {
+		AuthnRequestsSigned  bool   `xml:"AuthnRequestsSigned,attr"`
+		WantAssertionsSigned bool   `xml:"WantAssertionsSigned,attr"`
+		Protocols            string `xml:"protocolSupportEnumeration,attr"`
+		ACS                  struct {
+			Binding  string `xml:"Binding,attr"`
+			Location string `xml:"Location,attr"`
+			Index    int    `xml:"index,attr"`
+		} `xml:"AssertionConsumerService"`
+	} `xml:"SPSSODescriptor"`
+}
+
+// handleSAMLMetadata serves the service provider metadata to register with
+// the identity provider
+// GET /api/auth/saml/metadata
+func (s *Server) handleSAMLMetadata(w http.ResponseWriter, r *http.Request) {
+	if s.saml == nil {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var metadata samlMetadata
+	metadata.EntityID = s.saml.config.EntityID
+	metadata.SP.WantAssertionsSigned = true
+	metadata.SP.Protocols = samlProtocolNamespace
+	metadata.SP.ACS.Binding = samlPostBinding
+	metadata.SP.ACS.Location = s.saml.config.ACSURL
+
+	w.Header().Set("Content-Type", "application/samlmetadata+xml")
+	w.Write([]byte(xml.Header))
+	if err := xml.NewEncoder(w).Encode(metadata); err != nil {
+		log.Printf("Failed to encode SAML metadata: %v", err)
+	}
+}
+
+// handleSAMLLogin redirects the browser to the identity provider. An optional
+// local path to return to after login is passed as ?return_to=
+// GET /api/auth/saml/login
+func (s *Server) handleSAMLLogin(w http.ResponseWriter, r *http.Request) {
+	if s.saml == nil {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	target, err := s.saml.authnRequestURL(localPath(r.URL.Query().Get("return_to")), time.Now())
+	if err != nil {
+		log.Printf("Failed to build SAML request: %v", err)
+		http.Error(w, "failed to start login", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+// handleSAMLACS consumes the identity provider's response, starts a session
+// and sends the browser back to the dashboard
+// POST /api/auth/saml/acs
+func (s *Server) handleSAMLACS(w http.ResponseWriter, r *http.Request) {
+	if s.saml == nil {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, 2*maxSAMLResponse)
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "invalid form", http.StatusBadRequest)
+		return
+	}
+	now := time.Now()
+	identity, err := s.saml.consume(r.PostForm.Get("SAMLResponse"), now)
+	if err != nil {
+		log.Printf("Rejected SAML response: %v", err)
+		http.Error(w, "login failed", http.StatusForbidden)
+		return
+	}
+
+	token, expires := s.saml.sessions.issue(identity, now)
+	// SameSite=Lax keeps the cookie off cross-site POSTs, so it can't be used
+	// to forge acknowledgements
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    token,
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   s.saml.secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+	s.audit.RecordRequest(r, identity.Name, "auth.login", "saml", strings.Join(identity.Roles, ","))
+
+	target := localPath(r.PostForm.Get("RelayState"))
+	if target == "" {
+		target = "/"
+	}
+	http.Redirect(w, r, target, http.StatusSeeOther)
+}
+
+// handleSAMLLogout ends and revokes the browser's dashboard session. The
+// identity provider's own session is left alone.
+// POST /api/auth/saml/logout
+func (s *Server) handleSAMLLogout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if claims := s.endSession(w, r); claims != nil {
+		s.audit.RecordRequest(r, claims.Subject, "auth.logout", "saml", "")
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// localPath returns path if it is a path on this host, so RelayState can't
+// redirect to another site
+func localPath(path string) string {
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") || strings.HasPrefix(path, "/\\") {
+		return ""
+	}
+	return path
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testSPEntityID  = "https://dashboard.hospital.org/saml"
+	testACSURL      = "https://dashboard.hospital.org/api/auth/saml/acs"
+	testIdPEntityID = "https://idp.health.gov/saml"
+)
+
+// samlTestIdP signs responses for a service provider under test
+type samlTestIdP struct {
+	key      *rsa.PrivateKey
+	provider *samlProvider
+}
+
+func newSAMLTestIdP(t *testing.T) *samlTestIdP {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "idp.health.gov"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	certificate, _ := x509.ParseCertificate(der)
+
+	provider := newSAMLProvider(SAMLConfig{
+		EntityID:       testSPEntityID,
+		ACSURL:         testACSURL,
+		IdPEntityID:    testIdPEntityID,
+		IdPSSOURL:      "https://idp.health.gov/sso",
+		RoleAttribute:  "groups",
+		AttributeRoles: map[string][]string{"dashboard-admins": {adminRole}},
+	}, certificate)
+	provider.sessions, _ = newStreamTokens("", time.Hour)
+	return &samlTestIdP{key: key, provider: provider}
+}
+
+// startLogin begins a login and returns the AuthnRequest ID sent to the IdP
+func (idp *samlTestIdP) startLogin(t *testing.T) string {
+	t.Helper()
+	target, err := idp.provider.authnRequestURL("/", time.Now())
+	if err != nil {
+		t.Fatalf("Failed to build login URL: %v", err)
+	}
+	u, _ := url.Parse(target)
+	deflated, _ := base64.StdEncoding.DecodeString(u.Query().Get("SAMLRequest"))
+	request, _ := io.ReadAll(flate.NewReader(bytes.NewReader(deflated)))
+	match := regexp.MustCompile(`ID="([^"]+)"`).FindSubmatch(request)
+	if match == nil || !strings.Contains(string(request), testACSURL) {
+		t.Fatalf("Unexpected AuthnRequest %s", request)
+	}
+	return string(match[1])
+}
+
+// samlTestResponse returns a response to requestID with a {{sig}} marker
+// after the assertion's and a {{response-sig}} marker after the response's
+// issuer
+func samlTestResponse(requestID, nameID, audience string, now time.Time) string {
+	later := now.Add(5 * time.Minute).UTC().Format(time.RFC3339)
+	return `<samlp:Response xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol" xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion" ID="_response" Version="2.0" Destination="` + testACSURL + `" InResponseTo="` + requestID + `">
+  <saml:Issuer>` + testIdPEntityID + `</saml:Issuer>{{response-sig}}
+  <samlp:Status><samlp:StatusCode Value="urn:oasis:names:tc:SAML:2.0:status:Success"/></samlp:Status>
+  <saml:Assertion ID="_assertion" Version="2.0" IssueInstant="` + now.UTC().Format(time.RFC3339) + `">
+    <saml:Issuer>` + testIdPEntityID + `</saml:Issuer>{{sig}}
+    <saml:Subject>
+      <saml:NameID Format="urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress">` + nameID + `</saml:NameID>
+      <saml:SubjectConfirmation Method="urn:oasis:names:tc:SAML:2.0:cm:bearer">
+        <saml:SubjectConfirmationData Recipient="` + testACSURL + `" InResponseTo="` + requestID + `" NotOnOrAfter="` + later + `"/>
+      </saml:SubjectConfirmation>
+    </saml:Subject>
+    <saml:Conditions NotBefore="` + now.Add(-time.Minute).UTC().Format(time.RFC3339) + `" NotOnOrAfter="` + later + `">
+      <saml:AudienceRestriction><saml:Audience>` + audience + `</saml:Audience></saml:AudienceRestriction>
+    </saml:Conditions>
+    <saml:AttributeStatement>
+      <saml:Attribute Name="groups"><saml:AttributeValue>nurses</saml:AttributeValue><saml:AttributeValue>dashboard-admins</saml:AttributeValue></saml:Attribute>
+    </saml:AttributeStatement>
+  </saml:Assertion>
+</samlp:Response>`
+}
+
+// sign replaces marker with an enveloped signature over the element with the
+// given ID, and removes any other markers
+func (idp *samlTestIdP) sign(t *testing.T, doc, marker, id string) string {
+	t.Helper()
+	unsigned := strings.NewReplacer("{{sig}}", "", "{{response-sig}}", "").Replace(doc)
+	root, err := parseXMLTree([]byte(unsigned))
+	if err != nil {
+		t.Fatalf("Failed to parse test response: %v", err)
+	}
+	element := findXMLID(root, id)
+	if element == nil {
+		t.Fatalf("No element with ID %s", id)
+	}
+	digest := sha256.Sum256(canonicalize(element, nil, nil))
+
+	signedInfo := `<ds:SignedInfo xmlns:ds="http://www.w3.org/2000/09/xmldsig#">` +
+		`<ds:CanonicalizationMethod Algorithm="http://www.w3.org/2001/10/xml-exc-c14n#"/>` +
+		`<ds:SignatureMethod Algorithm="http://www.w3.org/2001/04/xmldsig-more#rsa-sha256"/>` +
+		`<ds:Reference URI="#` + id + `"><ds:Transforms>` +
+		`<ds:Transform Algorithm="http://www.w3.org/2000/09/xmldsig#enveloped-signature"/>` +
+		`<ds:Transform Algorithm="http://www.w3.org/2001/10/xml-exc-c14n#"/>` +
+		`</ds:Transforms><ds:DigestMethod Algorithm="http://www.w3.org/2001/04/xmlenc#sha256"/>` +
+		`<ds:DigestValue>` + base64.StdEncoding.EncodeToString(digest[:]) + `</ds:DigestValue></ds:Reference></ds:SignedInfo>`
+	signedInfoNode, err := parseXMLTree([]byte(signedInfo))
+	if err != nil {
+		t.Fatalf("Failed to parse SignedInfo: %v", err)
+	}
+	signedInfoDigest := sha256.Sum256(canonicalize(signedInfoNode, nil, nil))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, idp.key, crypto.SHA256, signedInfoDigest[:])
+	if err != nil {
+		t.Fatalf("Failed to sign: %v", err)
+	}
+
+	envelope := `<ds:Signature xmlns:ds="http://www.w3.org/2000/09/xmldsig#">` +
+		strings.Replace(signedInfo, ` xmlns:ds="http://www.w3.org/2000/09/xmldsig#"`, "", 1) +
+		`<ds:SignatureValue>` + base64.StdEncoding.EncodeToString(signature) + `</ds:SignatureValue></ds:Signature>`
+	doc = strings.Replace(doc, marker, envelope, 1)
+	return strings.NewReplacer("{{sig}}", "", "{{response-sig}}", "").Replace(doc)
+}
+
+func findXMLID(n *xmlNode, id string) *xmlNode {
+	if n.attr("ID") == id {
+		return n
+	}
+	for _, child := range n.children {
+		if child.node != nil {
+			if found := findXMLID(child.node, id); found != nil {
+				return found
+			}
+		}
+	}
+	return nil
+}
+
+func encodeSAML(doc string) string {
+	return base64.StdEncoding.EncodeToString([]byte(doc))
+}
+
+// TestSAMLConsume tests that a signed assertion answering a pending login
+// yields an identity with roles mapped from its attributes, only once
+func TestSAMLConsume(t *testing.T) {
+	idp := newSAMLTestIdP(t)
+	now := time.Now()
+	requestID := idp.startLogin(t)
+	response := idp.sign(t, samlTestResponse(requestID, "raj@hospital.org", testSPEntityID, now), "{{sig}}", "_assertion")
+
+	identity, err := idp.provider.consume(encodeSAML(response), now)
+	if err != nil {
+		t.Fatalf("Expected valid response, got %v", err)
+	}
+	if identity.Name != "raj@hospital.org" || len(identity.Roles) != 1 || identity.Roles[0] != adminRole {
+		t.Errorf("Expected raj@hospital.org with admin role, got %+v", identity)
+	}
+
+	if _, err := idp.provider.consume(encodeSAML(response), now); err == nil {
+		t.Error("Expected replayed response to be rejected")
+	}
+}
+
+// TestSAMLConsumeSignedResponse tests responses signed as a whole rather
+// than per assertion
+func TestSAMLConsumeSignedResponse(t *testing.T) {
+	idp := newSAMLTestIdP(t)
+	now := time.Now()
+	response := idp.sign(t, samlTestResponse(idp.startLogin(t), "raj@hospital.org", testSPEntityID, now), "{{response-sig}}", "_response")
+
+	if _, err := idp.provider.consume(encodeSAML(response), now); err != nil {
+		t.Errorf("Expected valid response, got %v", err)
+	}
+}
+
+// TestSAMLConsumeRejects tests that forged, misaddressed, expired and
+// unsolicited responses are refused
+func TestSAMLConsumeRejects(t *testing.T) {
+	idp := newSAMLTestIdP(t)
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		response func() string
+		at       time.Time
+		reason   string
+	}{
+		{"unsigned", func() string {
+			return strings.NewReplacer("{{sig}}", "", "{{response-sig}}", "").Replace(samlTestResponse(idp.startLogin(t), "raj@hospital.org", testSPEntityID, now))
+		}, now, "signature"},
+		{"tampered", func() string {
+			signed := idp.sign(t, samlTestResponse(idp.startLogin(t), "raj@hospital.org", testSPEntityID, now), "{{sig}}", "_assertion")
+			return strings.Replace(signed, "raj@hospital.org", "mallory@hospital.org", 1)
+		}, now, "digest mismatch"},
+		{"wrapped", func() string {
+			signed := idp.sign(t, samlTestResponse(idp.startLogin(t), "raj@hospital.org", testSPEntityID, now), "{{sig}}", "_assertion")
+			start, end := strings.Index(signed, "<saml:Assertion"), strings.Index(signed, "</saml:Assertion>")+len("</saml:Assertion>")
+			original := signed[start:end]
+			forged := strings.Replace(original, "raj@hospital.org", "mallory@hospital.org", 1)
+			forged = regexp.MustCompile(`(?s)<ds:Signature.*</ds:Signature>`).ReplaceAllString(forged, "")
+			return signed[:start] + forged + "<samlp:Extensions>" + original + "</samlp:Extensions>" + signed[end:]
+		}, now, "duplicate ID"},
+		{"wrong audience", func() string {
+			return idp.sign(t, samlTestResponse(idp.startLogin(t), "raj@hospital.org", "https://other.example", now), "{{sig}}", "_assertion")
+		}, now, "not addressed"},
+		{"expired", func() string {
+			return idp.sign(t, samlTestResponse(idp.startLogin(t), "raj@hospital.org", testSPEntityID, now), "{{sig}}", "_assertion")
+		}, now.Add(time.Hour), "expired"},
+		{"unsolicited", func() string {
+			return idp.sign(t, samlTestResponse("_never-requested", "raj@hospital.org", testSPEntityID, now), "{{sig}}", "_assertion")
+		}, now, "pending login"},
+	}
+
+	for _, tt := range tests {
+		_, err := idp.provider.consume(encodeSAML(tt.response()), tt.at)
+		if err == nil || !strings.Contains(err.Error(), tt.reason) {
+			t.Errorf("%s: Expected error containing %q, got %v", tt.name, tt.reason, err)
+		}
+	}
+}
+
+// TestSAMLLoginFlow tests that the ACS endpoint starts a session whose cookie
+// authenticates API requests, and that the metadata names the ACS
+func TestSAMLLoginFlow(t *testing.T) {
+	idp := newSAMLTestIdP(t)
+	server := &Server{saml: idp.provider, auth: &Authenticator{sessions: idp.provider.sessions}}
+
+	now := time.Now()
+	response := idp.sign(t, samlTestResponse(idp.startLogin(t), "raj@hospital.org", testSPEntityID, now), "{{sig}}", "_assertion")
+	form := url.Values{"SAMLResponse": {encodeSAML(response)}, "RelayState": {"//evil.example"}}
+	req := httptest.NewRequest("POST", "/api/auth/saml/acs", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	server.handleSAMLACS(w, req)
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/" {
+		t.Fatalf("Expected redirect to /, got %d %s", w.Code, w.Header().Get("Location"))
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != sessionCookie || !cookies[0].HttpOnly || !cookies[0].Secure {
+		t.Fatalf("Expected secure session cookie, got %+v", cookies)
+	}
+
+	var seen *Identity
+	handler := server.authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = identityFromContext(r.Context())
+	}))
+	req = httptest.NewRequest("GET", "/api/status", nil)
+	req.AddCookie(cookies[0])
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || seen == nil || seen.Name != "raj@hospital.org" || !seen.hasRole(adminRole) {
+		t.Errorf("Expected session to authenticate raj@hospital.org as admin, got %d %+v", w.Code, seen)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/auth/saml/metadata", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected metadata without a session, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	server.handleSAMLMetadata(w, httptest.NewRequest("GET", "/api/auth/saml/metadata", nil))
+	if body := w.Body.String(); !strings.Contains(body, `entityID="`+testSPEntityID+`"`) || !strings.Contains(body, `Location="`+testACSURL+`"`) {
+		t.Errorf("Unexpected metadata %s", body)
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// pollSchedule evaluates namespaces at their own intervals, e.g. the ICU
+// every 10s and dev every 2m. The poller ticks at the greatest common
+// divisor of all intervals; each cycle only the namespaces that are due are
+// re-evaluated, along with pinned workloads, and the cached entries of the
+// others are kept. The Collector API can't be filtered by namespace, so every
+// cycle with anything due fetches all reports.
+type pollSchedule struct {
+	base      time.Duration            // interval of namespaces without an entry
+	intervals map[string]time.Duration // namespace or pattern -> interval
+
+	mu   sync.Mutex
+	next map[string]time.Time // entry ("" for the base interval) -> next evaluation
+	due  map[string]bool      // entries being evaluated this cycle
+}
+
+// parsePollIntervals parses NAMESPACE_POLL_INTERVALS, e.g. "icu=10s,dev-*=2m".
+// Returns nil if no namespace has its own interval.
+func parsePollIntervals(spec string, base time.Duration) (*pollSchedule, error) {
+	intervals := make(map[string]time.Duration)
+	for _, entry := range strings.Split(spec, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		namespace, value, ok := strings.Cut(entry, "=")
+		namespace, value = strings.TrimSpace(namespace), strings.TrimSpace(value)
+		if !ok || namespace == "" {
+			return nil, fmt.Errorf("invalid poll interval entry %q, expected namespace=interval", entry)
+		}
+		if _, err := path.Match(namespace, ""); err != nil {
+			return nil, fmt.Errorf("invalid namespace pattern %q", namespace)
+		}
+		interval, err := time.ParseDuration(value)
+		if err != nil || interval < time.Second {
+			return nil, fmt.Errorf("invalid poll interval %q for %s, expected a duration of at least 1s", value, namespace)
+		}
+		intervals[namespace] = interval
+	}
+	if len(intervals) == 0 {
+		return nil, nil
+	}
+	return &pollSchedule{base: base, intervals: intervals, next: make(map[string]time.Time)}, nil
+}
+
+// tick returns how often the poller must run to serve every interval
+func (ps *pollSchedule) tick() time.Duration {
+	tick := ps.base
+	for _, interval := range ps.intervals {
+		a, b := tick, interval
+		for b != 0 {
+			a, b = b, a%b
+		}
+		tick = a
+	}
+	if tick < time.Second {
+		return time.Second
+	}
+	return tick
+}
+
+// entryOf returns the entry deciding a namespace's interval: an exact entry
+// wins over patterns, and among patterns the shortest interval wins
+func (ps *pollSchedule) entryOf(namespace string) string {
+	if _, ok := ps.intervals[namespace]; ok {
+		return namespace
+	}
+	entry := ""
+	for pattern, interval := range ps.intervals {
+		if matched, _ := path.Match(pattern, namespace); !matched {
+			continue
+		}
+		if entry == "" || interval < ps.intervals[entry] || interval == ps.intervals[entry] && pattern < entry {
+			entry = pattern
+		}
+	}
+	return entry
+}
+
+// intervalOf returns the interval of an entry
+func (ps *pollSchedule) intervalOf(entry string) time.Duration {
+	if entry == "" {
+		return ps.base
+	}
+	return ps.intervals[entry]
+}
+
+// begin starts a cycle and reports whether any namespace is due. Being less
+// than a tick early counts as due, so jitter doesn't skip a cycle. A nil
+// schedule evaluates everything every cycle.
+func (ps *pollSchedule) begin(now time.Time) bool {
+	if ps == nil {
+		return true
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.due = make(map[string]bool)
+	slack := ps.tick() / 2
+	for _, entry := range append(ps.entries(), "") {
+		if next, ok := ps.next[entry]; ok && next.Sub(now) > slack {
+			continue
+		}
+		ps.due[entry] = true
+		ps.next[entry] = now.Add(ps.intervalOf(entry))
+	}
+	return len(ps.due) > 0
+}
+
+// reset makes every namespace due in the next cycle
+func (ps *pollSchedule) reset() {
+	if ps == nil {
+		return
+	}
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.next = make(map[string]time.Time)
+}
+
+// evaluates reports whether a namespace is re-evaluated this cycle
+func (ps *pollSchedule) evaluates(namespace string) bool {
+	if ps == nil {
+		return true
+	}
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	return ps.due[ps.entryOf(namespace)]
+}
+
+// evaluates reports whether a workload is re-evaluated this cycle: when its
+// namespace is due, and every cycle while it is pinned
+func (s *Server) evaluates(namespace, name string) bool {
+	return s.schedule.evaluates(namespace) || s.watchlist.Pinned(namespace+"/"+name, time.Now())
+}
+
+// dueReports returns the reports of the workloads re-evaluated this cycle
+func (s *Server) dueReports(reports []CollectorReport) []CollectorReport {
+	if s.schedule == nil {
+		return reports
+	}
+	due := reports[:0]
+	for _, report := range reports {
+		if s.evaluates(report.Namespace, report.PodName) {
+			due = append(due, report)
+		}
+	}
+	return due
+}
+
+// entries returns the configured namespaces and patterns, sorted
+func (ps *pollSchedule) entries() []string {
+	entries := make([]string, 0, len(ps.intervals))
+	for entry := range ps.intervals {
+		entries = append(entries, entry)
+	}
+	sort.Strings(entries)
+	return entries
+}
+
+// describe lists the intervals, e.g. for the runtime endpoint
+func (ps *pollSchedule) describe() map[string]string {
+	if ps == nil {
+		return nil
+	}
+	described := map[string]string{"*": ps.base.String()}
+	for entry, interval := range ps.intervals {
+		described[entry] = interval.String()
+	}
+	return described
+}
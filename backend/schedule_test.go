@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestParsePollIntervals tests the NAMESPACE_POLL_INTERVALS format and the
+// resulting tick
+func TestParsePollIntervals(t *testing.T) {
+	if schedule, err := parsePollIntervals("", 30*time.Second); schedule != nil || err != nil {
+		t.Errorf("Expected no schedule without entries, got %v, %v", schedule, err)
+	}
+
+	schedule, err := parsePollIntervals("icu=10s, dev-*=2m", 30*time.Second)
+	if err != nil {
+		t.Fatalf("Failed to parse intervals: %v", err)
+	}
+	if tick := schedule.tick(); tick != 10*time.Second {
+		t.Errorf("Expected a 10s tick, got %s", tick)
+	}
+	if schedule, _ := parsePollIntervals("icu=45s", 30*time.Second); schedule.tick() != 15*time.Second {
+		t.Errorf("Expected a 15s tick for 45s and 30s, got %s", schedule.tick())
+	}
+
+	for _, spec := range []string{"icu", "icu=fast", "icu=100ms", "[=10s"} {
+		if _, err := parsePollIntervals(spec, 30*time.Second); err == nil {
+			t.Errorf("Expected %q to be rejected", spec)
+		}
+	}
+}
+
+// TestPollScheduleEntryOf tests that exact entries win over patterns, and
+// the shortest interval among patterns
+func TestPollScheduleEntryOf(t *testing.T) {
+	schedule, _ := parsePollIntervals("icu-*=20s,icu-ai=10s,*-ai=15s,dev-*=2m", 30*time.Second)
+	tests := map[string]string{
+		"icu-ai":     "icu-ai",
+		"icu-beds":   "icu-*",
+		"oncology":   "",
+		"dev-ai":     "*-ai",
+		"dev-portal": "dev-*",
+	}
+	for namespace, expected := range tests {
+		if got := schedule.entryOf(namespace); got != expected {
+			t.Errorf("Expected %s to use entry %q, got %q", namespace, expected, got)
+		}
+	}
+}
+
+// TestPollScheduleBegin tests which namespaces are due in successive cycles
+func TestPollScheduleBegin(t *testing.T) {
+	schedule, _ := parsePollIntervals("icu=10s,dev=1m", 30*time.Second)
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	due := func(at time.Duration) []string {
+		var namespaces []string
+		if !schedule.begin(start.Add(at)) {
+			return nil
+		}
+		for _, namespace := range []string{"icu", "radiology", "dev"} {
+			if schedule.evaluates(namespace) {
+				namespaces = append(namespaces, namespace)
+			}
+		}
+		return namespaces
+	}
+
+	if got := due(0); len(got) != 3 {
+		t.Errorf("Expected every namespace in the first cycle, got %v", got)
+	}
+	if got := due(10 * time.Second); len(got) != 1 || got[0] != "icu" {
+		t.Errorf("Expected only icu after 10s, got %v", got)
+	}
+	// A tick arriving slightly early still counts
+	if got := due(30*time.Second - 200*time.Millisecond); len(got) != 2 || got[1] != "radiology" {
+		t.Errorf("Expected icu and radiology after 30s, got %v", got)
+	}
+	if got := due(60 * time.Second); len(got) != 3 {
+		t.Errorf("Expected every namespace after a minute, got %v", got)
+	}
+
+	schedule.reset()
+	if got := due(61 * time.Second); len(got) != 3 {
+		t.Errorf("Expected every namespace after a refresh, got %v", got)
+	}
+}
+
+// TestFetchEvaluatesDueNamespaces tests that namespaces not due keep their
+// cached entry while due ones are re-evaluated
+func TestFetchEvaluatesDueNamespaces(t *testing.T) {
+	attested := true
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]CollectorReport{
+			{PodName: "ai-model", Namespace: "icu", Attested: attested, Timestamp: time.Now()},
+			{PodName: "portal", Namespace: "dev", Attested: attested, Timestamp: time.Now()},
+		})
+	}))
+	defer collector.Close()
+
+	schedule, _ := parsePollIntervals("icu=10s", time.Hour)
+	server := &Server{collectorURL: collector.URL, httpClient: collector.Client(), schedule: schedule}
+	server.fetchFromCollector()
+
+	attested = false
+	schedule.mu.Lock()
+	schedule.next["icu"] = time.Now()
+	schedule.mu.Unlock()
+	server.fetchFromCollector()
+
+	if status := server.statusCache["icu/ai-model"]; status == nil || status.AttestationStatus != "failed" {
+		t.Errorf("Expected icu to be re-evaluated, got %+v", status)
+	}
+	if status := server.statusCache["dev/portal"]; status == nil || status.AttestationStatus != "verified" {
+		t.Errorf("Expected dev to keep its cached entry until due, got %+v", status)
+	}
+}
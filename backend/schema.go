@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/rh-summit-coco/raj-hospital-dashboard/backend/pkg/api"
+)
+
+// maxRequestBody bounds the size of JSON request bodies
+const maxRequestBody = 1 << 20
+
+// Schemas for the payloads the backend accepts. Collector reports may carry
+// fields the dashboard doesn't use, so they alone allow unknown properties.
+var (
+	ackRequestSchema       = publishSchema(api.JSONSchema(AckRequest{}, true))
+	batchAckSchema         = publishSchema(api.JSONSchema(BatchAckRequest{}, true))
+	annotationsSchema      = publishSchema(api.JSONSchema(AnnotationsRequest{}, true))
+	collectorReportSchema  = publishSchema(api.JSONSchema(CollectorReport{}, false))
+	expectedWorkloadSchema = publishSchema(api.JSONSchema(ExpectedWorkloadRequest{}, true))
+	watchSchema            = publishSchema(api.JSONSchema(WatchRequest{}, true))
+	suppressSchema         = publishSchema(api.JSONSchema(SuppressRequest{}, true))
+	shareSchema            = publishSchema(api.JSONSchema(ShareRequest{}, true))
+	collectorSchema        = publishSchema(api.JSONSchema(ClusterConfig{}, true))
+	downtimeSchema         = publishSchema(api.JSONSchema(DowntimeRequest{}, true))
+	policySchema           = publishSchema(api.JSONSchema(Policy{}, true))
+	policyVersionSchema    = publishSchema(api.JSONSchema(policyVersionRequest{}, true))
+	benchmarkSchema        = publishSchema(api.JSONSchema(benchmarkRequest{}, true))
+)
+
+// jsonSchemas are served at /api/schemas/{type}, keyed by type name
+var jsonSchemas = map[string]*api.Schema{}
+
+// mutationSchemas describe request bodies of endpoints that are disabled, and
+// so not published, in read-only mode
+var mutationSchemas = map[string]bool{
+	"AckRequest":              true,
+	"BatchAckRequest":         true,
+	"AnnotationsRequest":      true,
+	"ExpectedWorkloadRequest": true,
+	"WatchRequest":            true,
+	"SuppressRequest":         true,
+	"ShareRequest":            true,
+	"ClusterConfig":           true,
+	"DowntimeRequest":         true,
+	"policyVersionRequest":    true,
+	"benchmarkRequest":        true,
+}
+
+func init() {
+	for _, v := range api.Types {
+		schema := api.JSONSchema(v, false)
+		if _, ok := jsonSchemas[schema.Title]; !ok {
+			publishSchema(schema)
+		}
+	}
+}
+
+// publishSchema registers a schema under its title and sets its $id
+func publishSchema(schema *api.Schema) *api.Schema {
+	schema.ID = "/api/schemas/" + schema.Title
+	jsonSchemas[schema.Title] = schema
+	return schema
+}
+
+// handleSchema serves TypeScript interfaces for the API types, generated
+// from the same Go structs the server encodes
+// GET /api/schema
+func (s *Server) handleSchema(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/typescript; charset=utf-8")
+	w.Write([]byte(api.TypeScript()))
+}
+
+// handleJSONSchema serves the JSON Schema of one API payload, or the list of
+// available types
+// GET /api/schemas/{type}
+func (s *Server) handleJSONSchema(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/api/schemas/")
+	if name == "" {
+		names := make([]string, 0, len(jsonSchemas))
+		for name := range jsonSchemas {
+			if s.readOnly && mutationSchemas[name] {
+				continue
+			}
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(names)
+		return
+	}
+
+	schema, ok := jsonSchemas[name]
+	if !ok || (s.readOnly && mutationSchemas[name]) {
+		http.Error(w, "unknown schema", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/schema+json")
+	json.NewEncoder(w).Encode(schema)
+}
+
+// validationError is the response body for a payload that fails its schema
+type validationError struct {
+	Error   string   `json:"error"`
+	Details []string `json:"details"`
+}
+
+// decodeValid validates a JSON request body against schema and decodes it
+// into v. On failure it writes a 400 listing every violation and returns false.
+func decodeValid(w http.ResponseWriter, r *http.Request, schema *api.Schema, v interface{}) bool {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestBody))
+	if err != nil {
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return false
+	}
+
+	errs := schema.Validate(body)
+	if len(errs) == 0 {
+		if err := json.Unmarshal(body, v); err != nil {
+			errs = []string{err.Error()}
+		}
+	}
+	if len(errs) > 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(validationError{Error: "invalid request body", Details: errs})
+		return false
+	}
+	return true
+}
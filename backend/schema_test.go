@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rh-summit-coco/raj-hospital-dashboard/backend/pkg/api"
+)
+
+// TestHandleSchema tests that /api/schema serves the TypeScript interfaces
+func TestHandleSchema(t *testing.T) {
+	server := &Server{}
+
+	req := httptest.NewRequest("GET", "/api/schema", nil)
+	w := httptest.NewRecorder()
+	server.handleSchema(w, req)
+
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "application/typescript") {
+		t.Errorf("Unexpected content type %q", w.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(w.Body.String(), "export interface DashboardResponse {") {
+		t.Error("Expected DashboardResponse interface in schema")
+	}
+}
+
+// TestHandleJSONSchema tests serving JSON Schemas by type name
+func TestHandleJSONSchema(t *testing.T) {
+	server := &Server{}
+
+	w := httptest.NewRecorder()
+	server.handleJSONSchema(w, httptest.NewRequest("GET", "/api/schemas/WorkloadStatus", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", w.Code)
+	}
+	var schema api.Schema
+	if err := json.NewDecoder(w.Body).Decode(&schema); err != nil {
+		t.Fatalf("Failed to decode schema: %v", err)
+	}
+	if schema.ID != "/api/schemas/WorkloadStatus" || schema.Properties["attestation_status"] == nil {
+		t.Errorf("Unexpected schema %+v", schema)
+	}
+
+	w = httptest.NewRecorder()
+	server.handleJSONSchema(w, httptest.NewRequest("GET", "/api/schemas/", nil))
+	var names []string
+	json.NewDecoder(w.Body).Decode(&names)
+	for _, name := range []string{"AckRequest", "CollectorReport", "DashboardResponse"} {
+		if !containsString(names, name) {
+			t.Errorf("Expected %s in schema list, got %v", name, names)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	server.handleJSONSchema(w, httptest.NewRequest("GET", "/api/schemas/Nope", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown schema, got %d", w.Code)
+	}
+}
+
+// TestAckValidation tests that malformed ack bodies are rejected with details
+func TestAckValidation(t *testing.T) {
+	server := newAckTestServer(t)
+	raj := &Identity{Name: "raj"}
+
+	w := httptest.NewRecorder()
+	server.handleWorkloadDetail(w, ackRequestAs(raj, "POST", "/api/workload/icu/broken/ack", `{"comment":42,"until":"tomorrow"}`))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected 400, got %d", w.Code)
+	}
+
+	var body validationError
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode error: %v", err)
+	}
+	details := strings.Join(body.Details, "\n")
+	if !strings.Contains(details, "comment: expected string, got integer") || !strings.Contains(details, "until: unknown property") {
+		t.Errorf("Expected detailed validation errors, got %v", body.Details)
+	}
+	if server.acks.Get("icu/broken") != nil {
+		t.Error("Expected no acknowledgement from an invalid request")
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Search result limits for GET /api/search
+const (
+	defaultSearchLimit = 50
+	maxSearchLimit     = 500
+)
+
+// searchWeights rank where a query term matched: a hit on the workload name
+// says more than one buried in error details
+var searchWeights = map[string]int{
+	"name":      5,
+	"namespace": 3,
+	"notes":     2,
+	"labels":    2,
+	"details":   1,
+}
+
+// searchField is one searchable text of a workload or event
+type searchField struct {
+	name string
+	text string
+}
+
+// workloadSearchFields returns the searchable texts of a decorated workload.
+// Notes and labels are only searched on current workloads, as annotations
+// aren't part of history.
+func workloadSearchFields(status *WorkloadStatus, annotated bool) []searchField {
+	details := []string{status.Details}
+	for _, check := range status.FailedChecks {
+		details = append(details, check.Name, check.Actual)
+	}
+	for _, gate := range status.Gates {
+		details = append(details, gate.Details)
+	}
+	fields := []searchField{
+		{"name", status.Name},
+		{"namespace", status.Namespace},
+		{"details", strings.Join(details, "\n")},
+	}
+	if annotated && status.Annotations != nil {
+		labels := make([]string, 0, 2*len(status.Annotations.Labels))
+		for k, v := range status.Annotations.Labels {
+			labels = append(labels, k, v)
+		}
+		fields = append(fields,
+			searchField{"notes", status.Annotations.Notes},
+			searchField{"labels", strings.Join(labels, "\n")})
+	}
+	return fields
+}
+
+// scoreMatch scores fields against lowercase query terms. Every term must
+// match some field; each term counts its best field, doubled for an exact
+// match of the whole field. Returns 0 if a term doesn't match.
+func scoreMatch(fields []searchField, terms []string) (int, []string) {
+	score := 0
+	matched := map[string]bool{}
+	for _, term := range terms {
+		best, bestField := 0, ""
+		for _, field := range fields {
+			text := strings.ToLower(field.text)
+			if !strings.Contains(text, term) {
+				continue
+			}
+			weight := searchWeights[field.name]
+			if text == term {
+				weight *= 2
+			}
+			if weight > best {
+				best, bestField = weight, field.name
+			}
+		}
+		if best == 0 {
+			return 0, nil
+		}
+		score += best
+		matched[bestField] = true
+	}
+
+	fieldNames := make([]string, 0, len(matched))
+	for name := range matched {
+		fieldNames = append(fieldNames, name)
+	}
+	sort.Strings(fieldNames)
+	return score, fieldNames
+}
+
+// search matches the query against current workloads and retained history,
+// best matches first. At equal scores current workloads come before events,
+// and newer events before older ones.
+func (s *Server) search(query string, limit int) []SearchResult {
+	terms := strings.Fields(strings.ToLower(query))
+	results := []SearchResult{}
+	if len(terms) == 0 {
+		return results
+	}
+
+	s.cacheMutex.RLock()
+	for key, status := range s.statusCache {
+		workload := s.decorate(*status)
+		if score, matched := scoreMatch(workloadSearchFields(&workload, true), terms); score > 0 {
+			results = append(results, SearchResult{Kind: "workload", Key: key, Score: score, Matched: matched, Workload: &workload})
+		}
+	}
+	s.cacheMutex.RUnlock()
+
+	for _, event := range s.history.Events(time.Time{}, time.Now()) {
+		if event.Status == nil {
+			continue
+		}
+		if score, matched := scoreMatch(workloadSearchFields(event.Status, false), terms); score > 0 {
+			event := event
+			results = append(results, SearchResult{Kind: "event", Key: event.Key, Score: score, Matched: matched, Event: &event})
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Kind != b.Kind {
+			return a.Kind == "workload"
+		}
+		if a.Event != nil && !a.Event.Time.Equal(b.Event.Time) {
+			return a.Event.Time.After(b.Event.Time)
+		}
+		return a.Key < b.Key
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// handleSearch finds workloads and history events by free text, e.g. the
+// workloads and incidents mentioning "CDH unreachable"
+// GET /api/search?q=cdh+unreachable&limit=50
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	query := r.URL.Query().Get("q")
+	if strings.TrimSpace(query) == "" {
+		http.Error(w, "q parameter required", http.StatusBadRequest)
+		return
+	}
+	limit := defaultSearchLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 || n > maxSearchLimit {
+			http.Error(w, "invalid limit parameter", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	results := s.search(query, limit)
+	var workloads []WorkloadStatus
+	for _, result := range results {
+		if result.Workload != nil {
+			workloads = append(workloads, *result.Workload)
+		} else {
+			workloads = append(workloads, *result.Event.Status)
+		}
+	}
+	noteWorkloads(r, workloads)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestSearch tests matching and ranking across workloads, notes and history
+func TestSearch(t *testing.T) {
+	t0 := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	cdhDown := failedStatus("icu", "ai-model")
+	cdhDown.Details = "CDH unreachable: connection refused"
+
+	history, _ := newHistory(nil, 0)
+	history.Record([]HistoryEvent{
+		{Time: t0, Key: "lab/lims", Type: "changed", Status: &WorkloadStatus{Name: "lims", Namespace: "lab", Details: "CDH unreachable: timeout"}},
+		{Time: t0.Add(time.Hour), Key: "icu/ai-model", Type: "changed", Status: cdhDown},
+		{Time: t0.Add(2 * time.Hour), Key: "icu/pacs", Type: "added", Status: verifiedStatus("icu", "pacs")},
+	})
+	annotations, _ := newAnnotationStore(nil)
+	annotations.Set("icu/pacs", &Annotations{Notes: "CDH migration pending"})
+
+	server := &Server{
+		history:     history,
+		annotations: annotations,
+		statusCache: map[string]*WorkloadStatus{
+			"icu/ai-model": cdhDown,
+			"icu/pacs":     verifiedStatus("icu", "pacs"),
+			"lab/cdh":      verifiedStatus("lab", "cdh"),
+		},
+	}
+
+	results := server.search("CDH", 50)
+	keys := make([]string, len(results))
+	for i, result := range results {
+		keys[i] = result.Kind + ":" + result.Key
+	}
+	// Exact name, then notes, then details - current workloads before
+	// events, newer events first
+	want := []string{"workload:lab/cdh", "workload:icu/pacs", "workload:icu/ai-model", "event:icu/ai-model", "event:lab/lims"}
+	if len(keys) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, keys)
+	}
+	for i := range want {
+		if keys[i] != want[i] {
+			t.Errorf("Expected %v, got %v", want, keys)
+			break
+		}
+	}
+	if results[1].Matched[0] != "notes" {
+		t.Errorf("Expected icu/pacs to match on notes, got %v", results[1].Matched)
+	}
+
+	// Every term must match
+	if results := server.search("cdh timeout", 50); len(results) != 1 || results[0].Key != "lab/lims" {
+		t.Errorf("Expected only the lims event, got %+v", results)
+	}
+	if results := server.search("icu unreachable", 50); len(results) != 2 || results[0].Kind != "workload" {
+		t.Errorf("Expected ai-model workload and event, got %+v", results)
+	}
+	if results := server.search("cdh", 2); len(results) != 2 {
+		t.Errorf("Expected results limited to 2, got %d", len(results))
+	}
+
+	w := httptest.NewRecorder()
+	server.handleSearch(w, httptest.NewRequest("GET", "/api/search?q=", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without a query, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	server.handleSearch(w, httptest.NewRequest("GET", "/api/search?q=refused", nil))
+	var response []SearchResult
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil || len(response) != 2 {
+		t.Errorf("Expected 2 results for refused, got %s", w.Body.String())
+	}
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"sort"
+	"sync"
+	"time"
+)
+
+// selftestTimeout bounds each network check of the self-test
+const selftestTimeout = 5 * time.Second
+
+// selftestCheck is the result of one self-test check
+type selftestCheck struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"` // "pass", "fail" or "skip"
+	Details  string `json:"details,omitempty"`
+	Duration string `json:"duration,omitempty"`
+}
+
+// selftestResult is the response of GET /api/admin/selftest
+type selftestResult struct {
+	Status string          `json:"status"` // "pass" if no check failed
+	Checks []selftestCheck `json:"checks"`
+}
+
+func selftestPass(name, details string) selftestCheck {
+	return selftestCheck{Name: name, Status: "pass", Details: details}
+}
+
+func selftestFail(name string, err error) selftestCheck {
+	return selftestCheck{Name: name, Status: "fail", Details: err.Error()}
+}
+
+func selftestSkip(name, details string) selftestCheck {
+	return selftestCheck{Name: name, Status: "skip", Details: details}
+}
+
+// probeCollector checks that a Collector is reachable and accepts the
+// configured credentials
+func (s *Server) probeCollector(ctx context.Context, name string, cluster ClusterConfig) selftestCheck {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, cluster.CollectorURL+"/api/v1/reports", nil)
+	if err != nil {
+		return selftestFail(name, err)
+	}
+	if err := cluster.authorize(req); err != nil {
+		return selftestFail(name, err)
+	}
+
+	client := &http.Client{Timeout: selftestTimeout}
+	if cluster.httpClient != nil {
+		client = &http.Client{Timeout: selftestTimeout, Transport: cluster.httpClient.Transport}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return selftestFail(name, fmt.Errorf("unreachable: %w", err))
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return selftestFail(name, fmt.Errorf("credentials rejected (status %d) - check token or token_file", resp.StatusCode))
+	case resp.StatusCode != http.StatusOK:
+		return selftestFail(name, fmt.Errorf("collector returned status %d", resp.StatusCode))
+	}
+
+	raws, err := s.collectorLimits.decodeArray(resp.Body)
+	if err != nil {
+		return selftestFail(name, fmt.Errorf("unexpected response: %w", err))
+	}
+	if len(raws) == 0 {
+		return selftestPass(name, "reachable, but serving no reports - is the Collector watching the right namespaces?")
+	}
+	return selftestPass(name, fmt.Sprintf("reachable, %d reports", len(raws)))
+}
+
+// checkNotifyTarget checks that a notification target could be reached,
+// without delivering anything to it: webhook hosts must accept a TCP
+// connection and plugin commands must be executable
+func checkNotifyTarget(ctx context.Context, target *notifyTarget) selftestCheck {
+	name := "notify:" + target.Name
+	if len(target.Command) > 0 {
+		path, err := exec.LookPath(target.Command[0])
+		if err != nil {
+			return selftestFail(name, err)
+		}
+		return selftestPass(name, "plugin "+path)
+	}
+
+	u, err := url.Parse(target.URL)
+	if err != nil {
+		return selftestFail(name, err)
+	}
+	host := u.Host
+	if u.Port() == "" {
+		port := "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+		host = net.JoinHostPort(u.Hostname(), port)
+	}
+	dialer := net.Dialer{Timeout: selftestTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return selftestFail(name, fmt.Errorf("unreachable: %w", err))
+	}
+	conn.Close()
+	return selftestPass(name, "accepts connections at "+host)
+}
+
+// selftest runs every diagnostic check concurrently. Network checks are
+// abandoned when ctx is done.
+func (s *Server) selftest(ctx context.Context) selftestResult {
+	var checks []func() selftestCheck
+
+	for _, cluster := range s.collectorTargets() {
+		cluster := cluster
+		label := cluster.Name
+		if label == "" {
+			label = "default"
+		}
+		checks = append(checks, func() selftestCheck {
+			return s.probeCollector(ctx, "collector:"+label, cluster)
+		})
+		if cluster.SecondaryCollectorURL != "" {
+			secondary := cluster
+			secondary.CollectorURL = cluster.SecondaryCollectorURL
+			checks = append(checks, func() selftestCheck {
+				return s.probeCollector(ctx, "secondary_collector:"+label, secondary)
+			})
+		}
+	}
+
+	// EAR tokens are appraised by the Collector; this backend trusts its
+	// verdict and fetches no verification keys of its own
+	checks = append(checks, func() selftestCheck {
+		return selftestSkip("token_verification_keys", "EAR tokens are verified by the Collector; no keys are fetched by the dashboard")
+	})
+
+	checks = append(checks, func() selftestCheck {
+		if s.kube == nil {
+			return selftestSkip("kubernetes", "not running in a cluster - reports are not enriched")
+		}
+		var version struct {
+			GitVersion string `json:"gitVersion"`
+		}
+		if err := s.kube.get("/version", &version); err != nil {
+			return selftestFail("kubernetes", err)
+		}
+		return selftestPass("kubernetes", "API server "+version.GitVersion)
+	})
+
+	checks = append(checks, func() selftestCheck {
+		if s.store == nil {
+			return selftestSkip("storage", "no STORE_DIR - history is kept in memory only")
+		}
+		if err := s.store.Check(); err != nil {
+			return selftestFail("storage", err)
+		}
+		return selftestPass("storage", s.store.dir)
+	})
+
+	if s.notifier != nil {
+		for _, target := range s.notifier.targets {
+			target := target
+			checks = append(checks, func() selftestCheck { return checkNotifyTarget(ctx, target) })
+		}
+		if pending := s.notifier.Pending(); pending > 0 {
+			checks = append(checks, func() selftestCheck {
+				return selftestFail("notification_queue", fmt.Errorf("%d notifications awaiting delivery", pending))
+			})
+		}
+	}
+
+	result := selftestResult{Status: "pass", Checks: make([]selftestCheck, len(checks))}
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check func() selftestCheck) {
+			defer wg.Done()
+			start := time.Now()
+			result.Checks[i] = check()
+			result.Checks[i].Duration = time.Since(start).Round(time.Millisecond).String()
+		}(i, check)
+	}
+	wg.Wait()
+
+	sort.SliceStable(result.Checks, func(i, j int) bool { return result.Checks[i].Name < result.Checks[j].Name })
+	for _, check := range result.Checks {
+		if check.Status == "fail" {
+			result.Status = "fail"
+		}
+	}
+	return result
+}
+
+// handleSelftest runs connectivity and health checks against everything the
+// dashboard depends on - a first stop when the dashboard shows no workloads
+// GET /api/admin/selftest
+func (s *Server) handleSelftest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if _, ok := requireAdmin(w, r); !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.selftest(r.Context()))
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func selftestCheckNamed(result selftestResult, name string) *selftestCheck {
+	for i := range result.Checks {
+		if result.Checks[i].Name == name {
+			return &result.Checks[i]
+		}
+	}
+	return nil
+}
+
+// TestSelftest tests that the self-test reports a working primary Collector
+// and store, and a secondary Collector that rejects its credentials
+func TestSelftest(t *testing.T) {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"pod_name":"ai-model","namespace":"icu"}]`))
+	}))
+	defer primary.Close()
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	}))
+	defer secondary.Close()
+
+	store, err := openStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	s := &Server{collectorURL: primary.URL, secondaryCollectorURL: secondary.URL, store: store}
+
+	result := s.selftest(context.Background())
+	if result.Status != "fail" {
+		t.Errorf("Expected overall status fail, got %s", result.Status)
+	}
+	if check := selftestCheckNamed(result, "collector:default"); check == nil || check.Status != "pass" {
+		t.Errorf("Expected collector check to pass, got %+v", check)
+	}
+	if check := selftestCheckNamed(result, "secondary_collector:default"); check == nil || check.Status != "fail" {
+		t.Errorf("Expected secondary collector check to fail, got %+v", check)
+	}
+	if check := selftestCheckNamed(result, "storage"); check == nil || check.Status != "pass" {
+		t.Errorf("Expected storage check to pass, got %+v", check)
+	}
+	if check := selftestCheckNamed(result, "kubernetes"); check == nil || check.Status != "skip" {
+		t.Errorf("Expected kubernetes check to be skipped, got %+v", check)
+	}
+}
+
+// TestSelftestWithoutStore tests that storage is skipped without STORE_DIR
+// and that the overall status passes when nothing fails
+func TestSelftestWithoutStore(t *testing.T) {
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	}))
+	defer collector.Close()
+
+	s := &Server{collectorURL: collector.URL}
+	result := s.selftest(context.Background())
+	if result.Status != "pass" {
+		t.Errorf("Expected overall status pass, got %s: %+v", result.Status, result.Checks)
+	}
+	if check := selftestCheckNamed(result, "storage"); check == nil || check.Status != "skip" {
+		t.Errorf("Expected storage check to be skipped, got %+v", check)
+	}
+}
+
+// TestSelftestRequiresAdmin tests that the self-test is admin-only
+func TestSelftestRequiresAdmin(t *testing.T) {
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	}))
+	defer collector.Close()
+	s := &Server{collectorURL: collector.URL}
+
+	w := httptest.NewRecorder()
+	s.handleSelftest(w, ackRequestAs(&Identity{Name: "raj"}, "GET", "/api/admin/selftest", ""))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	s.handleSelftest(w, ackRequestAs(&Identity{Name: "sre", Roles: []string{adminRole}}, "GET", "/api/admin/selftest", ""))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var result selftestResult
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode result: %v", err)
+	}
+	if len(result.Checks) == 0 {
+		t.Error("Expected checks in the result")
+	}
+}
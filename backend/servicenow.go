@@ -0,0 +1,281 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+)
+
+// serviceNowIncidentTable is the Table API path of incidents
+const serviceNowIncidentTable = "/api/now/table/incident"
+
+// serviceNowResolved is the incident state set when a violation recovers
+const serviceNowResolved = "6"
+
+// serviceNowPriority is the impact and urgency (1 = high, 3 = low) an
+// incident is opened with; ServiceNow derives the priority from both
+type serviceNowPriority struct {
+	Impact  int `json:"impact"`
+	Urgency int `json:"urgency"`
+}
+
+// defaultServiceNowPriorities map check severities to impact and urgency
+var defaultServiceNowPriorities = map[string]serviceNowPriority{
+	severityCritical: {1, 1},
+	severityHigh:     {2, 2},
+	severityWarning:  {3, 3},
+}
+
+// serviceNowAssignment routes incidents of matching workloads to an
+// assignment group. Namespace is a glob; Team is the owning team from the
+// ownership directory. An empty field matches everything.
+type serviceNowAssignment struct {
+	Namespace string `json:"namespace,omitempty"`
+	Team      string `json:"team,omitempty"`
+	Group     string `json:"group"`
+}
+
+// serviceNowTarget is a notification target that keeps one ServiceNow
+// incident per violating workload: it opens the incident when a violation of
+// at least min_severity starts, adds work notes as it changes, and resolves it
+// on recovery. Incidents are found again by correlation_id, so no state is
+// kept on the dashboard's side.
+type serviceNowTarget struct {
+	InstanceURL  string `json:"instance_url"`            // e.g. https://hospital.service-now.com
+	Username     string `json:"username,omitempty"`      // basic auth, with password_file
+	PasswordFile string `json:"password_file,omitempty"` // re-read on every request
+	TokenFile    string `json:"token_file,omitempty"`    // OAuth bearer token, alternative to basic auth
+	// MinSeverity is the least severe failed check that opens an incident;
+	// default critical. Open incidents are updated whatever the severity.
+	MinSeverity string                        `json:"min_severity,omitempty"`
+	Priorities  map[string]serviceNowPriority `json:"priorities,omitempty"` // by check severity
+	// AssignmentGroups are tried in order; AssignmentGroup is the fallback
+	AssignmentGroups []serviceNowAssignment `json:"assignment_groups,omitempty"`
+	AssignmentGroup  string                 `json:"assignment_group,omitempty"`
+	Category         string                 `json:"category,omitempty"`
+	CallerID         string                 `json:"caller_id,omitempty"`
+	CloseCode        string                 `json:"close_code,omitempty"` // default "Solved (Permanently)"
+}
+
+// init validates the target and fills in defaults
+func (sn *serviceNowTarget) init() error {
+	if u, err := url.Parse(sn.InstanceURL); err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("invalid instance_url %q", sn.InstanceURL)
+	}
+	sn.InstanceURL = strings.TrimRight(sn.InstanceURL, "/")
+	if (sn.PasswordFile == "") == (sn.TokenFile == "") || sn.PasswordFile != "" && sn.Username == "" {
+		return fmt.Errorf("username with password_file, or token_file, is required")
+	}
+	if sn.MinSeverity == "" {
+		sn.MinSeverity = severityCritical
+	}
+	if severityRank[sn.MinSeverity] == 0 {
+		return fmt.Errorf("unknown min_severity %q", sn.MinSeverity)
+	}
+	priorities := make(map[string]serviceNowPriority, len(defaultServiceNowPriorities))
+	for severity, priority := range defaultServiceNowPriorities {
+		priorities[severity] = priority
+	}
+	for severity, priority := range sn.Priorities {
+		if severityRank[severity] == 0 {
+			return fmt.Errorf("unknown severity %q in priorities", severity)
+		}
+		if priority.Impact < 1 || priority.Impact > 3 || priority.Urgency < 1 || priority.Urgency > 3 {
+			return fmt.Errorf("priority of %s: impact and urgency must be 1-3", severity)
+		}
+		priorities[severity] = priority
+	}
+	sn.Priorities = priorities
+	for i, rule := range sn.AssignmentGroups {
+		if rule.Group == "" {
+			return fmt.Errorf("assignment group %d: group is required", i)
+		}
+		if _, err := path.Match(rule.Namespace, ""); err != nil {
+			return fmt.Errorf("assignment group %d: invalid namespace pattern %q", i, rule.Namespace)
+		}
+	}
+	if sn.CloseCode == "" {
+		sn.CloseCode = "Solved (Permanently)"
+	}
+	return nil
+}
+
+// assignmentGroup returns the group incidents of a workload are assigned to
+func (sn *serviceNowTarget) assignmentGroup(workload *WorkloadStatus) string {
+	team := ""
+	if workload.Owner != nil {
+		team = workload.Owner.Team
+	}
+	for _, rule := range sn.AssignmentGroups {
+		if ok, _ := path.Match(rule.Namespace, workload.Namespace); rule.Namespace != "" && !ok {
+			continue
+		}
+		if rule.Team != "" && rule.Team != team {
+			continue
+		}
+		return rule.Group
+	}
+	return sn.AssignmentGroup
+}
+
+// serviceNowIncident is the subset of an incident record the target reads
+type serviceNowIncident struct {
+	SysID  string `json:"sys_id"`
+	Number string `json:"number"`
+}
+
+// serviceNowCall makes a Table API request and decodes the "result" of the
+// response into result
+func (n *Notifier) serviceNowCall(sn *serviceNowTarget, method, apiPath string, body, result interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, sn.InstanceURL+apiPath, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if sn.TokenFile != "" {
+		token, err := os.ReadFile(sn.TokenFile)
+		if err != nil {
+			return fmt.Errorf("failed to read ServiceNow token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	} else {
+		password, err := os.ReadFile(sn.PasswordFile)
+		if err != nil {
+			return fmt.Errorf("failed to read ServiceNow password: %w", err)
+		}
+		req.SetBasicAuth(sn.Username, strings.TrimSpace(string(password)))
+	}
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("ServiceNow returned status %d for %s %s", resp.StatusCode, method, apiPath)
+	}
+	if result == nil {
+		return nil
+	}
+	envelope := struct {
+		Result interface{} `json:"result"`
+	}{result}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return fmt.Errorf("invalid ServiceNow response: %w", err)
+	}
+	return nil
+}
+
+// serviceNowCorrelationID identifies the incidents of one workload
+func serviceNowCorrelationID(key string) string {
+	return "attestation-dashboard:" + key
+}
+
+// serviceNowDescription describes the state of a workload for an incident
+// or work note
+func (n *Notifier) serviceNowDescription(payload WebhookPayload) string {
+	var b strings.Builder
+	if payload.Summary != "" {
+		fmt.Fprintf(&b, "%s\n\n", payload.Summary)
+	}
+	if w := payload.Workload; w != nil {
+		fmt.Fprintf(&b, "Workload: %s\nCluster: %s\nStatus: %s (was %s)\nDetails: %s\n", payload.Key, w.Cluster, w.AttestationStatus, payload.PreviousStatus, w.Details)
+		for _, check := range w.FailedChecks {
+			fmt.Fprintf(&b, "Failed check %s [%s]: expected %s, got %s\n", check.Name, check.Severity, check.Expected, check.Actual)
+		}
+		if w.Owner != nil {
+			fmt.Fprintf(&b, "Owner: %s %s\n", w.Owner.Team, w.Owner.Contact)
+		}
+	}
+	if link := n.workloadLink(payload.Key); link != "" {
+		fmt.Fprintf(&b, "Dashboard: %s\n", link)
+	}
+	return b.String()
+}
+
+// sendServiceNow opens, updates or resolves the workload's incident
+func (n *Notifier) sendServiceNow(target *notifyTarget, payload WebhookPayload) error {
+	sn := target.ServiceNow
+	correlationID := serviceNowCorrelationID(payload.Key)
+
+	var open []serviceNowIncident
+	query := url.Values{
+		"sysparm_query":  {"correlation_id=" + correlationID + "^active=true"},
+		"sysparm_fields": {"sys_id,number"},
+		"sysparm_limit":  {"1"},
+	}
+	if err := n.serviceNowCall(sn, http.MethodGet, serviceNowIncidentTable+"?"+query.Encode(), nil, &open); err != nil {
+		return err
+	}
+
+	workload := payload.Workload
+	violating := payload.Event != "workload.removed" && workload != nil && isViolation(workload)
+	severity := ""
+	if violating {
+		if severity = highestSeverity(workload.FailedChecks); severity == "" {
+			severity = severityHigh
+		}
+	}
+
+	switch {
+	case len(open) > 0 && !violating:
+		notes := "Workload " + payload.Key + " was removed"
+		if workload != nil && payload.Event != "workload.removed" {
+			notes = "Attestation recovered: " + payload.Key + " is " + workload.AttestationStatus
+		}
+		return n.serviceNowCall(sn, http.MethodPatch, serviceNowIncidentTable+"/"+open[0].SysID, map[string]string{
+			"state":       serviceNowResolved,
+			"close_code":  sn.CloseCode,
+			"close_notes": notes,
+		}, nil)
+
+	case len(open) > 0:
+		priority := sn.Priorities[severity]
+		return n.serviceNowCall(sn, http.MethodPatch, serviceNowIncidentTable+"/"+open[0].SysID, map[string]interface{}{
+			"work_notes": n.serviceNowDescription(payload),
+			"impact":     priority.Impact,
+			"urgency":    priority.Urgency,
+		}, nil)
+
+	case violating && severityRank[severity] >= severityRank[sn.MinSeverity]:
+		priority := sn.Priorities[severity]
+		incident := map[string]interface{}{
+			"short_description": fmt.Sprintf("Attestation violation: %s is %s", payload.Key, workload.AttestationStatus),
+			"description":       n.serviceNowDescription(payload),
+			"correlation_id":    correlationID,
+			"impact":            priority.Impact,
+			"urgency":           priority.Urgency,
+		}
+		for field, value := range map[string]string{"assignment_group": sn.assignmentGroup(workload), "category": sn.Category, "caller_id": sn.CallerID} {
+			if value != "" {
+				incident[field] = value
+			}
+		}
+		var created serviceNowIncident
+		if err := n.serviceNowCall(sn, http.MethodPost, serviceNowIncidentTable, incident, &created); err != nil {
+			return err
+		}
+		log.Printf("Opened ServiceNow incident %s for %s", created.Number, payload.Key)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeServiceNow is a minimal incident Table API
+type fakeServiceNow struct {
+	mu        sync.Mutex
+	incidents map[string]map[string]interface{} // by sys_id
+	notes     []string
+}
+
+func (f *fakeServiceNow) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if user, pass, ok := r.BasicAuth(); !ok || user != "dashboard" || pass != "s3cret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet:
+		correlationID := strings.TrimSuffix(strings.TrimPrefix(r.URL.Query().Get("sysparm_query"), "correlation_id="), "^active=true")
+		result := []map[string]interface{}{}
+		for _, incident := range f.incidents {
+			if incident["correlation_id"] == correlationID && incident["state"] != serviceNowResolved {
+				result = append(result, incident)
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"result": result})
+	case r.Method == http.MethodPost:
+		var incident map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&incident)
+		id := "sys" + string(rune('0'+len(f.incidents)))
+		incident["sys_id"], incident["number"] = id, "INC000"+id
+		f.incidents[id] = incident
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{"result": incident})
+	case r.Method == http.MethodPatch:
+		var update map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&update)
+		incident := f.incidents[strings.TrimPrefix(r.URL.Path, serviceNowIncidentTable+"/")]
+		for field, value := range update {
+			incident[field] = value
+		}
+		if notes, ok := update["work_notes"].(string); ok {
+			f.notes = append(f.notes, notes)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"result": incident})
+	}
+}
+
+// TestServiceNowTarget tests opening, updating and resolving an incident
+// over the lifecycle of a violation
+func TestServiceNowTarget(t *testing.T) {
+	fake := &fakeServiceNow{incidents: make(map[string]map[string]interface{})}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	passwordFile := filepath.Join(t.TempDir(), "password")
+	os.WriteFile(passwordFile, []byte("s3cret\n"), 0o600)
+
+	notifier, err := newNotifier([]notifyTarget{{Name: "snow", ServiceNow: &serviceNowTarget{
+		InstanceURL:      srv.URL,
+		Username:         "dashboard",
+		PasswordFile:     passwordFile,
+		AssignmentGroups: []serviceNowAssignment{{Team: "imaging", Group: "Imaging Ops"}},
+		AssignmentGroup:  "Service Desk",
+	}}}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create notifier: %v", err)
+	}
+	notifier.dashboardURL = "https://dashboard.hospital.example"
+
+	event := func(status *WorkloadStatus, severity string) HistoryEvent {
+		if severity != "" {
+			failCheck(status, "tee_attestation", "attested", "not attested", severity)
+		}
+		return HistoryEvent{Time: time.Now(), Key: "radiology/pacs", Type: "changed", Status: status}
+	}
+	deliver := func(e HistoryEvent) {
+		notifier.Notify([]HistoryEvent{e})
+		notifier.deliverDue()
+		if pending := notifier.Pending(); pending != 0 {
+			t.Fatalf("Expected delivery, %d pending: %s", pending, notifier.queue[0].LastError)
+		}
+	}
+
+	// A violation below min_severity opens nothing
+	deliver(event(failedStatus("radiology", "pacs"), severityHigh))
+	if len(fake.incidents) != 0 {
+		t.Fatalf("Expected no incident for a high violation, got %+v", fake.incidents)
+	}
+
+	critical := failedStatus("radiology", "pacs")
+	critical.Owner = &Owner{Team: "imaging"}
+	deliver(event(critical, severityCritical))
+	if len(fake.incidents) != 1 {
+		t.Fatalf("Expected an incident, got %+v", fake.incidents)
+	}
+	incident := fake.incidents["sys0"]
+	if incident["assignment_group"] != "Imaging Ops" || incident["impact"] != 1.0 || incident["urgency"] != 1.0 ||
+		!strings.Contains(incident["description"].(string), "https://dashboard.hospital.example/api/workload/radiology/pacs") {
+		t.Errorf("Unexpected incident %+v", incident)
+	}
+
+	// Further changes are work notes on the same incident
+	deliver(event(failedStatus("radiology", "pacs"), severityHigh))
+	if len(fake.incidents) != 1 || len(fake.notes) != 1 || incident["impact"] != 2.0 {
+		t.Errorf("Expected the incident to be updated, got %+v with notes %v", fake.incidents, fake.notes)
+	}
+
+	deliver(event(verifiedStatus("radiology", "pacs"), ""))
+	if incident["state"] != serviceNowResolved || incident["close_code"] != "Solved (Permanently)" {
+		t.Errorf("Expected the incident to be resolved, got %+v", incident)
+	}
+}
+
+// TestServiceNowTargetValidation tests rejecting incomplete targets
+func TestServiceNowTargetValidation(t *testing.T) {
+	for _, sn := range []serviceNowTarget{
+		{InstanceURL: "hospital.service-now.com", TokenFile: "/token"},
+		{InstanceURL: "https://hospital.service-now.com"},
+		{InstanceURL: "https://hospital.service-now.com", PasswordFile: "/password"},
+		{InstanceURL: "https://hospital.service-now.com", TokenFile: "/token", MinSeverity: "urgent"},
+		{InstanceURL: "https://hospital.service-now.com", TokenFile: "/token", Priorities: map[string]serviceNowPriority{severityHigh: {4, 1}}},
+	} {
+		if err := sn.init(); err == nil {
+			t.Errorf("Expected %+v to be rejected", sn)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Browser sessions are signed cookies (see streamTokens) holding the caller's
+// identity. Besides SAML logins, any credential the API accepts - an LDAP
+// Basic login or a bearer token - can be exchanged for a session at
+// POST /api/session, so the web UI doesn't have to keep the credential.
+// Logging out revokes the session server-side, so a copied cookie stops
+// working before it expires.
+
+const revokedSessionsDoc = "revoked-sessions"
+
+// sessionRevocations lists sessions ended before their expiry. Entries are
+// dropped once the session would have expired anyway.
+type sessionRevocations struct {
+	mu      sync.Mutex
+	revoked map[string]time.Time // session ID -> session expiry
+	store   *Store
+}
+
+func newSessionRevocations(store *Store) (*sessionRevocations, error) {
+	sr := &sessionRevocations{revoked: make(map[string]time.Time), store: store}
+	if _, err := store.LoadDoc(revokedSessionsDoc, &sr.revoked); err != nil {
+		return nil, fmt.Errorf("failed to load revoked sessions: %w", err)
+	}
+	return sr, nil
+}
+
+// Revoke ends a session before its expiry
+func (sr *sessionRevocations) Revoke(id string, expires, now time.Time) {
+	if sr == nil || id == "" {
+		return
+	}
+
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	pruneExpired(sr.revoked, now)
+	sr.revoked[id] = expires
+	if err := sr.store.SaveDoc(revokedSessionsDoc, sr.revoked); err != nil {
+		// The revocation still holds until restart
+		log.Printf("Failed to persist revoked sessions: %v", err)
+	}
+}
+
+// Revoked reports whether a session was revoked
+func (sr *sessionRevocations) Revoked(id string) bool {
+	if sr == nil {
+		return false
+	}
+
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	_, revoked := sr.revoked[id]
+	return revoked
+}
+
+// sessionInfo describes the caller for GET /api/session
+func sessionInfo(identity *Identity) Session {
+	if identity == nil {
+		return Session{}
+	}
+	info := Session{
+		Authenticated: true,
+		Name:          identity.Name,
+		Roles:         identity.Roles,
+		Namespaces:    identity.Namespaces,
+		Permissions:   identity.permissions,
+	}
+	if identity.session != nil {
+		expires := time.Unix(identity.session.Expires, 0).UTC()
+		info.ExpiresAt = &expires
+	}
+	return info
+}
+
+// endSession revokes the request's session cookie, if it carries a valid
+// one, and tells the browser to drop it. Returns the ended session.
+func (s *Server) endSession(w http.ResponseWriter, r *http.Request) *streamClaims {
+	http.SetCookie(w, &http.Cookie{Name: sessionCookie, Value: "", Path: "/", MaxAge: -1, HttpOnly: true, SameSite: http.SameSiteLaxMode})
+	if s.auth == nil || s.auth.sessions == nil {
+		return nil
+	}
+	cookie, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return nil
+	}
+	now := time.Now()
+	claims, err := s.auth.sessions.verify(cookie.Value, now)
+	if err != nil {
+		return nil
+	}
+	s.auth.revoked.Revoke(claims.ID, time.Unix(claims.Expires, 0), now)
+	return claims
+}
+
+// handleSession reports the caller's identity, starts a browser session
+// from the request's credentials, or ends the current session
+// GET /api/session
+// POST /api/session
+// DELETE /api/session
+func (s *Server) handleSession(w http.ResponseWriter, r *http.Request) {
+	identity := identityFromContext(r.Context())
+
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(sessionInfo(identity))
+
+	case http.MethodPost:
+		if s.auth == nil || s.auth.sessions == nil {
+			http.Error(w, "authentication is not enabled", http.StatusNotFound)
+			return
+		}
+		if identity == nil {
+			http.Error(w, "sessions require an authenticated identity", http.StatusUnauthorized)
+			return
+		}
+		now := time.Now()
+		token, expires := s.auth.sessions.issue(identity, now)
+		http.SetCookie(w, &http.Cookie{
+			Name:     sessionCookie,
+			Value:    token,
+			Path:     "/",
+			Expires:  expires,
+			HttpOnly: true,
+			Secure:   s.auth.secureCookies,
+			SameSite: http.SameSiteLaxMode,
+		})
+		method := "token"
+		if strings.HasPrefix(r.Header.Get("Authorization"), "Basic ") {
+			method = "ldap"
+		}
+		s.audit.RecordRequest(r, identity.Name, "auth.login", method, strings.Join(identity.Roles, ","))
+
+		info := sessionInfo(identity)
+		info.ExpiresAt = &expires
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(info)
+
+	case http.MethodDelete:
+		if claims := s.endSession(w, r); claims != nil {
+			s.audit.RecordRequest(r, claims.Subject, "auth.logout", "session", "")
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newSessionTestServer(t *testing.T, store *Store) *Server {
+	t.Helper()
+	sessions, _ := newStreamTokens("session-secret", time.Hour)
+	revoked, err := newSessionRevocations(store)
+	if err != nil {
+		t.Fatalf("Failed to load revoked sessions: %v", err)
+	}
+	audit, _ := newAuditLog(nil)
+	return &Server{
+		auth: &Authenticator{
+			tokens:        []apiToken{{Token: "raj-token", Identity: "raj", Roles: []string{"operator"}}},
+			sessions:      sessions,
+			revoked:       revoked,
+			secureCookies: true,
+		},
+		audit: audit,
+	}
+}
+
+// TestSessionLifecycle tests exchanging a token for a cookie session,
+// querying it and revoking it on logout
+func TestSessionLifecycle(t *testing.T) {
+	store, err := openStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	server := newSessionTestServer(t, store)
+	handler := server.authMiddleware(http.HandlerFunc(server.handleSession))
+
+	req := httptest.NewRequest("POST", "/api/session", nil)
+	req.Header.Set("Authorization", "Bearer raj-token")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	cookies := w.Result().Cookies()
+	if w.Code != http.StatusOK || len(cookies) != 1 {
+		t.Fatalf("Expected a session cookie, got %d %+v", w.Code, cookies)
+	}
+	cookie := cookies[0]
+	if cookie.Name != sessionCookie || !cookie.HttpOnly || !cookie.Secure || cookie.SameSite != http.SameSiteLaxMode {
+		t.Errorf("Expected a secure HttpOnly cookie, got %+v", cookie)
+	}
+
+	withCookie := func(method string) *http.Request {
+		req := httptest.NewRequest(method, "/api/session", nil)
+		req.AddCookie(cookie)
+		return req
+	}
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, withCookie("GET"))
+	var session Session
+	if err := json.NewDecoder(w.Body).Decode(&session); err != nil {
+		t.Fatalf("Failed to decode session: %v", err)
+	}
+	if !session.Authenticated || session.Name != "raj" || session.Roles[0] != "operator" || session.ExpiresAt == nil {
+		t.Errorf("Unexpected session %+v", session)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, withCookie("DELETE"))
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Expected 204 on logout, got %d", w.Code)
+	}
+	if entries := server.audit.Entries(time.Time{}); len(entries) != 2 || entries[1].Action != "auth.logout" || entries[1].Actor != "raj" {
+		t.Errorf("Expected login and logout audit entries, got %+v", entries)
+	}
+
+	// The cookie is revoked server-side, also after a restart
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, withCookie("GET"))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for a revoked session, got %d", w.Code)
+	}
+	restarted := newSessionTestServer(t, store)
+	restarted.auth.sessions = server.auth.sessions
+	w = httptest.NewRecorder()
+	restarted.authMiddleware(http.HandlerFunc(restarted.handleSession)).ServeHTTP(w, withCookie("GET"))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected revocation to survive a restart, got %d", w.Code)
+	}
+}
+
+// TestSessionWithoutAuth tests GET /api/session when authentication is disabled
+func TestSessionWithoutAuth(t *testing.T) {
+	server := &Server{}
+	w := httptest.NewRecorder()
+	server.handleSession(w, httptest.NewRequest("GET", "/api/session", nil))
+	var session Session
+	json.NewDecoder(w.Body).Decode(&session)
+	if session.Authenticated {
+		t.Errorf("Expected an anonymous session, got %+v", session)
+	}
+
+	w = httptest.NewRecorder()
+	server.handleSession(w, httptest.NewRequest("POST", "/api/session", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 without authentication, got %d", w.Code)
+	}
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Share links let engineers show one workload's detail and history to people
+// without dashboard access, e.g. a vendor investigating an incident. A link
+// carries a token signed like stream tokens, naming the workload and its
+// expiry. Links are also recorded, so they can be listed and revoked before
+// they expire. Holders see responses redacted as viewers do.
+
+const (
+	shareLinksDoc      = "share-links"
+	sharePurpose       = "share"
+	defaultShareTTL    = 24 * time.Hour
+	defaultShareMaxTTL = 7 * 24 * time.Hour
+)
+
+// shareClaims is the signed content of a share token
+type shareClaims struct {
+	ID      string `json:"jti"`
+	Purpose string `json:"typ"` // sharePurpose, so no other token signed with the secret passes as one
+	Key     string `json:"key"`
+	Expires int64  `json:"exp"`
+}
+
+// ShareStore holds the share links issued, persisted to the store, until
+// they expire or are revoked
+type ShareStore struct {
+	mu     sync.Mutex
+	links  map[string]*ShareLink // by ID
+	store  *Store
+	tokens *streamTokens
+	maxTTL time.Duration
+}
+
+// newShareStore loads persisted links. With no secret a random one is
+// generated, so links stop working at a restart.
+func newShareStore(store *Store, secret string, maxTTL time.Duration) (*ShareStore, error) {
+	tokens, err := newStreamTokens(secret, maxTTL)
+	if err != nil {
+		return nil, err
+	}
+	ss := &ShareStore{
+		links:  make(map[string]*ShareLink),
+		store:  store,
+		tokens: tokens,
+		maxTTL: maxTTL,
+	}
+	if _, err := store.LoadDoc(shareLinksDoc, &ss.links); err != nil {
+		return nil, fmt.Errorf("failed to load share links: %w", err)
+	}
+	return ss, nil
+}
+
+// Create issues a link to a workload and returns it with its token
+func (ss *ShareStore) Create(key, sharedBy string, ttl time.Duration, now time.Time) (ShareLink, string) {
+	id := make([]byte, 16)
+	rand.Read(id)
+	link := ShareLink{
+		ID:        base64.RawURLEncoding.EncodeToString(id),
+		Key:       key,
+		SharedBy:  sharedBy,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl).Truncate(time.Second),
+	}
+	token := ss.tokens.signClaims(shareClaims{ID: link.ID, Purpose: sharePurpose, Key: key, Expires: link.ExpiresAt.Unix()})
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	ss.pruneLocked(now)
+	ss.links[link.ID] = &link
+	ss.persistLocked()
+	return link, token
+}
+
+// List returns the unexpired links to a workload, oldest first
+func (ss *ShareStore) List(key string, now time.Time) []ShareLink {
+	if ss == nil {
+		return nil
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	var list []ShareLink
+	for _, link := range ss.links {
+		if link.Key == key && now.Before(link.ExpiresAt) {
+			list = append(list, *link)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	return list
+}
+
+// Revoke ends and returns a link to a workload before its expiry
+func (ss *ShareStore) Revoke(key, id string) *ShareLink {
+	if ss == nil {
+		return nil
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	link, ok := ss.links[id]
+	if !ok || link.Key != key {
+		return nil
+	}
+	delete(ss.links, id)
+	ss.persistLocked()
+	return link
+}
+
+// Verify checks a token's signature, expiry and revocation and returns its link
+func (ss *ShareStore) Verify(token string, now time.Time) (*ShareLink, error) {
+	if ss == nil {
+		return nil, fmt.Errorf("share links are not enabled")
+	}
+	var claims shareClaims
+	if err := ss.tokens.verifyClaims(token, &claims); err != nil {
+		return nil, err
+	}
+	if claims.Purpose != sharePurpose {
+		return nil, fmt.Errorf("not a share token")
+	}
+	if now.Unix() >= claims.Expires {
+		return nil, fmt.Errorf("link expired")
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	link, ok := ss.links[claims.ID]
+	if !ok || link.Key != claims.Key {
+		return nil, fmt.Errorf("link revoked")
+	}
+	copied := *link
+	return &copied, nil
+}
+
+// pruneLocked drops expired links. Caller must hold mu.
+func (ss *ShareStore) pruneLocked(now time.Time) {
+	for id, link := range ss.links {
+		if !now.Before(link.ExpiresAt) {
+			delete(ss.links, id)
+		}
+	}
+}
+
+// persistLocked saves the links to the store. Caller must hold mu.
+func (ss *ShareStore) persistLocked() {
+	if err := ss.store.SaveDoc(shareLinksDoc, ss.links); err != nil {
+		// Links still verify until restart
+		log.Printf("Failed to persist share links: %v", err)
+	}
+}
+
+// shareURL returns where a share token can be opened
+func (s *Server) shareURL(token string) string {
+	return s.dashboardURL + "/api/shared/" + token
+}
+
+// isSharedPath reports whether a path authenticates with a share token
+// instead of a bearer token
+func isSharedPath(path string) bool {
+	return strings.HasPrefix(path, "/api/shared/")
+}
+
+// handleShare lists (GET) or creates (POST) the share links to a workload,
+// or revokes one (DELETE)
+// GET/POST /api/workload/{namespace}/{name}/share
+// DELETE /api/workload/{namespace}/{name}/share/{id}
+func (s *Server) handleShare(w http.ResponseWriter, r *http.Request, key, id string) {
+	if s.shares == nil {
+		http.Error(w, "share links are not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && id == "":
+		links := s.shares.List(key, time.Now())
+		if links == nil {
+			links = []ShareLink{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(links)
+		return
+	case r.Method == http.MethodPost && id == "", r.Method == http.MethodDelete && id != "":
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	identity := identityFromContext(r.Context())
+	if identity == nil {
+		http.Error(w, "sharing workloads requires an authenticated identity", http.StatusUnauthorized)
+		return
+	}
+
+	if r.Method == http.MethodDelete {
+		if s.shares.Revoke(key, id) == nil {
+			http.Error(w, "share link not found", http.StatusNotFound)
+			return
+		}
+		s.audit.RecordRequest(r, identity.Name, "workload.unshare", key, id)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var req ShareRequest
+	if !decodeValid(w, r, shareSchema, &req) {
+		return
+	}
+	ttl := defaultShareTTL
+	if req.TTL != "" {
+		var err error
+		if ttl, err = time.ParseDuration(req.TTL); err != nil || ttl <= 0 {
+			http.Error(w, "invalid ttl, expected a positive duration such as 4h", http.StatusBadRequest)
+			return
+		}
+	}
+	if ttl > s.shares.maxTTL {
+		http.Error(w, fmt.Sprintf("ttl may be at most %s", s.shares.maxTTL), http.StatusBadRequest)
+		return
+	}
+
+	s.cacheMutex.RLock()
+	_, exists := s.statusCache[key]
+	s.cacheMutex.RUnlock()
+	if !exists {
+		http.Error(w, "workload not found", http.StatusNotFound)
+		return
+	}
+
+	link, token := s.shares.Create(key, identity.Name, ttl, time.Now())
+	link.URL = s.shareURL(token)
+	s.audit.RecordRequest(r, identity.Name, "workload.share", key, fmt.Sprintf("link %s until %s", link.ID, link.ExpiresAt.Format(time.RFC3339)))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(link)
+}
+
+// handleShared serves a shared workload's detail and history to the holder
+// of a share link. Operator notes and acknowledgements are left out.
+// GET /api/shared/{token}
+func (s *Server) handleShared(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	now := time.Now()
+	link, err := s.shares.Verify(strings.TrimPrefix(r.URL.Path, "/api/shared/"), now)
+	if err != nil {
+		http.Error(w, "invalid share link: "+err.Error(), http.StatusForbidden)
+		return
+	}
+
+	shared := SharedWorkload{History: []HistoryEvent{}, SharedBy: link.SharedBy, ExpiresAt: link.ExpiresAt}
+	for _, event := range s.history.Events(time.Time{}, now) {
+		if event.Key == link.Key {
+			shared.History = append(shared.History, event)
+		}
+	}
+
+	s.cacheMutex.RLock()
+	status, exists := s.statusCache[link.Key]
+	if exists {
+		shared.Workload = *status
+		shared.Workload.FailedChecks = s.remediate(status)
+	}
+	s.cacheMutex.RUnlock()
+	if !exists {
+		// Show the last known state of a workload removed since it was shared
+		if n := len(shared.History); n > 0 && shared.History[n-1].Status != nil {
+			shared.Workload = *shared.History[n-1].Status
+		} else {
+			http.Error(w, "workload not found", http.StatusNotFound)
+			return
+		}
+	}
+	s.audit.RecordRequest(r, "share:"+link.ID, "workload.share.view", link.Key, "shared by "+link.SharedBy)
+	noteWorkloadKeys(r, link.Key)
+
+	// Keep the token out of the Referer of links followed from the response
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(shared)
+}
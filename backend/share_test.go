@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestShareLinks tests creating a share link, opening it without other
+// credentials, and revoking it
+func TestShareLinks(t *testing.T) {
+	store, err := openStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	shares, err := newShareStore(store, "secret", 48*time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to create share store: %v", err)
+	}
+	audit, _ := newAuditLog(nil)
+	history, _ := newHistory(nil, time.Hour)
+	history.Record([]HistoryEvent{{Time: time.Now(), Key: "icu/pacs", Type: "changed", PreviousStatus: "verified", Status: failedStatus("icu", "pacs")}})
+	server := &Server{
+		statusCache:  map[string]*WorkloadStatus{"icu/pacs": failedStatus("icu", "pacs")},
+		shares:       shares,
+		history:      history,
+		audit:        audit,
+		dashboardURL: "https://dashboard.example.org",
+	}
+	raj := &Identity{Name: "raj"}
+
+	tests := []struct {
+		identity *Identity
+		path     string
+		body     string
+		want     int
+	}{
+		{nil, "/api/workload/icu/pacs/share", `{}`, http.StatusUnauthorized},
+		{raj, "/api/workload/icu/gone/share", `{}`, http.StatusNotFound},
+		{raj, "/api/workload/icu/pacs/share", `{"ttl":"forever"}`, http.StatusBadRequest},
+		{raj, "/api/workload/icu/pacs/share", `{"ttl":"72h"}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		server.handleWorkloadDetail(w, ackRequestAs(tt.identity, "POST", tt.path, tt.body))
+		if w.Code != tt.want {
+			t.Errorf("%s %s: expected %d, got %d", tt.path, tt.body, tt.want, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	server.handleWorkloadDetail(w, ackRequestAs(raj, "POST", "/api/workload/icu/pacs/share", `{"ttl":"4h"}`))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var link ShareLink
+	json.NewDecoder(w.Body).Decode(&link)
+	token, ok := strings.CutPrefix(link.URL, "https://dashboard.example.org/api/shared/")
+	if !ok || link.SharedBy != "raj" || link.ExpiresAt.Sub(time.Now()) > 4*time.Hour {
+		t.Fatalf("Unexpected link %+v", link)
+	}
+
+	open := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		server.handleShared(w, httptest.NewRequest("GET", "/api/shared/"+token, nil))
+		return w
+	}
+	w = open()
+	var shared SharedWorkload
+	if err := json.NewDecoder(w.Body).Decode(&shared); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("Expected the shared workload, got %d: %v", w.Code, err)
+	}
+	if shared.Workload.Name != "pacs" || len(shared.History) != 1 || shared.SharedBy != "raj" {
+		t.Errorf("Unexpected shared workload %+v", shared)
+	}
+
+	// Listing doesn't reveal tokens
+	w = httptest.NewRecorder()
+	server.handleWorkloadDetail(w, ackRequestAs(raj, "GET", "/api/workload/icu/pacs/share", ""))
+	var links []ShareLink
+	json.NewDecoder(w.Body).Decode(&links)
+	if len(links) != 1 || links[0].ID != link.ID || links[0].URL != "" {
+		t.Errorf("Expected the link listed without its URL, got %+v", links)
+	}
+
+	w = httptest.NewRecorder()
+	server.handleWorkloadDetail(w, ackRequestAs(raj, "DELETE", "/api/workload/icu/pacs/share/"+link.ID, ""))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Expected 204, got %d", w.Code)
+	}
+	if w = open(); w.Code != http.StatusForbidden {
+		t.Errorf("Expected a revoked link to be rejected, got %d", w.Code)
+	}
+
+	var actions []string
+	for _, entry := range audit.Entries(time.Time{}) {
+		actions = append(actions, entry.Action)
+	}
+	if strings.Join(actions, ",") != "workload.share,workload.share.view,workload.unshare" {
+		t.Errorf("Unexpected audit trail %v", actions)
+	}
+}
+
+// TestShareTokens tests that share tokens are bound to their purpose,
+// workload and expiry
+func TestShareTokens(t *testing.T) {
+	shares, err := newShareStore(nil, "secret", time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to create share store: %v", err)
+	}
+	now := time.Now()
+	link, token := shares.Create("icu/pacs", "raj", time.Hour, now)
+
+	if got, err := shares.Verify(token, now); err != nil || got.ID != link.ID {
+		t.Errorf("Expected a valid token, got %v", err)
+	}
+	if _, err := shares.Verify(token, now.Add(2*time.Hour)); err == nil {
+		t.Error("Expected an expired token to be rejected")
+	}
+	if _, err := shares.Verify(token+"x", now); err == nil {
+		t.Error("Expected a tampered token to be rejected")
+	}
+
+	// A stream token signed with the same secret is no share token
+	streamToken, _ := shares.tokens.issue(&Identity{Name: "raj"}, now)
+	if _, err := shares.Verify(streamToken, now); err == nil {
+		t.Error("Expected a stream token to be rejected")
+	}
+}
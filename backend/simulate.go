@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// simulateRequest is the body of POST /api/simulate/report
+type simulateRequest struct {
+	Report       json.RawMessage `json:"report"`                  // as the Collector would serve it
+	ImageDigests []string        `json:"image_digests,omitempty"` // stands in for Kubernetes enrichment
+}
+
+// simulationResult is the status the backend would derive from a report
+type simulationResult struct {
+	Status       WorkloadStatus `json:"status"`
+	Violation    bool           `json:"violation"`
+	SchemaErrors []string       `json:"schema_errors,omitempty"`
+}
+
+// handleSimulateReport classifies a hypothetical Collector report with the
+// live policy and gates, without caching or recording anything, so that
+// Collector developers can debug classification mismatches
+// POST /api/simulate/report
+func (s *Server) handleSimulateReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req simulateRequest
+	dec := json.NewDecoder(io.LimitReader(r.Body, maxRequestBody))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil || len(req.Report) == 0 {
+		http.Error(w, `invalid request body, expected {"report": {...}}`, http.StatusBadRequest)
+		return
+	}
+
+	schemaErrors := collectorReportSchema.Validate(req.Report)
+	report, err := decodeCollectorReport(req.Report)
+	if err != nil {
+		details := schemaErrors
+		if len(details) == 0 {
+			details = []string{err.Error()}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(validationError{Error: "report does not identify a pod and would be rejected", Details: details})
+		return
+	}
+	if report.Cluster == "" {
+		report.Cluster = s.localCluster
+	}
+	report.imageDigests = req.ImageDigests
+
+	s.cacheMutex.RLock()
+	evaluator := &Server{ar4siProfile: s.ar4siProfile, imagePolicies: s.imagePolicies, gates: s.gates, clockSkew: s.clockSkew}
+	s.cacheMutex.RUnlock()
+
+	status := evaluator.convertCollectorReport(report)
+	evaluator.evaluateGates([]CollectorReport{report}, []*WorkloadStatus{status})
+	evaluator.checkImagePolicies([]*WorkloadStatus{status})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(simulationResult{Status: *status, Violation: isViolation(status), SchemaErrors: schemaErrors})
+}
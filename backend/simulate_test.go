@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func simulate(t *testing.T, server *Server, body string) (*httptest.ResponseRecorder, simulationResult) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	server.handleSimulateReport(w, httptest.NewRequest("POST", "/api/simulate/report", strings.NewReader(body)))
+	var result simulationResult
+	if w.Code == http.StatusOK {
+		if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+			t.Fatalf("Failed to decode result: %v", err)
+		}
+	}
+	return w, result
+}
+
+// TestSimulateReport tests classifying hypothetical reports with the live
+// gates and image policies
+func TestSimulateReport(t *testing.T) {
+	cmdb := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/registered") {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer cmdb.Close()
+
+	cmdbGate := &ExternalGate{Name: "cmdb", URLTemplate: cmdb.URL + "/{{.Name}}"}
+	if err := cmdbGate.init(); err != nil {
+		t.Fatalf("Failed to init gate: %v", err)
+	}
+	images := []ImagePolicy{{Namespace: "icu", Digests: []string{"sha256:aaa"}}}
+	initImagePolicies(images)
+	server := &Server{statusCache: make(map[string]*WorkloadStatus), gates: []gate{cmdbGate}, imagePolicies: images, localCluster: "site-a"}
+
+	w, result := simulate(t, server, `{"report":{"pod_name":"registered","namespace":"icu","attested":true,"trust_vector":{"hardware":2}},"image_digests":["sha256:aaa"]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if result.Violation || result.Status.AttestationStatus != "verified" || result.Status.Cluster != "site-a" {
+		t.Errorf("Expected a compliant workload in site-a, got %+v", result)
+	}
+
+	_, result = simulate(t, server, `{"report":{"pod_name":"unknown","namespace":"icu","attested":true},"image_digests":["sha256:bbb"]}`)
+	checks := make(map[string]string)
+	for _, check := range result.Status.FailedChecks {
+		checks[check.Name] = check.Severity
+	}
+	if !result.Violation || checks["gate:cmdb"] != severityHigh || checks["image_allowlist"] != severityCritical {
+		t.Errorf("Expected gate and image policy hits, got %+v", result.Status.FailedChecks)
+	}
+
+	_, result = simulate(t, server, `{"report":{"pod_name":"typo","namespace":"icu","attested":"yes"}}`)
+	if result.Status.AttestationStatus != unknownStatus || len(result.SchemaErrors) != 1 {
+		t.Errorf("Expected malformed evidence with schema errors, got %+v", result)
+	}
+
+	if len(server.statusCache) != 0 {
+		t.Error("Expected simulation not to touch the cache")
+	}
+}
+
+// TestSimulateReportRejected tests reports that would be dropped entirely
+func TestSimulateReportRejected(t *testing.T) {
+	server := &Server{}
+
+	w, _ := simulate(t, server, `{"report":{"namespace":"icu","attested":true}}`)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "pod_name: missing required property") {
+		t.Errorf("Expected 400 with schema details, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w, _ = simulate(t, server, `{"pod_name":"not-wrapped"}`)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unwrapped report, got %d", w.Code)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"log"
+	"time"
+)
+
+// defaultSweepInterval is how often cached workloads are re-checked against
+// the freshness limits between Collector polls
+const defaultSweepInterval = 10 * time.Second
+
+// runStalenessSweeper re-evaluates the cache every interval, so a report
+// crossing REPORT_MAX_AGE, a Collector outage outlasting it or a terminating
+// workload reaching the end of its grace period shows within one interval
+// rather than at the next poll. With a long poll interval or an unreachable
+// Collector that can be minutes.
+func (s *Server) runStalenessSweeper(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		s.recordEvents(s.sweepStaleness(now))
+	}
+}
+
+// sweepStaleness applies the freshness limits to the cached workloads as of
+// now and returns the resulting transitions: verified workloads whose report
+// has grown older than REPORT_MAX_AGE, and the workloads of clusters whose
+// Collector has been failing as long, become unknown, and terminating
+// workloads past the grace period are removed
+func (s *Server) sweepStaleness(now time.Time) []HistoryEvent {
+	s.cacheMutex.Lock()
+	defer s.cacheMutex.Unlock()
+
+	cache := make(map[string]*WorkloadStatus, len(s.statusCache))
+	stale, outages, expired := 0, 0, 0
+	for key, status := range s.statusCache {
+		if status.Lifecycle == lifecycleTerminating && status.LastSeen != nil && now.Sub(*status.LastSeen) >= s.gracePeriod {
+			expired++
+			continue
+		}
+		if outage := s.collectorOutage(status.Cluster, now); outage != "" && !isUnknown(status) {
+			cache[key] = markCollectorError(status, outage)
+			outages++
+			continue
+		}
+		report, ok := s.reports[key]
+		if s.clockSkew.maxAge > 0 && ok && status.AttestationStatus == "verified" && s.clockSkew.skew(report.Timestamp, now) != "" {
+			updated := copyStatus(status)
+			updated.FailedChecks = append([]Check(nil), status.FailedChecks...)
+			s.checkClockSkew(report, updated, now)
+			status = updated
+			stale++
+		}
+		cache[key] = status
+	}
+	if stale == 0 && outages == 0 && expired == 0 {
+		return nil
+	}
+
+	events := diffCaches(s.statusCache, cache, now)
+	s.statusCache = cache
+	if len(events) > 0 {
+		s.bumpGenerationLocked()
+	}
+	s.observeOverallStatus()
+
+	s.metrics.Add("dashboard_staleness_sweeps_total", "Workloads changed by the staleness sweeper between polls.", float64(stale), "reason", "report_age")
+	s.metrics.Add("dashboard_staleness_sweeps_total", "Workloads changed by the staleness sweeper between polls.", float64(outages), "reason", "collector_error")
+	s.metrics.Add("dashboard_staleness_sweeps_total", "Workloads changed by the staleness sweeper between polls.", float64(expired), "reason", "grace_period")
+	log.Printf("Staleness sweep: %d reports past max age, %d workloads of unreachable Collectors, %d terminating workloads removed", stale, outages, expired)
+	return events
+}
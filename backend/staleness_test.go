@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestSweepStaleness tests that reports age out and grace periods end
+// between polls
+func TestSweepStaleness(t *testing.T) {
+	now := time.Now()
+	lastSeen := now.Add(-50 * time.Minute)
+	terminating := verifiedStatus("icu", "old")
+	terminating.Lifecycle = lifecycleTerminating
+	terminating.LastSeen = &lastSeen
+	pacs := verifiedStatus("icu", "pacs")
+	pacs.FailedChecks = make([]Check, 0, 4)
+
+	server := &Server{
+		metrics:     newMetrics(),
+		gracePeriod: time.Hour,
+		clockSkew:   clockSkewLimits{maxAge: 30 * time.Minute},
+		statusCache: map[string]*WorkloadStatus{"icu/pacs": pacs, "icu/old": terminating},
+		reports: map[string]CollectorReport{
+			"icu/pacs": {PodName: "pacs", Namespace: "icu", Timestamp: now.Add(-20 * time.Minute)},
+		},
+	}
+
+	if events := server.sweepStaleness(now); len(events) != 0 {
+		t.Fatalf("Expected no changes while everything is fresh, got %+v", events)
+	}
+
+	events := server.sweepStaleness(now.Add(15 * time.Minute))
+	if len(events) != 2 {
+		t.Fatalf("Expected a stale and a removed workload, got %+v", events)
+	}
+	if status := server.statusCache["icu/pacs"]; status.AttestationStatus != unknownStatus || status.UnknownReason != unknownStale || len(status.FailedChecks) != 1 {
+		t.Errorf("Expected icu/pacs to become unknown as stale, got %+v", status)
+	}
+	if pacs.AttestationStatus != "verified" || len(pacs.FailedChecks) != 0 {
+		t.Errorf("Expected the previous cache entry to be left untouched, got %+v", pacs)
+	}
+	if _, ok := server.statusCache["icu/old"]; ok {
+		t.Error("Expected icu/old to be removed after its grace period")
+	}
+	if v := server.metrics.Value("dashboard_staleness_sweeps_total", "reason", "report_age"); v != 1 {
+		t.Errorf("Expected 1 report age sweep, got %g", v)
+	}
+
+	// Already downgraded - nothing more to do
+	if events := server.sweepStaleness(now.Add(20 * time.Minute)); len(events) != 0 {
+		t.Errorf("Expected no further changes, got %+v", events)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// stateSnapshot captures the full cache and the metadata needed to tell
+// later where and under which configuration it was taken. Unlike
+// /api/status it is never localized, filtered or replaced by demo data.
+func (s *Server) stateSnapshot(takenBy string, now time.Time) StateSnapshot {
+	s.cacheMutex.RLock()
+	defer s.cacheMutex.RUnlock()
+
+	snapshot := StateSnapshot{
+		TakenAt:    now.UTC(),
+		TakenBy:    takenBy,
+		Site:       s.siteName,
+		Version:    version,
+		Commit:     commit,
+		ConfigHash: s.configHash,
+		Generation: s.generation,
+		Clusters:   s.clusterSummariesLocked(),
+		Workloads:  make([]WorkloadStatus, 0, len(s.statusCache)),
+	}
+	for _, status := range s.statusCache {
+		snapshot.Workloads = append(snapshot.Workloads, s.decorate(*status))
+	}
+	sortByCluster(snapshot.Workloads)
+	snapshot.OverallStatus = s.debounce.status(statusScope(""), s.overallStatus(snapshot.Workloads))
+	if s.rollup.criticalViolation(snapshot.Workloads) {
+		snapshot.OverallStatus = "violation"
+	}
+	snapshot.DataSource, snapshot.DataAsOf = s.dataSourceLocked()
+	return snapshot
+}
+
+// signSnapshot signs a snapshot with the response signing key, in the
+// flattened JWS JSON serialization so any JOSE library can verify it
+func (s *Server) signSnapshot(snapshot StateSnapshot) (SignedSnapshot, error) {
+	payload, err := json.Marshal(snapshot)
+	if err != nil {
+		return SignedSnapshot{}, err
+	}
+	detached, err := s.signer.sign(payload)
+	if err != nil {
+		return SignedSnapshot{}, err
+	}
+	protected, signature, _ := strings.Cut(detached, "..")
+	return SignedSnapshot{
+		Payload:   base64.RawURLEncoding.EncodeToString(payload),
+		Protected: protected,
+		Signature: signature,
+	}, nil
+}
+
+// handleStateSnapshot returns the full dashboard state, signed and
+// timestamped, for archival in an evidence vault at change-control
+// checkpoints. Requires RESPONSE_SIGNING_KEY.
+// GET /api/export/snapshot
+func (s *Server) handleStateSnapshot(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.signer == nil {
+		http.Error(w, "response signing is not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	actor := "anonymous"
+	if identity := identityFromContext(r.Context()); identity != nil {
+		actor = identity.Name
+	}
+	snapshot := s.stateSnapshot(actor, time.Now())
+	signed, err := s.signSnapshot(snapshot)
+	if err != nil {
+		http.Error(w, "failed to sign snapshot", http.StatusInternalServerError)
+		return
+	}
+	noteWorkloads(r, snapshot.Workloads)
+	s.audit.RecordRequest(r, actor, "snapshot.export", fmt.Sprintf("generation/%d", snapshot.Generation),
+		fmt.Sprintf("%d workloads, overall status %s", len(snapshot.Workloads), snapshot.OverallStatus))
+
+	w.Header().Set("Content-Type", "application/jose+json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="dashboard-snapshot-%s.jws.json"`, snapshot.TakenAt.Format("20060102T150405Z")))
+	json.NewEncoder(w).Encode(signed)
+}
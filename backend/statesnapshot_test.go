@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestHandleStateSnapshot tests that the snapshot carries the whole cache,
+// verifies against the signing key and is audited and access logged
+func TestHandleStateSnapshot(t *testing.T) {
+	audit, _ := newAuditLog(nil)
+	access, _ := newAccessLog(nil, time.Hour)
+	server := &Server{
+		statusCache: map[string]*WorkloadStatus{
+			"lab/imaging": verifiedStatus("lab", "imaging"),
+			"icu/pacs":    failedStatus("icu", "pacs"),
+		},
+		generation: 7,
+		siteName:   "north",
+		configHash: "abc123",
+		audit:      audit,
+		access:     access,
+	}
+	raj := &Identity{Name: "raj"}
+
+	w := httptest.NewRecorder()
+	server.handleStateSnapshot(w, ackRequestAs(raj, "GET", "/api/export/snapshot", ""))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 without a signing key, got %d", w.Code)
+	}
+
+	public, private, _ := ed25519.GenerateKey(rand.Reader)
+	signer, err := newResponseSigner(private)
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+	server.signer = signer
+
+	w = httptest.NewRecorder()
+	server.accessLogMiddleware(http.HandlerFunc(server.handleStateSnapshot)).ServeHTTP(w, ackRequestAs(raj, "GET", "/api/export/snapshot", ""))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var signed SignedSnapshot
+	if err := json.NewDecoder(w.Body).Decode(&signed); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(signed.Payload)
+	if err != nil {
+		t.Fatalf("Invalid payload encoding: %v", err)
+	}
+	if !verifyDetachedJWS(t, signed.Protected+".."+signed.Signature, payload, public) {
+		t.Error("Expected the snapshot signature to verify")
+	}
+
+	var snapshot StateSnapshot
+	if err := json.Unmarshal(payload, &snapshot); err != nil {
+		t.Fatalf("Failed to decode snapshot: %v", err)
+	}
+	if snapshot.TakenBy != "raj" || snapshot.Site != "north" || snapshot.ConfigHash != "abc123" || snapshot.Generation != 7 {
+		t.Errorf("Unexpected metadata %+v", snapshot)
+	}
+	if time.Since(snapshot.TakenAt) > time.Minute || snapshot.OverallStatus != "violation" || len(snapshot.Clusters) != 1 {
+		t.Errorf("Unexpected state %+v", snapshot)
+	}
+	if len(snapshot.Workloads) != 2 || snapshot.Workloads[0].Name != "pacs" || snapshot.Workloads[1].Name != "imaging" {
+		t.Errorf("Expected both workloads in order, got %+v", snapshot.Workloads)
+	}
+
+	entries := audit.Entries(time.Time{})
+	if len(entries) != 1 || entries[0].Action != "snapshot.export" || entries[0].Target != "generation/7" {
+		t.Errorf("Expected the export audited, got %+v", entries)
+	}
+	accessed, _ := access.Entries(context.Background(), time.Time{}, "raj")
+	if len(accessed) != 1 || len(accessed[0].Workloads) != 2 || accessed[0].Workloads[0] != "icu/pacs" {
+		t.Errorf("Expected both workloads in the access log, got %+v", accessed)
+	}
+}
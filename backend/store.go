@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Store persists dashboard state as files in a directory: append-only
+// buckets are JSON lines (<bucket>.jsonl), documents are plain JSON
+// (<name>.json). A nil *Store keeps state in memory only.
+type Store struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// openStore opens (creating if needed) a store rooted at dir
+func openStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create store directory: %w", err)
+	}
+	return &Store{dir: dir}, nil
+}
+
+func (st *Store) bucketPath(bucket string) string {
+	return filepath.Join(st.dir, bucket+".jsonl")
+}
+
+func (st *Store) docPath(name string) string {
+	return filepath.Join(st.dir, name+".json")
+}
+
+// Append adds records to the end of a bucket
+func (st *Store) Append(bucket string, records ...interface{}) error {
+	if st == nil || len(records) == 0 {
+		return nil
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	f, err := os.OpenFile(st.bucketPath(bucket), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o640)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, record := range records {
+		if err := enc.Encode(record); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// Load calls fn for every record in a bucket, in insertion order.
+// A missing bucket is not an error.
+func (st *Store) Load(bucket string, fn func(json.RawMessage) error) error {
+	return st.LoadContext(context.Background(), bucket, fn)
+}
+
+// LoadContext is Load for request handlers: it stops reading with the
+// context's error once the request is cancelled or times out
+func (st *Store) LoadContext(ctx context.Context, bucket string, fn func(json.RawMessage) error) error {
+	if st == nil {
+		return nil
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	f, err := os.Open(st.bucketPath(bucket))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		if err := fn(json.RawMessage(line)); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// Rewrite atomically replaces the contents of a bucket
+func (st *Store) Rewrite(bucket string, records []interface{}) error {
+	if st == nil {
+		return nil
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	return writeFileAtomic(st.bucketPath(bucket), func(w *bufio.Writer) error {
+		enc := json.NewEncoder(w)
+		for _, record := range records {
+			if err := enc.Encode(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// SaveDoc atomically writes a JSON document
+func (st *Store) SaveDoc(name string, v interface{}) error {
+	if st == nil {
+		return nil
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	return writeFileAtomic(st.docPath(name), func(w *bufio.Writer) error {
+		return json.NewEncoder(w).Encode(v)
+	})
+}
+
+// LoadDoc reads a JSON document into v. Returns false if it doesn't exist.
+func (st *Store) LoadDoc(name string, v interface{}) (bool, error) {
+	if st == nil {
+		return false, nil
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	data, err := os.ReadFile(st.docPath(name))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, json.Unmarshal(data, v)
+}
+
+// blobPath returns the sharded path of a content-addressed blob
+func (st *Store) blobPath(bucket, id string) string {
+	return filepath.Join(st.dir, bucket, id[:2], id+".json")
+}
+
+// SaveBlob writes an immutable blob under id, unless it already exists
+func (st *Store) SaveBlob(bucket, id string, data []byte) error {
+	if st == nil {
+		return nil
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	path := st.blobPath(bucket, id)
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, func(w *bufio.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// LoadBlob reads a blob. Returns false if it doesn't exist.
+func (st *Store) LoadBlob(bucket, id string) ([]byte, bool, error) {
+	if st == nil {
+		return nil, false, nil
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	data, err := os.ReadFile(st.blobPath(bucket, id))
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// Check writes, reads back and removes a probe file in the store directory
+func (st *Store) Check() error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	probe := []byte(time.Now().Format(time.RFC3339Nano))
+	path := filepath.Join(st.dir, ".selftest")
+	defer os.Remove(path)
+
+	err := writeFileAtomic(path, func(w *bufio.Writer) error {
+		_, err := w.Write(probe)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("store is not writable: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("store is not readable: %w", err)
+	}
+	if string(data) != string(probe) {
+		return fmt.Errorf("store returned different data than was written")
+	}
+	return nil
+}
+
+// writeFileAtomic writes to a temp file and renames it over path
+func writeFileAtomic(path string, write func(*bufio.Writer) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	if err := write(w); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
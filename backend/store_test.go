@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestStoreAppendAndLoad tests append-only buckets
+func TestStoreAppendAndLoad(t *testing.T) {
+	store, err := openStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+
+	store.Append("events", map[string]int{"n": 1}, map[string]int{"n": 2})
+	store.Append("events", map[string]int{"n": 3})
+
+	var values []int
+	err = store.Load("events", func(raw json.RawMessage) error {
+		var v map[string]int
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		values = append(values, v["n"])
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to load bucket: %v", err)
+	}
+	if len(values) != 3 || values[0] != 1 || values[2] != 3 {
+		t.Errorf("Expected [1 2 3], got %v", values)
+	}
+
+	store.Rewrite("events", []interface{}{map[string]int{"n": 9}})
+	values = nil
+	store.Load("events", func(raw json.RawMessage) error {
+		var v map[string]int
+		json.Unmarshal(raw, &v)
+		values = append(values, v["n"])
+		return nil
+	})
+	if len(values) != 1 || values[0] != 9 {
+		t.Errorf("Expected [9] after rewrite, got %v", values)
+	}
+}
+
+// TestStoreDocs tests document save and load, including a nil store
+func TestStoreDocs(t *testing.T) {
+	store, _ := openStore(t.TempDir())
+
+	var doc map[string]string
+	if found, err := store.LoadDoc("settings", &doc); found || err != nil {
+		t.Errorf("Expected missing document, got found=%v err=%v", found, err)
+	}
+
+	store.SaveDoc("settings", map[string]string{"a": "b"})
+	if found, err := store.LoadDoc("settings", &doc); !found || err != nil || doc["a"] != "b" {
+		t.Errorf("Expected saved document, got %v (found=%v err=%v)", doc, found, err)
+	}
+
+	var nilStore *Store
+	if err := nilStore.Append("events", 1); err != nil {
+		t.Errorf("Expected nil store to be a no-op, got %v", err)
+	}
+}
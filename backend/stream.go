@@ -0,0 +1,317 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// streamBuffer is how many events a subscriber may fall behind before
+	// its overflow policy applies
+	streamBuffer = 64
+
+	streamKeepalive = 30 * time.Second
+)
+
+// eventBroker fans history events out to live SSE and WebSocket subscribers.
+// A subscriber that falls behind by its buffer is disconnected (clients
+// reconnect and re-read /api/status) or loses its oldest events, so a slow
+// wallboard never holds up publishing or grows without bound.
+type eventBroker struct {
+	mu          sync.Mutex
+	subscribers map[*subscriber]struct{}
+	buffer      int
+	overflow    overflowPolicy // default for subscribers not choosing one
+	metrics     *Metrics
+}
+
+// subscriber is one live stream connection
+type subscriber struct {
+	events   chan HistoryEvent
+	filter   func(HistoryEvent) bool
+	overflow overflowPolicy
+}
+
+func newEventBroker() *eventBroker {
+	return &eventBroker{subscribers: make(map[*subscriber]struct{}), buffer: streamBuffer, overflow: overflowDisconnect}
+}
+
+// newEventBrokerFromEnv configures the buffer and default overflow policy of
+// stream subscribers from STREAM_BUFFER and STREAM_OVERFLOW
+func newEventBrokerFromEnv(metrics *Metrics) (*eventBroker, error) {
+	b := newEventBroker()
+	b.metrics = metrics
+	if b.buffer = getEnvInt("STREAM_BUFFER", streamBuffer); b.buffer <= 0 {
+		return nil, fmt.Errorf("STREAM_BUFFER must be positive, got %d", b.buffer)
+	}
+	overflow, err := parseOverflowPolicy(os.Getenv("STREAM_OVERFLOW"), overflowDisconnect)
+	if err != nil {
+		return nil, fmt.Errorf("invalid STREAM_OVERFLOW: %w", err)
+	}
+	b.overflow = overflow
+	return b, nil
+}
+
+// subscribe registers a subscriber receiving the events filter accepts. An
+// empty overflow policy selects the broker's default.
+func (b *eventBroker) subscribe(filter func(HistoryEvent) bool, overflow overflowPolicy) *subscriber {
+	if overflow == "" {
+		overflow = b.overflow
+	}
+	sub := &subscriber{events: make(chan HistoryEvent, b.buffer), filter: filter, overflow: overflow}
+	b.mu.Lock()
+	b.subscribers[sub] = struct{}{}
+	b.mu.Unlock()
+	return sub
+}
+
+// unsubscribe removes a subscriber; safe to call more than once
+func (b *eventBroker) unsubscribe(sub *subscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subscribers[sub]; ok {
+		delete(b.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+// count returns the number of live subscribers
+func (b *eventBroker) count() int {
+	if b == nil {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subscribers)
+}
+
+// publish delivers events to every matching subscriber without blocking,
+// applying the overflow policy of subscribers that have fallen behind
+func (b *eventBroker) publish(events []HistoryEvent) {
+	if b == nil || len(events) == 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subscribers {
+		for _, event := range events {
+			if sub.filter != nil && !sub.filter(event) {
+				continue
+			}
+			if !b.deliverLocked(sub, event) {
+				break
+			}
+		}
+	}
+}
+
+// deliverLocked queues one event for a subscriber and reports whether the
+// subscriber is still connected. Caller must hold mu.
+func (b *eventBroker) deliverLocked(sub *subscriber, event HistoryEvent) bool {
+	select {
+	case sub.events <- event:
+		return true
+	default:
+	}
+
+	if sub.overflow == overflowDisconnect {
+		log.Printf("Dropping slow stream subscriber")
+		b.metrics.Inc("dashboard_stream_disconnects_total", "Stream subscribers disconnected for falling behind.")
+		delete(b.subscribers, sub)
+		close(sub.events)
+		return false
+	}
+
+	// Only publish sends, so there is room once the oldest event is dropped
+	// here or read by the subscriber meanwhile
+	select {
+	case <-sub.events:
+		b.metrics.Inc("dashboard_stream_dropped_events_total", "Events dropped for stream subscribers that fell behind.")
+	default:
+	}
+	sub.events <- event
+	return true
+}
+
+// subscriptionFilter authorizes a stream request and returns the filter for
+// its events, and the field filter for callers who may not see sensitive
+// fields. With auth enabled a valid ?token= from /api/stream-token is
+// required, and events are limited to the tenant's namespaces.
+func (s *Server) subscriptionFilter(r *http.Request) (func(HistoryEvent) bool, *fieldFilter, error) {
+	var namespaces []string
+	var redact *fieldFilter
+	if s.auth != nil {
+		claims, err := s.streamTokens.verify(r.URL.Query().Get("token"), time.Now())
+		if err != nil {
+			return nil, nil, err
+		}
+		namespaces = claims.Namespaces
+
+		identity := &Identity{Name: claims.Subject, Roles: claims.Roles}
+		if s.rbac != nil {
+			identity.permissions = s.rbac.permissionsFor(identity)
+		}
+		if !s.seesSensitiveFields(identity) {
+			redact = s.fieldFilter
+		}
+	}
+	cluster := r.URL.Query().Get("cluster")
+
+	return func(event HistoryEvent) bool {
+		if len(namespaces) > 0 {
+			namespace, _, _ := strings.Cut(event.Key, "/")
+			if !containsString(namespaces, namespace) {
+				return false
+			}
+		}
+		return cluster == "" || (event.Status != nil && event.Status.Cluster == cluster)
+	}, redact, nil
+}
+
+// isStreamPath reports whether a path authenticates with a subscription
+// token instead of a bearer token
+func isStreamPath(path string) bool {
+	return path == "/api/events" || path == "/api/ws"
+}
+
+// handleEvents streams workload events as Server-Sent Events
+// GET /api/events[?token=...][&cluster=...][&overflow=drop-oldest|disconnect]
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if s.stream == nil {
+		http.Error(w, "streaming is not enabled", http.StatusNotFound)
+		return
+	}
+
+	filter, redact, err := s.subscriptionFilter(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid subscription token: %v", err), http.StatusUnauthorized)
+		return
+	}
+	overflow, err := parseOverflowPolicy(r.URL.Query().Get("overflow"), "")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	sub := s.stream.subscribe(filter, overflow)
+	defer s.stream.unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepalive := time.NewTicker(streamKeepalive)
+	defer keepalive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepalive.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+		case event, ok := <-sub.events:
+			if !ok {
+				return
+			}
+			data, _ := json.Marshal(event)
+			data = redact.redactJSON(data)
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+		}
+		flusher.Flush()
+	}
+}
+
+// handleWebSocket streams workload events as WebSocket text messages
+// GET /api/ws[?token=...][&cluster=...][&overflow=drop-oldest|disconnect]
+func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	if s.stream == nil {
+		http.Error(w, "streaming is not enabled", http.StatusNotFound)
+		return
+	}
+
+	filter, redact, err := s.subscriptionFilter(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid subscription token: %v", err), http.StatusUnauthorized)
+		return
+	}
+	overflow, err := parseOverflowPolicy(r.URL.Query().Get("overflow"), "")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	conn, rw, err := acceptWebSocket(w, r)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	sub := s.stream.subscribe(filter, overflow)
+	defer s.stream.unsubscribe(sub)
+
+	var writeMu sync.Mutex
+	write := func(opcode byte, payload []byte) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+		return writeWSFrame(rw.Writer, opcode, payload)
+	}
+
+	// Clients only send control frames - answer pings, stop on close or error
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			opcode, payload, err := readWSFrame(rw.Reader)
+			if err != nil {
+				return
+			}
+			switch opcode {
+			case wsOpPing:
+				write(wsOpPong, payload)
+			case wsOpClose:
+				write(wsOpClose, nil)
+				return
+			}
+		}
+	}()
+
+	keepalive := time.NewTicker(streamKeepalive)
+	defer keepalive.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case <-keepalive.C:
+			if err := write(wsOpPing, nil); err != nil {
+				return
+			}
+		case event, ok := <-sub.events:
+			if !ok {
+				write(wsOpClose, nil)
+				return
+			}
+			data, _ := json.Marshal(event)
+			if err := write(wsOpText, redact.redactJSON(data)); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newStreamTestServer returns a server with auth enabled for two tenants and
+// an HTTP server running the full middleware chain
+func newStreamTestServer(t *testing.T) (*Server, *httptest.Server) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	os.WriteFile(path, []byte(`[
+		{"token":"admin-token","identity":"admin"},
+		{"token":"icu-token","identity":"icu-team","namespaces":["icu"]}
+	]`), 0o600)
+
+	auth, err := loadAuthenticator(path)
+	if err != nil {
+		t.Fatalf("Failed to load tokens: %v", err)
+	}
+	tokens, _ := newStreamTokens("secret", time.Minute)
+	server := &Server{auth: auth, stream: newEventBroker(), streamTokens: tokens}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/events", server.handleEvents)
+	mux.HandleFunc("/api/ws", server.handleWebSocket)
+	mux.HandleFunc("/api/stream-token", server.handleStreamToken)
+	return server, httptest.NewServer(server.authMiddleware(mux))
+}
+
+// streamToken exchanges a bearer token for a subscription token
+func streamToken(t *testing.T, base, bearer string) string {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodPost, base+"/api/stream-token", nil)
+	req.Header.Set("Authorization", "Bearer "+bearer)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to request stream token: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body.Token == "" {
+		t.Fatalf("Expected a stream token, got status %d", resp.StatusCode)
+	}
+	return body.Token
+}
+
+// waitForSubscribers waits until n stream subscribers are registered
+func waitForSubscribers(t *testing.T, broker *eventBroker, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		broker.mu.Lock()
+		count := len(broker.subscribers)
+		broker.mu.Unlock()
+		if count == n {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("Expected %d subscribers", n)
+}
+
+func streamEvents() []HistoryEvent {
+	return []HistoryEvent{
+		{Time: time.Now(), Key: "radiology/scanner", Type: "changed", Status: &WorkloadStatus{Name: "scanner", Namespace: "radiology"}},
+		{Time: time.Now(), Key: "icu/monitor", Type: "changed", Status: &WorkloadStatus{Name: "monitor", Namespace: "icu"}},
+	}
+}
+
+// TestSSEStreamTenantFiltering tests that SSE subscriptions require a stream
+// token and only receive events for the tenant's namespaces
+func TestSSEStreamTenantFiltering(t *testing.T) {
+	server, ts := newStreamTestServer(t)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/api/events")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without a stream token, got %d", resp.StatusCode)
+	}
+
+	token := streamToken(t, ts.URL, "icu-token")
+	resp, err = http.Get(ts.URL + "/api/events?token=" + url.QueryEscape(token))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("Expected event stream, got status %d", resp.StatusCode)
+	}
+
+	waitForSubscribers(t, server.stream, 1)
+	server.stream.publish(streamEvents())
+
+	reader := bufio.NewReader(resp.Body)
+	var data string
+	for data == "" {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Failed to read stream: %v", err)
+		}
+		if strings.HasPrefix(line, "data: ") {
+			data = strings.TrimPrefix(strings.TrimSpace(line), "data: ")
+		}
+	}
+
+	var event HistoryEvent
+	if err := json.Unmarshal([]byte(data), &event); err != nil {
+		t.Fatalf("Invalid event data: %v", err)
+	}
+	if event.Key != "icu/monitor" {
+		t.Errorf("Expected only the tenant's event icu/monitor, got %s", event.Key)
+	}
+}
+
+// TestWebSocketStream tests the WebSocket handshake and event delivery
+func TestWebSocketStream(t *testing.T) {
+	server, ts := newStreamTestServer(t)
+	defer ts.Close()
+
+	token := streamToken(t, ts.URL, "admin-token")
+	conn, err := net.Dial("tcp", strings.TrimPrefix(ts.URL, "http://"))
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	fmt.Fprintf(conn, "GET /api/ws?token=%s HTTP/1.1\r\nHost: test\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n"+
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n\r\n", url.QueryEscape(token))
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatalf("Failed to read handshake: %v", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("Expected 101, got %d", resp.StatusCode)
+	}
+	// Example key and accept value from RFC 6455 section 1.3
+	if accept := resp.Header.Get("Sec-WebSocket-Accept"); accept != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("Unexpected Sec-WebSocket-Accept %q", accept)
+	}
+
+	waitForSubscribers(t, server.stream, 1)
+	server.stream.publish(streamEvents())
+
+	for _, expected := range []string{"radiology/scanner", "icu/monitor"} {
+		opcode, payload, err := readWSFrame(reader)
+		if err != nil {
+			t.Fatalf("Failed to read frame: %v", err)
+		}
+		var event HistoryEvent
+		if opcode != wsOpText || json.Unmarshal(payload, &event) != nil || event.Key != expected {
+			t.Errorf("Expected text frame for %s, got opcode %d: %s", expected, opcode, payload)
+		}
+	}
+
+	// A masked close frame from the client ends the subscription
+	conn.Write([]byte{0x80 | wsOpClose, 0x80, 1, 2, 3, 4})
+	if opcode, _, err := readWSFrame(reader); err != nil || opcode != wsOpClose {
+		t.Errorf("Expected close frame in reply, got opcode %d (%v)", opcode, err)
+	}
+	waitForSubscribers(t, server.stream, 0)
+}
+
+// TestStreamOverflowPolicies tests that a subscriber falling behind is
+// disconnected or loses its oldest events, as it asked
+func TestStreamOverflowPolicies(t *testing.T) {
+	broker := newEventBroker()
+	broker.buffer, broker.metrics = 2, newMetrics()
+	disconnected := broker.subscribe(nil, "")
+	dropping := broker.subscribe(nil, overflowDropOldest)
+
+	for _, key := range []string{"icu/a", "icu/b", "icu/c", "icu/d"} {
+		broker.publish([]HistoryEvent{{Key: key, Type: "changed"}})
+	}
+
+	if broker.count() != 1 {
+		t.Errorf("Expected the default subscriber to be disconnected, got %d subscribers", broker.count())
+	}
+	var keys []string
+	for event := range disconnected.events {
+		keys = append(keys, event.Key)
+	}
+	if len(keys) != 2 {
+		t.Errorf("Expected the disconnected subscriber to keep what it had buffered, got %v", keys)
+	}
+	if first, second := <-dropping.events, <-dropping.events; first.Key != "icu/c" || second.Key != "icu/d" {
+		t.Errorf("Expected the newest events to be kept, got %s and %s", first.Key, second.Key)
+	}
+	if got := broker.metrics.Value("dashboard_stream_dropped_events_total"); got != 2 {
+		t.Errorf("Expected 2 dropped events, got %v", got)
+	}
+	if got := broker.metrics.Value("dashboard_stream_disconnects_total"); got != 1 {
+		t.Errorf("Expected 1 disconnect, got %v", got)
+	}
+}
+
+// TestEventBrokerFromEnv tests the buffer and default policy configuration
+func TestEventBrokerFromEnv(t *testing.T) {
+	t.Setenv("STREAM_BUFFER", "8")
+	t.Setenv("STREAM_OVERFLOW", "drop-oldest")
+	broker, err := newEventBrokerFromEnv(newMetrics())
+	if err != nil {
+		t.Fatalf("Failed to configure streams: %v", err)
+	}
+	if broker.buffer != 8 || broker.overflow != overflowDropOldest {
+		t.Errorf("Unexpected broker %+v", broker)
+	}
+
+	t.Setenv("STREAM_OVERFLOW", "block")
+	if _, err := newEventBrokerFromEnv(newMetrics()); err == nil {
+		t.Error("Expected an unknown overflow policy to be rejected")
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Browsers can't set an Authorization header on EventSource or WebSocket
+// connections, so when auth is enabled streaming clients first exchange their
+// bearer token for a short-lived signed subscription token (POST
+// /api/stream-token) and pass it as ?token= when connecting.
+
+// streamClaims is the signed content of a subscription token
+type streamClaims struct {
+	ID         string   `json:"jti"` // lets a session be revoked before it expires
+	Subject    string   `json:"sub"`
+	Roles      []string `json:"roles,omitempty"`
+	Namespaces []string `json:"ns,omitempty"` // tenant scope; empty = all namespaces
+	Expires    int64    `json:"exp"`
+}
+
+// streamTokens issues and verifies subscription tokens
+type streamTokens struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+// newStreamTokens creates a token issuer. With no secret a random one is
+// generated, so tokens don't survive a restart - fine for tokens this short-lived.
+func newStreamTokens(secret string, ttl time.Duration) (*streamTokens, error) {
+	key := []byte(secret)
+	if secret == "" {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("failed to generate stream token secret: %w", err)
+		}
+	}
+	return &streamTokens{secret: key, ttl: ttl}, nil
+}
+
+// issue returns a signed token for the identity and its expiry
+func (st *streamTokens) issue(identity *Identity, now time.Time) (string, time.Time) {
+	expires := now.Add(st.ttl)
+	id := make([]byte, 16)
+	rand.Read(id)
+	claims := streamClaims{ID: base64.RawURLEncoding.EncodeToString(id), Subject: "anonymous", Expires: expires.Unix()}
+	if identity != nil {
+		claims.Subject = identity.Name
+		claims.Roles = identity.Roles
+		claims.Namespaces = identity.Namespaces
+	}
+
+	return st.signClaims(claims), expires
+}
+
+// verify checks a token's signature and expiry and returns its claims
+func (st *streamTokens) verify(token string, now time.Time) (*streamClaims, error) {
+	if st == nil {
+		return nil, fmt.Errorf("stream tokens are not configured")
+	}
+	var claims streamClaims
+	if err := st.verifyClaims(token, &claims); err != nil {
+		return nil, err
+	}
+	if now.Unix() >= claims.Expires {
+		return nil, fmt.Errorf("token expired")
+	}
+	return &claims, nil
+}
+
+// signClaims encodes and signs a token's claims
+func (st *streamTokens) signClaims(claims interface{}) string {
+	body, _ := json.Marshal(claims)
+	encoded := base64.RawURLEncoding.EncodeToString(body)
+	return encoded + "." + st.sign(encoded)
+}
+
+// verifyClaims checks a token's signature and decodes its claims
+func (st *streamTokens) verifyClaims(token string, claims interface{}) error {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return fmt.Errorf("malformed token")
+	}
+	if !hmac.Equal([]byte(signature), []byte(st.sign(encoded))) {
+		return fmt.Errorf("invalid token signature")
+	}
+
+	body, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return fmt.Errorf("malformed token")
+	}
+	if err := json.Unmarshal(body, claims); err != nil {
+		return fmt.Errorf("malformed token")
+	}
+	return nil
+}
+
+func (st *streamTokens) sign(encoded string) string {
+	mac := hmac.New(sha256.New, st.secret)
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// handleStreamToken issues a subscription token for the authenticated caller
+// POST /api/stream-token
+func (s *Server) handleStreamToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.streamTokens == nil {
+		http.Error(w, "streaming is not enabled", http.StatusNotFound)
+		return
+	}
+
+	token, expires := s.streamTokens.issue(identityFromContext(r.Context()), time.Now())
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"token":      token,
+		"expires_at": expires,
+	})
+}
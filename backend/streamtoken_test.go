@@ -0,0 +1,43 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestStreamTokens tests issuing and verifying subscription tokens
+func TestStreamTokens(t *testing.T) {
+	tokens, err := newStreamTokens("secret", time.Minute)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	now := time.Now()
+
+	token, expires := tokens.issue(&Identity{Name: "icu-team", Namespaces: []string{"icu"}}, now)
+	if !expires.Equal(now.Add(time.Minute)) {
+		t.Errorf("Expected expiry in 1m, got %s", expires.Sub(now))
+	}
+
+	claims, err := tokens.verify(token, now)
+	if err != nil {
+		t.Fatalf("Expected valid token, got %v", err)
+	}
+	if claims.Subject != "icu-team" || len(claims.Namespaces) != 1 || claims.Namespaces[0] != "icu" {
+		t.Errorf("Unexpected claims: %+v", claims)
+	}
+
+	if _, err := tokens.verify(token, now.Add(2*time.Minute)); err == nil {
+		t.Error("Expected expired token to be rejected")
+	}
+
+	encoded, signature, _ := strings.Cut(token, ".")
+	if _, err := tokens.verify(encoded+"x."+signature, now); err == nil {
+		t.Error("Expected tampered token to be rejected")
+	}
+
+	other, _ := newStreamTokens("other-secret", time.Minute)
+	if _, err := other.verify(token, now); err == nil {
+		t.Error("Expected token signed with another secret to be rejected")
+	}
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const suppressionsDoc = "suppressions"
+
+// SuppressionStore holds the workloads operators marked as decommissioned,
+// persisted to the store
+type SuppressionStore struct {
+	mu        sync.Mutex
+	workloads map[string]*SuppressedWorkload
+	store     *Store
+}
+
+// newSuppressionStore loads persisted suppressions
+func newSuppressionStore(store *Store) (*SuppressionStore, error) {
+	ss := &SuppressionStore{
+		workloads: make(map[string]*SuppressedWorkload),
+		store:     store,
+	}
+	if _, err := store.LoadDoc(suppressionsDoc, &ss.workloads); err != nil {
+		return nil, fmt.Errorf("failed to load suppressions: %w", err)
+	}
+	return ss, nil
+}
+
+// List returns the suppressed workloads, sorted by key
+func (ss *SuppressionStore) List() []SuppressedWorkload {
+	if ss == nil {
+		return nil
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	list := make([]SuppressedWorkload, 0, len(ss.workloads))
+	for _, workload := range ss.workloads {
+		list = append(list, *workload)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Key < list[j].Key })
+	return list
+}
+
+// Suppressed reports whether a workload is decommissioned
+func (ss *SuppressionStore) Suppressed(key string) bool {
+	if ss == nil {
+		return false
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	_, ok := ss.workloads[key]
+	return ok
+}
+
+// Set suppresses a workload, replacing any existing suppression
+func (ss *SuppressionStore) Set(workload SuppressedWorkload) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	ss.workloads[workload.Key] = &workload
+	ss.persistLocked()
+}
+
+// Remove lifts and returns a suppression
+func (ss *SuppressionStore) Remove(key string) *SuppressedWorkload {
+	if ss == nil {
+		return nil
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	workload, ok := ss.workloads[key]
+	if !ok {
+		return nil
+	}
+	delete(ss.workloads, key)
+	ss.persistLocked()
+	return workload
+}
+
+// persistLocked saves the suppressions to the store. Caller must hold mu.
+func (ss *SuppressionStore) persistLocked() {
+	if err := ss.store.SaveDoc(suppressionsDoc, ss.workloads); err != nil {
+		log.Printf("Failed to persist suppressions: %v", err)
+	}
+}
+
+// dropSuppressed removes the reports of decommissioned workloads
+func (s *Server) dropSuppressed(reports []CollectorReport) []CollectorReport {
+	if s.suppressions == nil {
+		return reports
+	}
+	kept := reports[:0]
+	for _, report := range reports {
+		if s.suppressions.Suppressed(report.Namespace + "/" + report.PodName) {
+			s.metrics.Inc("dashboard_suppressed_reports_total", "Reports ignored because their workload is decommissioned.")
+			continue
+		}
+		kept = append(kept, report)
+	}
+	return kept
+}
+
+// evictSuppressed drops a newly suppressed workload from the cache at once,
+// rather than at the next poll, and returns the resulting "removed" event.
+// Skipping the grace period is the point: the workload is gone for good.
+func (s *Server) evictSuppressed(key string) []HistoryEvent {
+	s.cacheMutex.Lock()
+	defer s.cacheMutex.Unlock()
+
+	if _, ok := s.statusCache[key]; !ok {
+		return nil
+	}
+	cache := make(map[string]*WorkloadStatus, len(s.statusCache))
+	for k, status := range s.statusCache {
+		if k != key {
+			cache[k] = status
+		}
+	}
+	events := diffCaches(s.statusCache, cache, time.Now())
+	s.statusCache = cache
+	s.bumpGenerationLocked()
+	s.observeOverallStatus()
+	return events
+}
+
+// handleSuppress marks a workload as decommissioned (POST) or reinstates it
+// (DELETE). A reinstated workload returns with its next report.
+// POST/DELETE /api/workload/{namespace}/{name}/suppress
+func (s *Server) handleSuppress(w http.ResponseWriter, r *http.Request, key string) {
+	if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	identity := identityFromContext(r.Context())
+	if identity == nil {
+		http.Error(w, "suppressing workloads requires an authenticated identity", http.StatusUnauthorized)
+		return
+	}
+	if s.suppressions == nil {
+		http.Error(w, "suppression is not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	if r.Method == http.MethodDelete {
+		if s.suppressions.Remove(key) == nil {
+			http.Error(w, "workload is not suppressed", http.StatusNotFound)
+			return
+		}
+		s.audit.RecordRequest(r, identity.Name, "workload.unsuppress", key, "")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var req SuppressRequest
+	if !decodeValid(w, r, suppressSchema, &req) {
+		return
+	}
+	if strings.TrimSpace(req.Reason) == "" {
+		http.Error(w, "a reason is required to suppress a workload", http.StatusBadRequest)
+		return
+	}
+
+	namespace, name, _ := strings.Cut(key, "/")
+	workload := SuppressedWorkload{
+		Key:          key,
+		Namespace:    namespace,
+		Name:         name,
+		Reason:       req.Reason,
+		SuppressedBy: identity.Name,
+		SuppressedAt: time.Now(),
+	}
+	s.suppressions.Set(workload)
+	s.audit.RecordRequest(r, identity.Name, "workload.suppress", key, req.Reason)
+	s.recordEvents(s.evictSuppressed(key))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(workload)
+}
+
+// handleSuppressions lists the decommissioned workloads
+// GET /api/suppressions
+func (s *Server) handleSuppressions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	workloads := s.suppressions.List()
+	if workloads == nil {
+		workloads = []SuppressedWorkload{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(workloads)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestSuppressWorkload tests that a decommissioned workload is dropped at
+// once, its lingering reports are ignored, and reinstating it brings it back
+func TestSuppressWorkload(t *testing.T) {
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]CollectorReport{
+			{PodName: "pilot", Namespace: "radiology", Attested: false, Timestamp: time.Now()},
+			{PodName: "pacs", Namespace: "radiology", Attested: true, Timestamp: time.Now()},
+		})
+	}))
+	defer collector.Close()
+
+	store, err := openStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	suppressions, err := newSuppressionStore(store)
+	if err != nil {
+		t.Fatalf("Failed to create suppression store: %v", err)
+	}
+	expected, _ := newExpectedWorkloadStore(nil)
+	expected.Set(ExpectedWorkload{Key: "radiology/pilot", Namespace: "radiology", Name: "pilot"})
+	audit, _ := newAuditLog(nil)
+	server := &Server{
+		collectorURL: collector.URL,
+		httpClient:   collector.Client(),
+		statusCache:  make(map[string]*WorkloadStatus),
+		suppressions: suppressions,
+		expected:     expected,
+		audit:        audit,
+		metrics:      newMetrics(),
+	}
+	server.fetchFromCollector()
+	raj := &Identity{Name: "raj"}
+
+	w := httptest.NewRecorder()
+	server.handleWorkloadDetail(w, ackRequestAs(nil, "POST", "/api/workload/radiology/pilot/suppress", `{"reason":"CHG-1042"}`))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for anonymous suppression, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	server.handleWorkloadDetail(w, ackRequestAs(raj, "POST", "/api/workload/radiology/pilot/suppress", `{"reason":" "}`))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without a reason, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	server.handleWorkloadDetail(w, ackRequestAs(raj, "POST", "/api/workload/radiology/pilot/suppress", `{"reason":"CHG-1042 pilot retired"}`))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if _, ok := server.statusCache["radiology/pilot"]; ok {
+		t.Error("Expected the suppressed workload to be dropped at once")
+	}
+
+	server.fetchFromCollector()
+	if _, ok := server.statusCache["radiology/pilot"]; ok {
+		t.Error("Expected lingering reports of the suppressed workload to be ignored")
+	}
+	if _, ok := server.statusCache["radiology/pacs"]; !ok {
+		t.Error("Expected other workloads to be unaffected")
+	}
+	if got := server.metrics.Value("dashboard_suppressed_reports_total"); got != 1 {
+		t.Errorf("Expected 1 ignored report, got %v", got)
+	}
+
+	reloaded, _ := newSuppressionStore(store)
+	if list := reloaded.List(); len(list) != 1 || list[0].SuppressedBy != "raj" || list[0].Reason != "CHG-1042 pilot retired" {
+		t.Errorf("Expected the persisted suppression, got %+v", list)
+	}
+	w = httptest.NewRecorder()
+	server.handleSuppressions(w, httptest.NewRequest("GET", "/api/suppressions", nil))
+	var listed []SuppressedWorkload
+	if err := json.Unmarshal(w.Body.Bytes(), &listed); err != nil || len(listed) != 1 || listed[0].Key != "radiology/pilot" {
+		t.Errorf("Expected 1 suppressed workload, got %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	server.handleWorkloadDetail(w, ackRequestAs(raj, "DELETE", "/api/workload/radiology/pilot/suppress", ""))
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Expected 204, got %d", w.Code)
+	}
+	server.fetchFromCollector()
+	if _, ok := server.statusCache["radiology/pilot"]; !ok {
+		t.Error("Expected the reinstated workload back after the next poll")
+	}
+
+	entries := server.audit.Entries(time.Time{})
+	if len(entries) != 2 || entries[0].Action != "workload.suppress" || entries[1].Action != "workload.unsuppress" {
+		t.Errorf("Expected suppress and unsuppress audit entries, got %+v", entries)
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+)
+
+// unknownTEE groups workloads and nodes whose TEE type or TCB level the
+// Collector did not report
+const unknownTEE = "unknown"
+
+// teeAccumulator collects one TEE type's inventory
+type teeAccumulator struct {
+	inventory TEEInventory
+	nodes     map[string]bool
+	clusters  map[string]bool
+	tcb       map[string]*tcbAccumulator
+}
+
+type tcbAccumulator struct {
+	workloads int
+	nodes     map[string]bool
+}
+
+// handleTEEInventory summarizes the TEE types in the fleet, with counts per
+// TCB level, to track TDX/SNP migration progress
+// GET /api/tee-inventory?cluster=east
+func (s *Server) handleTEEInventory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	cluster := r.URL.Query().Get("cluster")
+	s.cacheMutex.RLock()
+	workloads := make([]WorkloadStatus, 0, len(s.statusCache))
+	for _, status := range s.statusCache {
+		if matchesCluster(r, status) {
+			workloads = append(workloads, *status)
+		}
+	}
+	hosts := make([]NodeReport, 0, len(s.nodeReports))
+	for _, report := range s.nodeReports {
+		if cluster == "" || report.Cluster == cluster {
+			hosts = append(hosts, report)
+		}
+	}
+	s.cacheMutex.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(teeInventory(workloads, hosts))
+}
+
+// teeInventory groups workloads and host reports by TEE type and TCB level.
+// Nodes are counted once per type and level however many workloads they run.
+func teeInventory(workloads []WorkloadStatus, hosts []NodeReport) []TEEInventory {
+	byType := make(map[string]*teeAccumulator)
+	add := func(teeType, tcbVersion, cluster, node string) (*teeAccumulator, *tcbAccumulator) {
+		if teeType == "" {
+			teeType = unknownTEE
+		}
+		if tcbVersion == "" {
+			tcbVersion = unknownTEE
+		}
+		acc, ok := byType[teeType]
+		if !ok {
+			acc = &teeAccumulator{
+				inventory: TEEInventory{TEEType: teeType},
+				nodes:     make(map[string]bool),
+				clusters:  make(map[string]bool),
+				tcb:       make(map[string]*tcbAccumulator),
+			}
+			byType[teeType] = acc
+		}
+		level, ok := acc.tcb[tcbVersion]
+		if !ok {
+			level = &tcbAccumulator{nodes: make(map[string]bool)}
+			acc.tcb[tcbVersion] = level
+		}
+		if cluster != "" {
+			acc.clusters[cluster] = true
+		}
+		if node != "" {
+			acc.nodes[cluster+"/"+node] = true
+			level.nodes[cluster+"/"+node] = true
+		}
+		return acc, level
+	}
+
+	for i := range workloads {
+		wl := &workloads[i]
+		acc, level := add(wl.TEEType, wl.TCBVersion, wl.Cluster, wl.NodeName)
+		acc.inventory.Workloads++
+		if wl.Attested {
+			acc.inventory.Attested++
+		}
+		level.workloads++
+	}
+	for i := range hosts {
+		add(hosts[i].TEEType, hosts[i].TCBVersion, hosts[i].Cluster, hosts[i].NodeName)
+	}
+
+	inventory := make([]TEEInventory, 0, len(byType))
+	for _, acc := range byType {
+		acc.inventory.Nodes = len(acc.nodes)
+		acc.inventory.Clusters = []string{}
+		for cluster := range acc.clusters {
+			acc.inventory.Clusters = append(acc.inventory.Clusters, cluster)
+		}
+		sort.Strings(acc.inventory.Clusters)
+		acc.inventory.TCBVersions = []TCBVersionCount{}
+		for version, level := range acc.tcb {
+			acc.inventory.TCBVersions = append(acc.inventory.TCBVersions, TCBVersionCount{Version: version, Workloads: level.workloads, Nodes: len(level.nodes)})
+		}
+		sort.Slice(acc.inventory.TCBVersions, func(i, j int) bool {
+			return acc.inventory.TCBVersions[i].Version < acc.inventory.TCBVersions[j].Version
+		})
+		inventory = append(inventory, acc.inventory)
+	}
+	sort.Slice(inventory, func(i, j int) bool { return inventory[i].TEEType < inventory[j].TEEType })
+	return inventory
+}
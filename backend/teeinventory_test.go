@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestTEEInventory tests grouping by TEE type and TCB level, counting
+// nodes once
+func TestTEEInventory(t *testing.T) {
+	workload := func(cluster, node, teeType, tcb string, attested bool) WorkloadStatus {
+		return WorkloadStatus{Cluster: cluster, NodeName: node, TEEType: teeType, TCBVersion: tcb, Attested: attested}
+	}
+	workloads := []WorkloadStatus{
+		workload("east", "node-1", "tdx", "1.5", true),
+		workload("east", "node-1", "tdx", "1.5", false),
+		workload("east", "node-2", "tdx", "", true),
+		workload("west", "node-1", "snp", "3.7", true),
+		workload("west", "", "", "", true),
+	}
+	hosts := []NodeReport{
+		{Cluster: "east", NodeName: "node-3", TEEType: "tdx", TCBVersion: "1.5", Attested: true},
+	}
+
+	inventory := teeInventory(workloads, hosts)
+	if len(inventory) != 3 || inventory[0].TEEType != "snp" || inventory[1].TEEType != "tdx" || inventory[2].TEEType != unknownTEE {
+		t.Fatalf("Expected snp, tdx and unknown, got %+v", inventory)
+	}
+
+	tdx := inventory[1]
+	if tdx.Workloads != 3 || tdx.Attested != 2 || tdx.Nodes != 3 || len(tdx.Clusters) != 1 || tdx.Clusters[0] != "east" {
+		t.Errorf("Unexpected tdx summary %+v", tdx)
+	}
+	if len(tdx.TCBVersions) != 2 {
+		t.Fatalf("Expected 2 tdx TCB levels, got %+v", tdx.TCBVersions)
+	}
+	if v := tdx.TCBVersions[0]; v.Version != "1.5" || v.Workloads != 2 || v.Nodes != 2 {
+		t.Errorf("Unexpected tdx 1.5 count %+v", v)
+	}
+	if v := tdx.TCBVersions[1]; v.Version != unknownTEE || v.Workloads != 1 || v.Nodes != 1 {
+		t.Errorf("Unexpected unknown TCB count %+v", v)
+	}
+	if unknown := inventory[2]; unknown.Workloads != 1 || unknown.Nodes != 0 {
+		t.Errorf("Unexpected unknown TEE summary %+v", unknown)
+	}
+}
+
+// TestHandleTEEInventory tests the cluster filter
+func TestHandleTEEInventory(t *testing.T) {
+	east, west := verifiedStatus("icu", "a"), verifiedStatus("icu", "b")
+	east.Cluster, east.TEEType = "east", "tdx"
+	west.Cluster, west.TEEType = "west", "snp"
+	server := &Server{statusCache: map[string]*WorkloadStatus{"east/icu/a": east, "west/icu/b": west}}
+
+	w := httptest.NewRecorder()
+	server.handleTEEInventory(w, httptest.NewRequest("GET", "/api/tee-inventory?cluster=west", nil))
+	var inventory []TEEInventory
+	if err := json.NewDecoder(w.Body).Decode(&inventory); err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+	if len(inventory) != 1 || inventory[0].TEEType != "snp" || inventory[0].Workloads != 1 {
+		t.Errorf("Expected only west's snp workload, got %+v", inventory)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// defaultRequestTimeout bounds API requests unless REQUEST_TIMEOUT or an
+// endpoint timeout says otherwise
+const defaultRequestTimeout = 30 * time.Second
+
+// endpointTimeouts override REQUEST_TIMEOUT by path prefix, for endpoints
+// that legitimately take longer. 0 means no timeout: event streams stay open
+// until the client disconnects.
+var endpointTimeouts = map[string]time.Duration{
+	"/api/events":          0,
+	"/api/ws":              0,
+	"/api/status/wait":     maxLongPollTimeout + 10*time.Second,
+	"/api/admin/backup":    5 * time.Minute,
+	"/api/admin/restore":   5 * time.Minute,
+	"/api/admin/selftest":  2 * selftestTimeout,
+	"/api/admin/benchmark": 5 * time.Minute,
+	"/api/admin/import":    5 * time.Minute,
+	"/api/audit":           2 * time.Minute, // exports of the whole log
+	"/api/export/":         2 * time.Minute,
+	"/api/reports/":        2 * time.Minute,
+}
+
+// endpointTimeout returns the timeout of requests to path, by the longest
+// matching prefix in endpointTimeouts
+func (s *Server) endpointTimeout(path string) time.Duration {
+	timeout := s.requestTimeout
+	if timeout <= 0 {
+		timeout = defaultRequestTimeout
+	}
+	longest := 0
+	for prefix, t := range endpointTimeouts {
+		if strings.HasPrefix(path, prefix) && len(prefix) > longest {
+			timeout, longest = t, len(prefix)
+		}
+	}
+	return timeout
+}
+
+// timeoutMiddleware answers API requests that run past their endpoint's
+// timeout with 503 and cancels their context, so store reads and outbound
+// calls made for them stop rather than pinning goroutines. Requests whose
+// client went away are cancelled the same way.
+func (s *Server) timeoutMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timeout := s.endpointTimeout(r.URL.Path)
+		if !strings.HasPrefix(r.URL.Path, "/api/") || timeout == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		http.TimeoutHandler(next, timeout, "request timed out").ServeHTTP(w, r.WithContext(ctx))
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			log.Printf("%s %s from %s timed out after %s", r.Method, r.URL.Path, requestIP(r), timeout)
+			s.metrics.Inc("dashboard_request_timeouts_total", "API requests answered with 503 for running past their timeout.")
+		}
+	})
+}
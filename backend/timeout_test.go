@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestEndpointTimeout tests that the longest matching prefix decides
+func TestEndpointTimeout(t *testing.T) {
+	server := &Server{requestTimeout: 10 * time.Second}
+	tests := []struct {
+		path     string
+		expected time.Duration
+	}{
+		{"/api/status", 10 * time.Second},
+		{"/api/status/wait", maxLongPollTimeout + 10*time.Second},
+		{"/api/events", 0},
+		{"/api/audit/access", 2 * time.Minute},
+		{"/api/admin/backup", 5 * time.Minute},
+		{"/api/admin/refresh", 10 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := server.endpointTimeout(tt.path); got != tt.expected {
+			t.Errorf("Expected %s for %s, got %s", tt.expected, tt.path, got)
+		}
+	}
+	if got := (&Server{}).endpointTimeout("/api/status"); got != defaultRequestTimeout {
+		t.Errorf("Expected the default timeout without REQUEST_TIMEOUT, got %s", got)
+	}
+}
+
+// TestTimeoutMiddleware tests that slow handlers are answered with 503 and
+// see their context cancelled
+func TestTimeoutMiddleware(t *testing.T) {
+	server := &Server{requestTimeout: 50 * time.Millisecond, metrics: newMetrics()}
+	cancelled := make(chan error, 1)
+	handler := server.timeoutMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+			cancelled <- r.Context().Err()
+		case <-time.After(5 * time.Second):
+			cancelled <- nil
+		}
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/status", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503, got %d", w.Code)
+	}
+	select {
+	case err := <-cancelled:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected the handler's context to time out, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Handler still running after the timeout")
+	}
+	if got := server.metrics.Value("dashboard_request_timeouts_total"); got != 1 {
+		t.Errorf("Expected 1 timeout, got %v", got)
+	}
+
+	// Streams are not timed out
+	handler = server.timeoutMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Deadline(); ok {
+			t.Error("Expected no deadline on event streams")
+		}
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/events", nil))
+}
+
+// TestStoreLoadContext tests that reading a bucket stops once the request
+// is cancelled
+func TestStoreLoadContext(t *testing.T) {
+	store, err := openStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		store.Append("access", AccessEntry{Identity: "raj"})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	read := 0
+	err = store.LoadContext(ctx, "access", func(raw json.RawMessage) error {
+		read++
+		cancel()
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) || read != 1 {
+		t.Errorf("Expected reading to stop after 1 record with context.Canceled, got %d records and %v", read, err)
+	}
+
+	access := &AccessLog{store: store}
+	if _, err := access.Entries(ctx, time.Time{}, ""); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected access log export to be cancelled, got %v", err)
+	}
+}
+
+// TestFetchCancelledWithContext tests that a hung Collector doesn't hold up
+// a poll cycle past its context
+func TestFetchCancelledWithContext(t *testing.T) {
+	release := make(chan struct{})
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer collector.Close()
+	defer close(release)
+
+	server := &Server{httpClient: &http.Client{}}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := server.fetchClusterReports(ctx, ClusterConfig{CollectorURL: collector.URL}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the fetch to time out, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the fetch to be abandoned promptly, took %s", elapsed)
+	}
+}
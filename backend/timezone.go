@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	_ "time/tzdata" // DISPLAY_TIMEZONE and ?tz= must work in minimal images without zoneinfo
+)
+
+// defaultDisplayLayout names the zone, so a displayed time is never mistaken
+// for one in another zone
+const defaultDisplayLayout = "2006-01-02 15:04:05 MST"
+
+// displayLayouts are the named DISPLAY_TIME_FORMAT values; anything else is
+// taken as a Go time layout
+var displayLayouts = map[string]string{
+	"iso":     defaultDisplayLayout,
+	"rfc3339": time.RFC3339,
+	"rfc1123": time.RFC1123,
+	"us":      "01/02/2006 03:04:05 PM MST",
+	"eu":      "02/01/2006 15:04:05 MST",
+}
+
+// displayZone formats timestamps for people. Machine-readable time fields
+// are always RFC3339 UTC; the human-facing *_local fields next to them use
+// DISPLAY_TIMEZONE (default UTC) and DISPLAY_TIME_FORMAT, or the request's
+// ?tz=, so incident timelines never mix local and UTC times unlabelled.
+type displayZone struct {
+	loc    *time.Location // nil = UTC
+	layout string         // "" = defaultDisplayLayout
+}
+
+// newDisplayZone parses an IANA time zone name and a layout
+func newDisplayZone(name, format string) (displayZone, error) {
+	var zone displayZone
+	if name != "" {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			return zone, fmt.Errorf("invalid time zone %q: %w", name, err)
+		}
+		zone.loc = loc
+	}
+	if named, ok := displayLayouts[strings.ToLower(format)]; ok {
+		zone.layout = named
+	} else if format != "" {
+		if time.Date(2024, 5, 1, 13, 4, 5, 0, time.UTC).Format(format) == format {
+			return zone, fmt.Errorf("time format %q has no layout elements", format)
+		}
+		zone.layout = format
+	}
+	return zone, nil
+}
+
+// format renders t for display; zero times render as ""
+func (z displayZone) format(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	loc, layout := z.loc, z.layout
+	if loc == nil {
+		loc = time.UTC
+	}
+	if layout == "" {
+		layout = defaultDisplayLayout
+	}
+	return t.In(loc).Format(layout)
+}
+
+// requestZone returns the display zone for a request: the ?tz= override, or
+// the configured one. Writes a 400 for an unknown zone.
+func (s *Server) requestZone(w http.ResponseWriter, r *http.Request) (displayZone, bool) {
+	name := r.URL.Query().Get("tz")
+	if name == "" {
+		return s.displayZone, true
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		http.Error(w, "invalid tz parameter, expected an IANA time zone such as Europe/Madrid", http.StatusBadRequest)
+		return displayZone{}, false
+	}
+	zone := s.displayZone
+	zone.loc = loc
+	return zone, true
+}
+
+// displayWorkloadTimes normalizes the time fields of workloads to UTC and
+// fills their human-facing counterparts
+func displayWorkloadTimes(zone displayZone, workloads []WorkloadStatus) {
+	for i := range workloads {
+		status := &workloads[i]
+		if reported, err := time.Parse(time.RFC3339, status.Timestamp); err == nil {
+			status.Timestamp = reported.UTC().Format(time.RFC3339)
+			status.TimestampLocal = zone.format(reported)
+		}
+		status.LastChecked = status.LastChecked.UTC()
+		status.LastCheckedLocal = zone.format(status.LastChecked)
+	}
+}
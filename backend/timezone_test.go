@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestNewDisplayZone tests parsing the display time settings
+func TestNewDisplayZone(t *testing.T) {
+	at := time.Date(2024, 5, 1, 22, 30, 0, 0, time.UTC)
+
+	zone, err := newDisplayZone("", "")
+	if err != nil || zone.format(at) != "2024-05-01 22:30:00 UTC" {
+		t.Errorf("Expected UTC by default, got %q (%v)", zone.format(at), err)
+	}
+	zone, err = newDisplayZone("Europe/Madrid", "eu")
+	if err != nil || zone.format(at) != "02/05/2024 00:30:00 CEST" {
+		t.Errorf("Expected Madrid time, got %q (%v)", zone.format(at), err)
+	}
+	zone, err = newDisplayZone("America/Mexico_City", "15:04 MST")
+	if err != nil || zone.format(at) != "16:30 CST" {
+		t.Errorf("Expected a custom layout, got %q (%v)", zone.format(at), err)
+	}
+	if zone.format(time.Time{}) != "" {
+		t.Error("Expected zero times to render empty")
+	}
+
+	for _, invalid := range [][2]string{{"Mars/Olympus", ""}, {"", "timestamp"}} {
+		if _, err := newDisplayZone(invalid[0], invalid[1]); err == nil {
+			t.Errorf("Expected %v to be rejected", invalid)
+		}
+	}
+}
+
+// TestDisplayWorkloadTimes tests UTC normalization and ?tz= on responses
+func TestDisplayWorkloadTimes(t *testing.T) {
+	status := verifiedStatus("icu", "pacs")
+	status.Timestamp = "2024-05-01T18:00:00-04:00"
+	status.LastChecked = time.Date(2024, 5, 1, 22, 0, 30, 0, time.FixedZone("EDT", -4*3600))
+	server := &Server{statusCache: map[string]*WorkloadStatus{"icu/pacs": status}}
+
+	w := httptest.NewRecorder()
+	server.handleWorkloadDetail(w, httptest.NewRequest("GET", "/api/workload/icu/pacs?tz=Europe/Madrid", nil))
+	var detail WorkloadStatus
+	if err := json.NewDecoder(w.Body).Decode(&detail); err != nil {
+		t.Fatalf("Failed to decode detail: %v", err)
+	}
+	if detail.Timestamp != "2024-05-01T22:00:00Z" || detail.TimestampLocal != "2024-05-02 00:00:00 CEST" {
+		t.Errorf("Expected UTC timestamp with Madrid local time, got %q / %q", detail.Timestamp, detail.TimestampLocal)
+	}
+	if detail.LastChecked.Location() != time.UTC || detail.LastCheckedLocal != "2024-05-02 04:00:30 CEST" {
+		t.Errorf("Expected UTC last_checked with local time, got %v / %q", detail.LastChecked, detail.LastCheckedLocal)
+	}
+	if status.TimestampLocal != "" {
+		t.Error("Expected the cached status to be left untouched")
+	}
+
+	w = httptest.NewRecorder()
+	server.handleWorkloads(w, httptest.NewRequest("GET", "/api/workloads?tz=Nowhere/Special", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown zone, got %d", w.Code)
+	}
+}
+
+// TestAuditExportLocalTime tests local times in the audit export
+func TestAuditExportLocalTime(t *testing.T) {
+	audit, _ := newAuditLog(nil)
+	audit.Record("raj", "ack.create", "icu/pacs", "")
+	zone, _ := newDisplayZone("Asia/Tokyo", "rfc3339")
+	server := &Server{audit: audit, displayZone: zone}
+
+	w := httptest.NewRecorder()
+	server.handleAudit(w, httptest.NewRequest("GET", "/api/audit", nil))
+	var entries []AuditEntry
+	json.NewDecoder(w.Body).Decode(&entries)
+	if len(entries) != 1 {
+		t.Fatalf("Expected one entry, got %s", w.Body.String())
+	}
+	local, err := time.Parse(time.RFC3339, entries[0].LocalTime)
+	if err != nil || !local.Equal(entries[0].Time.Truncate(time.Second)) || entries[0].Time.Location() != time.UTC {
+		t.Errorf("Expected UTC time with Tokyo local time, got %v / %q", entries[0].Time, entries[0].LocalTime)
+	}
+	if _, offset := local.Zone(); offset != 9*3600 {
+		t.Errorf("Expected +09:00, got %q", entries[0].LocalTime)
+	}
+}
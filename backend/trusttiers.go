@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+)
+
+// trustTier is how one EAR trust tier is labeled and colored
+type trustTier struct {
+	Label string `json:"label,omitempty"`
+	Color string `json:"color,omitempty"`
+}
+
+// trustTierNames are the EAR trust tiers by the names TRUST_TIER_CONFIG uses
+var trustTierNames = map[string]int{"none": 0, "affirming": 2, "warning": 32, "contraindicated": 96}
+
+// defaultTrustTiers label tiers with their EAR names, in the web UI's colors
+var defaultTrustTiers = map[int]trustTier{
+	0:  {Label: "None", Color: "#6c757d"},
+	2:  {Label: "Affirming", Color: "#28a745"},
+	32: {Label: "Warning", Color: "#ffc107"},
+	96: {Label: "Contraindicated", Color: "#dc3545"},
+}
+
+// trustTiers are the labels in effect, set from TRUST_TIER_CONFIG at
+// startup. They are used wherever a tier is written out: workload details,
+// failed checks, trust trends, and so reports and notifications too.
+var trustTiers = defaultTrustTiers
+
+var tierColorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// loadTrustTiers reads label and color overrides from a JSON file keyed by
+// tier name, e.g. {"affirming": {"label": "Pass", "color": "#2e7d32"}}. A
+// field left empty keeps the default's. Labels are shown as configured in
+// every language.
+func loadTrustTiers(path string) (map[int]trustTier, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var configured map[string]trustTier
+	if err := json.Unmarshal(data, &configured); err != nil {
+		return nil, fmt.Errorf("invalid trust tier config: %w", err)
+	}
+
+	tiers := make(map[int]trustTier, len(defaultTrustTiers))
+	for value, tier := range defaultTrustTiers {
+		tiers[value] = tier
+	}
+	for name, override := range configured {
+		value, ok := trustTierNames[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown trust tier %q, expected none, affirming, warning or contraindicated", name)
+		}
+		if override.Color != "" && !tierColorPattern.MatchString(override.Color) {
+			return nil, fmt.Errorf("tier %q: invalid color %q, expected #rgb or #rrggbb", name, override.Color)
+		}
+		tier := tiers[value]
+		if override.Label != "" {
+			tier.Label = override.Label
+		}
+		if override.Color != "" {
+			tier.Color = override.Color
+		}
+		tiers[value] = tier
+	}
+	return tiers, nil
+}
+
+// trustTierColor returns the color a tier is shown in, or "" for values
+// outside the EAR tiers
+func trustTierColor(value int) string {
+	return trustTiers[value].Color
+}
+
+// handleTrustTiers lists the trust tiers with their labels and colors, for
+// legends in clients
+// GET /api/trust-tiers
+func (s *Server) handleTrustTiers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	tiers := make([]TrustTier, 0, len(trustTierNames))
+	for name, value := range trustTierNames {
+		tier := trustTiers[value]
+		tiers = append(tiers, TrustTier{Name: name, Value: value, Label: tier.Label, Color: tier.Color})
+	}
+	sort.Slice(tiers, func(i, j int) bool { return tiers[i].Value < tiers[j].Value })
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tiers)
+}
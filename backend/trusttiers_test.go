@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestTrustTierOverrides tests that relabeled tiers appear in workload
+// details, failed checks and trust trends
+func TestTrustTierOverrides(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tiers.json")
+	os.WriteFile(path, []byte(`{"affirming": {"label": "Pass"}, "warning": {"label": "Review", "color": "#f0ab00"}, "contraindicated": {"label": "Fail"}}`), 0o600)
+	tiers, err := loadTrustTiers(path)
+	if err != nil {
+		t.Fatalf("Failed to load tiers: %v", err)
+	}
+	if tiers[2] != (trustTier{Label: "Pass", Color: "#28a745"}) || tiers[0].Label != "None" {
+		t.Errorf("Expected overrides on top of the defaults, got %+v", tiers)
+	}
+
+	defer func(saved map[int]trustTier) { trustTiers = saved }(trustTiers)
+	trustTiers = tiers
+
+	status := (&Server{}).convertCollectorReport(CollectorReport{PodName: "pacs", Namespace: "icu", Attested: true, TEEType: "snp",
+		TrustVector: &TrustVector{Hardware: 2, Configuration: 32, Executables: 2}})
+	if !strings.Contains(status.Details, "Hardware: Pass, Config: Review, Executables: Pass") {
+		t.Errorf("Expected relabeled details, got %q", status.Details)
+	}
+	if len(status.FailedChecks) != 1 || status.FailedChecks[0].Expected != "Pass" || status.FailedChecks[0].Actual != "Review (32)" {
+		t.Errorf("Expected a relabeled check, got %+v", status.FailedChecks)
+	}
+
+	trend := trustTrend("icu/pacs", []HistoryEvent{{Key: "icu/pacs", Type: "added", Status: status}}, status.LastChecked)
+	if sample := trend.Dimensions["configuration"][0]; sample.Tier != "Review" || sample.Color != "#f0ab00" {
+		t.Errorf("Expected a relabeled trend sample, got %+v", sample)
+	}
+
+	w := httptest.NewRecorder()
+	(&Server{}).handleTrustTiers(w, httptest.NewRequest("GET", "/api/trust-tiers", nil))
+	var listed []TrustTier
+	json.NewDecoder(w.Body).Decode(&listed)
+	if len(listed) != 4 || listed[1] != (TrustTier{Name: "affirming", Value: 2, Label: "Pass", Color: "#28a745"}) {
+		t.Errorf("Unexpected tiers %+v", listed)
+	}
+}
+
+// TestLoadTrustTiersInvalid tests that unknown tiers and colors that aren't
+// hex are rejected
+func TestLoadTrustTiersInvalid(t *testing.T) {
+	for _, config := range []string{
+		`{"passing": {"label": "Pass"}}`,
+		`{"warning": {"color": "red; background: url(x)"}}`,
+		`["Pass"]`,
+	} {
+		path := filepath.Join(t.TempDir(), "tiers.json")
+		os.WriteFile(path, []byte(config), 0o600)
+		if _, err := loadTrustTiers(path); err == nil {
+			t.Errorf("Expected %s to be rejected", config)
+		}
+	}
+}
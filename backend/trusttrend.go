@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// trustVectorValues returns every claim of a trust vector, keyed by its
+// AR4SI name
+func trustVectorValues(tv *TrustVector) map[string]int {
+	return map[string]int{
+		"instance_identity": tv.InstanceIdentity,
+		"configuration":     tv.Configuration,
+		"executables":       tv.Executables,
+		"file_system":       tv.FileSystem,
+		"hardware":          tv.Hardware,
+		"runtime_opaque":    tv.RuntimeOpaque,
+		"storage_opaque":    tv.StorageOpaque,
+		"sourced_data":      tv.SourcedData,
+	}
+}
+
+// trustTrend replays a workload's history events (ordered by time) into one
+// series per trust vector claim, with a sample whenever the claim changed.
+// Series start at since with the value the claim had then.
+func trustTrend(key string, events []HistoryEvent, since time.Time) TrustTrend {
+	trend := TrustTrend{Key: key, Dimensions: make(map[string][]TrustSample)}
+	for _, event := range events {
+		if event.Key != key || event.Type == "removed" || event.Status == nil || event.Status.TrustVector == nil {
+			continue
+		}
+		for name, value := range trustVectorValues(event.Status.TrustVector) {
+			samples := trend.Dimensions[name]
+			if n := len(samples); n > 0 && samples[n-1].Value == value {
+				continue
+			}
+			sample := TrustSample{Time: event.Time, Value: value, Tier: trustTierToString(value), Color: trustTierColor(value)}
+			if n := len(samples); n > 0 && !sample.Time.After(since) {
+				// Only the value in effect at since is kept from before it
+				samples = samples[:n-1]
+			}
+			trend.Dimensions[name] = append(samples, sample)
+		}
+	}
+	for name, samples := range trend.Dimensions {
+		if samples[0].Time.Before(since) {
+			samples[0].Time = since
+		}
+		trend.Dimensions[name] = samples
+	}
+	return trend
+}
+
+// handleTrustTrend returns how a workload's trust vector developed, e.g.
+// Configuration degrading from Affirming to Warning across a rollout
+// GET /api/workload/{ns}/{name}/trust-trend?since=2024-05-01T00:00:00Z
+func (s *Server) handleTrustTrend(w http.ResponseWriter, r *http.Request, key string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var since time.Time
+	if raw := r.URL.Query().Get("since"); raw != "" {
+		var err error
+		if since, err = time.Parse(time.RFC3339, raw); err != nil {
+			http.Error(w, "invalid since parameter, expected RFC3339", http.StatusBadRequest)
+			return
+		}
+	}
+
+	trend := trustTrend(key, s.history.Events(time.Time{}, time.Now()), since)
+	if len(trend.Dimensions) == 0 {
+		s.cacheMutex.RLock()
+		_, exists := s.statusCache[key]
+		s.cacheMutex.RUnlock()
+		if !exists {
+			http.Error(w, "workload not found", http.StatusNotFound)
+			return
+		}
+	}
+
+	noteWorkloadKeys(r, key)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(trend)
+}
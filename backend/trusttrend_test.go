@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestTrustTrend tests replaying trust vector snapshots into per-claim series
+func TestTrustTrend(t *testing.T) {
+	t0 := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	snapshot := func(configuration, executables int) *WorkloadStatus {
+		status := verifiedStatus("icu", "a")
+		status.TrustVector = &TrustVector{Hardware: 2, Configuration: configuration, Executables: executables}
+		return status
+	}
+	events := []HistoryEvent{
+		{Time: t0, Key: "icu/a", Type: "added", Status: snapshot(2, 2)},
+		{Time: t0.Add(time.Hour), Key: "icu/a", Type: "changed", Status: snapshot(2, 32)},
+		{Time: t0.Add(2 * time.Hour), Key: "icu/b", Type: "added", Status: snapshot(96, 96)},
+		{Time: t0.Add(3 * time.Hour), Key: "icu/a", Type: "changed", Status: snapshot(32, 32)},
+		{Time: t0.Add(4 * time.Hour), Key: "icu/a", Type: "changed", Status: failedStatus("icu", "a")},
+	}
+
+	trend := trustTrend("icu/a", events, time.Time{})
+	configuration := trend.Dimensions["configuration"]
+	if len(configuration) != 2 || configuration[1].Value != 32 || configuration[1].Tier != "Warning" || !configuration[1].Time.Equal(t0.Add(3*time.Hour)) {
+		t.Errorf("Expected configuration to degrade to Warning at t0+3h, got %+v", configuration)
+	}
+	if hardware := trend.Dimensions["hardware"]; len(hardware) != 1 || hardware[0].Tier != "Affirming" {
+		t.Errorf("Expected a steady hardware series, got %+v", hardware)
+	}
+	if len(trend.Dimensions) != 8 {
+		t.Errorf("Expected all 8 claims, got %d", len(trend.Dimensions))
+	}
+
+	// A window starts with the value in effect at its start
+	since := t0.Add(90 * time.Minute)
+	executables := trustTrend("icu/a", events, since).Dimensions["executables"]
+	if len(executables) != 1 || executables[0].Value != 32 || !executables[0].Time.Equal(since) {
+		t.Errorf("Expected executables Warning from the window start, got %+v", executables)
+	}
+	configuration = trustTrend("icu/a", events, since).Dimensions["configuration"]
+	if len(configuration) != 2 || !configuration[0].Time.Equal(since) || configuration[0].Value != 2 {
+		t.Errorf("Expected configuration Affirming then Warning, got %+v", configuration)
+	}
+
+	history, _ := newHistory(nil, 0)
+	history.Record(events)
+	server := &Server{history: history, statusCache: map[string]*WorkloadStatus{}}
+	w := httptest.NewRecorder()
+	server.handleWorkloadDetail(w, httptest.NewRequest("GET", "/api/workload/icu/a/trust-trend", nil))
+	var response TrustTrend
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil || response.Key != "icu/a" || len(response.Dimensions["configuration"]) != 2 {
+		t.Errorf("Expected the icu/a trend, got %d %+v", w.Code, response)
+	}
+	w = httptest.NewRecorder()
+	server.handleWorkloadDetail(w, httptest.NewRequest("GET", "/api/workload/icu/missing/trust-trend", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown workload, got %d", w.Code)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// unknownStatus is the AttestationStatus of a workload whose attestation
+// could not be checked. Unlike "failed" it says nothing about the workload
+// itself, so it is not a violation and rolls up by its own rule.
+const unknownStatus = "unknown"
+
+// Reasons a workload's attestation is unknown, in its UnknownReason
+const (
+	unknownCollectorError    = "collector-error"    // its cluster's Collector has been failing for longer than the staleness limit
+	unknownMalformedEvidence = "malformed-evidence" // its report could not be validated against the AR4SI claim registry
+	unknownStale             = "stale"              // its latest report is older than REPORT_MAX_AGE
+)
+
+// How unknown workloads roll up into the overall status, per STATUS_UNKNOWN_ROLLUP
+const (
+	unknownRollupWarning   = "warning"   // any unknown workload degrades the status to warning
+	unknownRollupIgnore    = "ignore"    // unknown workloads don't affect the status
+	unknownRollupViolation = "violation" // unknown workloads count as violations, as failed ones do
+)
+
+// parseUnknownRollup validates STATUS_UNKNOWN_ROLLUP
+func parseUnknownRollup(rule string) (string, error) {
+	switch rule {
+	case "":
+		return unknownRollupWarning, nil
+	case unknownRollupWarning, unknownRollupIgnore, unknownRollupViolation:
+		return rule, nil
+	}
+	return "", fmt.Errorf("unknown rollup rule %q, expected warning, ignore or violation", rule)
+}
+
+// isUnknown reports whether a workload's attestation could not be checked
+func isUnknown(status *WorkloadStatus) bool {
+	return status.AttestationStatus == unknownStatus
+}
+
+// markUnknown sets a workload's attestation to unknown for a reason. The
+// TEE attestation gate is unknown as well: neither passing nor failed.
+func markUnknown(status *WorkloadStatus, reason, details string) {
+	status.Attested = false
+	status.AttestationStatus = unknownStatus
+	status.UnknownReason = reason
+	status.GateTwoStatus = unknownStatus
+	status.Details = details
+}
+
+// collectorOutage returns the error of a cluster whose Collector has not
+// been synced for longer than the staleness limit and failed its last
+// poll, or "" if its workloads' last known statuses still stand. Caller
+// must hold cacheMutex.
+func (s *Server) collectorOutage(cluster string, now time.Time) string {
+	state, ok := s.clusterState[cluster]
+	if !ok || state.LastError == "" || now.Sub(state.LastSync) <= s.staleAfter() {
+		return ""
+	}
+	return state.LastError
+}
+
+// markCollectorError makes a copy of a cached workload whose Collector is
+// out, with its attestation unknown and its last known status in the details
+func markCollectorError(status *WorkloadStatus, outage string) *WorkloadStatus {
+	updated := copyStatus(status)
+	updated.FailedChecks = append([]Check(nil), status.FailedChecks...)
+	markUnknown(updated, unknownCollectorError, fmt.Sprintf("Collector unreachable: %s - last known status %s (%s)",
+		outage, status.AttestationStatus, status.Details))
+	return updated
+}
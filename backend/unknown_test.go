@@ -0,0 +1,88 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestUnknownRollup tests that unknown attestations roll up by their own
+// rule rather than as violations
+func TestUnknownRollup(t *testing.T) {
+	malformed := (&Server{}).convertCollectorReport(CollectorReport{PodName: "pacs", Namespace: "icu", Attested: true, TrustVector: &TrustVector{Hardware: 7}})
+	if !isUnknown(malformed) || malformed.UnknownReason != unknownMalformedEvidence || isViolation(malformed) {
+		t.Fatalf("Expected malformed evidence to be unknown and no violation, got %+v", malformed)
+	}
+	workloads := []WorkloadStatus{*verifiedStatus("icu", "monitor"), *malformed}
+
+	tests := []struct {
+		rule string
+		want string
+	}{
+		{"", "warning"},
+		{unknownRollupWarning, "warning"},
+		{unknownRollupIgnore, "compliant"},
+		{unknownRollupViolation, "violation"},
+	}
+	for _, tt := range tests {
+		if got := (rollupPolicy{unknown: tt.rule}).status(workloads); got != tt.want {
+			t.Errorf("%q: expected %s, got %s", tt.rule, tt.want, got)
+		}
+	}
+	if got := (rollupPolicy{ignoredNamespaces: map[string]bool{"icu": true}}).status(workloads); got != "compliant" {
+		t.Errorf("Expected unknown workloads in ignored namespaces not to count, got %s", got)
+	}
+
+	// A failed gate is a violation whatever the attestation
+	malformed.GateOneStatus = "failed"
+	if !isViolation(malformed) {
+		t.Error("Expected a failed gate to be a violation")
+	}
+
+	if _, err := parseUnknownRollup("red"); err == nil {
+		t.Error("Expected an invalid rollup rule to be rejected")
+	}
+}
+
+// TestSweepCollectorOutage tests that the workloads of a Collector failing
+// for longer than the staleness limit become unknown, keeping their last
+// known status in the details
+func TestSweepCollectorOutage(t *testing.T) {
+	now := time.Now()
+	north := verifiedStatus("icu", "pacs")
+	north.Cluster = "north"
+	south := failedStatus("lab", "imaging")
+	south.Cluster = "south"
+
+	server := &Server{
+		metrics:      newMetrics(),
+		pollInterval: time.Minute,
+		statusCache:  map[string]*WorkloadStatus{"icu/pacs": north, "lab/imaging": south},
+		clusterState: map[string]*clusterSyncState{
+			"north": {LastSync: now.Add(-2 * time.Minute), LastError: "collector returned status 503"},
+			"south": {LastSync: now},
+		},
+	}
+
+	if events := server.sweepStaleness(now); len(events) != 0 {
+		t.Fatalf("Expected last known statuses to stand within the staleness limit, got %+v", events)
+	}
+
+	events := server.sweepStaleness(now.Add(2 * time.Minute))
+	if len(events) != 1 || events[0].Key != "icu/pacs" {
+		t.Fatalf("Expected only icu/pacs to change, got %+v", events)
+	}
+	status := server.statusCache["icu/pacs"]
+	if status.AttestationStatus != unknownStatus || status.UnknownReason != unknownCollectorError || status.Attested {
+		t.Errorf("Expected icu/pacs to become unknown, got %+v", status)
+	}
+	if north.AttestationStatus != "verified" {
+		t.Errorf("Expected the previous cache entry to be left untouched, got %+v", north)
+	}
+	if got := server.rollup.status([]WorkloadStatus{*status, *verifiedStatus("icu", "monitor")}); got != "warning" {
+		t.Errorf("Expected an unreachable Collector to roll up to warning, got %s", got)
+	}
+
+	if events := server.sweepStaleness(now.Add(3 * time.Minute)); len(events) != 0 {
+		t.Errorf("Expected no further changes, got %+v", events)
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+)
+
+// verifierSplitStatus is the AttestationStatus of a workload whose primary
+// and secondary verifiers disagree, e.g. while migrating between Trustee versions
+const verifierSplitStatus = "verifier-split"
+
+// fetchSecondaryReports fetches a cluster's reports from its secondary
+// verifier into byKey. Failures are logged and leave verdicts unflagged.
+func (s *Server) fetchSecondaryReports(ctx context.Context, cluster ClusterConfig, byKey map[string]CollectorReport) {
+	secondary := cluster
+	secondary.CollectorURL = cluster.SecondaryCollectorURL
+
+	reports, err := s.fetchClusterReports(ctx, secondary)
+	if err != nil {
+		log.Printf("Failed to fetch from secondary verifier %s: %v", secondary.CollectorURL, err)
+		return
+	}
+
+	for _, report := range reports {
+		byKey[report.Namespace+"/"+report.PodName] = report
+	}
+}
+
+// compareVerifiers records the secondary verdict on every status and marks
+// disagreements as a verifier split. Workloads the secondary has no report
+// for are marked "missing" but not flagged.
+func (s *Server) compareVerifiers(statuses []*WorkloadStatus, secondary map[string]CollectorReport) {
+	if len(secondary) == 0 {
+		return
+	}
+
+	for _, status := range statuses {
+		if !s.clusterHasSecondary(status.Cluster) {
+			continue
+		}
+
+		report, ok := secondary[status.Namespace+"/"+status.Name]
+		if !ok {
+			status.SecondaryVerdict = "missing"
+			continue
+		}
+
+		status.SecondaryVerdict = verdictString(report.Attested)
+		if report.Attested == status.Attested {
+			continue
+		}
+
+		status.Details = fmt.Sprintf("Verifier split - primary: %s, secondary: %s. %s",
+			verdictString(status.Attested), status.SecondaryVerdict, status.Details)
+		status.AttestationStatus = verifierSplitStatus
+		failCheck(status, "verifier_agreement", verdictString(status.Attested), "secondary verifier: "+status.SecondaryVerdict, severityHigh)
+	}
+}
+
+// clusterHasSecondary reports whether the named cluster has a secondary verifier
+func (s *Server) clusterHasSecondary(name string) bool {
+	for _, cluster := range s.collectorTargets() {
+		if cluster.Name == name {
+			return cluster.SecondaryCollectorURL != ""
+		}
+	}
+	return false
+}
+
+func verdictString(attested bool) string {
+	if attested {
+		return "verified"
+	}
+	return "failed"
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+// TestVerifierSplit tests that disagreeing verdicts from a secondary
+// verifier are flagged as a verifier split and counted as a violation
+func TestVerifierSplit(t *testing.T) {
+	primary := newMockCollector(t, "", []CollectorReport{
+		{PodName: "agree", Namespace: "icu", Attested: true, Timestamp: time.Now()},
+		{PodName: "split", Namespace: "icu", Attested: true, Timestamp: time.Now()},
+		{PodName: "unknown", Namespace: "icu", Attested: true, Timestamp: time.Now()},
+	})
+	defer primary.Close()
+
+	secondary := newMockCollector(t, "", []CollectorReport{
+		{PodName: "agree", Namespace: "icu", Attested: true, Timestamp: time.Now()},
+		{PodName: "split", Namespace: "icu", Attested: false, Timestamp: time.Now()},
+	})
+	defer secondary.Close()
+
+	server := &Server{
+		collectorURL:          primary.URL,
+		secondaryCollectorURL: secondary.URL,
+		statusCache:           make(map[string]*WorkloadStatus),
+		httpClient:            &http.Client{Timeout: 10 * time.Second},
+	}
+
+	server.fetchFromCollector()
+
+	agree := server.statusCache["icu/agree"]
+	if agree.AttestationStatus != "verified" || agree.SecondaryVerdict != "verified" {
+		t.Errorf("Expected agreeing workload to stay verified, got %+v", agree)
+	}
+
+	split := server.statusCache["icu/split"]
+	if split.AttestationStatus != verifierSplitStatus {
+		t.Errorf("Expected status '%s', got '%s'", verifierSplitStatus, split.AttestationStatus)
+	}
+	if split.SecondaryVerdict != "failed" {
+		t.Errorf("Expected secondary verdict 'failed', got '%s'", split.SecondaryVerdict)
+	}
+	if !isViolation(split) {
+		t.Error("Expected verifier split to count as a violation")
+	}
+
+	unknown := server.statusCache["icu/unknown"]
+	if unknown.SecondaryVerdict != "missing" || unknown.AttestationStatus != "verified" {
+		t.Errorf("Expected workload unknown to the secondary to be marked missing only, got %+v", unknown)
+	}
+
+	// An unreachable secondary must not affect the primary verdicts
+	secondary.Close()
+	server.fetchFromCollector()
+
+	if server.statusCache["icu/split"].AttestationStatus != "verified" {
+		t.Errorf("Expected primary verdict when secondary is down, got '%s'", server.statusCache["icu/split"].AttestationStatus)
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// Build information, set with -ldflags "-X main.version=... -X main.commit=...
+// -X main.buildDate=..." (see Dockerfile.backend)
+var (
+	version   = "dev"
+	commit    = "unknown"
+	buildDate = "unknown"
+)
+
+// configEnv are the settings that make up the active configuration hash.
+// Files named by *_CONFIG settings are hashed by content, so sites running
+// the same cluster or policy file get the same hash regardless of its path.
+var configEnv = []string{
+	"ACCESS_LOG_RETENTION", "ACK_DEFAULT_TTL", "ACK_MAX_TTL", "ADMIN_ALLOWED_IPS", "API_RATE_LIMIT",
+	"AR4SI_PROFILE", "CACHE_MAX_ENTRY_BYTES", "CACHE_MAX_WORKLOADS", "CHAOS_CONFIG", "CLOCK_SKEW_TOLERANCE",
+	"CLUSTERS_CONFIG", "CLUSTER_NAME", "COLLECTOR_DISCOVERY_NAMESPACE", "COLLECTOR_DISCOVERY_PORT",
+	"COLLECTOR_DISCOVERY_SCHEME", "COLLECTOR_DISCOVERY_SELECTOR", "COLLECTOR_MAX_REPORTS", "COLLECTOR_MAX_RESPONSE_BYTES",
+	"COLLECTOR_URL", "DASHBOARD_URL", "DISPLAY_TIMEZONE",
+	"DISPLAY_TIME_FORMAT", "EVENT_QUEUE_SIZE", "FEATURE_FLAGS", "FEDERATION_CONFIG", "FEDERATION_POLL_INTERVAL", "FIPS_MODE",
+	"FLAP_THRESHOLD", "FLAP_WINDOW", "GATES_CONFIG",
+	"HEARTBEAT_FAIL_URL", "HEARTBEAT_URL", "HISTORY_RETENTION",
+	"IMAGE_POLICY_CONFIG", "INGEST_CONFIG", "JIRA_CONFIG",
+	"K8S_ENRICHMENT", "LDAP_CONFIG", "MAINTENANCE_CONFIG", "NAMESPACE_CRITICALITY", "NAMESPACE_POLL_INTERVALS",
+	"NODE_ATTESTATION",
+	"NOTIFY_CONFIG", "NOTIFY_DEDUP_WINDOW", "NOTIFY_MAX_QUEUED", "NOTIFY_RATE_LIMIT", "OTEL_EXPORTER_OTLP_ENDPOINT",
+	"OTEL_EXPORTER_OTLP_PROTOCOL", "OTEL_LOGS_EXPORTER", "OTEL_METRICS_EXPORTER", "OTEL_METRIC_EXPORT_INTERVAL",
+	"OTEL_RESOURCE_ATTRIBUTES", "OTEL_SERVICE_NAME", "OWNERSHIP_CACHE_TTL", "OWNERSHIP_CONFIG",
+	"OWNERSHIP_URL", "PHI_SAFE_LOGS", "RAW_REPORT_ARCHIVE",
+	"RBAC_CONFIG", "READ_ONLY", "REDACTION_CONFIG", "REMEDIATION_CONFIG", "REPORT_MAX_AGE", "REQUEST_TIMEOUT",
+	"RESPONSE_REDACTED_FIELDS", "SAML_CONFIG",
+	"SECONDARY_COLLECTOR_URL", "SESSION_COOKIE_SECURE", "SESSION_TTL", "SHARE_LINK_MAX_TTL", "SITE_NAME", "STATUS_IGNORED_NAMESPACES",
+	"STALENESS_SWEEP_INTERVAL", "STATUS_RECOVERY_CYCLES", "STATUS_TOLERATED_VIOLATIONS", "STATUS_UNKNOWN_ROLLUP",
+	"STATUS_VERIFIER_QUORUM", "STATUS_VIOLATION_CYCLES", "STREAM_BUFFER", "STREAM_OVERFLOW", "STREAM_TOKEN_TTL", "TRUSTED_PROXIES",
+	"TRUST_TIER_CONFIG", "WEBHOOK_URLS", "WORKLOAD_GRACE_PERIOD",
+}
+
+// secretEnv only contribute whether they are set, so the hash can't be used
+// to confirm a guessed secret
+var secretEnv = []string{
+	"AUTH_TOKENS_FILE", "OTEL_EXPORTER_OTLP_HEADERS", "RESPONSE_SIGNING_KEY", "SESSION_SECRET", "SHARE_TOKEN_SECRET", "STREAM_TOKEN_SECRET",
+	"WEBHOOK_SECRET",
+}
+
+// configHash returns a short hash of the active configuration, so support
+// can tell at a glance whether two sites are configured alike
+func configHash(getenv func(string) string) string {
+	h := sha256.New()
+	for _, key := range configEnv {
+		value := getenv(key)
+		h.Write([]byte(key + "=" + value + "\n"))
+		if strings.HasSuffix(key, "_CONFIG") && value != "" {
+			data, err := os.ReadFile(value)
+			if err != nil {
+				log.Printf("Failed to read %s for the config hash: %v", key, err)
+			}
+			sum := sha256.Sum256(data)
+			h.Write([]byte(hex.EncodeToString(sum[:]) + "\n"))
+		}
+	}
+	for _, key := range secretEnv {
+		if getenv(key) != "" {
+			h.Write([]byte(key + " set\n"))
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// features lists the optional features enabled in this instance
+func (s *Server) features() []string {
+	enabled := map[string]bool{
+		"fips":                fipsMode,
+		"read-only":           s.readOnly,
+		"multi-cluster":       len(s.clusters) > 0 || len(s.collectors.List()) > 0,
+		"secondary-verifier":  s.secondaryCollectorURL != "",
+		"node-attestation":    s.nodeAttestation,
+		"ar4si":               s.ar4siProfile != "",
+		"store":               s.store != nil,
+		"notifications":       s.notifier != nil,
+		"jira":                s.jira != nil,
+		"heartbeat":           s.heartbeat != nil,
+		"chaos":               s.chaos != nil,
+		"auth":                s.auth != nil,
+		"ldap":                s.auth != nil && s.auth.ldap != nil,
+		"rbac":                s.rbac != nil,
+		"saml":                s.saml != nil,
+		"access-log":          s.access != nil,
+		"raw-archive":         s.rawArchive != nil,
+		"k8s-enrichment":      s.kube != nil,
+		"maintenance":         len(s.maintenance) > 0,
+		"ownership":           s.owners != nil,
+		"gates":               len(s.gates) > 0,
+		"image-policies":      len(s.imagePolicies) > 0,
+		"response-signing":    s.signer != nil,
+		"ip-allowlist":        s.allowlist != nil,
+		"rate-limit":          s.rateLimit != nil,
+		"otlp":                s.otlp != nil,
+		"trusted-proxies":     s.clientIPs != nil && len(s.clientIPs.trustedProxies) > 0,
+		"collector-discovery": s.discovery != nil,
+		"federation":          s.federation != nil,
+		"field-redaction":     s.fieldFilter != nil && s.auth != nil,
+		"ingest":              s.ingest != nil,
+		"share-links":         s.shares != nil && s.auth != nil && s.featureEnabled("share-links"),
+	}
+	if _, ok := log.Writer().(*redactingWriter); ok {
+		enabled["phi-safe-logs"] = true
+	}
+
+	features := []string{}
+	for name, on := range enabled {
+		if on {
+			features = append(features, name)
+		}
+	}
+	sort.Strings(features)
+	return features
+}
+
+// versionInfo is the response of GET /api/version
+type versionInfo struct {
+	Version    string   `json:"version"`
+	Commit     string   `json:"commit"`
+	BuildDate  string   `json:"build_date"`
+	GoVersion  string   `json:"go_version"`
+	Features   []string `json:"features"`
+	ConfigHash string   `json:"config_hash,omitempty"`
+	// Whether TLS and token verification are restricted to approved algorithms
+	FIPSMode bool `json:"fips_mode"`
+	// Whether the cryptography is provided by a FIPS 140 validated module
+	FIPSModule bool `json:"fips_module"`
+}
+
+// handleVersion reports the build, its enabled features and configuration,
+// for comparing behavior across sites
+// GET /api/version
+func (s *Server) handleVersion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(versionInfo{
+		Version:    version,
+		Commit:     commit,
+		BuildDate:  buildDate,
+		GoVersion:  runtime.Version(),
+		Features:   s.features(),
+		ConfigHash: s.configHash,
+		FIPSMode:   fipsMode,
+		FIPSModule: fipsModule(),
+	})
+}
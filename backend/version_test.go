@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestConfigHash tests that the hash tracks settings and config file
+// contents, but not secret values
+func TestConfigHash(t *testing.T) {
+	clusters := filepath.Join(t.TempDir(), "clusters.json")
+	os.WriteFile(clusters, []byte(`[{"name":"east"}]`), 0644)
+	env := map[string]string{"COLLECTOR_URL": "http://collector:8080", "CLUSTERS_CONFIG": clusters, "SESSION_SECRET": "one"}
+	getenv := func(key string) string { return env[key] }
+
+	base := configHash(getenv)
+	if len(base) != 16 || configHash(getenv) != base {
+		t.Fatalf("Expected a stable 16 character hash, got %q", base)
+	}
+
+	env["SESSION_SECRET"] = "two"
+	if configHash(getenv) != base {
+		t.Error("Expected secret values not to change the hash")
+	}
+	delete(env, "SESSION_SECRET")
+	if configHash(getenv) == base {
+		t.Error("Expected unsetting a secret to change the hash")
+	}
+	env["SESSION_SECRET"] = "one"
+
+	os.WriteFile(clusters, []byte(`[{"name":"west"}]`), 0644)
+	if configHash(getenv) == base {
+		t.Error("Expected config file contents to change the hash")
+	}
+	os.WriteFile(clusters, []byte(`[{"name":"east"}]`), 0644)
+
+	env["READ_ONLY"] = "true"
+	if configHash(getenv) == base {
+		t.Error("Expected a setting to change the hash")
+	}
+}
+
+// TestHandleVersion tests that the version endpoint reports build info,
+// enabled features and the FIPS mode
+func TestHandleVersion(t *testing.T) {
+	defer func(mode bool) { fipsMode = mode }(fipsMode)
+	fipsMode = true
+
+	server := &Server{readOnly: true, auth: &Authenticator{}, configHash: "0123456789abcdef"}
+	w := httptest.NewRecorder()
+	server.handleVersion(w, httptest.NewRequest("GET", "/api/version", nil))
+
+	var info versionInfo
+	if err := json.NewDecoder(w.Body).Decode(&info); err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+	if info.Version != version || info.Commit != commit || info.GoVersion == "" || info.ConfigHash != "0123456789abcdef" || !info.FIPSMode {
+		t.Errorf("Unexpected version info %+v", info)
+	}
+	expected := []string{"auth", "fips", "read-only"}
+	if len(info.Features) != len(expected) {
+		t.Fatalf("Expected features %v, got %v", expected, info.Features)
+	}
+	for i := range expected {
+		if info.Features[i] != expected[i] {
+			t.Errorf("Expected features %v, got %v", expected, info.Features)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The wallboard is the lobby display's view of the fleet: a color, the few
+// issues most worth someone's attention and a handful of counts. Kiosks
+// poll it over guest networks, so it carries no workload list, evidence or
+// error text.
+
+// maxWallboardIssues is how many issues the wallboard lists
+const maxWallboardIssues = 3
+
+// criticalityWeight scales an issue's severity by its workload's
+// criticality, so a warning on a critical workload outranks one in dev
+var criticalityWeight = map[string]int{criticalityCritical: 3, criticalityStandard: 2, criticalityDev: 1}
+
+// statusTiers color overall statuses from the trust tier palette, so a
+// TRUST_TIER_CONFIG recolors the wallboard along with the rest of the UI
+var statusTiers = map[string]int{"compliant": 2, "warning": 32, "violation": 96}
+
+// issueTexts are the wallboard texts of failed checks and unknown
+// attestations, short enough for one line of a lobby display
+var issueTexts = map[string]string{
+	"tee_attestation":       "TEE attestation failed",
+	"evidence_format":       "Malformed attestation evidence",
+	"attestation_freshness": "Restarted since last attestation",
+	"clock_skew":            "Report timestamp out of tolerance",
+	"report_conflict":       "Conflicting reports",
+	"verifier_agreement":    "Verifiers disagree",
+	"host_attestation":      "Node failed attestation",
+	"image_allowlist":       "Image not allowlisted",
+	unknownCollectorError:   "Collector unreachable",
+	unknownStale:            "Attestation report too old",
+}
+
+// issueSeverity returns the severity of a workload's most urgent problem,
+// or "" if it has none. Unknown attestations without a failed check are
+// warnings.
+func issueSeverity(status *WorkloadStatus) string {
+	severity := highestSeverity(status.FailedChecks)
+	switch {
+	case severity != "":
+		return severity
+	case isViolation(status):
+		return severityCritical
+	case isUnknown(status):
+		return severityWarning
+	}
+	return ""
+}
+
+// issueText describes a workload's most urgent problem
+func issueText(status *WorkloadStatus, severity string) string {
+	for _, check := range status.FailedChecks {
+		if check.Severity != severity {
+			continue
+		}
+		if text, ok := issueTexts[check.Name]; ok {
+			return text
+		}
+		if claim, ok := strings.CutPrefix(check.Name, "trust_vector."); ok {
+			return "Trust vector " + claim + " not " + strings.ToLower(trustTierToString(2))
+		}
+		if gate, ok := strings.CutPrefix(check.Name, "gate:"); ok {
+			return gate + " gate failing"
+		}
+	}
+	if text, ok := issueTexts[status.UnknownReason]; ok {
+		return text
+	}
+	return "Attestation " + status.AttestationStatus
+}
+
+// wallboard summarizes workloads for the lobby display. Issues are ranked by
+// severity weighted by criticality, unacknowledged first among equals.
+// Workloads in maintenance or in ignored namespaces are not issues.
+func (s *Server) wallboard(workloads []WorkloadStatus) WallboardResponse {
+	type ranked struct {
+		issue  WallboardIssue
+		weight int
+	}
+	var issues []ranked
+	board := WallboardResponse{Counts: WallboardCounts{Workloads: len(workloads)}}
+	for i := range workloads {
+		status := &workloads[i]
+		if isViolation(status) {
+			board.Counts.Violations++
+		}
+		if isUnknown(status) {
+			board.Counts.Unknown++
+		}
+		severity := issueSeverity(status)
+		if severity == "" || status.Maintenance != "" || s.rollup.ignoredNamespaces[status.Namespace] {
+			continue
+		}
+		board.Counts.Issues++
+		if status.Acknowledgement != nil {
+			board.Counts.Acknowledged++
+		}
+		issues = append(issues, ranked{
+			issue: WallboardIssue{
+				Workload:     status.Namespace + "/" + status.Name,
+				Cluster:      status.Cluster,
+				Severity:     severity,
+				Text:         issueText(status, severity),
+				Acknowledged: status.Acknowledgement != nil,
+			},
+			weight: severityRank[severity] * criticalityWeight[s.rollup.workloadCriticality(status)],
+		})
+	}
+
+	sort.Slice(issues, func(i, j int) bool {
+		a, b := issues[i], issues[j]
+		switch {
+		case a.weight != b.weight:
+			return a.weight > b.weight
+		case a.issue.Acknowledged != b.issue.Acknowledged:
+			return !a.issue.Acknowledged
+		}
+		return a.issue.Workload < b.issue.Workload
+	})
+	board.Issues = make([]WallboardIssue, 0, maxWallboardIssues)
+	for i := 0; i < len(issues) && i < maxWallboardIssues; i++ {
+		board.Issues = append(board.Issues, issues[i].issue)
+	}
+	return board
+}
+
+// handleWallboard serves the compact fleet summary polled by lobby displays
+// GET /api/wallboard?cluster=north
+func (s *Server) handleWallboard(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	now := time.Now()
+
+	s.cacheMutex.RLock()
+	workloads := make([]WorkloadStatus, 0, len(s.statusCache))
+	for key, status := range s.statusCache {
+		if !matchesCluster(r, status) {
+			continue
+		}
+		// Only the operator state the ranking needs; the checks are not
+		// shipped, so they need no remediation hints
+		workload := *status
+		workload.Acknowledgement = s.acks.Get(key)
+		workload.Pinned = s.watchlist.Pinned(key, now)
+		workloads = append(workloads, workload)
+	}
+	if len(s.statusCache) == 0 {
+		workloads = getDemoResponse().Workloads
+	}
+	overall := s.debounce.status(statusScope(r.URL.Query().Get("cluster")), s.overallStatus(workloads))
+	if s.rollup.criticalViolation(workloads) {
+		overall = "violation"
+	}
+	dataSource, dataAsOf := s.dataSourceLocked()
+	generation := s.generation
+	s.cacheMutex.RUnlock()
+
+	board := s.wallboard(workloads)
+	board.OverallStatus = overall
+	board.Color = trustTierColor(statusTiers[overall])
+	board.DataSource, board.DataAsOf = dataSource, dataAsOf
+	board.Stale = dataSource != sourceDemo && (dataAsOf == nil || (s.staleAfter() > 0 && now.Sub(*dataAsOf) > s.staleAfter()))
+	board.LastUpdated = now
+
+	// Only the workloads named by an issue are shown
+	shown := make([]WorkloadStatus, 0, len(board.Issues))
+	for _, issue := range board.Issues {
+		for i := range workloads {
+			if workloads[i].Namespace+"/"+workloads[i].Name == issue.Workload {
+				shown = append(shown, workloads[i])
+				break
+			}
+		}
+	}
+	noteWorkloads(r, shown)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Cache-Generation", strconv.FormatUint(generation, 10))
+	json.NewEncoder(w).Encode(board)
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestHandleWallboard tests that issues are ranked by severity weighted by
+// criticality, that the workload list is not shipped, and that only the
+// workloads named are access logged
+func TestHandleWallboard(t *testing.T) {
+	criticality, _ := parseCriticality("icu=critical, dev-*=dev")
+	failed := func(ns, name string) *WorkloadStatus {
+		status := failedStatus(ns, name)
+		status.Details = "TEE evidence verification failed at https://kbs.internal:8080"
+		failCheck(status, "tee_attestation", "attested", status.Details, severityCritical)
+		return status
+	}
+	monitor := verifiedStatus("icu", "monitor")
+	markUnknown(monitor, unknownCollectorError, "Collector unreachable: dial tcp 10.0.0.7:8080")
+	maintained := failed("lab", "analyzer")
+	maintained.Maintenance = "firmware update"
+
+	acks, _ := newAckStore(nil, time.Hour, 4*time.Hour)
+	acks.Set(Acknowledgement{Key: "radiology/pacs", By: "raj", ExpiresAt: time.Now().Add(time.Hour)})
+	lastSync := time.Now().Add(-time.Hour)
+	access, _ := newAccessLog(nil, time.Hour)
+	server := &Server{
+		access:       access,
+		rollup:       rollupPolicy{criticality: criticality},
+		acks:         acks,
+		pollInterval: time.Minute,
+		clusterState: map[string]*clusterSyncState{"local": {LastSync: lastSync}},
+		statusCache: map[string]*WorkloadStatus{
+			"icu/ai-model":      failed("icu", "ai-model"),
+			"icu/monitor":       monitor,
+			"radiology/pacs":    failed("radiology", "pacs"),
+			"radiology/ris":     failed("radiology", "ris"),
+			"radiology/viewer":  verifiedStatus("radiology", "viewer"),
+			"dev-lab/prototype": failed("dev-lab", "prototype"),
+			"lab/analyzer":      maintained,
+		},
+	}
+
+	w := httptest.NewRecorder()
+	server.accessLogMiddleware(http.HandlerFunc(server.handleWallboard)).ServeHTTP(w, ackRequestAs(&Identity{Name: "kiosk"}, "GET", "/api/wallboard", ""))
+	body := w.Body.String()
+	var board WallboardResponse
+	if err := json.Unmarshal([]byte(body), &board); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if board.OverallStatus != "violation" || board.Color != trustTierColor(96) {
+		t.Errorf("Expected a red violation, got %s %s", board.OverallStatus, board.Color)
+	}
+	var got []string
+	for _, issue := range board.Issues {
+		got = append(got, issue.Workload+" "+issue.Severity+" "+issue.Text)
+	}
+	expected := "icu/ai-model critical TEE attestation failed," +
+		"radiology/ris critical TEE attestation failed," +
+		"radiology/pacs critical TEE attestation failed"
+	if strings.Join(got, ",") != expected {
+		t.Errorf("Unexpected issues %v", got)
+	}
+	if !board.Issues[2].Acknowledged {
+		t.Error("Expected the acknowledged issue flagged")
+	}
+	want := WallboardCounts{Workloads: 7, Issues: 5, Violations: 5, Unknown: 1, Acknowledged: 1}
+	if board.Counts != want {
+		t.Errorf("Expected counts %+v, got %+v", want, board.Counts)
+	}
+	if !board.Stale || board.DataAsOf == nil || !board.DataAsOf.Equal(lastSync) {
+		t.Errorf("Expected data an hour old to be stale, got %v as of %v", board.Stale, board.DataAsOf)
+	}
+	for _, leak := range []string{"workloads\":[", "kbs.internal", "10.0.0.7", "details"} {
+		if strings.Contains(body, leak) {
+			t.Errorf("Expected %q not to be shipped to the wallboard: %s", leak, body)
+		}
+	}
+	entries, _ := access.Entries(context.Background(), time.Time{}, "kiosk")
+	if len(entries) != 1 || strings.Join(entries[0].Workloads, ",") != "icu/ai-model,radiology/pacs,radiology/ris" {
+		t.Errorf("Expected the issues' workloads in the access log, got %+v", entries)
+	}
+}
+
+// TestIssueText tests the short texts of the issues on the wallboard
+func TestIssueText(t *testing.T) {
+	status := func(check, severity string) *WorkloadStatus {
+		status := verifiedStatus("icu", "pacs")
+		failCheck(status, "clock_skew", "", "", severityWarning)
+		failCheck(status, check, "", "", severity)
+		return status
+	}
+	unknown := verifiedStatus("icu", "pacs")
+	markUnknown(unknown, unknownStale, "")
+
+	tests := []struct {
+		status *WorkloadStatus
+		want   string
+	}{
+		{status("image_allowlist", severityHigh), "Image not allowlisted"},
+		{status("trust_vector.hardware", severityHigh), "Trust vector hardware not affirming"},
+		{status("gate:cmdb", severityCritical), "cmdb gate failing"},
+		{status("host_attestation", severityWarning), "Report timestamp out of tolerance"},
+		{unknown, "Attestation report too old"},
+	}
+	for _, tt := range tests {
+		if got := issueText(tt.status, issueSeverity(tt.status)); got != tt.want {
+			t.Errorf("Expected %q, got %q", tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const watchlistDoc = "watchlist"
+
+// Watchlist holds the workloads operators pinned, persisted to the store.
+// Pins with an end time lapse on their own once it has passed.
+type Watchlist struct {
+	mu        sync.Mutex
+	workloads map[string]*WatchedWorkload
+	store     *Store
+}
+
+// newWatchlist loads persisted pins
+func newWatchlist(store *Store) (*Watchlist, error) {
+	wl := &Watchlist{
+		workloads: make(map[string]*WatchedWorkload),
+		store:     store,
+	}
+	if _, err := store.LoadDoc(watchlistDoc, &wl.workloads); err != nil {
+		return nil, fmt.Errorf("failed to load watchlist: %w", err)
+	}
+	return wl, nil
+}
+
+// List returns the pinned workloads, sorted by key
+func (wl *Watchlist) List(now time.Time) []WatchedWorkload {
+	if wl == nil {
+		return nil
+	}
+
+	wl.mu.Lock()
+	defer wl.mu.Unlock()
+
+	list := make([]WatchedWorkload, 0, len(wl.workloads))
+	for _, workload := range wl.workloads {
+		if pinned(workload, now) {
+			list = append(list, *workload)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Key < list[j].Key })
+	return list
+}
+
+// Pinned reports whether a workload is on the watchlist
+func (wl *Watchlist) Pinned(key string, now time.Time) bool {
+	if wl == nil {
+		return false
+	}
+
+	wl.mu.Lock()
+	defer wl.mu.Unlock()
+	return pinned(wl.workloads[key], now)
+}
+
+// pinned reports whether a pin is in effect
+func pinned(workload *WatchedWorkload, now time.Time) bool {
+	return workload != nil && (workload.Until == nil || now.Before(*workload.Until))
+}
+
+// Set pins a workload, replacing any existing pin
+func (wl *Watchlist) Set(workload WatchedWorkload) {
+	wl.mu.Lock()
+	defer wl.mu.Unlock()
+
+	wl.workloads[workload.Key] = &workload
+	wl.persistLocked()
+}
+
+// Remove unpins and returns a workload
+func (wl *Watchlist) Remove(key string) *WatchedWorkload {
+	if wl == nil {
+		return nil
+	}
+
+	wl.mu.Lock()
+	defer wl.mu.Unlock()
+
+	workload, ok := wl.workloads[key]
+	if !ok {
+		return nil
+	}
+	delete(wl.workloads, key)
+	wl.persistLocked()
+	return workload
+}
+
+// persistLocked saves the watchlist to the store. Caller must hold mu.
+func (wl *Watchlist) persistLocked() {
+	if err := wl.store.SaveDoc(watchlistDoc, wl.workloads); err != nil {
+		log.Printf("Failed to persist watchlist: %v", err)
+	}
+}
+
+// sortPinnedFirst moves pinned workloads to the front of a listing, keeping
+// the order within each group
+func sortPinnedFirst(workloads []WorkloadStatus) {
+	sort.SliceStable(workloads, func(i, j int) bool {
+		return workloads[i].Pinned && !workloads[j].Pinned
+	})
+}
+
+// handleWatchlist lists (GET) or pins (POST) workloads
+// GET/POST /api/watchlist
+func (s *Server) handleWatchlist(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		workloads := s.watchlist.List(time.Now())
+		if workloads == nil {
+			workloads = []WatchedWorkload{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(workloads)
+
+	case http.MethodPost:
+		identity := identityFromContext(r.Context())
+		if identity == nil {
+			http.Error(w, "pinning workloads requires an authenticated identity", http.StatusUnauthorized)
+			return
+		}
+		if s.watchlist == nil {
+			http.Error(w, "the watchlist is not enabled", http.StatusServiceUnavailable)
+			return
+		}
+
+		var req WatchRequest
+		if !decodeValid(w, r, watchSchema, &req) {
+			return
+		}
+		if req.Namespace == "" || req.Name == "" || strings.Contains(req.Namespace, "/") || strings.Contains(req.Name, "/") {
+			http.Error(w, "namespace and name are required and may not contain '/'", http.StatusBadRequest)
+			return
+		}
+		now := time.Now()
+		if req.Until != nil && !req.Until.After(now) {
+			http.Error(w, "until must be in the future", http.StatusBadRequest)
+			return
+		}
+
+		workload := WatchedWorkload{
+			Key:       req.Namespace + "/" + req.Name,
+			Namespace: req.Namespace,
+			Name:      req.Name,
+			Reason:    req.Reason,
+			PinnedBy:  identity.Name,
+			PinnedAt:  now,
+			Until:     req.Until,
+		}
+		s.watchlist.Set(workload)
+		s.audit.RecordRequest(r, identity.Name, "watchlist.pin", workload.Key, workload.Reason)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(workload)
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleWatchedWorkload unpins a workload
+// DELETE /api/watchlist/{namespace}/{name}
+func (s *Server) handleWatchedWorkload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	identity := identityFromContext(r.Context())
+	if identity == nil {
+		http.Error(w, "unpinning workloads requires an authenticated identity", http.StatusUnauthorized)
+		return
+	}
+
+	key, action := splitWorkloadPath(strings.TrimPrefix(r.URL.Path, "/api/watchlist/"))
+	if !strings.Contains(key, "/") || action != "" {
+		http.NotFound(w, r)
+		return
+	}
+	if s.watchlist.Remove(key) == nil {
+		http.Error(w, "workload is not pinned", http.StatusNotFound)
+		return
+	}
+	s.audit.RecordRequest(r, identity.Name, "watchlist.unpin", key, "")
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestHandleWatchlist tests pinning, listing and unpinning workloads, and
+// that pins persist
+func TestHandleWatchlist(t *testing.T) {
+	store, err := openStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	watchlist, err := newWatchlist(store)
+	if err != nil {
+		t.Fatalf("Failed to create watchlist: %v", err)
+	}
+	audit, _ := newAuditLog(nil)
+	server := &Server{watchlist: watchlist, audit: audit}
+	raj := &Identity{Name: "raj"}
+
+	w := httptest.NewRecorder()
+	server.handleWatchlist(w, ackRequestAs(nil, "POST", "/api/watchlist", `{"namespace":"icu","name":"sepsis-model"}`))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for anonymous pinning, got %d", w.Code)
+	}
+
+	for _, body := range []string{`{"namespace":"icu"}`, `{"namespace":"icu","name":"a/b"}`, `{"namespace":"icu","name":"sepsis-model","until":"2020-01-01T00:00:00Z"}`} {
+		w = httptest.NewRecorder()
+		server.handleWatchlist(w, ackRequestAs(raj, "POST", "/api/watchlist", body))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d", body, w.Code)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	server.handleWatchlist(w, ackRequestAs(raj, "POST", "/api/watchlist", `{"namespace":"icu","name":"sepsis-model","reason":"go-live"}`))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	reloaded, err := newWatchlist(store)
+	if err != nil {
+		t.Fatalf("Failed to reload watchlist: %v", err)
+	}
+	if list := reloaded.List(time.Now()); len(list) != 1 || list[0].Key != "icu/sepsis-model" || list[0].PinnedBy != "raj" || list[0].Reason != "go-live" {
+		t.Errorf("Expected persisted pin, got %+v", list)
+	}
+
+	w = httptest.NewRecorder()
+	server.handleWatchlist(w, httptest.NewRequest("GET", "/api/watchlist", nil))
+	var listed []WatchedWorkload
+	if err := json.Unmarshal(w.Body.Bytes(), &listed); err != nil || len(listed) != 1 {
+		t.Errorf("Expected 1 pinned workload, got %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	server.handleWatchedWorkload(w, ackRequestAs(raj, "DELETE", "/api/watchlist/icu/sepsis-model", ""))
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Expected 204, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	server.handleWatchedWorkload(w, ackRequestAs(raj, "DELETE", "/api/watchlist/icu/sepsis-model", ""))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unpinned workload, got %d", w.Code)
+	}
+
+	entries := server.audit.Entries(time.Time{})
+	if len(entries) != 2 || entries[0].Action != "watchlist.pin" || entries[1].Action != "watchlist.unpin" {
+		t.Errorf("Expected pin and unpin audit entries, got %+v", entries)
+	}
+}
+
+// TestWatchlistExpiry tests that pins with an end time lapse
+func TestWatchlistExpiry(t *testing.T) {
+	watchlist, _ := newWatchlist(nil)
+	now := time.Now()
+	until := now.Add(time.Hour)
+	watchlist.Set(WatchedWorkload{Key: "icu/sepsis-model", Until: &until})
+
+	if !watchlist.Pinned("icu/sepsis-model", now) {
+		t.Error("Expected the workload to be pinned before its end time")
+	}
+	if watchlist.Pinned("icu/sepsis-model", now.Add(2*time.Hour)) || len(watchlist.List(now.Add(2*time.Hour))) != 0 {
+		t.Error("Expected the pin to lapse after its end time")
+	}
+}
+
+// TestPinnedWorkloadsInStatus tests that pinned workloads are listed first
+// and that their violations aren't tolerated or debounced
+func TestPinnedWorkloadsInStatus(t *testing.T) {
+	watchlist, _ := newWatchlist(nil)
+	watchlist.Set(WatchedWorkload{Key: "oncology/dosing"})
+	server := &Server{
+		watchlist: watchlist,
+		rollup:    rollupPolicy{tolerated: 1},
+		debounce:  newStatusDebouncer(3, 1),
+		statusCache: map[string]*WorkloadStatus{
+			"icu/a":           verifiedStatus("icu", "a"),
+			"icu/b":           verifiedStatus("icu", "b"),
+			"oncology/dosing": failedStatus("oncology", "dosing"),
+		},
+	}
+	server.debounce.observe(statusScope(""), "compliant")
+
+	w := httptest.NewRecorder()
+	server.handleStatus(w, httptest.NewRequest("GET", "/api/status", nil))
+	var response DashboardResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Workloads[0].Name != "dosing" || !response.Workloads[0].Pinned || response.Workloads[1].Pinned {
+		t.Errorf("Expected the pinned workload first, got %+v", response.Workloads)
+	}
+	if response.OverallStatus != "violation" {
+		t.Errorf("Expected a pinned violation to show at once, got %s", response.OverallStatus)
+	}
+
+	watchlist.Remove("oncology/dosing")
+	w = httptest.NewRecorder()
+	server.handleStatus(w, httptest.NewRequest("GET", "/api/status", nil))
+	json.NewDecoder(w.Body).Decode(&response)
+	if response.OverallStatus != "compliant" {
+		t.Errorf("Expected the violation to be tolerated once unpinned, got %s", response.OverallStatus)
+	}
+}
+
+// TestPinnedWorkloadsEvaluatedEveryCycle tests that pinned workloads are
+// re-evaluated even while their namespace isn't due
+func TestPinnedWorkloadsEvaluatedEveryCycle(t *testing.T) {
+	attested := true
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]CollectorReport{
+			{PodName: "sepsis-model", Namespace: "icu", Attested: attested, Timestamp: time.Now()},
+			{PodName: "monitor", Namespace: "icu", Attested: attested, Timestamp: time.Now()},
+		})
+	}))
+	defer collector.Close()
+
+	schedule, _ := parsePollIntervals("dev=10s", time.Hour)
+	watchlist, _ := newWatchlist(nil)
+	watchlist.Set(WatchedWorkload{Key: "icu/sepsis-model"})
+	server := &Server{collectorURL: collector.URL, httpClient: collector.Client(), schedule: schedule, watchlist: watchlist}
+	server.fetchFromCollector()
+
+	attested = false
+	schedule.mu.Lock()
+	schedule.next["dev"] = time.Now()
+	schedule.mu.Unlock()
+	server.fetchFromCollector()
+
+	if status := server.statusCache["icu/sepsis-model"]; status == nil || status.AttestationStatus != "failed" {
+		t.Errorf("Expected the pinned workload to be re-evaluated, got %+v", status)
+	}
+	if status := server.statusCache["icu/monitor"]; status == nil || status.AttestationStatus != "verified" {
+		t.Errorf("Expected the rest of icu to wait for its interval, got %+v", status)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+const workloadStatsDoc = "workload-stats"
+
+// workloadRecord is the lifetime record of one workload. Unlike history it
+// isn't subject to retention, so a chronic offender stays recognizable.
+type workloadRecord struct {
+	FirstSeen       time.Time  `json:"first_seen"`
+	TotalViolations int        `json:"total_violations"`
+	LastViolationAt *time.Time `json:"last_violation_at,omitempty"`
+	Violating       bool       `json:"violating"` // whether the last known status was a violation
+}
+
+// WorkloadStats tracks the lifetime record of every workload from its
+// history events, persisted to the store
+type WorkloadStats struct {
+	mu      sync.Mutex
+	records map[string]*workloadRecord
+	store   *Store
+}
+
+// newWorkloadStats loads persisted records. On first use they are rebuilt
+// from whatever history is still retained.
+func newWorkloadStats(store *Store, history *History) (*WorkloadStats, error) {
+	ws := &WorkloadStats{
+		records: make(map[string]*workloadRecord),
+		store:   store,
+	}
+	found, err := store.LoadDoc(workloadStatsDoc, &ws.records)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load workload stats: %w", err)
+	}
+	if !found {
+		ws.Record(history.Events(time.Time{}, time.Now()))
+	}
+	return ws, nil
+}
+
+// Record updates the records with history events, in order. Entering
+// violation - as a new workload or from a healthy state - counts once.
+func (ws *WorkloadStats) Record(events []HistoryEvent) {
+	if ws == nil || len(events) == 0 {
+		return
+	}
+
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	changed := false
+	for _, event := range events {
+		record, ok := ws.records[event.Key]
+		if !ok {
+			record = &workloadRecord{FirstSeen: event.Time}
+			ws.records[event.Key] = record
+			changed = true
+		}
+		if event.Type == "removed" || event.Status == nil {
+			if record.Violating {
+				record.Violating = false
+				changed = true
+			}
+			continue
+		}
+
+		violating := isViolation(event.Status)
+		if violating && !record.Violating {
+			at := event.Time
+			record.TotalViolations++
+			record.LastViolationAt = &at
+		}
+		if violating != record.Violating {
+			record.Violating = violating
+			changed = true
+		}
+	}
+
+	if changed {
+		if err := ws.store.SaveDoc(workloadStatsDoc, ws.records); err != nil {
+			log.Printf("Failed to persist workload stats: %v", err)
+		}
+	}
+}
+
+// apply sets the lifetime record fields of a workload status
+func (ws *WorkloadStats) apply(status *WorkloadStatus) {
+	if ws == nil {
+		return
+	}
+
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	record, ok := ws.records[status.Namespace+"/"+status.Name]
+	if !ok {
+		return
+	}
+	firstSeen := record.FirstSeen
+	status.FirstSeen = &firstSeen
+	status.TotalViolations = record.TotalViolations
+	if record.LastViolationAt != nil {
+		at := *record.LastViolationAt
+		status.LastViolationAt = &at
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestWorkloadStats tests counting violation entries and that the record is
+// persisted, rebuilt from history on first use and shown in the detail API
+func TestWorkloadStats(t *testing.T) {
+	t0 := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	store, err := openStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	history, _ := newHistory(nil, 0)
+	history.Record([]HistoryEvent{
+		{Time: t0, Key: "icu/a", Type: "added", Status: failedStatus("icu", "a")},
+		{Time: t0.Add(time.Hour), Key: "icu/a", Type: "changed", Status: verifiedStatus("icu", "a")},
+	})
+
+	stats, err := newWorkloadStats(store, history)
+	if err != nil {
+		t.Fatalf("Failed to create workload stats: %v", err)
+	}
+	stats.Record([]HistoryEvent{
+		{Time: t0.Add(2 * time.Hour), Key: "icu/a", Type: "changed", Status: failedStatus("icu", "a")},
+		// Still in violation - not a new entry
+		{Time: t0.Add(3 * time.Hour), Key: "icu/a", Type: "changed", Status: failedStatus("icu", "a")},
+		{Time: t0.Add(4 * time.Hour), Key: "icu/b", Type: "added", Status: verifiedStatus("icu", "b")},
+	})
+
+	reloaded, err := newWorkloadStats(store, nil)
+	if err != nil {
+		t.Fatalf("Failed to reload workload stats: %v", err)
+	}
+	server := &Server{
+		stats:       reloaded,
+		statusCache: map[string]*WorkloadStatus{"icu/a": failedStatus("icu", "a"), "icu/b": verifiedStatus("icu", "b")},
+	}
+
+	w := httptest.NewRecorder()
+	server.handleWorkloadDetail(w, httptest.NewRequest("GET", "/api/workload/icu/a", nil))
+	var a WorkloadStatus
+	json.NewDecoder(w.Body).Decode(&a)
+	if a.FirstSeen == nil || !a.FirstSeen.Equal(t0) {
+		t.Errorf("Expected first seen %s, got %v", t0, a.FirstSeen)
+	}
+	if a.TotalViolations != 2 {
+		t.Errorf("Expected 2 violations, got %d", a.TotalViolations)
+	}
+	if a.LastViolationAt == nil || !a.LastViolationAt.Equal(t0.Add(2*time.Hour)) {
+		t.Errorf("Expected last violation at %s, got %v", t0.Add(2*time.Hour), a.LastViolationAt)
+	}
+
+	w = httptest.NewRecorder()
+	server.handleWorkloadDetail(w, httptest.NewRequest("GET", "/api/workload/icu/b", nil))
+	var b WorkloadStatus
+	json.NewDecoder(w.Body).Decode(&b)
+	if b.TotalViolations != 0 || b.LastViolationAt != nil || b.FirstSeen == nil {
+		t.Errorf("Expected a clean record for icu/b, got %+v", b)
+	}
+}
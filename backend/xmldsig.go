@@ -0,0 +1,390 @@
+package main
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// XML signature verification for SAML, limited to what identity providers
+// actually send: an enveloped RSA-SHA256 signature over one element,
+// canonicalized with Exclusive XML Canonicalization (without comments).
+// Callers must only trust the canonical bytes returned by
+// verifyEnvelopedSignature - never the document it was found in - so a
+// signature can't be wrapped around content other than what it signed.
+
+// XML namespaces and algorithm identifiers
+const (
+	xmlNamespace       = "http://www.w3.org/XML/1998/namespace"
+	dsigNamespace      = "http://www.w3.org/2000/09/xmldsig#"
+	excC14NAlgorithm   = "http://www.w3.org/2001/10/xml-exc-c14n#"
+	envelopedTransform = "http://www.w3.org/2000/09/xmldsig#enveloped-signature"
+	rsaSHA256Algorithm = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha256"
+	sha256Algorithm    = "http://www.w3.org/2001/04/xmlenc#sha256"
+)
+
+// xmlNode is a parsed element that keeps namespace prefixes and attributes
+// as written, which canonicalization needs
+type xmlNode struct {
+	prefix   string
+	local    string
+	attrs    []xml.Attr // Name.Space is the prefix; declarations are xmlns / xmlns:p
+	children []xmlChild
+	parent   *xmlNode
+}
+
+// xmlChild is either an element or character data
+type xmlChild struct {
+	node *xmlNode
+	text string
+}
+
+// parseXMLTree parses a document into an element tree. DTDs are rejected,
+// so entity expansion can't be used against the parser.
+func parseXMLTree(data []byte) (*xmlNode, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var root, current *xmlNode
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &xmlNode{prefix: t.Name.Space, local: t.Name.Local, attrs: append([]xml.Attr(nil), t.Attr...), parent: current}
+			if current == nil {
+				if root != nil {
+					return nil, errors.New("multiple root elements")
+				}
+				root = node
+			} else {
+				current.children = append(current.children, xmlChild{node: node})
+			}
+			current = node
+		case xml.EndElement:
+			if current == nil || t.Name.Space != current.prefix || t.Name.Local != current.local {
+				return nil, fmt.Errorf("unexpected end element %s", t.Name.Local)
+			}
+			current = current.parent
+		case xml.CharData:
+			if current != nil {
+				current.children = append(current.children, xmlChild{text: string(t)})
+			}
+		case xml.Directive:
+			return nil, errors.New("DTDs are not allowed")
+		case xml.ProcInst:
+			if current != nil {
+				return nil, errors.New("processing instructions are not supported")
+			}
+		}
+	}
+	if root == nil || current != nil {
+		return nil, errors.New("incomplete document")
+	}
+	return root, nil
+}
+
+// namespace resolves a prefix ("" for the default namespace) in the scope of n
+func (n *xmlNode) namespace(prefix string) (string, bool) {
+	if prefix == "xml" {
+		return xmlNamespace, true
+	}
+	for node := n; node != nil; node = node.parent {
+		for _, attr := range node.attrs {
+			if (prefix == "" && attr.Name.Space == "" && attr.Name.Local == "xmlns") ||
+				(prefix != "" && attr.Name.Space == "xmlns" && attr.Name.Local == prefix) {
+				return attr.Value, true
+			}
+		}
+	}
+	return "", false
+}
+
+// is reports whether n is the element {ns}local
+func (n *xmlNode) is(ns, local string) bool {
+	uri, _ := n.namespace(n.prefix)
+	return n.local == local && uri == ns
+}
+
+// attr returns the value of an unqualified attribute
+func (n *xmlNode) attr(name string) string {
+	for _, attr := range n.attrs {
+		if attr.Name.Space == "" && attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// childElements returns the child elements named {ns}local
+func (n *xmlNode) childElements(ns, local string) []*xmlNode {
+	var found []*xmlNode
+	for _, child := range n.children {
+		if child.node != nil && child.node.is(ns, local) {
+			found = append(found, child.node)
+		}
+	}
+	return found
+}
+
+// child returns the single child element named {ns}local
+func (n *xmlNode) child(ns, local string) (*xmlNode, error) {
+	found := n.childElements(ns, local)
+	if len(found) != 1 {
+		return nil, fmt.Errorf("expected one %s in %s, found %d", local, n.local, len(found))
+	}
+	return found[0], nil
+}
+
+// text returns the concatenated character data of n
+func (n *xmlNode) text() string {
+	var sb strings.Builder
+	for _, child := range n.children {
+		sb.WriteString(child.text)
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// countIDs counts the elements of the tree carrying each ID attribute value
+func countIDs(n *xmlNode, counts map[string]int) {
+	if id := n.attr("ID"); id != "" {
+		counts[id]++
+	}
+	for _, child := range n.children {
+		if child.node != nil {
+			countIDs(child.node, counts)
+		}
+	}
+}
+
+// canonicalize serializes n with Exclusive XML Canonicalization, leaving out
+// the excluded element (the enveloped signature). inclusive lists the
+// InclusiveNamespaces prefixes, "#default" for the default namespace.
+func canonicalize(n, exclude *xmlNode, inclusive []string) []byte {
+	var buf bytes.Buffer
+	writeCanonical(&buf, n, exclude, inclusive, map[string]string{})
+	return buf.Bytes()
+}
+
+func writeCanonical(buf *bytes.Buffer, n, exclude *xmlNode, inclusive []string, rendered map[string]string) {
+	// Namespaces visibly utilized by the element and its attributes, plus
+	// the inclusive ones, are declared unless an output ancestor already did
+	used := map[string]bool{n.prefix: true}
+	for _, attr := range n.attrs {
+		if attr.Name.Space != "" && attr.Name.Space != "xmlns" {
+			used[attr.Name.Space] = true
+		}
+	}
+	for _, prefix := range inclusive {
+		if prefix == "#default" {
+			prefix = ""
+		}
+		used[prefix] = true
+	}
+
+	type declaration struct{ prefix, uri string }
+	var declarations []declaration
+	scope := make(map[string]string, len(rendered)+len(used))
+	for prefix, uri := range rendered {
+		scope[prefix] = uri
+	}
+	for prefix := range used {
+		if prefix == "xml" || prefix == "xmlns" {
+			continue
+		}
+		uri, ok := n.namespace(prefix)
+		if prefix != "" && !ok {
+			continue
+		}
+		if prev, ok := rendered[prefix]; (ok && prev == uri) || (!ok && prefix == "" && uri == "") {
+			continue
+		}
+		declarations = append(declarations, declaration{prefix, uri})
+		scope[prefix] = uri
+	}
+	sort.Slice(declarations, func(i, j int) bool { return declarations[i].prefix < declarations[j].prefix })
+
+	type attribute struct{ uri, name, value string }
+	var attributes []attribute
+	for _, attr := range n.attrs {
+		if (attr.Name.Space == "" && attr.Name.Local == "xmlns") || attr.Name.Space == "xmlns" {
+			continue
+		}
+		a := attribute{name: attr.Name.Local, value: attr.Value}
+		if attr.Name.Space != "" {
+			a.uri, _ = n.namespace(attr.Name.Space)
+			a.name = attr.Name.Space + ":" + attr.Name.Local
+		}
+		attributes = append(attributes, a)
+	}
+	sort.Slice(attributes, func(i, j int) bool {
+		if attributes[i].uri != attributes[j].uri {
+			return attributes[i].uri < attributes[j].uri
+		}
+		return localName(attributes[i].name) < localName(attributes[j].name)
+	})
+
+	name := n.local
+	if n.prefix != "" {
+		name = n.prefix + ":" + n.local
+	}
+	buf.WriteString("<" + name)
+	for _, d := range declarations {
+		if d.prefix == "" {
+			buf.WriteString(` xmlns="` + escapeCanonicalAttr(d.uri) + `"`)
+		} else {
+			buf.WriteString(" xmlns:" + d.prefix + `="` + escapeCanonicalAttr(d.uri) + `"`)
+		}
+	}
+	for _, a := range attributes {
+		buf.WriteString(" " + a.name + `="` + escapeCanonicalAttr(a.value) + `"`)
+	}
+	buf.WriteString(">")
+
+	for _, child := range n.children {
+		switch {
+		case child.node == nil:
+			buf.WriteString(escapeCanonicalText(child.text))
+		case child.node != exclude:
+			writeCanonical(buf, child.node, exclude, inclusive, scope)
+		}
+	}
+	buf.WriteString("</" + name + ">")
+}
+
+func localName(name string) string {
+	if i := strings.IndexByte(name, ':'); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+var (
+	canonicalTextEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\r", "&#xD;")
+	canonicalAttrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", `"`, "&quot;", "\t", "&#x9;", "\n", "&#xA;", "\r", "&#xD;")
+)
+
+func escapeCanonicalText(s string) string { return canonicalTextEscaper.Replace(s) }
+func escapeCanonicalAttr(s string) string { return canonicalAttrEscaper.Replace(s) }
+
+// inclusivePrefixes returns the PrefixList of an InclusiveNamespaces element
+// inside a transform or canonicalization method, if any
+func inclusivePrefixes(method *xmlNode) []string {
+	for _, child := range method.children {
+		if child.node != nil && child.node.is(excC14NAlgorithm, "InclusiveNamespaces") {
+			return strings.Fields(child.node.attr("PrefixList"))
+		}
+	}
+	return nil
+}
+
+// verifyEnvelopedSignature verifies the signature enveloped in el against the
+// certificate and returns the canonical bytes of el without the signature -
+// the only content the signature vouches for
+func verifyEnvelopedSignature(el *xmlNode, cert *x509.Certificate) ([]byte, error) {
+	publicKey, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("only RSA signing certificates are supported")
+	}
+
+	signature, err := el.child(dsigNamespace, "Signature")
+	if err != nil {
+		return nil, err
+	}
+	signedInfo, err := signature.child(dsigNamespace, "SignedInfo")
+	if err != nil {
+		return nil, err
+	}
+
+	c14nMethod, err := signedInfo.child(dsigNamespace, "CanonicalizationMethod")
+	if err != nil {
+		return nil, err
+	}
+	if algorithm := c14nMethod.attr("Algorithm"); algorithm != excC14NAlgorithm {
+		return nil, fmt.Errorf("unsupported canonicalization %q", algorithm)
+	}
+	signatureMethod, err := signedInfo.child(dsigNamespace, "SignatureMethod")
+	if err != nil {
+		return nil, err
+	}
+	if algorithm := signatureMethod.attr("Algorithm"); algorithm != rsaSHA256Algorithm {
+		return nil, fmt.Errorf("unsupported signature algorithm %q", algorithm)
+	}
+
+	reference, err := signedInfo.child(dsigNamespace, "Reference")
+	if err != nil {
+		return nil, err
+	}
+	if id := el.attr("ID"); id == "" || reference.attr("URI") != "#"+id {
+		return nil, fmt.Errorf("signature references %q, not the signed element", reference.attr("URI"))
+	}
+
+	transforms, err := reference.child(dsigNamespace, "Transforms")
+	if err != nil {
+		return nil, err
+	}
+	var enveloped bool
+	var prefixes []string
+	for _, transform := range transforms.childElements(dsigNamespace, "Transform") {
+		switch algorithm := transform.attr("Algorithm"); algorithm {
+		case envelopedTransform:
+			enveloped = true
+		case excC14NAlgorithm:
+			prefixes = inclusivePrefixes(transform)
+		default:
+			return nil, fmt.Errorf("unsupported transform %q", algorithm)
+		}
+	}
+	if !enveloped {
+		return nil, errors.New("signature is not enveloped")
+	}
+
+	digestMethod, err := reference.child(dsigNamespace, "DigestMethod")
+	if err != nil {
+		return nil, err
+	}
+	if algorithm := digestMethod.attr("Algorithm"); algorithm != sha256Algorithm {
+		return nil, fmt.Errorf("unsupported digest algorithm %q", algorithm)
+	}
+	digestValue, err := reference.child(dsigNamespace, "DigestValue")
+	if err != nil {
+		return nil, err
+	}
+	expectedDigest, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(digestValue.text()), ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid digest value: %w", err)
+	}
+
+	signed := canonicalize(el, signature, prefixes)
+	digest := sha256.Sum256(signed)
+	if !bytes.Equal(digest[:], expectedDigest) {
+		return nil, errors.New("digest mismatch - the signed content was modified")
+	}
+
+	signatureValue, err := signature.child(dsigNamespace, "SignatureValue")
+	if err != nil {
+		return nil, err
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(signatureValue.text()), ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature value: %w", err)
+	}
+	signedInfoDigest := sha256.Sum256(canonicalize(signedInfo, nil, inclusivePrefixes(c14nMethod)))
+	if err := rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, signedInfoDigest[:], sig); err != nil {
+		return nil, errors.New("invalid signature")
+	}
+	return signed, nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestCanonicalize tests Exclusive XML Canonicalization of whole documents
+// and of subtrees that inherit namespace declarations
+func TestCanonicalize(t *testing.T) {
+	doc := `<?xml version="1.0"?>
+<a:Root xmlns:a="urn:a" xmlns:b="urn:b" xmlns:unused="urn:u" z="1" b:y="2" a:x='q"t'>
+  <!-- dropped -->
+  <a:Child   b:k="v" id="3">t&amp;&lt;&gt;<![CDATA[c<d]]></a:Child>
+  <x xmlns="urn:d"><y/></x>
+</a:Root>`
+	root, err := parseXMLTree([]byte(doc))
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+
+	// The comment goes, the whitespace either side of it stays
+	want := "<a:Root xmlns:a=\"urn:a\" xmlns:b=\"urn:b\" z=\"1\" a:x=\"q&quot;t\" b:y=\"2\">\n  \n" + `  <a:Child id="3" b:k="v">t&amp;&lt;&gt;c&lt;d</a:Child>
+  <x xmlns="urn:d"><y></y></x>
+</a:Root>`
+	if got := string(canonicalize(root, nil, nil)); got != want {
+		t.Errorf("Expected\n%s\ngot\n%s", want, got)
+	}
+
+	child := root.childElements("urn:a", "Child")[0]
+	want = `<a:Child xmlns:a="urn:a" xmlns:b="urn:b" id="3" b:k="v">t&amp;&lt;&gt;c&lt;d</a:Child>`
+	if got := string(canonicalize(child, nil, nil)); got != want {
+		t.Errorf("Expected\n%s\ngot\n%s", want, got)
+	}
+
+	want = `<a:Child xmlns:a="urn:a" xmlns:b="urn:b" xmlns:unused="urn:u" id="3" b:k="v">t&amp;&lt;&gt;c&lt;d</a:Child>`
+	if got := string(canonicalize(child, nil, []string{"unused"})); got != want {
+		t.Errorf("Expected inclusive prefix to be declared\n%s\ngot\n%s", want, got)
+	}
+}
+
+// TestCanonicalizeExclude tests that the excluded element is left out but
+// the whitespace around it is kept
+func TestCanonicalizeExclude(t *testing.T) {
+	root, err := parseXMLTree([]byte(`<r ID="1"> <s/> <t/></r>`))
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	got := string(canonicalize(root, root.children[1].node, nil))
+	if want := `<r ID="1">  <t></t></r>`; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+// TestParseXMLTreeRejectsDTD tests that documents with a DTD are refused
+func TestParseXMLTreeRejectsDTD(t *testing.T) {
+	_, err := parseXMLTree([]byte(`<!DOCTYPE r [<!ENTITY e "x">]><r>&e;</r>`))
+	if err == nil || !strings.Contains(err.Error(), "DTD") {
+		t.Errorf("Expected DTD to be rejected, got %v", err)
+	}
+}